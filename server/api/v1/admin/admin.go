@@ -399,6 +399,104 @@ func ResetInstancePassword(c *gin.Context) {
 	common.ResponseSuccess(c, response, "密码重置任务创建成功")
 }
 
+// ResizeInstance 管理员调整实例配置
+// @Summary 管理员调整实例配置
+// @Description 调整实例的CPU、内存、磁盘和带宽，磁盘只支持扩容，调整后的配置受实例所属用户的等级和节点配额限制
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body admin.ResizeInstanceRequest true "调整后的实例配置"
+// @Success 200 {object} common.Response{data=object} "任务创建成功，返回任务ID"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "实例不存在"
+// @Failure 500 {object} common.Response "创建任务失败"
+// @Router /admin/instances/{id}/resize [put]
+func ResizeInstance(c *gin.Context) {
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的实例ID"))
+		return
+	}
+
+	var req admin.ResizeInstanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	instanceService := instance.NewService(task.GetTaskService())
+	taskID, err := instanceService.ResizeInstance(uint(instanceID), req)
+	if err != nil {
+		global.APP_LOG.Error("管理员创建调整实例配置任务失败",
+			zap.Uint64("instanceID", instanceID),
+			zap.Error(err))
+		if err.Error() == "实例不存在" {
+			common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+			return
+		}
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+
+	global.APP_LOG.Info("管理员创建调整实例配置任务成功",
+		zap.Uint64("instanceID", instanceID),
+		zap.Uint("taskID", taskID))
+
+	common.ResponseSuccess(c, gin.H{"taskId": taskID}, "调整配置任务创建成功")
+}
+
+// MigrateInstance 管理员迁移实例到其他节点
+// @Summary 管理员迁移实例到其他节点
+// @Description 将实例停止后导出并导入到同类型的目标节点，重建端口映射和流量监控，完成后删除源节点上的实例
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body admin.MigrateInstanceRequest true "目标节点"
+// @Success 200 {object} common.Response{data=object} "任务创建成功，返回任务ID"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "实例不存在"
+// @Failure 500 {object} common.Response "创建任务失败"
+// @Router /admin/instances/{id}/migrate [post]
+func MigrateInstance(c *gin.Context) {
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的实例ID"))
+		return
+	}
+
+	var req admin.MigrateInstanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	instanceService := instance.NewService(task.GetTaskService())
+	taskID, err := instanceService.MigrateInstance(uint(instanceID), req)
+	if err != nil {
+		global.APP_LOG.Error("管理员创建迁移实例任务失败",
+			zap.Uint64("instanceID", instanceID),
+			zap.Uint("targetProviderID", req.TargetProviderID),
+			zap.Error(err))
+		if err.Error() == "实例不存在" {
+			common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+			return
+		}
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+
+	global.APP_LOG.Info("管理员创建迁移实例任务成功",
+		zap.Uint64("instanceID", instanceID),
+		zap.Uint("targetProviderID", req.TargetProviderID),
+		zap.Uint("taskID", taskID))
+
+	common.ResponseSuccess(c, gin.H{"taskId": taskID}, "迁移任务创建成功")
+}
+
 // GetInstanceNewPassword 管理员获取实例重置后的新密码
 // @Summary 管理员获取实例重置后的新密码
 // @Description 通过任务ID获取实例重置后的新密码
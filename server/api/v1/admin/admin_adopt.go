@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"strconv"
+
+	"oneclickvirt/model/admin"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/admin/instance"
+	"oneclickvirt/service/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetUnmanagedInstances 获取节点上未管理的实例
+// @Summary 获取节点上未管理的实例
+// @Description 列出节点上存在但面板中没有记录的实例，并读取实例的实际CPU、内存、磁盘、IP以及proxy设备或iptables DNAT端口转发
+// @Tags 实例管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Success 200 {object} common.Response{data=[]admin.UnmanagedInstance} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "获取失败"
+// @Router /admin/providers/{id}/unmanaged-instances [get]
+func GetUnmanagedInstances(c *gin.Context) {
+	providerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的提供商ID"))
+		return
+	}
+
+	instanceService := instance.NewService(task.GetTaskService())
+	instances, err := instanceService.ListUnmanagedInstances(c.Request.Context(), uint(providerID))
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, instances, "获取成功")
+}
+
+// AdoptInstances 导入节点上未管理的实例
+// @Summary 导入节点上未管理的实例
+// @Description 将节点上的实例分配给用户，登记发现的端口映射，计入节点资源和用户配额并启动流量监控；资源为0时使用从节点读取的值
+// @Tags 实例管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body admin.AdoptInstancesRequest true "导入参数"
+// @Success 200 {object} common.Response{data=[]admin.AdoptInstanceResult} "导入完成"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "导入失败"
+// @Router /admin/instances/adopt [post]
+func AdoptInstances(c *gin.Context) {
+	var req admin.AdoptInstancesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	instanceService := instance.NewService(task.GetTaskService())
+	results, err := instanceService.AdoptInstances(c.Request.Context(), req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, results, "导入完成")
+}
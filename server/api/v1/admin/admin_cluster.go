@@ -0,0 +1,37 @@
+package admin
+
+import (
+	"strconv"
+
+	"oneclickvirt/model/common"
+	adminProvider "oneclickvirt/service/admin/provider"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetClusterNodes 获取集群节点列表
+// @Summary 获取集群节点列表
+// @Description 返回Proxmox集群中各节点的CPU、内存、磁盘使用情况、实例数量和在线状态，单节点部署时只有一个节点
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Success 200 {object} common.Response{data=[]provider.ClusterNode} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "获取失败"
+// @Router /admin/providers/{id}/cluster-nodes [get]
+func GetClusterNodes(c *gin.Context) {
+	providerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的提供商ID"))
+		return
+	}
+
+	nodes, err := adminProvider.NewService().GetClusterNodes(c.Request.Context(), uint(providerID))
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, nodes, "获取成功")
+}
@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"errors"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/console"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// AdminInstanceConsoleWebSocket 管理员WebSocket实例控制台
+// @Summary 管理员WebSocket实例控制台
+// @Description 管理员通过WebSocket连接任意实例所在节点的虚拟化控制台（串口或VNC）
+// @Tags 管理员/实例
+// @Accept json
+// @Produce json
+// @Param id path uint true "实例ID"
+// @Param type query string false "控制台类型: serial(默认) 或 vnc"
+// @Success 101 {string} string "Switching Protocols"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Failure 401 {object} common.Response "未授权"
+// @Failure 404 {object} common.Response "实例不存在"
+// @Failure 500 {object} common.Response "服务器错误"
+// @Router /v1/admin/instances/{id}/console [get]
+func AdminInstanceConsoleWebSocket(c *gin.Context) {
+	adminID, err := getUserIDFromContext(c)
+	if err != nil {
+		c.JSON(401, gin.H{"code": 401, "message": "未授权"})
+		return
+	}
+
+	instanceID := c.Param("id")
+	if instanceID == "" {
+		c.JSON(400, gin.H{"code": 400, "message": "实例ID不能为空"})
+		return
+	}
+
+	consoleType, err := console.NormalizeConsoleType(c.Query("type"))
+	if err != nil {
+		c.JSON(400, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	// 管理员可以访问任意实例
+	var instance providerModel.Instance
+	err = global.APP_DB.Select("id", "name", "provider_id", "status").
+		Where("id = ?", instanceID).
+		First(&instance).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"code": 404, "message": "实例不存在"})
+			return
+		}
+		global.APP_LOG.Error("查询实例失败", zap.Error(err))
+		c.JSON(500, gin.H{"code": 500, "message": "查询实例失败"})
+		return
+	}
+
+	if instance.Status != "running" {
+		c.JSON(400, gin.H{"code": 400, "message": "实例未运行，无法连接控制台"})
+		return
+	}
+
+	ws, err := adminUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		global.APP_LOG.Error("WebSocket升级失败", zap.Error(err))
+		return
+	}
+	defer ws.Close()
+
+	console.Serve(ws, &instance, console.SessionInfo{
+		UserID:      adminID,
+		Username:    c.GetString("username"),
+		IsAdmin:     true,
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		ClientIP:    c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		ConsoleType: consoleType,
+	})
+}
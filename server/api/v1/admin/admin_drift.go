@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"strconv"
+
+	"oneclickvirt/model/admin"
+	"oneclickvirt/model/common"
+	adminProvider "oneclickvirt/service/admin/provider"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDriftReport 获取实例状态漂移报告
+// @Summary 获取实例状态漂移报告
+// @Description 返回节点实例与数据库记录之间的漂移，包括节点上已不存在的实例、未管理的实例、状态和资源不一致以及失效的端口映射
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码"
+// @Param pageSize query int false "每页数量"
+// @Param providerId query int false "Provider ID"
+// @Param kind query string false "漂移类型(ghost/orphan/status/resource/port)"
+// @Param includeFixed query bool false "是否包含已修复的记录"
+// @Success 200 {object} common.Response{data=[]provider.InstanceDrift} "获取成功"
+// @Failure 500 {object} common.Response "获取失败"
+// @Router /admin/drifts [get]
+func GetDriftReport(c *gin.Context) {
+	var req admin.DriftListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+
+	drifts, total, err := adminProvider.NewService().GetDriftReport(req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccessWithPagination(c, drifts, total, req.Page, req.PageSize)
+}
+
+// ReconcileProvider 立即检查节点的实例状态漂移
+// @Summary 立即检查节点的实例状态漂移
+// @Description 对比节点上的实例与数据库记录并更新漂移报告，节点开启自动修复时会修复连续出现的漂移
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Success 200 {object} common.Response{data=admin.DriftReconcileResult} "检查完成"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "检查失败"
+// @Router /admin/providers/{id}/reconcile [post]
+func ReconcileProvider(c *gin.Context) {
+	providerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的提供商ID"))
+		return
+	}
+
+	result, err := adminProvider.NewService().ReconcileProvider(c.Request.Context(), uint(providerID))
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, result, "检查完成")
+}
+
+// FixDrift 手动修复漂移
+// @Summary 手动修复漂移
+// @Description 修复单条漂移记录：清理节点上已不存在的实例记录、按节点状态更新实例状态或删除失效的端口映射
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "漂移记录ID"
+// @Success 200 {object} common.Response "修复成功"
+// @Failure 400 {object} common.Response "修复失败"
+// @Router /admin/drifts/{id}/fix [post]
+func FixDrift(c *gin.Context) {
+	driftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的漂移记录ID"))
+		return
+	}
+
+	if err := adminProvider.NewService().FixDrift(uint(driftID)); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, nil, "修复成功")
+}
@@ -0,0 +1,123 @@
+package admin
+
+import (
+	"strconv"
+
+	"oneclickvirt/model/admin"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/resources"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetFlavors 获取实例规格列表
+// @Summary 获取实例规格列表
+// @Description 管理员获取所有实例规格，可按名称、实例类型和启用状态筛选
+// @Tags 实例规格管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name query string false "规格名称"
+// @Param instanceType query string false "实例类型"
+// @Param enabled query string false "是否启用(true/false)"
+// @Success 200 {object} common.Response{data=[]resource.Flavor} "获取成功"
+// @Failure 500 {object} common.Response "获取失败"
+// @Router /admin/flavors [get]
+func GetFlavors(c *gin.Context) {
+	var req admin.FlavorListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	flavors, err := resources.NewFlavorService().ListFlavors(req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, flavors, "获取成功")
+}
+
+// CreateFlavor 创建实例规格
+// @Summary 创建实例规格
+// @Description CPU、内存、磁盘和带宽需为系统预定义规格中的取值，镜像和节点列表为空表示不限制
+// @Tags 实例规格管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body admin.FlavorRequest true "实例规格"
+// @Success 200 {object} common.Response{data=resource.Flavor} "创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/flavors [post]
+func CreateFlavor(c *gin.Context) {
+	var req admin.FlavorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	flavor, err := resources.NewFlavorService().CreateFlavor(req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, flavor, "创建成功")
+}
+
+// UpdateFlavor 更新实例规格
+// @Summary 更新实例规格
+// @Description 更新实例规格配置，已创建的实例不受影响
+// @Tags 实例规格管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "规格ID"
+// @Param request body admin.FlavorRequest true "实例规格"
+// @Success 200 {object} common.Response{data=resource.Flavor} "更新成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/flavors/{id} [put]
+func UpdateFlavor(c *gin.Context) {
+	flavorID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的规格ID"))
+		return
+	}
+
+	var req admin.FlavorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	flavor, err := resources.NewFlavorService().UpdateFlavor(uint(flavorID), req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, flavor, "更新成功")
+}
+
+// DeleteFlavor 删除实例规格
+// @Summary 删除实例规格
+// @Description 删除实例规格，仍被产品引用时不允许删除
+// @Tags 实例规格管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "规格ID"
+// @Success 200 {object} common.Response "删除成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/flavors/{id} [delete]
+func DeleteFlavor(c *gin.Context) {
+	flavorID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的规格ID"))
+		return
+	}
+
+	if err := resources.NewFlavorService().DeleteFlavor(uint(flavorID)); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, nil, "删除成功")
+}
@@ -0,0 +1,138 @@
+package admin
+
+import (
+	"strconv"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/admin"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/admin/instance"
+	adminProvider "oneclickvirt/service/admin/provider"
+	"oneclickvirt/service/task"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// EnterProviderMaintenance 节点进入维护模式
+// @Summary 节点进入维护模式
+// @Description 禁止在节点上申领新实例并邮件通知节点上的用户，evacuate为true时同时为所有实例排队迁移到同类型的其他节点
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body admin.EnterMaintenanceRequest true "维护参数"
+// @Success 200 {object} common.Response{data=admin.EvacuationResult} "进入维护模式成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "操作失败"
+// @Router /admin/providers/maintenance/enter [post]
+func EnterProviderMaintenance(c *gin.Context) {
+	var req admin.EnterMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	providerService := adminProvider.NewService()
+	if err := providerService.EnterMaintenance(req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+
+	if !req.Evacuate {
+		common.ResponseSuccess(c, nil, "节点已进入维护模式")
+		return
+	}
+
+	instanceService := instance.NewService(task.GetTaskService())
+	result, err := instanceService.EvacuateProvider(req.ID)
+	if err != nil {
+		global.APP_LOG.Error("节点批量迁移实例失败", zap.Uint("providerID", req.ID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "节点已进入维护模式，但创建迁移任务失败: "+err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, result, "节点已进入维护模式，迁移任务已创建")
+}
+
+// ExitProviderMaintenance 节点退出维护模式
+// @Summary 节点退出维护模式
+// @Description 退出维护模式并恢复进入维护前的申领状态
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body admin.ExitMaintenanceRequest true "节点ID"
+// @Success 200 {object} common.Response "退出维护模式成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "操作失败"
+// @Router /admin/providers/maintenance/exit [post]
+func ExitProviderMaintenance(c *gin.Context) {
+	var req admin.ExitMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	providerService := adminProvider.NewService()
+	if err := providerService.ExitMaintenance(req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, nil, "节点已退出维护模式")
+}
+
+// GetProviderMaintenanceStatus 获取节点维护进度
+// @Summary 获取节点维护进度
+// @Description 返回节点上剩余的实例数以及本次维护期间创建的迁移任务进度
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Success 200 {object} common.Response{data=admin.MaintenanceStatusResponse} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "获取失败"
+// @Router /admin/providers/{id}/maintenance [get]
+func GetProviderMaintenanceStatus(c *gin.Context) {
+	providerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的提供商ID"))
+		return
+	}
+
+	providerService := adminProvider.NewService()
+	status, err := providerService.GetMaintenanceStatus(uint(providerID))
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, status, "获取成功")
+}
+
+// EvacuateProvider 迁移维护中节点上的所有实例
+// @Summary 迁移维护中节点上的所有实例
+// @Description 为维护中节点上尚未迁移的实例排队迁移任务，可在部分实例因资源不足被跳过后重复调用
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Success 200 {object} common.Response{data=admin.EvacuationResult} "迁移任务已创建"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "创建任务失败"
+// @Router /admin/providers/{id}/evacuate [post]
+func EvacuateProvider(c *gin.Context) {
+	providerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的提供商ID"))
+		return
+	}
+
+	instanceService := instance.NewService(task.GetTaskService())
+	result, err := instanceService.EvacuateProvider(uint(providerID))
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, result, "迁移任务已创建")
+}
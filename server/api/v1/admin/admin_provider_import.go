@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"io"
+	"strconv"
+
+	"oneclickvirt/model/common"
+	adminProvider "oneclickvirt/service/admin/provider"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxManifestSize 节点清单文件大小上限
+const maxManifestSize = 2 << 20
+
+// ImportProviders 按清单批量导入节点
+// @Summary 按清单批量导入节点
+// @Description 上传YAML或CSV节点清单（字段名与创建Provider接口一致），逐行测试SSH连接、创建节点并对LXD/Incus/Proxmox执行自动配置；已存在的同名同地址节点不会重复创建，可重复执行
+// @Tags 提供商管理
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file false "节点清单文件(.yaml/.yml/.csv)，不上传时读取请求体"
+// @Param format query string false "请求体的清单格式(yaml/csv)，默认yaml"
+// @Param concurrency query int false "同时处理的节点数，默认4，最大10"
+// @Param skipSshTest query bool false "跳过SSH连接测试"
+// @Param autoConfigure query bool false "是否执行自动配置，默认true"
+// @Success 200 {object} common.Response{data=[]admin.ProviderImportResult} "导入完成"
+// @Failure 400 {object} common.Response "清单格式错误"
+// @Router /admin/providers/import [post]
+func ImportProviders(c *gin.Context) {
+	var data []byte
+	format := c.Query("format")
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		if fileHeader.Size > maxManifestSize {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, "清单文件不能超过2MB"))
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, "读取清单文件失败: "+err.Error()))
+			return
+		}
+		defer file.Close()
+		if data, err = io.ReadAll(file); err != nil {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, "读取清单文件失败: "+err.Error()))
+			return
+		}
+		if format == "" {
+			format = adminProvider.ManifestFormatFromFilename(fileHeader.Filename)
+		}
+	} else {
+		if data, err = io.ReadAll(io.LimitReader(c.Request.Body, maxManifestSize)); err != nil {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, "读取清单失败: "+err.Error()))
+			return
+		}
+	}
+
+	requests, err := adminProvider.ParseProviderManifest(data, format)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+
+	opts := adminProvider.ProviderImportOptions{AutoConfigure: true}
+	opts.Concurrency, _ = strconv.Atoi(c.Query("concurrency"))
+	opts.SkipSSHTest, _ = strconv.ParseBool(c.Query("skipSshTest"))
+	if v, err := strconv.ParseBool(c.DefaultQuery("autoConfigure", "true")); err == nil {
+		opts.AutoConfigure = v
+	}
+
+	results := adminProvider.NewService().ImportProviders(c.Request.Context(), requests, opts)
+	common.ResponseSuccess(c, results, "导入完成")
+}
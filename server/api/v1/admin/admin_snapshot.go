@@ -0,0 +1,174 @@
+package admin
+
+import (
+	"strconv"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/admin"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/admin/instance"
+	"oneclickvirt/service/task"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// parseSnapshotParams 解析路径中的实例ID和快照ID
+func parseSnapshotParams(c *gin.Context, withSnapshot bool) (uint, uint, bool) {
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的实例ID"))
+		return 0, 0, false
+	}
+	if !withSnapshot {
+		return uint(instanceID), 0, true
+	}
+	snapshotID, err := strconv.ParseUint(c.Param("snapshotId"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的快照ID"))
+		return 0, 0, false
+	}
+	return uint(instanceID), uint(snapshotID), true
+}
+
+// respondSnapshotError 统一处理快照操作错误
+func respondSnapshotError(c *gin.Context, err error) {
+	if err.Error() == "实例不存在" || err.Error() == "快照不存在" {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		return
+	}
+	common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+}
+
+// GetInstanceSnapshots 管理员获取实例快照列表
+// @Summary 管理员获取实例快照列表
+// @Description 获取指定实例的所有快照
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Success 200 {object} common.Response{data=[]provider.InstanceSnapshot} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "实例不存在"
+// @Router /admin/instances/{id}/snapshots [get]
+func GetInstanceSnapshots(c *gin.Context) {
+	instanceID, _, ok := parseSnapshotParams(c, false)
+	if !ok {
+		return
+	}
+
+	instanceService := instance.NewService(task.GetTaskService())
+	snapshots, err := instanceService.GetInstanceSnapshots(instanceID)
+	if err != nil {
+		respondSnapshotError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, snapshots)
+}
+
+// CreateInstanceSnapshot 管理员创建实例快照
+// @Summary 管理员创建实例快照
+// @Description 为指定实例创建快照，快照数量受实例所属用户的等级限制
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body admin.CreateSnapshotRequest true "创建快照请求参数"
+// @Success 200 {object} common.Response{data=user.SnapshotTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "实例不存在"
+// @Failure 500 {object} common.Response "创建任务失败"
+// @Router /admin/instances/{id}/snapshots [post]
+func CreateInstanceSnapshot(c *gin.Context) {
+	instanceID, _, ok := parseSnapshotParams(c, false)
+	if !ok {
+		return
+	}
+
+	var req admin.CreateSnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	instanceService := instance.NewService(task.GetTaskService())
+	result, err := instanceService.CreateInstanceSnapshot(instanceID, req)
+	if err != nil {
+		global.APP_LOG.Error("管理员创建实例快照失败",
+			zap.Uint("instanceID", instanceID),
+			zap.Error(err))
+		respondSnapshotError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "快照创建任务已提交")
+}
+
+// RestoreInstanceSnapshot 管理员恢复实例快照
+// @Summary 管理员恢复实例快照
+// @Description 将指定实例恢复到指定快照，快照之后的数据将丢失
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param snapshotId path int true "快照ID"
+// @Success 200 {object} common.Response{data=user.SnapshotTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "实例或快照不存在"
+// @Router /admin/instances/{id}/snapshots/{snapshotId}/restore [post]
+func RestoreInstanceSnapshot(c *gin.Context) {
+	instanceID, snapshotID, ok := parseSnapshotParams(c, true)
+	if !ok {
+		return
+	}
+
+	instanceService := instance.NewService(task.GetTaskService())
+	result, err := instanceService.RestoreInstanceSnapshot(instanceID, snapshotID)
+	if err != nil {
+		global.APP_LOG.Error("管理员恢复实例快照失败",
+			zap.Uint("instanceID", instanceID),
+			zap.Uint("snapshotID", snapshotID),
+			zap.Error(err))
+		respondSnapshotError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "快照恢复任务已提交")
+}
+
+// DeleteInstanceSnapshot 管理员删除实例快照
+// @Summary 管理员删除实例快照
+// @Description 删除指定实例的快照
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param snapshotId path int true "快照ID"
+// @Success 200 {object} common.Response{data=user.SnapshotTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "实例或快照不存在"
+// @Router /admin/instances/{id}/snapshots/{snapshotId} [delete]
+func DeleteInstanceSnapshot(c *gin.Context) {
+	instanceID, snapshotID, ok := parseSnapshotParams(c, true)
+	if !ok {
+		return
+	}
+
+	instanceService := instance.NewService(task.GetTaskService())
+	result, err := instanceService.DeleteInstanceSnapshot(instanceID, snapshotID)
+	if err != nil {
+		global.APP_LOG.Error("管理员删除实例快照失败",
+			zap.Uint("instanceID", instanceID),
+			zap.Uint("snapshotID", snapshotID),
+			zap.Error(err))
+		respondSnapshotError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "快照删除任务已提交")
+}
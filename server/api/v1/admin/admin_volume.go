@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"oneclickvirt/model/admin"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/admin/instance"
+	"oneclickvirt/service/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetVolumeList 管理员获取数据卷列表
+// @Summary 管理员获取数据卷列表
+// @Description 分页获取所有用户的数据卷，可按节点、用户、实例和状态筛选
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码"
+// @Param pageSize query int false "每页数量"
+// @Param providerId query int false "Provider ID"
+// @Param userId query int false "用户ID"
+// @Param instanceId query int false "实例ID"
+// @Param status query string false "状态"
+// @Success 200 {object} common.Response{data=[]provider.Volume} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "获取失败"
+// @Router /admin/volumes [get]
+func GetVolumeList(c *gin.Context) {
+	var req admin.VolumeListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+
+	volumes, total, err := instance.NewService(task.GetTaskService()).GetVolumeList(req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccessWithPagination(c, volumes, total, req.Page, req.PageSize)
+}
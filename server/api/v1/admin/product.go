@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"oneclickvirt/global"
 	productModel "oneclickvirt/model/product"
+	resourceModel "oneclickvirt/model/resource"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -73,6 +74,9 @@ func GetProducts(c *gin.Context) {
 	// 从用户等级限制中自动填充产品资源配置，确保产品配置与最新的等级配额一致
 	for i := range products {
 		fillProductResourcesFromLevelLimit(&products[i])
+		if err := fillProductResourcesFromFlavor(&products[i]); err != nil {
+			global.APP_LOG.Warn("产品关联的实例规格无效", zap.Uint("productID", products[i].ID), zap.Error(err))
+		}
 	}
 
 	global.APP_LOG.Info("获取产品列表成功", zap.Int("count", len(products)))
@@ -107,6 +111,10 @@ func CreateProduct(c *gin.Context) {
 
 	// 从用户等级限制中自动填充产品资源配置
 	fillProductResourcesFromLevelLimit(&product)
+	if err := fillProductResourcesFromFlavor(&product); err != nil {
+		c.JSON(400, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
 
 	if err := global.APP_DB.Create(&product).Error; err != nil {
 		global.APP_LOG.Error("创建产品失败", zap.Error(err))
@@ -146,6 +154,10 @@ func UpdateProduct(c *gin.Context) {
 
 	// 从用户等级限制中自动填充产品资源配置
 	fillProductResourcesFromLevelLimit(&product)
+	if err := fillProductResourcesFromFlavor(&product); err != nil {
+		c.JSON(400, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
 
 	// 创建一个map来存储需要更新的字段，确保is_enabled字段被正确处理
 	updateMap := map[string]interface{}{
@@ -166,6 +178,7 @@ func UpdateProduct(c *gin.Context) {
 		"allow_repeat":  product.AllowRepeat, // 添加是否允许重复购买字段
 		"stock":         product.Stock,
 		"sold_count":    product.SoldCount,
+		"flavor_id":     product.FlavorID,
 	}
 
 	// 更新产品
@@ -330,6 +343,25 @@ func UpdateProductStock(c *gin.Context) {
 	})
 }
 
+// fillProductResourcesFromFlavor 产品关联实例规格时，使用规格的资源配置覆盖等级限制填充的配置
+func fillProductResourcesFromFlavor(product *productModel.Product) error {
+	if product.FlavorID == 0 {
+		return nil
+	}
+	var flavor resourceModel.Flavor
+	if err := global.APP_DB.First(&flavor, product.FlavorID).Error; err != nil {
+		return fmt.Errorf("关联的实例规格不存在")
+	}
+	product.CPU = flavor.CPU
+	product.Memory = int(flavor.Memory)
+	product.Disk = int(flavor.Disk)
+	product.Bandwidth = flavor.Bandwidth
+	if flavor.Traffic > 0 {
+		product.Traffic = flavor.Traffic
+	}
+	return nil
+}
+
 // fillProductResourcesFromLevelLimit 从用户等级限制中自动填充产品资源配置
 func fillProductResourcesFromLevelLimit(product *productModel.Product) {
 	// 获取全局配置中的等级限制
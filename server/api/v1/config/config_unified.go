@@ -222,9 +222,13 @@ func getUserConfig(cm *config.ConfigManager, authCtx *authModel.AuthContext) map
 	for level, limitInfo := range global.APP_CONFIG.Quota.LevelLimits {
 		levelKey := fmt.Sprintf("%d", level)
 		levelLimits[levelKey] = map[string]interface{}{
-			"max-instances": limitInfo.MaxInstances,
-			"max-resources": limitInfo.MaxResources,
-			"max-traffic":   limitInfo.MaxTraffic,
+			"max-instances":      limitInfo.MaxInstances,
+			"max-resources":      limitInfo.MaxResources,
+			"max-traffic":        limitInfo.MaxTraffic,
+			"max-snapshots":      limitInfo.MaxSnapshots,
+			"allow-user-data":    limitInfo.AllowUserData,
+			"max-user-data-size": limitInfo.MaxUserDataSize,
+			"max-firewall-rules": limitInfo.MaxFirewallRules,
 		}
 	}
 
@@ -278,9 +282,13 @@ func getAdminConfig(cm *config.ConfigManager) map[string]interface{} {
 	for level, limitInfo := range global.APP_CONFIG.Quota.LevelLimits {
 		levelKey := fmt.Sprintf("%d", level)
 		levelLimits[levelKey] = map[string]interface{}{
-			"max-instances": limitInfo.MaxInstances,
-			"max-resources": limitInfo.MaxResources,
-			"max-traffic":   limitInfo.MaxTraffic,
+			"max-instances":      limitInfo.MaxInstances,
+			"max-resources":      limitInfo.MaxResources,
+			"max-traffic":        limitInfo.MaxTraffic,
+			"max-snapshots":      limitInfo.MaxSnapshots,
+			"allow-user-data":    limitInfo.AllowUserData,
+			"max-user-data-size": limitInfo.MaxUserDataSize,
+			"max-firewall-rules": limitInfo.MaxFirewallRules,
 		}
 	}
 
@@ -303,6 +311,16 @@ func getAdminConfig(cm *config.ConfigManager) map[string]interface{} {
 		"defaultLanguage": global.APP_CONFIG.Other.DefaultLanguage,
 	}
 
+	// 自动调度配置
+	result["placement"] = map[string]interface{}{
+		"strategy":     global.APP_CONFIG.Placement.Strategy,
+		"cpuWeight":    global.APP_CONFIG.Placement.CPUWeight,
+		"memoryWeight": global.APP_CONFIG.Placement.MemoryWeight,
+		"diskWeight":   global.APP_CONFIG.Placement.DiskWeight,
+		"queueWeight":  global.APP_CONFIG.Placement.QueueWeight,
+		"healthWeight": global.APP_CONFIG.Placement.HealthWeight,
+	}
+
 	// 支付接口配置
 	result["payment"] = map[string]interface{}{
 		"alipayAppId":       global.APP_CONFIG.Payment.AlipayAppID,
@@ -175,7 +175,7 @@ func (p *ProviderApi) ListInstances(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param type path string true "Provider类型" Enums(docker,lxd,incus,proxmox)
+// @Param type path string true "Provider类型" Enums(docker,lxd,incus,proxmox,libvirt,podman)
 // @Param request body provider.CreateInstanceRequest true "创建实例请求参数"
 // @Success 200 {object} common.Response{data=object} "创建成功"
 // @Failure 400 {object} common.Response "参数错误"
@@ -25,7 +25,7 @@ type SystemImageResponse struct {
 // CreateSystemImageRequest 创建系统镜像请求
 type CreateSystemImageRequest struct {
 	Name         string `json:"name" binding:"required"`
-	ProviderType string `json:"providerType" binding:"required,oneof=proxmox lxd incus docker"`
+	ProviderType string `json:"providerType" binding:"required,oneof=proxmox lxd incus docker libvirt podman"`
 	InstanceType string `json:"instanceType" binding:"required,oneof=vm container"`
 	Architecture string `json:"architecture" binding:"required,oneof=amd64 arm64 s390x"`
 	URL          string `json:"url" binding:"required,url"`
@@ -43,7 +43,7 @@ type CreateSystemImageRequest struct {
 // UpdateSystemImageRequest 更新系统镜像请求
 type UpdateSystemImageRequest struct {
 	Name         string `json:"name"`
-	ProviderType string `json:"providerType" binding:"omitempty,oneof=proxmox lxd incus docker"`
+	ProviderType string `json:"providerType" binding:"omitempty,oneof=proxmox lxd incus docker libvirt podman"`
 	InstanceType string `json:"instanceType" binding:"omitempty,oneof=vm container"`
 	Architecture string `json:"architecture" binding:"omitempty,oneof=amd64 arm64 s390x"`
 	URL          string `json:"url" binding:"omitempty,url"`
@@ -68,7 +68,7 @@ type UpdateSystemImageRequest struct {
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param pageSize query int false "每页数量" default(10)
-// @Param providerType query string false "提供商类型" Enums(proxmox,lxd,incus,docker)
+// @Param providerType query string false "提供商类型" Enums(proxmox,lxd,incus,docker,libvirt,podman)
 // @Param instanceType query string false "实例类型" Enums(vm,container)
 // @Param architecture query string false "架构" Enums(amd64,arm64,s390x)
 // @Param status query string false "状态" Enums(active,inactive)
@@ -527,13 +527,17 @@ func validateImageURL(providerType, instanceType, url string) error {
 		if instanceType == "container" && !strings.HasSuffix(url, ".tar.xz") {
 			return fmt.Errorf("ProxmoxVE LXC容器镜像地址必须是.tar.xz文件")
 		}
+	case "libvirt":
+		if !strings.HasSuffix(url, ".qcow2") && !strings.HasSuffix(url, ".img") {
+			return fmt.Errorf("libvirt云镜像地址必须是.qcow2或.img文件")
+		}
 	case "lxd", "incus":
 		if !strings.HasSuffix(url, ".zip") {
 			return fmt.Errorf("LXD/Incus镜像地址必须是zip文件")
 		}
-	case "docker":
+	case "docker", "podman":
 		if instanceType == "container" && !strings.HasSuffix(url, ".tar.gz") {
-			return fmt.Errorf("Docker容器镜像地址必须是.tar.gz文件")
+			return fmt.Errorf("Docker/Podman容器镜像地址必须是.tar.gz文件")
 		}
 	}
 	return nil
@@ -0,0 +1,108 @@
+package user
+
+import (
+	"strconv"
+
+	"oneclickvirt/model/common"
+	"oneclickvirt/model/user"
+	userService "oneclickvirt/service/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSSHKeys 获取SSH公钥列表
+// @Summary 获取SSH公钥列表
+// @Description 获取当前用户保存的SSH公钥，创建或重装实例时可选择注入
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=[]user.SSHKey} "获取成功"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/ssh-keys [get]
+func GetSSHKeys(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	userServiceInstance := userService.NewService()
+	keys, err := userServiceInstance.GetSSHKeys(userID)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取SSH公钥列表失败"))
+		return
+	}
+
+	common.ResponseSuccess(c, keys)
+}
+
+// CreateSSHKey 添加SSH公钥
+// @Summary 添加SSH公钥
+// @Description 添加authorized_keys格式的SSH公钥，同一公钥不能重复添加
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body user.CreateSSHKeyRequest true "添加SSH公钥请求参数"
+// @Success 200 {object} common.Response{data=user.SSHKey} "添加成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Router /user/ssh-keys [post]
+func CreateSSHKey(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	var req user.CreateSSHKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return
+	}
+
+	userServiceInstance := userService.NewService()
+	key, err := userServiceInstance.CreateSSHKey(userID, req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+
+	common.ResponseSuccess(c, key, "添加成功")
+}
+
+// DeleteSSHKey 删除SSH公钥
+// @Summary 删除SSH公钥
+// @Description 删除当前用户的SSH公钥，已注入实例的公钥不会从实例中移除
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "SSH公钥ID"
+// @Success 200 {object} common.Response "删除成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Router /user/ssh-keys/{id} [delete]
+func DeleteSSHKey(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	keyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的公钥ID"))
+		return
+	}
+
+	userServiceInstance := userService.NewService()
+	if err := userServiceInstance.DeleteSSHKey(userID, uint(keyID)); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+
+	common.ResponseSuccess(c, nil, "删除成功")
+}
@@ -87,6 +87,77 @@ func GetAvailableResources(c *gin.Context) {
 	common.ResponseSuccessWithPagination(c, resources, total, req.Page, req.PageSize)
 }
 
+// GetAvailableFlavors 获取可选实例规格
+// @Summary 获取可选实例规格
+// @Description 返回已启用、节点提供且不超过用户等级资源限制的实例规格，申领或创建实例时传入flavorId使用
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param providerId query int false "节点ID"
+// @Param instanceType query string false "实例类型"
+// @Success 200 {object} common.Response{data=[]resource.Flavor} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 500 {object} common.Response "获取失败"
+// @Router /user/flavors [get]
+func GetAvailableFlavors(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	var req user.AvailableFlavorsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	userServiceInstance := userService.NewService()
+	flavors, err := userServiceInstance.GetAvailableFlavors(userID, req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, flavors, "获取成功")
+}
+
+// PreviewPlacement 预览自动选择节点
+// @Summary 预览自动选择节点
+// @Description 按管理员配置的调度策略为指定规格的实例给可申领节点打分，返回得分排序和被排除节点的原因
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body resource.PlacementRequest true "实例规格和筛选条件"
+// @Success 200 {object} common.Response{data=resource.PlacementResult} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 500 {object} common.Response "获取失败"
+// @Router /user/resources/placement [post]
+func PreviewPlacement(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	var req resource.PlacementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	userServiceInstance := userService.NewService()
+	result, err := userServiceInstance.PreviewPlacement(userID, req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, result, "获取成功")
+}
+
 // ClaimResource 申领资源
 // @Summary 申领资源
 // @Description 用户申领可用的资源实例
@@ -137,7 +208,7 @@ func ClaimResource(c *gin.Context) {
 		}
 
 		msg := err.Error()
-		if strings.Contains(msg, "不存在") || strings.Contains(msg, "不允许") || strings.Contains(msg, "已冻结") || strings.Contains(msg, "已过期") || strings.Contains(msg, "已达上限") || strings.Contains(msg, "资源不足") || strings.Contains(msg, "配额验证失败") || strings.Contains(msg, "资源分配失败") || strings.Contains(msg, "用户账户已被禁用") {
+		if strings.Contains(msg, "不存在") || strings.Contains(msg, "不允许") || strings.Contains(msg, "已冻结") || strings.Contains(msg, "已过期") || strings.Contains(msg, "已达上限") || strings.Contains(msg, "资源不足") || strings.Contains(msg, "配额验证失败") || strings.Contains(msg, "资源分配失败") || strings.Contains(msg, "用户账户已被禁用") || strings.Contains(msg, "没有满足条件的可用节点") || strings.Contains(msg, "实例规格") {
 			common.ResponseWithError(c, common.NewError(common.CodeValidationError, msg))
 			return
 		}
@@ -1102,3 +1173,107 @@ func QueryInstancePmacctData(c *gin.Context) {
 
 	common.ResponseSuccess(c, summary, "查询pmacct数据成功")
 }
+
+// ResizeInstance 用户调整实例配置
+// @Summary 用户调整实例配置
+// @Description 调整实例的CPU、内存、磁盘和带宽，磁盘只支持扩容，调整后的配置受用户等级和节点配额限制
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body user.ResizeInstanceRequest true "调整后的实例配置"
+// @Success 200 {object} common.Response{data=user.ResizeInstanceResponse} "任务创建成功，返回任务ID"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Failure 500 {object} common.Response "创建任务失败"
+// @Router /user/instances/{id}/resize [put]
+func ResizeInstance(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的实例ID"))
+		return
+	}
+
+	var req user.ResizeInstanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	userInstanceService := userService.NewService()
+	taskID, err := userInstanceService.ResizeInstance(userID, uint(instanceID), req)
+	if err != nil {
+		global.APP_LOG.Error("用户创建调整实例配置任务失败",
+			zap.Uint("userID", userID),
+			zap.Uint64("instanceID", instanceID),
+			zap.Error(err))
+		if err.Error() == "实例不存在或无权限" {
+			common.ResponseWithError(c, common.NewError(common.CodeForbidden, err.Error()))
+			return
+		}
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+
+	common.ResponseSuccess(c, user.ResizeInstanceResponse{TaskID: taskID}, "调整配置任务创建成功")
+}
+
+// ReinstallInstance 用户重装实例系统
+// @Summary 用户重装实例系统
+// @Description 使用该节点可用镜像列表中的任意镜像重建实例，保留端口映射、IPv6地址和SSH端口，实例数据会被清空
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body user.ReinstallInstanceRequest true "目标镜像"
+// @Success 200 {object} common.Response{data=user.ReinstallInstanceResponse} "任务创建成功，返回任务ID"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Failure 500 {object} common.Response "创建任务失败"
+// @Router /user/instances/{id}/reinstall [post]
+func ReinstallInstance(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的实例ID"))
+		return
+	}
+
+	var req user.ReinstallInstanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	userInstanceService := userService.NewService()
+	taskID, err := userInstanceService.ReinstallInstance(userID, uint(instanceID), req)
+	if err != nil {
+		global.APP_LOG.Error("用户创建重装系统任务失败",
+			zap.Uint("userID", userID),
+			zap.Uint64("instanceID", instanceID),
+			zap.Error(err))
+		if err.Error() == "实例不存在或无权限" {
+			common.ResponseWithError(c, common.NewError(common.CodeForbidden, err.Error()))
+			return
+		}
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+
+	common.ResponseSuccess(c, user.ReinstallInstanceResponse{TaskID: taskID}, "重装系统任务创建成功")
+}
@@ -0,0 +1,318 @@
+package user
+
+import (
+	"strconv"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	"oneclickvirt/model/user"
+	userService "oneclickvirt/service/user"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// parseBackupParams 解析路径中的实例ID和备份ID
+func parseBackupParams(c *gin.Context, withBackup bool) (uint, uint, bool) {
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的实例ID"))
+		return 0, 0, false
+	}
+	if !withBackup {
+		return uint(instanceID), 0, true
+	}
+	backupID, err := strconv.ParseUint(c.Param("backupId"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的备份ID"))
+		return 0, 0, false
+	}
+	return uint(instanceID), uint(backupID), true
+}
+
+// respondBackupError 统一处理备份操作错误
+func respondBackupError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "实例不存在或无权限":
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, err.Error()))
+	case "备份不存在", "备份文件不存在":
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+	default:
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+	}
+}
+
+// GetInstanceBackups 获取实例备份列表
+// @Summary 获取实例备份列表
+// @Description 获取用户实例的所有备份，包括手动备份和定时备份
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Success 200 {object} common.Response{data=[]provider.InstanceBackup} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Router /user/instances/{id}/backups [get]
+func GetInstanceBackups(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, _, ok := parseBackupParams(c, false)
+	if !ok {
+		return
+	}
+
+	backups, err := userService.NewService().GetInstanceBackups(userID, instanceID)
+	if err != nil {
+		respondBackupError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, backups)
+}
+
+// CreateInstanceBackup 创建实例备份
+// @Summary 创建实例备份
+// @Description 导出用户实例的完整归档并保存到服务器，创建异步任务执行
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body user.CreateBackupRequest true "创建备份请求参数"
+// @Success 200 {object} common.Response{data=user.BackupTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Failure 500 {object} common.Response "创建任务失败"
+// @Router /user/instances/{id}/backups [post]
+func CreateInstanceBackup(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, _, ok := parseBackupParams(c, false)
+	if !ok {
+		return
+	}
+
+	var req user.CreateBackupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	result, err := userService.NewService().CreateInstanceBackup(userID, instanceID, req)
+	if err != nil {
+		global.APP_LOG.Error("用户创建实例备份失败",
+			zap.Uint("userID", userID),
+			zap.Uint("instanceID", instanceID),
+			zap.Error(err))
+		respondBackupError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "备份创建任务已提交")
+}
+
+// RestoreInstanceBackup 恢复实例备份
+// @Summary 恢复实例备份
+// @Description 用指定备份原地覆盖用户实例，备份之后的数据将丢失
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param backupId path int true "备份ID"
+// @Success 200 {object} common.Response{data=user.BackupTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Failure 404 {object} common.Response "备份不存在"
+// @Router /user/instances/{id}/backups/{backupId}/restore [post]
+func RestoreInstanceBackup(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, backupID, ok := parseBackupParams(c, true)
+	if !ok {
+		return
+	}
+
+	result, err := userService.NewService().RestoreInstanceBackup(userID, instanceID, backupID)
+	if err != nil {
+		global.APP_LOG.Error("用户恢复实例备份失败",
+			zap.Uint("userID", userID),
+			zap.Uint("instanceID", instanceID),
+			zap.Uint("backupID", backupID),
+			zap.Error(err))
+		respondBackupError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "备份恢复任务已提交")
+}
+
+// DeleteInstanceBackup 删除实例备份
+// @Summary 删除实例备份
+// @Description 删除用户实例的指定备份及其归档文件
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param backupId path int true "备份ID"
+// @Success 200 {object} common.Response "删除成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Failure 404 {object} common.Response "备份不存在"
+// @Router /user/instances/{id}/backups/{backupId} [delete]
+func DeleteInstanceBackup(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, backupID, ok := parseBackupParams(c, true)
+	if !ok {
+		return
+	}
+
+	if err := userService.NewService().DeleteInstanceBackup(userID, instanceID, backupID); err != nil {
+		global.APP_LOG.Error("用户删除实例备份失败",
+			zap.Uint("userID", userID),
+			zap.Uint("instanceID", instanceID),
+			zap.Uint("backupID", backupID),
+			zap.Error(err))
+		respondBackupError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, nil, "备份已删除")
+}
+
+// DownloadInstanceBackup 下载实例备份归档
+// @Summary 下载实例备份归档
+// @Description 下载用户实例指定备份的归档文件
+// @Tags 用户管理
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param backupId path int true "备份ID"
+// @Success 200 {file} file "备份归档"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Failure 404 {object} common.Response "备份不存在"
+// @Router /user/instances/{id}/backups/{backupId}/download [get]
+func DownloadInstanceBackup(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, backupID, ok := parseBackupParams(c, true)
+	if !ok {
+		return
+	}
+
+	fullPath, fileName, err := userService.NewService().GetInstanceBackupFile(userID, instanceID, backupID)
+	if err != nil {
+		respondBackupError(c, err)
+		return
+	}
+
+	c.FileAttachment(fullPath, fileName)
+}
+
+// GetInstanceBackupPolicy 获取实例定时备份策略
+// @Summary 获取实例定时备份策略
+// @Description 获取用户实例的定时备份策略，未配置时返回默认值
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Success 200 {object} common.Response{data=provider.InstanceBackupPolicy} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Router /user/instances/{id}/backup-policy [get]
+func GetInstanceBackupPolicy(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, _, ok := parseBackupParams(c, false)
+	if !ok {
+		return
+	}
+
+	policy, err := userService.NewService().GetInstanceBackupPolicy(userID, instanceID)
+	if err != nil {
+		respondBackupError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, policy)
+}
+
+// UpdateInstanceBackupPolicy 更新实例定时备份策略
+// @Summary 更新实例定时备份策略
+// @Description 设置用户实例的定时备份频率、执行时间和保留数量
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body user.UpdateBackupPolicyRequest true "备份策略参数"
+// @Success 200 {object} common.Response{data=provider.InstanceBackupPolicy} "更新成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Router /user/instances/{id}/backup-policy [put]
+func UpdateInstanceBackupPolicy(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, _, ok := parseBackupParams(c, false)
+	if !ok {
+		return
+	}
+
+	var req user.UpdateBackupPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	policy, err := userService.NewService().UpdateInstanceBackupPolicy(userID, instanceID, req)
+	if err != nil {
+		global.APP_LOG.Error("用户更新实例备份策略失败",
+			zap.Uint("userID", userID),
+			zap.Uint("instanceID", instanceID),
+			zap.Error(err))
+		respondBackupError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, policy, "备份策略已更新")
+}
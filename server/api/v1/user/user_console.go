@@ -0,0 +1,86 @@
+package user
+
+import (
+	"errors"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/console"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// InstanceConsoleWebSocket 处理WebSocket实例控制台连接
+// @Summary WebSocket实例控制台
+// @Description 通过WebSocket连接实例所在节点的虚拟化控制台（串口或VNC），不依赖实例内的网络和sshd
+// @Tags 用户/实例
+// @Accept json
+// @Produce json
+// @Param id path uint true "实例ID"
+// @Param type query string false "控制台类型: serial(默认) 或 vnc"
+// @Success 101 {string} string "Switching Protocols"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Failure 401 {object} common.Response "未授权"
+// @Failure 404 {object} common.Response "实例不存在"
+// @Failure 500 {object} common.Response "服务器错误"
+// @Router /v1/user/instances/{id}/console [get]
+func InstanceConsoleWebSocket(c *gin.Context) {
+	// 获取用户ID
+	userIDInterface, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{"code": 401, "message": "未授权"})
+		return
+	}
+	userID := userIDInterface.(uint)
+
+	instanceID := c.Param("id")
+	if instanceID == "" {
+		c.JSON(400, gin.H{"code": 400, "message": "实例ID不能为空"})
+		return
+	}
+
+	consoleType, err := console.NormalizeConsoleType(c.Query("type"))
+	if err != nil {
+		c.JSON(400, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	// 与WebSocket SSH相同：只能连接自己名下的实例
+	var instance providerModel.Instance
+	err = global.APP_DB.Select("id", "name", "provider_id", "status").
+		Where("id = ? AND user_id = ?", instanceID, userID).
+		First(&instance).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"code": 404, "message": "实例不存在"})
+			return
+		}
+		global.APP_LOG.Error("查询实例失败", zap.Error(err))
+		c.JSON(500, gin.H{"code": 500, "message": "查询实例失败"})
+		return
+	}
+
+	if instance.Status != "running" {
+		c.JSON(400, gin.H{"code": 400, "message": "实例未运行，无法连接控制台"})
+		return
+	}
+
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		global.APP_LOG.Error("WebSocket升级失败", zap.Error(err))
+		return
+	}
+	defer ws.Close()
+
+	console.Serve(ws, &instance, console.SessionInfo{
+		UserID:      userID,
+		Username:    c.GetString("username"),
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		ClientIP:    c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		ConsoleType: consoleType,
+	})
+}
@@ -0,0 +1,109 @@
+package user
+
+import (
+	"strconv"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	"oneclickvirt/model/user"
+	userService "oneclickvirt/service/user"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// respondFirewallError 统一处理防火墙操作错误
+func respondFirewallError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "实例不存在或无权限":
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, err.Error()))
+	case strings.HasPrefix(err.Error(), "第 "), strings.Contains(err.Error(), "防火墙规则数量"),
+		strings.Contains(err.Error(), "不允许使用防火墙"), strings.HasPrefix(err.Error(), "只有运行中"),
+		strings.HasPrefix(err.Error(), "当前节点的"):
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+	default:
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+	}
+}
+
+// GetInstanceFirewall 获取实例防火墙规则
+// @Summary 获取实例防火墙规则
+// @Description 获取用户实例的入站防火墙规则，以及当前等级允许的最大规则数量
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Success 200 {object} common.Response{data=user.FirewallRulesResponse} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Router /user/instances/{id}/firewall [get]
+func GetInstanceFirewall(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的实例ID"))
+		return
+	}
+
+	result, err := userService.NewService().GetInstanceFirewall(userID, uint(instanceID))
+	if err != nil {
+		respondFirewallError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result)
+}
+
+// UpdateInstanceFirewall 更新实例防火墙规则
+// @Summary 更新实例防火墙规则
+// @Description 整体替换用户实例的入站防火墙规则，规则按顺序匹配，首条命中的规则生效，未命中的流量放行；规则数量受用户等级限制
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body user.UpdateFirewallRulesRequest true "防火墙规则"
+// @Success 200 {object} common.Response{data=user.FirewallTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Router /user/instances/{id}/firewall [put]
+func UpdateInstanceFirewall(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的实例ID"))
+		return
+	}
+
+	var req user.UpdateFirewallRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	result, err := userService.NewService().UpdateInstanceFirewall(userID, uint(instanceID), req)
+	if err != nil {
+		global.APP_LOG.Error("用户更新实例防火墙规则失败",
+			zap.Uint("userID", userID),
+			zap.Uint64("instanceID", instanceID),
+			zap.Error(err))
+		respondFirewallError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "防火墙规则已保存，正在应用")
+}
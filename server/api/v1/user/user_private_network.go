@@ -0,0 +1,230 @@
+package user
+
+import (
+	"strconv"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	"oneclickvirt/model/user"
+	userService "oneclickvirt/service/user"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// parsePrivateNetworkID 解析路径中的私有网络ID
+func parsePrivateNetworkID(c *gin.Context) (uint, bool) {
+	networkID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的私有网络ID"))
+		return 0, false
+	}
+	return uint(networkID), true
+}
+
+// respondPrivateNetworkError 统一处理私有网络操作错误
+func respondPrivateNetworkError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "实例不存在或无权限":
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, err.Error()))
+	case "私有网络不存在", "实例未接入该私有网络":
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+	default:
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+	}
+}
+
+// GetUserPrivateNetworks 获取用户私有网络列表
+// @Summary 获取用户私有网络列表
+// @Description 获取当前用户的所有私有网络及接入的实例
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=[]provider.PrivateNetwork} "获取成功"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Router /user/private-networks [get]
+func GetUserPrivateNetworks(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	networks, err := userService.NewService().GetUserPrivateNetworks(userID)
+	if err != nil {
+		respondPrivateNetworkError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, networks)
+}
+
+// CreatePrivateNetwork 创建私有网络
+// @Summary 创建私有网络
+// @Description 在节点上创建只在自己实例之间互通的隔离网络，网段必须是私有IPv4地址段
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body user.CreatePrivateNetworkRequest true "创建私有网络请求参数"
+// @Success 200 {object} common.Response{data=user.PrivateNetworkTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 500 {object} common.Response "创建任务失败"
+// @Router /user/private-networks [post]
+func CreatePrivateNetwork(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	var req user.CreatePrivateNetworkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	result, err := userService.NewService().CreatePrivateNetwork(userID, req)
+	if err != nil {
+		global.APP_LOG.Error("用户创建私有网络失败",
+			zap.Uint("userID", userID),
+			zap.Error(err))
+		respondPrivateNetworkError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "私有网络创建任务已提交")
+}
+
+// DeletePrivateNetwork 删除私有网络
+// @Summary 删除私有网络
+// @Description 删除没有实例接入的私有网络
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "私有网络ID"
+// @Success 200 {object} common.Response{data=user.PrivateNetworkTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 404 {object} common.Response "私有网络不存在"
+// @Router /user/private-networks/{id} [delete]
+func DeletePrivateNetwork(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	networkID, ok := parsePrivateNetworkID(c)
+	if !ok {
+		return
+	}
+
+	result, err := userService.NewService().DeletePrivateNetwork(userID, networkID)
+	if err != nil {
+		global.APP_LOG.Error("用户删除私有网络失败",
+			zap.Uint("userID", userID),
+			zap.Uint("networkID", networkID),
+			zap.Error(err))
+		respondPrivateNetworkError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "私有网络删除任务已提交")
+}
+
+// AttachPrivateNetwork 实例接入私有网络
+// @Summary 实例接入私有网络
+// @Description 为同一节点上的实例添加接入私有网络的网卡，未指定地址时自动分配
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "私有网络ID"
+// @Param request body user.AttachPrivateNetworkRequest true "接入请求参数"
+// @Success 200 {object} common.Response{data=user.PrivateNetworkTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Failure 404 {object} common.Response "私有网络不存在"
+// @Router /user/private-networks/{id}/attach [post]
+func AttachPrivateNetwork(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	networkID, ok := parsePrivateNetworkID(c)
+	if !ok {
+		return
+	}
+
+	var req user.AttachPrivateNetworkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	result, err := userService.NewService().AttachPrivateNetwork(userID, networkID, req)
+	if err != nil {
+		global.APP_LOG.Error("用户实例接入私有网络失败",
+			zap.Uint("userID", userID),
+			zap.Uint("networkID", networkID),
+			zap.Uint("instanceID", req.InstanceID),
+			zap.Error(err))
+		respondPrivateNetworkError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "接入私有网络任务已提交")
+}
+
+// DetachPrivateNetwork 实例断开私有网络
+// @Summary 实例断开私有网络
+// @Description 移除实例上接入私有网络的网卡并释放地址
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "私有网络ID"
+// @Param request body user.DetachPrivateNetworkRequest true "断开请求参数"
+// @Success 200 {object} common.Response{data=user.PrivateNetworkTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 404 {object} common.Response "私有网络不存在"
+// @Router /user/private-networks/{id}/detach [post]
+func DetachPrivateNetwork(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	networkID, ok := parsePrivateNetworkID(c)
+	if !ok {
+		return
+	}
+
+	var req user.DetachPrivateNetworkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	result, err := userService.NewService().DetachPrivateNetwork(userID, networkID, req)
+	if err != nil {
+		global.APP_LOG.Error("用户实例断开私有网络失败",
+			zap.Uint("userID", userID),
+			zap.Uint("networkID", networkID),
+			zap.Uint("instanceID", req.InstanceID),
+			zap.Error(err))
+		respondPrivateNetworkError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "断开私有网络任务已提交")
+}
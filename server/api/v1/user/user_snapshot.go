@@ -0,0 +1,206 @@
+package user
+
+import (
+	"strconv"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	"oneclickvirt/model/user"
+	userService "oneclickvirt/service/user"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// parseSnapshotParams 解析路径中的实例ID和快照ID
+func parseSnapshotParams(c *gin.Context, withSnapshot bool) (uint, uint, bool) {
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的实例ID"))
+		return 0, 0, false
+	}
+	if !withSnapshot {
+		return uint(instanceID), 0, true
+	}
+	snapshotID, err := strconv.ParseUint(c.Param("snapshotId"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的快照ID"))
+		return 0, 0, false
+	}
+	return uint(instanceID), uint(snapshotID), true
+}
+
+// respondSnapshotError 统一处理快照操作错误
+func respondSnapshotError(c *gin.Context, err error) {
+	if err.Error() == "实例不存在或无权限" {
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, err.Error()))
+		return
+	}
+	if err.Error() == "快照不存在" {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		return
+	}
+	common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+}
+
+// GetInstanceSnapshots 获取实例快照列表
+// @Summary 获取实例快照列表
+// @Description 获取用户实例的所有快照
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Success 200 {object} common.Response{data=[]provider.InstanceSnapshot} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Router /user/instances/{id}/snapshots [get]
+func GetInstanceSnapshots(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, _, ok := parseSnapshotParams(c, false)
+	if !ok {
+		return
+	}
+
+	snapshots, err := userService.NewService().GetInstanceSnapshots(userID, instanceID)
+	if err != nil {
+		respondSnapshotError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, snapshots)
+}
+
+// CreateInstanceSnapshot 创建实例快照
+// @Summary 创建实例快照
+// @Description 为用户实例创建快照，创建异步任务执行，快照数量受用户等级限制
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body user.CreateSnapshotRequest true "创建快照请求参数"
+// @Success 200 {object} common.Response{data=user.SnapshotTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Failure 500 {object} common.Response "创建任务失败"
+// @Router /user/instances/{id}/snapshots [post]
+func CreateInstanceSnapshot(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, _, ok := parseSnapshotParams(c, false)
+	if !ok {
+		return
+	}
+
+	var req user.CreateSnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	result, err := userService.NewService().CreateInstanceSnapshot(userID, instanceID, req)
+	if err != nil {
+		global.APP_LOG.Error("用户创建实例快照失败",
+			zap.Uint("userID", userID),
+			zap.Uint("instanceID", instanceID),
+			zap.Error(err))
+		respondSnapshotError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "快照创建任务已提交")
+}
+
+// RestoreInstanceSnapshot 恢复实例快照
+// @Summary 恢复实例快照
+// @Description 将用户实例恢复到指定快照，快照之后的数据将丢失
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param snapshotId path int true "快照ID"
+// @Success 200 {object} common.Response{data=user.SnapshotTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Failure 404 {object} common.Response "快照不存在"
+// @Router /user/instances/{id}/snapshots/{snapshotId}/restore [post]
+func RestoreInstanceSnapshot(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, snapshotID, ok := parseSnapshotParams(c, true)
+	if !ok {
+		return
+	}
+
+	result, err := userService.NewService().RestoreInstanceSnapshot(userID, instanceID, snapshotID)
+	if err != nil {
+		global.APP_LOG.Error("用户恢复实例快照失败",
+			zap.Uint("userID", userID),
+			zap.Uint("instanceID", instanceID),
+			zap.Uint("snapshotID", snapshotID),
+			zap.Error(err))
+		respondSnapshotError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "快照恢复任务已提交")
+}
+
+// DeleteInstanceSnapshot 删除实例快照
+// @Summary 删除实例快照
+// @Description 删除用户实例的指定快照
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param snapshotId path int true "快照ID"
+// @Success 200 {object} common.Response{data=user.SnapshotTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Failure 404 {object} common.Response "快照不存在"
+// @Router /user/instances/{id}/snapshots/{snapshotId} [delete]
+func DeleteInstanceSnapshot(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, snapshotID, ok := parseSnapshotParams(c, true)
+	if !ok {
+		return
+	}
+
+	result, err := userService.NewService().DeleteInstanceSnapshot(userID, instanceID, snapshotID)
+	if err != nil {
+		global.APP_LOG.Error("用户删除实例快照失败",
+			zap.Uint("userID", userID),
+			zap.Uint("instanceID", instanceID),
+			zap.Uint("snapshotID", snapshotID),
+			zap.Error(err))
+		respondSnapshotError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "快照删除任务已提交")
+}
@@ -0,0 +1,270 @@
+package user
+
+import (
+	"strconv"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	"oneclickvirt/model/user"
+	userService "oneclickvirt/service/user"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// parseVolumeID 解析路径中的数据卷ID
+func parseVolumeID(c *gin.Context) (uint, bool) {
+	volumeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的数据卷ID"))
+		return 0, false
+	}
+	return uint(volumeID), true
+}
+
+// respondVolumeError 统一处理数据卷操作错误
+func respondVolumeError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "实例不存在或无权限":
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, err.Error()))
+	case err.Error() == "数据卷不存在":
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+	case strings.Contains(err.Error(), "资源不足"):
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+	default:
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+	}
+}
+
+// GetUserVolumes 获取用户数据卷列表
+// @Summary 获取用户数据卷列表
+// @Description 获取当前用户的所有数据卷及挂载状态
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=[]provider.Volume} "获取成功"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Router /user/volumes [get]
+func GetUserVolumes(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	volumes, err := userService.NewService().GetUserVolumes(userID)
+	if err != nil {
+		respondVolumeError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, volumes)
+}
+
+// CreateVolume 创建数据卷
+// @Summary 创建数据卷
+// @Description 在节点上创建数据卷，指定实例时在实例所在节点创建并直接挂载，容量计入磁盘配额
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body user.CreateVolumeRequest true "创建数据卷请求参数"
+// @Success 200 {object} common.Response{data=user.VolumeTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Failure 500 {object} common.Response "创建任务失败"
+// @Router /user/volumes [post]
+func CreateVolume(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	var req user.CreateVolumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	result, err := userService.NewService().CreateVolume(userID, req)
+	if err != nil {
+		global.APP_LOG.Error("用户创建数据卷失败",
+			zap.Uint("userID", userID),
+			zap.Error(err))
+		respondVolumeError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "数据卷创建任务已提交")
+}
+
+// AttachVolume 挂载数据卷
+// @Summary 挂载数据卷
+// @Description 将未挂载的数据卷挂载到同一节点上相同类型的实例，Docker实例挂载时会重建容器
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "数据卷ID"
+// @Param request body user.AttachVolumeRequest true "挂载数据卷请求参数"
+// @Success 200 {object} common.Response{data=user.VolumeTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Failure 404 {object} common.Response "数据卷不存在"
+// @Router /user/volumes/{id}/attach [post]
+func AttachVolume(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	volumeID, ok := parseVolumeID(c)
+	if !ok {
+		return
+	}
+
+	var req user.AttachVolumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	result, err := userService.NewService().AttachVolume(userID, volumeID, req)
+	if err != nil {
+		global.APP_LOG.Error("用户挂载数据卷失败",
+			zap.Uint("userID", userID),
+			zap.Uint("volumeID", volumeID),
+			zap.Error(err))
+		respondVolumeError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "数据卷挂载任务已提交")
+}
+
+// DetachVolume 卸载数据卷
+// @Summary 卸载数据卷
+// @Description 从实例卸载数据卷，数据卷中的数据保留
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "数据卷ID"
+// @Success 200 {object} common.Response{data=user.VolumeTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 404 {object} common.Response "数据卷不存在"
+// @Router /user/volumes/{id}/detach [post]
+func DetachVolume(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	volumeID, ok := parseVolumeID(c)
+	if !ok {
+		return
+	}
+
+	result, err := userService.NewService().DetachVolume(userID, volumeID)
+	if err != nil {
+		global.APP_LOG.Error("用户卸载数据卷失败",
+			zap.Uint("userID", userID),
+			zap.Uint("volumeID", volumeID),
+			zap.Error(err))
+		respondVolumeError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "数据卷卸载任务已提交")
+}
+
+// ResizeVolume 数据卷扩容
+// @Summary 数据卷扩容
+// @Description 增大数据卷容量，新增容量计入磁盘配额，不支持缩容
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "数据卷ID"
+// @Param request body user.ResizeVolumeRequest true "扩容请求参数"
+// @Success 200 {object} common.Response{data=user.VolumeTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 404 {object} common.Response "数据卷不存在"
+// @Router /user/volumes/{id}/resize [post]
+func ResizeVolume(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	volumeID, ok := parseVolumeID(c)
+	if !ok {
+		return
+	}
+
+	var req user.ResizeVolumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	result, err := userService.NewService().ResizeVolume(userID, volumeID, req)
+	if err != nil {
+		global.APP_LOG.Error("用户扩容数据卷失败",
+			zap.Uint("userID", userID),
+			zap.Uint("volumeID", volumeID),
+			zap.Error(err))
+		respondVolumeError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "数据卷扩容任务已提交")
+}
+
+// DeleteVolume 删除数据卷
+// @Summary 删除数据卷
+// @Description 删除未挂载的数据卷并释放磁盘配额，数据不可恢复
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "数据卷ID"
+// @Success 200 {object} common.Response{data=user.VolumeTaskResponse} "任务创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 404 {object} common.Response "数据卷不存在"
+// @Router /user/volumes/{id} [delete]
+func DeleteVolume(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	volumeID, ok := parseVolumeID(c)
+	if !ok {
+		return
+	}
+
+	result, err := userService.NewService().DeleteVolume(userID, volumeID)
+	if err != nil {
+		global.APP_LOG.Error("用户删除数据卷失败",
+			zap.Uint("userID", userID),
+			zap.Uint("volumeID", volumeID),
+			zap.Error(err))
+		respondVolumeError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, result, "数据卷删除任务已提交")
+}
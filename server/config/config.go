@@ -13,6 +13,7 @@ type Server struct {
 	Redis      Redis      `mapstructure:"redis" json:"redis" yaml:"redis"`
 	CDN        CDN        `mapstructure:"cdn" json:"cdn" yaml:"cdn"`
 	Task       Task       `mapstructure:"task" json:"task" yaml:"task"`
+	Placement  Placement  `mapstructure:"placement" json:"placement" yaml:"placement"`
 	Upload     Upload     `mapstructure:"upload" json:"upload" yaml:"upload"`
 	Other      Other      `mapstructure:"other" json:"other" yaml:"other"`
 	Payment    Payment    `mapstructure:"payment" json:"payment" yaml:"payment"`
@@ -64,7 +65,15 @@ type InstanceTypePermissions struct {
 type LevelLimitInfo struct {
 	MaxInstances int                    `mapstructure:"max-instances" json:"max-instances" yaml:"max-instances"`
 	MaxResources map[string]interface{} `mapstructure:"max-resources" json:"max-resources" yaml:"max-resources"`
-	MaxTraffic   int64                  `mapstructure:"max-traffic" json:"max-traffic" yaml:"max-traffic"` // 最大流量限制（MB）
+	MaxTraffic   int64                  `mapstructure:"max-traffic" json:"max-traffic" yaml:"max-traffic"`       // 最大流量限制（MB）
+	MaxSnapshots int                    `mapstructure:"max-snapshots" json:"max-snapshots" yaml:"max-snapshots"` // 每个实例最大快照数量，0表示不允许创建快照
+
+	// user-data
+	AllowUserData   bool `mapstructure:"allow-user-data" json:"allow-user-data" yaml:"allow-user-data"`          // 是否允许创建或重装实例时提供cloud-init user-data
+	MaxUserDataSize int  `mapstructure:"max-user-data-size" json:"max-user-data-size" yaml:"max-user-data-size"` // user-data最大大小（KB），0表示使用默认值16KB
+
+	// firewall
+	MaxFirewallRules int `mapstructure:"max-firewall-rules" json:"max-firewall-rules" yaml:"max-firewall-rules"` // 每个实例最大防火墙规则数量，0表示不允许使用防火墙
 }
 
 type System struct {
@@ -139,6 +148,16 @@ type Task struct {
 	DeleteRetryDelay int `mapstructure:"delete-retry-delay" json:"delete-retry-delay" yaml:"delete-retry-delay"` // 删除实例重试延迟（秒），默认2
 }
 
+// Placement 自动选择节点的调度配置
+type Placement struct {
+	Strategy     string  `mapstructure:"strategy" json:"strategy" yaml:"strategy"`                // 调度策略：spread优先选择空闲资源多的节点，pack优先填满已有负载的节点
+	CPUWeight    float64 `mapstructure:"cpu-weight" json:"cpu-weight" yaml:"cpu-weight"`          // CPU余量权重
+	MemoryWeight float64 `mapstructure:"memory-weight" json:"memory-weight" yaml:"memory-weight"` // 内存余量权重
+	DiskWeight   float64 `mapstructure:"disk-weight" json:"disk-weight" yaml:"disk-weight"`       // 磁盘余量权重
+	QueueWeight  float64 `mapstructure:"queue-weight" json:"queue-weight" yaml:"queue-weight"`    // 任务队列长度权重，队列越短得分越高
+	HealthWeight float64 `mapstructure:"health-weight" json:"health-weight" yaml:"health-weight"` // 健康状态权重
+}
+
 // Upload 上传配置
 type Upload struct {
 	MaxAvatarSize int64 `mapstructure:"max-avatar-size" json:"max-avatar-size" yaml:"max-avatar-size"` // 头像最大大小（MB）
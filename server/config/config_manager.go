@@ -237,6 +237,18 @@ func (cm *ConfigManager) initValidationRules() {
 		},
 	}
 
+	// 自动调度配置验证规则
+	cm.validationRules["placement.strategy"] = ConfigValidationRule{
+		Required: false,
+		Type:     "string",
+		Validator: func(value interface{}) error {
+			if s, ok := value.(string); !ok || (s != "spread" && s != "pack") {
+				return fmt.Errorf("配置项 placement.strategy 只能是 spread 或 pack")
+			}
+			return nil
+		},
+	}
+
 	// 更多验证规则...
 }
 
@@ -488,7 +500,9 @@ func (cm *ConfigManager) validateLevelLimits(value interface{}) error {
 				"disk":      1024,
 				"bandwidth": 100,
 			},
-			"max-traffic": 102400,
+			"max-traffic":        102400,
+			"max-snapshots":      1,
+			"max-firewall-rules": 5,
 		},
 		"2": {
 			"max-instances": 3,
@@ -498,7 +512,9 @@ func (cm *ConfigManager) validateLevelLimits(value interface{}) error {
 				"disk":      20480,
 				"bandwidth": 200,
 			},
-			"max-traffic": 204800,
+			"max-traffic":        204800,
+			"max-snapshots":      2,
+			"max-firewall-rules": 10,
 		},
 		"3": {
 			"max-instances": 5,
@@ -508,7 +524,9 @@ func (cm *ConfigManager) validateLevelLimits(value interface{}) error {
 				"disk":      40960,
 				"bandwidth": 500,
 			},
-			"max-traffic": 307200,
+			"max-traffic":        307200,
+			"max-snapshots":      3,
+			"max-firewall-rules": 20,
 		},
 		"4": {
 			"max-instances": 10,
@@ -518,7 +536,9 @@ func (cm *ConfigManager) validateLevelLimits(value interface{}) error {
 				"disk":      81920,
 				"bandwidth": 1000,
 			},
-			"max-traffic": 409600,
+			"max-traffic":        409600,
+			"max-snapshots":      5,
+			"max-firewall-rules": 30,
 		},
 		"5": {
 			"max-instances": 20,
@@ -528,7 +548,9 @@ func (cm *ConfigManager) validateLevelLimits(value interface{}) error {
 				"disk":      163840,
 				"bandwidth": 2000,
 			},
-			"max-traffic": 512000,
+			"max-traffic":        512000,
+			"max-snapshots":      10,
+			"max-firewall-rules": 50,
 		},
 	}
 
@@ -578,6 +600,50 @@ func (cm *ConfigManager) validateLevelLimits(value interface{}) error {
 			}
 		}
 
+		// 验证并填充 max-snapshots（0表示该等级不允许创建快照）
+		maxSnapshots, exists := limitMap["max-snapshots"]
+		if !exists || maxSnapshots == nil {
+			if hasDefault {
+				limitMap["max-snapshots"] = defaultConfig["max-snapshots"]
+				cm.logger.Info("自动填充默认配置",
+					zap.String("level", levelStr),
+					zap.String("field", "max-snapshots"),
+					zap.Any("value", defaultConfig["max-snapshots"]))
+			}
+		} else if maxSnapshots != 0 {
+			if err := validatePositiveNumber(maxSnapshots, fmt.Sprintf("等级 %s 的 max-snapshots", levelStr)); err != nil {
+				return err
+			}
+		}
+
+		// 验证并填充 max-firewall-rules（0表示该等级不允许使用防火墙）
+		maxFirewallRules, exists := limitMap["max-firewall-rules"]
+		if !exists || maxFirewallRules == nil {
+			if hasDefault {
+				limitMap["max-firewall-rules"] = defaultConfig["max-firewall-rules"]
+				cm.logger.Info("自动填充默认配置",
+					zap.String("level", levelStr),
+					zap.String("field", "max-firewall-rules"),
+					zap.Any("value", defaultConfig["max-firewall-rules"]))
+			}
+		} else if maxFirewallRules != 0 {
+			if err := validatePositiveNumber(maxFirewallRules, fmt.Sprintf("等级 %s 的 max-firewall-rules", levelStr)); err != nil {
+				return err
+			}
+		}
+
+		// 验证 max-user-data-size（0表示使用默认值，上限64KB）
+		if maxUserDataSize, exists := limitMap["max-user-data-size"]; exists && maxUserDataSize != nil && maxUserDataSize != 0 {
+			if err := validatePositiveNumber(maxUserDataSize, fmt.Sprintf("等级 %s 的 max-user-data-size", levelStr)); err != nil {
+				return err
+			}
+			if size, ok := maxUserDataSize.(float64); ok && size > 64 {
+				return fmt.Errorf("等级 %s 的 max-user-data-size 不能超过64KB", levelStr)
+			} else if size, ok := maxUserDataSize.(int); ok && size > 64 {
+				return fmt.Errorf("等级 %s 的 max-user-data-size 不能超过64KB", levelStr)
+			}
+		}
+
 		// 验证并填充 max-resources
 		maxResources, exists := limitMap["max-resources"]
 		if !exists || maxResources == nil {
@@ -1359,7 +1425,9 @@ func getDefaultConfigMap() map[string]interface{} {
 						"memory": 1024,
 						"disk":   10,
 					},
-					"max-traffic": 0,
+					"max-traffic":        0,
+					"max-snapshots":      1,
+					"max-firewall-rules": 5,
 				},
 				"2": map[string]interface{}{
 					"max-instances": 3,
@@ -1368,7 +1436,9 @@ func getDefaultConfigMap() map[string]interface{} {
 						"memory": 1024,
 						"disk":   20,
 					},
-					"max-traffic": 0,
+					"max-traffic":        0,
+					"max-snapshots":      2,
+					"max-firewall-rules": 10,
 				},
 				"3": map[string]interface{}{
 					"max-instances": 5,
@@ -1377,7 +1447,9 @@ func getDefaultConfigMap() map[string]interface{} {
 						"memory": 2048,
 						"disk":   40,
 					},
-					"max-traffic": 0,
+					"max-traffic":        0,
+					"max-snapshots":      3,
+					"max-firewall-rules": 20,
 				},
 				"4": map[string]interface{}{
 					"max-instances": 10,
@@ -1386,7 +1458,9 @@ func getDefaultConfigMap() map[string]interface{} {
 						"memory": 4096,
 						"disk":   80,
 					},
-					"max-traffic": 0,
+					"max-traffic":        0,
+					"max-snapshots":      5,
+					"max-firewall-rules": 30,
 				},
 				"5": map[string]interface{}{
 					"max-instances": 20,
@@ -1395,7 +1469,9 @@ func getDefaultConfigMap() map[string]interface{} {
 						"memory": 8192,
 						"disk":   160,
 					},
-					"max-traffic": 0,
+					"max-traffic":        0,
+					"max-snapshots":      10,
+					"max-firewall-rules": 50,
 				},
 			},
 		},
@@ -1440,6 +1516,14 @@ func getDefaultConfigMap() map[string]interface{} {
 			"max-avatar-size":  5.0,
 			"default-language": "zh",
 		},
+		"placement": map[string]interface{}{
+			"strategy":      "spread",
+			"cpu-weight":    1.0,
+			"memory-weight": 2.0,
+			"disk-weight":   1.0,
+			"queue-weight":  1.0,
+			"health-weight": 1.0,
+		},
 	}
 }
 
@@ -27,6 +27,8 @@ const (
 	ProviderTypeLXD     ProviderType = "lxd"
 	ProviderTypeIncus   ProviderType = "incus"
 	ProviderTypeProxmox ProviderType = "proxmox"
+	ProviderTypeLibvirt ProviderType = "libvirt"
+	ProviderTypePodman  ProviderType = "podman"
 )
 
 // Architecture 架构类型
@@ -44,6 +46,7 @@ const (
 	PortMappingMethodDeviceProxy PortMappingMethod = "device_proxy" // LXD/Incus使用的设备代理方式
 	PortMappingMethodIptables    PortMappingMethod = "iptables"     // 使用iptables进行端口映射
 	PortMappingMethodNative      PortMappingMethod = "native"       // 原生实现（Docker, Proxmox独立IP）
+	PortMappingMethodNftables    PortMappingMethod = "nftables"     // 使用nftables映射集合进行端口映射
 )
 
 // NetworkType 网络配置类型
@@ -83,6 +83,10 @@ func syncConfigToGlobal(key string, oldValue, newValue interface{}) error {
 		if paymentConfig, ok := newValue.(map[string]interface{}); ok {
 			syncPaymentConfig(paymentConfig)
 		}
+	case "placement":
+		if placementConfig, ok := newValue.(map[string]interface{}); ok {
+			syncPlacementConfig(placementConfig)
+		}
 	}
 	return nil
 }
@@ -194,6 +198,28 @@ func syncQuotaConfig(quotaConfig map[string]interface{}) {
 					levelLimit.MaxTraffic = int64(v)
 				}
 
+				if v, ok := limitMap["max-snapshots"].(float64); ok {
+					levelLimit.MaxSnapshots = int(v)
+				} else if v, ok := limitMap["max-snapshots"].(int); ok {
+					levelLimit.MaxSnapshots = v
+				}
+
+				if v, ok := limitMap["allow-user-data"].(bool); ok {
+					levelLimit.AllowUserData = v
+				}
+
+				if v, ok := limitMap["max-user-data-size"].(float64); ok {
+					levelLimit.MaxUserDataSize = int(v)
+				} else if v, ok := limitMap["max-user-data-size"].(int); ok {
+					levelLimit.MaxUserDataSize = v
+				}
+
+				if v, ok := limitMap["max-firewall-rules"].(float64); ok {
+					levelLimit.MaxFirewallRules = int(v)
+				} else if v, ok := limitMap["max-firewall-rules"].(int); ok {
+					levelLimit.MaxFirewallRules = v
+				}
+
 				global.APP_CONFIG.Quota.LevelLimits[level] = levelLimit
 			}
 		}
@@ -356,6 +382,30 @@ func syncOtherConfig(otherConfig map[string]interface{}) {
 	}
 }
 
+// syncPlacementConfig 同步自动调度配置
+func syncPlacementConfig(placementConfig map[string]interface{}) {
+	if v, ok := placementConfig["strategy"].(string); ok {
+		global.APP_CONFIG.Placement.Strategy = v
+	}
+	weights := map[string]*float64{
+		"cpu-weight":    &global.APP_CONFIG.Placement.CPUWeight,
+		"memory-weight": &global.APP_CONFIG.Placement.MemoryWeight,
+		"disk-weight":   &global.APP_CONFIG.Placement.DiskWeight,
+		"queue-weight":  &global.APP_CONFIG.Placement.QueueWeight,
+		"health-weight": &global.APP_CONFIG.Placement.HealthWeight,
+	}
+	for key, target := range weights {
+		switch v := placementConfig[key].(type) {
+		case float64:
+			*target = v
+		case int:
+			*target = float64(v)
+		case int64:
+			*target = float64(v)
+		}
+	}
+}
+
 // syncPaymentConfig 同步支付配置 - 只支持 kebab-case 格式
 func syncPaymentConfig(paymentConfig map[string]interface{}) {
 	// 支付宝配置
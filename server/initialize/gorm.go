@@ -116,18 +116,28 @@ func RegisterTables(db *gorm.DB) {
 		&authModel.Role{},     // 角色管理表
 		&userModel.UserRole{}, // 用户角色关联表
 		&userModel.APIKey{},   // API 密钥表
+		&userModel.SSHKey{},   // 用户SSH公钥表
 
 		// OAuth2相关表
 		&oauth2Model.OAuth2Provider{}, // OAuth2提供商配置表
 
 		// 实例相关表
-		&providerModel.Instance{}, // 虚拟机/容器实例表
-		&providerModel.Provider{}, // 服务提供商配置表
-		&providerModel.Port{},     // 端口映射表
-		&adminModel.Task{},        // 用户任务表
+		&providerModel.Instance{},                 // 虚拟机/容器实例表
+		&providerModel.Provider{},                 // 服务提供商配置表
+		&providerModel.Port{},                     // 端口映射表
+		&providerModel.InstanceSnapshot{},         // 实例快照表
+		&providerModel.InstanceBackup{},           // 实例备份表
+		&providerModel.InstanceBackupPolicy{},     // 实例定时备份策略表
+		&providerModel.InstanceDrift{},            // 实例状态漂移记录表
+		&providerModel.Volume{},                   // 数据卷表
+		&providerModel.PrivateNetwork{},           // 私有网络表
+		&providerModel.PrivateNetworkAttachment{}, // 私有网络接入表
+		&providerModel.FirewallRule{},             // 实例防火墙规则表
+		&adminModel.Task{},                        // 用户任务表
 
 		// 资源管理表
 		&resourceModel.ResourceReservation{}, // 资源预留表
+		&resourceModel.Flavor{},              // 实例规格模板表
 
 		// 认证相关表
 		&userModel.VerifyCode{},    // 验证码表（邮箱/短信）
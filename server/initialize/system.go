@@ -24,6 +24,8 @@ import (
 	_ "oneclickvirt/provider/portmapping/incus"
 	_ "oneclickvirt/provider/portmapping/iptables"
 	_ "oneclickvirt/provider/portmapping/lxd"
+	_ "oneclickvirt/provider/portmapping/nftables"
+	_ "oneclickvirt/provider/portmapping/podman"
 
 	"go.uber.org/zap"
 )
@@ -12,7 +12,9 @@ import (
 	_ "oneclickvirt/docs"
 	_ "oneclickvirt/provider/docker"
 	_ "oneclickvirt/provider/incus"
+	_ "oneclickvirt/provider/libvirt"
 	_ "oneclickvirt/provider/lxd"
+	_ "oneclickvirt/provider/podman"
 	_ "oneclickvirt/provider/proxmox"
 
 	"go.uber.org/zap"
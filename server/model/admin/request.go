@@ -1,6 +1,10 @@
 package admin
 
-import "oneclickvirt/model/common"
+import (
+	"time"
+
+	"oneclickvirt/model/common"
+)
 
 type CreateUserRequest struct {
 	Username      string `json:"username" binding:"required"`
@@ -46,7 +50,7 @@ type UserListRequest struct {
 
 type CreateProviderRequest struct {
 	Name                  string `json:"name" binding:"required"`
-	Type                  string `json:"type" binding:"required"`
+	Type                  string `json:"type" binding:"required"` // docker, podman, lxd, incus, proxmox, libvirt
 	Endpoint              string `json:"endpoint"`
 	PortIP                string `json:"portIP"` // 端口映射使用的公网IP
 	SSHPort               int    `json:"sshPort"`
@@ -101,8 +105,8 @@ type CreateProviderRequest struct {
 	TrafficAutoResetBatchSize  int    `json:"trafficAutoResetBatchSize"`  // 流量自动重置批量大小
 
 	// 端口映射方式配置
-	IPv4PortMappingMethod string `json:"ipv4PortMappingMethod"` // IPv4端口映射方式：device_proxy, iptables, native
-	IPv6PortMappingMethod string `json:"ipv6PortMappingMethod"` // IPv6端口映射方式：device_proxy, iptables, native
+	IPv4PortMappingMethod string `json:"ipv4PortMappingMethod"` // IPv4端口映射方式：device_proxy, iptables, nftables, native
+	IPv6PortMappingMethod string `json:"ipv6PortMappingMethod"` // IPv6端口映射方式：device_proxy, iptables, nftables, native
 	// SSH连接配置
 	SSHConnectTimeout int `json:"sshConnectTimeout"` // SSH连接超时时间（秒），默认30秒
 	SSHExecuteTimeout int `json:"sshExecuteTimeout"` // SSH命令执行超时时间（秒），默认300秒
@@ -114,6 +118,17 @@ type CreateProviderRequest struct {
 	VMLimitCpu    bool `json:"vmLimitCpu"`    // 虚拟机CPU是否计入总量预算
 	VMLimitMemory bool `json:"vmLimitMemory"` // 虚拟机内存是否计入总量预算
 	VMLimitDisk   bool `json:"vmLimitDisk"`   // 虚拟机硬盘是否计入总量预算
+	// 超分配比例（仅在对应资源计入总量预算时生效，0表示1.0）
+	ContainerCPURatio    float64 `json:"containerCpuRatio" binding:"min=0,max=100"`
+	ContainerMemoryRatio float64 `json:"containerMemoryRatio" binding:"min=0,max=100"`
+	ContainerDiskRatio   float64 `json:"containerDiskRatio" binding:"min=0,max=100"`
+	VMCPURatio           float64 `json:"vmCpuRatio" binding:"min=0,max=100"`
+	VMMemoryRatio        float64 `json:"vmMemoryRatio" binding:"min=0,max=100"`
+	VMDiskRatio          float64 `json:"vmDiskRatio" binding:"min=0,max=100"`
+	// 状态漂移自动修复策略
+	DriftFixGhost  bool `json:"driftFixGhost"`  // 自动清理节点上已不存在的实例记录
+	DriftFixStatus bool `json:"driftFixStatus"` // 自动以节点上的状态更新实例状态
+	DriftFixPort   bool `json:"driftFixPort"`   // 自动删除指向已删除实例的端口映射
 	// 容器特殊配置选项（仅 LXD/Incus 容器）
 	ContainerPrivileged   bool   `json:"containerPrivileged"`   // 是否启用特权容器
 	ContainerAllowNesting bool   `json:"containerAllowNesting"` // 是否允许嵌套虚拟化
@@ -122,6 +137,10 @@ type CreateProviderRequest struct {
 	ContainerMemorySwap   bool   `json:"containerMemorySwap"`   // 是否允许使用swap
 	ContainerMaxProcesses int    `json:"containerMaxProcesses"` // 最大进程数限制（0表示不限制）
 	ContainerDiskIOLimit  string `json:"containerDiskIoLimit"`  // 磁盘IO限制（如"10MB"或"100iops"）
+	// Podman运行模式（仅 Podman）：为空使用rootful模式，填写宿主机用户名则以该用户运行rootless容器
+	PodmanRootlessUser string `json:"podmanRootlessUser"`
+	// Proxmox集群新实例固定放置的节点，为空时自动选择负载最低的节点
+	ClusterPinnedNode string `json:"clusterPinnedNode" binding:"max=64"`
 
 	// 节点级别的等级限制配置
 	// 用于限制该节点上不同等级用户能创建的最大资源
@@ -186,8 +205,8 @@ type UpdateProviderRequest struct {
 	TrafficAutoResetBatchSize  int    `json:"trafficAutoResetBatchSize"`  // 流量自动重置批量大小
 
 	// 端口映射方式配置
-	IPv4PortMappingMethod string `json:"ipv4PortMappingMethod"` // IPv4端口映射方式：device_proxy, iptables, native
-	IPv6PortMappingMethod string `json:"ipv6PortMappingMethod"` // IPv6端口映射方式：device_proxy, iptables, native
+	IPv4PortMappingMethod string `json:"ipv4PortMappingMethod"` // IPv4端口映射方式：device_proxy, iptables, nftables, native
+	IPv6PortMappingMethod string `json:"ipv6PortMappingMethod"` // IPv6端口映射方式：device_proxy, iptables, nftables, native
 	// SSH连接配置
 	SSHConnectTimeout int `json:"sshConnectTimeout"` // SSH连接超时时间（秒），默认30秒
 	SSHExecuteTimeout int `json:"sshExecuteTimeout"` // SSH命令执行超时时间（秒），默认300秒
@@ -199,6 +218,17 @@ type UpdateProviderRequest struct {
 	VMLimitCpu    bool `json:"vmLimitCpu"`    // 虚拟机CPU是否计入总量预算
 	VMLimitMemory bool `json:"vmLimitMemory"` // 虚拟机内存是否计入总量预算
 	VMLimitDisk   bool `json:"vmLimitDisk"`   // 虚拟机硬盘是否计入总量预算
+	// 超分配比例（仅在对应资源计入总量预算时生效，0表示1.0）
+	ContainerCPURatio    float64 `json:"containerCpuRatio" binding:"min=0,max=100"`
+	ContainerMemoryRatio float64 `json:"containerMemoryRatio" binding:"min=0,max=100"`
+	ContainerDiskRatio   float64 `json:"containerDiskRatio" binding:"min=0,max=100"`
+	VMCPURatio           float64 `json:"vmCpuRatio" binding:"min=0,max=100"`
+	VMMemoryRatio        float64 `json:"vmMemoryRatio" binding:"min=0,max=100"`
+	VMDiskRatio          float64 `json:"vmDiskRatio" binding:"min=0,max=100"`
+	// 状态漂移自动修复策略
+	DriftFixGhost  bool `json:"driftFixGhost"`  // 自动清理节点上已不存在的实例记录
+	DriftFixStatus bool `json:"driftFixStatus"` // 自动以节点上的状态更新实例状态
+	DriftFixPort   bool `json:"driftFixPort"`   // 自动删除指向已删除实例的端口映射
 	// 容器特殊配置选项（仅 LXD/Incus 容器）
 	ContainerPrivileged   bool   `json:"containerPrivileged"`   // 是否启用特权容器
 	ContainerAllowNesting bool   `json:"containerAllowNesting"` // 是否允许嵌套虚拟化
@@ -207,6 +237,10 @@ type UpdateProviderRequest struct {
 	ContainerMemorySwap   bool   `json:"containerMemorySwap"`   // 是否允许使用swap
 	ContainerMaxProcesses int    `json:"containerMaxProcesses"` // 最大进程数限制（0表示不限制）
 	ContainerDiskIOLimit  string `json:"containerDiskIoLimit"`  // 磁盘IO限制（如"10MB"或"100iops"）
+	// Podman运行模式（仅 Podman）：为空使用rootful模式，填写宿主机用户名则以该用户运行rootless容器
+	PodmanRootlessUser string `json:"podmanRootlessUser"`
+	// Proxmox集群新实例固定放置的节点，为空时自动选择负载最低的节点
+	ClusterPinnedNode string `json:"clusterPinnedNode" binding:"max=64"`
 
 	// 节点级别的等级限制配置
 	// 用于限制该节点上不同等级用户能创建的最大资源
@@ -220,10 +254,47 @@ type ProviderListRequest struct {
 	Status string `json:"status" form:"status"`
 }
 
+// DriftListRequest 状态漂移报告查询请求
+type DriftListRequest struct {
+	common.PageInfo
+	ProviderID   uint   `json:"providerId" form:"providerId"`
+	Kind         string `json:"kind" form:"kind"`                 // ghost, orphan, status, resource, port
+	IncludeFixed bool   `json:"includeFixed" form:"includeFixed"` // 是否包含已修复的记录
+}
+
+// AdoptInstanceItem 导入单个未管理的实例，资源字段为0时使用从节点读取的值
+type AdoptInstanceItem struct {
+	Name      string     `json:"name" binding:"required"`   // 节点上的实例名称
+	UserID    uint       `json:"userId" binding:"required"` // 分配给的用户
+	CPU       int        `json:"cpu"`
+	Memory    int64      `json:"memory"`    // MB
+	Disk      int64      `json:"disk"`      // MB
+	Bandwidth int        `json:"bandwidth"` // Mbps，为0时使用用户等级的带宽限制
+	ExpiredAt *time.Time `json:"expiredAt"` // 为空时与节点到期时间同步
+}
+
+// AdoptInstancesRequest 批量导入节点上未管理的实例
+type AdoptInstancesRequest struct {
+	ProviderID uint                `json:"providerId" binding:"required"`
+	Instances  []AdoptInstanceItem `json:"instances" binding:"required,min=1,dive"`
+}
+
 type FreezeProviderRequest struct {
 	ID uint `json:"id" binding:"required"`
 }
 
+// EnterMaintenanceRequest 节点进入维护模式请求
+type EnterMaintenanceRequest struct {
+	ID       uint   `json:"id" binding:"required"`
+	Reason   string `json:"reason" binding:"max=255"` // 维护原因，会通知到受影响的用户
+	Evacuate bool   `json:"evacuate"`                 // 是否将节点上的实例迁移到同类型的其他节点
+}
+
+// ExitMaintenanceRequest 节点退出维护模式请求
+type ExitMaintenanceRequest struct {
+	ID uint `json:"id" binding:"required"`
+}
+
 type UnfreezeProviderRequest struct {
 	ID        uint   `json:"id" binding:"required"`
 	ExpiresAt string `json:"expiresAt"` // 新的过期时间，格式: "2006-01-02 15:04:05"
@@ -269,6 +340,7 @@ type CreateInstanceRequest struct {
 	Disk         int64  `json:"disk"`
 	InstanceType string `json:"instance_type"`
 	UserID       uint   `json:"userId"`
+	Node         string `json:"node"` // Proxmox集群中指定放置的节点，为空时按Provider配置自动选择
 }
 
 type UpdateInstanceRequest struct {
@@ -294,6 +366,25 @@ type InstanceActionRequest struct {
 	Action string `json:"action" binding:"required"`
 }
 
+// CreateSnapshotRequest 管理员创建实例快照请求
+type CreateSnapshotRequest struct {
+	Name        string `json:"name" binding:"required,max=40"`
+	Description string `json:"description" binding:"max=256"`
+}
+
+// ResizeInstanceRequest 管理员调整实例配置请求，传入调整后的完整配置
+type ResizeInstanceRequest struct {
+	CPU       int   `json:"cpu" binding:"required,min=1"`       // CPU核心数
+	Memory    int64 `json:"memory" binding:"required,min=64"`   // 内存大小（MB）
+	Disk      int64 `json:"disk" binding:"required,min=512"`    // 磁盘大小（MB），只能扩容
+	Bandwidth int   `json:"bandwidth" binding:"required,min=1"` // 带宽（Mbps）
+}
+
+// MigrateInstanceRequest 管理员迁移实例请求，目标节点必须与源节点类型相同
+type MigrateInstanceRequest struct {
+	TargetProviderID uint `json:"targetProviderId" binding:"required"` // 目标Provider ID
+}
+
 // ResetInstancePasswordRequest 管理员重置实例密码请求
 type ResetInstancePasswordRequest struct {
 	// 不需要传递任何参数，由后端自动生成新密码
@@ -434,6 +525,30 @@ type ProviderPortConfigRequest struct {
 	NetworkType      string `json:"networkType" binding:"oneof=nat_ipv4 nat_ipv4_ipv6 dedicated_ipv4 dedicated_ipv4_ipv6 ipv6_only"` // 网络配置类型
 }
 
+// FlavorRequest 创建或更新实例规格请求
+// CPU、内存、磁盘和带宽需为系统预定义规格中的取值
+type FlavorRequest struct {
+	Name          string   `json:"name" binding:"required,max=64"`
+	Description   string   `json:"description" binding:"max=255"`
+	CPU           int      `json:"cpu" binding:"required,min=1"`
+	Memory        int64    `json:"memory" binding:"required,min=1"`    // 内存(MB)
+	Disk          int64    `json:"disk" binding:"required,min=1"`      // 磁盘(MB)
+	Bandwidth     int      `json:"bandwidth" binding:"required,min=1"` // 带宽(Mbps)
+	Traffic       int64    `json:"traffic" binding:"min=0"`            // 实例流量限制(MB)，0表示继承用户等级限制
+	InstanceTypes []string `json:"instanceTypes" binding:"required,min=1,dive,oneof=container vm"`
+	ImageIDs      []uint   `json:"imageIds"`    // 允许的镜像ID，为空表示不限制
+	ProviderIDs   []uint   `json:"providerIds"` // 提供该规格的节点ID，为空表示所有节点
+	IsEnabled     bool     `json:"isEnabled"`
+	SortOrder     int      `json:"sortOrder"`
+}
+
+// FlavorListRequest 实例规格列表请求
+type FlavorListRequest struct {
+	Name         string `json:"name" form:"name"`
+	InstanceType string `json:"instanceType" form:"instanceType"`
+	Enabled      string `json:"enabled" form:"enabled"` // true/false，为空表示全部
+}
+
 // CreateInstanceTaskRequest 创建实例任务数据结构
 type CreateInstanceTaskRequest struct {
 	ProviderId  uint   `json:"providerId"`
@@ -444,6 +559,11 @@ type CreateInstanceTaskRequest struct {
 	BandwidthId string `json:"bandwidthId"`
 	Description string `json:"description"`
 	SessionId   string `json:"sessionId"` // 会话ID，用于新的资源预留机制
+
+	SSHKeyIDs            []uint `json:"sshKeyIds,omitempty"`            // 注入到实例的用户SSH公钥ID
+	DisablePasswordLogin bool   `json:"disablePasswordLogin,omitempty"` // 是否禁用SSH密码登录
+	UserData             string `json:"userData,omitempty"`             // cloud-init user-data或首次启动脚本
+	FlavorID             uint   `json:"flavorId,omitempty"`             // 选择的实例规格ID
 }
 
 // InstanceOperationTaskRequest 实例操作任务数据结构（启动、停止、重启、重置）
@@ -465,6 +585,85 @@ type ResetPasswordTaskRequest struct {
 	ProviderId uint `json:"providerId"`
 }
 
+// ResizeTaskRequest 调整实例配置任务数据结构
+type ResizeTaskRequest struct {
+	InstanceId     uint   `json:"instanceId"`
+	ProviderId     uint   `json:"providerId"`
+	CPU            int    `json:"cpu"`
+	Memory         int64  `json:"memory"`
+	Disk           int64  `json:"disk"`
+	Bandwidth      int    `json:"bandwidth"`
+	OriginalStatus string `json:"originalStatus"` // 调整前的实例状态
+}
+
+// ReinstallTaskRequest 重装系统任务数据结构
+type ReinstallTaskRequest struct {
+	InstanceId     uint   `json:"instanceId"`
+	ProviderId     uint   `json:"providerId"`
+	SystemImageId  uint   `json:"systemImageId"`  // 目标系统镜像ID
+	OriginalStatus string `json:"originalStatus"` // 重装前的实例状态
+
+	SSHKeyIDs            []uint `json:"sshKeyIds,omitempty"`            // 注入到实例的用户SSH公钥ID
+	DisablePasswordLogin bool   `json:"disablePasswordLogin,omitempty"` // 是否禁用SSH密码登录
+	UserData             string `json:"userData,omitempty"`             // cloud-init user-data或首次启动脚本
+}
+
+// MigrateTaskRequest 迁移实例任务数据结构
+type MigrateTaskRequest struct {
+	InstanceId       uint   `json:"instanceId"`
+	SourceProviderId uint   `json:"sourceProviderId"`
+	TargetProviderId uint   `json:"targetProviderId"`
+	OriginalStatus   string `json:"originalStatus"` // 迁移前的实例状态
+}
+
+// SnapshotTaskRequest 快照任务数据结构（创建、恢复、删除快照共用）
+type SnapshotTaskRequest struct {
+	InstanceId     uint   `json:"instanceId"`
+	ProviderId     uint   `json:"providerId"`
+	SnapshotId     uint   `json:"snapshotId"`
+	OriginalStatus string `json:"originalStatus,omitempty"` // 恢复快照前的实例状态
+}
+
+// BackupTaskRequest 备份任务数据结构（创建、恢复备份共用）
+type BackupTaskRequest struct {
+	InstanceId     uint   `json:"instanceId"`
+	ProviderId     uint   `json:"providerId"`
+	BackupId       uint   `json:"backupId"`
+	OriginalStatus string `json:"originalStatus,omitempty"` // 恢复备份前的实例状态
+}
+
+// VolumeTaskRequest 数据卷任务数据结构（创建、挂载、卸载、调整容量、删除共用）
+type VolumeTaskRequest struct {
+	VolumeId   uint   `json:"volumeId"`
+	ProviderId uint   `json:"providerId"`
+	InstanceId uint   `json:"instanceId,omitempty"` // 挂载目标实例，卸载和调整容量时为当前挂载的实例
+	SizeMB     int64  `json:"sizeMb,omitempty"`     // 调整容量的目标大小
+	MountPath  string `json:"mountPath,omitempty"`  // 挂载到容器的路径
+}
+
+// PrivateNetworkTaskRequest 私有网络任务数据结构（创建、删除、接入、断开共用）
+type PrivateNetworkTaskRequest struct {
+	NetworkId    uint `json:"networkId"`
+	ProviderId   uint `json:"providerId"`
+	AttachmentId uint `json:"attachmentId,omitempty"` // 接入和断开时的接入记录
+	InstanceId   uint `json:"instanceId,omitempty"`   // 接入和断开的实例
+}
+
+// FirewallTaskRequest 应用实例防火墙规则任务数据结构
+type FirewallTaskRequest struct {
+	InstanceId uint `json:"instanceId"`
+	ProviderId uint `json:"providerId"`
+}
+
+// VolumeListRequest 管理员数据卷列表查询请求
+type VolumeListRequest struct {
+	common.PageInfo
+	ProviderID uint   `json:"providerId" form:"providerId"`
+	UserID     uint   `json:"userId" form:"userId"`
+	InstanceID uint   `json:"instanceId" form:"instanceId"`
+	Status     string `json:"status" form:"status"`
+}
+
 // CreatePortMappingTaskRequest 创建端口映射任务数据结构
 type CreatePortMappingTaskRequest struct {
 	PortID       uint   `json:"portId"`       // 端口映射ID
@@ -58,6 +58,22 @@ type ProviderManageResponse struct {
 	CurrentVMCount        int `json:"currentVMCount"`        // 当前虚拟机实例数量
 	// 流量使用情况
 	UsedTraffic int64 `json:"usedTraffic"` // 已使用流量（MB）
+	// 超分配后的有效容量（物理容量见NodeCPUCores等字段）
+	ContainerCapacity ProviderCapacityInfo `json:"containerCapacity"`
+	VMCapacity        ProviderCapacityInfo `json:"vmCapacity"`
+}
+
+// ProviderCapacityInfo 节点某一实例类型的超分配比例和有效容量
+type ProviderCapacityInfo struct {
+	LimitCPU          bool    `json:"limitCpu"` // 是否计入总量预算，为false时不限制
+	LimitMemory       bool    `json:"limitMemory"`
+	LimitDisk         bool    `json:"limitDisk"`
+	CPURatio          float64 `json:"cpuRatio"`
+	MemoryRatio       float64 `json:"memoryRatio"`
+	DiskRatio         float64 `json:"diskRatio"`
+	EffectiveCPUCores int     `json:"effectiveCpuCores"` // 物理核心数乘以超分配比例
+	EffectiveMemory   int64   `json:"effectiveMemory"`   // MB
+	EffectiveDisk     int64   `json:"effectiveDisk"`     // MB
 }
 
 type InviteCodeResponse struct {
@@ -103,6 +119,9 @@ type ProviderStatusResponse struct {
 	NodeDiskTotal    int64      `json:"nodeDiskTotal"`
 	ResourceSynced   bool       `json:"resourceSynced"`
 	ResourceSyncedAt *time.Time `json:"resourceSyncedAt"`
+	// 超分配后的有效容量
+	ContainerCapacity ProviderCapacityInfo `json:"containerCapacity"`
+	VMCapacity        ProviderCapacityInfo `json:"vmCapacity"`
 }
 
 // ConfigurationTaskResponse 配置任务响应
@@ -191,3 +210,100 @@ type TestSSHConnectionResponse struct {
 	TestCount          int    `json:"testCount"`              // 测试次数
 	ErrorMessage       string `json:"errorMessage,omitempty"` // 错误信息（如果失败）
 }
+
+// MaintenanceMigrationItem 维护期间创建的迁移任务
+type MaintenanceMigrationItem struct {
+	TaskID           uint   `json:"taskId"`
+	InstanceID       uint   `json:"instanceId"`
+	InstanceName     string `json:"instanceName"`
+	TargetProviderID uint   `json:"targetProviderId"`
+	Status           string `json:"status"`
+	Progress         int    `json:"progress"`
+	ErrorMessage     string `json:"errorMessage,omitempty"`
+}
+
+// MaintenanceStatusResponse 节点维护进度
+type MaintenanceStatusResponse struct {
+	ProviderID          uint                       `json:"providerId"`
+	ProviderName        string                     `json:"providerName"`
+	MaintenanceMode     bool                       `json:"maintenanceMode"`
+	Reason              string                     `json:"reason"`
+	StartedAt           *time.Time                 `json:"startedAt"`
+	RemainingInstances  int64                      `json:"remainingInstances"` // 节点上尚未迁出的实例数
+	Unscheduled         int64                      `json:"unscheduled"`        // 没有进行中迁移任务的剩余实例数
+	PendingMigrations   int                        `json:"pendingMigrations"`  // 排队或执行中的迁移任务数
+	CompletedMigrations int                        `json:"completedMigrations"`
+	FailedMigrations    int                        `json:"failedMigrations"`
+	Empty               bool                       `json:"empty"` // 节点已清空，可以安全维护
+	Migrations          []MaintenanceMigrationItem `json:"migrations"`
+}
+
+// EvacuationSkippedItem 未能安排迁移的实例及原因
+type EvacuationSkippedItem struct {
+	InstanceID   uint   `json:"instanceId"`
+	InstanceName string `json:"instanceName"`
+	Reason       string `json:"reason"`
+}
+
+// EvacuationResult 批量迁移节点实例的结果
+type EvacuationResult struct {
+	Queued  []MaintenanceMigrationItem `json:"queued"`
+	Skipped []EvacuationSkippedItem    `json:"skipped"`
+}
+
+// DriftReconcileResult 单个节点的状态漂移检查结果
+type DriftReconcileResult struct {
+	ProviderID    uint           `json:"providerId"`
+	ProviderName  string         `json:"providerName"`
+	CheckedAt     time.Time      `json:"checkedAt"`
+	HostInstances int            `json:"hostInstances"` // 节点上的实例数
+	DBInstances   int            `json:"dbInstances"`   // 数据库中该节点的实例数
+	Counts        map[string]int `json:"counts"`        // 本次发现的各类型漂移数量
+	AutoFixed     int            `json:"autoFixed"`     // 本次按节点策略自动修复的数量
+}
+
+// UnmanagedPort 从节点上发现的实例端口转发
+type UnmanagedPort struct {
+	HostPort     int    `json:"hostPort"`
+	HostPortEnd  int    `json:"hostPortEnd"`
+	GuestPort    int    `json:"guestPort"`
+	GuestPortEnd int    `json:"guestPortEnd"`
+	Protocol     string `json:"protocol"` // tcp, udp, both
+	Source       string `json:"source"`   // device(proxy设备或端口绑定), iptables(DNAT规则)
+}
+
+// UnmanagedInstance 节点上存在但面板中没有记录的实例
+type UnmanagedInstance struct {
+	Name         string          `json:"name"`
+	Status       string          `json:"status"`
+	InstanceType string          `json:"instanceType"`
+	Image        string          `json:"image"`
+	CPU          int             `json:"cpu"`
+	Memory       int64           `json:"memory"`
+	Disk         int64           `json:"disk"`
+	PrivateIP    string          `json:"privateIP"`
+	IPv6Address  string          `json:"ipv6Address"`
+	Ports        []UnmanagedPort `json:"ports"`
+	Inspected    bool            `json:"inspected"`              // 是否读取到了实例的实际配置
+	InspectError string          `json:"inspectError,omitempty"` // 读取实例配置失败的原因
+}
+
+// ProviderImportResult 节点清单中单行的导入结果
+type ProviderImportResult struct {
+	Row            int    `json:"row"` // 清单中的行号，从1开始
+	Name           string `json:"name"`
+	ProviderID     uint   `json:"providerId,omitempty"`
+	Action         string `json:"action"`                   // created(新建), existing(已存在，未修改), failed(失败)
+	SSHLatency     int64  `json:"sshLatency,omitempty"`     // SSH连接平均延迟(ms)
+	Configured     bool   `json:"configured"`               // 是否已完成自动配置
+	Error          string `json:"error,omitempty"`          // 校验、连接或创建失败的原因
+	ConfigureError string `json:"configureError,omitempty"` // 节点已创建但自动配置失败的原因
+}
+
+// AdoptInstanceResult 单个实例的导入结果
+type AdoptInstanceResult struct {
+	Name       string `json:"name"`
+	InstanceID uint   `json:"instanceId,omitempty"`
+	PortCount  int    `json:"portCount"`
+	Error      string `json:"error,omitempty"`
+}
@@ -40,7 +40,15 @@ type QuotaConfig struct {
 type LevelLimitInfo struct {
 	MaxInstances int                    `json:"maxInstances"`
 	MaxResources map[string]interface{} `json:"maxResources"`
-	MaxTraffic   int64                  `json:"maxTraffic"` // 最大流量限制(MB)
+	MaxTraffic   int64                  `json:"maxTraffic"`   // 最大流量限制(MB)
+	MaxSnapshots int                    `json:"maxSnapshots"` // 每个实例最大快照数量
+
+	// user-data
+	AllowUserData   bool `json:"allowUserData"`   // 是否允许提供cloud-init user-data
+	MaxUserDataSize int  `json:"maxUserDataSize"` // user-data最大大小(KB)
+
+	// firewall
+	MaxFirewallRules int `json:"maxFirewallRules"` // 每个实例最大防火墙规则数量
 }
 
 // DatabaseConfig 数据库初始化配置
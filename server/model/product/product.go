@@ -25,6 +25,7 @@ type Product struct {
 	AllowRepeat  int       `json:"allowRepeat" gorm:"column:allow_repeat;default:1;comment:是否允许重复购买(1:允许, 0:不允许)"`
 	Stock        int       `json:"stock" gorm:"column:stock;default:-1;comment:库存量(-1表示无限)"`
 	SoldCount    int       `json:"soldCount" gorm:"column:sold_count;default:0;comment:已售数量"`
+	FlavorID     uint      `json:"flavorId" gorm:"column:flavor_id;default:0;index;comment:关联的实例规格ID(0表示不关联)"`
 	CreatedAt    time.Time `json:"createdAt" gorm:"autoCreateTime"`
 	UpdatedAt    time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
 }
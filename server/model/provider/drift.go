@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"fmt"
+	"time"
+)
+
+// 状态漂移类型
+const (
+	DriftKindGhost    = "ghost"    // 数据库中存在但节点上已不存在的实例
+	DriftKindOrphan   = "orphan"   // 节点上存在但数据库中没有记录的实例
+	DriftKindStatus   = "status"   // 数据库状态与节点上的实际状态不一致
+	DriftKindResource = "resource" // 数据库配置与节点上报告的资源不一致
+	DriftKindPort     = "port"     // 端口映射指向已删除或不存在的实例
+)
+
+// InstanceDrift 实例状态漂移记录
+// 每次检查时未再出现的未修复记录会被删除，连续多次出现的记录才会按节点策略自动修复
+type InstanceDrift struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	ProviderID   uint   `json:"providerId" gorm:"not null;index:idx_drift_provider_kind,priority:1"`
+	Kind         string `json:"kind" gorm:"size:16;not null;index:idx_drift_provider_kind,priority:2"` // ghost, orphan, status, resource, port
+	InstanceID   uint   `json:"instanceId" gorm:"index"`                                               // 数据库实例ID，节点上的孤儿实例为0
+	InstanceName string `json:"instanceName" gorm:"size:128"`                                          // 实例名称
+	PortID       uint   `json:"portId"`                                                                // 端口漂移对应的端口映射ID
+	Expected     string `json:"expected" gorm:"size:128"`                                              // 数据库中的值
+	Actual       string `json:"actual" gorm:"size:128"`                                                // 节点上的值
+	Detail       string `json:"detail" gorm:"size:255"`                                                // 说明
+
+	FirstSeenAt time.Time  `json:"firstSeenAt"`                // 首次发现时间
+	LastSeenAt  time.Time  `json:"lastSeenAt"`                 // 最近一次发现时间
+	SeenCount   int        `json:"seenCount" gorm:"default:1"` // 连续发现次数
+	FixedAt     *time.Time `json:"fixedAt" gorm:"index"`       // 修复时间，为空表示未修复
+	FixAction   string     `json:"fixAction" gorm:"size:64"`   // 执行的修复操作
+	FixedBy     string     `json:"fixedBy" gorm:"size:16"`     // auto或manual
+}
+
+// TableName 指定表名
+func (InstanceDrift) TableName() string {
+	return "instance_drifts"
+}
+
+// DriftKey 同一漂移在多次检查之间的唯一标识
+func (d *InstanceDrift) DriftKey() string {
+	return fmt.Sprintf("%s|%s|%d|%d", d.Kind, d.InstanceName, d.InstanceID, d.PortID)
+}
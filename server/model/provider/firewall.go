@@ -0,0 +1,42 @@
+package provider
+
+import "time"
+
+// 防火墙规则动作
+const (
+	FirewallActionAllow = "allow"
+	FirewallActionDeny  = "deny"
+)
+
+// 防火墙规则协议
+const (
+	FirewallProtocolTCP  = "tcp"
+	FirewallProtocolUDP  = "udp"
+	FirewallProtocolICMP = "icmp"
+	FirewallProtocolAll  = "all"
+)
+
+// 防火墙规则IP版本
+const (
+	FirewallIPv4 = "ipv4"
+	FirewallIPv6 = "ipv6"
+)
+
+// FirewallRule 用户为实例配置的入站防火墙规则，在宿主机上按Priority从小到大依次匹配，首条命中的规则生效
+type FirewallRule struct {
+	// 基础字段
+	ID        uint      `json:"id" gorm:"primarykey"` // 规则主键ID
+	CreatedAt time.Time `json:"createdAt"`            // 创建时间
+	UpdatedAt time.Time `json:"updatedAt"`            // 更新时间
+
+	// 规则信息
+	InstanceID  uint   `json:"instanceId" gorm:"index:idx_firewall_instance;not null"` // 关联的实例ID
+	Priority    int    `json:"priority" gorm:"default:0"`                              // 匹配顺序，数值越小越先匹配
+	Action      string `json:"action" gorm:"size:8;not null"`                          // 动作：allow, deny
+	Protocol    string `json:"protocol" gorm:"size:8;not null"`                        // 协议：tcp, udp, icmp, all
+	PortStart   int    `json:"portStart" gorm:"default:0"`                             // 起始端口，0表示所有端口（仅tcp/udp有效）
+	PortEnd     int    `json:"portEnd" gorm:"default:0"`                               // 结束端口，与起始端口相同表示单个端口
+	SourceCIDR  string `json:"sourceCidr" gorm:"size:64"`                              // 来源网段，为空表示任意来源
+	IPVersion   string `json:"ipVersion" gorm:"size:8;default:ipv4"`                   // IP版本：ipv4, ipv6
+	Description string `json:"description" gorm:"size:128"`                            // 规则备注
+}
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 私有网络状态
+const (
+	PrivateNetworkStatusCreating = "creating"
+	PrivateNetworkStatusActive   = "active"
+	PrivateNetworkStatusDeleting = "deleting"
+	PrivateNetworkStatusFailed   = "failed"
+)
+
+// 私有网络接入状态
+const (
+	NetworkAttachmentStatusAttaching = "attaching"
+	NetworkAttachmentStatusAttached  = "attached"
+	NetworkAttachmentStatusDetaching = "detaching"
+)
+
+// PrivateNetwork 用户在同一节点上的实例之间的隔离二层网络
+type PrivateNetwork struct {
+	// 基础字段
+	ID        uint           `json:"id" gorm:"primarykey"` // 私有网络主键ID
+	CreatedAt time.Time      `json:"createdAt"`            // 创建时间
+	UpdatedAt time.Time      `json:"updatedAt"`            // 更新时间
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`       // 软删除时间
+
+	// 网络信息
+	Name       string `json:"name" gorm:"not null;size:64"`                         // 网络名称（用户可见）
+	ProviderID uint   `json:"providerId" gorm:"index:idx_private_network_provider"` // 所在的Provider ID
+	UserID     uint   `json:"userId" gorm:"index:idx_private_network_user"`         // 所属用户ID
+	CIDR       string `json:"cidr" gorm:"size:32;not null"`                         // 网段，如 10.10.0.0/24
+	VlanID     int    `json:"vlanId" gorm:"default:0"`                              // VLAN标签，仅Proxmox使用
+	Status     string `json:"status" gorm:"default:creating;size:16;index"`         // 状态：creating, active, deleting, failed
+
+	// 关联
+	Attachments []PrivateNetworkAttachment `json:"attachments,omitempty" gorm:"foreignKey:NetworkID"` // 接入的实例
+}
+
+// PrivateNetworkNamePrefix 私有网络在Provider上的名称前缀
+const PrivateNetworkNamePrefix = "ocvnet"
+
+// ProviderNetworkName 私有网络在Provider上的名称，LXD网络名最长15个字符
+func (n *PrivateNetwork) ProviderNetworkName() string {
+	return fmt.Sprintf("%s%d", PrivateNetworkNamePrefix, n.ID)
+}
+
+// PrivateNetworkAttachment 实例接入私有网络的记录，一个实例在同一网络中只有一个地址
+type PrivateNetworkAttachment struct {
+	ID        uint      `json:"id" gorm:"primarykey"` // 接入记录主键ID
+	CreatedAt time.Time `json:"createdAt"`            // 创建时间
+	UpdatedAt time.Time `json:"updatedAt"`            // 更新时间
+
+	NetworkID  uint   `json:"networkId" gorm:"uniqueIndex:idx_network_instance;uniqueIndex:idx_network_ip"` // 私有网络ID
+	InstanceID uint   `json:"instanceId" gorm:"uniqueIndex:idx_network_instance;index"`                     // 实例ID
+	IPAddress  string `json:"ipAddress" gorm:"size:64;uniqueIndex:idx_network_ip"`                          // 实例在私有网络中的地址
+	Device     string `json:"device" gorm:"size:32"`                                                        // 实例上的网卡设备名
+	Status     string `json:"status" gorm:"default:attaching;size:16"`                                      // 状态：attaching, attached, detaching
+}
@@ -1,6 +1,9 @@
 package provider
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -110,8 +113,8 @@ type Provider struct {
 	AllowClaim            bool   `json:"allowClaim" gorm:"default:true"`        // 是否允许用户使用此Provider
 
 	// 端口映射配置
-	IPv4PortMappingMethod string `json:"ipv4PortMappingMethod" gorm:"size:16;default:device_proxy"` // IPv4端口映射方式：device_proxy, iptables, native
-	IPv6PortMappingMethod string `json:"ipv6PortMappingMethod" gorm:"size:16;default:device_proxy"` // IPv6端口映射方式：device_proxy, iptables, native
+	IPv4PortMappingMethod string `json:"ipv4PortMappingMethod" gorm:"size:16;default:device_proxy"` // IPv4端口映射方式：device_proxy, iptables, nftables, native
+	IPv6PortMappingMethod string `json:"ipv6PortMappingMethod" gorm:"size:16;default:device_proxy"` // IPv6端口映射方式：device_proxy, iptables, nftables, native
 
 	// 配额管理
 	UsedQuota    int        `json:"usedQuota" gorm:"default:0"`                // 已使用配额（传统字段，兼容性保留）
@@ -120,6 +123,18 @@ type Provider struct {
 	ExpiresAt    *time.Time `json:"expiresAt" gorm:"index;column:expires_at"`  // Provider过期时间
 	IsFrozen     bool       `json:"isFrozen" gorm:"default:false"`             // 是否被冻结（冻结后无法使用）
 
+	// 维护模式：禁止申领新实例，可选将现有实例迁移到同类型的其他节点
+	MaintenanceMode       bool       `json:"maintenanceMode" gorm:"default:false"` // 是否处于维护模式
+	MaintenanceReason     string     `json:"maintenanceReason" gorm:"size:255"`    // 维护原因，通知用户时使用
+	MaintenanceStartedAt  *time.Time `json:"maintenanceStartedAt"`                 // 进入维护模式的时间，用于统计本次维护的迁移任务
+	MaintenanceAllowClaim bool       `json:"-" gorm:"default:false"`               // 进入维护前的allow_claim，退出维护时恢复
+
+	// 状态漂移检查：定期对比节点上的实例与数据库记录，自动修复策略默认关闭，仅生成报告
+	DriftFixGhost  bool       `json:"driftFixGhost" gorm:"default:false"`  // 自动清理节点上已不存在的实例记录
+	DriftFixStatus bool       `json:"driftFixStatus" gorm:"default:false"` // 自动以节点上的状态更新实例状态
+	DriftFixPort   bool       `json:"driftFixPort" gorm:"default:false"`   // 自动删除指向已删除实例的端口映射
+	DriftCheckedAt *time.Time `json:"driftCheckedAt"`                      // 最近一次漂移检查时间
+
 	// 存储配置（所有Provider类型通用）
 	StoragePool     string `json:"storagePool" gorm:"size:64;default:local"`   // 存储池名称，用于存储虚拟机磁盘和容器
 	StoragePoolPath string `json:"storagePoolPath" gorm:"size:255;default:''"` // 存储池实际挂载路径，用于准确获取硬盘大小
@@ -155,6 +170,9 @@ type Provider struct {
 	NodeMemoryTotal int64 `json:"nodeMemoryTotal" gorm:"default:0"` // 节点总内存大小（MB）
 	NodeDiskTotal   int64 `json:"nodeDiskTotal" gorm:"default:0"`   // 节点总磁盘空间（MB）
 
+	// 节点能力文档（连接和健康检查时由Provider探测）
+	Capabilities string `json:"capabilities" gorm:"type:text"` // JSON格式: ProviderCapabilities，为空表示尚未探测
+
 	// 并发控制配置
 	AllowConcurrentTasks bool `json:"allowConcurrentTasks" gorm:"default:false"` // 是否允许并发执行任务
 	MaxConcurrentTasks   int  `json:"maxConcurrentTasks" gorm:"default:1"`       // 最大并发任务数量
@@ -188,6 +206,15 @@ type Provider struct {
 	VMLimitMemory bool `json:"vmLimitMemory" gorm:"default:true"` // 虚拟机内存是否计入Provider总量预算，默认true（严格限制）
 	VMLimitDisk   bool `json:"vmLimitDisk" gorm:"default:true"`   // 虚拟机硬盘是否计入Provider总量预算，默认true（严格限制）
 
+	// 超分配比例（仅在对应资源计入总量预算时生效）
+	// 该类型实例可分配的容量为物理容量乘以比例，例如容器CPU为4.0表示容器最多可分配4倍物理核心数，0按1.0处理
+	ContainerCPURatio    float64 `json:"containerCpuRatio" gorm:"default:1"`    // 容器CPU超分配比例
+	ContainerMemoryRatio float64 `json:"containerMemoryRatio" gorm:"default:1"` // 容器内存超分配比例
+	ContainerDiskRatio   float64 `json:"containerDiskRatio" gorm:"default:1"`   // 容器硬盘超分配比例
+	VMCPURatio           float64 `json:"vmCpuRatio" gorm:"default:1"`           // 虚拟机CPU超分配比例
+	VMMemoryRatio        float64 `json:"vmMemoryRatio" gorm:"default:1"`        // 虚拟机内存超分配比例
+	VMDiskRatio          float64 `json:"vmDiskRatio" gorm:"default:1"`          // 虚拟机硬盘超分配比例
+
 	// 端口映射配置
 	DefaultPortCount  int    `json:"defaultPortCount" gorm:"default:10"`                   // 每个实例默认映射端口数量
 	PortRangeStart    int    `json:"portRangeStart" gorm:"default:10000"`                  // 端口映射范围起始
@@ -228,6 +255,14 @@ type Provider struct {
 	ResourceSyncedAt *time.Time `json:"resourceSyncedAt"`                    // 资源信息最后同步时间
 	CountCacheExpiry *time.Time `json:"countCacheExpiry"`                    // 数量缓存过期时间（避免频繁查询数据库）
 
+	// 按实例类型拆分的资源占用（仅统计计入总量预算的资源），分别与该类型超分配后的容量比较
+	ContainerUsedCPUCores int   `json:"containerUsedCpuCores" gorm:"default:0"` // 容器已占用的CPU核心数
+	ContainerUsedMemory   int64 `json:"containerUsedMemory" gorm:"default:0"`   // 容器已占用的内存大小（MB）
+	ContainerUsedDisk     int64 `json:"containerUsedDisk" gorm:"default:0"`     // 容器已占用的磁盘空间（MB）
+	VMUsedCPUCores        int   `json:"vmUsedCpuCores" gorm:"default:0"`        // 虚拟机已占用的CPU核心数
+	VMUsedMemory          int64 `json:"vmUsedMemory" gorm:"default:0"`          // 虚拟机已占用的内存大小（MB）
+	VMUsedDisk            int64 `json:"vmUsedDisk" gorm:"default:0"`            // 虚拟机已占用的磁盘空间（MB）
+
 	// 可用资源统计（动态计算得出）
 	AvailableCPUCores int   `json:"availableCpuCores" gorm:"default:0"` // 可用的CPU核心数（NodeCPUCores - UsedCPUCores）
 	AvailableMemory   int64 `json:"availableMemory" gorm:"default:0"`   // 可用的内存大小（NodeMemoryTotal - UsedMemory）
@@ -249,6 +284,12 @@ type Provider struct {
 	ContainerMemorySwap   bool   `json:"containerMemorySwap" gorm:"default:true"`           // 内存交换：允许使用swap空间
 	ContainerMaxProcesses int    `json:"containerMaxProcesses" gorm:"default:0"`            // 最大进程数：0表示不限制
 	ContainerDiskIOLimit  string `json:"containerDiskIoLimit" gorm:"size:32"`               // 磁盘IO限制：例如 "10MB" 或 "100iops"
+
+	// Podman运行模式：为空时以root身份运行（rootful），否则以该宿主机用户运行rootless容器
+	PodmanRootlessUser string `json:"podmanRootlessUser" gorm:"size:32"`
+
+	// Proxmox集群：新实例固定放置的节点，为空时自动选择负载最低的在线节点
+	ClusterPinnedNode string `json:"clusterPinnedNode" gorm:"size:64"`
 }
 
 func (p *Provider) BeforeCreate(tx *gorm.DB) error {
@@ -307,6 +348,127 @@ func (p *Provider) GetAuthMethod() string {
 	return "password"
 }
 
+// MaxOvercommitRatio 超分配比例上限
+const MaxOvercommitRatio = 100
+
+// ResourceLimits 返回指定实例类型的CPU、内存、硬盘是否计入总量预算
+func (p *Provider) ResourceLimits(instanceType string) (cpu, memory, disk bool) {
+	if instanceType == "vm" {
+		return p.VMLimitCPU, p.VMLimitMemory, p.VMLimitDisk
+	}
+	return p.ContainerLimitCPU, p.ContainerLimitMemory, p.ContainerLimitDisk
+}
+
+// OvercommitRatios 返回指定实例类型的CPU、内存、硬盘超分配比例，未设置时为1.0
+func (p *Provider) OvercommitRatios(instanceType string) (cpu, memory, disk float64) {
+	if instanceType == "vm" {
+		cpu, memory, disk = p.VMCPURatio, p.VMMemoryRatio, p.VMDiskRatio
+	} else {
+		cpu, memory, disk = p.ContainerCPURatio, p.ContainerMemoryRatio, p.ContainerDiskRatio
+	}
+	return normalizeOvercommitRatio(cpu), normalizeOvercommitRatio(memory), normalizeOvercommitRatio(disk)
+}
+
+// EffectiveCapacity 返回指定实例类型按超分配比例换算后的可分配总量
+func (p *Provider) EffectiveCapacity(instanceType string) (cpu int, memory, disk int64) {
+	cpuRatio, memoryRatio, diskRatio := p.OvercommitRatios(instanceType)
+	cpu = int(float64(p.NodeCPUCores) * cpuRatio)
+	memory = int64(float64(p.NodeMemoryTotal) * memoryRatio)
+	disk = int64(float64(p.NodeDiskTotal) * diskRatio)
+	return cpu, memory, disk
+}
+
+// UsedResources 返回指定实例类型已计入总量预算的CPU、内存、硬盘占用
+func (p *Provider) UsedResources(instanceType string) (cpu int, memory, disk int64) {
+	if instanceType == "vm" {
+		return p.VMUsedCPUCores, p.VMUsedMemory, p.VMUsedDisk
+	}
+	return p.ContainerUsedCPUCores, p.ContainerUsedMemory, p.ContainerUsedDisk
+}
+
+// PhysicalUsage 返回两种实例类型的占用按各自超分配比例折算回物理资源后的合计
+// 例如内存超分配比例为1.5的容器占用3GB，折算为2GB物理内存
+func (p *Provider) PhysicalUsage() (cpu, memory, disk float64) {
+	for _, instanceType := range []string{"container", "vm"} {
+		cpuRatio, memoryRatio, diskRatio := p.OvercommitRatios(instanceType)
+		usedCPU, usedMemory, usedDisk := p.UsedResources(instanceType)
+		cpu += float64(usedCPU) / cpuRatio
+		memory += float64(usedMemory) / memoryRatio
+		disk += float64(usedDisk) / diskRatio
+	}
+	return cpu, memory, disk
+}
+
+// AvailableResources 返回指定实例类型还可分配的CPU、内存、硬盘
+// 取该类型超分配容量减去自身占用，与剩余物理资源按该类型比例换算后的较小值，
+// 避免虚拟机和容器各自未超出预算但合计超出节点物理容量
+func (p *Provider) AvailableResources(instanceType string) (cpu int, memory, disk int64) {
+	capacityCPU, capacityMemory, capacityDisk := p.EffectiveCapacity(instanceType)
+	usedCPU, usedMemory, usedDisk := p.UsedResources(instanceType)
+	cpuRatio, memoryRatio, diskRatio := p.OvercommitRatios(instanceType)
+	physicalCPU, physicalMemory, physicalDisk := p.PhysicalUsage()
+
+	cpu = min(capacityCPU-usedCPU, int(physicalHeadroom(float64(p.NodeCPUCores), physicalCPU, cpuRatio)))
+	memory = min(capacityMemory-usedMemory, physicalHeadroom(float64(p.NodeMemoryTotal), physicalMemory, memoryRatio))
+	disk = min(capacityDisk-usedDisk, physicalHeadroom(float64(p.NodeDiskTotal), physicalDisk, diskRatio))
+	return cpu, memory, disk
+}
+
+// physicalHeadroom 返回剩余物理资源按超分配比例换算后的可分配量
+func physicalHeadroom(total, used, ratio float64) int64 {
+	return int64(math.Floor((total - used) * ratio))
+}
+
+// ReserveResources 按资源限制配置在内存中累加指定实例类型的占用，用于批量规划，不写入数据库
+func (p *Provider) ReserveResources(instanceType string, cpu int, memory, disk int64) {
+	limitCPU, limitMemory, limitDisk := p.ResourceLimits(instanceType)
+	typedCPU, typedMemory, typedDisk := &p.ContainerUsedCPUCores, &p.ContainerUsedMemory, &p.ContainerUsedDisk
+	if instanceType == "vm" {
+		typedCPU, typedMemory, typedDisk = &p.VMUsedCPUCores, &p.VMUsedMemory, &p.VMUsedDisk
+	}
+	if limitCPU {
+		p.UsedCPUCores += cpu
+		*typedCPU += cpu
+	}
+	if limitMemory {
+		p.UsedMemory += memory
+		*typedMemory += memory
+	}
+	if limitDisk {
+		p.UsedDisk += disk
+		*typedDisk += disk
+	}
+}
+
+// ValidateOvercommitRatios 校验超分配比例，0表示使用默认值1.0
+func (p *Provider) ValidateOvercommitRatios() error {
+	ratios := []struct {
+		name  string
+		value float64
+	}{
+		{"容器CPU", p.ContainerCPURatio},
+		{"容器内存", p.ContainerMemoryRatio},
+		{"容器硬盘", p.ContainerDiskRatio},
+		{"虚拟机CPU", p.VMCPURatio},
+		{"虚拟机内存", p.VMMemoryRatio},
+		{"虚拟机硬盘", p.VMDiskRatio},
+	}
+	for _, ratio := range ratios {
+		if ratio.value < 0 || ratio.value > MaxOvercommitRatio {
+			return fmt.Errorf("%s超分配比例必须在0到%d之间", ratio.name, MaxOvercommitRatio)
+		}
+	}
+	return nil
+}
+
+// normalizeOvercommitRatio 未设置的比例按1.0处理
+func normalizeOvercommitRatio(ratio float64) float64 {
+	if ratio <= 0 {
+		return 1
+	}
+	return ratio
+}
+
 // Instance 实例模型
 type Instance struct {
 	// 基础字段
@@ -330,9 +492,11 @@ type Instance struct {
 	Memory    int64 `json:"memory" gorm:"default:512"`   // 内存大小（MB）
 	Disk      int64 `json:"disk" gorm:"default:10240"`   // 磁盘大小（MB）
 	Bandwidth int   `json:"bandwidth" gorm:"default:10"` // 网络带宽（Mbps）
+	FlavorID  uint  `json:"flavorId" gorm:"default:0"`   // 创建时选择的实例规格ID，0表示自定义配置
 
 	// 网络配置
 	Network        string `json:"network" gorm:"size:64"`      // 网络名称或配置
+	Node           string `json:"node" gorm:"size:64"`         // 集群Provider中实例所在的节点，单节点Provider为空
 	PrivateIP      string `json:"privateIP" gorm:"size:64"`    // 内网/私有IPv4地址
 	PublicIP       string `json:"publicIP" gorm:"size:64"`     // 公网IPv4地址
 	IPv6Address    string `json:"ipv6Address" gorm:"size:128"` // 内网IPv6地址
@@ -342,8 +506,11 @@ type Instance struct {
 	PortRangeEnd   int    `json:"portRangeEnd"`                // 端口映射范围结束
 
 	// 访问凭据
-	Username string `json:"username" gorm:"size:64"`  // 登录用户名
-	Password string `json:"password" gorm:"size:128"` // 登录密码
+	Username              string `json:"username" gorm:"size:64"`                    // 登录用户名
+	Password              string `json:"password" gorm:"size:128"`                   // 登录密码
+	SSHKeyIDs             string `json:"sshKeyIds" gorm:"size:255"`                  // 注入的用户SSH公钥ID，逗号分隔，重置时重新注入
+	PasswordLoginDisabled bool   `json:"passwordLoginDisabled" gorm:"default:false"` // 是否禁用SSH密码登录
+	UserData              string `json:"-" gorm:"type:text"`                         // 创建或重装时提供的user-data，重置时重新执行
 
 	// 系统信息
 	OSType string `json:"osType" gorm:"size:64"` // 操作系统类型：ubuntu, centos, debian等
@@ -399,6 +566,79 @@ type Port struct {
 	MappingMethod string `json:"mappingMethod" gorm:"size:32;default:native"` // 映射方法：native, iptables, firewall
 }
 
+// InstanceSnapshot 实例快照记录
+type InstanceSnapshot struct {
+	// 基础字段
+	ID        uint           `json:"id" gorm:"primarykey"` // 快照记录主键ID
+	CreatedAt time.Time      `json:"createdAt"`            // 创建时间
+	UpdatedAt time.Time      `json:"updatedAt"`            // 更新时间
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`       // 软删除时间
+
+	// 快照信息
+	InstanceID  uint   `json:"instanceId" gorm:"index:idx_snapshot_instance;not null"` // 关联的实例ID
+	ProviderID  uint   `json:"providerId" gorm:"index:idx_snapshot_provider"`          // 关联的Provider ID
+	UserID      uint   `json:"userId" gorm:"index:idx_snapshot_user"`                  // 所属用户ID
+	Name        string `json:"name" gorm:"not null;size:64"`                           // 快照名称（与Provider上的快照名一致）
+	Description string `json:"description" gorm:"size:256"`                            // 快照描述
+	Status      string `json:"status" gorm:"default:creating;size:16"`                 // 快照状态：creating, available, restoring, deleting, failed
+}
+
+// InstanceBackup 实例备份记录，备份归档保存在存储目录下
+type InstanceBackup struct {
+	// 基础字段
+	ID        uint           `json:"id" gorm:"primarykey"` // 备份记录主键ID
+	CreatedAt time.Time      `json:"createdAt"`            // 创建时间
+	UpdatedAt time.Time      `json:"updatedAt"`            // 更新时间
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`       // 软删除时间
+
+	// 备份信息
+	InstanceID  uint   `json:"instanceId" gorm:"index:idx_backup_instance;not null"` // 关联的实例ID
+	ProviderID  uint   `json:"providerId" gorm:"index:idx_backup_provider"`          // 创建备份时实例所在的Provider ID
+	UserID      uint   `json:"userId" gorm:"index:idx_backup_user"`                  // 所属用户ID
+	Name        string `json:"name" gorm:"not null;size:64"`                         // 备份名称
+	Description string `json:"description" gorm:"size:256"`                          // 备份描述
+	FilePath    string `json:"-" gorm:"size:512"`                                    // 归档文件相对路径
+	Size        int64  `json:"size" gorm:"default:0"`                                // 归档大小（字节）
+	TriggerType string `json:"triggerType" gorm:"default:manual;size:16"`            // 触发方式：manual, scheduled
+	Status      string `json:"status" gorm:"default:creating;size:16"`               // 备份状态：creating, available, restoring, failed
+}
+
+// InstanceBackupPolicy 实例定时备份策略，每个实例最多一条
+type InstanceBackupPolicy struct {
+	// 基础字段
+	ID        uint           `json:"id" gorm:"primarykey"` // 策略主键ID
+	CreatedAt time.Time      `json:"createdAt"`            // 创建时间
+	UpdatedAt time.Time      `json:"updatedAt"`            // 更新时间
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`       // 软删除时间
+
+	// 策略配置
+	InstanceID uint       `json:"instanceId" gorm:"uniqueIndex;not null"`         // 关联的实例ID
+	UserID     uint       `json:"userId" gorm:"index"`                            // 所属用户ID
+	Enabled    bool       `json:"enabled" gorm:"default:false"`                   // 是否启用定时备份
+	Frequency  string     `json:"frequency" gorm:"default:daily;size:16"`         // 备份频率：daily, weekly
+	Hour       int        `json:"hour" gorm:"default:3"`                          // 每天执行的小时（0-23，服务器时区）
+	Weekday    int        `json:"weekday" gorm:"default:0"`                       // 每周执行的星期（0-6，周日为0），仅weekly有效
+	KeepLast   int        `json:"keepLast" gorm:"default:7"`                      // 保留最近N个备份，超出的自动删除
+	LastRunAt  *time.Time `json:"lastRunAt"`                                      // 上次执行时间
+	NextRunAt  *time.Time `json:"nextRunAt" gorm:"index"`                         // 下次执行时间
+}
+
+// NextRunAfter 计算from之后的下一次备份时间
+func (p *InstanceBackupPolicy) NextRunAfter(from time.Time) time.Time {
+	next := time.Date(from.Year(), from.Month(), from.Day(), p.Hour, 0, 0, 0, from.Location())
+	if p.Frequency == "weekly" {
+		next = next.AddDate(0, 0, (p.Weekday-int(next.Weekday())+7)%7)
+		if !next.After(from) {
+			next = next.AddDate(0, 0, 7)
+		}
+		return next
+	}
+	if !next.After(from) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
 // PendingDeletion 待删除资源模型
 type PendingDeletion struct {
 	ID           uint      `json:"id" gorm:"primarykey"`
@@ -441,6 +681,104 @@ type ProviderImage struct {
 	Metadata    map[string]string `json:"metadata"`
 }
 
+// ProviderSnapshot 快照信息
+type ProviderSnapshot struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Size        string            `json:"size"`
+	Stateful    bool              `json:"stateful"` // 是否包含内存状态
+	Created     time.Time         `json:"created"`
+	Metadata    map[string]string `json:"metadata"`
+}
+
+// 节点能力名称，用于任务执行前的能力检查
+const (
+	CapabilityIPv6           = "ipv6"
+	CapabilitySnapshot       = "snapshot"
+	CapabilityBackup         = "backup"
+	CapabilityMigration      = "migration"
+	CapabilityDiskResize     = "diskResize"
+	CapabilityDiskQuota      = "diskQuota"
+	CapabilityBandwidthLimit = "bandwidthLimit"
+	CapabilityDiskIOLimit    = "diskIoLimit"
+	CapabilityNesting        = "nesting"
+	CapabilityPrivileged     = "privileged"
+)
+
+// ProviderCapabilities 节点能力文档，描述节点实际能完成的操作
+type ProviderCapabilities struct {
+	InstanceTypes  []string  `json:"instanceTypes"`  // 支持的实例类型：container、vm
+	IPv6           bool      `json:"ipv6"`           // 可为实例分配IPv6地址
+	Snapshot       bool      `json:"snapshot"`       // 快照
+	Backup         bool      `json:"backup"`         // 备份与恢复
+	Migration      bool      `json:"migration"`      // 可导入同类型节点导出的实例（跨节点迁移）
+	DiskResize     bool      `json:"diskResize"`     // 已有实例磁盘扩容
+	DiskQuota      bool      `json:"diskQuota"`      // 创建时限制磁盘大小
+	BandwidthLimit bool      `json:"bandwidthLimit"` // 实例带宽限速
+	DiskIOLimit    bool      `json:"diskIoLimit"`    // 磁盘IO限速
+	Nesting        bool      `json:"nesting"`        // 容器嵌套
+	Privileged     bool      `json:"privileged"`     // 特权容器
+	ProbedAt       time.Time `json:"probedAt"`       // 探测时间
+}
+
+// Supports 是否具备指定能力，未知能力名称视为不支持
+func (c *ProviderCapabilities) Supports(name string) bool {
+	switch name {
+	case CapabilityIPv6:
+		return c.IPv6
+	case CapabilitySnapshot:
+		return c.Snapshot
+	case CapabilityBackup:
+		return c.Backup
+	case CapabilityMigration:
+		return c.Migration
+	case CapabilityDiskResize:
+		return c.DiskResize
+	case CapabilityDiskQuota:
+		return c.DiskQuota
+	case CapabilityBandwidthLimit:
+		return c.BandwidthLimit
+	case CapabilityDiskIOLimit:
+		return c.DiskIOLimit
+	case CapabilityNesting:
+		return c.Nesting
+	case CapabilityPrivileged:
+		return c.Privileged
+	}
+	return false
+}
+
+// SupportsInstanceType 是否支持指定实例类型
+func (c *ProviderCapabilities) SupportsInstanceType(instanceType string) bool {
+	for _, t := range c.InstanceTypes {
+		if t == instanceType {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCapabilities 解析已持久化的能力文档，尚未探测或解析失败时返回false
+func (p *Provider) ParseCapabilities() (*ProviderCapabilities, bool) {
+	if p.Capabilities == "" {
+		return nil, false
+	}
+	var caps ProviderCapabilities
+	if err := json.Unmarshal([]byte(p.Capabilities), &caps); err != nil {
+		return nil, false
+	}
+	return &caps, true
+}
+
+// ProviderResizeSpec 实例配置调整参数，零值表示该项不调整
+type ProviderResizeSpec struct {
+	InstanceType string `json:"instance_type"` // container 或 vm
+	CPU          int    `json:"cpu"`           // CPU核心数
+	Memory       int64  `json:"memory"`        // 内存大小（MB）
+	Disk         int64  `json:"disk"`          // 磁盘大小（MB），仅支持扩容
+	Bandwidth    int    `json:"bandwidth"`     // 带宽（Mbps）
+}
+
 // ProviderInstanceConfig 实例配置
 type ProviderInstanceConfig struct {
 	Name         string            `json:"name"`
@@ -456,6 +794,14 @@ type ProviderInstanceConfig struct {
 	Env          map[string]string `json:"env"`
 	Metadata     map[string]string `json:"metadata"`
 	InstanceType string            `json:"instance_type"` // container 或 vm
+	Node         string            `json:"node,omitempty"` // 集群Provider中的目标节点，为空时在连接节点上创建
+
+	// 登录方式：注入到root的authorized_keys中的SSH公钥，可选禁用SSH密码登录
+	SSHKeys              []string `json:"ssh_keys,omitempty"`
+	DisablePasswordLogin bool     `json:"disable_password_login,omitempty"`
+
+	// UserData 用户提供的cloud-init user-data（#cloud-config）或首次启动执行的shell脚本（#!）
+	UserData string `json:"user_data,omitempty"`
 
 	// ZJMF specific fields
 	ProductID   int    `json:"product_id"`   // ZJMF产品ID
@@ -516,6 +862,12 @@ type ProviderNodeConfig struct {
 	// 节点标识（用于区分多个相同hostname的节点）
 	HostName string `json:"host_name"` // 节点主机名（hostname），用于Proxmox等需要节点名的Provider
 
+	// 存储池名称，用于libvirt等在连接时确定磁盘存放位置的Provider
+	StoragePool string `json:"storage_pool"`
+
+	// rootless Podman运行用户，为空表示rootful模式
+	PodmanRootlessUser string `json:"podman_rootless_user"`
+
 	// 容器特殊配置选项（仅适用于 LXD 和 Incus 的容器实例）
 	ContainerPrivileged   bool   `json:"containerPrivileged"`   // 容器特权模式
 	ContainerAllowNesting bool   `json:"containerAllowNesting"` // 容器嵌套
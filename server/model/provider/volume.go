@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 数据卷状态
+const (
+	VolumeStatusCreating  = "creating"
+	VolumeStatusAvailable = "available" // 已创建，未挂载到实例
+	VolumeStatusAttaching = "attaching"
+	VolumeStatusInUse     = "in-use" // 已挂载到实例
+	VolumeStatusDetaching = "detaching"
+	VolumeStatusResizing  = "resizing"
+	VolumeStatusDeleting  = "deleting"
+	VolumeStatusFailed    = "failed"
+)
+
+// Volume 可挂载的数据卷，独立于实例存在，实例删除或重装时保留
+type Volume struct {
+	// 基础字段
+	ID        uint           `json:"id" gorm:"primarykey"` // 数据卷主键ID
+	CreatedAt time.Time      `json:"createdAt"`            // 创建时间
+	UpdatedAt time.Time      `json:"updatedAt"`            // 更新时间
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`       // 软删除时间
+
+	// 数据卷信息
+	Name         string `json:"name" gorm:"not null;size:64"`                  // 数据卷名称（用户可见）
+	ProviderID   uint   `json:"providerId" gorm:"index:idx_volume_provider"`   // 所在的Provider ID
+	UserID       uint   `json:"userId" gorm:"index:idx_volume_user"`           // 所属用户ID
+	InstanceID   uint   `json:"instanceId" gorm:"index:idx_volume_instance"`   // 挂载的实例ID，0表示未挂载
+	InstanceType string `json:"instanceType" gorm:"size:16;default:container"` // 可挂载的实例类型：container, vm
+	SizeMB       int64  `json:"sizeMb" gorm:"not null"`                        // 容量（MB）
+	Pool         string `json:"pool" gorm:"size:64"`                           // Provider上的存储池
+	Device       string `json:"device" gorm:"size:32"`                         // 挂载后在实例上的设备名（如scsi1、mp0）
+	MountPath    string `json:"mountPath" gorm:"size:255"`                     // 容器内的挂载路径，虚拟机为块设备不使用
+	Status       string `json:"status" gorm:"default:creating;size:16;index"`  // 状态：creating, available, attaching, in-use, detaching, resizing, deleting, failed
+}
+
+// ProviderVolumeName 数据卷在Provider上的名称，使用ID保证唯一且与用户填写的名称无关
+func (v *Volume) ProviderVolumeName() string {
+	return fmt.Sprintf("ocv-vol-%d", v.ID)
+}
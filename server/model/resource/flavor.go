@@ -0,0 +1,117 @@
+package resource
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Flavor 管理员预定义的实例规格模板
+// 用户申领或创建实例时从规格中选择，产品可关联规格用于展示和定价
+type Flavor struct {
+	ID          uint   `json:"id" gorm:"primarykey"`
+	Name        string `json:"name" gorm:"size:64;not null;uniqueIndex"` // 规格名称
+	Description string `json:"description" gorm:"size:255"`              // 规格描述
+	CPU         int    `json:"cpu" gorm:"not null"`                      // CPU核心数
+	Memory      int64  `json:"memory" gorm:"not null"`                   // 内存(MB)
+	Disk        int64  `json:"disk" gorm:"not null"`                     // 磁盘(MB)
+	Bandwidth   int    `json:"bandwidth" gorm:"not null"`                // 带宽(Mbps)
+	Traffic     int64  `json:"traffic" gorm:"default:0"`                 // 实例流量限制(MB)，0表示继承用户等级限制
+
+	InstanceTypes string `json:"instanceTypes" gorm:"size:32;not null"` // 允许的实例类型，逗号分隔：container,vm
+	ImageIDs      string `json:"imageIds" gorm:"type:text"`             // 允许的镜像ID，逗号分隔，为空表示不限制
+	ProviderIDs   string `json:"providerIds" gorm:"type:text"`          // 提供该规格的节点ID，逗号分隔，为空表示所有节点
+
+	IsEnabled bool           `json:"isEnabled" gorm:"index"`     // 是否启用
+	SortOrder int            `json:"sortOrder" gorm:"default:0"` // 排序
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定表名
+func (Flavor) TableName() string {
+	return "flavors"
+}
+
+// InstanceTypeList 返回规格允许的实例类型
+func (f *Flavor) InstanceTypeList() []string {
+	var types []string
+	for _, part := range strings.Split(f.InstanceTypes, ",") {
+		if t := strings.TrimSpace(part); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// ImageIDList 返回规格允许的镜像ID，为空表示不限制
+func (f *Flavor) ImageIDList() []uint {
+	return parseFlavorIDs(f.ImageIDs)
+}
+
+// ProviderIDList 返回提供该规格的节点ID，为空表示所有节点
+func (f *Flavor) ProviderIDList() []uint {
+	return parseFlavorIDs(f.ProviderIDs)
+}
+
+// AllowsInstanceType 检查规格是否允许指定的实例类型
+func (f *Flavor) AllowsInstanceType(instanceType string) bool {
+	for _, t := range f.InstanceTypeList() {
+		if t == instanceType {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsImage 检查规格是否允许指定的镜像
+func (f *Flavor) AllowsImage(imageID uint) bool {
+	return containsFlavorID(f.ImageIDList(), imageID)
+}
+
+// AllowsProvider 检查节点是否提供该规格
+func (f *Flavor) AllowsProvider(providerID uint) bool {
+	return containsFlavorID(f.ProviderIDList(), providerID)
+}
+
+// FormatFlavorIDs 将ID列表格式化为逗号分隔的字符串，去除重复和无效ID
+func FormatFlavorIDs(ids []uint) string {
+	seen := make(map[uint]bool, len(ids))
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		parts = append(parts, strconv.FormatUint(uint64(id), 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseFlavorIDs 解析逗号分隔的ID列表，忽略无法解析的项
+func parseFlavorIDs(value string) []uint {
+	var ids []uint
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32)
+		if err == nil && id > 0 {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// containsFlavorID 空列表表示不限制
+func containsFlavorID(ids []uint, id uint) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
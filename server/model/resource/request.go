@@ -40,3 +40,38 @@ type ReserveResourcesResult struct {
 	ReservationID uint
 	ExpiresAt     time.Time
 }
+
+// PlacementRequest 自动选择节点请求
+type PlacementRequest struct {
+	InstanceType string `json:"instanceType" binding:"required,oneof=container vm"`
+	Region       string `json:"region"`       // 地区筛选，为空表示不限
+	Country      string `json:"country"`      // 国家或国家代码筛选，为空表示不限
+	Architecture string `json:"architecture"` // CPU架构筛选，为空表示不限
+	CPU          int    `json:"cpu" binding:"min=0"`
+	Memory       int64  `json:"memory" binding:"min=0"` // MB
+	Disk         int64  `json:"disk" binding:"min=0"`   // MB
+	FlavorID     uint   `json:"flavorId"`               // 选择实例规格时按规格的资源和可用节点选择
+}
+
+// PlacementCandidate 节点评分结果
+type PlacementCandidate struct {
+	ProviderID   uint    `json:"providerId"`
+	ProviderName string  `json:"providerName"`
+	Region       string  `json:"region"`
+	Country      string  `json:"country"`
+	Score        float64 `json:"score"` // 综合得分（0-100）
+	CPUScore     float64 `json:"cpuScore"`
+	MemoryScore  float64 `json:"memoryScore"`
+	DiskScore    float64 `json:"diskScore"`
+	QueueScore   float64 `json:"queueScore"`
+	HealthScore  float64 `json:"healthScore"`
+	QueueLength  int64   `json:"queueLength"` // 排队和执行中的任务数
+}
+
+// PlacementResult 自动选择节点结果，Candidates按得分从高到低排列
+type PlacementResult struct {
+	Strategy   string               `json:"strategy"`
+	Selected   *PlacementCandidate  `json:"selected"`
+	Candidates []PlacementCandidate `json:"candidates"`
+	Excluded   map[uint]string      `json:"excluded"` // 被排除的节点及原因
+}
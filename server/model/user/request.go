@@ -3,13 +3,18 @@ package user
 import "oneclickvirt/model/common"
 
 type ClaimResourceRequest struct {
-	ProviderID   uint   `json:"providerId" binding:"required"`
+	ProviderID   uint   `json:"providerId"` // 为0时按调度策略自动选择节点
 	InstanceType string `json:"instanceType" binding:"required"`
 	Name         string `json:"name" binding:"required"`
 	Image        string `json:"image" binding:"required"`
-	CPU          int    `json:"cpu" binding:"required,min=1"`
-	Memory       int64  `json:"memory" binding:"required,min=1"`
-	Disk         int64  `json:"disk" binding:"required,min=1"`
+	FlavorID     uint   `json:"flavorId"` // 选择实例规格时CPU、内存和磁盘使用规格的配置
+	CPU          int    `json:"cpu" binding:"omitempty,min=1"`
+	Memory       int64  `json:"memory" binding:"omitempty,min=1"`
+	Disk         int64  `json:"disk" binding:"omitempty,min=1"`
+	// 自动选择节点时的筛选条件
+	Region       string `json:"region"`
+	Country      string `json:"country"`
+	Architecture string `json:"architecture"`
 }
 
 type InstanceActionRequest struct {
@@ -26,6 +31,12 @@ type UserInstanceListRequest struct {
 	ProviderName string `json:"providerName" form:"providerName"` // 节点名称搜索
 }
 
+// AvailableFlavorsRequest 获取可选实例规格请求
+type AvailableFlavorsRequest struct {
+	ProviderID   uint   `json:"providerId" form:"providerId"`                                            // 为0时不按节点过滤
+	InstanceType string `json:"instanceType" form:"instanceType" binding:"omitempty,oneof=container vm"` // 为空时不按实例类型过滤
+}
+
 type AvailableResourcesRequest struct {
 	common.PageInfo
 	Country      string `json:"country" form:"country"`
@@ -54,6 +65,105 @@ type ResetInstancePasswordRequest struct {
 	// 不需要传递任何参数，由后端自动生成新密码
 }
 
+// ResizeInstanceRequest 用户调整实例配置请求，传入调整后的完整配置
+type ResizeInstanceRequest struct {
+	CPU       int   `json:"cpu" binding:"required,min=1"`       // CPU核心数
+	Memory    int64 `json:"memory" binding:"required,min=64"`   // 内存大小（MB）
+	Disk      int64 `json:"disk" binding:"required,min=512"`    // 磁盘大小（MB），只能扩容
+	Bandwidth int   `json:"bandwidth" binding:"required,min=1"` // 带宽（Mbps）
+}
+
+// ReinstallInstanceRequest 用户重装系统请求，镜像须来自该节点可用的镜像列表
+type ReinstallInstanceRequest struct {
+	ImageID uint `json:"imageId" binding:"required"` // 目标系统镜像ID
+
+	SSHKeyIDs            []uint `json:"sshKeyIds" binding:"max=20"` // 注入到实例的SSH公钥ID
+	DisablePasswordLogin bool   `json:"disablePasswordLogin"`       // 禁用SSH密码登录，需要至少选择一个公钥
+	UserData             string `json:"userData"`                   // cloud-init user-data（#cloud-config）或shell脚本（#!），需等级允许
+}
+
+// CreateSSHKeyRequest 添加SSH公钥请求
+type CreateSSHKeyRequest struct {
+	Name      string `json:"name" binding:"required,max=100"`       // 公钥名称
+	PublicKey string `json:"publicKey" binding:"required,max=8192"` // authorized_keys格式的公钥
+}
+
+// CreateSnapshotRequest 创建实例快照请求
+type CreateSnapshotRequest struct {
+	Name        string `json:"name" binding:"required,max=40"`
+	Description string `json:"description" binding:"max=256"`
+}
+
+// CreateBackupRequest 创建实例备份请求
+type CreateBackupRequest struct {
+	Name        string `json:"name" binding:"required,max=40"`
+	Description string `json:"description" binding:"max=256"`
+}
+
+// CreateVolumeRequest 创建数据卷请求，指定InstanceID时创建后直接挂载到该实例
+type CreateVolumeRequest struct {
+	Name         string `json:"name" binding:"required,max=40"`
+	ProviderID   uint   `json:"providerId"`
+	InstanceType string `json:"instanceType" binding:"omitempty,oneof=container vm"`
+	SizeMB       int64  `json:"sizeMb" binding:"required,min=1"`
+	InstanceID   uint   `json:"instanceId"`
+	MountPath    string `json:"mountPath" binding:"max=255"`
+}
+
+// AttachVolumeRequest 挂载数据卷请求
+type AttachVolumeRequest struct {
+	InstanceID uint   `json:"instanceId" binding:"required"`
+	MountPath  string `json:"mountPath" binding:"max=255"`
+}
+
+// ResizeVolumeRequest 调整数据卷容量请求，只支持扩容
+type ResizeVolumeRequest struct {
+	SizeMB int64 `json:"sizeMb" binding:"required,min=1"`
+}
+
+// CreatePrivateNetworkRequest 创建私有网络请求
+type CreatePrivateNetworkRequest struct {
+	Name       string `json:"name" binding:"required,max=40"`
+	ProviderID uint   `json:"providerId" binding:"required"`
+	CIDR       string `json:"cidr" binding:"required,max=32"`
+}
+
+// AttachPrivateNetworkRequest 实例接入私有网络请求，IPAddress为空时自动分配
+type AttachPrivateNetworkRequest struct {
+	InstanceID uint   `json:"instanceId" binding:"required"`
+	IPAddress  string `json:"ipAddress" binding:"omitempty,ipv4"`
+}
+
+// DetachPrivateNetworkRequest 实例断开私有网络请求
+type DetachPrivateNetworkRequest struct {
+	InstanceID uint `json:"instanceId" binding:"required"`
+}
+
+// FirewallRuleRequest 单条防火墙规则，PortStart为0表示所有端口，PortEnd为0表示单个端口
+type FirewallRuleRequest struct {
+	Action      string `json:"action" binding:"required,oneof=allow deny"`
+	Protocol    string `json:"protocol" binding:"required,oneof=tcp udp icmp all"`
+	PortStart   int    `json:"portStart" binding:"min=0,max=65535"`
+	PortEnd     int    `json:"portEnd" binding:"min=0,max=65535"`
+	SourceCIDR  string `json:"sourceCidr" binding:"max=64"`
+	IPVersion   string `json:"ipVersion" binding:"omitempty,oneof=ipv4 ipv6"`
+	Description string `json:"description" binding:"max=128"`
+}
+
+// UpdateFirewallRulesRequest 整体替换实例防火墙规则请求，规则按数组顺序匹配，空数组表示清空规则
+type UpdateFirewallRulesRequest struct {
+	Rules []FirewallRuleRequest `json:"rules" binding:"dive"`
+}
+
+// UpdateBackupPolicyRequest 更新实例定时备份策略请求
+type UpdateBackupPolicyRequest struct {
+	Enabled   bool   `json:"enabled"`
+	Frequency string `json:"frequency" binding:"required,oneof=daily weekly"`
+	Hour      int    `json:"hour" binding:"min=0,max=23"`
+	Weekday   int    `json:"weekday" binding:"min=0,max=6"`
+	KeepLast  int    `json:"keepLast" binding:"required,min=1,max=30"`
+}
+
 // UserTasksRequest 用户任务列表请求
 type UserTasksRequest struct {
 	common.PageInfo
@@ -74,13 +184,18 @@ type SystemImagesRequest struct {
 // 安全设计：所有参数都是从后端预定义配置中选择的ID，不允许自定义输入
 // 实例名称由后端根据provider名称自动生成
 type CreateInstanceRequest struct {
-	ProviderId  uint   `json:"providerId" binding:"required"`  // 节点ID
-	ImageId     uint   `json:"imageId" binding:"required"`     // 镜像ID（从数据库获取）
-	CPUId       string `json:"cpuId" binding:"required"`       // CPU规格ID
-	MemoryId    string `json:"memoryId" binding:"required"`    // 内存规格ID
-	DiskId      string `json:"diskId" binding:"required"`      // 磁盘规格ID
-	BandwidthId string `json:"bandwidthId" binding:"required"` // 带宽规格ID
-	Description string `json:"description"`                    // 描述信息
+	ProviderId  uint   `json:"providerId" binding:"required"`                   // 节点ID
+	ImageId     uint   `json:"imageId" binding:"required"`                      // 镜像ID（从数据库获取）
+	CPUId       string `json:"cpuId" binding:"required_without=FlavorID"`       // CPU规格ID
+	MemoryId    string `json:"memoryId" binding:"required_without=FlavorID"`    // 内存规格ID
+	DiskId      string `json:"diskId" binding:"required_without=FlavorID"`      // 磁盘规格ID
+	BandwidthId string `json:"bandwidthId" binding:"required_without=FlavorID"` // 带宽规格ID
+	FlavorID    uint   `json:"flavorId"`                                        // 实例规格ID，选择后使用规格的配置
+	Description string `json:"description"`                                     // 描述信息
+
+	SSHKeyIDs            []uint `json:"sshKeyIds" binding:"max=20"` // 注入到实例的SSH公钥ID
+	DisablePasswordLogin bool   `json:"disablePasswordLogin"`       // 禁用SSH密码登录，需要至少选择一个公钥
+	UserData             string `json:"userData"`                   // cloud-init user-data（#cloud-config）或shell脚本（#!），需等级允许
 }
 
 // QuotaCheckRequest 配额检查请求
@@ -273,6 +273,52 @@ type ResetInstancePasswordResponse struct {
 	TaskID uint `json:"taskId"`
 }
 
+// ResizeInstanceResponse 调整实例配置任务响应
+type ResizeInstanceResponse struct {
+	TaskID uint `json:"taskId"`
+}
+
+// ReinstallInstanceResponse 重装系统任务响应
+type ReinstallInstanceResponse struct {
+	TaskID uint `json:"taskId"`
+}
+
+// SnapshotTaskResponse 快照操作任务响应
+type SnapshotTaskResponse struct {
+	TaskID     uint `json:"taskId"`
+	SnapshotID uint `json:"snapshotId"`
+}
+
+// BackupTaskResponse 备份操作任务响应
+type BackupTaskResponse struct {
+	TaskID   uint `json:"taskId"`
+	BackupID uint `json:"backupId"`
+}
+
+// VolumeTaskResponse 数据卷操作任务响应
+type VolumeTaskResponse struct {
+	TaskID   uint `json:"taskId"`
+	VolumeID uint `json:"volumeId"`
+}
+
+// PrivateNetworkTaskResponse 私有网络操作任务响应
+type PrivateNetworkTaskResponse struct {
+	TaskID    uint `json:"taskId"`
+	NetworkID uint `json:"networkId"`
+}
+
+// FirewallRulesResponse 实例防火墙规则响应
+type FirewallRulesResponse struct {
+	Rules    []providerModel.FirewallRule `json:"rules"`
+	MaxRules int                          `json:"maxRules"` // 当前等级允许的最大规则数量，0表示不允许使用防火墙
+}
+
+// FirewallTaskResponse 应用防火墙规则任务响应
+type FirewallTaskResponse struct {
+	TaskID     uint `json:"taskId"`
+	InstanceID uint `json:"instanceId"`
+}
+
 // GetInstancePasswordResponse 获取实例新密码响应
 type GetInstancePasswordResponse struct {
 	NewPassword string `json:"newPassword"`
@@ -0,0 +1,19 @@
+package user
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SSHKey 用户SSH公钥，创建或重装实例时可选择注入到实例中
+type SSHKey struct {
+	ID          uint           `json:"id" gorm:"primarykey"`
+	UserID      uint           `json:"userId" gorm:"not null;index:idx_ssh_key_user"`
+	Name        string         `json:"name" gorm:"size:100;not null"`       // 公钥名称，便于识别
+	PublicKey   string         `json:"publicKey" gorm:"type:text;not null"` // authorized_keys格式的公钥
+	Fingerprint string         `json:"fingerprint" gorm:"size:128;index"`   // SHA256指纹
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
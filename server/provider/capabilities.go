@@ -0,0 +1,28 @@
+package provider
+
+import (
+	"sync"
+	"time"
+)
+
+// CapabilityCache 缓存最近一次探测到的能力文档，由各Provider嵌入以实现GetCapabilities
+type CapabilityCache struct {
+	capMu        sync.RWMutex
+	capabilities Capabilities
+}
+
+// GetCapabilities 返回最近一次探测结果，尚未探测时为零值
+func (c *CapabilityCache) GetCapabilities() Capabilities {
+	c.capMu.RLock()
+	defer c.capMu.RUnlock()
+	return c.capabilities
+}
+
+// StoreCapabilities 保存探测结果并记录探测时间
+func (c *CapabilityCache) StoreCapabilities(caps Capabilities) Capabilities {
+	caps.ProbedAt = time.Now()
+	c.capMu.Lock()
+	c.capabilities = caps
+	c.capMu.Unlock()
+	return caps
+}
@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ClusterNode 集群Provider中的单个节点及其容量和负载
+type ClusterNode struct {
+	Name      string  `json:"name"`
+	IP        string  `json:"ip"`
+	Online    bool    `json:"online"`
+	CPUUsage  float64 `json:"cpuUsage"` // 0-1
+	MaxCPU    int     `json:"maxCpu"`
+	Memory    int64   `json:"memory"`    // 已用内存MB
+	MaxMemory int64   `json:"maxMemory"` // MB
+	Disk      int64   `json:"disk"`      // 已用磁盘MB
+	MaxDisk   int64   `json:"maxDisk"`   // MB
+	Instances int     `json:"instances"` // 节点上的虚拟机和容器数量
+	Local     bool    `json:"local"`     // 是否为面板SSH连接所在的节点
+}
+
+// ClusterProvider 一个连接端点背后有多个节点的Provider实现此接口
+// 单节点部署时SelectClusterNode返回空字符串，调用方无需记录节点
+type ClusterProvider interface {
+	ListClusterNodes(ctx context.Context) ([]ClusterNode, error)
+	SelectClusterNode(ctx context.Context, config InstanceConfig) (string, error)
+}
+
+// ClusterNodeHostKeys Proxmox集群维护的节点主机密钥文件，加入集群时由pvecm写入
+const ClusterNodeHostKeys = "/etc/pve/priv/known_hosts"
+
+// ClusterNodeCommand 将命令包装为经集群内部SSH在指定节点上执行的命令
+// 集群节点之间默认互信root密钥，节点名可直接解析；主机密钥只信任集群记录的密钥，不自动接受未知主机
+func ClusterNodeCommand(node, command string) string {
+	return fmt.Sprintf("ssh -o BatchMode=yes -o UserKnownHostsFile=%s -o StrictHostKeyChecking=yes root@%s '%s'",
+		ClusterNodeHostKeys, node, strings.ReplaceAll(command, "'", `'\''`))
+}
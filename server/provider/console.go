@@ -0,0 +1,192 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"oneclickvirt/utils"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/crypto/ssh"
+)
+
+// 控制台类型
+const (
+	ConsoleTypeSerial = "serial" // 文本控制台：虚拟机串口、容器控制台或 docker attach
+	ConsoleTypeVNC    = "vnc"    // 图形控制台：RFB字节流，由浏览器端noVNC解析
+)
+
+// ConsoleSession 实例控制台会话，Read/Write为控制台原始字节流
+type ConsoleSession interface {
+	io.ReadWriteCloser
+	// Resize 调整终端大小，图形控制台忽略
+	Resize(rows, cols int) error
+	// Password 浏览器端连接控制台所需的密码（如Proxmox VNC票据），不需要时返回空
+	Password() string
+}
+
+// ConsoleProvider 支持浏览器控制台的Provider实现此接口
+// 控制台直接连接虚拟化层，不依赖实例内的网络和sshd
+type ConsoleProvider interface {
+	OpenConsole(ctx context.Context, instanceID, consoleType string) (ConsoleSession, error)
+}
+
+// sshConsole 通过宿主机SSH会话运行控制台命令（如 virsh console、docker attach）
+type sshConsole struct {
+	session *ssh.Session
+	stdin   io.WriteCloser
+	stdout  io.Reader
+}
+
+// NewSSHConsole 在宿主机上分配PTY并运行控制台命令
+func NewSSHConsole(client *utils.SSHClient, command string) (ConsoleSession, error) {
+	if client == nil || client.GetUnderlyingClient() == nil {
+		return nil, fmt.Errorf("SSH连接不可用")
+	}
+
+	session, err := client.GetUnderlyingClient().NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("创建SSH会话失败: %w", err)
+	}
+
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	if err := session.RequestPty("xterm-256color", 24, 80, modes); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("请求PTY失败: %w", err)
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("获取SSH stdin失败: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("获取SSH stdout失败: %w", err)
+	}
+
+	if err := session.Start(command); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("启动控制台命令失败: %w", err)
+	}
+
+	return &sshConsole{session: session, stdin: stdin, stdout: stdout}, nil
+}
+
+func (c *sshConsole) Read(p []byte) (int, error)  { return c.stdout.Read(p) }
+func (c *sshConsole) Write(p []byte) (int, error) { return c.stdin.Write(p) }
+func (c *sshConsole) Close() error                { return c.session.Close() }
+func (c *sshConsole) Password() string            { return "" }
+
+func (c *sshConsole) Resize(rows, cols int) error {
+	return c.session.WindowChange(rows, cols)
+}
+
+// tunnelConsole 通过SSH转发宿主机本地端口（如只监听127.0.0.1的VNC端口）
+type tunnelConsole struct {
+	net.Conn
+}
+
+// NewSSHTunnelConsole 经SSH连接转发到宿主机上的地址，用于桥接RFB等原始字节流
+func NewSSHTunnelConsole(client *utils.SSHClient, address string) (ConsoleSession, error) {
+	if client == nil || client.GetUnderlyingClient() == nil {
+		return nil, fmt.Errorf("SSH连接不可用")
+	}
+	conn, err := client.GetUnderlyingClient().Dial("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("转发到 %s 失败: %w", address, err)
+	}
+	return &tunnelConsole{Conn: conn}, nil
+}
+
+func (c *tunnelConsole) Resize(rows, cols int) error { return nil }
+func (c *tunnelConsole) Password() string            { return "" }
+
+// WebSocketConsole 将虚拟化平台的控制台websocket（LXD/Incus /console、Proxmox vncwebsocket）适配为ConsoleSession
+type WebSocketConsole struct {
+	Conn *websocket.Conn
+	// EncodeInput 写入前对输入编码（如Proxmox termproxy的 "0:长度:数据" 帧格式），为空时原样发送
+	EncodeInput func(p []byte) []byte
+	// ResizeFunc 调整终端大小，为空时忽略
+	ResizeFunc func(rows, cols int) error
+	// OnClose 关闭数据连接前调用（如关闭LXD控制连接）
+	OnClose func()
+	// Secret 浏览器端需要的控制台密码
+	Secret string
+
+	reader    io.Reader
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+}
+
+// Read 按顺序读取websocket消息内容
+func (c *WebSocketConsole) Read(p []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			_, r, err := c.Conn.NextReader()
+			if err != nil {
+				return 0, err
+			}
+			c.reader = r
+		}
+		n, err := c.reader.Read(p)
+		if err == io.EOF {
+			c.reader = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// Write 以二进制消息发送输入
+func (c *WebSocketConsole) Write(p []byte) (int, error) {
+	data := p
+	if c.EncodeInput != nil {
+		data = c.EncodeInput(bytes.Clone(p))
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.Conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteControl 发送平台自定义的控制消息（与Write共用写锁）
+func (c *WebSocketConsole) WriteControl(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.Conn.WriteMessage(websocket.BinaryMessage, data)
+}
+
+func (c *WebSocketConsole) Resize(rows, cols int) error {
+	if c.ResizeFunc == nil {
+		return nil
+	}
+	return c.ResizeFunc(rows, cols)
+}
+
+func (c *WebSocketConsole) Password() string { return c.Secret }
+
+func (c *WebSocketConsole) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		if c.OnClose != nil {
+			c.OnClose()
+		}
+		err = c.Conn.Close()
+	})
+	return err
+}
@@ -0,0 +1,29 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"oneclickvirt/provider"
+)
+
+// ProbeCapabilities 探测Docker节点能力
+// 快照和备份基于 docker commit/export，始终可用；磁盘限制取决于存储驱动，IPv6取决于ipv6_net网络和ndpresponder
+func (d *DockerProvider) ProbeCapabilities(ctx context.Context) (provider.Capabilities, error) {
+	if !d.connected || d.sshClient == nil {
+		return d.GetCapabilities(), fmt.Errorf("provider not connected")
+	}
+
+	diskQuota, _, err := d.checkStorageDriver()
+	if err != nil {
+		return d.GetCapabilities(), err
+	}
+
+	return d.StoreCapabilities(provider.Capabilities{
+		InstanceTypes: d.GetSupportedInstanceTypes(),
+		IPv6:          d.checkIPv6NetworkAvailable(),
+		Snapshot:      true,
+		Backup:        true,
+		DiskQuota:     diskQuota,
+	}), nil
+}
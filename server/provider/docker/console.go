@@ -0,0 +1,40 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// OpenConsole 打开容器控制台
+// 容器以 -it 启动时使用 docker attach 连接主进程，否则通过 docker exec 启动shell
+func (d *DockerProvider) OpenConsole(ctx context.Context, instanceID, consoleType string) (provider.ConsoleSession, error) {
+	if !d.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+	if d.config.ExecutionRule == "api_only" {
+		return nil, fmt.Errorf("Docker provider不支持API调用，无法使用api_only执行规则")
+	}
+	if consoleType != provider.ConsoleTypeSerial {
+		return nil, fmt.Errorf("Docker容器不支持%s控制台", consoleType)
+	}
+
+	return provider.NewSSHConsole(d.sshClient, d.consoleCommand(instanceID))
+}
+
+// consoleCommand 根据容器是否分配了TTY选择 attach 或 exec
+func (d *DockerProvider) consoleCommand(instanceID string) string {
+	output, err := d.sshClient.Execute(fmt.Sprintf("docker inspect -f '{{.Config.Tty}} {{.Config.OpenStdin}}' %s", instanceID))
+	if err == nil && strings.TrimSpace(output) == "true true" {
+		return fmt.Sprintf("docker attach --sig-proxy=false %s", instanceID)
+	}
+	global.APP_LOG.Debug("容器未分配TTY，使用docker exec打开控制台",
+		zap.String("instance", utils.TruncateString(instanceID, 32)))
+	return fmt.Sprintf("docker exec -it %s sh -c 'command -v bash >/dev/null && exec bash || exec sh'", instanceID)
+}
@@ -16,6 +16,8 @@ import (
 )
 
 type DockerProvider struct {
+	provider.CapabilityCache
+
 	config        provider.NodeConfig
 	sshClient     *utils.SSHClient
 	connected     bool
@@ -97,6 +99,11 @@ func (d *DockerProvider) Connect(ctx context.Context, config provider.NodeConfig
 			zap.Error(err))
 	}
 
+	// 探测节点能力，失败时保留上一次结果
+	if _, err := d.ProbeCapabilities(ctx); err != nil {
+		global.APP_LOG.Warn("Docker节点能力探测失败", zap.Error(err))
+	}
+
 	global.APP_LOG.Info("Docker provider连接成功",
 		zap.String("host", utils.TruncateString(config.Host, 32)),
 		zap.Int("port", config.Port),
@@ -0,0 +1,118 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"oneclickvirt/provider"
+)
+
+// dockerInspect docker inspect 输出中导入实例需要的字段
+type dockerInspect struct {
+	Config struct {
+		Image string `json:"Image"`
+	} `json:"Config"`
+	HostConfig struct {
+		NanoCpus     int64             `json:"NanoCpus"`
+		CpuQuota     int64             `json:"CpuQuota"`
+		CpuPeriod    int64             `json:"CpuPeriod"`
+		Memory       int64             `json:"Memory"`
+		StorageOpt   map[string]string `json:"StorageOpt"`
+		PortBindings map[string][]struct {
+			HostPort string `json:"HostPort"`
+		} `json:"PortBindings"`
+	} `json:"HostConfig"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			IPAddress         string `json:"IPAddress"`
+			GlobalIPv6Address string `json:"GlobalIPv6Address"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// InspectInstance 读取容器的CPU、内存、磁盘限制、端口绑定和内网地址
+func (d *DockerProvider) InspectInstance(ctx context.Context, instanceName string) (*provider.InstanceInspection, error) {
+	if !d.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	output, err := d.sshClient.Execute(fmt.Sprintf("docker inspect --type container %s", instanceName))
+	if err != nil {
+		return nil, fmt.Errorf("获取容器配置失败: %w", err)
+	}
+	return parseDockerInspect(output)
+}
+
+// parseDockerInspect 解析 docker inspect 的输出
+func parseDockerInspect(output string) (*provider.InstanceInspection, error) {
+	var results []dockerInspect
+	if err := json.Unmarshal([]byte(output), &results); err != nil {
+		return nil, fmt.Errorf("解析容器配置失败: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("容器不存在")
+	}
+	data := results[0]
+
+	inspection := &provider.InstanceInspection{
+		InstanceType: "container",
+		Image:        data.Config.Image,
+		Memory:       data.HostConfig.Memory / 1024 / 1024,
+		Disk:         provider.ParseSizeMB(data.HostConfig.StorageOpt["size"]),
+		Ports:        []provider.InspectedPort{},
+	}
+	switch {
+	case data.HostConfig.NanoCpus > 0:
+		inspection.CPU = int((data.HostConfig.NanoCpus + 999999999) / 1000000000)
+	case data.HostConfig.CpuQuota > 0 && data.HostConfig.CpuPeriod > 0:
+		inspection.CPU = int((data.HostConfig.CpuQuota + data.HostConfig.CpuPeriod - 1) / data.HostConfig.CpuPeriod)
+	}
+
+	networkNames := make([]string, 0, len(data.NetworkSettings.Networks))
+	for name := range data.NetworkSettings.Networks {
+		networkNames = append(networkNames, name)
+	}
+	sort.Strings(networkNames)
+	for _, name := range networkNames {
+		network := data.NetworkSettings.Networks[name]
+		if inspection.PrivateIP == "" {
+			inspection.PrivateIP = network.IPAddress
+		}
+		if inspection.IPv6Address == "" {
+			inspection.IPv6Address = network.GlobalIPv6Address
+		}
+	}
+
+	// 端口绑定的键形如 22/tcp，排序保证顺序稳定
+	bindings := make([]string, 0, len(data.HostConfig.PortBindings))
+	for key := range data.HostConfig.PortBindings {
+		bindings = append(bindings, key)
+	}
+	sort.Strings(bindings)
+	for _, key := range bindings {
+		guest, protocol, _ := strings.Cut(key, "/")
+		guestPort, err := strconv.Atoi(guest)
+		if err != nil {
+			continue
+		}
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		for _, binding := range data.HostConfig.PortBindings[key] {
+			hostPort, err := strconv.Atoi(binding.HostPort)
+			if err != nil || hostPort <= 0 {
+				continue
+			}
+			inspection.Ports = append(inspection.Ports, provider.InspectedPort{
+				HostPort:  hostPort,
+				GuestPort: guestPort,
+				Protocol:  protocol,
+			})
+		}
+	}
+	return inspection, nil
+}
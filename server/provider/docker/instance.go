@@ -761,7 +761,8 @@ func (d *DockerProvider) sshDeleteInstance(ctx context.Context, id string) error
 			name: "system_prune_targeted",
 			commands: []string{
 				fmt.Sprintf("docker rm -f %s", id),
-				"docker system prune -f --volumes",
+				"docker system prune -f --filter label!=" + privateNetworkLabel, // 不清理暂时没有容器的私有网络
+				"docker volume prune -f --filter label!=" + volumeSizeLabel,     // 清理未使用的卷，排除未挂载的数据卷
 			},
 			description: "删除容器并清理系统资源",
 		},
@@ -831,6 +832,7 @@ func (d *DockerProvider) sshDeleteInstance(ctx context.Context, id string) error
 						zap.String("id", utils.TruncateString(id, 32)),
 						zap.String("strategy", strategy.name),
 						zap.Int("retry", retry))
+					d.cleanupSnapshotImages(id)
 					return nil
 				} else {
 					global.APP_LOG.Warn("删除命令执行成功但容器仍存在",
@@ -1161,6 +1163,20 @@ func (d *DockerProvider) configureInstanceSSHPassword(ctx context.Context, confi
 		return fmt.Errorf("设置容器密码失败: %w", err)
 	}
 
+	// 注入用户选择的SSH公钥 - 失败不影响密码登录
+	if err := d.configureInstanceSSHKeys(config); err != nil {
+		global.APP_LOG.Warn("配置容器SSH公钥失败",
+			zap.String("instanceName", config.Name),
+			zap.Error(err))
+	}
+
+	// 执行用户提供的首次启动脚本
+	if err := d.runInstanceUserDataScript(config); err != nil {
+		global.APP_LOG.Warn("执行用户首次启动脚本失败",
+			zap.String("instanceName", config.Name),
+			zap.Error(err))
+	}
+
 	global.APP_LOG.Info("Docker容器SSH密码配置成功",
 		zap.String("instanceName", config.Name))
 
@@ -1180,15 +1196,46 @@ func (d *DockerProvider) configureInstanceSSHPassword(ctx context.Context, confi
 	return nil
 }
 
+// configureInstanceSSHKeys 将用户选择的SSH公钥写入容器root的authorized_keys，并按需禁用SSH密码登录
+func (d *DockerProvider) configureInstanceSSHKeys(config provider.InstanceConfig) error {
+	if !provider.NeedsSSHKeyInjection(config) {
+		return nil
+	}
+	cmd := fmt.Sprintf("docker exec %s sh -c '%s'", config.Name, provider.BuildSSHKeyCommand(config.SSHKeys, config.DisablePasswordLogin))
+	if _, err := d.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("写入SSH公钥失败: %w", err)
+	}
+	global.APP_LOG.Info("容器SSH公钥配置完成",
+		zap.String("instanceName", config.Name),
+		zap.Int("keys", len(config.SSHKeys)),
+		zap.Bool("disablePasswordLogin", config.DisablePasswordLogin))
+	return nil
+}
+
+// runInstanceUserDataScript 容器没有cloud-init，在容器内后台执行用户提供的首次启动脚本
+func (d *DockerProvider) runInstanceUserDataScript(config provider.InstanceConfig) error {
+	if provider.DetectUserDataType(config.UserData) != provider.UserDataTypeScript {
+		return nil
+	}
+	cmd := fmt.Sprintf("docker exec %s sh -c '%s'", config.Name, provider.BuildUserDataCommand(config.UserData, false))
+	if _, err := d.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("执行首次启动脚本失败: %w", err)
+	}
+	global.APP_LOG.Info("容器首次启动脚本已执行",
+		zap.String("instanceName", config.Name),
+		zap.Int("size", len(config.UserData)))
+	return nil
+}
+
 // getContainerPrivateIP 获取容器的内网IP地址
 func (d *DockerProvider) getContainerPrivateIP(containerName string) (string, error) {
-	cmd := fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{$config.IPAddress}}{{end}}'", containerName)
+	cmd := fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{$net}} {{$config.IPAddress}}{{println}}{{end}}'", containerName)
 	output, err := d.sshClient.Execute(cmd)
 	if err != nil {
 		return "", fmt.Errorf("failed to get container IP: %w", err)
 	}
 
-	ipAddress := strings.TrimSpace(output)
+	ipAddress := primaryNetworkIP(output)
 	if ipAddress == "" || ipAddress == "<no value>" {
 		// 尝试使用默认网络
 		cmd = fmt.Sprintf("docker inspect %s --format '{{.NetworkSettings.IPAddress}}'", containerName)
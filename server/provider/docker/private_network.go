@@ -0,0 +1,135 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// 私有网络使用 --internal 的用户自定义网桥，不能访问外网
+// Docker默认隔离不同的自定义网桥，其他租户的容器无法访问该网络
+
+// privateNetworkLabel 私有网络的标签，清理系统资源时跳过带此标签的网络
+const privateNetworkLabel = "oneclickvirt.network"
+
+// CreatePrivateNetwork 创建内部网桥
+func (d *DockerProvider) CreatePrivateNetwork(ctx context.Context, spec provider.PrivateNetworkSpec) error {
+	if err := d.checkPrivateNetworkPrerequisites(); err != nil {
+		return err
+	}
+
+	ipNet, err := provider.ParsePrivateNetworkCIDR(spec.CIDR)
+	if err != nil {
+		return err
+	}
+
+	cmd := fmt.Sprintf("docker network create --driver bridge --internal --subnet %s --gateway %s --label %s=%s %s",
+		ipNet.String(), provider.PrivateNetworkGateway(ipNet), privateNetworkLabel, spec.Name, spec.Name)
+	if output, err := d.sshClient.Execute(cmd); err != nil {
+		if strings.Contains(output, "already exists") {
+			return nil
+		}
+		return fmt.Errorf("failed to create private network: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("Docker私有网络创建成功",
+		zap.String("network", spec.Name),
+		zap.String("cidr", spec.CIDR))
+	return nil
+}
+
+// DeletePrivateNetwork 删除内部网桥
+func (d *DockerProvider) DeletePrivateNetwork(ctx context.Context, spec provider.PrivateNetworkSpec) error {
+	if err := d.checkPrivateNetworkPrerequisites(); err != nil {
+		return err
+	}
+
+	if output, err := d.sshClient.Execute(fmt.Sprintf("docker network rm %s", spec.Name)); err != nil {
+		if strings.Contains(output, "not found") {
+			return nil
+		}
+		return fmt.Errorf("failed to delete private network: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("Docker私有网络删除成功", zap.String("network", spec.Name))
+	return nil
+}
+
+// AttachPrivateNetwork 将容器以固定地址连接到私有网络
+func (d *DockerProvider) AttachPrivateNetwork(ctx context.Context, spec provider.PrivateNetworkSpec) (string, error) {
+	if err := d.checkPrivateNetworkPrerequisites(); err != nil {
+		return "", err
+	}
+
+	cmd := fmt.Sprintf("docker network connect --ip %s %s %s", spec.IPAddress, spec.Name, spec.Instance)
+	if output, err := d.sshClient.Execute(cmd); err != nil {
+		return "", fmt.Errorf("failed to attach private network: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return spec.Name, nil
+}
+
+// DetachPrivateNetwork 断开容器与私有网络的连接
+func (d *DockerProvider) DetachPrivateNetwork(ctx context.Context, spec provider.PrivateNetworkSpec) error {
+	if err := d.checkPrivateNetworkPrerequisites(); err != nil {
+		return err
+	}
+
+	if output, err := d.sshClient.Execute(fmt.Sprintf("docker network disconnect -f %s %s", spec.Name, spec.Instance)); err != nil {
+		// 容器已不存在或未连接时视为已断开
+		if strings.Contains(output, "No such container") || strings.Contains(output, "is not connected") {
+			return nil
+		}
+		return fmt.Errorf("failed to detach private network: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return nil
+}
+
+// checkPrivateNetworkPrerequisites 检查私有网络操作的前置条件
+func (d *DockerProvider) checkPrivateNetworkPrerequisites() error {
+	if !d.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if d.config.ExecutionRule == "api_only" {
+		return fmt.Errorf("Docker provider不支持API调用，无法使用api_only执行规则")
+	}
+	return nil
+}
+
+// primaryNetworkIP 从 "网络名 地址" 列表中取主网络的地址，跳过用户私有网络
+func primaryNetworkIP(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.HasPrefix(fields[0], providerModel.PrivateNetworkNamePrefix) {
+			continue
+		}
+		return fields[1]
+	}
+	return ""
+}
+
+// reconnectPrivateNetworks 重建容器后按原地址重新连接私有网络
+func (d *DockerProvider) reconnectPrivateNetworks(instanceID string, spec *dockerContainerSpec) {
+	for name, network := range spec.NetworkSettings.Networks {
+		if !strings.HasPrefix(name, providerModel.PrivateNetworkNamePrefix) {
+			continue
+		}
+		cmd := fmt.Sprintf("docker network connect %s %s", name, instanceID)
+		if network.IPAMConfig != nil && network.IPAMConfig.IPv4Address != "" {
+			cmd = fmt.Sprintf("docker network connect --ip %s %s %s", network.IPAMConfig.IPv4Address, name, instanceID)
+		}
+		if output, err := d.sshClient.Execute(cmd); err != nil {
+			global.APP_LOG.Warn("重建容器后重新连接私有网络失败",
+				zap.String("instance", utils.TruncateString(instanceID, 32)),
+				zap.String("network", name),
+				zap.String("output", utils.TruncateString(output, 200)),
+				zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// ResizeInstance 使用 docker update 调整容器的CPU和内存限制
+func (d *DockerProvider) ResizeInstance(ctx context.Context, instanceID string, spec provider.ResizeSpec) error {
+	if !d.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if d.config.ExecutionRule == "api_only" {
+		return fmt.Errorf("Docker provider不支持API调用，无法使用api_only执行规则")
+	}
+
+	if spec.Disk > 0 {
+		// storage-opt 只能在创建时指定；存储驱动不支持大小限制时创建时也未限制，直接跳过
+		supportsDiskLimit, storageDriver, err := d.checkStorageDriver()
+		if err != nil {
+			return fmt.Errorf("检查存储驱动失败: %w", err)
+		}
+		if supportsDiskLimit {
+			return fmt.Errorf("Docker不支持调整已有容器的磁盘大小")
+		}
+		global.APP_LOG.Warn("存储驱动不支持硬盘大小限制，跳过磁盘调整",
+			zap.String("instance", utils.TruncateString(instanceID, 32)),
+			zap.String("storage_driver", storageDriver))
+	}
+
+	if spec.Bandwidth > 0 {
+		global.APP_LOG.Warn("Docker容器不支持带宽限制，跳过带宽调整",
+			zap.String("instance", utils.TruncateString(instanceID, 32)),
+			zap.Int("bandwidth", spec.Bandwidth))
+	}
+
+	args := ""
+	if spec.CPU > 0 {
+		args += fmt.Sprintf(" --cpus=%d", spec.CPU)
+	}
+	if spec.Memory > 0 {
+		// 创建时未指定memory-swap，默认为内存的两倍；同时更新避免新内存超过原swap限制导致失败
+		args += fmt.Sprintf(" --memory=%dm --memory-swap=%dm", spec.Memory, spec.Memory*2)
+	}
+	if args == "" {
+		return nil
+	}
+
+	output, err := d.sshClient.Execute(fmt.Sprintf("docker update%s %s", args, instanceID))
+	if err != nil {
+		return fmt.Errorf("failed to resize container: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("Docker实例配置调整成功",
+		zap.String("instance", utils.TruncateString(instanceID, 32)),
+		zap.Int("cpu", spec.CPU),
+		zap.Int64("memory", spec.Memory))
+	return nil
+}
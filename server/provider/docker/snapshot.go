@@ -0,0 +1,326 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// Docker没有原生快照，使用 docker commit 将容器文件系统保存为镜像
+// 快照镜像命名格式: oneclickvirt_snapshot_<实例名>:<快照名>
+
+// dockerContainerSpec docker inspect 中重建容器所需的字段
+type dockerContainerSpec struct {
+	Config struct {
+		Hostname string   `json:"Hostname"`
+		Env      []string `json:"Env"`
+	} `json:"Config"`
+	HostConfig struct {
+		NanoCpus      int64             `json:"NanoCpus"`
+		Memory        int64             `json:"Memory"`
+		StorageOpt    map[string]string `json:"StorageOpt"`
+		Binds         []string          `json:"Binds"`
+		CapAdd        []string          `json:"CapAdd"`
+		Privileged    bool              `json:"Privileged"`
+		NetworkMode   string            `json:"NetworkMode"`
+		RestartPolicy struct {
+			Name string `json:"Name"`
+		} `json:"RestartPolicy"`
+		PortBindings map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"PortBindings"`
+	} `json:"HostConfig"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			IPAMConfig *struct {
+				IPv4Address string `json:"IPv4Address"`
+			} `json:"IPAMConfig"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// CreateSnapshot 创建实例快照
+func (d *DockerProvider) CreateSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	if err := d.checkSnapshotPrerequisites(snapshotName); err != nil {
+		return err
+	}
+
+	image := d.snapshotImageName(instanceID, snapshotName)
+	cmd := fmt.Sprintf("docker commit -m 'oneclickvirt snapshot %s' %s %s", snapshotName, instanceID, image)
+	output, err := d.sshClient.Execute(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("Docker实例快照创建成功",
+		zap.String("instance", utils.TruncateString(instanceID, 32)),
+		zap.String("image", image))
+	return nil
+}
+
+// ListSnapshots 列出实例快照
+func (d *DockerProvider) ListSnapshots(ctx context.Context, instanceID string) ([]provider.Snapshot, error) {
+	if !d.connected {
+		return nil, fmt.Errorf("provider not connected")
+	}
+	if d.config.ExecutionRule == "api_only" {
+		return nil, fmt.Errorf("Docker provider不支持API调用，无法使用api_only执行规则")
+	}
+
+	repository := d.snapshotRepository(instanceID)
+	output, err := d.sshClient.Execute(fmt.Sprintf("docker images %s --format '{{.Tag}}|{{.CreatedAt}}|{{.Size}}'", repository))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var snapshots []provider.Snapshot
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(strings.TrimSpace(line), "|")
+		if len(parts) < 3 || parts[0] == "" || parts[0] == "<none>" {
+			continue
+		}
+
+		snapshot := provider.Snapshot{
+			Name:     parts[0],
+			Size:     parts[2],
+			Metadata: map[string]string{"image": repository + ":" + parts[0]},
+		}
+		// CreatedAt 格式: 2024-01-02 15:04:05 +0000 UTC
+		if created, err := time.Parse("2006-01-02 15:04:05 -0700 MST", parts[1]); err == nil {
+			snapshot.Created = created
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
+
+// RestoreSnapshot 使用快照镜像按原有配置重建容器
+func (d *DockerProvider) RestoreSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	if err := d.checkSnapshotPrerequisites(snapshotName); err != nil {
+		return err
+	}
+
+	image := d.snapshotImageName(instanceID, snapshotName)
+	if !d.imageExists(image) {
+		return fmt.Errorf("snapshot %s not found", snapshotName)
+	}
+
+	spec, err := d.inspectContainerSpec(instanceID)
+	if err != nil {
+		return err
+	}
+	if err := d.recreateContainer(instanceID, image, spec); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+
+	global.APP_LOG.Info("Docker实例快照恢复成功",
+		zap.String("instance", utils.TruncateString(instanceID, 32)),
+		zap.String("image", image))
+	return nil
+}
+
+// DeleteSnapshot 删除实例快照
+func (d *DockerProvider) DeleteSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	if err := d.checkSnapshotPrerequisites(snapshotName); err != nil {
+		return err
+	}
+
+	image := d.snapshotImageName(instanceID, snapshotName)
+	output, err := d.sshClient.Execute(fmt.Sprintf("docker rmi %s", image))
+	if err != nil {
+		// 快照镜像已不存在时视为删除成功
+		if strings.Contains(output, "No such image") || strings.Contains(err.Error(), "No such image") {
+			return nil
+		}
+		return fmt.Errorf("failed to delete snapshot: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("Docker实例快照删除成功",
+		zap.String("instance", utils.TruncateString(instanceID, 32)),
+		zap.String("image", image))
+	return nil
+}
+
+// cleanupSnapshotImages 删除实例的所有快照镜像，实例删除后快照随之清理
+func (d *DockerProvider) cleanupSnapshotImages(instanceID string) {
+	cmd := fmt.Sprintf("docker images %s -q | sort -u | xargs -r docker rmi -f", d.snapshotRepository(instanceID))
+	if output, err := d.sshClient.Execute(cmd); err != nil {
+		global.APP_LOG.Warn("清理Docker实例快照镜像失败",
+			zap.String("instance", utils.TruncateString(instanceID, 32)),
+			zap.String("output", utils.TruncateString(output, 200)),
+			zap.Error(err))
+	}
+}
+
+// inspectContainerSpec 读取重建容器所需的原有配置
+func (d *DockerProvider) inspectContainerSpec(instanceID string) (*dockerContainerSpec, error) {
+	inspectOutput, err := d.sshClient.Execute(fmt.Sprintf("docker inspect %s --format '{{json .}}'", instanceID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	var spec dockerContainerSpec
+	if err := json.Unmarshal([]byte(strings.TrimSpace(inspectOutput)), &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse container spec: %w", err)
+	}
+	return &spec, nil
+}
+
+// recreateContainer 使用指定镜像按spec重建同名容器，保持原有运行状态，新容器创建失败时回滚原容器
+func (d *DockerProvider) recreateContainer(instanceID, image string, spec *dockerContainerSpec) error {
+	// 记录重建前的运行状态，重建后保持一致
+	stateOutput, _ := d.sshClient.Execute(fmt.Sprintf("docker inspect -f '{{.State.Running}}' %s", instanceID))
+	wasRunning := strings.TrimSpace(stateOutput) == "true"
+
+	runCmd := fmt.Sprintf("docker run -d --name %s%s %s", instanceID, buildRunArgsFromSpec(spec), image)
+	backupName := instanceID + "_snapbak"
+
+	// 停止并重命名原容器，新容器创建失败时可以回滚
+	d.sshClient.Execute(fmt.Sprintf("docker stop %s", instanceID))
+	if output, err := d.sshClient.Execute(fmt.Sprintf("docker rename %s %s", instanceID, backupName)); err != nil {
+		if wasRunning {
+			d.sshClient.Execute(fmt.Sprintf("docker start %s", instanceID))
+		}
+		return fmt.Errorf("failed to rename container: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("重建Docker容器",
+		zap.String("instance", utils.TruncateString(instanceID, 32)),
+		zap.String("command", utils.TruncateString(runCmd, 300)))
+
+	if output, err := d.sshClient.Execute(runCmd); err != nil {
+		global.APP_LOG.Error("重建容器失败，回滚原容器",
+			zap.String("instance", utils.TruncateString(instanceID, 32)),
+			zap.String("output", utils.TruncateString(output, 500)),
+			zap.Error(err))
+		d.sshClient.Execute(fmt.Sprintf("docker rm -f %s", instanceID))
+		d.sshClient.Execute(fmt.Sprintf("docker rename %s %s", backupName, instanceID))
+		if wasRunning {
+			d.sshClient.Execute(fmt.Sprintf("docker start %s", instanceID))
+		}
+		return err
+	}
+
+	if !wasRunning {
+		d.sshClient.Execute(fmt.Sprintf("docker stop %s", instanceID))
+	}
+
+	if _, err := d.sshClient.Execute(fmt.Sprintf("docker rm -f %s", backupName)); err != nil {
+		global.APP_LOG.Warn("删除重建前的备份容器失败",
+			zap.String("container", backupName),
+			zap.Error(err))
+	}
+
+	// docker run 只连接主网络，原容器释放地址后再按原地址接回私有网络
+	d.reconnectPrivateNetworks(instanceID, spec)
+
+	// 重建后容器内网IP可能变化，同步到数据库
+	if privateIP, err := d.getContainerPrivateIP(instanceID); err == nil && privateIP != "" {
+		var providerRecord providerModel.Provider
+		if err := global.APP_DB.Where("name = ?", d.config.Name).First(&providerRecord).Error; err == nil {
+			global.APP_DB.Model(&providerModel.Instance{}).
+				Where("name = ? AND provider_id = ?", instanceID, providerRecord.ID).
+				Update("private_ip", privateIP)
+		}
+	}
+	return nil
+}
+
+// checkSnapshotPrerequisites 检查快照操作的前置条件
+func (d *DockerProvider) checkSnapshotPrerequisites(snapshotName string) error {
+	if !d.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if d.config.ExecutionRule == "api_only" {
+		return fmt.Errorf("Docker provider不支持API调用，无法使用api_only执行规则")
+	}
+	if !utils.IsValidSnapshotName(snapshotName) {
+		return fmt.Errorf("invalid snapshot name: %s", snapshotName)
+	}
+	return nil
+}
+
+// snapshotRepository 快照镜像仓库名（Docker仓库名只允许小写）
+func (d *DockerProvider) snapshotRepository(instanceID string) string {
+	return "oneclickvirt_snapshot_" + strings.ToLower(instanceID)
+}
+
+// snapshotImageName 快照镜像完整名称
+func (d *DockerProvider) snapshotImageName(instanceID, snapshotName string) string {
+	return d.snapshotRepository(instanceID) + ":" + snapshotName
+}
+
+// buildRunArgsFromSpec 根据原容器配置构建docker run参数
+func buildRunArgsFromSpec(spec *dockerContainerSpec) string {
+	var args strings.Builder
+	hc := spec.HostConfig
+
+	if spec.Config.Hostname != "" {
+		args.WriteString(fmt.Sprintf(" --hostname %s", spec.Config.Hostname))
+	}
+	if hc.NetworkMode != "" && hc.NetworkMode != "default" && hc.NetworkMode != "bridge" {
+		args.WriteString(fmt.Sprintf(" --network=%s", hc.NetworkMode))
+	}
+	if hc.NanoCpus > 0 {
+		args.WriteString(fmt.Sprintf(" --cpus=%g", float64(hc.NanoCpus)/1e9))
+	}
+	if hc.Memory > 0 {
+		args.WriteString(fmt.Sprintf(" --memory=%db", hc.Memory))
+	}
+	if hc.RestartPolicy.Name != "" && hc.RestartPolicy.Name != "no" {
+		args.WriteString(fmt.Sprintf(" --restart=%s", hc.RestartPolicy.Name))
+	}
+	if hc.Privileged {
+		args.WriteString(" --privileged")
+	}
+
+	// map遍历顺序不固定，排序后生成稳定的命令
+	storageKeys := make([]string, 0, len(hc.StorageOpt))
+	for key := range hc.StorageOpt {
+		storageKeys = append(storageKeys, key)
+	}
+	sort.Strings(storageKeys)
+	for _, key := range storageKeys {
+		args.WriteString(fmt.Sprintf(" --storage-opt %s=%s", key, hc.StorageOpt[key]))
+	}
+
+	containerPorts := make([]string, 0, len(hc.PortBindings))
+	for containerPort := range hc.PortBindings {
+		containerPorts = append(containerPorts, containerPort)
+	}
+	sort.Strings(containerPorts)
+	for _, containerPort := range containerPorts {
+		for _, binding := range hc.PortBindings[containerPort] {
+			hostIP := binding.HostIP
+			if hostIP == "" {
+				hostIP = "0.0.0.0"
+			} else if strings.Contains(hostIP, ":") {
+				hostIP = "[" + hostIP + "]"
+			}
+			args.WriteString(fmt.Sprintf(" -p %s:%s:%s", hostIP, binding.HostPort, containerPort))
+		}
+	}
+
+	for _, bind := range hc.Binds {
+		args.WriteString(fmt.Sprintf(" -v %s", bind))
+	}
+	for _, capability := range hc.CapAdd {
+		args.WriteString(fmt.Sprintf(" --cap-add=%s", capability))
+	}
+	for _, env := range spec.Config.Env {
+		args.WriteString(fmt.Sprintf(" -e '%s'", strings.ReplaceAll(env, "'", `'\''`)))
+	}
+
+	return args.String()
+}
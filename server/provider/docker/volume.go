@@ -0,0 +1,136 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// 数据卷使用Docker命名卷，local驱动不支持限制容量，大小只用于配额统计
+// Docker不支持给已有容器增加挂载，挂载和卸载时先提交容器文件系统为镜像，再按原配置加减 -v 重建容器
+
+// volumeSizeLabel 数据卷标签，记录数据卷大小，同时用于在清理未使用的卷时排除数据卷
+const volumeSizeLabel = "oneclickvirt.size"
+
+// CreateVolume 创建命名卷
+func (d *DockerProvider) CreateVolume(ctx context.Context, spec provider.VolumeSpec) (string, error) {
+	if err := d.checkVolumePrerequisites(); err != nil {
+		return "", err
+	}
+
+	cmd := fmt.Sprintf("docker volume create --label %s=%dM %s", volumeSizeLabel, spec.SizeMB, spec.Name)
+	if output, err := d.sshClient.Execute(cmd); err != nil {
+		return "", fmt.Errorf("failed to create volume: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return "local", nil
+}
+
+// AttachVolume 重建容器并挂载命名卷
+func (d *DockerProvider) AttachVolume(ctx context.Context, spec provider.VolumeSpec) (string, error) {
+	if err := d.checkVolumePrerequisites(); err != nil {
+		return "", err
+	}
+
+	mountPath := spec.MountPath
+	if mountPath == "" {
+		mountPath = provider.DefaultVolumeMountPath(spec.Name)
+	}
+
+	containerSpec, err := d.inspectContainerSpec(spec.Instance)
+	if err != nil {
+		return "", err
+	}
+	containerSpec.HostConfig.Binds = append(removeVolumeBind(containerSpec.HostConfig.Binds, spec.Name), spec.Name+":"+mountPath)
+
+	if err := d.recreateWithVolumes(spec.Instance, containerSpec); err != nil {
+		return "", fmt.Errorf("failed to attach volume: %w", err)
+	}
+	return mountPath, nil
+}
+
+// DetachVolume 重建容器并去掉命名卷挂载
+func (d *DockerProvider) DetachVolume(ctx context.Context, spec provider.VolumeSpec) error {
+	if err := d.checkVolumePrerequisites(); err != nil {
+		return err
+	}
+
+	containerSpec, err := d.inspectContainerSpec(spec.Instance)
+	if err != nil {
+		// 容器已不存在时数据卷自然已卸载
+		if strings.Contains(err.Error(), "No such") {
+			return nil
+		}
+		return err
+	}
+	binds := removeVolumeBind(containerSpec.HostConfig.Binds, spec.Name)
+	if len(binds) == len(containerSpec.HostConfig.Binds) {
+		return nil
+	}
+	containerSpec.HostConfig.Binds = binds
+
+	if err := d.recreateWithVolumes(spec.Instance, containerSpec); err != nil {
+		return fmt.Errorf("failed to detach volume: %w", err)
+	}
+	return nil
+}
+
+// ResizeVolume local驱动的命名卷没有容量限制，只更新记录
+func (d *DockerProvider) ResizeVolume(ctx context.Context, spec provider.VolumeSpec) error {
+	return d.checkVolumePrerequisites()
+}
+
+// DeleteVolume 删除命名卷
+func (d *DockerProvider) DeleteVolume(ctx context.Context, spec provider.VolumeSpec) error {
+	if err := d.checkVolumePrerequisites(); err != nil {
+		return err
+	}
+
+	output, err := d.sshClient.Execute(fmt.Sprintf("docker volume rm %s", spec.Name))
+	if err != nil {
+		if strings.Contains(output, "no such volume") || strings.Contains(err.Error(), "no such volume") {
+			return nil
+		}
+		return fmt.Errorf("failed to delete volume: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("Docker数据卷删除成功", zap.String("volume", spec.Name))
+	return nil
+}
+
+// recreateWithVolumes 提交当前容器文件系统后按新的挂载配置重建容器
+func (d *DockerProvider) recreateWithVolumes(instanceID string, spec *dockerContainerSpec) error {
+	image := "oneclickvirt_volume_" + strings.ToLower(instanceID) + ":latest"
+	if output, err := d.sshClient.Execute(fmt.Sprintf("docker commit %s %s", instanceID, image)); err != nil {
+		return fmt.Errorf("failed to commit container: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return d.recreateContainer(instanceID, image, spec)
+}
+
+// removeVolumeBind 去掉指定命名卷的挂载项
+func removeVolumeBind(binds []string, volumeName string) []string {
+	result := make([]string, 0, len(binds))
+	for _, bind := range binds {
+		if strings.HasPrefix(bind, volumeName+":") {
+			continue
+		}
+		result = append(result, bind)
+	}
+	return result
+}
+
+// checkVolumePrerequisites 检查数据卷操作的前置条件
+func (d *DockerProvider) checkVolumePrerequisites() error {
+	if !d.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if d.config.ExecutionRule == "api_only" {
+		return fmt.Errorf("Docker provider不支持API调用，无法使用api_only执行规则")
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// libvirtConnectURI virsh 连接的系统级libvirt URI
+const libvirtConnectURI = "qemu:///system"
+
+// LibvirtHealthChecker libvirt/KVM健康检查器，libvirt没有对外的HTTP API，所有检查均通过SSH完成
+// API检查项对应 virsh 能否连接到 libvirtd，服务检查项对应 systemd 服务状态
+type LibvirtHealthChecker struct {
+	*BaseHealthChecker
+	sshClient      *utils.SSHClient
+	shouldCloseSSH bool       // 仅当连接由检查器自己创建时才关闭
+	mu             sync.Mutex // 保护sshClient
+}
+
+// NewLibvirtHealthChecker 创建libvirt健康检查器，首次检查时自行建立SSH连接
+func NewLibvirtHealthChecker(config HealthConfig, logger *zap.Logger) *LibvirtHealthChecker {
+	return &LibvirtHealthChecker{
+		BaseHealthChecker: NewBaseHealthChecker(config, logger),
+		shouldCloseSSH:    true,
+	}
+}
+
+// NewLibvirtHealthCheckerWithSSH 创建使用Provider SSH连接的libvirt健康检查器
+func NewLibvirtHealthCheckerWithSSH(config HealthConfig, logger *zap.Logger, sshClient *utils.SSHClient) *LibvirtHealthChecker {
+	return &LibvirtHealthChecker{
+		BaseHealthChecker: NewBaseHealthChecker(config, logger),
+		sshClient:         sshClient,
+		shouldCloseSSH:    false,
+	}
+}
+
+// CheckHealth 执行libvirt健康检查
+func (l *LibvirtHealthChecker) CheckHealth(ctx context.Context) (*HealthResult, error) {
+	checks := []func(context.Context) CheckResult{}
+	if l.config.SSHEnabled {
+		checks = append(checks, l.createCheckFunc(CheckTypeSSH, l.checkSSH))
+	}
+	if l.config.APIEnabled {
+		checks = append(checks, l.createCheckFunc(CheckTypeAPI, l.checkVirsh))
+	}
+	if len(l.config.ServiceChecks) > 0 {
+		checks = append(checks, l.createCheckFunc(CheckTypeService, l.checkServices))
+	}
+
+	result := l.executeChecks(ctx, checks)
+
+	if result.SSHStatus == "online" {
+		if hostname, err := l.execute(ctx, "hostname"); err == nil && strings.TrimSpace(hostname) != "" {
+			result.HostName = strings.TrimSpace(hostname)
+		} else if l.logger != nil {
+			l.logger.Warn("获取libvirt节点hostname失败",
+				zap.String("host", l.config.Host),
+				zap.Error(err))
+		}
+	}
+
+	return result, nil
+}
+
+// checkSSH 检查SSH连接是否可用
+func (l *LibvirtHealthChecker) checkSSH(ctx context.Context) error {
+	output, err := l.execute(ctx, "echo ok")
+	if err != nil {
+		return fmt.Errorf("SSH连接失败: %w", err)
+	}
+	if strings.TrimSpace(output) != "ok" {
+		return fmt.Errorf("SSH命令返回异常: %s", utils.TruncateString(output, 100))
+	}
+	return nil
+}
+
+// checkVirsh 检查virsh能否连接libvirtd
+func (l *LibvirtHealthChecker) checkVirsh(ctx context.Context) error {
+	output, err := l.execute(ctx, fmt.Sprintf("virsh -c %s version", libvirtConnectURI))
+	if err != nil {
+		return fmt.Errorf("virsh无法连接libvirtd: %w", err)
+	}
+	if !strings.Contains(output, "libvirt") {
+		return fmt.Errorf("virsh version输出异常: %s", utils.TruncateString(output, 100))
+	}
+	if l.logger != nil {
+		l.logger.Debug("libvirt连接检查成功", zap.String("host", l.config.Host))
+	}
+	return nil
+}
+
+// checkServices 检查libvirt相关服务，新版本libvirt拆分为模块化守护进程（virtqemud），任一处于active即视为可用
+func (l *LibvirtHealthChecker) checkServices(ctx context.Context) error {
+	for _, service := range l.config.ServiceChecks {
+		cmd := fmt.Sprintf("(systemctl is-active --quiet %s || systemctl is-active --quiet virtqemud) && echo running || echo stopped", service)
+		output, err := l.execute(ctx, cmd)
+		if err != nil {
+			return fmt.Errorf("检查服务 %s 失败: %w", service, err)
+		}
+		if strings.TrimSpace(output) != "running" {
+			return fmt.Errorf("服务 %s 未运行", service)
+		}
+	}
+	return nil
+}
+
+// execute 通过SSH执行命令，没有可用连接时按配置建立连接
+func (l *LibvirtHealthChecker) execute(ctx context.Context, command string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.sshClient == nil {
+		if !l.shouldCloseSSH {
+			return "", fmt.Errorf("external SSH client is nil")
+		}
+		timeout := l.config.Timeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		client, err := utils.NewSSHClient(utils.SSHConfig{
+			Host:           l.config.Host,
+			Port:           l.config.Port,
+			Username:       l.config.Username,
+			Password:       l.config.Password,
+			PrivateKey:     l.config.PrivateKey,
+			ConnectTimeout: timeout,
+			ExecuteTimeout: timeout,
+		})
+		if err != nil {
+			return "", err
+		}
+		l.sshClient = client
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return l.sshClient.Execute(command)
+}
+
+// Close 关闭检查器自己创建的SSH连接
+func (l *LibvirtHealthChecker) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.shouldCloseSSH && l.sshClient != nil {
+		err := l.sshClient.Close()
+		l.sshClient = nil
+		return err
+	}
+	return nil
+}
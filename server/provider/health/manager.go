@@ -13,9 +13,12 @@ type ProviderType string
 
 const (
 	ProviderTypeDocker  ProviderType = "docker"
+	ProviderTypePodman  ProviderType = "podman"
 	ProviderTypeLXD     ProviderType = "lxd"
 	ProviderTypeIncus   ProviderType = "incus"
 	ProviderTypeProxmox ProviderType = "proxmox"
+	ProviderTypeLibvirt ProviderType = "libvirt"
+	ProviderTypeMock    ProviderType = "mock"
 )
 
 // HealthManager 健康检查管理器
@@ -74,6 +77,12 @@ func (hm *HealthManager) CreateChecker(providerType ProviderType, config HealthC
 		checker = NewDockerHealthChecker(configCopy, hm.logger)
 		checkerTypeName = "DockerHealthChecker"
 
+	case ProviderTypePodman:
+		// Podman没有常驻守护进程，所有检查均通过SSH执行podman命令完成
+		configCopy.APIEnabled = false
+		checker = NewPodmanHealthChecker(configCopy, hm.logger)
+		checkerTypeName = "PodmanHealthChecker"
+
 	case ProviderTypeLXD:
 		if configCopy.APIPort == 0 {
 			configCopy.APIPort = 8443
@@ -95,6 +104,15 @@ func (hm *HealthManager) CreateChecker(providerType ProviderType, config HealthC
 		checker = NewProxmoxHealthChecker(configCopy, hm.logger)
 		checkerTypeName = "ProxmoxHealthChecker"
 
+	case ProviderTypeLibvirt:
+		// libvirt没有HTTP API，API检查项通过SSH执行virsh完成
+		checker = NewLibvirtHealthChecker(configCopy, hm.logger)
+		checkerTypeName = "LibvirtHealthChecker"
+
+	case ProviderTypeMock:
+		checker = NewMockHealthChecker(configCopy, hm.logger)
+		checkerTypeName = "MockHealthChecker"
+
 	default:
 		if hm.logger != nil {
 			hm.logger.Error("不支持的Provider类型",
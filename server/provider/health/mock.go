@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// MockHealthChecker 模拟Provider健康检查器，不访问网络，默认健康并返回固定的节点资源信息
+type MockHealthChecker struct {
+	*BaseHealthChecker
+	probe        func(ctx context.Context) error
+	resourceInfo ResourceInfo
+	lastStatus   HealthStatus
+	mu           sync.RWMutex // 保护probe、resourceInfo和lastStatus
+}
+
+// NewMockHealthChecker 创建模拟健康检查器
+func NewMockHealthChecker(config HealthConfig, logger *zap.Logger) *MockHealthChecker {
+	return &MockHealthChecker{
+		BaseHealthChecker: NewBaseHealthChecker(config, logger),
+		resourceInfo: ResourceInfo{
+			CPUCores:        8,
+			MemoryTotal:     16384,
+			SwapTotal:       2048,
+			DiskTotal:       204800,
+			DiskFree:        184320,
+			StoragePoolPath: "/var/lib/mock",
+			HostName:        config.ProviderName,
+		},
+		lastStatus: HealthStatusUnknown,
+	}
+}
+
+// SetProbe 设置检查时调用的探测函数，返回错误时SSH和API检查均失败
+func (m *MockHealthChecker) SetProbe(probe func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.probe = probe
+}
+
+// SetResourceInfo 设置健康检查返回的节点资源信息
+func (m *MockHealthChecker) SetResourceInfo(info ResourceInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resourceInfo = info
+}
+
+// CheckHealth 执行模拟健康检查，每次只调用一次探测函数，结果同时作用于所有启用的检查项
+func (m *MockHealthChecker) CheckHealth(ctx context.Context) (*HealthResult, error) {
+	m.mu.RLock()
+	probe := m.probe
+	info := m.resourceInfo
+	m.mu.RUnlock()
+
+	var probeErr error
+	if probe != nil {
+		probeErr = probe(ctx)
+	}
+	probeFunc := func(context.Context) error { return probeErr }
+
+	config := m.GetConfig()
+	checks := []func(context.Context) CheckResult{}
+	if config.SSHEnabled {
+		checks = append(checks, m.createCheckFunc(CheckTypeSSH, probeFunc))
+	}
+	if config.APIEnabled {
+		checks = append(checks, m.createCheckFunc(CheckTypeAPI, probeFunc))
+	}
+	if len(config.ServiceChecks) > 0 {
+		checks = append(checks, m.createCheckFunc(CheckTypeService, probeFunc))
+	}
+
+	result := m.executeChecks(ctx, checks)
+	if probeErr == nil {
+		now := time.Now()
+		info.Synced = true
+		info.SyncedAt = &now
+		result.ResourceInfo = &info
+		result.HostName = info.HostName
+	}
+
+	m.mu.Lock()
+	m.lastStatus = result.Status
+	m.mu.Unlock()
+	return result, nil
+}
+
+// GetHealthStatus 返回最近一次检查的健康状态
+func (m *MockHealthChecker) GetHealthStatus() HealthStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastStatus
+}
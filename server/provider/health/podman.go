@@ -0,0 +1,139 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// PodmanHealthChecker Podman健康检查器
+// Podman没有常驻守护进程和默认开放的HTTP API，所有检查均通过SSH完成，服务检查项对应 podman 命令能否正常访问存储
+type PodmanHealthChecker struct {
+	*BaseHealthChecker
+	sshClient      *utils.SSHClient
+	shouldCloseSSH bool       // 仅当连接由检查器自己创建时才关闭
+	mu             sync.Mutex // 保护sshClient
+}
+
+// NewPodmanHealthChecker 创建Podman健康检查器，首次检查时自行建立SSH连接
+func NewPodmanHealthChecker(config HealthConfig, logger *zap.Logger) *PodmanHealthChecker {
+	return &PodmanHealthChecker{
+		BaseHealthChecker: NewBaseHealthChecker(config, logger),
+		shouldCloseSSH:    true,
+	}
+}
+
+// NewPodmanHealthCheckerWithSSH 创建使用Provider SSH连接的Podman健康检查器
+func NewPodmanHealthCheckerWithSSH(config HealthConfig, logger *zap.Logger, sshClient *utils.SSHClient) *PodmanHealthChecker {
+	return &PodmanHealthChecker{
+		BaseHealthChecker: NewBaseHealthChecker(config, logger),
+		sshClient:         sshClient,
+		shouldCloseSSH:    false,
+	}
+}
+
+// CheckHealth 执行Podman健康检查
+func (p *PodmanHealthChecker) CheckHealth(ctx context.Context) (*HealthResult, error) {
+	checks := []func(context.Context) CheckResult{}
+	if p.config.SSHEnabled {
+		checks = append(checks, p.createCheckFunc(CheckTypeSSH, p.checkSSH))
+	}
+	if len(p.config.ServiceChecks) > 0 {
+		checks = append(checks, p.createCheckFunc(CheckTypeService, p.checkPodman))
+	}
+
+	result := p.executeChecks(ctx, checks)
+
+	if result.SSHStatus == "online" {
+		if hostname, err := p.execute(ctx, "hostname"); err == nil && strings.TrimSpace(hostname) != "" {
+			result.HostName = strings.TrimSpace(hostname)
+		} else if p.logger != nil {
+			p.logger.Warn("获取Podman节点hostname失败",
+				zap.String("host", p.config.Host),
+				zap.Error(err))
+		}
+	}
+
+	return result, nil
+}
+
+// checkSSH 检查SSH连接是否可用
+func (p *PodmanHealthChecker) checkSSH(ctx context.Context) error {
+	output, err := p.execute(ctx, "echo ok")
+	if err != nil {
+		return fmt.Errorf("SSH连接失败: %w", err)
+	}
+	if strings.TrimSpace(output) != "ok" {
+		return fmt.Errorf("SSH命令返回异常: %s", utils.TruncateString(output, 100))
+	}
+	return nil
+}
+
+// checkPodman 检查podman命令是否可用，podman info 会实际访问容器存储，比 podman --version 更能反映可用性
+func (p *PodmanHealthChecker) checkPodman(ctx context.Context) error {
+	output, err := p.execute(ctx, "podman info --format '{{.Version.Version}}'")
+	if err != nil {
+		return fmt.Errorf("Podman不可用: %w", err)
+	}
+	if strings.TrimSpace(output) == "" {
+		return fmt.Errorf("podman info输出为空")
+	}
+	if p.logger != nil {
+		p.logger.Debug("Podman服务检查成功",
+			zap.String("host", p.config.Host),
+			zap.String("version", strings.TrimSpace(output)))
+	}
+	return nil
+}
+
+// execute 通过SSH执行命令，没有可用连接时按配置建立连接
+func (p *PodmanHealthChecker) execute(ctx context.Context, command string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sshClient == nil {
+		if !p.shouldCloseSSH {
+			return "", fmt.Errorf("external SSH client is nil")
+		}
+		timeout := p.config.Timeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		client, err := utils.NewSSHClient(utils.SSHConfig{
+			Host:           p.config.Host,
+			Port:           p.config.Port,
+			Username:       p.config.Username,
+			Password:       p.config.Password,
+			PrivateKey:     p.config.PrivateKey,
+			ConnectTimeout: timeout,
+			ExecuteTimeout: timeout,
+		})
+		if err != nil {
+			return "", err
+		}
+		p.sshClient = client
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return p.sshClient.Execute(command)
+}
+
+// Close 关闭检查器自己创建的SSH连接
+func (p *PodmanHealthChecker) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.shouldCloseSSH && p.sshClient != nil {
+		err := p.sshClient.Close()
+		p.sshClient = nil
+		return err
+	}
+	return nil
+}
@@ -19,6 +19,10 @@ func (phc *ProviderHealthChecker) DetectStoragePoolPath(client *ssh.Client, prov
 		return phc.detectIncusStoragePath(client, storagePoolName)
 	case "docker":
 		return phc.detectDockerStoragePath(client)
+	case "podman":
+		return phc.detectPodmanStoragePath(client)
+	case "libvirt":
+		return phc.detectLibvirtStoragePath(client, storagePoolName)
 	default:
 		// 默认返回根目录
 		if phc.logger != nil {
@@ -198,6 +202,56 @@ func (phc *ProviderHealthChecker) detectDockerStoragePath(client *ssh.Client) (s
 	return defaultPath, nil
 }
 
+// detectPodmanStoragePath 检测Podman存储路径（rootful模式的graphRoot）
+func (phc *ProviderHealthChecker) detectPodmanStoragePath(client *ssh.Client) (string, error) {
+	cmd := "podman info --format '{{.Store.GraphRoot}}' 2>/dev/null"
+	output, err := phc.executeSSHCommand(client, cmd)
+	if err == nil && strings.TrimSpace(output) != "" {
+		path := strings.TrimSpace(output)
+		if phc.logger != nil {
+			phc.logger.Info("检测到Podman存储路径",
+				zap.String("path", path))
+		}
+		return path, nil
+	}
+
+	defaultPath := "/var/lib/containers/storage"
+	if phc.logger != nil {
+		phc.logger.Info("使用Podman默认存储路径",
+			zap.String("path", defaultPath))
+	}
+	return defaultPath, nil
+}
+
+// detectLibvirtStoragePath 检测libvirt存储池路径
+func (phc *ProviderHealthChecker) detectLibvirtStoragePath(client *ssh.Client, storagePoolName string) (string, error) {
+	// Provider表的存储池默认值local来自Proxmox，libvirt的默认存储池名为default
+	if storagePoolName == "" || storagePoolName == "local" {
+		storagePoolName = "default"
+	}
+
+	cmd := fmt.Sprintf("virsh -c qemu:///system pool-dumpxml %s 2>/dev/null | grep -oP '(?<=<path>).*(?=</path>)' | head -1", storagePoolName)
+	output, err := phc.executeSSHCommand(client, cmd)
+	if err == nil && strings.TrimSpace(output) != "" {
+		path := strings.TrimSpace(output)
+		if phc.logger != nil {
+			phc.logger.Info("检测到libvirt存储池路径",
+				zap.String("storagePool", storagePoolName),
+				zap.String("path", path))
+		}
+		return path, nil
+	}
+
+	// 默认路径
+	defaultPath := "/var/lib/libvirt/images"
+	if phc.logger != nil {
+		phc.logger.Info("使用libvirt默认存储池路径",
+			zap.String("storagePool", storagePoolName),
+			zap.String("path", defaultPath))
+	}
+	return defaultPath, nil
+}
+
 // getDiskInfoByPath 根据指定路径获取磁盘信息
 func (phc *ProviderHealthChecker) getDiskInfoByPath(client *ssh.Client, path string) (total int64, free int64, err error) {
 	// 如果没有指定路径，使用根目录
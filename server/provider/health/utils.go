@@ -115,6 +115,11 @@ func (phc *ProviderHealthChecker) CheckProviderHealthWithAuthConfig(ctx context.
 		config.APIPort = 2375
 		config.APIScheme = "http"
 		config.ServiceChecks = []string{"docker"}
+	case "podman":
+		config.APIEnabled = false
+		config.ServiceChecks = []string{"podman"}
+	case "libvirt":
+		config.ServiceChecks = []string{"libvirtd"}
 	}
 
 	// 创建checker前再次记录配置，确保config.Host正确
@@ -147,6 +152,10 @@ func (phc *ProviderHealthChecker) CheckProviderHealthWithAuthConfig(ctx context.
 		c.Close()
 	case *ProxmoxHealthChecker:
 		c.Close()
+	case *LibvirtHealthChecker:
+		c.Close()
+	case *PodmanHealthChecker:
+		c.Close()
 	}
 
 	sshStatus := "unknown"
@@ -197,6 +206,11 @@ func (phc *ProviderHealthChecker) CheckProviderHealthFromConfig(ctx context.Cont
 		config.APIPort = 8006
 		config.APIScheme = "https"
 		config.ServiceChecks = []string{"pvestatd", "pvedaemon", "pveproxy"}
+	case "podman":
+		config.APIEnabled = false
+		config.ServiceChecks = []string{"podman"}
+	case "libvirt":
+		config.ServiceChecks = []string{"libvirtd"}
 	}
 	checker, err := phc.manager.CreateChecker(ProviderType(providerType), config)
 	if err != nil {
@@ -215,6 +229,10 @@ func (phc *ProviderHealthChecker) CheckProviderHealthFromConfig(ctx context.Cont
 		c.Close()
 	case *ProxmoxHealthChecker:
 		c.Close()
+	case *LibvirtHealthChecker:
+		c.Close()
+	case *PodmanHealthChecker:
+		c.Close()
 	}
 	sshStatus := "unknown"
 	apiStatus := "unknown"
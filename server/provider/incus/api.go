@@ -243,6 +243,9 @@ func (i *IncusProvider) apiCreateInstanceWithProgress(ctx context.Context, confi
 	if config.Memory != "" {
 		instanceConfig["config"].(map[string]interface{})["limits.memory"] = config.Memory
 	}
+	if config.UserData != "" {
+		instanceConfig["config"].(map[string]interface{})["cloud-init.user-data"] = config.UserData
+	}
 	if config.Disk != "" {
 		instanceConfig["devices"].(map[string]interface{})["root"] = map[string]interface{}{
 			"type": "disk",
@@ -301,6 +304,11 @@ func (i *IncusProvider) apiCreateInstanceWithProgress(ctx context.Context, confi
 		}
 	}
 
+	// 注入用户选择的SSH公钥，需在设置密码之后执行，以便按需禁用密码登录
+	if err := i.configureInstanceSSHKeys(config); err != nil {
+		return fmt.Errorf("配置实例SSH公钥失败: %w", err)
+	}
+
 	updateProgress(100, "Incus API实例创建完成")
 	global.APP_LOG.Info("Incus API实例创建成功", zap.String("name", config.Name))
 	return nil
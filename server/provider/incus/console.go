@@ -0,0 +1,116 @@
+package incus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// consoleOperation POST /1.0/instances/<name>/console 返回的异步操作
+type consoleOperation struct {
+	Operation string `json:"operation"`
+	Error     string `json:"error"`
+	Metadata  struct {
+		Metadata struct {
+			Fds map[string]string `json:"fds"`
+		} `json:"metadata"`
+	} `json:"metadata"`
+}
+
+// OpenConsole 打开实例控制台
+// 配置了API证书时连接 /console 操作的websocket，否则通过SSH运行 incus console
+func (i *IncusProvider) OpenConsole(ctx context.Context, instanceID, consoleType string) (provider.ConsoleSession, error) {
+	if !i.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+	// Incus的图形控制台是SPICE协议，浏览器端无法直接使用
+	if consoleType != provider.ConsoleTypeSerial {
+		return nil, fmt.Errorf("Incus节点不支持%s控制台", consoleType)
+	}
+
+	if i.shouldUseAPI() {
+		session, err := i.apiOpenConsole(ctx, instanceID)
+		if err == nil {
+			return session, nil
+		}
+		global.APP_LOG.Warn("Incus API打开控制台失败", zap.String("instance", instanceID), zap.Error(err))
+		if !i.shouldFallbackToSSH() {
+			return nil, fmt.Errorf("API调用失败且不允许回退到SSH: %w", err)
+		}
+	}
+
+	if !i.shouldUseSSH() {
+		return nil, fmt.Errorf("执行规则不允许使用SSH")
+	}
+	return provider.NewSSHConsole(i.sshClient, fmt.Sprintf("incus console %s", instanceID))
+}
+
+// apiOpenConsole 创建console操作并连接数据和控制websocket
+func (i *IncusProvider) apiOpenConsole(ctx context.Context, instanceID string) (provider.ConsoleSession, error) {
+	body, _ := json.Marshal(map[string]interface{}{"type": "console", "width": 80, "height": 24})
+	apiURL := fmt.Sprintf("https://%s:8443/1.0/instances/%s/console", i.config.Host, instanceID)
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := i.apiClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var op consoleOperation
+	if err := json.NewDecoder(resp.Body).Decode(&op); err != nil {
+		return nil, fmt.Errorf("解析console操作失败: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("创建console操作失败: %d %s", resp.StatusCode, op.Error)
+	}
+	dataSecret, controlSecret := op.Metadata.Metadata.Fds["0"], op.Metadata.Metadata.Fds["control"]
+	if op.Operation == "" || dataSecret == "" || controlSecret == "" {
+		return nil, fmt.Errorf("console操作缺少websocket密钥")
+	}
+
+	dialer := websocket.Dialer{TLSClientConfig: i.transport.TLSClientConfig, HandshakeTimeout: 10 * time.Second}
+	websocketURL := func(secret string) string {
+		return fmt.Sprintf("wss://%s:8443%s/websocket?secret=%s", i.config.Host, op.Operation, url.QueryEscape(secret))
+	}
+
+	control, _, err := dialer.DialContext(ctx, websocketURL(controlSecret), nil)
+	if err != nil {
+		return nil, fmt.Errorf("连接控制websocket失败: %w", err)
+	}
+	data, _, err := dialer.DialContext(ctx, websocketURL(dataSecret), nil)
+	if err != nil {
+		control.Close()
+		return nil, fmt.Errorf("连接数据websocket失败: %w", err)
+	}
+
+	return &provider.WebSocketConsole{
+		Conn: data,
+		ResizeFunc: func(rows, cols int) error {
+			return control.WriteJSON(map[string]interface{}{
+				"command": "window-resize",
+				"args":    map[string]string{"width": strconv.Itoa(cols), "height": strconv.Itoa(rows)},
+			})
+		},
+		OnClose: func() {
+			// 关闭控制连接后Incus结束console操作
+			control.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			control.Close()
+		},
+	}, nil
+}
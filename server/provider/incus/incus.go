@@ -19,6 +19,8 @@ import (
 )
 
 type IncusProvider struct {
+	provider.CapabilityCache
+
 	config        provider.NodeConfig
 	sshClient     *utils.SSHClient
 	apiClient     *http.Client
@@ -146,6 +148,11 @@ func (i *IncusProvider) Connect(ctx context.Context, config provider.NodeConfig)
 			zap.Error(err))
 	}
 
+	// 探测节点能力，失败时保留上一次结果
+	if _, err := i.ProbeCapabilities(ctx); err != nil {
+		global.APP_LOG.Warn("Incus节点能力探测失败", zap.Error(err))
+	}
+
 	global.APP_LOG.Info("Incus provider SSH连接成功",
 		zap.String("host", utils.TruncateString(config.Host, 32)),
 		zap.Int("port", config.Port),
@@ -7,6 +7,9 @@ import (
 	"time"
 
 	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	"oneclickvirt/provider/portmapping/nftables"
 
 	"go.uber.org/zap"
 )
@@ -20,6 +23,7 @@ type IPv6Config struct {
 	Interface        string
 	Gateway          string
 	UseIptables      bool
+	UseNftables      bool
 	UseNetworkDevice bool
 }
 
@@ -613,6 +617,7 @@ func (i *IncusProvider) configureIPv6Network(ctx context.Context, containerName
 		Gateway:          gatewayInfo,
 		UseNetworkDevice: portMappingMethod == "device_proxy", // device_proxy使用网络设备方式
 		UseIptables:      portMappingMethod == "iptables",     // iptables使用iptables方式
+		UseNftables:      portMappingMethod == "nftables",     // nftables使用地址映射集合
 	}
 
 	var containerIPv6 string
@@ -622,11 +627,11 @@ func (i *IncusProvider) configureIPv6Network(ctx context.Context, containerName
 		if err != nil {
 			return fmt.Errorf("使用device_proxy方式配置IPv6网络失败: %w", err)
 		}
-	} else if config.UseIptables {
-		// 使用iptables方式配置IPv6映射
+	} else if config.UseIptables || config.UseNftables {
+		// 使用iptables或nftables方式配置IPv6映射，两者只在NAT规则的下发方式上不同
 		containerIPv6, err = i.setupIptablesIPv6(ctx, config)
 		if err != nil {
-			return fmt.Errorf("使用iptables方式配置IPv6网络失败: %w", err)
+			return fmt.Errorf("使用%s方式配置IPv6网络失败: %w", portMappingMethod, err)
 		}
 	} else {
 		// 默认使用device_proxy方式
@@ -673,6 +678,11 @@ func (i *IncusProvider) setupIptablesIPv6(ctx context.Context, config IPv6Config
 		}
 	}
 
+	// nftables方式直接写入地址映射集合，不经过firewalld
+	if config.UseNftables {
+		useFirewalld = false
+	}
+
 	// 安装必要的包
 	err = i.installNetfilterPackages(ctx, osType, useFirewalld)
 	if err != nil {
@@ -753,7 +763,9 @@ func (i *IncusProvider) setupIptablesIPv6(ctx context.Context, config IPv6Config
 
 		// 检查firewall或iptables规则
 		var checkRuleCmd string
-		if useFirewalld {
+		if config.UseNftables {
+			checkRuleCmd = nftables.AddressExistsCommand(testIPv6)
+		} else if useFirewalld {
 			checkRuleCmd = fmt.Sprintf("firewall-cmd --direct --query-rule ipv6 nat PREROUTING 0 -d %s -j DNAT --to-destination %s", testIPv6, containerIPv6)
 		} else {
 			checkRuleCmd = fmt.Sprintf("ip6tables -t nat -C PREROUTING -d %s -j DNAT --to-destination %s 2>/dev/null", testIPv6, containerIPv6)
@@ -781,8 +793,15 @@ func (i *IncusProvider) setupIptablesIPv6(ctx context.Context, config IPv6Config
 		return "", fmt.Errorf("添加IPv6地址失败: %w", err)
 	}
 
-	// 防火墙/iptables规则
-	if useFirewalld {
+	// 防火墙/iptables/nftables规则
+	if config.UseNftables {
+		// nftables地址映射，命令中已包含持久化
+		for _, cmd := range nftables.AddressApplyCommands(mappedIPv6, containerIPv6) {
+			if _, err := i.sshClient.Execute(cmd); err != nil {
+				return "", fmt.Errorf("添加nftables IPv6地址映射失败: %w", err)
+			}
+		}
+	} else if useFirewalld {
 		// 启用firewalld
 		i.sshClient.Execute("systemctl enable --now firewalld")
 		time.Sleep(3 * time.Second)
@@ -815,9 +834,11 @@ func (i *IncusProvider) setupIptablesIPv6(ctx context.Context, config IPv6Config
 	}
 
 	// 保存规则
-	err = i.saveNetfilterRules(ctx, useFirewalld)
-	if err != nil {
-		global.APP_LOG.Warn("保存防火墙规则失败", zap.Error(err))
+	if !config.UseNftables {
+		err = i.saveNetfilterRules(ctx, useFirewalld)
+		if err != nil {
+			global.APP_LOG.Warn("保存防火墙规则失败", zap.Error(err))
+		}
 	}
 
 	// 测试连通性
@@ -993,3 +1014,20 @@ func (i *IncusProvider) testIPv6Connectivity(ctx context.Context, ipv6Addr, cont
 
 	return nil
 }
+
+// AllocateInstanceIPv6 按节点网络配置为已有实例分配IPv6，用于实例迁移到本节点后重新分配地址
+func (i *IncusProvider) AllocateInstanceIPv6(ctx context.Context, instanceName string) (string, string, error) {
+	var providerInfo providerModel.Provider
+	if err := global.APP_DB.Where("name = ?", i.config.Name).First(&providerInfo).Error; err != nil {
+		return "", "", fmt.Errorf("获取节点配置失败: %w", err)
+	}
+	if !provider.NetworkTypeHasIPv6(providerInfo.NetworkType) {
+		return "", "", nil
+	}
+	if err := i.configureIPv6Network(ctx, instanceName, true, providerInfo.IPv6PortMappingMethod); err != nil {
+		return "", "", err
+	}
+	ipv6Address, _ := i.GetInstanceIPv6(ctx, instanceName)
+	publicIPv6, _ := i.GetInstancePublicIPv6(ctx, instanceName)
+	return ipv6Address, publicIPv6, nil
+}
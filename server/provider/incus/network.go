@@ -23,8 +23,8 @@ type NetworkConfig struct {
 	InSpeed               int    // 入站速度（Mbps）- 从Provider配置或用户等级获取
 	OutSpeed              int    // 出站速度（Mbps）- 从Provider配置或用户等级获取
 	NetworkType           string // 网络配置类型：nat_ipv4, nat_ipv4_ipv6, dedicated_ipv4, dedicated_ipv4_ipv6, ipv6_only
-	IPv4PortMappingMethod string // IPv4端口映射方式：device_proxy, iptables, native
-	IPv6PortMappingMethod string // IPv6端口映射方式：device_proxy, iptables, native
+	IPv4PortMappingMethod string // IPv4端口映射方式：device_proxy, iptables, nftables, native
+	IPv6PortMappingMethod string // IPv6端口映射方式：device_proxy, iptables, nftables, native
 }
 
 // parseNetworkConfigFromInstanceConfig 从实例配置中解析网络配置
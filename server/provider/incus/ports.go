@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider/portmapping/nftables"
 	"sort"
 	"strings"
 
@@ -136,6 +137,55 @@ func (i *IncusProvider) configurePortMappingsWithIP(ctx context.Context, instanc
 	return nil
 }
 
+// setupNftablesMappings 使用nftables映射集合设置端口映射，所有元素整批提交并持久化
+func (i *IncusProvider) setupNftablesMappings(instanceName string, ports []providerModel.Port, instanceIP string) error {
+	mappings := make([]nftables.Mapping, 0, len(ports))
+	for _, port := range ports {
+		mappings = append(mappings, nftables.Mapping{
+			Protocol:    port.Protocol,
+			HostPort:    port.HostPort,
+			HostPortEnd: port.HostPortEnd,
+			GuestPort:   port.GuestPort,
+			TargetIP:    instanceIP,
+		})
+	}
+
+	for _, cmd := range nftables.ApplyCommands(mappings) {
+		if output, err := i.sshClient.Execute(cmd); err != nil {
+			return fmt.Errorf("添加nftables端口映射失败: %w, output: %s", err, output)
+		}
+	}
+
+	global.APP_LOG.Info("Nftables端口映射设置成功",
+		zap.String("instance", instanceName),
+		zap.Int("count", len(mappings)))
+
+	return nil
+}
+
+// removeNftablesMapping 移除nftables端口映射，按端口记录的地址族和端口段删除元素
+func (i *IncusProvider) removeNftablesMapping(instanceName string, hostPort int, protocol string) error {
+	mapping := nftables.Mapping{Protocol: protocol, HostPort: hostPort}
+	var port providerModel.Port
+	if err := global.APP_DB.Where("provider_id = ? AND host_port = ? AND protocol = ?", i.providerID, hostPort, protocol).
+		First(&port).Error; err == nil {
+		mapping.HostPortEnd = port.HostPortEnd
+		mapping.GuestPort = port.GuestPort
+		mapping.TargetIP = port.IPv6Address
+	}
+	for _, cmd := range nftables.RemoveCommands([]nftables.Mapping{mapping}) {
+		if _, err := i.sshClient.Execute(cmd); err != nil {
+			return fmt.Errorf("移除nftables端口映射失败: %w", err)
+		}
+	}
+
+	global.APP_LOG.Info("Nftables端口映射移除成功",
+		zap.String("instance", instanceName),
+		zap.Int("hostPort", hostPort))
+
+	return nil
+}
+
 // configureFirewallPorts 配置防火墙端口
 func (i *IncusProvider) configureFirewallPorts(instanceName string) error {
 	// 获取实例的端口映射信息
@@ -247,6 +297,8 @@ func (i *IncusProvider) setupPortMappingWithIP(instanceName string, hostPort, gu
 		return i.setupDeviceProxyMappingWithIP(instanceName, hostPort, guestPort, protocol)
 	case "iptables":
 		return i.setupIptablesMappingWithIP(instanceName, hostPort, guestPort, protocol, instanceIP)
+	case "nftables":
+		return i.setupNftablesMappings(instanceName, []providerModel.Port{{HostPort: hostPort, GuestPort: guestPort, Protocol: protocol}}, instanceIP)
 	case "native":
 		// 独立IPv4模式下使用native方法，跳过端口映射
 		global.APP_LOG.Info("独立IPv4模式，跳过端口映射",
@@ -371,6 +423,10 @@ func (i *IncusProvider) setupPortRangeMappingWithIP(instanceName string, ports [
 	if len(ports) == 0 {
 		return nil
 	}
+	// nftables方式下所有端口作为一批元素原子提交，端口段只占一个元素
+	if method == "nftables" {
+		return i.setupNftablesMappings(instanceName, ports, instanceIP)
+	}
 
 	// 按端口号排序
 	sort.Slice(ports, func(i, j int) bool {
@@ -538,6 +594,8 @@ func (i *IncusProvider) removePortMapping(instanceName string, hostPort int, pro
 		return i.removeDeviceProxyMapping(instanceName, hostPort, protocol)
 	case "iptables":
 		return i.removeIptablesMappingByPort(instanceName, hostPort, protocol)
+	case "nftables":
+		return i.removeNftablesMapping(instanceName, hostPort, protocol)
 	default:
 		// 默认使用device proxy方式
 		return i.removeDeviceProxyMapping(instanceName, hostPort, protocol)
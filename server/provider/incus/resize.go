@@ -0,0 +1,100 @@
+package incus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// ResizeInstance 调整实例的CPU、内存、磁盘和带宽配置
+func (i *IncusProvider) ResizeInstance(ctx context.Context, instanceID string, spec provider.ResizeSpec) error {
+	if !i.connected {
+		return fmt.Errorf("provider not connected")
+	}
+
+	if spec.CPU > 0 {
+		if err := i.setInstanceConfig(ctx, instanceID, "limits.cpu", fmt.Sprintf("%d", spec.CPU)); err != nil {
+			return fmt.Errorf("调整CPU失败: %w", err)
+		}
+	}
+
+	if spec.Memory > 0 {
+		if err := i.setInstanceConfig(ctx, instanceID, "limits.memory", fmt.Sprintf("%dMiB", spec.Memory)); err != nil {
+			return fmt.Errorf("调整内存失败: %w", err)
+		}
+	}
+
+	// 根磁盘和网卡通常继承自profile，需要先override到实例上才能修改，只能通过SSH执行
+	if spec.Disk > 0 || spec.Bandwidth > 0 {
+		if !i.shouldUseSSH() {
+			return fmt.Errorf("执行规则不允许使用SSH，无法调整磁盘和带宽")
+		}
+	}
+
+	if spec.Disk > 0 {
+		if err := i.setOrOverrideDevice(instanceID, "root", map[string]string{
+			"size": fmt.Sprintf("%dMiB", spec.Disk),
+		}); err != nil {
+			return fmt.Errorf("调整磁盘失败: %w", err)
+		}
+	}
+
+	if spec.Bandwidth > 0 {
+		limit := fmt.Sprintf("%dMbit", spec.Bandwidth)
+		if err := i.setOrOverrideDevice(instanceID, "eth0", map[string]string{
+			"limits.egress":  limit,
+			"limits.ingress": limit,
+			"limits.max":     limit,
+		}); err != nil {
+			return fmt.Errorf("调整带宽失败: %w", err)
+		}
+	}
+
+	global.APP_LOG.Info("Incus实例配置调整成功",
+		zap.String("instance", utils.TruncateString(instanceID, 50)),
+		zap.Int("cpu", spec.CPU),
+		zap.Int64("memory", spec.Memory),
+		zap.Int64("disk", spec.Disk),
+		zap.Int("bandwidth", spec.Bandwidth))
+	return nil
+}
+
+// setOrOverrideDevice 修改实例设备配置，设备来自profile时使用override复制到实例上
+func (i *IncusProvider) setOrOverrideDevice(instanceName, deviceName string, options map[string]string) error {
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// 设备已在实例本地时可以直接set
+	setFailed := false
+	for _, key := range keys {
+		cmd := fmt.Sprintf("incus config device set %s %s %s=%s", instanceName, deviceName, key, options[key])
+		if _, err := i.sshClient.Execute(cmd); err != nil {
+			setFailed = true
+			break
+		}
+	}
+	if !setFailed {
+		return nil
+	}
+
+	params := make([]string, 0, len(keys))
+	for _, key := range keys {
+		params = append(params, fmt.Sprintf("%s=%s", key, options[key]))
+	}
+	cmd := fmt.Sprintf("incus config device override %s %s %s", instanceName, deviceName, strings.Join(params, " "))
+	output, err := i.sshClient.Execute(cmd)
+	if err != nil {
+		return fmt.Errorf("%w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return nil
+}
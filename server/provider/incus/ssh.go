@@ -272,6 +272,11 @@ func (i *IncusProvider) sshCreateInstanceWithProgress(ctx context.Context, confi
 		global.APP_LOG.Warn("配置实例安全设置失败，但继续", zap.Error(err))
 	}
 
+	// 用户提供的cloud-init配置需要在首次启动前写入
+	if err := i.setInstanceUserData(config); err != nil {
+		global.APP_LOG.Warn("配置实例user-data失败，但继续", zap.Error(err))
+	}
+
 	updateProgress(50, "启动实例...")
 	// 启动实例
 	_, err = i.sshClient.Execute(fmt.Sprintf("incus start %s", config.Name))
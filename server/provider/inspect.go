@@ -0,0 +1,220 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// InspectedPort 实例自身声明的端口转发（如LXD/Incus的proxy设备、Docker端口绑定）
+type InspectedPort struct {
+	HostPort     int    `json:"hostPort"`
+	HostPortEnd  int    `json:"hostPortEnd"` // 0表示单端口
+	GuestPort    int    `json:"guestPort"`
+	GuestPortEnd int    `json:"guestPortEnd"` // 0表示单端口
+	Protocol     string `json:"protocol"`     // tcp, udp
+}
+
+// InstanceInspection 节点上实例的实际配置，用于接管不是由面板创建的实例
+// 无法读取的资源字段为0，由调用方使用其他来源的值
+type InstanceInspection struct {
+	InstanceType string          `json:"instanceType"` // container, vm
+	Image        string          `json:"image"`
+	CPU          int             `json:"cpu"`
+	Memory       int64           `json:"memory"` // MB
+	Disk         int64           `json:"disk"`   // MB
+	PrivateIP    string          `json:"privateIP"`
+	IPv6Address  string          `json:"ipv6Address"`
+	Ports        []InspectedPort `json:"ports"`
+}
+
+// InstanceInspector 能够读取实例实际配置的Provider实现此接口
+type InstanceInspector interface {
+	InspectInstance(ctx context.Context, instanceName string) (*InstanceInspection, error)
+}
+
+// ParseSizeMB 解析带单位的容量字符串为MB，支持 B/KB/MB/GB/TB 及 KiB/MiB/GiB/TiB，无单位时按字节处理，无法解析时返回0
+func ParseSizeMB(value string) int64 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	upper := strings.ToUpper(value)
+	number := strings.TrimRight(upper, "KMGTIB")
+	unit := strings.TrimSpace(upper[len(number):])
+	n, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	switch unit {
+	case "", "B":
+		return int64(n / 1024 / 1024)
+	case "K", "KB", "KIB":
+		return int64(n / 1024)
+	case "M", "MB", "MIB":
+		return int64(n)
+	case "G", "GB", "GIB":
+		return int64(n * 1024)
+	case "T", "TB", "TIB":
+		return int64(n * 1024 * 1024)
+	default:
+		return 0
+	}
+}
+
+// ParseCPULimit 解析CPU限制，支持核心数（"2"）和核心列表（"0-1,3"），无法解析时返回0
+func ParseCPULimit(value string) int {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		return n
+	}
+	count := 0
+	for _, part := range strings.Split(value, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return 0
+		}
+		end := start
+		if len(bounds) == 2 {
+			if end, err = strconv.Atoi(bounds[1]); err != nil || end < start {
+				return 0
+			}
+		}
+		count += end - start + 1
+	}
+	return count
+}
+
+// ParseProxyDevicePort 解析LXD/Incus proxy设备的listen和connect地址，如 tcp:0.0.0.0:10000-10010 与 tcp:127.0.0.1:22
+func ParseProxyDevicePort(listen, connect string) (InspectedPort, error) {
+	protocol, hostStart, hostEnd, err := parseProxyAddress(listen)
+	if err != nil {
+		return InspectedPort{}, err
+	}
+	_, guestStart, guestEnd, err := parseProxyAddress(connect)
+	if err != nil {
+		return InspectedPort{}, err
+	}
+	return InspectedPort{
+		HostPort:     hostStart,
+		HostPortEnd:  hostEnd,
+		GuestPort:    guestStart,
+		GuestPortEnd: guestEnd,
+		Protocol:     protocol,
+	}, nil
+}
+
+// parseProxyAddress 解析 协议:地址:端口[-结束端口]，IPv6地址带方括号
+func parseProxyAddress(address string) (protocol string, start, end int, err error) {
+	parts := strings.SplitN(address, ":", 2)
+	if len(parts) != 2 || (parts[0] != "tcp" && parts[0] != "udp") {
+		return "", 0, 0, fmt.Errorf("不支持的proxy地址: %s", address)
+	}
+	idx := strings.LastIndex(parts[1], ":")
+	if idx < 0 {
+		return "", 0, 0, fmt.Errorf("proxy地址缺少端口: %s", address)
+	}
+	start, end, err = ParsePortRange(parts[1][idx+1:])
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("proxy地址端口无效: %s", address)
+	}
+	return parts[0], start, end, nil
+}
+
+// ParsePortRange 解析单端口或 起始-结束 端口段，单端口时结束端口为0
+func ParsePortRange(value string) (start, end int, err error) {
+	bounds := strings.SplitN(strings.TrimSpace(value), "-", 2)
+	if start, err = strconv.Atoi(bounds[0]); err != nil || start <= 0 || start > 65535 {
+		return 0, 0, fmt.Errorf("端口无效: %s", value)
+	}
+	if len(bounds) == 2 {
+		if end, err = strconv.Atoi(bounds[1]); err != nil || end < start || end > 65535 {
+			return 0, 0, fmt.Errorf("端口无效: %s", value)
+		}
+		if end == start {
+			end = 0
+		}
+	}
+	return start, end, nil
+}
+
+// ParseLXDInstanceInspection 解析LXD/Incus `query /1.0/instances/<name>` 返回的实例配置
+func ParseLXDInstanceInspection(output string) (*InstanceInspection, error) {
+	var data struct {
+		Type            string                       `json:"type"`
+		ExpandedConfig  map[string]string            `json:"expanded_config"`
+		ExpandedDevices map[string]map[string]string `json:"expanded_devices"`
+	}
+	if err := json.Unmarshal([]byte(output), &data); err != nil {
+		return nil, fmt.Errorf("解析实例配置失败: %w", err)
+	}
+
+	inspection := &InstanceInspection{
+		InstanceType: "container",
+		CPU:          ParseCPULimit(data.ExpandedConfig["limits.cpu"]),
+		Memory:       ParseSizeMB(data.ExpandedConfig["limits.memory"]),
+		Ports:        []InspectedPort{},
+	}
+	if data.Type == "virtual-machine" {
+		inspection.InstanceType = "vm"
+	}
+	if os := data.ExpandedConfig["image.os"]; os != "" {
+		inspection.Image = strings.ToLower(os) + data.ExpandedConfig["image.release"]
+	}
+
+	// 按设备名排序，保证端口顺序稳定
+	names := make([]string, 0, len(data.ExpandedDevices))
+	for name := range data.ExpandedDevices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		device := data.ExpandedDevices[name]
+		switch device["type"] {
+		case "disk":
+			if device["path"] == "/" {
+				inspection.Disk = ParseSizeMB(device["size"])
+			}
+		case "proxy":
+			if port, err := ParseProxyDevicePort(device["listen"], device["connect"]); err == nil {
+				inspection.Ports = append(inspection.Ports, port)
+			}
+		}
+	}
+	return inspection, nil
+}
+
+// ParseLXDInstanceAddresses 解析LXD/Incus `query /1.0/instances/<name>/state` 返回的eth0全局地址，实例未运行时为空
+func ParseLXDInstanceAddresses(output string) (ipv4, ipv6 string) {
+	var state struct {
+		Network map[string]struct {
+			Addresses []struct {
+				Family  string `json:"family"`
+				Address string `json:"address"`
+				Scope   string `json:"scope"`
+			} `json:"addresses"`
+		} `json:"network"`
+	}
+	if err := json.Unmarshal([]byte(output), &state); err != nil {
+		return "", ""
+	}
+	for _, addr := range state.Network["eth0"].Addresses {
+		if addr.Scope != "global" {
+			continue
+		}
+		if addr.Family == "inet" && ipv4 == "" {
+			ipv4 = addr.Address
+		}
+		if addr.Family == "inet6" && ipv6 == "" {
+			ipv6 = addr.Address
+		}
+	}
+	return ipv4, ipv6
+}
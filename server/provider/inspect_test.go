@@ -0,0 +1,30 @@
+package provider
+
+import "testing"
+
+func TestParseLXDInstanceInspection(t *testing.T) {
+	output := `{"type": "container", "expanded_config": {"limits.cpu": "0-1", "limits.memory": "512MiB", "image.os": "Debian", "image.release": "12"},
+"expanded_devices": {"root": {"type": "disk", "path": "/", "pool": "default", "size": "10GiB"},
+"ssh": {"type": "proxy", "listen": "tcp:0.0.0.0:10022", "connect": "tcp:127.0.0.1:22"},
+"nat": {"type": "proxy", "listen": "udp:0.0.0.0:20000-20010", "connect": "udp:127.0.0.1:20000-20010"}}}`
+
+	inspection, err := ParseLXDInstanceInspection(output)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if inspection.InstanceType != "container" || inspection.CPU != 2 || inspection.Memory != 512 || inspection.Disk != 10240 {
+		t.Fatalf("unexpected resources: %+v", inspection)
+	}
+	if inspection.Image != "debian12" {
+		t.Errorf("unexpected image: %s", inspection.Image)
+	}
+	if len(inspection.Ports) != 2 {
+		t.Fatalf("expected 2 proxy ports, got %+v", inspection.Ports)
+	}
+	if nat := inspection.Ports[0]; nat.Protocol != "udp" || nat.HostPort != 20000 || nat.HostPortEnd != 20010 {
+		t.Errorf("unexpected range proxy: %+v", nat)
+	}
+	if ssh := inspection.Ports[1]; ssh.HostPort != 10022 || ssh.GuestPort != 22 || ssh.Protocol != "tcp" {
+		t.Errorf("unexpected ssh proxy: %+v", ssh)
+	}
+}
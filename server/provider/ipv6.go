@@ -0,0 +1,15 @@
+package provider
+
+import "context"
+
+// IPv6Allocator 能为已有实例按节点配置重新分配IPv6的Provider实现此接口
+// IPv6地址由实例所在节点分配，实例迁移到其他节点后需要在目标节点上重新分配
+type IPv6Allocator interface {
+	// AllocateInstanceIPv6 按节点的网络类型为实例配置IPv6，返回内网IPv6和公网IPv6地址，节点未启用IPv6时均为空
+	AllocateInstanceIPv6(ctx context.Context, instanceName string) (ipv6Address, publicIPv6 string, err error)
+}
+
+// NetworkTypeHasIPv6 节点网络类型是否包含IPv6
+func NetworkTypeHasIPv6(networkType string) bool {
+	return networkType == "nat_ipv4_ipv6" || networkType == "dedicated_ipv4_ipv6" || networkType == "ipv6_only"
+}
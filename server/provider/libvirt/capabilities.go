@@ -0,0 +1,29 @@
+package libvirt
+
+import (
+	"context"
+	"fmt"
+
+	"oneclickvirt/provider"
+)
+
+// ProbeCapabilities 探测libvirt节点能力
+// 实例接入default网络，只有该网络配置了IPv6地址段时才能分配IPv6
+func (l *LibvirtProvider) ProbeCapabilities(ctx context.Context) (provider.Capabilities, error) {
+	if !l.connected || l.sshClient == nil {
+		return l.GetCapabilities(), fmt.Errorf("provider not connected")
+	}
+
+	_, ipv6Err := l.virsh("net-dumpxml default | grep -q 'family=.ipv6'")
+
+	return l.StoreCapabilities(provider.Capabilities{
+		InstanceTypes:  l.GetSupportedInstanceTypes(),
+		IPv6:           ipv6Err == nil,
+		Snapshot:       true,
+		Backup:         true,
+		Migration:      true,
+		DiskResize:     true,
+		DiskQuota:      true,
+		BandwidthLimit: true,
+	}), nil
+}
@@ -0,0 +1,53 @@
+package libvirt
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"oneclickvirt/provider"
+)
+
+// vncBasePort VNC显示号对应的起始端口
+const vncBasePort = 5900
+
+// OpenConsole 打开虚拟机控制台
+// 串口通过 virsh console 连接，--force 会断开已有连接，避免上一个会话异常退出后无法再次连接；
+// VNC只监听在宿主机127.0.0.1上，通过SSH转发
+func (l *LibvirtProvider) OpenConsole(ctx context.Context, instanceID, consoleType string) (provider.ConsoleSession, error) {
+	if !l.connected || l.sshClient == nil {
+		return nil, fmt.Errorf("libvirt provider not connected")
+	}
+
+	switch consoleType {
+	case provider.ConsoleTypeSerial:
+		return provider.NewSSHConsole(l.sshClient, fmt.Sprintf("virsh -c %s console --force %s", connectURI, instanceID))
+	case provider.ConsoleTypeVNC:
+		output, err := l.virsh(fmt.Sprintf("vncdisplay %s", instanceID))
+		if err != nil {
+			return nil, fmt.Errorf("获取VNC显示号失败: %w", err)
+		}
+		port, err := parseVNCDisplay(output)
+		if err != nil {
+			return nil, err
+		}
+		return provider.NewSSHTunnelConsole(l.sshClient, fmt.Sprintf("127.0.0.1:%d", port))
+	default:
+		return nil, fmt.Errorf("不支持的控制台类型: %s", consoleType)
+	}
+}
+
+// parseVNCDisplay 解析 virsh vncdisplay 输出（如 "127.0.0.1:0"）为端口号
+func parseVNCDisplay(output string) (int, error) {
+	display := strings.TrimSpace(output)
+	idx := strings.LastIndex(display, ":")
+	if idx < 0 {
+		return 0, fmt.Errorf("虚拟机未启用VNC: %s", display)
+	}
+	num, err := strconv.Atoi(display[idx+1:])
+	if err != nil {
+		return 0, fmt.Errorf("无法解析VNC显示号: %s", display)
+	}
+	return vncBasePort + num, nil
+}
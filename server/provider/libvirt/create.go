@@ -0,0 +1,293 @@
+package libvirt
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// seedWorkDir 生成cloud-init种子镜像时使用的临时目录
+const seedWorkDir = "/tmp/oneclickvirt-libvirt-seed"
+
+func (l *LibvirtProvider) CreateInstance(ctx context.Context, config provider.InstanceConfig) error {
+	return l.CreateInstanceWithProgress(ctx, config, nil)
+}
+
+// CreateInstanceWithProgress 使用云镜像创建KVM虚拟机
+// 流程：下载云镜像 -> 复制为实例qcow2磁盘并扩容 -> 生成cloud-init种子ISO -> virt-install导入 -> 等待DHCP分配IP -> 下发端口映射
+func (l *LibvirtProvider) CreateInstanceWithProgress(ctx context.Context, config provider.InstanceConfig, progressCallback provider.ProgressCallback) error {
+	if !l.connected {
+		return fmt.Errorf("not connected")
+	}
+	if config.InstanceType == "container" {
+		return fmt.Errorf("libvirt provider只支持虚拟机实例")
+	}
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+	}
+
+	global.APP_LOG.Info("开始创建libvirt虚拟机",
+		zap.String("instance", config.Name),
+		zap.String("image", config.Image),
+		zap.String("cpu", config.CPU),
+		zap.String("memory", config.Memory),
+		zap.String("disk", config.Disk))
+
+	cpu, err := strconv.Atoi(strings.TrimSpace(config.CPU))
+	if err != nil || cpu <= 0 {
+		cpu = 1
+	}
+	memoryMB := parseSizeMB(config.Memory, 1)
+	if memoryMB <= 0 {
+		memoryMB = 512
+	}
+	// 磁盘未带单位时与Proxmox一致按GB处理
+	diskMB := parseSizeMB(config.Disk, 1024)
+
+	updateProgress(10, "准备系统镜像...")
+	baseImage, err := l.ensureImage(ctx, config.Image, config.ImageURL)
+	if err != nil {
+		return fmt.Errorf("准备系统镜像失败: %w", err)
+	}
+
+	updateProgress(30, "创建虚拟机磁盘...")
+	disk := l.diskPath(config.Name)
+	// 复制为独立的qcow2磁盘而不是基于云镜像的增量盘，便于删除镜像和迁移实例
+	convertCmd := fmt.Sprintf("qemu-img convert -O qcow2 %s %s", baseImage, disk)
+	if output, err := l.sshClient.ExecuteLongRunning(ctx, convertCmd, fmt.Sprintf("/tmp/oneclickvirt-libvirt-convert-%s", config.Name)); err != nil {
+		return fmt.Errorf("创建虚拟机磁盘失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	if diskMB > 0 {
+		if output, err := l.sshClient.Execute(fmt.Sprintf("qemu-img resize %s %dM", disk, diskMB)); err != nil {
+			// 云镜像本身大于目标大小时qemu-img拒绝缩容，保留镜像原始大小
+			global.APP_LOG.Warn("调整虚拟机磁盘大小失败",
+				zap.String("instance", config.Name),
+				zap.Int64("diskMB", diskMB),
+				zap.String("output", utils.TruncateString(output, 200)))
+		}
+	}
+
+	updateProgress(45, "生成cloud-init配置...")
+	password := config.Metadata["password"]
+	if password == "" {
+		password = utils.GenerateInstancePassword()
+	}
+	if err := l.createSeedISO(config, password); err != nil {
+		l.sshClient.Execute(fmt.Sprintf("rm -f %s", disk))
+		return fmt.Errorf("生成cloud-init种子镜像失败: %w", err)
+	}
+
+	updateProgress(60, "创建虚拟机...")
+	installCmd := l.buildVirtInstallCommand(config.Name, cpu, memoryMB, disk)
+	if output, err := l.sshClient.Execute(installCmd); err != nil {
+		l.sshClient.Execute(fmt.Sprintf("rm -f %s %s", disk, l.seedPath(config.Name)))
+		return fmt.Errorf("virt-install执行失败: %w, output: %s", err, utils.TruncateString(output, 500))
+	}
+	l.refreshPool()
+
+	if bandwidth, err := strconv.Atoi(config.Metadata["bandwidth_spec"]); err == nil && bandwidth > 0 {
+		if err := l.setBandwidth(config.Name, bandwidth); err != nil {
+			global.APP_LOG.Warn("设置libvirt实例带宽限制失败", zap.String("instance", config.Name), zap.Error(err))
+		}
+	}
+
+	updateProgress(75, "等待虚拟机获取IP地址...")
+	instanceIP, err := l.waitForIPv4(ctx, config.Name, 3*time.Minute)
+	if err != nil {
+		global.APP_LOG.Warn("等待libvirt实例IP超时，跳过端口映射配置",
+			zap.String("instance", config.Name),
+			zap.Error(err))
+	} else {
+		updateProgress(90, "配置端口映射...")
+		networkType := config.Metadata["network_type"]
+		if networkType == "" {
+			networkType = l.config.NetworkType
+		}
+		if err := l.applyInstancePortMappings(ctx, config.Name, networkType, instanceIP); err != nil {
+			global.APP_LOG.Warn("配置libvirt实例端口映射失败",
+				zap.String("instance", config.Name),
+				zap.Error(err))
+		}
+	}
+
+	updateProgress(100, "虚拟机创建完成")
+	global.APP_LOG.Info("libvirt虚拟机创建成功",
+		zap.String("instance", config.Name),
+		zap.String("ip", instanceIP))
+	return nil
+}
+
+// buildVirtInstallCommand 构建以导入方式创建虚拟机的virt-install命令
+func (l *LibvirtProvider) buildVirtInstallCommand(name string, cpu int, memoryMB int64, disk string) string {
+	args := []string{
+		"virt-install",
+		"--connect " + connectURI,
+		"--name " + name,
+		fmt.Sprintf("--vcpus %d", cpu),
+		fmt.Sprintf("--memory %d", memoryMB),
+		"--import",
+		fmt.Sprintf("--disk path=%s,format=qcow2,bus=virtio", disk),
+		fmt.Sprintf("--disk path=%s,device=cdrom", l.seedPath(name)),
+		fmt.Sprintf("--network network=%s,model=virtio", defaultNetwork),
+		// 设置密码依赖qemu-guest-agent，需要提供virtio串口通道
+		"--channel unix,target_type=virtio,name=org.qemu.guest_agent.0",
+		"--os-variant detect=on,require=off",
+		"--graphics vnc,listen=127.0.0.1",
+		"--noautoconsole",
+	}
+	if l.kvmAvailable() {
+		args = append(args, "--virt-type kvm", "--cpu host-passthrough")
+	} else {
+		args = append(args, "--virt-type qemu")
+		global.APP_LOG.Warn("KVM不可用，使用软件模拟", zap.String("instance", name))
+	}
+	return strings.Join(args, " ")
+}
+
+// kvmAvailable 检测节点是否支持硬件虚拟化
+func (l *LibvirtProvider) kvmAvailable() bool {
+	output, _ := l.sshClient.Execute("[ -e /dev/kvm ] && [ -r /dev/kvm ] && [ -w /dev/kvm ] && echo 'kvm_available' || echo 'kvm_unavailable'")
+	return strings.TrimSpace(output) == "kvm_available"
+}
+
+// createSeedISO 生成cloud-init NoCloud种子镜像，user-data通过SFTP上传，避免密码出现在命令行中
+func (l *LibvirtProvider) createSeedISO(config provider.InstanceConfig, password string) error {
+	name := config.Name
+	workDir := fmt.Sprintf("%s/%s", seedWorkDir, name)
+	defer l.sshClient.Execute(fmt.Sprintf("rm -rf %s", workDir))
+
+	if err := l.sshClient.UploadContent(buildUserData(config, password), workDir+"/user-data", 0600); err != nil {
+		return fmt.Errorf("上传user-data失败: %w", err)
+	}
+	if err := l.sshClient.UploadContent(buildMetaData(name), workDir+"/meta-data", 0600); err != nil {
+		return fmt.Errorf("上传meta-data失败: %w", err)
+	}
+
+	// 优先使用cloud-image-utils提供的cloud-localds，不存在时使用genisoimage/mkisofs
+	seed := l.seedPath(name)
+	cmd := fmt.Sprintf("cd %s && if command -v cloud-localds >/dev/null 2>&1; then cloud-localds %s user-data meta-data; "+
+		"elif command -v genisoimage >/dev/null 2>&1; then genisoimage -output %s -volid cidata -joliet -rock user-data meta-data; "+
+		"else mkisofs -output %s -volid cidata -joliet -rock user-data meta-data; fi",
+		workDir, seed, seed, seed)
+	if output, err := l.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("%w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return nil
+}
+
+// buildUserData 生成cloud-init user-data：设置root密码、开启SSH密码登录并安装qemu-guest-agent
+// 选择了SSH公钥时在runcmd中写入root的authorized_keys，禁用密码登录时不开启SSH密码登录
+func buildUserData(config provider.InstanceConfig, password string) string {
+	var b strings.Builder
+	b.WriteString("#cloud-config\n")
+	fmt.Fprintf(&b, "hostname: %s\n", config.Name)
+	b.WriteString("manage_etc_hosts: true\n")
+	b.WriteString("disable_root: false\n")
+	fmt.Fprintf(&b, "ssh_pwauth: %t\n", !config.DisablePasswordLogin)
+	b.WriteString("chpasswd:\n")
+	b.WriteString("  expire: false\n")
+	b.WriteString("  list: |\n")
+	fmt.Fprintf(&b, "    root:%s\n", password)
+	b.WriteString("packages:\n")
+	b.WriteString("  - qemu-guest-agent\n")
+	b.WriteString("runcmd:\n")
+	b.WriteString("  - sed -i 's/^#\\?PermitRootLogin.*/PermitRootLogin yes/' /etc/ssh/sshd_config\n")
+	if !config.DisablePasswordLogin {
+		b.WriteString("  - sed -i 's/^#\\?PasswordAuthentication.*/PasswordAuthentication yes/' /etc/ssh/sshd_config\n")
+	}
+	b.WriteString("  - systemctl restart sshd || systemctl restart ssh\n")
+	b.WriteString("  - systemctl enable --now qemu-guest-agent\n")
+	if provider.NeedsSSHKeyInjection(config) {
+		fmt.Fprintf(&b, "  - %s\n", provider.BuildSSHKeyCommand(config.SSHKeys, config.DisablePasswordLogin))
+	}
+	return b.String()
+}
+
+// buildMetaData 生成cloud-init meta-data
+func buildMetaData(hostname string) string {
+	return fmt.Sprintf("instance-id: %s\nlocal-hostname: %s\n", hostname, hostname)
+}
+
+// setBandwidth 设置虚拟机网卡出入带宽，domiftune单位为KB/s
+func (l *LibvirtProvider) setBandwidth(name string, bandwidthMbps int) error {
+	addresses, err := l.getDomainAddresses(name)
+	iface := ""
+	if err == nil {
+		iface = addresses.Interface
+	}
+	if iface == "" {
+		// 虚拟机尚未分配地址时从 domiflist 获取网卡名
+		output, err := l.virsh(fmt.Sprintf("domiflist %s", name))
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(output, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 5 && fields[0] != "Interface" && !strings.HasPrefix(fields[0], "---") {
+				iface = fields[0]
+				break
+			}
+		}
+	}
+	if iface == "" {
+		return fmt.Errorf("未找到实例网卡")
+	}
+
+	rateKBps := bandwidthMbps * 1000 / 8
+	cmd := fmt.Sprintf("domiftune %s %s --inbound %d --outbound %d --config --live", name, iface, rateKBps, rateKBps)
+	if output, err := l.virsh(cmd); err != nil {
+		return fmt.Errorf("%w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return nil
+}
+
+// waitForIPv4 轮询等待虚拟机通过DHCP获取IPv4地址
+func (l *LibvirtProvider) waitForIPv4(ctx context.Context, name string, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if ip, err := l.GetInstanceIPv4(ctx, name); err == nil {
+			return ip, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+	return "", fmt.Errorf("等待实例 %s 获取IP地址超时", name)
+}
+
+// parseSizeMB 解析带单位的容量字符串为MB，支持 m/MB/g/GB 后缀，无单位时乘以defaultUnitMB
+func parseSizeMB(value string, defaultUnitMB int64) int64 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	upper := strings.ToUpper(value)
+	multiplier := defaultUnitMB
+	switch {
+	case strings.HasSuffix(upper, "GB"):
+		multiplier, upper = 1024, strings.TrimSuffix(upper, "GB")
+	case strings.HasSuffix(upper, "G"):
+		multiplier, upper = 1024, strings.TrimSuffix(upper, "G")
+	case strings.HasSuffix(upper, "MB"):
+		multiplier, upper = 1, strings.TrimSuffix(upper, "MB")
+	case strings.HasSuffix(upper, "M"):
+		multiplier, upper = 1, strings.TrimSuffix(upper, "M")
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(upper), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n * multiplier
+}
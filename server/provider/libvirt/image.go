@@ -0,0 +1,129 @@
+package libvirt
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// imageNamePattern 镜像名只保留文件名安全的字符
+var imageNamePattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// imagePath 云镜像在节点上的路径，镜像以系统镜像名命名，统一使用.qcow2后缀
+func (l *LibvirtProvider) imagePath(image string) string {
+	return fmt.Sprintf("%s/%s.qcow2", l.imageDir(), imageNamePattern.ReplaceAllString(image, "_"))
+}
+
+// ensureImage 确保云镜像已下载到节点，返回镜像路径
+func (l *LibvirtProvider) ensureImage(ctx context.Context, image, imageURL string) (string, error) {
+	if image == "" {
+		image = imageNameFromURL(imageURL)
+	}
+	if image == "" {
+		return "", fmt.Errorf("未指定系统镜像")
+	}
+	imagePath := l.imagePath(image)
+
+	output, err := l.sshClient.Execute(fmt.Sprintf("[ -f %s ] && echo 'exists' || echo 'missing'", imagePath))
+	if err != nil {
+		return "", fmt.Errorf("检查镜像文件失败: %w", err)
+	}
+	if strings.TrimSpace(output) == "exists" {
+		return imagePath, nil
+	}
+	if imageURL == "" {
+		return "", fmt.Errorf("镜像 %s 不存在且未提供下载地址", image)
+	}
+
+	global.APP_LOG.Info("下载libvirt云镜像",
+		zap.String("image", image),
+		zap.String("url", utils.TruncateString(imageURL, 100)))
+
+	// 先下载到临时文件，完成后再改名，避免中断时留下不完整的镜像
+	downloadCmd := fmt.Sprintf("mkdir -p %s && curl -fL --retry 3 -o %s.part %s && mv %s.part %s",
+		l.imageDir(), imagePath, shellQuote(imageURL), imagePath, imagePath)
+	statusPrefix := fmt.Sprintf("/tmp/oneclickvirt-libvirt-download-%s", imageNamePattern.ReplaceAllString(image, "_"))
+	if output, err := l.sshClient.ExecuteLongRunning(ctx, downloadCmd, statusPrefix); err != nil {
+		l.sshClient.Execute(fmt.Sprintf("rm -f %s.part", imagePath))
+		return "", fmt.Errorf("下载镜像失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return imagePath, nil
+}
+
+// ListImages 列出已下载的云镜像
+func (l *LibvirtProvider) ListImages(ctx context.Context) ([]provider.Image, error) {
+	if !l.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+	// 输出格式: 文件名 大小(字节) 修改时间(Unix秒)
+	cmd := fmt.Sprintf("find %s -maxdepth 1 -type f -name '*.qcow2' -printf '%%f %%s %%T@\\n' 2>/dev/null", l.imageDir())
+	output, err := l.sshClient.Execute(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+
+	var images []provider.Image
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		name := strings.TrimSuffix(fields[0], ".qcow2")
+		image := provider.Image{
+			ID:   name,
+			Name: name,
+			Tag:  "qcow2",
+			Size: fields[1],
+		}
+		if seconds, err := strconv.ParseFloat(fields[2], 64); err == nil {
+			image.Created = time.Unix(int64(seconds), 0)
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+// PullImage 下载云镜像，image为镜像下载地址，镜像以地址中的文件名命名
+func (l *LibvirtProvider) PullImage(ctx context.Context, image string) error {
+	if !l.connected {
+		return fmt.Errorf("not connected")
+	}
+	if !strings.HasPrefix(image, "http://") && !strings.HasPrefix(image, "https://") {
+		return fmt.Errorf("libvirt镜像需要提供下载地址")
+	}
+	_, err := l.ensureImage(ctx, imageNameFromURL(image), image)
+	return err
+}
+
+// DeleteImage 删除云镜像，实例磁盘是独立的qcow2文件，删除镜像不影响已创建的实例
+func (l *LibvirtProvider) DeleteImage(ctx context.Context, id string) error {
+	if !l.connected {
+		return fmt.Errorf("not connected")
+	}
+	if output, err := l.sshClient.Execute(fmt.Sprintf("rm -f %s", l.imagePath(id))); err != nil {
+		return fmt.Errorf("failed to delete image: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return nil
+}
+
+// imageNameFromURL 从下载地址提取镜像名，去掉常见的镜像扩展名
+func imageNameFromURL(imageURL string) string {
+	if imageURL == "" {
+		return ""
+	}
+	name := path.Base(strings.SplitN(imageURL, "?", 2)[0])
+	for _, ext := range []string{".qcow2", ".img", ".raw"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
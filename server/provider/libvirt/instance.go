@@ -0,0 +1,306 @@
+package libvirt
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// domainAddresses virsh domifaddr 解析出的实例地址
+type domainAddresses struct {
+	Interface string
+	MAC       string
+	IPv4      string
+	IPv6      string
+}
+
+// ListInstances 列出节点上的所有虚拟机，运行中的虚拟机额外查询IP地址
+func (l *LibvirtProvider) ListInstances(ctx context.Context) ([]provider.Instance, error) {
+	output, err := l.virsh("list --all")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+
+	domains := parseDomainList(output)
+	instances := make([]provider.Instance, 0, len(domains))
+	for _, domain := range domains {
+		instance := provider.Instance{
+			ID:       domain.name,
+			Name:     domain.name,
+			Status:   normalizeState(domain.state),
+			Type:     "vm",
+			Metadata: map[string]string{},
+		}
+		if instance.Status == "running" {
+			l.fillAddresses(&instance)
+		}
+		instances = append(instances, instance)
+	}
+
+	global.APP_LOG.Debug("libvirt获取实例列表成功", zap.Int("count", len(instances)))
+	return instances, nil
+}
+
+// GetInstance 获取虚拟机详情
+func (l *LibvirtProvider) GetInstance(ctx context.Context, id string) (*provider.Instance, error) {
+	output, err := l.virsh(fmt.Sprintf("dominfo %s", id))
+	if err != nil {
+		return nil, fmt.Errorf("instance %s not found: %w", id, err)
+	}
+	info := parseKeyValues(output)
+
+	instance := &provider.Instance{
+		ID:       info["Name"],
+		Name:     info["Name"],
+		Status:   normalizeState(info["State"]),
+		Type:     "vm",
+		CPU:      info["CPU(s)"],
+		Metadata: map[string]string{"uuid": info["UUID"]},
+	}
+	// Max memory 格式为 "1048576 KiB"
+	if memKiB := parseLeadingInt(info["Max memory"]); memKiB > 0 {
+		instance.Memory = fmt.Sprintf("%dMB", memKiB/1024)
+	}
+	if blk, err := l.virsh(fmt.Sprintf("domblkinfo %s vda", id)); err == nil {
+		if capacity := parseLeadingInt(parseKeyValues(blk)["Capacity"]); capacity > 0 {
+			instance.Disk = fmt.Sprintf("%dMB", capacity/1024/1024)
+		}
+	}
+	if instance.Status == "running" {
+		l.fillAddresses(instance)
+	}
+	return instance, nil
+}
+
+// GetInstanceIPv4 获取虚拟机内网IPv4地址
+func (l *LibvirtProvider) GetInstanceIPv4(ctx context.Context, instanceName string) (string, error) {
+	addresses, err := l.getDomainAddresses(instanceName)
+	if err != nil {
+		return "", err
+	}
+	if addresses.IPv4 == "" {
+		return "", fmt.Errorf("实例 %s 暂未获取到IPv4地址", instanceName)
+	}
+	return addresses.IPv4, nil
+}
+
+// StartInstance 启动虚拟机
+func (l *LibvirtProvider) StartInstance(ctx context.Context, id string) error {
+	if output, err := l.virsh(fmt.Sprintf("start %s", id)); err != nil {
+		if strings.Contains(output, "already active") {
+			return nil
+		}
+		return fmt.Errorf("failed to start instance: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	global.APP_LOG.Info("libvirt实例启动成功", zap.String("instance", utils.TruncateString(id, 50)))
+	return nil
+}
+
+// StopInstance 优雅关机，超时后强制关闭
+func (l *LibvirtProvider) StopInstance(ctx context.Context, id string) error {
+	if state, err := l.domainState(id); err == nil && state != "running" {
+		return nil
+	}
+	if output, err := l.virsh(fmt.Sprintf("shutdown %s", id)); err != nil {
+		return fmt.Errorf("failed to stop instance: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	if err := l.waitForState(ctx, id, "stopped", 60*time.Second); err != nil {
+		global.APP_LOG.Warn("libvirt实例关机超时，强制关闭", zap.String("instance", utils.TruncateString(id, 50)))
+		if output, err := l.virsh(fmt.Sprintf("destroy %s", id)); err != nil {
+			return fmt.Errorf("failed to force stop instance: %w, output: %s", err, utils.TruncateString(output, 200))
+		}
+	}
+	global.APP_LOG.Info("libvirt实例停止成功", zap.String("instance", utils.TruncateString(id, 50)))
+	return nil
+}
+
+// RestartInstance 重启虚拟机，已停止的虚拟机直接启动
+func (l *LibvirtProvider) RestartInstance(ctx context.Context, id string) error {
+	if state, err := l.domainState(id); err == nil && state != "running" {
+		return l.StartInstance(ctx, id)
+	}
+	if output, err := l.virsh(fmt.Sprintf("reboot %s", id)); err != nil {
+		return fmt.Errorf("failed to restart instance: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	global.APP_LOG.Info("libvirt实例重启成功", zap.String("instance", utils.TruncateString(id, 50)))
+	return nil
+}
+
+// DeleteInstance 删除虚拟机及其快照、磁盘、种子镜像和端口转发规则
+func (l *LibvirtProvider) DeleteInstance(ctx context.Context, id string) error {
+	l.removeInstancePortMappings(ctx, id)
+
+	l.virsh(fmt.Sprintf("destroy %s", id))
+	output, err := l.virsh(fmt.Sprintf("undefine %s --managed-save --snapshots-metadata --nvram", id))
+	if err != nil && !strings.Contains(output, "failed to get domain") {
+		return fmt.Errorf("failed to undefine instance: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	if _, err := l.sshClient.Execute(fmt.Sprintf("rm -f %s %s", l.diskPath(id), l.seedPath(id))); err != nil {
+		global.APP_LOG.Warn("删除libvirt实例磁盘失败", zap.String("instance", id), zap.Error(err))
+	}
+	l.refreshPool()
+
+	global.APP_LOG.Info("libvirt实例删除成功", zap.String("instance", utils.TruncateString(id, 50)))
+	return nil
+}
+
+// fillAddresses 查询并填充实例IP地址，查询失败时保持为空
+func (l *LibvirtProvider) fillAddresses(instance *provider.Instance) {
+	addresses, err := l.getDomainAddresses(instance.Name)
+	if err != nil {
+		global.APP_LOG.Debug("获取libvirt实例IP失败", zap.String("instance", instance.Name), zap.Error(err))
+		return
+	}
+	instance.IP = addresses.IPv4
+	instance.PrivateIP = addresses.IPv4
+	instance.IPv6Address = addresses.IPv6
+	if addresses.Interface != "" {
+		instance.Metadata["network_interface"] = addresses.Interface
+	}
+	if addresses.MAC != "" {
+		instance.Metadata["mac_address"] = addresses.MAC
+	}
+}
+
+// getDomainAddresses 优先从libvirt网络的DHCP租约获取地址，没有租约时回退到guest agent
+func (l *LibvirtProvider) getDomainAddresses(name string) (*domainAddresses, error) {
+	output, err := l.virsh(fmt.Sprintf("domifaddr %s", name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain addresses: %w", err)
+	}
+	addresses := parseDomIfAddr(output)
+	if addresses.IPv4 != "" {
+		return addresses, nil
+	}
+	if output, err := l.virsh(fmt.Sprintf("domifaddr %s --source agent", name)); err == nil {
+		if agentAddresses := parseDomIfAddr(output); agentAddresses.IPv4 != "" {
+			return agentAddresses, nil
+		}
+	}
+	return addresses, nil
+}
+
+// domainState 获取虚拟机状态
+func (l *LibvirtProvider) domainState(id string) (string, error) {
+	output, err := l.virsh(fmt.Sprintf("domstate %s", id))
+	if err != nil {
+		return "", err
+	}
+	return normalizeState(output), nil
+}
+
+// waitForState 轮询等待虚拟机进入指定状态
+func (l *LibvirtProvider) waitForState(ctx context.Context, id, state string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if current, err := l.domainState(id); err == nil && current == state {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+	return fmt.Errorf("等待实例 %s 进入 %s 状态超时", id, state)
+}
+
+// domainListEntry virsh list 的一行
+type domainListEntry struct {
+	name  string
+	state string
+}
+
+// parseDomainList 解析 virsh list --all 的表格输出，状态可能包含空格（如 shut off）
+func parseDomainList(output string) []domainListEntry {
+	var domains []domainListEntry
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] == "Id" || strings.HasPrefix(fields[0], "---") {
+			continue
+		}
+		domains = append(domains, domainListEntry{
+			name:  fields[1],
+			state: strings.Join(fields[2:], " "),
+		})
+	}
+	return domains
+}
+
+// parseDomIfAddr 解析 virsh domifaddr 输出，取第一个IPv4和第一个非链路本地IPv6
+func parseDomIfAddr(output string) *domainAddresses {
+	addresses := &domainAddresses{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] == "Name" {
+			continue
+		}
+		// 同一网卡的后续地址行中网卡名和MAC显示为 -
+		iface, mac, protocol, address := fields[0], fields[1], fields[2], fields[3]
+		ip := strings.SplitN(address, "/", 2)[0]
+		switch protocol {
+		case "ipv4":
+			if addresses.IPv4 == "" && !strings.HasPrefix(ip, "127.") {
+				addresses.IPv4 = ip
+				if iface != "-" {
+					addresses.Interface = iface
+				}
+				if mac != "-" {
+					addresses.MAC = mac
+				}
+			}
+		case "ipv6":
+			if addresses.IPv6 == "" && ip != "::1" && !strings.HasPrefix(strings.ToLower(ip), "fe80:") {
+				addresses.IPv6 = ip
+			}
+		}
+	}
+	return addresses
+}
+
+// parseKeyValues 解析 virsh dominfo/domblkinfo 的 "键: 值" 输出
+func parseKeyValues(output string) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if idx := strings.Index(line, ":"); idx > 0 {
+			values[strings.TrimSpace(line[:idx])] = strings.TrimSpace(line[idx+1:])
+		}
+	}
+	return values
+}
+
+// parseLeadingInt 解析以数字开头的字段，如 "1048576 KiB"，解析失败返回0
+func parseLeadingInt(value string) int64 {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0
+	}
+	n, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// normalizeState 将libvirt状态转换为系统统一状态
+func normalizeState(state string) string {
+	switch strings.TrimSpace(state) {
+	case "running", "idle", "in shutdown":
+		return "running"
+	case "shut off", "crashed":
+		return "stopped"
+	case "paused", "pmsuspended":
+		return "paused"
+	default:
+		return strings.TrimSpace(state)
+	}
+}
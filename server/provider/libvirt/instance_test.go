@@ -0,0 +1,105 @@
+package libvirt
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"oneclickvirt/global"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// newReplayProvider 创建回放录制文件的libvirt Provider，不连接任何节点
+func newReplayProvider(t *testing.T, fixture string) (*LibvirtProvider, *utils.CommandReplayer) {
+	t.Helper()
+	global.APP_LOG = zap.NewNop()
+	replayer, err := utils.LoadCommandReplayer(fixture)
+	if err != nil {
+		t.Fatalf("加载录制文件失败: %v", err)
+	}
+	return &LibvirtProvider{
+		sshClient:       utils.NewSSHClientWithExecutor(utils.SSHConfig{Host: "kvm-node.example"}, replayer),
+		connected:       true,
+		storagePool:     defaultStoragePool,
+		storagePoolPath: defaultStoragePoolPath,
+	}, replayer
+}
+
+func TestListInstances_ParsesStateAndAddresses(t *testing.T) {
+	l, replayer := newReplayProvider(t, "testdata/virsh_list.json")
+
+	instances, err := l.ListInstances(context.Background())
+	if err != nil {
+		t.Fatalf("获取实例列表失败: %v", err)
+	}
+	if len(instances) != 2 {
+		t.Fatalf("实例数量不正确: %d", len(instances))
+	}
+
+	web := instances[0]
+	if web.Name != "web1" || web.Status != "running" || web.Type != "vm" {
+		t.Errorf("web1基本信息不正确: %+v", web)
+	}
+	if web.PrivateIP != "192.168.122.15" || web.Metadata["network_interface"] != "vnet0" {
+		t.Errorf("web1网络信息不正确: %+v", web)
+	}
+	// 链路本地地址应被跳过
+	if web.IPv6Address != "2001:db8::15" {
+		t.Errorf("web1 IPv6不正确: %q", web.IPv6Address)
+	}
+
+	// 状态 shut off 包含空格，停止的虚拟机不查询地址
+	db := instances[1]
+	if db.Name != "db2" || db.Status != "stopped" || db.PrivateIP != "" {
+		t.Errorf("db2基本信息不正确: %+v", db)
+	}
+
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Errorf("存在未执行的录制命令: %v", unused)
+	}
+}
+
+func TestRewriteDomainXML_ResetsIdentity(t *testing.T) {
+	xml := `<domain type='kvm'>
+  <name>web1</name>
+  <uuid>6f1c2a4e-0d43-4c1e-9a57-0f3c1d2b8e11</uuid>
+  <devices>
+    <disk type='file' device='disk'>
+      <driver name='qemu' type='qcow2'/>
+      <source file='/var/lib/libvirt/images/web1.qcow2'/>
+      <target dev='vda' bus='virtio'/>
+    </disk>
+    <disk type='file' device='cdrom'>
+      <source file='/var/lib/libvirt/images/web1-seed.iso'/>
+      <target dev='sda' bus='sata'/>
+    </disk>
+    <interface type='network'>
+      <mac address='52:54:00:6b:3c:1e'/>
+      <source network='default'/>
+    </interface>
+    <channel type='unix'>
+      <target type='virtio' name='org.qemu.guest_agent.0'/>
+    </channel>
+  </devices>
+</domain>`
+
+	rewritten := rewriteDomainXML(xml, "web1-migrated", "/data/pool/web1-migrated.qcow2")
+
+	if !strings.Contains(rewritten, "<name>web1-migrated</name>") {
+		t.Errorf("虚拟机名称未替换: %s", rewritten)
+	}
+	if !strings.Contains(rewritten, "<source file='/data/pool/web1-migrated.qcow2'/>") {
+		t.Errorf("系统盘路径未替换: %s", rewritten)
+	}
+	for _, removed := range []string{"<uuid>", "<mac address", "device='cdrom'", "web1-seed.iso"} {
+		if strings.Contains(rewritten, removed) {
+			t.Errorf("%s 应被移除: %s", removed, rewritten)
+		}
+	}
+	// guest agent通道的name属性不能被当作虚拟机名称替换
+	if !strings.Contains(rewritten, "name='org.qemu.guest_agent.0'") {
+		t.Errorf("guest agent通道被错误修改: %s", rewritten)
+	}
+}
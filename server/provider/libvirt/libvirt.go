@@ -0,0 +1,293 @@
+package libvirt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/provider/health"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// connectURI virsh 连接的系统级libvirt URI
+	connectURI = "qemu:///system"
+	// defaultStoragePool libvirt默认存储池名称
+	defaultStoragePool = "default"
+	// defaultStoragePoolPath 无法查询存储池时使用的默认路径
+	defaultStoragePoolPath = "/var/lib/libvirt/images"
+	// imageDirName 云镜像在存储池目录下的子目录，不会出现在 virsh vol-list 中
+	imageDirName = "oneclickvirt-images"
+	// defaultNetwork 实例默认接入的libvirt NAT网络
+	defaultNetwork = "default"
+)
+
+// LibvirtProvider 通过SSH在节点上执行 virsh/virt-install 管理KVM虚拟机
+type LibvirtProvider struct {
+	provider.CapabilityCache
+
+	config          provider.NodeConfig
+	sshClient       *utils.SSHClient
+	connected       bool
+	healthChecker   health.HealthChecker
+	version         string // libvirt 版本
+	storagePool     string // 存储池名称
+	storagePoolPath string // 存储池目录，qcow2磁盘和cloud-init种子镜像存放于此
+	mu              sync.RWMutex
+}
+
+// NewLibvirtProvider 创建libvirt Provider
+func NewLibvirtProvider() provider.Provider {
+	return &LibvirtProvider{}
+}
+
+func (l *LibvirtProvider) GetType() string {
+	return "libvirt"
+}
+
+func (l *LibvirtProvider) GetName() string {
+	return l.config.Name
+}
+
+func (l *LibvirtProvider) GetSupportedInstanceTypes() []string {
+	return []string{"vm"}
+}
+
+// Connect 从全局SSH连接池获取节点连接，并确定存储池路径
+func (l *LibvirtProvider) Connect(ctx context.Context, config provider.NodeConfig) error {
+	l.config = config
+	global.APP_LOG.Info("libvirt provider开始连接",
+		zap.String("host", utils.TruncateString(config.Host, 32)),
+		zap.Int("port", config.Port))
+
+	if config.ExecutionRule == "api_only" {
+		return fmt.Errorf("libvirt provider不支持API调用，无法使用api_only执行规则")
+	}
+
+	sshConnectTimeout := config.SSHConnectTimeout
+	sshExecuteTimeout := config.SSHExecuteTimeout
+	if sshConnectTimeout <= 0 {
+		sshConnectTimeout = 30 // 默认30秒
+	}
+	if sshExecuteTimeout <= 0 {
+		sshExecuteTimeout = 300 // 默认300秒
+	}
+
+	sshConfig := utils.SSHConfig{
+		Host:           config.Host,
+		Port:           config.Port,
+		Username:       config.Username,
+		Password:       config.Password,
+		PrivateKey:     config.PrivateKey,
+		ConnectTimeout: time.Duration(sshConnectTimeout) * time.Second,
+		ExecuteTimeout: time.Duration(sshExecuteTimeout) * time.Second,
+	}
+	client, err := utils.GetGlobalSSHPool().GetOrCreate(config.ID, sshConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect via SSH: %w", err)
+	}
+
+	if err := l.attach(client); err != nil {
+		return err
+	}
+
+	// 探测节点能力，失败时保留上一次结果
+	if _, err := l.ProbeCapabilities(ctx); err != nil {
+		global.APP_LOG.Warn("libvirt节点能力探测失败", zap.Error(err))
+	}
+	return nil
+}
+
+// attach 使用已建立的SSH连接完成初始化，测试中可直接传入回放连接
+func (l *LibvirtProvider) attach(client *utils.SSHClient) error {
+	l.mu.Lock()
+	l.sshClient = client
+	l.connected = true
+	l.mu.Unlock()
+
+	healthConfig := health.HealthConfig{
+		ProviderID:    l.config.ID,
+		ProviderName:  l.config.Name,
+		Host:          l.config.Host,
+		Port:          l.config.Port,
+		Username:      l.config.Username,
+		Password:      l.config.Password,
+		PrivateKey:    l.config.PrivateKey,
+		SSHEnabled:    true,
+		APIEnabled:    true,
+		Timeout:       30 * time.Second,
+		ServiceChecks: []string{"libvirtd"},
+	}
+	l.healthChecker = health.NewLibvirtHealthCheckerWithSSH(healthConfig, global.APP_LOG, client)
+
+	if err := l.resolveStoragePool(); err != nil {
+		global.APP_LOG.Warn("获取libvirt存储池路径失败，使用默认路径",
+			zap.String("storagePool", l.storagePool),
+			zap.Error(err))
+	}
+	if err := l.getLibvirtVersion(); err != nil {
+		global.APP_LOG.Warn("libvirt 版本获取失败", zap.Error(err))
+	}
+
+	global.APP_LOG.Info("libvirt provider连接成功",
+		zap.String("host", utils.TruncateString(l.config.Host, 32)),
+		zap.String("version", l.version),
+		zap.String("storagePool", l.storagePool),
+		zap.String("storagePoolPath", l.storagePoolPath))
+	return nil
+}
+
+// Disconnect 断开连接，SSH连接归连接池管理，这里只从连接池移除
+func (l *LibvirtProvider) Disconnect(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sshClient != nil {
+		utils.GetGlobalSSHPool().Remove(l.config.ID)
+		l.sshClient = nil
+	}
+	l.connected = false
+	return nil
+}
+
+func (l *LibvirtProvider) IsConnected() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.connected && l.sshClient != nil && l.sshClient.IsHealthy()
+}
+
+func (l *LibvirtProvider) HealthCheck(ctx context.Context) (*health.HealthResult, error) {
+	if l.healthChecker == nil {
+		return nil, fmt.Errorf("health checker not initialized")
+	}
+	return l.healthChecker.CheckHealth(ctx)
+}
+
+func (l *LibvirtProvider) GetHealthChecker() health.HealthChecker {
+	return l.healthChecker
+}
+
+func (l *LibvirtProvider) GetVersion() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.version
+}
+
+// ExecuteSSHCommand 执行SSH命令，iptables端口映射后端通过它在节点上下发规则
+func (l *LibvirtProvider) ExecuteSSHCommand(ctx context.Context, command string) (string, error) {
+	if !l.connected || l.sshClient == nil {
+		return "", fmt.Errorf("libvirt provider not connected")
+	}
+
+	global.APP_LOG.Debug("执行SSH命令",
+		zap.String("command", utils.TruncateString(command, 200)))
+
+	output, err := l.sshClient.Execute(command)
+	if err != nil {
+		global.APP_LOG.Error("SSH命令执行失败",
+			zap.String("command", utils.TruncateString(command, 200)),
+			zap.String("output", utils.TruncateString(output, 500)),
+			zap.Error(err))
+		return "", fmt.Errorf("SSH command execution failed: %w", err)
+	}
+	return output, nil
+}
+
+// virsh 执行virsh子命令
+func (l *LibvirtProvider) virsh(args string) (string, error) {
+	if !l.connected || l.sshClient == nil {
+		return "", fmt.Errorf("libvirt provider not connected")
+	}
+	return l.sshClient.Execute(fmt.Sprintf("virsh -c %s %s", connectURI, args))
+}
+
+// getLibvirtVersion 获取 libvirt 版本
+func (l *LibvirtProvider) getLibvirtVersion() error {
+	output, err := l.virsh("version --daemon")
+	if err != nil {
+		l.version = "unknown"
+		return err
+	}
+	// 输出示例: Running against daemon: 9.0.0
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, "daemon") {
+			if idx := strings.LastIndex(line, ":"); idx != -1 {
+				l.version = strings.TrimSpace(line[idx+1:])
+				return nil
+			}
+		}
+	}
+	l.version = "unknown"
+	return fmt.Errorf("无法解析版本信息")
+}
+
+// resolveStoragePool 确定存储池名称和目录
+func (l *LibvirtProvider) resolveStoragePool() error {
+	// Provider表的存储池默认值local来自Proxmox，libvirt的默认存储池名为default
+	l.storagePool = l.config.StoragePool
+	if l.storagePool == "" || l.storagePool == "local" {
+		l.storagePool = defaultStoragePool
+	}
+	l.storagePoolPath = defaultStoragePoolPath
+
+	output, err := l.virsh(fmt.Sprintf("pool-dumpxml %s", l.storagePool))
+	if err != nil {
+		return err
+	}
+	path := extractXMLValue(output, "path")
+	if path == "" {
+		return fmt.Errorf("存储池 %s 不是目录类型存储池", l.storagePool)
+	}
+	l.storagePoolPath = path
+	return nil
+}
+
+// diskPath 实例系统盘路径
+func (l *LibvirtProvider) diskPath(name string) string {
+	return fmt.Sprintf("%s/%s.qcow2", l.storagePoolPath, name)
+}
+
+// seedPath 实例cloud-init种子镜像路径
+func (l *LibvirtProvider) seedPath(name string) string {
+	return fmt.Sprintf("%s/%s-seed.iso", l.storagePoolPath, name)
+}
+
+// imageDir 云镜像存放目录
+func (l *LibvirtProvider) imageDir() string {
+	return fmt.Sprintf("%s/%s", l.storagePoolPath, imageDirName)
+}
+
+// refreshPool 刷新存储池，使直接写入目录的卷出现在 virsh vol-list 中
+func (l *LibvirtProvider) refreshPool() {
+	if _, err := l.virsh(fmt.Sprintf("pool-refresh %s", l.storagePool)); err != nil {
+		global.APP_LOG.Debug("刷新libvirt存储池失败", zap.String("storagePool", l.storagePool), zap.Error(err))
+	}
+}
+
+// shellQuote 使用单引号包裹shell参数
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// extractXMLValue 提取简单XML片段中第一个指定标签的文本内容
+func extractXMLValue(xml, tag string) string {
+	start := strings.Index(xml, "<"+tag+">")
+	if start == -1 {
+		return ""
+	}
+	start += len(tag) + 2
+	end := strings.Index(xml[start:], "</"+tag+">")
+	if end == -1 {
+		return ""
+	}
+	return strings.TrimSpace(xml[start : start+end])
+}
+
+func init() {
+	provider.RegisterProvider("libvirt", NewLibvirtProvider)
+}
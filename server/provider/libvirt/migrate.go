@@ -0,0 +1,192 @@
+package libvirt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// migrateArchiveDir 迁移和备份归档在宿主机上的临时目录
+const migrateArchiveDir = "/tmp/oneclickvirt-migrate"
+
+// 归档为tar格式，包含 domain.xml（virsh dumpxml）和 disk.qcow2（合并快照后的系统盘）
+// 快照不随归档导出；cloud-init种子镜像只在首次启动时使用，也不导出
+
+var (
+	domainNamePattern  = regexp.MustCompile(`<name>[^<]*</name>`)
+	domainUUIDPattern  = regexp.MustCompile(`\s*<uuid>[^<]*</uuid>`)
+	domainMACPattern   = regexp.MustCompile(`\s*<mac address=['"][^'"]*['"]\s*/>`)
+	domainCDROMPattern = regexp.MustCompile(`(?s)\s*<disk type=['"]file['"] device=['"]cdrom['"]>.*?</disk>`)
+	domainDiskPattern  = regexp.MustCompile(`<source file=['"][^'"]*\.qcow2['"]\s*/>`)
+)
+
+// ExportInstance 停机导出虚拟机定义和系统盘，原本运行中的虚拟机在导出后重新启动
+func (l *LibvirtProvider) ExportInstance(ctx context.Context, instanceID string, w io.Writer, progressCallback provider.ProgressCallback) error {
+	if !l.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+	}
+
+	state, err := l.domainState(instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance %s: %w", instanceID, err)
+	}
+	workDir := fmt.Sprintf("%s/%s-export", migrateArchiveDir, instanceID)
+	defer l.sshClient.Execute(fmt.Sprintf("rm -rf %s", workDir))
+
+	if state == "running" {
+		updateProgress(5, "正在停止虚拟机...")
+		if err := l.StopInstance(ctx, instanceID); err != nil {
+			return err
+		}
+		defer l.StartInstance(context.Background(), instanceID)
+	}
+
+	updateProgress(10, "正在导出虚拟机磁盘...")
+	exportCmd := fmt.Sprintf("mkdir -p %s && virsh -c %s dumpxml %s > %s/domain.xml && qemu-img convert -O qcow2 %s %s/disk.qcow2 && tar -C %s -cf %s/archive.tar domain.xml disk.qcow2",
+		workDir, connectURI, instanceID, workDir, l.diskPath(instanceID), workDir, workDir, workDir)
+	if output, err := l.sshClient.ExecuteLongRunning(ctx, exportCmd, workDir+"/export"); err != nil {
+		return fmt.Errorf("failed to export instance: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	updateProgress(50, "正在传输虚拟机归档...")
+	written, err := l.sshClient.DownloadToWriter(workDir+"/archive.tar", w)
+	if err != nil {
+		return fmt.Errorf("failed to transfer archive: %w", err)
+	}
+
+	updateProgress(100, "虚拟机归档导出完成")
+	global.APP_LOG.Info("libvirt实例导出成功",
+		zap.String("instance", utils.TruncateString(instanceID, 50)),
+		zap.Int64("bytes", written))
+	return nil
+}
+
+// ImportInstance 从归档创建新虚拟机，使用新的名称、UUID和MAC地址，导入后保持停止状态
+func (l *LibvirtProvider) ImportInstance(ctx context.Context, instanceName, instanceType string, r io.Reader, progressCallback provider.ProgressCallback) error {
+	if !l.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+	}
+
+	workDir := fmt.Sprintf("%s/%s-import", migrateArchiveDir, instanceName)
+	defer l.sshClient.Execute(fmt.Sprintf("rm -rf %s", workDir))
+
+	if err := l.uploadAndExtract(ctx, r, workDir, updateProgress); err != nil {
+		return err
+	}
+
+	updateProgress(70, "正在定义虚拟机...")
+	disk := l.diskPath(instanceName)
+	if output, err := l.sshClient.Execute(fmt.Sprintf("mv %s/disk.qcow2 %s", workDir, disk)); err != nil {
+		return fmt.Errorf("failed to move disk: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	xml, err := l.sshClient.Execute(fmt.Sprintf("cat %s/domain.xml", workDir))
+	if err != nil {
+		l.sshClient.Execute(fmt.Sprintf("rm -f %s", disk))
+		return fmt.Errorf("failed to read domain xml: %w", err)
+	}
+	xmlPath := workDir + "/domain-import.xml"
+	if err := l.sshClient.UploadContent(rewriteDomainXML(xml, instanceName, disk), xmlPath, 0600); err != nil {
+		l.sshClient.Execute(fmt.Sprintf("rm -f %s", disk))
+		return fmt.Errorf("failed to upload domain xml: %w", err)
+	}
+	if output, err := l.virsh(fmt.Sprintf("define %s", xmlPath)); err != nil {
+		l.sshClient.Execute(fmt.Sprintf("rm -f %s", disk))
+		return fmt.Errorf("failed to define instance: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	l.refreshPool()
+
+	updateProgress(100, "虚拟机导入完成")
+	global.APP_LOG.Info("libvirt实例导入成功", zap.String("instance", instanceName))
+	return nil
+}
+
+// RestoreInstance 用归档中的系统盘覆盖原虚拟机磁盘，保留虚拟机定义，原有快照一并失效
+func (l *LibvirtProvider) RestoreInstance(ctx context.Context, instanceID string, r io.Reader, progressCallback provider.ProgressCallback) error {
+	if !l.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+	}
+
+	workDir := fmt.Sprintf("%s/%s-restore", migrateArchiveDir, instanceID)
+	defer l.sshClient.Execute(fmt.Sprintf("rm -rf %s", workDir))
+
+	if err := l.uploadAndExtract(ctx, r, workDir, updateProgress); err != nil {
+		return err
+	}
+
+	updateProgress(70, "正在停止虚拟机...")
+	if err := l.StopInstance(ctx, instanceID); err != nil {
+		return err
+	}
+
+	// 内部快照保存在被替换的磁盘中，先删除快照元数据避免残留
+	snapshots, _ := l.ListSnapshots(ctx, instanceID)
+	for _, snapshot := range snapshots {
+		l.virsh(fmt.Sprintf("snapshot-delete %s %s --metadata", instanceID, snapshot.Name))
+	}
+
+	updateProgress(85, "正在替换虚拟机磁盘...")
+	if output, err := l.sshClient.Execute(fmt.Sprintf("mv -f %s/disk.qcow2 %s", workDir, l.diskPath(instanceID))); err != nil {
+		return fmt.Errorf("failed to replace disk: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	updateProgress(100, "虚拟机恢复完成")
+	global.APP_LOG.Info("libvirt实例恢复成功", zap.String("instance", utils.TruncateString(instanceID, 50)))
+	return nil
+}
+
+// uploadAndExtract 上传并解压归档
+func (l *LibvirtProvider) uploadAndExtract(ctx context.Context, r io.Reader, workDir string, updateProgress func(int, string)) error {
+	updateProgress(10, "正在上传虚拟机归档...")
+	archivePath := workDir + "/archive.tar"
+	if _, err := l.sshClient.UploadFromReader(r, archivePath, 0600); err != nil {
+		return fmt.Errorf("failed to upload archive: %w", err)
+	}
+
+	updateProgress(50, "正在解压虚拟机归档...")
+	extractCmd := fmt.Sprintf("tar -C %s -xf %s domain.xml disk.qcow2 && rm -f %s", workDir, archivePath, archivePath)
+	if output, err := l.sshClient.ExecuteLongRunning(ctx, extractCmd, workDir+"/extract"); err != nil {
+		return fmt.Errorf("failed to extract archive: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return nil
+}
+
+// rewriteDomainXML 为导入的虚拟机改写定义：替换名称和系统盘路径，去掉UUID、MAC和cloud-init光驱由libvirt重新生成
+func rewriteDomainXML(xml, name, diskPath string) string {
+	replaced := false
+	xml = domainNamePattern.ReplaceAllStringFunc(xml, func(match string) string {
+		// 只替换第一个<name>，即虚拟机名称
+		if replaced {
+			return match
+		}
+		replaced = true
+		return fmt.Sprintf("<name>%s</name>", name)
+	})
+	xml = domainUUIDPattern.ReplaceAllString(xml, "")
+	xml = domainMACPattern.ReplaceAllString(xml, "")
+	xml = domainCDROMPattern.ReplaceAllString(xml, "")
+	xml = domainDiskPattern.ReplaceAllString(xml, fmt.Sprintf("<source file='%s'/>", diskPath))
+	return strings.TrimSpace(xml) + "\n"
+}
@@ -0,0 +1,40 @@
+package libvirt
+
+import (
+	"context"
+	"fmt"
+
+	"oneclickvirt/global"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// SetInstancePassword 通过qemu-guest-agent设置root密码，要求虚拟机正在运行且已安装guest agent
+func (l *LibvirtProvider) SetInstancePassword(ctx context.Context, instanceID, password string) error {
+	if !l.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if state, err := l.domainState(instanceID); err != nil {
+		return fmt.Errorf("failed to get instance state: %w", err)
+	} else if state != "running" {
+		return fmt.Errorf("实例 %s 未运行，无法设置密码", instanceID)
+	}
+
+	output, err := l.virsh(fmt.Sprintf("set-user-password %s root %s", instanceID, shellQuote(password)))
+	if err != nil {
+		return fmt.Errorf("设置密码失败（需要虚拟机内运行qemu-guest-agent）: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("libvirt实例密码设置成功", zap.String("instance", utils.TruncateString(instanceID, 50)))
+	return nil
+}
+
+// ResetInstancePassword 生成随机密码并设置
+func (l *LibvirtProvider) ResetInstancePassword(ctx context.Context, instanceID string) (string, error) {
+	password := utils.GenerateInstancePassword()
+	if err := l.SetInstancePassword(ctx, instanceID, password); err != nil {
+		return "", err
+	}
+	return password, nil
+}
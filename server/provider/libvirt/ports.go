@@ -0,0 +1,154 @@
+package libvirt
+
+import (
+	"context"
+	"fmt"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider/portmapping/iptables"
+	"oneclickvirt/provider/portmapping/nftables"
+
+	"go.uber.org/zap"
+)
+
+// libvirt的端口映射与Proxmox一致由iptables后端管理（Provider配置为nftables时由nftables后端管理），
+// 手动添加/删除端口经由portmapping的对应实现下发
+// 这里只负责实例创建时下发预分配的端口和删除实例时清理规则，规则格式与对应后端保持一致
+
+// applyInstancePortMappings 将数据库中预分配的端口映射下发到节点
+func (l *LibvirtProvider) applyInstancePortMappings(ctx context.Context, instanceName, networkType, instanceIP string) error {
+	// 独立IP和纯IPv6模式不需要IPv4端口映射
+	if networkType == "dedicated_ipv4" || networkType == "dedicated_ipv4_ipv6" || networkType == "ipv6_only" {
+		global.APP_LOG.Info("独立IP模式或纯IPv6模式，跳过IPv4端口映射配置",
+			zap.String("instance", instanceName),
+			zap.String("networkType", networkType))
+		return nil
+	}
+
+	instance, err := l.instanceRecord(instanceName)
+	if err != nil {
+		return err
+	}
+	ports, err := activePorts(instance.ID)
+	if err != nil {
+		return err
+	}
+	if len(ports) == 0 {
+		global.APP_LOG.Warn("未找到端口映射配置", zap.String("instance", instanceName))
+		return nil
+	}
+
+	if l.usesNftables() {
+		for _, cmd := range nftables.ApplyCommands(nftablesMappings(ports, instanceIP)) {
+			if output, err := l.sshClient.Execute(cmd); err != nil {
+				return fmt.Errorf("添加nftables端口映射失败: %w, output: %s", err, output)
+			}
+		}
+		global.APP_LOG.Info("libvirt实例nftables端口映射配置成功",
+			zap.String("instance", instanceName),
+			zap.String("instanceIP", instanceIP),
+			zap.Int("count", len(ports)))
+		return nil
+	}
+
+	for _, port := range ports {
+		for _, cmd := range iptables.AddRuleCommands(port.Protocol, port.HostPort, port.GuestPort, instanceIP) {
+			if output, err := l.sshClient.Execute(cmd); err != nil {
+				return fmt.Errorf("添加端口映射 %d->%d 失败: %w, output: %s", port.HostPort, port.GuestPort, err, output)
+			}
+		}
+	}
+	if _, err := l.sshClient.Execute(iptables.SaveRulesCommand); err != nil {
+		global.APP_LOG.Warn("保存iptables规则失败", zap.Error(err))
+	}
+
+	global.APP_LOG.Info("libvirt实例端口映射配置成功",
+		zap.String("instance", instanceName),
+		zap.String("instanceIP", instanceIP),
+		zap.Int("count", len(ports)))
+	return nil
+}
+
+// removeInstancePortMappings 删除实例在节点上的端口转发规则，数据库记录由上层删除
+func (l *LibvirtProvider) removeInstancePortMappings(ctx context.Context, instanceName string) {
+	instance, err := l.instanceRecord(instanceName)
+	if err != nil {
+		global.APP_LOG.Debug("未找到实例记录，跳过端口规则清理", zap.String("instance", instanceName))
+		return
+	}
+	instanceIP := instance.PrivateIP
+	if instanceIP == "" {
+		if ip, err := l.GetInstanceIPv4(ctx, instanceName); err == nil {
+			instanceIP = ip
+		}
+	}
+	if instanceIP == "" {
+		global.APP_LOG.Warn("无法确定实例内网IP，跳过端口规则清理", zap.String("instance", instanceName))
+		return
+	}
+
+	ports, err := activePorts(instance.ID)
+	if err != nil {
+		global.APP_LOG.Warn("获取端口映射失败", zap.String("instance", instanceName), zap.Error(err))
+		return
+	}
+	if l.usesNftables() {
+		// 元素按端口删除，不存在时忽略
+		for _, cmd := range nftables.RemoveCommands(nftablesMappings(ports, instanceIP)) {
+			l.sshClient.Execute(cmd)
+		}
+		return
+	}
+	for _, port := range ports {
+		for _, cmd := range iptables.DeleteRuleCommands(port.Protocol, port.HostPort, port.GuestPort, instanceIP) {
+			// 规则可能已被手动删除，删除失败继续处理其余规则
+			l.sshClient.Execute(cmd)
+		}
+	}
+	if len(ports) > 0 {
+		l.sshClient.Execute(iptables.SaveRulesCommand)
+	}
+}
+
+// usesNftables Provider是否配置为使用nftables进行IPv4端口映射
+func (l *LibvirtProvider) usesNftables() bool {
+	var providerInfo providerModel.Provider
+	if err := global.APP_DB.Select("ipv4_port_mapping_method").First(&providerInfo, l.config.ID).Error; err != nil {
+		return false
+	}
+	return providerInfo.IPv4PortMappingMethod == "nftables"
+}
+
+// nftablesMappings 将端口记录转换为nftables映射
+func nftablesMappings(ports []providerModel.Port, instanceIP string) []nftables.Mapping {
+	mappings := make([]nftables.Mapping, 0, len(ports))
+	for _, port := range ports {
+		mappings = append(mappings, nftables.Mapping{
+			Protocol:    port.Protocol,
+			HostPort:    port.HostPort,
+			HostPortEnd: port.HostPortEnd,
+			GuestPort:   port.GuestPort,
+			TargetIP:    instanceIP,
+		})
+	}
+	return mappings
+}
+
+// instanceRecord 获取当前节点上指定名称的实例记录
+func (l *LibvirtProvider) instanceRecord(instanceName string) (*providerModel.Instance, error) {
+	var instance providerModel.Instance
+	if err := global.APP_DB.Where("name = ? AND provider_id = ?", instanceName, l.config.ID).First(&instance).Error; err != nil {
+		return nil, fmt.Errorf("获取实例信息失败: %w", err)
+	}
+	return &instance, nil
+}
+
+// activePorts 获取实例的有效端口映射
+func activePorts(instanceID uint) ([]providerModel.Port, error) {
+	var ports []providerModel.Port
+	if err := global.APP_DB.Where("instance_id = ? AND status = 'active'", instanceID).Find(&ports).Error; err != nil {
+		return nil, fmt.Errorf("获取端口映射失败: %w", err)
+	}
+	return ports, nil
+}
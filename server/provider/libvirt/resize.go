@@ -0,0 +1,73 @@
+package libvirt
+
+import (
+	"context"
+	"fmt"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// ResizeInstance 调整虚拟机的CPU、内存、磁盘和带宽
+// CPU和内存写入持久化配置，在虚拟机下次启动后生效；磁盘只支持扩容，运行中的虚拟机使用blockresize在线扩容
+func (l *LibvirtProvider) ResizeInstance(ctx context.Context, instanceID string, spec provider.ResizeSpec) error {
+	if !l.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	state, err := l.domainState(instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance %s: %w", instanceID, err)
+	}
+
+	if spec.CPU > 0 {
+		// 先调整上限再调整当前值，缩小时顺序相反也能成功
+		for _, args := range []string{
+			fmt.Sprintf("setvcpus %s %d --config --maximum", instanceID, spec.CPU),
+			fmt.Sprintf("setvcpus %s %d --config", instanceID, spec.CPU),
+		} {
+			if output, err := l.virsh(args); err != nil {
+				return fmt.Errorf("调整CPU失败: %w, output: %s", err, utils.TruncateString(output, 200))
+			}
+		}
+	}
+
+	if spec.Memory > 0 {
+		for _, args := range []string{
+			fmt.Sprintf("setmaxmem %s %dM --config", instanceID, spec.Memory),
+			fmt.Sprintf("setmem %s %dM --config", instanceID, spec.Memory),
+		} {
+			if output, err := l.virsh(args); err != nil {
+				return fmt.Errorf("调整内存失败: %w, output: %s", err, utils.TruncateString(output, 200))
+			}
+		}
+	}
+
+	if spec.Disk > 0 {
+		var output string
+		if state == "running" {
+			output, err = l.virsh(fmt.Sprintf("blockresize %s vda %dM", instanceID, spec.Disk))
+		} else {
+			output, err = l.sshClient.Execute(fmt.Sprintf("qemu-img resize %s %dM", l.diskPath(instanceID), spec.Disk))
+		}
+		if err != nil {
+			return fmt.Errorf("调整磁盘失败: %w, output: %s", err, utils.TruncateString(output, 200))
+		}
+	}
+
+	if spec.Bandwidth > 0 {
+		if err := l.setBandwidth(instanceID, spec.Bandwidth); err != nil {
+			return fmt.Errorf("调整带宽失败: %w", err)
+		}
+	}
+
+	global.APP_LOG.Info("libvirt实例配置调整成功",
+		zap.String("instance", utils.TruncateString(instanceID, 50)),
+		zap.Int("cpu", spec.CPU),
+		zap.Int64("memory", spec.Memory),
+		zap.Int64("disk", spec.Disk),
+		zap.Int("bandwidth", spec.Bandwidth))
+	return nil
+}
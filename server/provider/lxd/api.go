@@ -102,6 +102,9 @@ func (l *LXDProvider) apiCreateInstanceWithProgress(ctx context.Context, config
 	if config.Memory != "" {
 		instanceConfig["config"].(map[string]interface{})["limits.memory"] = config.Memory
 	}
+	if config.UserData != "" {
+		instanceConfig["config"].(map[string]interface{})["cloud-init.user-data"] = config.UserData
+	}
 	if config.Disk != "" {
 		instanceConfig["devices"].(map[string]interface{})["root"] = map[string]interface{}{
 			"type": "disk",
@@ -160,6 +163,11 @@ func (l *LXDProvider) apiCreateInstanceWithProgress(ctx context.Context, config
 		}
 	}
 
+	// 注入用户选择的SSH公钥，需在设置密码之后执行，以便按需禁用密码登录
+	if err := l.configureInstanceSSHKeys(config); err != nil {
+		return fmt.Errorf("配置实例SSH公钥失败: %w", err)
+	}
+
 	updateProgress(100, "LXD API实例创建完成")
 	global.APP_LOG.Info("LXD API实例创建成功", zap.String("name", config.Name))
 	return nil
@@ -0,0 +1,42 @@
+package lxd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/provider"
+)
+
+// ProbeCapabilities 探测LXD节点能力
+// 虚拟机需要qemu驱动；IPv6以宿主机是否有全局IPv6地址为准；迁移依赖SSH执行规则
+func (l *LXDProvider) ProbeCapabilities(ctx context.Context) (provider.Capabilities, error) {
+	if !l.connected || l.sshClient == nil {
+		return l.GetCapabilities(), fmt.Errorf("provider not connected")
+	}
+
+	drivers, err := l.sshClient.Execute("lxc info | grep -i 'driver:'")
+	if err != nil {
+		return l.GetCapabilities(), fmt.Errorf("无法获取LXD驱动信息: %w", err)
+	}
+	instanceTypes := []string{"container"}
+	if strings.Contains(strings.ToLower(drivers), "qemu") {
+		instanceTypes = append(instanceTypes, "vm")
+	}
+
+	_, ipv6Err := l.sshClient.Execute("ip -6 addr show scope global 2>/dev/null | grep -q inet6")
+
+	return l.StoreCapabilities(provider.Capabilities{
+		InstanceTypes:  instanceTypes,
+		IPv6:           ipv6Err == nil,
+		Snapshot:       true,
+		Backup:         true,
+		Migration:      l.shouldUseSSH(),
+		DiskResize:     true,
+		DiskQuota:      true,
+		BandwidthLimit: true,
+		DiskIOLimit:    true,
+		Nesting:        true,
+		Privileged:     true,
+	}), nil
+}
@@ -0,0 +1,116 @@
+package lxd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// consoleOperation POST /1.0/instances/<name>/console 返回的异步操作
+type consoleOperation struct {
+	Operation string `json:"operation"`
+	Error     string `json:"error"`
+	Metadata  struct {
+		Metadata struct {
+			Fds map[string]string `json:"fds"`
+		} `json:"metadata"`
+	} `json:"metadata"`
+}
+
+// OpenConsole 打开实例控制台
+// 配置了API证书时连接 /console 操作的websocket，否则通过SSH运行 lxc console
+func (l *LXDProvider) OpenConsole(ctx context.Context, instanceID, consoleType string) (provider.ConsoleSession, error) {
+	if !l.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+	// LXD的图形控制台是SPICE协议，浏览器端无法直接使用
+	if consoleType != provider.ConsoleTypeSerial {
+		return nil, fmt.Errorf("LXD节点不支持%s控制台", consoleType)
+	}
+
+	if l.shouldUseAPI() {
+		session, err := l.apiOpenConsole(ctx, instanceID)
+		if err == nil {
+			return session, nil
+		}
+		global.APP_LOG.Warn("LXD API打开控制台失败", zap.String("instance", instanceID), zap.Error(err))
+		if !l.shouldFallbackToSSH() {
+			return nil, fmt.Errorf("API调用失败且不允许回退到SSH: %w", err)
+		}
+	}
+
+	if !l.shouldUseSSH() {
+		return nil, fmt.Errorf("执行规则不允许使用SSH")
+	}
+	return provider.NewSSHConsole(l.sshClient, fmt.Sprintf("lxc console %s", instanceID))
+}
+
+// apiOpenConsole 创建console操作并连接数据和控制websocket
+func (l *LXDProvider) apiOpenConsole(ctx context.Context, instanceID string) (provider.ConsoleSession, error) {
+	body, _ := json.Marshal(map[string]interface{}{"type": "console", "width": 80, "height": 24})
+	apiURL := fmt.Sprintf("https://%s:8443/1.0/instances/%s/console", l.config.Host, instanceID)
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := l.apiClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var op consoleOperation
+	if err := json.NewDecoder(resp.Body).Decode(&op); err != nil {
+		return nil, fmt.Errorf("解析console操作失败: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("创建console操作失败: %d %s", resp.StatusCode, op.Error)
+	}
+	dataSecret, controlSecret := op.Metadata.Metadata.Fds["0"], op.Metadata.Metadata.Fds["control"]
+	if op.Operation == "" || dataSecret == "" || controlSecret == "" {
+		return nil, fmt.Errorf("console操作缺少websocket密钥")
+	}
+
+	dialer := websocket.Dialer{TLSClientConfig: l.transport.TLSClientConfig, HandshakeTimeout: 10 * time.Second}
+	websocketURL := func(secret string) string {
+		return fmt.Sprintf("wss://%s:8443%s/websocket?secret=%s", l.config.Host, op.Operation, url.QueryEscape(secret))
+	}
+
+	control, _, err := dialer.DialContext(ctx, websocketURL(controlSecret), nil)
+	if err != nil {
+		return nil, fmt.Errorf("连接控制websocket失败: %w", err)
+	}
+	data, _, err := dialer.DialContext(ctx, websocketURL(dataSecret), nil)
+	if err != nil {
+		control.Close()
+		return nil, fmt.Errorf("连接数据websocket失败: %w", err)
+	}
+
+	return &provider.WebSocketConsole{
+		Conn: data,
+		ResizeFunc: func(rows, cols int) error {
+			return control.WriteJSON(map[string]interface{}{
+				"command": "window-resize",
+				"args":    map[string]string{"width": strconv.Itoa(cols), "height": strconv.Itoa(rows)},
+			})
+		},
+		OnClose: func() {
+			// 关闭控制连接后LXD结束console操作
+			control.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			control.Close()
+		},
+	}, nil
+}
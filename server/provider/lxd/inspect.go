@@ -0,0 +1,30 @@
+package lxd
+
+import (
+	"context"
+	"fmt"
+
+	"oneclickvirt/provider"
+)
+
+// InspectInstance 读取实例的资源限制、根磁盘大小、proxy端口转发和内网地址
+func (l *LXDProvider) InspectInstance(ctx context.Context, instanceName string) (*provider.InstanceInspection, error) {
+	if !l.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	output, err := l.sshClient.Execute(fmt.Sprintf("lxc query /1.0/instances/%s", instanceName))
+	if err != nil {
+		return nil, fmt.Errorf("获取实例配置失败: %w", err)
+	}
+	inspection, err := provider.ParseLXDInstanceInspection(output)
+	if err != nil {
+		return nil, err
+	}
+
+	// 地址读取失败不影响导入，实例未运行时没有地址
+	if state, err := l.sshClient.Execute(fmt.Sprintf("lxc query /1.0/instances/%s/state", instanceName)); err == nil {
+		inspection.PrivateIP, inspection.IPv6Address = provider.ParseLXDInstanceAddresses(state)
+	}
+	return inspection, nil
+}
@@ -334,6 +334,20 @@ func (l *LXDProvider) configureInstanceSSHPassword(ctx context.Context, config p
 		return fmt.Errorf("设置实例密码失败: %w", err)
 	}
 
+	// 注入用户选择的SSH公钥 - 失败不影响密码登录
+	if err := l.configureInstanceSSHKeys(config); err != nil {
+		global.APP_LOG.Warn("配置实例SSH公钥失败",
+			zap.String("instanceName", config.Name),
+			zap.Error(err))
+	}
+
+	// 镜像没有cloud-init时通过exec执行用户脚本
+	if err := l.runInstanceUserDataScript(config); err != nil {
+		global.APP_LOG.Warn("执行用户首次启动脚本失败",
+			zap.String("instanceName", config.Name),
+			zap.Error(err))
+	}
+
 	// 清理历史记录 - 非阻塞式，如果失败不影响整体流程
 	_, err = l.sshClient.Execute(fmt.Sprintf("lxc exec %s -- bash -c 'history -c 2>/dev/null || true'", config.Name))
 	if err != nil {
@@ -367,6 +381,55 @@ func (l *LXDProvider) configureInstanceSSHPassword(ctx context.Context, config p
 	return nil
 }
 
+// configureInstanceSSHKeys 将用户选择的SSH公钥写入root的authorized_keys，并按需禁用SSH密码登录
+func (l *LXDProvider) configureInstanceSSHKeys(config provider.InstanceConfig) error {
+	if !provider.NeedsSSHKeyInjection(config) {
+		return nil
+	}
+	cmd := fmt.Sprintf("lxc exec %s -- sh -c '%s'", config.Name, provider.BuildSSHKeyCommand(config.SSHKeys, config.DisablePasswordLogin))
+	if _, err := l.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("写入SSH公钥失败: %w", err)
+	}
+	global.APP_LOG.Info("实例SSH公钥配置完成",
+		zap.String("instanceName", config.Name),
+		zap.Int("keys", len(config.SSHKeys)),
+		zap.Bool("disablePasswordLogin", config.DisablePasswordLogin))
+	return nil
+}
+
+// setInstanceUserData 在实例启动前写入cloud-init.user-data，首次启动时由镜像中的cloud-init处理
+// 内容通过临时文件传入，避免多行YAML和引号破坏命令行
+func (l *LXDProvider) setInstanceUserData(config provider.InstanceConfig) error {
+	if config.UserData == "" {
+		return nil
+	}
+	tmpFile := fmt.Sprintf("/tmp/oneclickvirt-userdata-%s", config.Name)
+	if err := l.sshClient.UploadContent(config.UserData, tmpFile, 0600); err != nil {
+		return fmt.Errorf("上传user-data失败: %w", err)
+	}
+	cmd := fmt.Sprintf("lxc config set %s cloud-init.user-data \"$(cat %s)\"; status=$?; rm -f %s; exit $status", config.Name, tmpFile, tmpFile)
+	if _, err := l.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("设置cloud-init.user-data失败: %w", err)
+	}
+	global.APP_LOG.Info("实例user-data配置完成",
+		zap.String("instanceName", config.Name),
+		zap.String("type", provider.DetectUserDataType(config.UserData)),
+		zap.Int("size", len(config.UserData)))
+	return nil
+}
+
+// runInstanceUserDataScript 镜像没有cloud-init时在实例内后台执行用户提供的shell脚本
+func (l *LXDProvider) runInstanceUserDataScript(config provider.InstanceConfig) error {
+	if provider.DetectUserDataType(config.UserData) != provider.UserDataTypeScript {
+		return nil
+	}
+	cmd := fmt.Sprintf("lxc exec %s -- sh -c '%s'", config.Name, provider.BuildUserDataCommand(config.UserData, true))
+	if _, err := l.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("执行首次启动脚本失败: %w", err)
+	}
+	return nil
+}
+
 // waitForVMAgentReady 等待Agent启动完成
 func (l *LXDProvider) waitForVMAgentReady(instanceName string, timeoutSeconds int) error {
 	global.APP_LOG.Info("开始等待Agent启动",
@@ -7,6 +7,9 @@ import (
 	"time"
 
 	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	"oneclickvirt/provider/portmapping/nftables"
 
 	"go.uber.org/zap"
 )
@@ -20,6 +23,7 @@ type IPv6Config struct {
 	Interface        string
 	Gateway          string
 	UseIptables      bool
+	UseNftables      bool
 	UseNetworkDevice bool
 }
 
@@ -722,6 +726,7 @@ func (l *LXDProvider) configureIPv6Network(ctx context.Context, containerName st
 		Gateway:          gatewayInfo,
 		UseNetworkDevice: portMappingMethod == "device_proxy", // device_proxy使用网络设备方式
 		UseIptables:      portMappingMethod == "iptables",     // iptables使用iptables方式
+		UseNftables:      portMappingMethod == "nftables",     // nftables使用地址映射集合
 	}
 
 	var containerIPv6 string
@@ -731,11 +736,11 @@ func (l *LXDProvider) configureIPv6Network(ctx context.Context, containerName st
 		if err != nil {
 			return fmt.Errorf("使用device_proxy方式配置IPv6网络失败: %w", err)
 		}
-	} else if config.UseIptables {
-		// 使用iptables方式配置IPv6映射
+	} else if config.UseIptables || config.UseNftables {
+		// 使用iptables或nftables方式配置IPv6映射，两者只在NAT规则的下发方式上不同
 		containerIPv6, err = l.setupIptablesIPv6(ctx, config)
 		if err != nil {
-			return fmt.Errorf("使用iptables方式配置IPv6网络失败: %w", err)
+			return fmt.Errorf("使用%s方式配置IPv6网络失败: %w", portMappingMethod, err)
 		}
 	} else {
 		// 默认使用device_proxy方式
@@ -839,8 +844,11 @@ func (l *LXDProvider) setupIptablesIPv6(ctx context.Context, config IPv6Config)
 			continue
 		}
 
-		// 检查是否已存在iptables规则
+		// 检查是否已存在iptables规则或nftables地址映射
 		checkRuleCmd := fmt.Sprintf("ip6tables -t nat -C PREROUTING -d %s -j DNAT --to-destination %s 2>/dev/null", testIPv6, containerIPv6)
+		if config.UseNftables {
+			checkRuleCmd = nftables.AddressExistsCommand(testIPv6)
+		}
 		_, err = l.sshClient.Execute(checkRuleCmd)
 		if err == nil {
 			// 规则已存在
@@ -864,11 +872,20 @@ func (l *LXDProvider) setupIptablesIPv6(ctx context.Context, config IPv6Config)
 		return "", fmt.Errorf("添加IPv6地址失败: %w", err)
 	}
 
-	// iptables NAT规则
-	natRuleCmd := fmt.Sprintf("ip6tables -t nat -A PREROUTING -d %s -j DNAT --to-destination %s", mappedIPv6, containerIPv6)
-	_, err = l.sshClient.Execute(natRuleCmd)
-	if err != nil {
-		return "", fmt.Errorf("添加ip6tables NAT规则失败: %w", err)
+	if config.UseNftables {
+		// nftables地址映射，命令中已包含持久化
+		for _, cmd := range nftables.AddressApplyCommands(mappedIPv6, containerIPv6) {
+			if _, err := l.sshClient.Execute(cmd); err != nil {
+				return "", fmt.Errorf("添加nftables IPv6地址映射失败: %w", err)
+			}
+		}
+	} else {
+		// iptables NAT规则
+		natRuleCmd := fmt.Sprintf("ip6tables -t nat -A PREROUTING -d %s -j DNAT --to-destination %s", mappedIPv6, containerIPv6)
+		_, err = l.sshClient.Execute(natRuleCmd)
+		if err != nil {
+			return "", fmt.Errorf("添加ip6tables NAT规则失败: %w", err)
+		}
 	}
 
 	// 设置持久化服务和脚本
@@ -878,9 +895,11 @@ func (l *LXDProvider) setupIptablesIPv6(ctx context.Context, config IPv6Config)
 	}
 
 	// 保存iptables规则
-	err = l.saveIp6tablesRules(ctx)
-	if err != nil {
-		global.APP_LOG.Warn("保存ip6tables规则失败", zap.Error(err))
+	if !config.UseNftables {
+		err = l.saveIp6tablesRules(ctx)
+		if err != nil {
+			global.APP_LOG.Warn("保存ip6tables规则失败", zap.Error(err))
+		}
 	}
 
 	// 测试连通性
@@ -995,3 +1014,20 @@ func (l *LXDProvider) testIPv6Connectivity(ctx context.Context, ipv6Addr, contai
 
 	return nil
 }
+
+// AllocateInstanceIPv6 按节点网络配置为已有实例分配IPv6，用于实例迁移到本节点后重新分配地址
+func (l *LXDProvider) AllocateInstanceIPv6(ctx context.Context, instanceName string) (string, string, error) {
+	var providerInfo providerModel.Provider
+	if err := global.APP_DB.Where("name = ?", l.config.Name).First(&providerInfo).Error; err != nil {
+		return "", "", fmt.Errorf("获取节点配置失败: %w", err)
+	}
+	if !provider.NetworkTypeHasIPv6(providerInfo.NetworkType) {
+		return "", "", nil
+	}
+	if err := l.configureIPv6Network(ctx, instanceName, true, providerInfo.IPv6PortMappingMethod); err != nil {
+		return "", "", err
+	}
+	ipv6Address, _ := l.GetInstanceIPv6(instanceName)
+	publicIPv6, _ := l.GetInstancePublicIPv6(instanceName)
+	return ipv6Address, publicIPv6, nil
+}
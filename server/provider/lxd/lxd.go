@@ -19,6 +19,8 @@ import (
 )
 
 type LXDProvider struct {
+	provider.CapabilityCache
+
 	config        provider.NodeConfig
 	sshClient     *utils.SSHClient
 	apiClient     *http.Client
@@ -149,6 +151,11 @@ func (l *LXDProvider) Connect(ctx context.Context, config provider.NodeConfig) e
 			zap.Error(err))
 	}
 
+	// 探测节点能力，失败时保留上一次结果
+	if _, err := l.ProbeCapabilities(ctx); err != nil {
+		global.APP_LOG.Warn("LXD节点能力探测失败", zap.Error(err))
+	}
+
 	global.APP_LOG.Info("LXD provider SSH连接成功",
 		zap.String("host", utils.TruncateString(config.Host, 50)),
 		zap.Int("port", config.Port),
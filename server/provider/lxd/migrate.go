@@ -0,0 +1,195 @@
+package lxd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// migrateArchiveDir 迁移归档在宿主机上的临时目录
+const migrateArchiveDir = "/tmp/oneclickvirt-migrate"
+
+// lxdInstanceDevices lxc query返回的实例本地设备配置
+type lxdInstanceDevices struct {
+	Devices map[string]map[string]string `json:"devices"`
+}
+
+// ExportInstance 使用 lxc export 导出实例（包含快照），并通过SFTP写入w
+// 迁移时调用前实例已停止，备份时允许导出运行中的实例
+func (l *LXDProvider) ExportInstance(ctx context.Context, instanceID string, w io.Writer, progressCallback provider.ProgressCallback) error {
+	if err := l.checkMigratePrerequisites(); err != nil {
+		return err
+	}
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+	}
+
+	archivePath := fmt.Sprintf("%s/%s-export.tar.gz", migrateArchiveDir, instanceID)
+	defer l.sshClient.Execute(fmt.Sprintf("rm -f %s", archivePath))
+
+	updateProgress(10, "正在导出实例归档...")
+	exportCmd := fmt.Sprintf("mkdir -p %s && lxc export %s %s", migrateArchiveDir, instanceID, archivePath)
+	if output, err := l.sshClient.ExecuteLongRunning(ctx, exportCmd, archivePath); err != nil {
+		return fmt.Errorf("failed to export instance: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	updateProgress(50, "正在传输实例归档...")
+	written, err := l.sshClient.DownloadToWriter(archivePath, w)
+	if err != nil {
+		return fmt.Errorf("failed to transfer archive: %w", err)
+	}
+
+	updateProgress(100, "实例归档导出完成")
+	global.APP_LOG.Info("LXD实例导出成功",
+		zap.String("instance", utils.TruncateString(instanceID, 50)),
+		zap.Int64("bytes", written))
+	return nil
+}
+
+// ImportInstance 从r读取 lxc export 生成的归档并导入为同名实例，导入后实例保持停止状态
+// 归档中的proxy设备和IP绑定指向源节点，导入后清除，由调用方在新节点上重新配置
+func (l *LXDProvider) ImportInstance(ctx context.Context, instanceName, instanceType string, r io.Reader, progressCallback provider.ProgressCallback) error {
+	if err := l.checkMigratePrerequisites(); err != nil {
+		return err
+	}
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+	}
+
+	archivePath := fmt.Sprintf("%s/%s-import.tar.gz", migrateArchiveDir, instanceName)
+	defer l.sshClient.Execute(fmt.Sprintf("rm -f %s", archivePath))
+
+	updateProgress(10, "正在上传实例归档...")
+	written, err := l.sshClient.UploadFromReader(r, archivePath, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to upload archive: %w", err)
+	}
+
+	updateProgress(50, "正在导入实例...")
+	importCmd := fmt.Sprintf("lxc import %s %s", archivePath, instanceName)
+	if output, err := l.sshClient.ExecuteLongRunning(ctx, importCmd, archivePath); err != nil {
+		return fmt.Errorf("failed to import instance: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	updateProgress(90, "正在清理源节点网络配置...")
+	l.cleanupImportedDevices(instanceName, true)
+
+	updateProgress(100, "实例导入完成")
+	global.APP_LOG.Info("LXD实例导入成功",
+		zap.String("instance", utils.TruncateString(instanceName, 50)),
+		zap.String("instanceType", instanceType),
+		zap.Int64("bytes", written))
+	return nil
+}
+
+// checkMigratePrerequisites 检查迁移操作的前置条件，导出导入只能通过SSH执行
+func (l *LXDProvider) checkMigratePrerequisites() error {
+	if !l.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if !l.shouldUseSSH() {
+		return fmt.Errorf("执行规则不允许使用SSH，无法迁移实例")
+	}
+	return nil
+}
+
+// cleanupImportedDevices 删除导入实例上的proxy设备，unbindAddress为true时同时解除网卡IP绑定
+// 原节点恢复时IP不变，只需清除proxy设备，由调用方按当前端口记录重新创建
+func (l *LXDProvider) cleanupImportedDevices(instanceName string, unbindAddress bool) {
+	output, err := l.sshClient.Execute(fmt.Sprintf("lxc query /1.0/instances/%s", instanceName))
+	if err != nil {
+		global.APP_LOG.Warn("查询导入实例设备失败", zap.String("instance", instanceName), zap.Error(err))
+		return
+	}
+
+	var info lxdInstanceDevices
+	if err := json.Unmarshal([]byte(output), &info); err != nil {
+		global.APP_LOG.Warn("解析导入实例设备失败", zap.String("instance", instanceName), zap.Error(err))
+		return
+	}
+
+	for name, device := range info.Devices {
+		var cmd string
+		switch {
+		case device["type"] == "proxy":
+			cmd = fmt.Sprintf("lxc config device remove %s %s", instanceName, name)
+		case unbindAddress && device["type"] == "nic" && device["ipv4.address"] != "":
+			cmd = fmt.Sprintf("lxc config device unset %s %s ipv4.address", instanceName, name)
+		default:
+			continue
+		}
+		if _, err := l.sshClient.Execute(cmd); err != nil {
+			global.APP_LOG.Warn("清理导入实例设备失败",
+				zap.String("instance", instanceName),
+				zap.String("device", name),
+				zap.Error(err))
+		}
+	}
+}
+
+// RestoreInstance 用 lxc export 生成的归档覆盖同名实例，恢复后实例保持停止状态
+// 先将原实例改名保留，导入成功后再删除，导入失败时改回原名；归档中的proxy设备会被清除
+func (l *LXDProvider) RestoreInstance(ctx context.Context, instanceID string, r io.Reader, progressCallback provider.ProgressCallback) error {
+	if err := l.checkMigratePrerequisites(); err != nil {
+		return err
+	}
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+	}
+
+	archivePath := fmt.Sprintf("%s/%s-restore.tar.gz", migrateArchiveDir, instanceID)
+	defer l.sshClient.Execute(fmt.Sprintf("rm -f %s", archivePath))
+
+	updateProgress(10, "正在上传备份归档...")
+	if _, err := l.sshClient.UploadFromReader(r, archivePath, 0600); err != nil {
+		return fmt.Errorf("failed to upload archive: %w", err)
+	}
+
+	updateProgress(40, "正在停止实例...")
+	l.sshClient.Execute(fmt.Sprintf("lxc stop %s --force", instanceID))
+
+	backupName := instanceID + "-restore-old"
+	if output, err := l.sshClient.Execute(fmt.Sprintf("lxc move %s %s", instanceID, backupName)); err != nil {
+		return fmt.Errorf("failed to rename instance: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	updateProgress(50, "正在导入备份...")
+	importCmd := fmt.Sprintf("lxc import %s %s", archivePath, instanceID)
+	if output, err := l.sshClient.ExecuteLongRunning(ctx, importCmd, archivePath); err != nil {
+		l.sshClient.Execute(fmt.Sprintf("lxc delete %s --force", instanceID))
+		if _, renameErr := l.sshClient.Execute(fmt.Sprintf("lxc move %s %s", backupName, instanceID)); renameErr != nil {
+			global.APP_LOG.Error("恢复失败后还原实例名称失败",
+				zap.String("instance", instanceID),
+				zap.String("backupName", backupName),
+				zap.Error(renameErr))
+		}
+		return fmt.Errorf("failed to import backup: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	updateProgress(85, "正在清理归档中的端口映射设备...")
+	l.cleanupImportedDevices(instanceID, false)
+
+	updateProgress(90, "正在清理旧实例...")
+	if _, err := l.sshClient.Execute(fmt.Sprintf("lxc delete %s --force", backupName)); err != nil {
+		global.APP_LOG.Warn("删除恢复前的旧实例失败",
+			zap.String("instance", backupName),
+			zap.Error(err))
+	}
+
+	updateProgress(100, "备份恢复完成")
+	global.APP_LOG.Info("LXD实例备份恢复成功", zap.String("instance", utils.TruncateString(instanceID, 50)))
+	return nil
+}
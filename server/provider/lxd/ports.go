@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider/portmapping/nftables"
 	"sort"
 	"strings"
 	"time"
@@ -93,6 +94,10 @@ func (l *LXDProvider) setupPortRangeMappingWithIP(instanceName string, ports []p
 	if len(ports) == 0 {
 		return nil
 	}
+	// nftables方式下所有端口作为一批元素原子提交，端口段只占一个元素
+	if method == "nftables" {
+		return l.setupNftablesMappings(instanceName, ports, instanceIP)
+	}
 	// 按协议和端口号排序，尝试找到连续的端口范围
 	var tcpPorts []providerModel.Port
 	var udpPorts []providerModel.Port
@@ -350,6 +355,12 @@ func (l *LXDProvider) setupPortMapping(instanceName string, hostPort, guestPort
 		return l.setupDeviceProxyMapping(instanceName, hostPort, guestPort, protocol)
 	case "iptables":
 		return l.setupIptablesMapping(instanceName, hostPort, guestPort, protocol)
+	case "nftables":
+		instanceIP, err := l.getInstanceIP(instanceName)
+		if err != nil {
+			return fmt.Errorf("获取实例IP失败: %w", err)
+		}
+		return l.setupNftablesMappings(instanceName, []providerModel.Port{{HostPort: hostPort, GuestPort: guestPort, Protocol: protocol}}, instanceIP)
 	default:
 		// 默认使用device proxy方式
 		return l.setupDeviceProxyMapping(instanceName, hostPort, guestPort, protocol)
@@ -371,6 +382,8 @@ func (l *LXDProvider) setupPortMappingWithIP(instanceName string, hostPort, gues
 		return l.setupDeviceProxyMappingWithIP(instanceName, hostPort, guestPort, protocol, instanceIP)
 	case "iptables":
 		return l.setupIptablesMappingWithIP(instanceName, hostPort, guestPort, protocol, instanceIP)
+	case "nftables":
+		return l.setupNftablesMappings(instanceName, []providerModel.Port{{HostPort: hostPort, GuestPort: guestPort, Protocol: protocol}}, instanceIP)
 	case "native":
 		// 独立IPv4模式下使用native方法，跳过端口映射
 		global.APP_LOG.Info("独立IPv4模式，跳过端口映射",
@@ -620,6 +633,8 @@ func (l *LXDProvider) removePortMapping(instanceName string, hostPort int, proto
 		return l.removeDeviceProxyMapping(instanceName, hostPort, protocol)
 	case "iptables":
 		return l.removeIptablesMapping(instanceName, hostPort, protocol)
+	case "nftables":
+		return l.removeNftablesMapping(instanceName, hostPort, protocol)
 	default:
 		// 默认使用device proxy方式
 		return l.removeDeviceProxyMapping(instanceName, hostPort, protocol)
@@ -679,6 +694,55 @@ func (l *LXDProvider) removeIptablesMapping(instanceName string, hostPort int, p
 	return nil
 }
 
+// setupNftablesMappings 使用nftables映射集合设置端口映射，所有元素整批提交并持久化
+func (l *LXDProvider) setupNftablesMappings(instanceName string, ports []providerModel.Port, instanceIP string) error {
+	mappings := make([]nftables.Mapping, 0, len(ports))
+	for _, port := range ports {
+		mappings = append(mappings, nftables.Mapping{
+			Protocol:    port.Protocol,
+			HostPort:    port.HostPort,
+			HostPortEnd: port.HostPortEnd,
+			GuestPort:   port.GuestPort,
+			TargetIP:    instanceIP,
+		})
+	}
+
+	for _, cmd := range nftables.ApplyCommands(mappings) {
+		if output, err := l.sshClient.Execute(cmd); err != nil {
+			return fmt.Errorf("添加nftables端口映射失败: %w, output: %s", err, output)
+		}
+	}
+
+	global.APP_LOG.Info("Nftables端口映射设置成功",
+		zap.String("instance", instanceName),
+		zap.Int("count", len(mappings)))
+
+	return nil
+}
+
+// removeNftablesMapping 移除nftables端口映射，按端口记录的地址族和端口段删除元素
+func (l *LXDProvider) removeNftablesMapping(instanceName string, hostPort int, protocol string) error {
+	mapping := nftables.Mapping{Protocol: protocol, HostPort: hostPort}
+	var port providerModel.Port
+	if err := global.APP_DB.Where("provider_id = ? AND host_port = ? AND protocol = ?", l.providerID, hostPort, protocol).
+		First(&port).Error; err == nil {
+		mapping.HostPortEnd = port.HostPortEnd
+		mapping.GuestPort = port.GuestPort
+		mapping.TargetIP = port.IPv6Address
+	}
+	for _, cmd := range nftables.RemoveCommands([]nftables.Mapping{mapping}) {
+		if _, err := l.sshClient.Execute(cmd); err != nil {
+			return fmt.Errorf("移除nftables端口映射失败: %w", err)
+		}
+	}
+
+	global.APP_LOG.Info("Nftables端口映射移除成功",
+		zap.String("instance", instanceName),
+		zap.Int("hostPort", hostPort))
+
+	return nil
+}
+
 // configureFirewallPorts 配置防火墙端口 - 根据实际的端口映射配置（非阻塞式）
 func (l *LXDProvider) configureFirewallPorts(instanceName string) error {
 	// 从数据库获取实例信息
@@ -0,0 +1,105 @@
+package lxd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// 私有网络使用LXD托管网桥，每个网络一个独立网桥，关闭NAT和路由，只在网桥内二层互通
+// 实例网卡开启IP和MAC过滤，只能使用分配的地址，无法冒充同网络中的其他实例
+
+// CreatePrivateNetwork 创建不做NAT和路由的托管网桥
+func (l *LXDProvider) CreatePrivateNetwork(ctx context.Context, spec provider.PrivateNetworkSpec) error {
+	if err := l.checkPrivateNetworkPrerequisites(); err != nil {
+		return err
+	}
+
+	ipNet, err := provider.ParsePrivateNetworkCIDR(spec.CIDR)
+	if err != nil {
+		return err
+	}
+	gateway := provider.PrivateNetworkAddressWithPrefix(ipNet, provider.PrivateNetworkGateway(ipNet))
+
+	cmd := fmt.Sprintf("lxc network create %s --type=bridge ipv4.address=%s ipv4.nat=false ipv4.routing=false ipv4.dhcp=true ipv6.address=none",
+		spec.Name, gateway)
+	if output, err := l.sshClient.Execute(cmd); err != nil {
+		if strings.Contains(output, "already exists") {
+			return nil
+		}
+		return fmt.Errorf("创建私有网络失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("LXD私有网络创建成功",
+		zap.String("network", spec.Name),
+		zap.String("cidr", spec.CIDR))
+	return nil
+}
+
+// DeletePrivateNetwork 删除托管网桥
+func (l *LXDProvider) DeletePrivateNetwork(ctx context.Context, spec provider.PrivateNetworkSpec) error {
+	if err := l.checkPrivateNetworkPrerequisites(); err != nil {
+		return err
+	}
+
+	if output, err := l.sshClient.Execute(fmt.Sprintf("lxc network delete %s", spec.Name)); err != nil {
+		if strings.Contains(output, "not found") {
+			return nil
+		}
+		return fmt.Errorf("删除私有网络失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("LXD私有网络删除成功", zap.String("network", spec.Name))
+	return nil
+}
+
+// AttachPrivateNetwork 为实例添加接入私有网络的网卡，设备名与网络名相同
+func (l *LXDProvider) AttachPrivateNetwork(ctx context.Context, spec provider.PrivateNetworkSpec) (string, error) {
+	if err := l.checkPrivateNetworkPrerequisites(); err != nil {
+		return "", err
+	}
+
+	cmd := fmt.Sprintf("lxc config device add %s %s nic network=%s ipv4.address=%s security.ipv4_filtering=true security.mac_filtering=true",
+		spec.Instance, spec.Name, spec.Name, spec.IPAddress)
+	if output, err := l.sshClient.Execute(cmd); err != nil {
+		return "", fmt.Errorf("接入私有网络失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return spec.Name, nil
+}
+
+// DetachPrivateNetwork 移除实例上接入私有网络的网卡
+func (l *LXDProvider) DetachPrivateNetwork(ctx context.Context, spec provider.PrivateNetworkSpec) error {
+	if err := l.checkPrivateNetworkPrerequisites(); err != nil {
+		return err
+	}
+
+	device := spec.Device
+	if device == "" {
+		device = spec.Name
+	}
+	if output, err := l.sshClient.Execute(fmt.Sprintf("lxc config device remove %s %s", spec.Instance, device)); err != nil {
+		// 实例或设备已不存在时视为已断开
+		if strings.Contains(output, "not found") || strings.Contains(output, "doesn't exist") {
+			return nil
+		}
+		return fmt.Errorf("断开私有网络失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return nil
+}
+
+// checkPrivateNetworkPrerequisites 私有网络操作只能通过SSH执行
+func (l *LXDProvider) checkPrivateNetworkPrerequisites() error {
+	if !l.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if !l.shouldUseSSH() {
+		return fmt.Errorf("执行规则不允许使用SSH，无法管理私有网络")
+	}
+	return nil
+}
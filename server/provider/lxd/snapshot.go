@@ -0,0 +1,149 @@
+package lxd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// lxdSnapshotInfo lxc query返回的快照信息
+type lxdSnapshotInfo struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Stateful  bool      `json:"stateful"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateSnapshot 创建实例快照
+func (l *LXDProvider) CreateSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	if err := l.checkSnapshotPrerequisites(snapshotName); err != nil {
+		return err
+	}
+	return l.sshCreateSnapshot(ctx, instanceID, snapshotName)
+}
+
+// ListSnapshots 列出实例快照
+func (l *LXDProvider) ListSnapshots(ctx context.Context, instanceID string) ([]provider.Snapshot, error) {
+	if !l.connected {
+		return nil, fmt.Errorf("provider not connected")
+	}
+	if !l.shouldUseSSH() {
+		return nil, fmt.Errorf("执行规则不允许使用SSH，无法管理实例快照")
+	}
+	return l.sshListSnapshots(ctx, instanceID)
+}
+
+// RestoreSnapshot 将实例恢复到指定快照
+func (l *LXDProvider) RestoreSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	if err := l.checkSnapshotPrerequisites(snapshotName); err != nil {
+		return err
+	}
+	return l.sshRestoreSnapshot(ctx, instanceID, snapshotName)
+}
+
+// DeleteSnapshot 删除实例快照
+func (l *LXDProvider) DeleteSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	if err := l.checkSnapshotPrerequisites(snapshotName); err != nil {
+		return err
+	}
+	return l.sshDeleteSnapshot(ctx, instanceID, snapshotName)
+}
+
+// checkSnapshotPrerequisites 检查快照操作的前置条件
+// LXD API的快照操作是异步的，统一通过SSH执行以便等待操作完成
+func (l *LXDProvider) checkSnapshotPrerequisites(snapshotName string) error {
+	if !l.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if !utils.IsValidSnapshotName(snapshotName) {
+		return fmt.Errorf("invalid snapshot name: %s", snapshotName)
+	}
+	if !l.shouldUseSSH() {
+		return fmt.Errorf("执行规则不允许使用SSH，无法管理实例快照")
+	}
+	return nil
+}
+
+func (l *LXDProvider) sshCreateSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	output, err := l.sshClient.Execute(fmt.Sprintf("lxc snapshot %s %s", instanceID, snapshotName))
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("LXD实例快照创建成功",
+		zap.String("instance", utils.TruncateString(instanceID, 50)),
+		zap.String("snapshot", snapshotName))
+	return nil
+}
+
+func (l *LXDProvider) sshListSnapshots(ctx context.Context, instanceID string) ([]provider.Snapshot, error) {
+	output, err := l.sshClient.Execute(fmt.Sprintf("lxc query /1.0/instances/%s/snapshots?recursion=1", instanceID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var infos []lxdSnapshotInfo
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &infos); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot list: %w", err)
+	}
+
+	snapshots := make([]provider.Snapshot, 0, len(infos))
+	for _, info := range infos {
+		// 部分版本返回 instance/snapshot 形式的完整名称
+		name := info.Name
+		if idx := strings.LastIndex(name, "/"); idx >= 0 {
+			name = name[idx+1:]
+		}
+		snapshot := provider.Snapshot{
+			Name:     name,
+			Stateful: info.Stateful,
+			Created:  info.CreatedAt,
+			Metadata: map[string]string{},
+		}
+		if !info.ExpiresAt.IsZero() {
+			snapshot.Metadata["expires_at"] = info.ExpiresAt.Format(time.RFC3339)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
+
+func (l *LXDProvider) sshRestoreSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	output, err := l.sshClient.Execute(fmt.Sprintf("lxc restore %s %s", instanceID, snapshotName))
+	if err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("LXD实例快照恢复成功",
+		zap.String("instance", utils.TruncateString(instanceID, 50)),
+		zap.String("snapshot", snapshotName))
+	return nil
+}
+
+func (l *LXDProvider) sshDeleteSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	output, err := l.sshClient.Execute(fmt.Sprintf("lxc delete %s/%s", instanceID, snapshotName))
+	if err != nil {
+		// 快照已不存在时视为删除成功
+		if strings.Contains(output, "not found") || strings.Contains(err.Error(), "not found") {
+			global.APP_LOG.Info("LXD实例快照不存在，跳过删除",
+				zap.String("instance", utils.TruncateString(instanceID, 50)),
+				zap.String("snapshot", snapshotName))
+			return nil
+		}
+		return fmt.Errorf("failed to delete snapshot: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("LXD实例快照删除成功",
+		zap.String("instance", utils.TruncateString(instanceID, 50)),
+		zap.String("snapshot", snapshotName))
+	return nil
+}
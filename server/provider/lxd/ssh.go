@@ -409,6 +409,11 @@ func (l *LXDProvider) sshCreateInstanceWithProgress(ctx context.Context, config
 		global.APP_LOG.Warn("配置实例安全设置失败，但继续", zap.Error(err))
 	}
 
+	// 用户提供的cloud-init配置需要在首次启动前写入
+	if err := l.setInstanceUserData(config); err != nil {
+		global.APP_LOG.Warn("配置实例user-data失败，但继续", zap.Error(err))
+	}
+
 	updateProgress(55, "启动实例...")
 	// 启动实例
 	_, err = l.sshClient.Execute(fmt.Sprintf("lxc start %s", config.Name))
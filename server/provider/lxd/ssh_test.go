@@ -0,0 +1,62 @@
+package lxd
+
+import (
+	"context"
+	"testing"
+
+	"oneclickvirt/global"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// newReplayProvider 创建回放录制文件的LXD Provider，不连接任何节点
+func newReplayProvider(t *testing.T, fixture string) (*LXDProvider, *utils.CommandReplayer) {
+	t.Helper()
+	global.APP_LOG = zap.NewNop()
+	replayer, err := utils.LoadCommandReplayer(fixture)
+	if err != nil {
+		t.Fatalf("加载录制文件失败: %v", err)
+	}
+	return &LXDProvider{
+		sshClient: utils.NewSSHClientWithExecutor(utils.SSHConfig{Host: "lxd-node.example"}, replayer),
+		connected: true,
+	}, replayer
+}
+
+func TestSSHListInstances_ParsesCSVAndNetworkState(t *testing.T) {
+	l, replayer := newReplayProvider(t, "testdata/lxc_list.json")
+
+	instances, err := l.sshListInstances(context.Background())
+	if err != nil {
+		t.Fatalf("获取实例列表失败: %v", err)
+	}
+	if len(instances) != 2 {
+		t.Fatalf("实例数量不正确: %d", len(instances))
+	}
+
+	web := instances[0]
+	if web.Name != "web1" || web.Status != "running" || web.Type != "CONTAINER" {
+		t.Errorf("web1基本信息不正确: %+v", web)
+	}
+	if web.PrivateIP != "10.0.3.15" || web.IP != "10.0.3.15" {
+		t.Errorf("web1内网IPv4不正确: %q", web.PrivateIP)
+	}
+	// eth0上的ULA地址应被eth1上的公网IPv6替换
+	if web.IPv6Address != "2001:db8::15" {
+		t.Errorf("web1 IPv6不正确: %q", web.IPv6Address)
+	}
+
+	db := instances[1]
+	if db.Name != "db2" || db.Status != "stopped" || db.PrivateIP != "" {
+		t.Errorf("db2基本信息不正确: %+v", db)
+	}
+	// 停止的实例没有网络状态，IPv6从devices配置获取
+	if db.IPv6Address != "2001:db8::20" {
+		t.Errorf("db2 IPv6不正确: %q", db.IPv6Address)
+	}
+
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Errorf("存在未执行的录制命令: %v", unused)
+	}
+}
@@ -0,0 +1,131 @@
+package lxd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// 数据卷使用LXD自定义存储卷：容器挂载文件系统卷，虚拟机挂载块卷
+
+// CreateVolume 在存储池上创建自定义存储卷
+func (l *LXDProvider) CreateVolume(ctx context.Context, spec provider.VolumeSpec) (string, error) {
+	if err := l.checkVolumePrerequisites(); err != nil {
+		return "", err
+	}
+
+	pool := spec.Pool
+	if pool == "" {
+		pool = l.defaultVolumePool()
+	}
+
+	cmd := fmt.Sprintf("lxc storage volume create %s %s size=%dMiB", pool, spec.Name, spec.SizeMB)
+	if spec.InstanceType == "vm" {
+		cmd += " --type=block"
+	}
+	if output, err := l.sshClient.Execute(cmd); err != nil {
+		return "", fmt.Errorf("创建存储卷失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("LXD存储卷创建成功",
+		zap.String("pool", pool),
+		zap.String("volume", spec.Name),
+		zap.Int64("sizeMB", spec.SizeMB))
+	return pool, nil
+}
+
+// AttachVolume 将存储卷挂载到实例，设备名与卷名相同
+func (l *LXDProvider) AttachVolume(ctx context.Context, spec provider.VolumeSpec) (string, error) {
+	if err := l.checkVolumePrerequisites(); err != nil {
+		return "", err
+	}
+
+	cmd := fmt.Sprintf("lxc storage volume attach %s %s %s %s", spec.Pool, spec.Name, spec.Instance, spec.Name)
+	if spec.InstanceType != "vm" {
+		mountPath := spec.MountPath
+		if mountPath == "" {
+			mountPath = provider.DefaultVolumeMountPath(spec.Name)
+		}
+		cmd += " " + mountPath
+	}
+	if output, err := l.sshClient.Execute(cmd); err != nil {
+		return "", fmt.Errorf("挂载存储卷失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return spec.Name, nil
+}
+
+// DetachVolume 从实例上卸载存储卷
+func (l *LXDProvider) DetachVolume(ctx context.Context, spec provider.VolumeSpec) error {
+	if err := l.checkVolumePrerequisites(); err != nil {
+		return err
+	}
+
+	cmd := fmt.Sprintf("lxc storage volume detach %s %s %s", spec.Pool, spec.Name, spec.Instance)
+	if output, err := l.sshClient.Execute(cmd); err != nil {
+		// 实例或设备已不存在时视为已卸载
+		if strings.Contains(output, "not found") || strings.Contains(output, "No device found") {
+			return nil
+		}
+		return fmt.Errorf("卸载存储卷失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return nil
+}
+
+// ResizeVolume 调整存储卷容量
+func (l *LXDProvider) ResizeVolume(ctx context.Context, spec provider.VolumeSpec) error {
+	if err := l.checkVolumePrerequisites(); err != nil {
+		return err
+	}
+
+	cmd := fmt.Sprintf("lxc storage volume set %s %s size=%dMiB", spec.Pool, spec.Name, spec.SizeMB)
+	if output, err := l.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("调整存储卷容量失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return nil
+}
+
+// DeleteVolume 删除存储卷
+func (l *LXDProvider) DeleteVolume(ctx context.Context, spec provider.VolumeSpec) error {
+	if err := l.checkVolumePrerequisites(); err != nil {
+		return err
+	}
+
+	cmd := fmt.Sprintf("lxc storage volume delete %s %s", spec.Pool, spec.Name)
+	if output, err := l.sshClient.Execute(cmd); err != nil {
+		if strings.Contains(output, "not found") {
+			return nil
+		}
+		return fmt.Errorf("删除存储卷失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("LXD存储卷删除成功",
+		zap.String("pool", spec.Pool),
+		zap.String("volume", spec.Name))
+	return nil
+}
+
+// defaultVolumePool 默认profile根磁盘所在的存储池
+func (l *LXDProvider) defaultVolumePool() string {
+	output, err := l.sshClient.Execute("lxc profile device get default root pool")
+	if pool := strings.TrimSpace(output); err == nil && pool != "" {
+		return pool
+	}
+	return "default"
+}
+
+// checkVolumePrerequisites 存储卷操作只能通过SSH执行
+func (l *LXDProvider) checkVolumePrerequisites() error {
+	if !l.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if !l.shouldUseSSH() {
+		return fmt.Errorf("执行规则不允许使用SSH，无法管理存储卷")
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+
+	"oneclickvirt/provider"
+)
+
+// ProbeCapabilities 模拟节点具备全部能力，可通过故障注入模拟探测失败
+func (m *MockProvider) ProbeCapabilities(ctx context.Context) (provider.Capabilities, error) {
+	node, err := m.connectedNode()
+	if err != nil {
+		return m.GetCapabilities(), err
+	}
+	if err := node.simulate(ctx, "ProbeCapabilities"); err != nil {
+		return m.GetCapabilities(), fmt.Errorf("failed to probe mock node: %w", err)
+	}
+
+	return m.StoreCapabilities(provider.Capabilities{
+		InstanceTypes:  m.GetSupportedInstanceTypes(),
+		IPv6:           true,
+		Snapshot:       true,
+		Backup:         true,
+		Migration:      true,
+		DiskResize:     true,
+		DiskQuota:      true,
+		BandwidthLimit: true,
+		DiskIOLimit:    true,
+		Nesting:        true,
+		Privileged:     true,
+	}), nil
+}
@@ -0,0 +1,52 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+
+	"oneclickvirt/provider"
+)
+
+// mockConsole 回显输入的内存控制台，记录最近一次调整的终端大小
+type mockConsole struct {
+	net.Conn
+	rows, cols int
+}
+
+func (c *mockConsole) Resize(rows, cols int) error {
+	c.rows, c.cols = rows, cols
+	return nil
+}
+
+func (c *mockConsole) Password() string { return "" }
+
+// OpenConsole 打开回显控制台，实例必须存在且处于运行状态
+func (m *MockProvider) OpenConsole(ctx context.Context, instanceID, consoleType string) (provider.ConsoleSession, error) {
+	node, err := m.connectedNode()
+	if err != nil {
+		return nil, err
+	}
+	if err := node.simulate(ctx, "OpenConsole"); err != nil {
+		return nil, err
+	}
+
+	node.mu.Lock()
+	inst, ok := node.instances[instanceID]
+	running := ok && inst.info.Status == "running"
+	node.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("instance not found")
+	}
+	if !running {
+		return nil, fmt.Errorf("instance %s is not running", instanceID)
+	}
+
+	client, server := net.Pipe()
+	go func() {
+		io.Copy(server, server)
+		server.Close()
+	}()
+	return &mockConsole{Conn: client}, nil
+}
@@ -0,0 +1,562 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+)
+
+// mockArchive ExportInstance生成的归档内容
+type mockArchive struct {
+	Instance provider.Instance `json:"instance"`
+	Password string            `json:"password"`
+}
+
+func (m *MockProvider) ListInstances(ctx context.Context) ([]provider.Instance, error) {
+	node, err := m.connectedNode()
+	if err != nil {
+		return nil, err
+	}
+	if err := node.simulate(ctx, "ListInstances"); err != nil {
+		return nil, err
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	instances := make([]provider.Instance, 0, len(node.instances))
+	for _, inst := range node.instances {
+		instances = append(instances, copyInstance(inst.info))
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].Name < instances[j].Name })
+	return instances, nil
+}
+
+func (m *MockProvider) CreateInstance(ctx context.Context, config provider.InstanceConfig) error {
+	return m.CreateInstanceWithProgress(ctx, config, nil)
+}
+
+func (m *MockProvider) CreateInstanceWithProgress(ctx context.Context, config provider.InstanceConfig, progressCallback provider.ProgressCallback) error {
+	node, err := m.connectedNode()
+	if err != nil {
+		return err
+	}
+	if config.Name == "" {
+		return fmt.Errorf("instance name is required")
+	}
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+	}
+
+	updateProgress(10, "正在准备镜像...")
+	if err := node.simulate(ctx, "CreateInstance"); err != nil {
+		return err
+	}
+	updateProgress(50, "正在创建实例...")
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	if _, exists := node.instances[config.Name]; exists {
+		return fmt.Errorf("instance %s already exists", config.Name)
+	}
+
+	if config.Image != "" {
+		if _, ok := node.images[config.Image]; !ok {
+			node.images[config.Image] = newImage(config.Image)
+		}
+	}
+
+	instanceType := config.InstanceType
+	if instanceType == "" {
+		instanceType = "container"
+	}
+	ipv4, ipv6 := node.allocateAddresses(config.Metadata["network_type"])
+
+	metadata := make(map[string]string, len(config.Metadata)+1)
+	for k, v := range config.Metadata {
+		if k == "password" {
+			continue
+		}
+		metadata[k] = v
+	}
+	metadata["network_interface"] = "veth-" + config.Name
+
+	node.instances[config.Name] = &mockInstance{
+		info: provider.Instance{
+			ID:          config.Name,
+			Name:        config.Name,
+			Status:      "running",
+			Type:        instanceType,
+			Image:       config.Image,
+			IP:          ipv4,
+			PrivateIP:   ipv4,
+			IPv6Address: ipv6,
+			CPU:         config.CPU,
+			Memory:      config.Memory,
+			Disk:        config.Disk,
+			Created:     time.Now(),
+			Metadata:    metadata,
+		},
+		password: config.Metadata["password"],
+	}
+
+	updateProgress(100, "实例创建完成")
+	return nil
+}
+
+func (m *MockProvider) StartInstance(ctx context.Context, id string) error {
+	return m.setInstanceStatus(ctx, "StartInstance", id, "running")
+}
+
+func (m *MockProvider) StopInstance(ctx context.Context, id string) error {
+	return m.setInstanceStatus(ctx, "StopInstance", id, "stopped")
+}
+
+func (m *MockProvider) RestartInstance(ctx context.Context, id string) error {
+	return m.setInstanceStatus(ctx, "RestartInstance", id, "running")
+}
+
+// setInstanceStatus 修改实例运行状态
+func (m *MockProvider) setInstanceStatus(ctx context.Context, operation, id, status string) error {
+	node, err := m.connectedNode()
+	if err != nil {
+		return err
+	}
+	if err := node.simulate(ctx, operation); err != nil {
+		return err
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	inst, ok := node.instances[id]
+	if !ok {
+		return fmt.Errorf("instance %s not found", id)
+	}
+	inst.info.Status = status
+	return nil
+}
+
+func (m *MockProvider) DeleteInstance(ctx context.Context, id string) error {
+	node, err := m.connectedNode()
+	if err != nil {
+		return err
+	}
+	if err := node.simulate(ctx, "DeleteInstance"); err != nil {
+		return err
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	if _, ok := node.instances[id]; !ok {
+		return fmt.Errorf("instance %s not found", id)
+	}
+	delete(node.instances, id)
+	// 和proxy设备一样，实例删除后其端口映射随之删除
+	for key, mapping := range node.portMappings {
+		if mapping.InstanceName == id {
+			delete(node.portMappings, key)
+		}
+	}
+	return nil
+}
+
+func (m *MockProvider) GetInstance(ctx context.Context, id string) (*provider.Instance, error) {
+	node, err := m.connectedNode()
+	if err != nil {
+		return nil, err
+	}
+	if err := node.simulate(ctx, "GetInstance"); err != nil {
+		return nil, err
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	inst, ok := node.instances[id]
+	if !ok {
+		return nil, fmt.Errorf("instance not found")
+	}
+	info := copyInstance(inst.info)
+	return &info, nil
+}
+
+func (m *MockProvider) ListImages(ctx context.Context) ([]provider.Image, error) {
+	node, err := m.connectedNode()
+	if err != nil {
+		return nil, err
+	}
+	if err := node.simulate(ctx, "ListImages"); err != nil {
+		return nil, err
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	images := make([]provider.Image, 0, len(node.images))
+	for _, image := range node.images {
+		images = append(images, image)
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Name < images[j].Name })
+	return images, nil
+}
+
+func (m *MockProvider) PullImage(ctx context.Context, image string) error {
+	node, err := m.connectedNode()
+	if err != nil {
+		return err
+	}
+	if err := node.simulate(ctx, "PullImage"); err != nil {
+		return err
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	if _, ok := node.images[image]; !ok {
+		node.images[image] = newImage(image)
+	}
+	return nil
+}
+
+func (m *MockProvider) DeleteImage(ctx context.Context, id string) error {
+	node, err := m.connectedNode()
+	if err != nil {
+		return err
+	}
+	if err := node.simulate(ctx, "DeleteImage"); err != nil {
+		return err
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	if _, ok := node.images[id]; !ok {
+		return fmt.Errorf("image %s not found", id)
+	}
+	for _, inst := range node.instances {
+		if inst.info.Image == id {
+			return fmt.Errorf("image %s is used by instance %s", id, inst.info.Name)
+		}
+	}
+	delete(node.images, id)
+	return nil
+}
+
+func (m *MockProvider) SetInstancePassword(ctx context.Context, instanceID, password string) error {
+	node, err := m.connectedNode()
+	if err != nil {
+		return err
+	}
+	if err := node.simulate(ctx, "SetInstancePassword"); err != nil {
+		return err
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	inst, ok := node.instances[instanceID]
+	if !ok {
+		return fmt.Errorf("instance %s not found", instanceID)
+	}
+	if inst.info.Status != "running" {
+		return fmt.Errorf("instance %s is not running", instanceID)
+	}
+	inst.password = password
+	return nil
+}
+
+func (m *MockProvider) ResetInstancePassword(ctx context.Context, instanceID string) (string, error) {
+	password := utils.GenerateInstancePassword()
+	if err := m.SetInstancePassword(ctx, instanceID, password); err != nil {
+		return "", err
+	}
+	return password, nil
+}
+
+func (m *MockProvider) ResizeInstance(ctx context.Context, instanceID string, spec provider.ResizeSpec) error {
+	node, err := m.connectedNode()
+	if err != nil {
+		return err
+	}
+	if err := node.simulate(ctx, "ResizeInstance"); err != nil {
+		return err
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	inst, ok := node.instances[instanceID]
+	if !ok {
+		return fmt.Errorf("instance %s not found", instanceID)
+	}
+	if spec.Disk > 0 {
+		if current, err := strconv.ParseInt(inst.info.Disk, 10, 64); err == nil && spec.Disk < current {
+			return fmt.Errorf("磁盘只支持扩容，当前%dMB，目标%dMB", current, spec.Disk)
+		}
+		inst.info.Disk = strconv.FormatInt(spec.Disk, 10)
+	}
+	if spec.CPU > 0 {
+		inst.info.CPU = strconv.Itoa(spec.CPU)
+	}
+	if spec.Memory > 0 {
+		inst.info.Memory = strconv.FormatInt(spec.Memory, 10)
+	}
+	return nil
+}
+
+func (m *MockProvider) CreateSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	node, err := m.connectedNode()
+	if err != nil {
+		return err
+	}
+	if err := node.simulate(ctx, "CreateSnapshot"); err != nil {
+		return err
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	inst, ok := node.instances[instanceID]
+	if !ok {
+		return fmt.Errorf("instance %s not found", instanceID)
+	}
+	if inst.findSnapshot(snapshotName) >= 0 {
+		return fmt.Errorf("snapshot %s already exists", snapshotName)
+	}
+	inst.snapshots = append(inst.snapshots, &mockSnapshot{
+		info:     provider.Snapshot{Name: snapshotName, Created: time.Now()},
+		state:    copyInstance(inst.info),
+		password: inst.password,
+	})
+	return nil
+}
+
+func (m *MockProvider) ListSnapshots(ctx context.Context, instanceID string) ([]provider.Snapshot, error) {
+	node, err := m.connectedNode()
+	if err != nil {
+		return nil, err
+	}
+	if err := node.simulate(ctx, "ListSnapshots"); err != nil {
+		return nil, err
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	inst, ok := node.instances[instanceID]
+	if !ok {
+		return nil, fmt.Errorf("instance %s not found", instanceID)
+	}
+	snapshots := make([]provider.Snapshot, 0, len(inst.snapshots))
+	for _, snap := range inst.snapshots {
+		snapshots = append(snapshots, snap.info)
+	}
+	return snapshots, nil
+}
+
+func (m *MockProvider) RestoreSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	node, err := m.connectedNode()
+	if err != nil {
+		return err
+	}
+	if err := node.simulate(ctx, "RestoreSnapshot"); err != nil {
+		return err
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	inst, ok := node.instances[instanceID]
+	if !ok {
+		return fmt.Errorf("instance %s not found", instanceID)
+	}
+	idx := inst.findSnapshot(snapshotName)
+	if idx < 0 {
+		return fmt.Errorf("snapshot %s not found", snapshotName)
+	}
+	snap := inst.snapshots[idx]
+	status := inst.info.Status
+	inst.info = copyInstance(snap.state)
+	inst.info.Status = status
+	inst.password = snap.password
+	return nil
+}
+
+func (m *MockProvider) DeleteSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	node, err := m.connectedNode()
+	if err != nil {
+		return err
+	}
+	if err := node.simulate(ctx, "DeleteSnapshot"); err != nil {
+		return err
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	inst, ok := node.instances[instanceID]
+	if !ok {
+		return fmt.Errorf("instance %s not found", instanceID)
+	}
+	idx := inst.findSnapshot(snapshotName)
+	if idx < 0 {
+		return fmt.Errorf("snapshot %s not found", snapshotName)
+	}
+	inst.snapshots = append(inst.snapshots[:idx], inst.snapshots[idx+1:]...)
+	return nil
+}
+
+// ExportInstance 以JSON格式导出实例状态和密码
+func (m *MockProvider) ExportInstance(ctx context.Context, instanceID string, w io.Writer, progressCallback provider.ProgressCallback) error {
+	node, err := m.connectedNode()
+	if err != nil {
+		return err
+	}
+	if err := node.simulate(ctx, "ExportInstance"); err != nil {
+		return err
+	}
+
+	node.mu.Lock()
+	inst, ok := node.instances[instanceID]
+	var archive mockArchive
+	if ok {
+		archive = mockArchive{Instance: copyInstance(inst.info), Password: inst.password}
+	}
+	node.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("instance %s not found", instanceID)
+	}
+
+	if err := json.NewEncoder(w).Encode(archive); err != nil {
+		return fmt.Errorf("写入归档失败: %w", err)
+	}
+	if progressCallback != nil {
+		progressCallback(100, "实例导出完成")
+	}
+	return nil
+}
+
+// ImportInstance 从归档创建新实例，重新分配内网地址，导入后保持停止状态
+func (m *MockProvider) ImportInstance(ctx context.Context, instanceName, instanceType string, r io.Reader, progressCallback provider.ProgressCallback) error {
+	node, err := m.connectedNode()
+	if err != nil {
+		return err
+	}
+	if err := node.simulate(ctx, "ImportInstance"); err != nil {
+		return err
+	}
+
+	var archive mockArchive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return fmt.Errorf("解析归档失败: %w", err)
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	if _, exists := node.instances[instanceName]; exists {
+		return fmt.Errorf("instance %s already exists", instanceName)
+	}
+
+	info := copyInstance(archive.Instance)
+	info.ID = instanceName
+	info.Name = instanceName
+	info.Status = "stopped"
+	if instanceType != "" {
+		info.Type = instanceType
+	}
+	info.IP, info.IPv6Address = node.allocateAddresses("")
+	info.PrivateIP = info.IP
+	if info.Metadata == nil {
+		info.Metadata = make(map[string]string)
+	}
+	info.Metadata["network_interface"] = "veth-" + instanceName
+	node.instances[instanceName] = &mockInstance{info: info, password: archive.Password}
+
+	if progressCallback != nil {
+		progressCallback(100, "实例导入完成")
+	}
+	return nil
+}
+
+// RestoreInstance 用归档覆盖已有实例，保留名称和地址，快照不受影响，恢复后保持停止状态
+func (m *MockProvider) RestoreInstance(ctx context.Context, instanceID string, r io.Reader, progressCallback provider.ProgressCallback) error {
+	node, err := m.connectedNode()
+	if err != nil {
+		return err
+	}
+	if err := node.simulate(ctx, "RestoreInstance"); err != nil {
+		return err
+	}
+
+	var archive mockArchive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return fmt.Errorf("解析归档失败: %w", err)
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	inst, ok := node.instances[instanceID]
+	if !ok {
+		return fmt.Errorf("instance %s not found", instanceID)
+	}
+
+	info := copyInstance(archive.Instance)
+	info.ID = inst.info.ID
+	info.Name = inst.info.Name
+	info.Status = "stopped"
+	info.IP = inst.info.IP
+	info.PrivateIP = inst.info.PrivateIP
+	info.IPv6Address = inst.info.IPv6Address
+	info.Metadata = inst.info.Metadata
+	inst.info = info
+	inst.password = archive.Password
+
+	if progressCallback != nil {
+		progressCallback(100, "实例恢复完成")
+	}
+	return nil
+}
+
+// ExecuteSSHCommand 记录命令并返回SetCommandOutput预设的输出
+func (m *MockProvider) ExecuteSSHCommand(ctx context.Context, command string) (string, error) {
+	node, err := m.connectedNode()
+	if err != nil {
+		return "", fmt.Errorf("Mock provider not connected")
+	}
+	if err := node.simulate(ctx, "ExecuteSSHCommand"); err != nil {
+		return "", fmt.Errorf("SSH command execution failed: %w", err)
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	return node.recordCommand(command), nil
+}
+
+// findSnapshot 返回快照下标，不存在时返回-1
+func (inst *mockInstance) findSnapshot(name string) int {
+	for i, snap := range inst.snapshots {
+		if snap.info.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// copyInstance 深拷贝实例信息，避免调用方修改节点内部状态
+func copyInstance(info provider.Instance) provider.Instance {
+	if info.Metadata != nil {
+		metadata := make(map[string]string, len(info.Metadata))
+		for k, v := range info.Metadata {
+			metadata[k] = v
+		}
+		info.Metadata = metadata
+	}
+	return info
+}
+
+func newImage(name string) provider.Image {
+	return provider.Image{
+		ID:      name,
+		Name:    name,
+		Tag:     "latest",
+		Created: time.Now(),
+	}
+}
@@ -0,0 +1,143 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"oneclickvirt/provider"
+	"oneclickvirt/provider/health"
+)
+
+// MockProvider 内存模拟Provider，实例、镜像、IP、密码和端口映射都保存在内存节点中，
+// 用于在没有真实LXD/Incus/Proxmox节点的情况下运行任务、端口映射和流量流程
+type MockProvider struct {
+	provider.CapabilityCache
+
+	config        provider.NodeConfig
+	node          *Node
+	connected     bool
+	healthChecker *health.MockHealthChecker
+	mu            sync.RWMutex // 保护并发访问
+}
+
+// NewMockProvider 创建模拟Provider
+func NewMockProvider() provider.Provider {
+	return &MockProvider{}
+}
+
+func (m *MockProvider) GetType() string {
+	return "mock"
+}
+
+func (m *MockProvider) GetName() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.config.Name
+}
+
+func (m *MockProvider) GetSupportedInstanceTypes() []string {
+	return []string{"container", "vm"}
+}
+
+// Connect 连接到同名的模拟节点，同名Provider共享节点状态，和真实节点一样重连后实例仍然存在
+func (m *MockProvider) Connect(ctx context.Context, config provider.NodeConfig) error {
+	node := GetNode(nodeKey(config))
+	if err := node.simulate(ctx, "Connect"); err != nil {
+		return fmt.Errorf("failed to connect mock node: %w", err)
+	}
+
+	node.mu.Lock()
+	node.networkType = config.NetworkType
+	node.mu.Unlock()
+
+	healthConfig := health.HealthConfig{
+		ProviderID:   config.ID,
+		ProviderName: config.Name,
+		Host:         config.Host,
+		Port:         config.Port,
+		SSHEnabled:   true,
+		APIEnabled:   true,
+		Timeout:      30 * time.Second,
+	}
+	checker := health.NewMockHealthChecker(healthConfig, nil)
+	checker.SetProbe(func(ctx context.Context) error {
+		return node.simulate(ctx, "HealthCheck")
+	})
+
+	m.mu.Lock()
+	m.config = config
+	m.node = node
+	m.connected = true
+	m.healthChecker = checker
+	m.mu.Unlock()
+
+	m.ProbeCapabilities(ctx)
+	return nil
+}
+
+func (m *MockProvider) Disconnect(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connected = false
+	return nil
+}
+
+func (m *MockProvider) IsConnected() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.connected
+}
+
+func (m *MockProvider) HealthCheck(ctx context.Context) (*health.HealthResult, error) {
+	m.mu.RLock()
+	checker := m.healthChecker
+	m.mu.RUnlock()
+	if checker == nil {
+		return nil, fmt.Errorf("health checker not initialized")
+	}
+	return checker.CheckHealth(ctx)
+}
+
+func (m *MockProvider) GetHealthChecker() health.HealthChecker {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.healthChecker == nil {
+		return nil
+	}
+	return m.healthChecker
+}
+
+func (m *MockProvider) GetVersion() string {
+	return "mock-1.0"
+}
+
+// Node 返回当前连接的模拟节点，未连接时返回nil
+func (m *MockProvider) Node() *Node {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.node
+}
+
+// connectedNode 检查连接状态并返回模拟节点
+func (m *MockProvider) connectedNode() (*Node, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.connected || m.node == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+	return m.node, nil
+}
+
+// nodeKey 节点标识优先使用Provider名称，未设置时使用主机地址
+func nodeKey(config provider.NodeConfig) string {
+	if config.Name != "" {
+		return config.Name
+	}
+	return config.Host
+}
+
+func init() {
+	provider.RegisterProvider("mock", NewMockProvider)
+}
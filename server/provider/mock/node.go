@@ -0,0 +1,214 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"oneclickvirt/provider"
+)
+
+// FaultAll 对所有操作生效的故障注入标识
+const FaultAll = "*"
+
+// Node 模拟节点，保存实例、镜像、端口映射等全部状态，并支持配置延迟和故障注入
+type Node struct {
+	name           string
+	instances      map[string]*mockInstance
+	images         map[string]provider.Image
+	portMappings   map[string]*PortMapping
+	networkType    string
+	nextIP         int
+	latency        time.Duration
+	faults         map[string]*fault
+	commandOutputs map[string]string
+	commands       []string
+	mu             sync.Mutex
+}
+
+// mockInstance 模拟实例及其密码和快照
+type mockInstance struct {
+	info      provider.Instance
+	password  string
+	snapshots []*mockSnapshot
+}
+
+// mockSnapshot 模拟快照，保存创建时的实例状态用于回滚
+type mockSnapshot struct {
+	info     provider.Snapshot
+	state    provider.Instance
+	password string
+}
+
+// fault 注入的故障，remaining为剩余生效次数，0表示一直生效
+type fault struct {
+	err       error
+	remaining int
+}
+
+var (
+	nodes   = make(map[string]*Node)
+	nodesMu sync.Mutex
+)
+
+// GetNode 获取指定名称的模拟节点，不存在时创建，可在Connect之前预先注入故障
+func GetNode(name string) *Node {
+	nodesMu.Lock()
+	defer nodesMu.Unlock()
+	node, ok := nodes[name]
+	if !ok {
+		node = newNode(name)
+		nodes[name] = node
+	}
+	return node
+}
+
+// ResetNodes 清空所有模拟节点，测试之间调用以隔离状态
+func ResetNodes() {
+	nodesMu.Lock()
+	defer nodesMu.Unlock()
+	nodes = make(map[string]*Node)
+}
+
+func newNode(name string) *Node {
+	return &Node{
+		name:           name,
+		instances:      make(map[string]*mockInstance),
+		images:         make(map[string]provider.Image),
+		portMappings:   make(map[string]*PortMapping),
+		faults:         make(map[string]*fault),
+		commandOutputs: make(map[string]string),
+	}
+}
+
+// Name 节点名称
+func (n *Node) Name() string {
+	return n.name
+}
+
+// SetLatency 设置每次操作的模拟延迟
+func (n *Node) SetLatency(latency time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.latency = latency
+}
+
+// InjectFault 为指定操作注入故障，operation为Provider方法名（如CreateInstance）或FaultAll，
+// times为生效次数，小于等于0表示一直生效直到ClearFaults
+func (n *Node) InjectFault(operation string, err error, times int) {
+	if times < 0 {
+		times = 0
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.faults[operation] = &fault{err: err, remaining: times}
+}
+
+// ClearFaults 清除所有注入的故障
+func (n *Node) ClearFaults() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.faults = make(map[string]*fault)
+}
+
+// SetCommandOutput 设置以prefix开头的SSH命令的返回输出
+func (n *Node) SetCommandOutput(prefix, output string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.commandOutputs[prefix] = output
+}
+
+// ExecutedCommands 返回已执行的SSH命令记录
+func (n *Node) ExecutedCommands() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	commands := make([]string, len(n.commands))
+	copy(commands, n.commands)
+	return commands
+}
+
+// InstancePassword 返回实例当前密码，用于测试断言
+func (n *Node) InstancePassword(name string) (string, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	inst, ok := n.instances[name]
+	if !ok {
+		return "", false
+	}
+	return inst.password, true
+}
+
+// simulate 模拟一次节点操作：先等待配置的延迟（可被ctx取消），再返回注入的故障
+func (n *Node) simulate(ctx context.Context, operation string) error {
+	n.mu.Lock()
+	latency := n.latency
+	err := n.takeFault(operation)
+	n.mu.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	} else if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// takeFault 取出操作对应的故障并扣减次数，调用方需持有锁
+func (n *Node) takeFault(operation string) error {
+	key := operation
+	f, ok := n.faults[key]
+	if !ok {
+		key = FaultAll
+		if f, ok = n.faults[key]; !ok {
+			return nil
+		}
+	}
+	if f.remaining > 0 {
+		f.remaining--
+		if f.remaining == 0 {
+			delete(n.faults, key)
+		}
+	}
+	if f.err == nil {
+		return fmt.Errorf("mock fault injected: %s", operation)
+	}
+	return f.err
+}
+
+// allocateAddresses 按节点网络类型分配内网IPv4和IPv6地址，调用方需持有锁
+func (n *Node) allocateAddresses(networkType string) (string, string) {
+	if networkType == "" {
+		networkType = n.networkType
+	}
+	n.nextIP++
+	var ipv4, ipv6 string
+	if networkType != "ipv6_only" {
+		ipv4 = fmt.Sprintf("10.200.%d.%d", n.nextIP/250, n.nextIP%250+2)
+	}
+	if strings.Contains(networkType, "ipv6") {
+		ipv6 = fmt.Sprintf("fd00:200::%x", n.nextIP+1)
+	}
+	return ipv4, ipv6
+}
+
+// recordCommand 记录SSH命令并返回预设输出，调用方需持有锁
+func (n *Node) recordCommand(command string) string {
+	n.commands = append(n.commands, command)
+	longest := ""
+	output := ""
+	for prefix, out := range n.commandOutputs {
+		if strings.HasPrefix(command, prefix) && len(prefix) >= len(longest) {
+			longest = prefix
+			output = out
+		}
+	}
+	return output
+}
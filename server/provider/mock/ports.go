@@ -0,0 +1,86 @@
+package mock
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PortMapping 模拟节点上生效的端口转发规则
+type PortMapping struct {
+	InstanceName string `json:"instanceName"`
+	Protocol     string `json:"protocol"`
+	HostPort     int    `json:"hostPort"`
+	GuestPort    int    `json:"guestPort"`
+	GuestIP      string `json:"guestIP"`
+}
+
+func portMappingKey(protocol string, hostPort int) string {
+	return fmt.Sprintf("%s:%d", protocol, hostPort)
+}
+
+// AddPortMapping 添加端口转发规则，同协议的主机端口不能重复占用
+func (n *Node) AddPortMapping(mapping PortMapping) error {
+	if mapping.Protocol == "" {
+		mapping.Protocol = "tcp"
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := n.takeFault("AddPortMapping"); err != nil {
+		return err
+	}
+	inst, ok := n.instances[mapping.InstanceName]
+	if !ok {
+		return fmt.Errorf("instance %s not found", mapping.InstanceName)
+	}
+	// tcp/udp同时映射时分别占用两个协议的端口
+	protocols := []string{mapping.Protocol}
+	if mapping.Protocol == "both" {
+		protocols = []string{"tcp", "udp"}
+	}
+	for _, protocol := range protocols {
+		if existing, ok := n.portMappings[portMappingKey(protocol, mapping.HostPort)]; ok {
+			return fmt.Errorf("host port %d/%s is already used by %s", mapping.HostPort, protocol, existing.InstanceName)
+		}
+	}
+	if mapping.GuestIP == "" {
+		mapping.GuestIP = inst.info.PrivateIP
+	}
+	for _, protocol := range protocols {
+		m := mapping
+		m.Protocol = protocol
+		n.portMappings[portMappingKey(protocol, mapping.HostPort)] = &m
+	}
+	return nil
+}
+
+// RemovePortMapping 删除端口转发规则，规则不存在时不报错
+func (n *Node) RemovePortMapping(protocol string, hostPort int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if protocol == "both" {
+		delete(n.portMappings, portMappingKey("tcp", hostPort))
+		delete(n.portMappings, portMappingKey("udp", hostPort))
+		return
+	}
+	delete(n.portMappings, portMappingKey(protocol, hostPort))
+}
+
+// PortMappings 返回实例的端口转发规则，instanceName为空时返回全部
+func (n *Node) PortMappings(instanceName string) []PortMapping {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var mappings []PortMapping
+	for _, mapping := range n.portMappings {
+		if instanceName == "" || mapping.InstanceName == instanceName {
+			mappings = append(mappings, *mapping)
+		}
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		if mappings[i].HostPort != mappings[j].HostPort {
+			return mappings[i].HostPort < mappings[j].HostPort
+		}
+		return mappings[i].Protocol < mappings[j].Protocol
+	})
+	return mappings
+}
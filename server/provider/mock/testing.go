@@ -0,0 +1,73 @@
+package mock
+
+import (
+	"strings"
+	"testing"
+
+	"oneclickvirt/global"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// SetupTestDB 为测试打开内存SQLite并迁移指定模型，替换global.APP_DB并重置所有模拟节点，测试结束后自动关闭
+// 配合模拟Provider可以在没有MySQL和真实节点的情况下运行service层的完整流程
+func SetupTestDB(tb testing.TB, models ...interface{}) *gorm.DB {
+	tb.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		tb.Fatalf("打开SQLite失败: %v", err)
+	}
+	// 内存库每个连接都是独立的数据库，限制为单连接
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	if err := migrateSQLite(db, models...); err != nil {
+		tb.Fatalf("迁移表结构失败: %v", err)
+	}
+	global.APP_DB = db
+	global.APP_LOG = zap.NewNop()
+	ResetNodes()
+	tb.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		global.APP_DB = nil
+	})
+	return db
+}
+
+// migrateSQLite 逐个迁移模型，SQLite的索引名在库内全局唯一，迁移后为各表索引加上表名前缀避免冲突
+func migrateSQLite(db *gorm.DB, models ...interface{}) error {
+	for _, model := range models {
+		if err := db.AutoMigrate(model); err != nil {
+			return err
+		}
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+		table := stmt.Schema.Table
+		var indexes []struct {
+			Name string
+			SQL  string
+		}
+		if err := db.Raw("SELECT name, sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL", table).Scan(&indexes).Error; err != nil {
+			return err
+		}
+		for _, idx := range indexes {
+			if strings.Contains(idx.Name, table) {
+				continue
+			}
+			if err := db.Exec("DROP INDEX `" + idx.Name + "`").Error; err != nil {
+				return err
+			}
+			if err := db.Exec(strings.Replace(idx.SQL, "`"+idx.Name+"`", "`"+table+"_"+idx.Name+"`", 1)).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+
+	"oneclickvirt/provider"
+)
+
+// ProbeCapabilities 探测Podman节点能力
+// rootless模式下没有ipv6_net网络且无法使用磁盘配额，checkIPv6NetworkAvailable和checkStorageDriver已按模式区分
+func (p *PodmanProvider) ProbeCapabilities(ctx context.Context) (provider.Capabilities, error) {
+	if !p.connected || p.sshClient == nil {
+		return p.GetCapabilities(), fmt.Errorf("provider not connected")
+	}
+
+	diskQuota, _, err := p.checkStorageDriver()
+	if err != nil {
+		return p.GetCapabilities(), err
+	}
+
+	return p.StoreCapabilities(provider.Capabilities{
+		InstanceTypes: p.GetSupportedInstanceTypes(),
+		IPv6:          p.checkIPv6NetworkAvailable(),
+		Snapshot:      true,
+		Backup:        true,
+		DiskQuota:     diskQuota,
+	}), nil
+}
@@ -0,0 +1,30 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/provider"
+)
+
+// OpenConsole 打开容器控制台
+// 容器以 -it 启动时使用 podman attach 连接主进程，否则通过 podman exec 启动shell
+func (p *PodmanProvider) OpenConsole(ctx context.Context, instanceID, consoleType string) (provider.ConsoleSession, error) {
+	if !p.connected {
+		return nil, fmt.Errorf("provider not connected")
+	}
+	if err := p.checkExecutionRule(); err != nil {
+		return nil, err
+	}
+	if consoleType != provider.ConsoleTypeSerial {
+		return nil, fmt.Errorf("Podman容器不支持%s控制台", consoleType)
+	}
+
+	command := p.podman("exec -it %s sh -c 'command -v bash >/dev/null && exec bash || exec sh'", instanceID)
+	output, err := p.sshClient.Execute(p.podman("inspect -f '{{.Config.Tty}} {{.Config.OpenStdin}}' %s", instanceID))
+	if err == nil && strings.TrimSpace(output) == "true true" {
+		command = p.podman("attach --sig-proxy=false %s", instanceID)
+	}
+	return provider.NewSSHConsole(p.sshClient, command)
+}
@@ -0,0 +1,143 @@
+package podman
+
+import (
+	"crypto/md5"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// podmanImageDir 远程镜像下载目录
+const podmanImageDir = "/usr/local/bin/podman_ct_images"
+
+// downloadImageToRemote 在远程服务器上下载镜像
+// Podman可以直接加载Docker导出的镜像归档，因此镜像来源与Docker相同
+func (p *PodmanProvider) downloadImageToRemote(imageURL, imageName, providerCountry, architecture string, useCDN bool) (string, error) {
+	if _, err := p.sshClient.Execute(fmt.Sprintf("mkdir -p %s", podmanImageDir)); err != nil {
+		return "", fmt.Errorf("创建远程下载目录失败: %w", err)
+	}
+
+	remotePath := filepath.Join(podmanImageDir, p.generateRemoteFileName(imageName, imageURL, architecture))
+	if p.isRemoteFileValid(remotePath) {
+		global.APP_LOG.Info("远程镜像文件已存在且完整，跳过下载",
+			zap.String("imageName", imageName),
+			zap.String("remotePath", remotePath))
+		return remotePath, nil
+	}
+
+	downloadURL := p.getDownloadURL(imageURL, useCDN)
+	if err := p.downloadFileToRemote(downloadURL, remotePath); err != nil {
+		p.removeRemoteFile(remotePath)
+		return "", fmt.Errorf("远程下载镜像失败: %w", err)
+	}
+
+	return remotePath, nil
+}
+
+// cleanupRemoteImage 清理远程镜像文件
+func (p *PodmanProvider) cleanupRemoteImage(imageName, imageURL, architecture string) error {
+	return p.removeRemoteFile(filepath.Join(podmanImageDir, p.generateRemoteFileName(imageName, imageURL, architecture)))
+}
+
+// generateRemoteFileName 生成远程文件名
+func (p *PodmanProvider) generateRemoteFileName(imageName, imageURL, architecture string) string {
+	combined := fmt.Sprintf("%s_%s_%s", imageName, imageURL, architecture)
+	md5Hash := fmt.Sprintf("%x", md5.Sum([]byte(combined)))
+
+	safeName := strings.ReplaceAll(imageName, "/", "_")
+	safeName = strings.ReplaceAll(safeName, ":", "_")
+	return fmt.Sprintf("%s_%s.tar", safeName, md5Hash[:8])
+}
+
+// getDownloadURL 确定下载URL
+func (p *PodmanProvider) getDownloadURL(originalURL string, useCDN bool) string {
+	if !useCDN {
+		return originalURL
+	}
+	if cdnURL := utils.GetCDNURL(p.sshClient, originalURL, "Podman"); cdnURL != "" {
+		return cdnURL
+	}
+	return originalURL
+}
+
+// isRemoteFileValid 检查远程文件是否存在且完整
+func (p *PodmanProvider) isRemoteFileValid(remotePath string) bool {
+	_, err := p.sshClient.Execute(fmt.Sprintf("test -f %s -a -s %s", remotePath, remotePath))
+	return err == nil
+}
+
+// removeRemoteFile 删除远程文件
+func (p *PodmanProvider) removeRemoteFile(remotePath string) error {
+	_, err := p.sshClient.Execute(fmt.Sprintf("rm -f %s", remotePath))
+	return err
+}
+
+// downloadFileToRemote 在远程服务器上下载文件，支持断点续传
+func (p *PodmanProvider) downloadFileToRemote(url, remotePath string) error {
+	tmpPath := remotePath + ".tmp"
+	curlCmd := fmt.Sprintf(
+		"curl -4 -L -C - --connect-timeout 30 --retry 5 --retry-delay 10 --retry-max-time 0 -o %s '%s'",
+		tmpPath, url,
+	)
+
+	output, err := p.sshClient.Execute(curlCmd)
+	if err != nil {
+		p.sshClient.Execute(fmt.Sprintf("rm -f %s", tmpPath))
+		global.APP_LOG.Error("远程下载失败",
+			zap.String("url", utils.TruncateString(url, 100)),
+			zap.String("output", utils.TruncateString(output, 500)),
+			zap.Error(err))
+		return fmt.Errorf("远程下载失败: %w", err)
+	}
+
+	if _, err := p.sshClient.Execute(fmt.Sprintf("mv %s %s", tmpPath, remotePath)); err != nil {
+		return fmt.Errorf("移动文件失败: %w", err)
+	}
+	return nil
+}
+
+// ensureSSHScriptsAvailable 确保SSH脚本文件在远程服务器上可用
+// 容器内的SSH配置与Docker完全一致，直接复用 oneclickvirt/docker 仓库的脚本
+func (p *PodmanProvider) ensureSSHScriptsAvailable(providerCountry string) error {
+	scriptsDir := "/usr/local/bin"
+	for _, script := range []string{"ssh_bash.sh", "ssh_sh.sh"} {
+		scriptPath := filepath.Join(scriptsDir, script)
+		if p.isRemoteFileValid(scriptPath) {
+			continue
+		}
+
+		downloadURL := p.getSSHScriptDownloadURL("https://raw.githubusercontent.com/oneclickvirt/docker/main/scripts/"+script, providerCountry)
+		if err := p.downloadFileToRemote(downloadURL, scriptPath); err != nil {
+			return fmt.Errorf("下载SSH脚本 %s 失败: %w", script, err)
+		}
+		if _, err := p.sshClient.Execute(fmt.Sprintf("chmod +x %s", scriptPath)); err != nil {
+			return fmt.Errorf("设置SSH脚本 %s 执行权限失败: %w", script, err)
+		}
+		p.sshClient.Execute(fmt.Sprintf("command -v dos2unix >/dev/null 2>&1 && dos2unix %s || true", scriptPath))
+
+		global.APP_LOG.Info("SSH脚本下载并设置完成",
+			zap.String("script", script),
+			zap.String("scriptPath", scriptPath))
+	}
+	return nil
+}
+
+// getSSHScriptDownloadURL 获取SSH脚本下载URL，中国地区优先使用可用的CDN
+func (p *PodmanProvider) getSSHScriptDownloadURL(originalURL, providerCountry string) string {
+	if providerCountry != "CN" && providerCountry != "cn" {
+		return originalURL
+	}
+	for _, endpoint := range utils.GetCDNEndpoints() {
+		cdnURL := endpoint + originalURL
+		testCmd := fmt.Sprintf("curl -s -I --max-time 5 '%s' | head -n 1 | grep -q '200'", cdnURL)
+		if _, err := p.sshClient.Execute(testCmd); err == nil {
+			return cdnURL
+		}
+	}
+	return originalURL
+}
@@ -0,0 +1,114 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// sshListImages 列出所有镜像
+func (p *PodmanProvider) sshListImages(ctx context.Context) ([]provider.Image, error) {
+	output, err := p.sshClient.ExecuteWithLogging(p.podman("images --format '{{.Repository}}|{{.Tag}}|{{.ID}}|{{.Size}}'"), "PODMAN_IMAGES")
+	if err != nil {
+		return nil, err
+	}
+
+	var images []provider.Image
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "|")
+		if len(fields) < 4 {
+			continue
+		}
+		images = append(images, provider.Image{
+			ID:   fields[2],
+			Name: fields[0],
+			Tag:  fields[1],
+			Size: fields[3],
+		})
+	}
+
+	global.APP_LOG.Info("获取Podman镜像列表成功", zap.Int("count", len(images)))
+	return images, nil
+}
+
+// sshPullImage 拉取镜像
+func (p *PodmanProvider) sshPullImage(ctx context.Context, image string) error {
+	output, err := p.sshClient.Execute(p.podman("pull %s", image))
+	if err != nil {
+		global.APP_LOG.Error("Podman镜像拉取失败",
+			zap.String("image", utils.TruncateString(image, 64)),
+			zap.String("output", utils.TruncateString(output, 500)),
+			zap.Error(err))
+		return fmt.Errorf("failed to pull image: %w", err)
+	}
+
+	global.APP_LOG.Info("Podman镜像拉取成功", zap.String("image", utils.TruncateString(image, 64)))
+	return nil
+}
+
+// sshDeleteImage 删除镜像
+func (p *PodmanProvider) sshDeleteImage(ctx context.Context, id string) error {
+	if _, err := p.sshClient.Execute(p.podman("rmi -f %s", id)); err != nil {
+		return fmt.Errorf("failed to delete image: %w", err)
+	}
+
+	global.APP_LOG.Info("Podman镜像删除成功", zap.String("id", utils.TruncateString(id, 32)))
+	return nil
+}
+
+// loadImage 从归档加载镜像并标记为目标名称
+// 通过shell重定向读取归档，rootless用户无需拥有归档文件的读取权限
+func (p *PodmanProvider) loadImage(imagePath, targetImageName string) error {
+	output, err := p.sshClient.Execute(p.podman("load < %s", imagePath))
+	if err != nil {
+		global.APP_LOG.Error("Podman镜像加载失败",
+			zap.String("imagePath", utils.TruncateString(imagePath, 64)),
+			zap.String("output", utils.TruncateString(output, 500)),
+			zap.Error(err))
+		return fmt.Errorf("failed to load image from %s: %w", imagePath, err)
+	}
+
+	// 输出格式: "Loaded image: <name>:<tag>" 或 "Loaded image(s): <name>:<tag>"
+	var loadedImageName string
+	for _, line := range strings.Split(output, "\n") {
+		if idx := strings.Index(line, "Loaded image"); idx >= 0 {
+			if colon := strings.Index(line[idx:], ":"); colon >= 0 {
+				loadedImageName = strings.TrimSpace(strings.Split(line[idx+colon+1:], ",")[0])
+				break
+			}
+		}
+	}
+	if loadedImageName == "" {
+		return fmt.Errorf("failed to parse loaded image name: %s", utils.TruncateString(output, 200))
+	}
+
+	if loadedImageName != targetImageName {
+		if output, err := p.sshClient.Execute(p.podman("tag %s %s", loadedImageName, targetImageName)); err != nil {
+			return fmt.Errorf("failed to tag image from %s to %s: %w, output: %s", loadedImageName, targetImageName, err, utils.TruncateString(output, 200))
+		}
+	}
+
+	global.APP_LOG.Info("Podman镜像加载成功",
+		zap.String("imagePath", utils.TruncateString(imagePath, 64)),
+		zap.String("targetImageName", utils.TruncateString(targetImageName, 64)))
+	return nil
+}
+
+// cleanupPodmanImage 清理镜像
+func (p *PodmanProvider) cleanupPodmanImage(imageName string) {
+	p.sshClient.Execute(p.podman("rmi -f %s", imageName))
+	p.sshClient.Execute(p.podman("image prune -f"))
+}
+
+// imageExists 检查镜像是否已存在
+// 未带仓库前缀的本地镜像会被podman记为 localhost/<name>，image exists 能够自动匹配
+func (p *PodmanProvider) imageExists(imageName string) bool {
+	_, err := p.sshClient.Execute(p.podman("image exists %s", imageName))
+	return err == nil
+}
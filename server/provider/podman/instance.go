@@ -0,0 +1,530 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	"oneclickvirt/service/pmacct"
+	"oneclickvirt/service/traffic"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// sshListInstances 列出所有实例
+func (p *PodmanProvider) sshListInstances(ctx context.Context) ([]provider.Instance, error) {
+	output, err := p.sshClient.ExecuteWithLogging(p.podman("ps -a --format '{{.Names}}|{{.State}}|{{.Image}}|{{.ID}}'"), "PODMAN_LIST")
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []provider.Instance
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "|")
+		if len(fields) < 4 || fields[0] == "" {
+			continue
+		}
+
+		instance := provider.Instance{
+			ID:     fields[3],
+			Name:   fields[0],
+			Status: parseContainerStatus(fields[1]),
+			Image:  fields[2],
+		}
+		if instance.Status == "running" {
+			p.enrichInstanceWithNetworkInfo(&instance)
+		}
+		instances = append(instances, instance)
+	}
+
+	global.APP_LOG.Info("获取Podman实例列表成功", zap.Int("count", len(instances)))
+	return instances, nil
+}
+
+// sshCreateInstanceWithProgress 创建实例并报告进度
+func (p *PodmanProvider) sshCreateInstanceWithProgress(ctx context.Context, config provider.InstanceConfig, progressCallback provider.ProgressCallback) error {
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+		global.APP_LOG.Info("Podman实例创建进度",
+			zap.String("instance", config.Name),
+			zap.Int("percentage", percentage),
+			zap.String("message", message))
+	}
+
+	updateProgress(10, "开始创建Podman实例...")
+
+	// 端口参数先校验，rootless模式下特权端口无法绑定，避免镜像处理完才失败
+	portArgs, err := p.buildPortArgs(config.Ports)
+	if err != nil {
+		return err
+	}
+
+	updateProgress(15, "确保SSH脚本可用...")
+	if err := p.ensureSSHScriptsAvailable(p.config.Country); err != nil {
+		return fmt.Errorf("确保SSH脚本可用失败: %w", err)
+	}
+
+	updateProgress(20, "处理Podman镜像...")
+	imageNameWithPrefix := "oneclickvirt_" + config.Image
+	if !p.imageExists(imageNameWithPrefix) {
+		if config.ImageURL == "" {
+			return fmt.Errorf("镜像 %s 不存在，且没有提供下载URL", imageNameWithPrefix)
+		}
+
+		updateProgress(30, "下载镜像到远程服务器...")
+		remotePath, err := p.downloadImageToRemote(config.ImageURL, config.Image, p.config.Country, p.config.Architecture, config.UseCDN)
+		if err != nil {
+			return fmt.Errorf("下载镜像失败: %w", err)
+		}
+
+		updateProgress(50, "加载镜像到Podman...")
+		if err := p.loadImage(remotePath, imageNameWithPrefix); err != nil {
+			global.APP_LOG.Warn("Podman镜像加载失败，尝试重新下载",
+				zap.String("image", utils.TruncateString(imageNameWithPrefix, 64)),
+				zap.Error(err))
+
+			// 清理损坏的镜像文件和镜像后重试一次
+			p.cleanupRemoteImage(config.Image, config.ImageURL, p.config.Architecture)
+			p.cleanupPodmanImage(imageNameWithPrefix)
+
+			updateProgress(40, "重新下载镜像...")
+			remotePath, err = p.downloadImageToRemote(config.ImageURL, config.Image, p.config.Country, p.config.Architecture, config.UseCDN)
+			if err != nil {
+				return fmt.Errorf("重新下载镜像失败: %w", err)
+			}
+
+			updateProgress(55, "重新加载镜像到Podman...")
+			if err := p.loadImage(remotePath, imageNameWithPrefix); err != nil {
+				return fmt.Errorf("重新加载镜像失败: %w", err)
+			}
+		}
+
+		updateProgress(60, "清理临时文件...")
+		p.cleanupRemoteImage(config.Image, config.ImageURL, p.config.Architecture)
+	} else {
+		updateProgress(60, "Podman镜像已存在，跳过下载...")
+	}
+
+	updateProgress(70, "清理同名残留容器...")
+	if output, err := p.sshClient.Execute(p.podman("rm -f --ignore %s", config.Name)); err != nil {
+		global.APP_LOG.Debug("清理同名容器失败（可忽略）",
+			zap.String("instance", utils.TruncateString(config.Name, 32)),
+			zap.String("output", utils.TruncateString(output, 200)),
+			zap.Error(err))
+	}
+
+	updateProgress(72, "构建Podman run命令...")
+	cmd := p.podman("run -d --name %s", config.Name)
+
+	networkType := p.config.NetworkType
+	if config.Metadata != nil {
+		if metaNetworkType, ok := config.Metadata["network_type"]; ok {
+			networkType = metaNetworkType
+		}
+	}
+	hasIPv6 := networkType == "nat_ipv4_ipv6" || networkType == "dedicated_ipv4_ipv6" || networkType == "ipv6_only"
+	if hasIPv6 && p.checkIPv6NetworkAvailable() {
+		cmd += " --network=ipv6_net"
+	} else if hasIPv6 {
+		global.APP_LOG.Warn("Provider配置启用IPv6但ipv6_net网络不可用",
+			zap.String("name", utils.TruncateString(config.Name, 32)),
+			zap.String("provider", p.config.Name),
+			zap.Bool("rootless", p.isRootless()))
+	}
+
+	// rootless模式下只有委派给用户的cgroup控制器可用，未委派时podman会直接报错
+	if config.CPU != "" {
+		if p.hasCgroupController("cpu") {
+			cmd += fmt.Sprintf(" --cpus=%s", config.CPU)
+		} else {
+			global.APP_LOG.Warn("cpu控制器不可用，忽略CPU限制",
+				zap.String("name", utils.TruncateString(config.Name, 32)))
+		}
+	}
+	if config.Memory != "" {
+		if p.hasCgroupController("memory") {
+			cmd += fmt.Sprintf(" --memory=%s", config.Memory)
+		} else {
+			global.APP_LOG.Warn("memory控制器不可用，忽略内存限制",
+				zap.String("name", utils.TruncateString(config.Name, 32)))
+		}
+	}
+
+	updateProgress(75, "配置存储限制...")
+	if config.Disk != "" && config.Disk != "0" {
+		supportsDiskLimit, storageDriver, err := p.checkStorageDriver()
+		if err != nil {
+			global.APP_LOG.Warn("检查存储驱动失败，跳过硬盘大小限制",
+				zap.String("name", utils.TruncateString(config.Name, 32)),
+				zap.Error(err))
+		} else if supportsDiskLimit {
+			cmd += fmt.Sprintf(" --storage-opt size=%s", diskSizeToGB(config.Disk))
+		} else {
+			global.APP_LOG.Warn("当前存储驱动不支持硬盘大小限制，忽略硬盘参数",
+				zap.String("name", utils.TruncateString(config.Name, 32)),
+				zap.String("storage_driver", storageDriver),
+				zap.String("disk", config.Disk))
+		}
+	}
+
+	updateProgress(80, "配置端口映射...")
+	for _, arg := range portArgs {
+		cmd += " " + arg
+	}
+
+	updateProgress(85, "配置LXCFS卷挂载...")
+	if lxcfsVolumes := p.checkLXCFS(); len(lxcfsVolumes) > 0 {
+		cmd += " " + strings.Join(lxcfsVolumes, " ")
+	}
+
+	updateProgress(90, "配置容器能力和环境变量...")
+	// Podman默认能力集比Docker少，补上sshd登录（AUDIT_WRITE）和ping（NET_RAW）所需的能力
+	cmd += " --cap-add=MKNOD --cap-add=AUDIT_WRITE --cap-add=NET_RAW"
+	for key, value := range config.Env {
+		cmd += fmt.Sprintf(" -e %s", shellQuote(key+"="+value))
+	}
+	cmd += " " + imageNameWithPrefix
+
+	updateProgress(95, "执行Podman创建命令...")
+	global.APP_LOG.Info("开始执行Podman创建命令",
+		zap.String("name", utils.TruncateString(config.Name, 32)),
+		zap.String("command", utils.TruncateString(cmd, 300)))
+
+	output, err := p.sshClient.Execute(cmd)
+	if err != nil {
+		global.APP_LOG.Error("Podman创建容器失败",
+			zap.String("name", utils.TruncateString(config.Name, 32)),
+			zap.String("output", utils.TruncateString(output, 500)),
+			zap.Error(err))
+		return fmt.Errorf("failed to create container: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	if !p.waitForStatus(config.Name, "running", 30*time.Second) {
+		global.APP_LOG.Warn("无法确认容器运行状态，继续执行后续操作",
+			zap.String("name", utils.TruncateString(config.Name, 32)))
+	}
+
+	updateProgress(97, "配置SSH密码...")
+	if err := p.configureInstanceSSHPassword(ctx, config.Name); err != nil {
+		global.APP_LOG.Warn("配置SSH密码失败", zap.Error(err))
+	}
+	if err := p.configureInstanceSSHKeys(config); err != nil {
+		global.APP_LOG.Warn("配置SSH公钥失败", zap.Error(err))
+	}
+	if err := p.runInstanceUserDataScript(config); err != nil {
+		global.APP_LOG.Warn("执行用户首次启动脚本失败", zap.Error(err))
+	}
+
+	updateProgress(97, "获取实例内网IP...")
+	p.syncPrivateIP(config.Name)
+
+	updateProgress(98, "初始化流量监控...")
+	if err := p.initializePmacctMonitoring(config.Name); err != nil {
+		global.APP_LOG.Warn("初始化流量监控失败", zap.Error(err))
+	}
+
+	updateProgress(100, "Podman实例创建完成")
+	global.APP_LOG.Info("Podman实例创建成功", zap.String("name", utils.TruncateString(config.Name, 32)))
+	return nil
+}
+
+// containerStatus 获取容器当前状态
+func (p *PodmanProvider) containerStatus(id string) (string, error) {
+	output, err := p.sshClient.Execute(p.podman("inspect %s --format '{{.State.Status}}'", id))
+	if err != nil {
+		return "", err
+	}
+	return parseContainerStatus(output), nil
+}
+
+// waitForStatus 等待容器进入指定状态
+func (p *PodmanProvider) waitForStatus(id, expected string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if status, err := p.containerStatus(id); err == nil && status == expected {
+			return true
+		}
+		time.Sleep(2 * time.Second)
+	}
+	return false
+}
+
+// sshStartInstance 启动实例
+func (p *PodmanProvider) sshStartInstance(ctx context.Context, id string) error {
+	status, err := p.containerStatus(id)
+	if err != nil {
+		return fmt.Errorf("failed to check container status: %w", err)
+	}
+	if status == "running" {
+		return nil
+	}
+
+	output, err := p.sshClient.Execute(p.podman("start %s", id))
+	if err != nil {
+		return fmt.Errorf("failed to start container: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	if !p.waitForStatus(id, "running", 30*time.Second) {
+		return fmt.Errorf("等待容器启动超时 (30秒)")
+	}
+
+	global.APP_LOG.Info("Podman实例启动成功", zap.String("id", utils.TruncateString(id, 32)))
+	return nil
+}
+
+// sshStopInstance 停止实例
+func (p *PodmanProvider) sshStopInstance(ctx context.Context, id string) error {
+	output, err := p.sshClient.Execute(p.podman("stop %s", id))
+	if err != nil {
+		return fmt.Errorf("failed to stop container: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	if !p.waitForStatus(id, "stopped", 10*time.Second) {
+		global.APP_LOG.Warn("Podman实例停止命令执行成功但状态验证超时",
+			zap.String("id", utils.TruncateString(id, 32)))
+	}
+	return nil
+}
+
+// sshRestartInstance 重启实例
+func (p *PodmanProvider) sshRestartInstance(ctx context.Context, id string) error {
+	output, err := p.sshClient.Execute(p.podman("restart %s", id))
+	if err != nil {
+		return fmt.Errorf("failed to restart container: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("Podman实例重启成功", zap.String("id", utils.TruncateString(id, 32)))
+	return nil
+}
+
+// sshDeleteInstance 删除实例
+// podman rm -f 会先停止再删除容器，-t 限制停止等待时间
+func (p *PodmanProvider) sshDeleteInstance(ctx context.Context, id string) error {
+	global.APP_LOG.Info("开始删除Podman实例", zap.String("id", utils.TruncateString(id, 32)))
+
+	maxRetries := 3
+	for retry := 1; retry <= maxRetries; retry++ {
+		output, err := p.sshClient.Execute(p.podman("rm -f --ignore -t 10 %s", id))
+		if err != nil {
+			global.APP_LOG.Warn("删除Podman容器失败",
+				zap.String("id", utils.TruncateString(id, 32)),
+				zap.Int("retry", retry),
+				zap.String("output", utils.TruncateString(output, 200)),
+				zap.Error(err))
+		}
+
+		// container exists 返回非零表示容器已不存在
+		if _, err := p.sshClient.Execute(p.podman("container exists %s", id)); err != nil {
+			p.cleanupSnapshotImages(id)
+			p.sshClient.Execute(p.podman("rmi -f --ignore %s", p.recreateImageName(id)))
+			global.APP_LOG.Info("Podman实例删除成功", zap.String("id", utils.TruncateString(id, 32)))
+			return nil
+		}
+
+		if retry < maxRetries {
+			timer := time.NewTimer(time.Duration(retry*2) * time.Second)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+
+	return fmt.Errorf("failed to delete container after %d attempts: %s", maxRetries, id)
+}
+
+// checkStorageDriver 检查存储驱动是否支持 --storage-opt size
+// rootless模式下podman不支持容器级存储配额；rootful下btrfs，以及启用了prjquota的xfs上的overlay支持
+func (p *PodmanProvider) checkStorageDriver() (bool, string, error) {
+	output, err := p.sshClient.Execute(p.podman("info --format '{{.Store.GraphDriverName}}|{{.Store.GraphRoot}}'"))
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get storage driver: %w", err)
+	}
+	parts := strings.SplitN(strings.TrimSpace(output), "|", 2)
+	storageDriver := parts[0]
+
+	if p.isRootless() {
+		return false, storageDriver, nil
+	}
+
+	switch storageDriver {
+	case "btrfs":
+		return true, storageDriver, nil
+	case "overlay":
+		if len(parts) < 2 {
+			return false, storageDriver, nil
+		}
+		mountOutput, err := p.sshClient.Execute(fmt.Sprintf("findmnt -n -o FSTYPE,OPTIONS --target %s", parts[1]))
+		if err != nil {
+			return false, storageDriver, nil
+		}
+		fields := strings.Fields(mountOutput)
+		supported := len(fields) >= 2 && fields[0] == "xfs" && strings.Contains(fields[1], "prjquota")
+		return supported, storageDriver, nil
+	default:
+		return false, storageDriver, nil
+	}
+}
+
+// diskSizeToGB 将磁盘大小转换为 --storage-opt size 使用的GB单位，向上取整，最小1G
+func diskSizeToGB(disk string) string {
+	lower := strings.ToLower(strings.TrimSpace(disk))
+	switch {
+	case strings.HasSuffix(lower, "gb"):
+		return strings.TrimSuffix(lower, "b")
+	case strings.HasSuffix(lower, "g"):
+		return lower
+	}
+
+	mb, err := strconv.Atoi(strings.TrimSuffix(lower, "mb"))
+	if err != nil {
+		return "1g"
+	}
+	gb := (mb + 1023) / 1024
+	if gb < 1 {
+		gb = 1
+	}
+	return fmt.Sprintf("%dg", gb)
+}
+
+// checkLXCFS 检查LXCFS是否可用，返回可挂载的卷参数
+func (p *PodmanProvider) checkLXCFS() []string {
+	statusOutput, err := p.sshClient.Execute("systemctl is-active lxcfs 2>/dev/null")
+	if err != nil || strings.TrimSpace(statusOutput) != "active" {
+		return nil
+	}
+
+	files := []string{"cpuinfo", "diskstats", "meminfo", "stat", "swaps", "uptime"}
+	var volumes []string
+	for _, file := range files {
+		hostPath := "/var/lib/lxcfs/proc/" + file
+		if _, err := p.sshClient.Execute(fmt.Sprintf("test -f %s", hostPath)); err == nil {
+			volumes = append(volumes, fmt.Sprintf("--volume %s:/proc/%s:rw", hostPath, file))
+		}
+	}
+
+	global.APP_LOG.Debug("LXCFS检测结果",
+		zap.String("provider", p.config.Name),
+		zap.Int("mount_count", len(volumes)))
+	return volumes
+}
+
+// configureInstanceSSHPassword 为新建容器配置SSH并设置随机密码，同步到数据库
+func (p *PodmanProvider) configureInstanceSSHPassword(ctx context.Context, instanceName string) error {
+	password := p.generateRandomPassword()
+	if err := p.sshSetInstancePassword(ctx, instanceName, password); err != nil {
+		return err
+	}
+
+	if err := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("name = ?", instanceName).
+		Update("password", password).Error; err != nil {
+		global.APP_LOG.Warn("更新实例密码到数据库失败",
+			zap.String("instanceName", instanceName),
+			zap.Error(err))
+	}
+	return nil
+}
+
+// configureInstanceSSHKeys 将用户选择的SSH公钥写入容器root的authorized_keys，并按需禁用SSH密码登录
+func (p *PodmanProvider) configureInstanceSSHKeys(config provider.InstanceConfig) error {
+	if !provider.NeedsSSHKeyInjection(config) {
+		return nil
+	}
+	cmd := p.podman("exec %s sh -c '%s'", config.Name, provider.BuildSSHKeyCommand(config.SSHKeys, config.DisablePasswordLogin))
+	if _, err := p.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("写入SSH公钥失败: %w", err)
+	}
+	global.APP_LOG.Info("容器SSH公钥配置完成",
+		zap.String("instanceName", config.Name),
+		zap.Int("keys", len(config.SSHKeys)),
+		zap.Bool("disablePasswordLogin", config.DisablePasswordLogin))
+	return nil
+}
+
+// runInstanceUserDataScript 容器没有cloud-init，在容器内后台执行用户提供的首次启动脚本
+func (p *PodmanProvider) runInstanceUserDataScript(config provider.InstanceConfig) error {
+	if provider.DetectUserDataType(config.UserData) != provider.UserDataTypeScript {
+		return nil
+	}
+	cmd := p.podman("exec %s sh -c '%s'", config.Name, provider.BuildUserDataCommand(config.UserData, false))
+	if _, err := p.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("执行首次启动脚本失败: %w", err)
+	}
+	global.APP_LOG.Info("容器首次启动脚本已执行",
+		zap.String("instanceName", config.Name),
+		zap.Int("size", len(config.UserData)))
+	return nil
+}
+
+// findInstanceRecord 查找当前Provider下的实例记录
+func (p *PodmanProvider) findInstanceRecord(instanceName string) (*providerModel.Provider, *providerModel.Instance, error) {
+	var providerRecord providerModel.Provider
+	if err := global.APP_DB.Where("name = ?", p.config.Name).First(&providerRecord).Error; err != nil {
+		return nil, nil, fmt.Errorf("查找provider记录失败: %w", err)
+	}
+	var instance providerModel.Instance
+	if err := global.APP_DB.Where("name = ? AND provider_id = ?", instanceName, providerRecord.ID).First(&instance).Error; err != nil {
+		return nil, nil, fmt.Errorf("查找实例记录失败: %w", err)
+	}
+	return &providerRecord, &instance, nil
+}
+
+// initializePmacctMonitoring 初始化流量监控
+func (p *PodmanProvider) initializePmacctMonitoring(instanceName string) error {
+	providerRecord, instance, err := p.findInstanceRecord(instanceName)
+	if err != nil {
+		return err
+	}
+	if !providerRecord.EnableTrafficControl {
+		return nil
+	}
+
+	pmacctService := pmacct.NewService()
+	if err := pmacctService.InitializePmacctForInstance(instance.ID); err != nil {
+		return fmt.Errorf("初始化 pmacct 监控失败: %w", err)
+	}
+
+	syncTrigger := traffic.NewSyncTriggerService()
+	syncTrigger.TriggerInstanceTrafficSync(instance.ID, "Podman容器创建完成后初始化")
+	return nil
+}
+
+// refreshPmacctMonitoring 容器重建后接口和PID都会变化，重新生成pmacct配置（保留历史流量数据）
+func (p *PodmanProvider) refreshPmacctMonitoring(instanceName string) {
+	providerRecord, instance, err := p.findInstanceRecord(instanceName)
+	if err != nil || !providerRecord.EnableTrafficControl {
+		return
+	}
+
+	pmacctService := pmacct.NewService()
+	if err := pmacctService.CleanupPmacctData(instance.ID); err != nil {
+		global.APP_LOG.Warn("清理旧的pmacct配置失败",
+			zap.String("instance", instanceName),
+			zap.Error(err))
+	}
+	global.APP_DB.Model(instance).Updates(map[string]interface{}{
+		"pmacct_interface_v4": "",
+		"pmacct_interface_v6": "",
+	})
+	if err := pmacctService.InitializePmacctForInstance(instance.ID); err != nil {
+		global.APP_LOG.Warn("容器重建后重新初始化pmacct失败",
+			zap.String("instance", instanceName),
+			zap.Error(err))
+	}
+}
+
+// shellQuote 用单引号包裹参数，避免环境变量值中的特殊字符被shell解析
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
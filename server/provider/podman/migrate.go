@@ -0,0 +1,95 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// backupArchiveDir 备份归档在宿主机上的临时目录
+const backupArchiveDir = "/tmp/oneclickvirt-backup"
+
+// ExportInstance 使用 podman export 导出容器文件系统，并通过SFTP写入w
+// 归档通过shell重定向由SSH用户写入，rootless模式下无需调整目录权限
+func (p *PodmanProvider) ExportInstance(ctx context.Context, instanceID string, w io.Writer, progressCallback provider.ProgressCallback) error {
+	if !p.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+	}
+
+	archivePath := fmt.Sprintf("%s/%s-export.tar", backupArchiveDir, instanceID)
+	defer p.sshClient.Execute(fmt.Sprintf("rm -f %s", archivePath))
+
+	updateProgress(10, "正在导出容器文件系统...")
+	exportCmd := fmt.Sprintf("mkdir -p %s && %s > %s", backupArchiveDir, p.podman("export %s", instanceID), archivePath)
+	if output, err := p.sshClient.ExecuteLongRunning(ctx, exportCmd, archivePath); err != nil {
+		return fmt.Errorf("failed to export container: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	updateProgress(50, "正在传输容器归档...")
+	written, err := p.sshClient.DownloadToWriter(archivePath, w)
+	if err != nil {
+		return fmt.Errorf("failed to transfer archive: %w", err)
+	}
+
+	updateProgress(100, "容器归档导出完成")
+	global.APP_LOG.Info("Podman容器导出成功",
+		zap.String("instance", utils.TruncateString(instanceID, 50)),
+		zap.Int64("bytes", written))
+	return nil
+}
+
+// ImportInstance Podman暂不支持跨节点迁移
+func (p *PodmanProvider) ImportInstance(ctx context.Context, instanceName, instanceType string, r io.Reader, progressCallback provider.ProgressCallback) error {
+	return fmt.Errorf("Podman provider不支持实例迁移")
+}
+
+// RestoreInstance 将 podman export 生成的归档解包覆盖到原容器的根文件系统
+// 归档之后新增的文件不会被删除，恢复后容器保持停止状态
+func (p *PodmanProvider) RestoreInstance(ctx context.Context, instanceID string, r io.Reader, progressCallback provider.ProgressCallback) error {
+	if !p.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+	}
+
+	archivePath := fmt.Sprintf("%s/%s-restore.tar", backupArchiveDir, instanceID)
+	defer p.sshClient.Execute(fmt.Sprintf("rm -f %s", archivePath))
+
+	updateProgress(10, "正在上传容器归档...")
+	if _, err := p.sshClient.Execute(fmt.Sprintf("mkdir -p %s", backupArchiveDir)); err != nil {
+		return fmt.Errorf("failed to create archive dir: %w", err)
+	}
+	written, err := p.sshClient.UploadFromReader(r, archivePath, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to upload archive: %w", err)
+	}
+
+	updateProgress(40, "正在停止容器...")
+	p.sshClient.Execute(p.podman("stop %s", instanceID))
+
+	updateProgress(50, "正在恢复容器文件系统...")
+	restoreCmd := fmt.Sprintf("%s < %s", p.podman("cp - %s:/", instanceID), archivePath)
+	if output, err := p.sshClient.ExecuteLongRunning(ctx, restoreCmd, archivePath); err != nil {
+		return fmt.Errorf("failed to restore container: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	updateProgress(100, "备份恢复完成")
+	global.APP_LOG.Info("Podman容器备份恢复成功",
+		zap.String("instance", utils.TruncateString(instanceID, 50)),
+		zap.Int64("bytes", written))
+	return nil
+}
@@ -0,0 +1,185 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// rootful模式下容器通过bridge网络连接，宿主机侧有对应的veth接口
+// rootless模式下容器使用 slirp4netns/pasta 用户态网络，宿主机上不存在veth，
+// 流量统计需要进入容器网络命名空间，在其默认路由接口（tap0/eth0等）上抓包
+
+// netnsPIDCommand 获取容器主进程PID的命令，用于 nsenter 进入容器网络命名空间
+func (p *PodmanProvider) netnsPIDCommand(name string) string {
+	return p.podman("inspect -f '{{.State.Pid}}' %s", name)
+}
+
+// GetNetnsPIDCommand rootless模式下返回获取容器PID的命令，pmacct需要据此进入容器网络命名空间抓包
+// rootful模式返回false，pmacct直接在宿主机veth上抓包
+func (p *PodmanProvider) GetNetnsPIDCommand(instanceName string) (string, bool) {
+	if !p.isRootless() {
+		return "", false
+	}
+	return p.netnsPIDCommand(instanceName), true
+}
+
+// GetVethInterfaceName 获取容器流量采集接口
+// rootful返回宿主机侧veth名称；rootless返回容器网络命名空间内的默认路由接口
+func (p *PodmanProvider) GetVethInterfaceName(ctx context.Context, instanceName string) (string, error) {
+	if !p.connected || p.sshClient == nil {
+		return "", fmt.Errorf("provider not connected")
+	}
+
+	var script string
+	if p.isRootless() {
+		script = fmt.Sprintf(`
+CONTAINER_PID=$(%s 2>/dev/null)
+if [ -z "$CONTAINER_PID" ] || [ "$CONTAINER_PID" = "0" ]; then
+    exit 1
+fi
+nsenter -t $CONTAINER_PID -n ip -o -4 route show default 2>/dev/null | awk '{for (i = 1; i < NF; i++) if ($i == "dev") {print $(i+1); exit}}'
+`, p.netnsPIDCommand(instanceName))
+	} else {
+		script = fmt.Sprintf(`
+CONTAINER_PID=$(%s 2>/dev/null)
+if [ -z "$CONTAINER_PID" ] || [ "$CONTAINER_PID" = "0" ]; then
+    exit 1
+fi
+HOST_VETH_IFINDEX=$(nsenter -t $CONTAINER_PID -n ip link show eth0 2>/dev/null | head -n1 | sed -n 's/.*@if\([0-9]\+\).*/\1/p')
+if [ -z "$HOST_VETH_IFINDEX" ]; then
+    exit 1
+fi
+ip -o link show 2>/dev/null | awk -v idx="$HOST_VETH_IFINDEX" -F': ' '$1 == idx {print $2}' | cut -d'@' -f1
+`, p.netnsPIDCommand(instanceName))
+	}
+
+	output, err := p.sshClient.Execute(script)
+	if err != nil {
+		return "", fmt.Errorf("failed to detect interface: %w", err)
+	}
+	iface := strings.TrimSpace(output)
+	if iface == "" {
+		return "", fmt.Errorf("no interface found for container %s", instanceName)
+	}
+	return iface, nil
+}
+
+// GetInstanceIPv4 获取容器内网IPv4地址
+func (p *PodmanProvider) GetInstanceIPv4(ctx context.Context, instanceName string) (string, error) {
+	if !p.connected || p.sshClient == nil {
+		return "", fmt.Errorf("provider not connected")
+	}
+	return p.getContainerPrivateIP(instanceName)
+}
+
+// getContainerPrivateIP 获取容器的内网IP地址
+// rootless网络的地址不会出现在inspect结果中，需要从容器网络命名空间内读取
+func (p *PodmanProvider) getContainerPrivateIP(containerName string) (string, error) {
+	var cmd string
+	if p.isRootless() {
+		cmd = fmt.Sprintf(`
+CONTAINER_PID=$(%s 2>/dev/null)
+if [ -z "$CONTAINER_PID" ] || [ "$CONTAINER_PID" = "0" ]; then
+    exit 1
+fi
+DEV=$(nsenter -t $CONTAINER_PID -n ip -o -4 route show default 2>/dev/null | awk '{for (i = 1; i < NF; i++) if ($i == "dev") {print $(i+1); exit}}')
+[ -n "$DEV" ] || exit 1
+nsenter -t $CONTAINER_PID -n ip -o -4 addr show dev "$DEV" scope global 2>/dev/null | awk '{print $4}' | cut -d/ -f1 | head -n1
+`, p.netnsPIDCommand(containerName))
+	} else {
+		cmd = p.podman("inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{$config.IPAddress}} {{end}}'", containerName)
+	}
+
+	output, err := p.sshClient.Execute(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to get container IP: %w", err)
+	}
+
+	// 连接多个网络时取第一个地址
+	fields := strings.Fields(output)
+	if len(fields) == 0 || fields[0] == "<no value>" {
+		return "", fmt.Errorf("container IP is empty")
+	}
+	return fields[0], nil
+}
+
+// enrichInstanceWithNetworkInfo 补充单个实例的网络信息
+func (p *PodmanProvider) enrichInstanceWithNetworkInfo(instance *provider.Instance) {
+	if privateIP, err := p.getContainerPrivateIP(instance.Name); err == nil {
+		instance.PrivateIP = privateIP
+		instance.IP = privateIP // 保持向后兼容
+	}
+
+	if iface, err := p.GetVethInterfaceName(context.Background(), instance.Name); err == nil {
+		if instance.Metadata == nil {
+			instance.Metadata = make(map[string]string)
+		}
+		instance.Metadata["network_interface"] = iface
+	}
+
+	// IPv6仅在rootful模式下通过ipv6_net网络提供
+	if p.isRootless() {
+		return
+	}
+	cmd := p.podman("inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{if eq $net \"ipv6_net\"}}{{$config.GlobalIPv6Address}}{{end}}{{end}}'", instance.Name)
+	if output, err := p.sshClient.Execute(cmd); err == nil {
+		ipv6Address := strings.TrimSpace(output)
+		if ipv6Address != "" && ipv6Address != "<no value>" {
+			instance.IPv6Address = ipv6Address
+		}
+	}
+}
+
+// checkIPv6NetworkAvailable 检查IPv6网络是否可用
+// 与Docker一致，需要预先创建 ipv6_net 网络并运行 ndpresponder；rootless网络无法分配公网IPv6
+func (p *PodmanProvider) checkIPv6NetworkAvailable() bool {
+	if !p.connected || p.sshClient == nil || p.isRootless() {
+		return false
+	}
+
+	if _, err := p.sshClient.Execute(p.podman("network exists ipv6_net")); err != nil {
+		global.APP_LOG.Debug("IPv6网络检查失败: ipv6_net网络不存在",
+			zap.String("provider", p.config.Name))
+		return false
+	}
+
+	output, err := p.sshClient.Execute(p.podman("inspect -f '{{.State.Status}}' ndpresponder 2>/dev/null"))
+	if err != nil || strings.TrimSpace(output) != "running" {
+		global.APP_LOG.Debug("IPv6网络检查: ndpresponder容器未运行",
+			zap.String("provider", p.config.Name))
+		return false
+	}
+
+	return true
+}
+
+// syncPrivateIP 将容器当前的内网IP同步到数据库
+func (p *PodmanProvider) syncPrivateIP(instanceName string) {
+	privateIP, err := p.getContainerPrivateIP(instanceName)
+	if err != nil || privateIP == "" {
+		global.APP_LOG.Warn("获取Podman实例内网IP失败，pmacct可能使用公网IP",
+			zap.String("instanceName", instanceName),
+			zap.Error(err))
+		return
+	}
+
+	var providerRecord providerModel.Provider
+	if err := global.APP_DB.Where("name = ?", p.config.Name).First(&providerRecord).Error; err != nil {
+		return
+	}
+	if err := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("name = ? AND provider_id = ?", instanceName, providerRecord.ID).
+		Update("private_ip", privateIP).Error; err == nil {
+		global.APP_LOG.Info("已更新Podman实例内网IP",
+			zap.String("instanceName", utils.TruncateString(instanceName, 32)),
+			zap.String("privateIP", privateIP))
+	}
+}
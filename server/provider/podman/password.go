@@ -0,0 +1,103 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// SetInstancePassword 设置实例密码
+func (p *PodmanProvider) SetInstancePassword(ctx context.Context, instanceID, password string) error {
+	if !p.connected {
+		return fmt.Errorf("provider not connected")
+	}
+
+	return p.sshSetInstancePassword(ctx, instanceID, password)
+}
+
+// ResetInstancePassword 重置实例密码
+func (p *PodmanProvider) ResetInstancePassword(ctx context.Context, instanceID string) (string, error) {
+	if !p.connected {
+		return "", fmt.Errorf("provider not connected")
+	}
+
+	newPassword := p.generateRandomPassword()
+	if err := p.sshSetInstancePassword(ctx, instanceID, newPassword); err != nil {
+		return "", err
+	}
+	return newPassword, nil
+}
+
+// sshSetInstancePassword 在容器内执行SSH配置脚本并设置root密码
+func (p *PodmanProvider) sshSetInstancePassword(ctx context.Context, instanceID, password string) error {
+	if err := p.ensureSSHScriptsAvailable(p.config.Country); err != nil {
+		return fmt.Errorf("确保SSH脚本可用失败: %w", err)
+	}
+
+	if !p.waitForStatus(instanceID, "running", 30*time.Second) {
+		return fmt.Errorf("容器 %s 未运行，无法设置密码", instanceID)
+	}
+
+	// 等待容器内基础命令可用
+	ready := false
+	for i := 0; i < 6; i++ {
+		output, err := p.sshClient.Execute(p.podman("exec %s sh -c 'command -v passwd >/dev/null 2>&1 && echo ssh_ready'", instanceID))
+		if err == nil && strings.Contains(output, "ssh_ready") {
+			ready = true
+			break
+		}
+		time.Sleep(5 * time.Second)
+	}
+	if !ready {
+		return fmt.Errorf("容器 %s 未准备就绪，无法设置密码", instanceID)
+	}
+
+	// 根据操作系统类型选择SSH脚本
+	osOutput, _ := p.sshClient.Execute(p.podman("exec %s sh -c \"grep -E '^ID=' /etc/os-release | cut -d= -f2 | tr -d '\\\"'\"", instanceID))
+	osType := strings.TrimSpace(osOutput)
+	scriptName, shellType := "ssh_bash.sh", "bash"
+	if osType == "alpine" || osType == "openwrt" {
+		scriptName, shellType = "ssh_sh.sh", "sh"
+	}
+
+	hostScriptPath := "/usr/local/bin/" + scriptName
+	if _, err := p.sshClient.Execute(fmt.Sprintf("test -f %s", hostScriptPath)); err != nil {
+		return fmt.Errorf("宿主机上SSH脚本不存在: %s", hostScriptPath)
+	}
+
+	// podman cp 由运行用户读取源文件，脚本位于全局可读的 /usr/local/bin，rootless模式同样可用
+	if output, err := p.sshClient.Execute(p.podman("cp %s %s:/%s", hostScriptPath, instanceID, scriptName)); err != nil {
+		return fmt.Errorf("复制SSH脚本到容器失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	p.sshClient.Execute(p.podman("exec %s chmod +x /%s", instanceID, scriptName))
+
+	scriptCmd := p.podman("exec %s %s -c 'interactionless=true %s /%s %s'", instanceID, shellType, shellType, scriptName, password)
+	if output, err := p.sshClient.Execute(scriptCmd); err != nil {
+		global.APP_LOG.Warn("执行SSH配置脚本失败，继续直接设置密码",
+			zap.String("instanceID", instanceID),
+			zap.String("output", utils.TruncateString(output, 500)),
+			zap.Error(err))
+	}
+
+	// 额外使用chpasswd命令确保密码设置
+	setPasswordCmd := p.podman("exec %s %s -c 'echo \"root:%s\" | chpasswd'", instanceID, shellType, password)
+	if _, err := p.sshClient.Execute(setPasswordCmd); err != nil {
+		return fmt.Errorf("使用chpasswd设置密码失败: %w", err)
+	}
+
+	global.APP_LOG.Info("容器SSH密码设置成功",
+		zap.String("instanceID", utils.TruncateString(instanceID, 32)),
+		zap.String("osType", osType))
+	return nil
+}
+
+// generateRandomPassword 生成随机密码（仅包含数字和大小写英文字母，长度不低于8位）
+func (p *PodmanProvider) generateRandomPassword() string {
+	return utils.GenerateInstancePassword()
+}
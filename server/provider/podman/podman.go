@@ -0,0 +1,524 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/provider/health"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// PodmanProvider 基于Podman的容器Provider，命令行与Docker基本兼容
+// 支持两种运行模式：
+//   - rootful：SSH用户（root）直接执行 podman，网络为宿主机上的bridge + veth
+//   - rootless：通过 runuser 以 PodmanRootlessUser 身份执行 podman，网络为 slirp4netns/pasta，
+//     容器没有宿主机侧的veth，流量统计需要进入容器网络命名空间采集
+type PodmanProvider struct {
+	provider.CapabilityCache
+
+	config        provider.NodeConfig
+	sshClient     *utils.SSHClient
+	connected     bool
+	healthChecker health.HealthChecker
+	version       string // Podman 版本
+
+	rootlessUser          string // rootless运行用户，为空表示rootful
+	rootlessUID           string // rootless运行用户的UID，用于XDG_RUNTIME_DIR
+	cgroupControllers     string // podman info 报告的可用cgroup控制器
+	unprivilegedPortStart int    // rootless模式下非特权端口起始值
+
+	mu sync.RWMutex // 保护并发访问
+}
+
+func NewPodmanProvider() provider.Provider {
+	return &PodmanProvider{}
+}
+
+func (p *PodmanProvider) GetType() string {
+	return "podman"
+}
+
+func (p *PodmanProvider) GetName() string {
+	return p.config.Name
+}
+
+func (p *PodmanProvider) GetSupportedInstanceTypes() []string {
+	return []string{"container"}
+}
+
+func (p *PodmanProvider) Connect(ctx context.Context, config provider.NodeConfig) error {
+	p.config = config
+	p.rootlessUser = strings.TrimSpace(config.PodmanRootlessUser)
+	global.APP_LOG.Info("Podman provider开始连接",
+		zap.String("host", utils.TruncateString(config.Host, 32)),
+		zap.Int("port", config.Port),
+		zap.Bool("rootless", p.rootlessUser != ""))
+
+	// 设置SSH超时配置
+	sshConnectTimeout := config.SSHConnectTimeout
+	sshExecuteTimeout := config.SSHExecuteTimeout
+	if sshConnectTimeout <= 0 {
+		sshConnectTimeout = 30 // 默认30秒
+	}
+	if sshExecuteTimeout <= 0 {
+		sshExecuteTimeout = 300 // 默认300秒
+	}
+
+	sshConfig := utils.SSHConfig{
+		Host:           config.Host,
+		Port:           config.Port,
+		Username:       config.Username,
+		Password:       config.Password,
+		PrivateKey:     config.PrivateKey,
+		ConnectTimeout: time.Duration(sshConnectTimeout) * time.Second,
+		ExecuteTimeout: time.Duration(sshExecuteTimeout) * time.Second,
+	}
+	client, err := utils.NewSSHClient(sshConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect via SSH: %w", err)
+	}
+
+	p.sshClient = client
+
+	// rootless模式需要在执行任何podman命令前准备好用户运行环境
+	if p.rootlessUser != "" {
+		if err := p.prepareRootlessEnvironment(); err != nil {
+			client.Close()
+			p.sshClient = nil
+			return fmt.Errorf("rootless Podman环境准备失败: %w", err)
+		}
+	}
+
+	p.connected = true
+
+	// 初始化健康检查器，使用Provider的SSH连接
+	healthConfig := health.HealthConfig{
+		Host:          config.Host,
+		Port:          config.Port,
+		Username:      config.Username,
+		Password:      config.Password,
+		PrivateKey:    config.PrivateKey,
+		APIEnabled:    false, // Podman Provider 不使用 API
+		SSHEnabled:    true,
+		Timeout:       30 * time.Second,
+		ServiceChecks: []string{"podman"},
+	}
+	zapLogger, _ := zap.NewProduction()
+	p.healthChecker = health.NewPodmanHealthCheckerWithSSH(healthConfig, zapLogger, client)
+
+	// 获取 Podman 版本
+	if err := p.getPodmanVersion(); err != nil {
+		global.APP_LOG.Warn("Podman 版本获取失败",
+			zap.Error(err))
+	}
+
+	// 记录可用的cgroup控制器，rootless模式下cgroup v1或未委派控制器时无法限制CPU/内存
+	if output, err := p.sshClient.Execute(p.podman("info --format '{{.Host.CgroupControllers}}'")); err == nil {
+		p.cgroupControllers = strings.Trim(strings.TrimSpace(output), "[]")
+	}
+
+	// 探测节点能力，失败时保留上一次结果
+	if _, err := p.ProbeCapabilities(ctx); err != nil {
+		global.APP_LOG.Warn("Podman节点能力探测失败", zap.Error(err))
+	}
+
+	global.APP_LOG.Info("Podman provider连接成功",
+		zap.String("host", utils.TruncateString(config.Host, 32)),
+		zap.Int("port", config.Port),
+		zap.String("version", p.version),
+		zap.String("rootlessUser", p.rootlessUser),
+		zap.String("cgroupControllers", p.cgroupControllers))
+
+	return nil
+}
+
+// prepareRootlessEnvironment 准备rootless运行环境：解析UID、开启linger保证用户运行目录常驻、检查subuid映射
+func (p *PodmanProvider) prepareRootlessEnvironment() error {
+	output, err := p.sshClient.Execute(fmt.Sprintf("id -u %s", p.rootlessUser))
+	if err != nil {
+		return fmt.Errorf("宿主机用户 %s 不存在: %w", p.rootlessUser, err)
+	}
+	uid := strings.TrimSpace(output)
+	if _, err := strconv.Atoi(uid); err != nil {
+		return fmt.Errorf("无法解析用户 %s 的UID: %s", p.rootlessUser, utils.TruncateString(uid, 50))
+	}
+	p.rootlessUID = uid
+
+	// 没有登录会话时 /run/user/UID 不存在，podman 无法工作；linger 让 systemd 为该用户常驻 user@UID.service
+	lingerCmd := fmt.Sprintf("loginctl enable-linger %s >/dev/null 2>&1; for i in $(seq 1 10); do [ -d /run/user/%s ] && exit 0; sleep 1; done; exit 1", p.rootlessUser, uid)
+	if _, err := p.sshClient.Execute(lingerCmd); err != nil {
+		return fmt.Errorf("用户 %s 的运行目录 /run/user/%s 不可用，请确认宿主机使用systemd并已安装systemd-logind", p.rootlessUser, uid)
+	}
+
+	if _, err := p.sshClient.Execute(fmt.Sprintf("grep -q '^%s:' /etc/subuid", p.rootlessUser)); err != nil {
+		global.APP_LOG.Warn("rootless用户未配置subuid映射，多用户镜像可能无法运行",
+			zap.String("user", p.rootlessUser))
+	}
+
+	p.unprivilegedPortStart = 1024
+	if output, err := p.sshClient.Execute("sysctl -n net.ipv4.ip_unprivileged_port_start"); err == nil {
+		if start, err := strconv.Atoi(strings.TrimSpace(output)); err == nil {
+			p.unprivilegedPortStart = start
+		}
+	}
+	return nil
+}
+
+// podmanBinary 返回当前运行模式下的podman命令前缀
+func (p *PodmanProvider) podmanBinary() string {
+	if p.rootlessUser == "" {
+		return "podman"
+	}
+	return fmt.Sprintf("runuser -u %s -- env XDG_RUNTIME_DIR=/run/user/%s DBUS_SESSION_BUS_ADDRESS=unix:path=/run/user/%s/bus podman",
+		p.rootlessUser, p.rootlessUID, p.rootlessUID)
+}
+
+// podman 构建完整的podman命令，管道两侧的podman调用需要分别使用该方法
+func (p *PodmanProvider) podman(format string, args ...interface{}) string {
+	return p.podmanBinary() + " " + fmt.Sprintf(format, args...)
+}
+
+// isRootless 是否以rootless模式运行
+func (p *PodmanProvider) isRootless() bool {
+	return p.rootlessUser != ""
+}
+
+// hasCgroupController 检查cgroup控制器是否可用，无法检测时按可用处理
+func (p *PodmanProvider) hasCgroupController(name string) bool {
+	if p.cgroupControllers == "" {
+		return true
+	}
+	for _, controller := range strings.Fields(p.cgroupControllers) {
+		if controller == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *PodmanProvider) Disconnect(ctx context.Context) error {
+	if p.sshClient != nil {
+		p.sshClient.Close()
+		p.connected = false
+	}
+	return nil
+}
+
+func (p *PodmanProvider) IsConnected() bool {
+	return p.connected && p.sshClient != nil && p.sshClient.IsHealthy()
+}
+
+// EnsureConnection 确保SSH连接可用，如果连接不健康则尝试重连
+func (p *PodmanProvider) EnsureConnection() error {
+	if p.sshClient == nil {
+		return fmt.Errorf("SSH client not initialized")
+	}
+
+	if !p.sshClient.IsHealthy() {
+		global.APP_LOG.Warn("Podman Provider SSH连接不健康，尝试重连",
+			zap.String("host", utils.TruncateString(p.config.Host, 32)),
+			zap.Int("port", p.config.Port))
+
+		if err := p.sshClient.Reconnect(); err != nil {
+			p.connected = false
+			return fmt.Errorf("failed to reconnect SSH: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (p *PodmanProvider) HealthCheck(ctx context.Context) (*health.HealthResult, error) {
+	if p.healthChecker == nil {
+		return nil, fmt.Errorf("health checker not initialized")
+	}
+	return p.healthChecker.CheckHealth(ctx)
+}
+
+func (p *PodmanProvider) GetHealthChecker() health.HealthChecker {
+	return p.healthChecker
+}
+
+func (p *PodmanProvider) GetVersion() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.version
+}
+
+// getPodmanVersion 获取 Podman 版本
+func (p *PodmanProvider) getPodmanVersion() error {
+	if p.sshClient == nil {
+		return fmt.Errorf("SSH client not connected")
+	}
+
+	output, err := p.sshClient.Execute("podman version --format '{{.Client.Version}}' 2>/dev/null || podman --version")
+	if err != nil {
+		p.version = "unknown"
+		return err
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		// "podman version X.Y.Z" 格式
+		if strings.HasPrefix(line, "podman version") {
+			parts := strings.Fields(line)
+			if len(parts) >= 3 {
+				p.version = parts[2]
+				return nil
+			}
+		} else {
+			p.version = line
+			return nil
+		}
+	}
+
+	p.version = "unknown"
+	return fmt.Errorf("无法解析版本信息")
+}
+
+// checkExecutionRule Podman provider只支持SSH
+func (p *PodmanProvider) checkExecutionRule() error {
+	if p.config.ExecutionRule == "api_only" {
+		return fmt.Errorf("Podman provider不支持API调用，无法使用api_only执行规则")
+	}
+	return nil
+}
+
+func (p *PodmanProvider) ListInstances(ctx context.Context) ([]provider.Instance, error) {
+	if !p.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	return p.sshListInstances(ctx)
+}
+
+func (p *PodmanProvider) CreateInstance(ctx context.Context, config provider.InstanceConfig) error {
+	return p.CreateInstanceWithProgress(ctx, config, nil)
+}
+
+func (p *PodmanProvider) CreateInstanceWithProgress(ctx context.Context, config provider.InstanceConfig, progressCallback provider.ProgressCallback) error {
+	if !p.connected {
+		return fmt.Errorf("not connected")
+	}
+	if err := p.checkExecutionRule(); err != nil {
+		return err
+	}
+
+	return p.sshCreateInstanceWithProgress(ctx, config, progressCallback)
+}
+
+func (p *PodmanProvider) StartInstance(ctx context.Context, id string) error {
+	if !p.connected {
+		return fmt.Errorf("not connected")
+	}
+	if err := p.checkExecutionRule(); err != nil {
+		return err
+	}
+
+	return p.sshStartInstance(ctx, id)
+}
+
+func (p *PodmanProvider) StopInstance(ctx context.Context, id string) error {
+	if !p.connected {
+		return fmt.Errorf("not connected")
+	}
+	if err := p.checkExecutionRule(); err != nil {
+		return err
+	}
+
+	return p.sshStopInstance(ctx, id)
+}
+
+func (p *PodmanProvider) RestartInstance(ctx context.Context, id string) error {
+	if !p.connected {
+		return fmt.Errorf("not connected")
+	}
+	if err := p.checkExecutionRule(); err != nil {
+		return err
+	}
+
+	return p.sshRestartInstance(ctx, id)
+}
+
+func (p *PodmanProvider) DeleteInstance(ctx context.Context, id string) error {
+	if err := p.checkExecutionRule(); err != nil {
+		return err
+	}
+
+	// 删除实例带重连机制，与Docker保持一致
+	maxReconnectAttempts := 3
+	for attempt := 1; attempt <= maxReconnectAttempts; attempt++ {
+		if !p.connected {
+			global.APP_LOG.Warn("Podman Provider未连接，尝试重连",
+				zap.String("id", utils.TruncateString(id, 32)),
+				zap.Int("attempt", attempt))
+
+			if err := p.Connect(ctx, p.config); err != nil {
+				if attempt == maxReconnectAttempts {
+					return fmt.Errorf("重连失败，已达最大重试次数: %w", err)
+				}
+				time.Sleep(time.Duration(attempt) * time.Second)
+				continue
+			}
+		}
+
+		err := p.sshDeleteInstance(ctx, id)
+		if err != nil {
+			if p.isConnectionError(err) {
+				global.APP_LOG.Warn("检测到连接错误，标记为未连接",
+					zap.String("id", utils.TruncateString(id, 32)),
+					zap.Int("attempt", attempt),
+					zap.Error(err))
+				p.connected = false
+
+				if attempt < maxReconnectAttempts {
+					time.Sleep(time.Duration(attempt) * time.Second)
+					continue
+				}
+			}
+			return err
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("删除实例失败，已达最大重连尝试次数")
+}
+
+// isConnectionError 判断是否是SSH连接相关的错误
+func (p *PodmanProvider) isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	errorStr := strings.ToLower(err.Error())
+	connectionErrors := []string{
+		"connection refused",
+		"connection lost",
+		"connection reset",
+		"network is unreachable",
+		"no route to host",
+		"connection timed out",
+		"broken pipe",
+		"eof",
+		"ssh: handshake failed",
+		"ssh: unable to authenticate",
+	}
+	for _, connErr := range connectionErrors {
+		if strings.Contains(errorStr, connErr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *PodmanProvider) ListImages(ctx context.Context) ([]provider.Image, error) {
+	if !p.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	return p.sshListImages(ctx)
+}
+
+func (p *PodmanProvider) PullImage(ctx context.Context, image string) error {
+	if !p.connected {
+		return fmt.Errorf("not connected")
+	}
+
+	return p.sshPullImage(ctx, image)
+}
+
+func (p *PodmanProvider) DeleteImage(ctx context.Context, id string) error {
+	if !p.connected {
+		return fmt.Errorf("not connected")
+	}
+
+	return p.sshDeleteImage(ctx, id)
+}
+
+func (p *PodmanProvider) GetInstance(ctx context.Context, id string) (*provider.Instance, error) {
+	if !p.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	output, err := p.sshClient.ExecuteWithLogging(p.podman("inspect %s --format '{{.Name}}|{{.State.Status}}|{{.ImageName}}|{{.Id}}|{{.Created}}'", id), "PODMAN_INSPECT")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance: %w", err)
+	}
+
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return nil, fmt.Errorf("instance not found")
+	}
+
+	fields := strings.Split(output, "|")
+	if len(fields) < 4 {
+		global.APP_LOG.Warn("Podman inspect输出格式不正确",
+			zap.String("id", utils.TruncateString(id, 32)),
+			zap.String("output", utils.TruncateString(output, 200)))
+		return nil, fmt.Errorf("invalid instance data: unexpected format")
+	}
+
+	instance := &provider.Instance{
+		ID:     fields[3],
+		Name:   strings.TrimPrefix(fields[0], "/"),
+		Status: parseContainerStatus(fields[1]),
+		Image:  fields[2],
+	}
+
+	if instance.Status == "running" {
+		p.enrichInstanceWithNetworkInfo(instance)
+	}
+
+	return instance, nil
+}
+
+// parseContainerStatus 将podman容器状态转换为统一状态
+// podman 的 created/configured/exited/stopped 都表示容器未运行
+func parseContainerStatus(state string) string {
+	state = strings.ToLower(strings.TrimSpace(state))
+	switch {
+	case strings.HasPrefix(state, "running"), strings.HasPrefix(state, "up"):
+		return "running"
+	case strings.HasPrefix(state, "paused"):
+		return "paused"
+	case strings.HasPrefix(state, "exited"), strings.HasPrefix(state, "stopped"),
+		strings.HasPrefix(state, "created"), strings.HasPrefix(state, "configured"):
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// ExecuteSSHCommand 执行SSH命令
+func (p *PodmanProvider) ExecuteSSHCommand(ctx context.Context, command string) (string, error) {
+	if !p.connected || p.sshClient == nil {
+		return "", fmt.Errorf("Podman provider not connected")
+	}
+
+	output, err := p.sshClient.Execute(command)
+	if err != nil {
+		global.APP_LOG.Error("SSH命令执行失败",
+			zap.String("command", utils.TruncateString(command, 200)),
+			zap.String("output", utils.TruncateString(output, 500)),
+			zap.Error(err))
+		return "", fmt.Errorf("SSH command execution failed: %w", err)
+	}
+
+	return output, nil
+}
+
+func init() {
+	provider.RegisterProvider("podman", NewPodmanProvider)
+}
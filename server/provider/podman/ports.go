@@ -0,0 +1,118 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// buildPortArgs 将端口配置转换为 podman run -p 参数，只绑定IPv4
+// 支持的格式: "0.0.0.0:主机端口:容器端口/协议"、"主机端口:容器端口/协议"、"端口"，协议为both时拆分为tcp和udp
+func (p *PodmanProvider) buildPortArgs(ports []string) ([]string, error) {
+	var args []string
+	for _, port := range ports {
+		mapping := strings.TrimPrefix(strings.TrimSpace(port), "0.0.0.0:")
+		if mapping == "" {
+			continue
+		}
+
+		protocol := ""
+		if idx := strings.Index(mapping, "/"); idx >= 0 {
+			protocol = mapping[idx+1:]
+			mapping = mapping[:idx]
+		}
+
+		hostPort, guestPort := mapping, mapping
+		if parts := strings.Split(mapping, ":"); len(parts) >= 2 {
+			hostPort, guestPort = parts[len(parts)-2], parts[len(parts)-1]
+		}
+
+		if err := p.checkHostPort(hostPort); err != nil {
+			return nil, err
+		}
+
+		switch protocol {
+		case "both":
+			args = append(args,
+				fmt.Sprintf("-p 0.0.0.0:%s:%s/tcp", hostPort, guestPort),
+				fmt.Sprintf("-p 0.0.0.0:%s:%s/udp", hostPort, guestPort))
+		case "":
+			args = append(args, fmt.Sprintf("-p 0.0.0.0:%s:%s", hostPort, guestPort))
+		default:
+			args = append(args, fmt.Sprintf("-p 0.0.0.0:%s:%s/%s", hostPort, guestPort, protocol))
+		}
+	}
+	return args, nil
+}
+
+// checkHostPort rootless模式下无法绑定低于 net.ipv4.ip_unprivileged_port_start 的端口
+func (p *PodmanProvider) checkHostPort(hostPort string) error {
+	port, err := strconv.Atoi(hostPort)
+	if err != nil {
+		// 端口范围（如 20000-20010）由podman自行校验
+		if strings.Contains(hostPort, "-") {
+			port, err = strconv.Atoi(strings.SplitN(hostPort, "-", 2)[0])
+		}
+		if err != nil {
+			return fmt.Errorf("invalid host port: %s", hostPort)
+		}
+	}
+	if p.isRootless() && port < p.unprivilegedPortStart {
+		return fmt.Errorf("rootless模式下无法绑定特权端口 %d（net.ipv4.ip_unprivileged_port_start=%d），请调整端口范围或使用rootful模式",
+			port, p.unprivilegedPortStart)
+	}
+	return nil
+}
+
+// recreateImageName 端口变更重建时保存容器文件系统的镜像
+func (p *PodmanProvider) recreateImageName(instanceName string) string {
+	return "oneclickvirt_recreate_" + strings.ToLower(instanceName) + ":latest"
+}
+
+// UpdatePortMappings 使用新的端口列表重建容器
+// podman不支持修改已有容器的端口映射，先将容器commit为镜像保留数据，再按原配置和新端口重建
+func (p *PodmanProvider) UpdatePortMappings(ctx context.Context, instanceName string, ports []string) error {
+	if !p.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if err := p.checkExecutionRule(); err != nil {
+		return err
+	}
+
+	portArgs, err := p.buildPortArgs(ports)
+	if err != nil {
+		return err
+	}
+
+	spec, err := p.inspectContainerSpec(instanceName)
+	if err != nil {
+		return err
+	}
+
+	image := p.recreateImageName(instanceName)
+	if output, err := p.sshClient.Execute(p.podman("commit %s %s", instanceName, image)); err != nil {
+		return fmt.Errorf("failed to commit container: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	runArgs := buildRunArgsFromSpec(spec, false)
+	if len(portArgs) > 0 {
+		runArgs += " " + strings.Join(portArgs, " ")
+	}
+	if err := p.recreateContainer(instanceName, image, runArgs); err != nil {
+		return fmt.Errorf("failed to recreate container with new ports: %w", err)
+	}
+
+	// 上一次重建留下的镜像已不再被引用
+	p.sshClient.Execute(p.podman("image prune -f"))
+
+	global.APP_LOG.Info("Podman容器端口映射更新成功",
+		zap.String("instance", utils.TruncateString(instanceName, 32)),
+		zap.Strings("ports", ports))
+	return nil
+}
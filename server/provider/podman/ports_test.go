@@ -0,0 +1,42 @@
+package podman
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildPortArgs_SplitsBothProtocol(t *testing.T) {
+	p := &PodmanProvider{}
+
+	args, err := p.buildPortArgs([]string{"0.0.0.0:20022:22/both", "30000-30010:30000-30010/udp", "8080:80"})
+	if err != nil {
+		t.Fatalf("构建端口参数失败: %v", err)
+	}
+
+	expected := []string{
+		"-p 0.0.0.0:20022:22/tcp",
+		"-p 0.0.0.0:20022:22/udp",
+		"-p 0.0.0.0:30000-30010:30000-30010/udp",
+		"-p 0.0.0.0:8080:80",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("端口参数不正确:\n got: %v\nwant: %v", args, expected)
+	}
+}
+
+func TestBuildPortArgs_RootlessRejectsPrivilegedPort(t *testing.T) {
+	p := &PodmanProvider{rootlessUser: "podman", rootlessUID: "1001", unprivilegedPortStart: 1024}
+
+	if _, err := p.buildPortArgs([]string{"0.0.0.0:80:80/tcp"}); err == nil {
+		t.Fatal("rootless模式下绑定特权端口应失败")
+	}
+	if _, err := p.buildPortArgs([]string{"0.0.0.0:20080:80/tcp"}); err != nil {
+		t.Fatalf("非特权端口不应失败: %v", err)
+	}
+
+	cmd := p.podman("ps -a")
+	if !strings.HasPrefix(cmd, "runuser -u podman -- env XDG_RUNTIME_DIR=/run/user/1001 ") || !strings.HasSuffix(cmd, " podman ps -a") {
+		t.Errorf("rootless命令包装不正确: %s", cmd)
+	}
+}
@@ -0,0 +1,70 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// ResizeInstance 使用 podman update 调整容器的CPU和内存限制
+func (p *PodmanProvider) ResizeInstance(ctx context.Context, instanceID string, spec provider.ResizeSpec) error {
+	if !p.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if err := p.checkExecutionRule(); err != nil {
+		return err
+	}
+
+	if spec.Disk > 0 {
+		// storage-opt 只能在创建时指定；不支持大小限制时创建时也未限制，直接跳过
+		supportsDiskLimit, storageDriver, err := p.checkStorageDriver()
+		if err != nil {
+			return fmt.Errorf("检查存储驱动失败: %w", err)
+		}
+		if supportsDiskLimit {
+			return fmt.Errorf("Podman不支持调整已有容器的磁盘大小")
+		}
+		global.APP_LOG.Warn("存储驱动不支持硬盘大小限制，跳过磁盘调整",
+			zap.String("instance", utils.TruncateString(instanceID, 32)),
+			zap.String("storage_driver", storageDriver))
+	}
+
+	if spec.Bandwidth > 0 {
+		global.APP_LOG.Warn("Podman容器不支持带宽限制，跳过带宽调整",
+			zap.String("instance", utils.TruncateString(instanceID, 32)),
+			zap.Int("bandwidth", spec.Bandwidth))
+	}
+
+	args := ""
+	if spec.CPU > 0 {
+		if !p.hasCgroupController("cpu") {
+			return fmt.Errorf("cpu控制器不可用，无法调整CPU限制")
+		}
+		args += fmt.Sprintf(" --cpus=%d", spec.CPU)
+	}
+	if spec.Memory > 0 {
+		if !p.hasCgroupController("memory") {
+			return fmt.Errorf("memory控制器不可用，无法调整内存限制")
+		}
+		args += fmt.Sprintf(" --memory=%dm --memory-swap=%dm", spec.Memory, spec.Memory*2)
+	}
+	if args == "" {
+		return nil
+	}
+
+	output, err := p.sshClient.Execute(p.podman("update%s %s", args, instanceID))
+	if err != nil {
+		return fmt.Errorf("failed to resize container: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("Podman实例配置调整成功",
+		zap.String("instance", utils.TruncateString(instanceID, 32)),
+		zap.Int("cpu", spec.CPU),
+		zap.Int64("memory", spec.Memory))
+	return nil
+}
@@ -0,0 +1,315 @@
+package podman
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// 与Docker相同，使用 podman commit 将容器文件系统保存为镜像
+// 快照镜像命名格式: oneclickvirt_snapshot_<实例名>:<快照名>
+
+// podmanContainerSpec podman inspect 中重建容器所需的字段（与Docker的inspect格式兼容）
+type podmanContainerSpec struct {
+	Config struct {
+		Hostname string   `json:"Hostname"`
+		Env      []string `json:"Env"`
+	} `json:"Config"`
+	HostConfig struct {
+		NanoCpus      int64             `json:"NanoCpus"`
+		Memory        int64             `json:"Memory"`
+		StorageOpt    map[string]string `json:"StorageOpt"`
+		Binds         []string          `json:"Binds"`
+		CapAdd        []string          `json:"CapAdd"`
+		Privileged    bool              `json:"Privileged"`
+		NetworkMode   string            `json:"NetworkMode"`
+		RestartPolicy struct {
+			Name string `json:"Name"`
+		} `json:"RestartPolicy"`
+		PortBindings map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"PortBindings"`
+	} `json:"HostConfig"`
+}
+
+// CreateSnapshot 创建实例快照
+func (p *PodmanProvider) CreateSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	if err := p.checkSnapshotPrerequisites(snapshotName); err != nil {
+		return err
+	}
+
+	image := p.snapshotImageName(instanceID, snapshotName)
+	output, err := p.sshClient.Execute(p.podman("commit -m 'oneclickvirt snapshot %s' %s %s", snapshotName, instanceID, image))
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("Podman实例快照创建成功",
+		zap.String("instance", utils.TruncateString(instanceID, 32)),
+		zap.String("image", image))
+	return nil
+}
+
+// ListSnapshots 列出实例快照
+func (p *PodmanProvider) ListSnapshots(ctx context.Context, instanceID string) ([]provider.Snapshot, error) {
+	if !p.connected {
+		return nil, fmt.Errorf("provider not connected")
+	}
+	if err := p.checkExecutionRule(); err != nil {
+		return nil, err
+	}
+
+	repository := p.snapshotRepository(instanceID)
+	output, err := p.sshClient.Execute(p.podman("images %s --format '{{.Tag}}|{{.CreatedAt}}|{{.Size}}'", repository))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var snapshots []provider.Snapshot
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(strings.TrimSpace(line), "|")
+		if len(parts) < 3 || parts[0] == "" || parts[0] == "<none>" {
+			continue
+		}
+
+		snapshot := provider.Snapshot{
+			Name:     parts[0],
+			Size:     parts[2],
+			Metadata: map[string]string{"image": repository + ":" + parts[0]},
+		}
+		// CreatedAt 格式: 2024-01-02 15:04:05.123456789 +0000 UTC
+		if created, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", parts[1]); err == nil {
+			snapshot.Created = created
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
+
+// RestoreSnapshot 使用快照镜像按原有配置重建容器
+func (p *PodmanProvider) RestoreSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	if err := p.checkSnapshotPrerequisites(snapshotName); err != nil {
+		return err
+	}
+
+	image := p.snapshotImageName(instanceID, snapshotName)
+	if !p.imageExists(image) {
+		return fmt.Errorf("snapshot %s not found", snapshotName)
+	}
+
+	spec, err := p.inspectContainerSpec(instanceID)
+	if err != nil {
+		return err
+	}
+	if err := p.recreateContainer(instanceID, image, buildRunArgsFromSpec(spec, true)); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+
+	global.APP_LOG.Info("Podman实例快照恢复成功",
+		zap.String("instance", utils.TruncateString(instanceID, 32)),
+		zap.String("image", image))
+	return nil
+}
+
+// DeleteSnapshot 删除实例快照
+func (p *PodmanProvider) DeleteSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	if err := p.checkSnapshotPrerequisites(snapshotName); err != nil {
+		return err
+	}
+
+	image := p.snapshotImageName(instanceID, snapshotName)
+	if !p.imageExists(image) {
+		// 快照镜像已不存在时视为删除成功
+		return nil
+	}
+	if output, err := p.sshClient.Execute(p.podman("rmi %s", image)); err != nil {
+		return fmt.Errorf("failed to delete snapshot: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("Podman实例快照删除成功",
+		zap.String("instance", utils.TruncateString(instanceID, 32)),
+		zap.String("image", image))
+	return nil
+}
+
+// cleanupSnapshotImages 删除实例的所有快照镜像，实例删除后快照随之清理
+func (p *PodmanProvider) cleanupSnapshotImages(instanceID string) {
+	cmd := fmt.Sprintf("%s | sort -u | xargs -r %s",
+		p.podman("images %s -q", p.snapshotRepository(instanceID)),
+		p.podman("rmi -f"))
+	if output, err := p.sshClient.Execute(cmd); err != nil {
+		global.APP_LOG.Warn("清理Podman实例快照镜像失败",
+			zap.String("instance", utils.TruncateString(instanceID, 32)),
+			zap.String("output", utils.TruncateString(output, 200)),
+			zap.Error(err))
+	}
+}
+
+// checkSnapshotPrerequisites 检查快照操作的前置条件
+func (p *PodmanProvider) checkSnapshotPrerequisites(snapshotName string) error {
+	if !p.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if err := p.checkExecutionRule(); err != nil {
+		return err
+	}
+	if !utils.IsValidSnapshotName(snapshotName) {
+		return fmt.Errorf("invalid snapshot name: %s", snapshotName)
+	}
+	return nil
+}
+
+// snapshotRepository 快照镜像仓库名（仓库名只允许小写）
+func (p *PodmanProvider) snapshotRepository(instanceID string) string {
+	return "oneclickvirt_snapshot_" + strings.ToLower(instanceID)
+}
+
+// snapshotImageName 快照镜像完整名称
+func (p *PodmanProvider) snapshotImageName(instanceID, snapshotName string) string {
+	return p.snapshotRepository(instanceID) + ":" + snapshotName
+}
+
+// inspectContainerSpec 读取重建容器所需的原有配置
+func (p *PodmanProvider) inspectContainerSpec(instanceID string) (*podmanContainerSpec, error) {
+	output, err := p.sshClient.Execute(p.podman("inspect %s --format '{{json .}}'", instanceID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	var spec podmanContainerSpec
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse container spec: %w", err)
+	}
+	return &spec, nil
+}
+
+// recreateContainer 使用指定镜像和参数重建同名容器，失败时回滚到原容器
+// 原容器先停止并改名保留，新容器创建成功后才删除，重建前后保持原有运行状态
+func (p *PodmanProvider) recreateContainer(instanceID, image, runArgs string) error {
+	wasRunning := false
+	if status, err := p.containerStatus(instanceID); err == nil {
+		wasRunning = status == "running"
+	}
+
+	backupName := instanceID + "_recreate_bak"
+	p.sshClient.Execute(p.podman("stop %s", instanceID))
+	if output, err := p.sshClient.Execute(p.podman("rename %s %s", instanceID, backupName)); err != nil {
+		if wasRunning {
+			p.sshClient.Execute(p.podman("start %s", instanceID))
+		}
+		return fmt.Errorf("failed to rename container: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	runCmd := p.podman("run -d --name %s%s %s", instanceID, runArgs, image)
+	global.APP_LOG.Info("重建Podman容器",
+		zap.String("instance", utils.TruncateString(instanceID, 32)),
+		zap.String("command", utils.TruncateString(runCmd, 300)))
+
+	if output, err := p.sshClient.Execute(runCmd); err != nil {
+		global.APP_LOG.Error("重建Podman容器失败，回滚原容器",
+			zap.String("instance", utils.TruncateString(instanceID, 32)),
+			zap.String("output", utils.TruncateString(output, 500)),
+			zap.Error(err))
+		p.sshClient.Execute(p.podman("rm -f --ignore %s", instanceID))
+		p.sshClient.Execute(p.podman("rename %s %s", backupName, instanceID))
+		if wasRunning {
+			p.sshClient.Execute(p.podman("start %s", instanceID))
+		}
+		return fmt.Errorf("%w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	if !wasRunning {
+		p.sshClient.Execute(p.podman("stop %s", instanceID))
+	}
+	if _, err := p.sshClient.Execute(p.podman("rm -f %s", backupName)); err != nil {
+		global.APP_LOG.Warn("删除重建前的备份容器失败",
+			zap.String("container", backupName),
+			zap.Error(err))
+	}
+
+	// 重建后内网IP和网络接口都可能变化
+	if wasRunning {
+		p.syncPrivateIP(instanceID)
+		p.refreshPmacctMonitoring(instanceID)
+	}
+	return nil
+}
+
+// buildRunArgsFromSpec 根据原容器配置构建podman run参数，withPorts为false时不保留原端口映射
+func buildRunArgsFromSpec(spec *podmanContainerSpec, withPorts bool) string {
+	var args strings.Builder
+	hc := spec.HostConfig
+
+	if spec.Config.Hostname != "" {
+		args.WriteString(fmt.Sprintf(" --hostname %s", spec.Config.Hostname))
+	}
+	// 默认网络（rootful bridge / rootless slirp4netns、pasta）无需显式指定，重建时按当前默认值创建
+	switch hc.NetworkMode {
+	case "", "default", "bridge", "slirp4netns", "pasta":
+	default:
+		args.WriteString(fmt.Sprintf(" --network=%s", hc.NetworkMode))
+	}
+	if hc.NanoCpus > 0 {
+		args.WriteString(fmt.Sprintf(" --cpus=%g", float64(hc.NanoCpus)/1e9))
+	}
+	if hc.Memory > 0 {
+		args.WriteString(fmt.Sprintf(" --memory=%db", hc.Memory))
+	}
+	if hc.RestartPolicy.Name != "" && hc.RestartPolicy.Name != "no" {
+		args.WriteString(fmt.Sprintf(" --restart=%s", hc.RestartPolicy.Name))
+	}
+	if hc.Privileged {
+		args.WriteString(" --privileged")
+	}
+
+	// map遍历顺序不固定，排序后生成稳定的命令
+	storageKeys := make([]string, 0, len(hc.StorageOpt))
+	for key := range hc.StorageOpt {
+		storageKeys = append(storageKeys, key)
+	}
+	sort.Strings(storageKeys)
+	for _, key := range storageKeys {
+		args.WriteString(fmt.Sprintf(" --storage-opt %s=%s", key, hc.StorageOpt[key]))
+	}
+
+	if withPorts {
+		containerPorts := make([]string, 0, len(hc.PortBindings))
+		for containerPort := range hc.PortBindings {
+			containerPorts = append(containerPorts, containerPort)
+		}
+		sort.Strings(containerPorts)
+		for _, containerPort := range containerPorts {
+			for _, binding := range hc.PortBindings[containerPort] {
+				hostIP := binding.HostIP
+				if hostIP == "" {
+					hostIP = "0.0.0.0"
+				} else if strings.Contains(hostIP, ":") {
+					hostIP = "[" + hostIP + "]"
+				}
+				args.WriteString(fmt.Sprintf(" -p %s:%s:%s", hostIP, binding.HostPort, containerPort))
+			}
+		}
+	}
+
+	for _, bind := range hc.Binds {
+		args.WriteString(fmt.Sprintf(" -v %s", bind))
+	}
+	for _, capability := range hc.CapAdd {
+		args.WriteString(fmt.Sprintf(" --cap-add=%s", capability))
+	}
+	for _, env := range spec.Config.Env {
+		args.WriteString(" -e " + shellQuote(env))
+	}
+
+	return args.String()
+}
@@ -256,6 +256,8 @@ func (i *IncusPortMapping) determineMappingMethod(req *portmapping.PortMappingRe
 		switch providerInfo.IPv6PortMappingMethod {
 		case "iptables":
 			return "incus-iptables-ipv6"
+		case "nftables":
+			return "incus-nftables-ipv6"
 		case "device_proxy":
 			return "incus-device-proxy-ipv6"
 		default:
@@ -267,6 +269,8 @@ func (i *IncusPortMapping) determineMappingMethod(req *portmapping.PortMappingRe
 	switch providerInfo.IPv4PortMappingMethod {
 	case "iptables":
 		return "incus-iptables"
+	case "nftables":
+		return "incus-nftables"
 	case "device_proxy":
 		return "incus-device-proxy"
 	default:
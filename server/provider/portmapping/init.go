@@ -49,6 +49,13 @@ func GetProviderDescription(providerType string) map[string]interface{} {
 			"protocols":   []string{"tcp", "udp"},
 			"features":    []string{"native", "high-performance", "container-specific"},
 		},
+		"podman": {
+			"name":        "Podman",
+			"description": "Podman原生端口映射，使用podman run -p参数进行端口绑定，变更时重建容器",
+			"methods":     []string{"port-binding"},
+			"protocols":   []string{"tcp", "udp"},
+			"features":    []string{"native", "rootless", "container-specific"},
+		},
 		"lxd": {
 			"name":        "LXD",
 			"description": "LXD原生端口映射，使用proxy device进行端口转发",
@@ -77,6 +84,13 @@ func GetProviderDescription(providerType string) map[string]interface{} {
 			"protocols":   []string{"tcp", "udp"},
 			"features":    []string{"universal", "flexible", "host-level", "high-performance"},
 		},
+		"nftables": {
+			"name":        "nftables",
+			"description": "nftables DNAT端口映射，使用独立表和映射集合，端口段只占一个元素，变更整批原子提交",
+			"methods":     []string{"dnat", "map", "interval"},
+			"protocols":   []string{"tcp", "udp"},
+			"features":    []string{"universal", "atomic", "host-level", "high-performance"},
+		},
 	}
 
 	if desc, exists := descriptions[providerType]; exists {
@@ -104,6 +118,15 @@ func GetProviderCapabilities(providerType string) map[string]bool {
 			"hot_reload":           false,
 			"persistent":           true,
 		},
+		"podman": {
+			"auto_port_allocation": true,
+			"custom_port_range":    false,
+			"ipv6_support":         false,
+			"protocol_tcp":         true,
+			"protocol_udp":         true,
+			"hot_reload":           false,
+			"persistent":           true,
+		},
 		"lxd": {
 			"auto_port_allocation": true,
 			"custom_port_range":    true,
@@ -140,6 +163,15 @@ func GetProviderCapabilities(providerType string) map[string]bool {
 			"hot_reload":           true,
 			"persistent":           false,
 		},
+		"nftables": {
+			"auto_port_allocation": true,
+			"custom_port_range":    true,
+			"ipv6_support":         true,
+			"protocol_tcp":         true,
+			"protocol_udp":         true,
+			"hot_reload":           true,
+			"persistent":           true,
+		},
 	}
 
 	if caps, exists := capabilities[providerType]; exists {
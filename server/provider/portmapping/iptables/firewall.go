@@ -0,0 +1,178 @@
+package iptables
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/provider"
+	providerService "oneclickvirt/service/provider"
+
+	"go.uber.org/zap"
+)
+
+// FirewallChain 所有实例防火墙链的入口链，位于FORWARD链首位，先于端口映射的ACCEPT规则匹配
+const FirewallChain = "OCV-FIREWALL"
+
+// SaveIPv6RulesCommand 持久化当前ip6tables规则的命令
+const SaveIPv6RulesCommand = "ip6tables-save > /etc/iptables/rules.v6 2>/dev/null || true"
+
+// InstanceFirewallChain 实例专属防火墙链名称
+func InstanceFirewallChain(instanceID uint) string {
+	return fmt.Sprintf("OCV-FW-%d", instanceID)
+}
+
+// FirewallApplyCommands 生成应用实例防火墙规则的命令，可重复执行
+// 规则按目标地址匹配转发到实例的流量，已建立的连接直接放行；桥接网络（如Proxmox公网IP）需要宿主机开启bridge-nf-call-iptables。
+// LXD/Incus的device_proxy端口映射由宿主机进程代理，流量不经过FORWARD链，不受这些规则约束，调用方需拒绝此类节点。
+// 新规则先写入临时链并插入跳转，再移除旧链并将临时链改名，替换过程中实例不会出现无规则的窗口。
+func FirewallApplyCommands(instance *provider.Instance, rules []provider.FirewallRule) []string {
+	if len(rules) == 0 {
+		return FirewallRemoveCommands(instance.ID)
+	}
+
+	chain := InstanceFirewallChain(instance.ID)
+	pending := chain + "-NEW"
+	var commands []string
+	for _, family := range []struct {
+		version   string
+		binary    string
+		addresses []string
+	}{
+		{provider.FirewallIPv4, "iptables", firewallAddresses(instance.PrivateIP)},
+		{provider.FirewallIPv6, "ip6tables", firewallAddresses(instance.IPv6Address, instance.PublicIPv6)},
+	} {
+		if len(family.addresses) == 0 {
+			commands = append(commands, removeChainCommands(family.binary, chain)...)
+			continue
+		}
+		commands = append(commands,
+			fmt.Sprintf("%s -N %s 2>/dev/null || true", family.binary, FirewallChain),
+			fmt.Sprintf("%s -C FORWARD -j %s 2>/dev/null || %s -I FORWARD 1 -j %s", family.binary, FirewallChain, family.binary, FirewallChain),
+		)
+		// 清理上次中断遗留的临时链
+		commands = append(commands, removeChainCommands(family.binary, pending)...)
+		commands = append(commands,
+			fmt.Sprintf("%s -N %s", family.binary, pending),
+			fmt.Sprintf("%s -A %s -m conntrack --ctstate ESTABLISHED,RELATED -j RETURN", family.binary, pending),
+		)
+		for _, rule := range rules {
+			if rule.IPVersion != family.version {
+				continue
+			}
+			commands = append(commands, firewallRuleCommand(family.binary, pending, rule))
+		}
+		// 新链的跳转插在最前面先于旧链匹配，随后移除旧链并改名，链名保持稳定
+		for _, addr := range family.addresses {
+			commands = append(commands, fmt.Sprintf("%s -I %s 1 -d %s -j %s", family.binary, FirewallChain, addr, pending))
+		}
+		commands = append(commands, removeChainCommands(family.binary, chain)...)
+		commands = append(commands, fmt.Sprintf("%s -E %s %s", family.binary, pending, chain))
+	}
+	return commands
+}
+
+// FirewallRemoveCommands 生成移除实例防火墙链及其跳转规则的命令，链不存在时不报错
+func FirewallRemoveCommands(instanceID uint) []string {
+	chain := InstanceFirewallChain(instanceID)
+	var commands []string
+	for _, binary := range []string{"iptables", "ip6tables"} {
+		commands = append(commands, removeChainCommands(binary, chain)...)
+	}
+	return commands
+}
+
+// removeChainCommands 移除入口链中指向chain的跳转并删除chain
+func removeChainCommands(binary, chain string) []string {
+	return []string{
+		fmt.Sprintf("%s -S %s 2>/dev/null | grep -- '-j %s$' | sed 's/^-A/-D/' | xargs -r -L1 %s", binary, FirewallChain, chain, binary),
+		fmt.Sprintf("%s -F %s 2>/dev/null || true", binary, chain),
+		fmt.Sprintf("%s -X %s 2>/dev/null || true", binary, chain),
+	}
+}
+
+// firewallRuleCommand 生成单条防火墙规则命令，allow返回FORWARD链继续处理，deny直接丢弃
+func firewallRuleCommand(binary, chain string, rule provider.FirewallRule) string {
+	parts := []string{binary, "-A", chain}
+	switch rule.Protocol {
+	case provider.FirewallProtocolTCP, provider.FirewallProtocolUDP:
+		parts = append(parts, "-p", rule.Protocol)
+	case provider.FirewallProtocolICMP:
+		if binary == "ip6tables" {
+			parts = append(parts, "-p", "icmpv6")
+		} else {
+			parts = append(parts, "-p", "icmp")
+		}
+	}
+	if rule.SourceCIDR != "" {
+		parts = append(parts, "-s", rule.SourceCIDR)
+	}
+	if rule.PortStart > 0 && (rule.Protocol == provider.FirewallProtocolTCP || rule.Protocol == provider.FirewallProtocolUDP) {
+		if rule.PortEnd > rule.PortStart {
+			parts = append(parts, "--dport", fmt.Sprintf("%d:%d", rule.PortStart, rule.PortEnd))
+		} else {
+			parts = append(parts, "--dport", fmt.Sprintf("%d", rule.PortStart))
+		}
+	}
+	if rule.Action == provider.FirewallActionAllow {
+		parts = append(parts, "-j", "RETURN")
+	} else {
+		parts = append(parts, "-j", "DROP")
+	}
+	return strings.Join(parts, " ")
+}
+
+// firewallAddresses 去掉前缀长度并去重，返回实例在宿主机上可匹配的地址
+func firewallAddresses(addresses ...string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, addr := range addresses {
+		addr = strings.TrimSpace(strings.SplitN(addr, "/", 2)[0])
+		if addr == "" || seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		result = append(result, addr)
+	}
+	return result
+}
+
+// ExecuteHostCommands 在实例所在宿主机上依次执行命令并持久化规则
+// 优先复用Provider的SSH连接，未连接时回退到临时SSH连接；集群Provider中命令经集群内部SSH在实例所在节点执行
+func ExecuteHostCommands(ctx context.Context, instance *provider.Instance, providerInfo *provider.Provider, commands []string) error {
+	allCommands := append([]string{}, commands...)
+	allCommands = nodeCommands(instance, providerInfo, append(allCommands, SaveRulesCommand, SaveIPv6RulesCommand))
+
+	var execute func(cmd string) error
+	providerInstance, exists := providerService.GetProviderService().GetProviderByID(providerInfo.ID)
+	if exists && providerInstance.IsConnected() {
+		execute = func(cmd string) error {
+			_, err := providerInstance.ExecuteSSHCommand(ctx, cmd)
+			return err
+		}
+	} else {
+		global.APP_LOG.Warn("Provider未连接，使用临时SSH连接",
+			zap.Uint("providerId", providerInfo.ID),
+			zap.String("providerName", providerInfo.Name))
+		sshClient, err := (&IptablesPortMapping{}).createSSHClient(providerInfo)
+		if err != nil {
+			return fmt.Errorf("failed to create SSH client: %v", err)
+		}
+		defer sshClient.Close()
+		execute = func(cmd string) error {
+			_, err := sshClient.Execute(cmd)
+			return err
+		}
+	}
+
+	for _, cmd := range allCommands {
+		if err := execute(cmd); err != nil {
+			global.APP_LOG.Error("Failed to execute iptables command",
+				zap.String("command", cmd),
+				zap.Error(err))
+			return fmt.Errorf("failed to execute iptables command '%s': %v", cmd, err)
+		}
+	}
+	return nil
+}
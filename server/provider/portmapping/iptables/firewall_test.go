@@ -0,0 +1,51 @@
+package iptables
+
+import (
+	"strings"
+	"testing"
+
+	"oneclickvirt/model/provider"
+)
+
+func TestFirewallApplyCommands(t *testing.T) {
+	instance := &provider.Instance{PrivateIP: "10.0.0.5", IPv6Address: "2001:db8::5/64"}
+	instance.ID = 7
+	rules := []provider.FirewallRule{
+		{Action: "allow", Protocol: "tcp", PortStart: 22, PortEnd: 22, SourceCIDR: "203.0.113.0/24", IPVersion: "ipv4"},
+		{Action: "deny", Protocol: "udp", PortStart: 1000, PortEnd: 2000, IPVersion: "ipv4"},
+		{Action: "deny", Protocol: "icmp", IPVersion: "ipv6"},
+		{Action: "deny", Protocol: "all", IPVersion: "ipv4"},
+	}
+
+	script := strings.Join(FirewallApplyCommands(instance, rules), "\n")
+	for _, want := range []string{
+		"iptables -C FORWARD -j OCV-FIREWALL 2>/dev/null || iptables -I FORWARD 1 -j OCV-FIREWALL",
+		"iptables -A OCV-FW-7-NEW -p tcp -s 203.0.113.0/24 --dport 22 -j RETURN",
+		"iptables -A OCV-FW-7-NEW -p udp --dport 1000:2000 -j DROP",
+		"iptables -A OCV-FW-7-NEW -j DROP",
+		"iptables -I OCV-FIREWALL 1 -d 10.0.0.5 -j OCV-FW-7-NEW",
+		"iptables -E OCV-FW-7-NEW OCV-FW-7",
+		"ip6tables -A OCV-FW-7-NEW -p icmpv6 -j DROP",
+		"ip6tables -I OCV-FIREWALL 1 -d 2001:db8::5 -j OCV-FW-7-NEW",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("missing command %q in:\n%s", want, script)
+		}
+	}
+	// 新链的跳转生效后才移除旧链
+	jump := strings.Index(script, "iptables -I OCV-FIREWALL 1 -d 10.0.0.5 -j OCV-FW-7-NEW")
+	removeOld := strings.Index(script, "iptables -S OCV-FIREWALL 2>/dev/null | grep -- '-j OCV-FW-7$'")
+	if removeOld < jump {
+		t.Errorf("old chain removed before the new chain is linked:\n%s", script)
+	}
+	if strings.Contains(script, "ip6tables -A OCV-FW-7-NEW -p udp") {
+		t.Errorf("ipv4 rule leaked into ip6tables chain:\n%s", script)
+	}
+
+	// 清空规则时只移除链
+	for _, cmd := range FirewallApplyCommands(instance, nil) {
+		if strings.Contains(cmd, " -A ") || strings.Contains(cmd, " -N ") {
+			t.Errorf("unexpected command when rules are empty: %s", cmd)
+		}
+	}
+}
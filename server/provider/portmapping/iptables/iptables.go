@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"oneclickvirt/global"
 	"oneclickvirt/model/provider"
+	providerPkg "oneclickvirt/provider"
 	"oneclickvirt/provider/portmapping"
 	providerService "oneclickvirt/service/provider"
 	"oneclickvirt/utils"
@@ -285,6 +286,57 @@ func (i *IptablesPortMapping) getPublicIP(providerInfo *provider.Provider) strin
 	return providerInfo.Endpoint
 }
 
+// SaveRulesCommand 持久化当前iptables规则的命令
+const SaveRulesCommand = "iptables-save > /etc/iptables/rules.v4 2>/dev/null || true"
+
+// AddRuleCommands 生成添加端口转发所需的DNAT、FORWARD和MASQUERADE规则命令，protocol为both时同时生成TCP和UDP规则
+func AddRuleCommands(protocol string, hostPort, guestPort int, instanceIP string) []string {
+	return ruleCommands("-A", protocol, hostPort, guestPort, instanceIP)
+}
+
+// DeleteRuleCommands 生成删除端口转发规则的命令，与AddRuleCommands一一对应
+func DeleteRuleCommands(protocol string, hostPort, guestPort int, instanceIP string) []string {
+	return ruleCommands("-D", protocol, hostPort, guestPort, instanceIP)
+}
+
+// ruleCommands 按操作生成iptables规则命令
+func ruleCommands(action, protocol string, hostPort, guestPort int, instanceIP string) []string {
+	protocols := []string{protocol}
+	if protocol == "both" {
+		protocols = []string{"tcp", "udp"}
+	}
+
+	var commands []string
+	for _, proto := range protocols {
+		// PREROUTING DNAT规则 - 将外部端口转发到内部实例
+		dnatRule := fmt.Sprintf("iptables -t nat %s PREROUTING -p %s --dport %d -j DNAT --to-destination %s:%d",
+			action, proto, hostPort, instanceIP, guestPort)
+
+		// FORWARD规则 - 允许转发到实例
+		forwardRule := fmt.Sprintf("iptables %s FORWARD -p %s -d %s --dport %d -j ACCEPT",
+			action, proto, instanceIP, guestPort)
+
+		// POSTROUTING MASQUERADE规则 - 对来自实例的响应进行SNAT
+		masqueradeRule := fmt.Sprintf("iptables -t nat %s POSTROUTING -p %s -s %s --sport %d -j MASQUERADE",
+			action, proto, instanceIP, guestPort)
+
+		commands = append(commands, dnatRule, forwardRule, masqueradeRule)
+	}
+	return commands
+}
+
+// nodeCommands 集群Provider中实例位于连接节点以外的节点时，规则需要经集群内部SSH在该节点上执行
+func nodeCommands(instance *provider.Instance, providerInfo *provider.Provider, commands []string) []string {
+	if instance.Node == "" || instance.Node == providerInfo.HostName {
+		return commands
+	}
+	wrapped := make([]string, len(commands))
+	for idx, cmd := range commands {
+		wrapped[idx] = providerPkg.ClusterNodeCommand(instance.Node, cmd)
+	}
+	return wrapped
+}
+
 // createIptablesRule 创建iptables规则
 func (i *IptablesPortMapping) createIptablesRule(ctx context.Context, instance *provider.Instance, hostPort, guestPort int, protocol string, providerInfo *provider.Provider) error {
 	global.APP_LOG.Info("Creating iptables rule",
@@ -299,29 +351,7 @@ func (i *IptablesPortMapping) createIptablesRule(ctx context.Context, instance *
 		return fmt.Errorf("instance private IP address not found for %s", instance.Name)
 	}
 
-	// 如果协议是both，需要同时创建TCP和UDP规则
-	protocols := []string{protocol}
-	if protocol == "both" {
-		protocols = []string{"tcp", "udp"}
-	}
-
-	var allCommands []string
-
-	for _, proto := range protocols {
-		// 创建PREROUTING DNAT规则 - 将外部端口转发到内部实例
-		dnatRule := fmt.Sprintf("iptables -t nat -A PREROUTING -p %s --dport %d -j DNAT --to-destination %s:%d",
-			proto, hostPort, instanceIP, guestPort)
-
-		// 创建FORWARD规则 - 允许转发到实例
-		forwardRule := fmt.Sprintf("iptables -A FORWARD -p %s -d %s --dport %d -j ACCEPT",
-			proto, instanceIP, guestPort)
-
-		// 创建POSTROUTING MASQUERADE规则 - 对来自实例的响应进行SNAT
-		masqueradeRule := fmt.Sprintf("iptables -t nat -A POSTROUTING -p %s -s %s --sport %d -j MASQUERADE",
-			proto, instanceIP, guestPort)
-
-		allCommands = append(allCommands, dnatRule, forwardRule, masqueradeRule)
-	}
+	allCommands := nodeCommands(instance, providerInfo, AddRuleCommands(protocol, hostPort, guestPort, instanceIP))
 
 	global.APP_LOG.Info("Executing iptables commands",
 		zap.String("protocol", protocol),
@@ -356,7 +386,7 @@ func (i *IptablesPortMapping) createIptablesRule(ctx context.Context, instance *
 	}
 
 	// 保存iptables规则
-	saveCmd := "iptables-save > /etc/iptables/rules.v4 2>/dev/null || true"
+	saveCmd := nodeCommands(instance, providerInfo, []string{SaveRulesCommand})[0]
 	_, err := providerInstance.ExecuteSSHCommand(ctx, saveCmd)
 	if err != nil {
 		global.APP_LOG.Warn("Failed to save iptables rules", zap.Error(err))
@@ -395,7 +425,7 @@ func (i *IptablesPortMapping) createIptablesRuleWithTempSSH(ctx context.Context,
 	}
 
 	// 保存iptables规则
-	saveCmd := "iptables-save > /etc/iptables/rules.v4 2>/dev/null || true"
+	saveCmd := nodeCommands(instance, providerInfo, []string{SaveRulesCommand})[0]
 	_, err = sshClient.Execute(saveCmd)
 	if err != nil {
 		global.APP_LOG.Warn("Failed to save iptables rules", zap.Error(err))
@@ -423,33 +453,8 @@ func (i *IptablesPortMapping) removeIptablesRule(ctx context.Context, instance *
 		return fmt.Errorf("instance private IP address not found for %s", instance.Name)
 	}
 
-	// 如果协议是both，需要同时删除TCP和UDP规则
-	protocols := []string{protocol}
-	if protocol == "both" {
-		protocols = []string{"tcp", "udp"}
-	}
-
-	var allCommands []string
-
-	for _, proto := range protocols {
-		// 删除PREROUTING DNAT规则
-		dnatRule := fmt.Sprintf("iptables -t nat -D PREROUTING -p %s --dport %d -j DNAT --to-destination %s:%d",
-			proto, hostPort, instanceIP, guestPort)
-
-		// 删除FORWARD规则
-		forwardRule := fmt.Sprintf("iptables -D FORWARD -p %s -d %s --dport %d -j ACCEPT",
-			proto, instanceIP, guestPort)
-
-		// 删除POSTROUTING MASQUERADE规则
-		masqueradeRule := fmt.Sprintf("iptables -t nat -D POSTROUTING -p %s -s %s --sport %d -j MASQUERADE",
-			proto, instanceIP, guestPort)
-
-		allCommands = append(allCommands, dnatRule, forwardRule, masqueradeRule)
-	}
-
 	global.APP_LOG.Info("Executing iptables removal commands",
-		zap.String("protocol", protocol),
-		zap.Int("commandCount", len(allCommands)))
+		zap.String("protocol", protocol))
 
 	// 获取provider信息以创建SSH连接
 	var providerInfo *provider.Provider
@@ -460,6 +465,8 @@ func (i *IptablesPortMapping) removeIptablesRule(ctx context.Context, instance *
 		return fmt.Errorf("failed to get provider info: %v", err)
 	}
 
+	allCommands := nodeCommands(instance, providerInfo, DeleteRuleCommands(protocol, hostPort, guestPort, instanceIP))
+
 	// 创建SSH客户端连接到provider主机执行iptables命令
 	sshClient, err := i.createSSHClient(providerInfo)
 	if err != nil {
@@ -479,7 +486,7 @@ func (i *IptablesPortMapping) removeIptablesRule(ctx context.Context, instance *
 	}
 
 	// 保存iptables规则
-	saveCmd := "iptables-save > /etc/iptables/rules.v4 2>/dev/null || true"
+	saveCmd := nodeCommands(instance, providerInfo, []string{SaveRulesCommand})[0]
 	_, err = sshClient.Execute(saveCmd)
 	if err != nil {
 		global.APP_LOG.Warn("Failed to save iptables rules", zap.Error(err))
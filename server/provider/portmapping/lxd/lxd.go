@@ -256,6 +256,8 @@ func (l *LXDPortMapping) determineMappingMethod(req *portmapping.PortMappingRequ
 		switch providerInfo.IPv6PortMappingMethod {
 		case "iptables":
 			return "lxd-iptables-ipv6"
+		case "nftables":
+			return "lxd-nftables-ipv6"
 		case "device_proxy":
 			return "lxd-device-proxy-ipv6"
 		default:
@@ -267,6 +269,8 @@ func (l *LXDPortMapping) determineMappingMethod(req *portmapping.PortMappingRequ
 	switch providerInfo.IPv4PortMappingMethod {
 	case "iptables":
 		return "lxd-iptables"
+	case "nftables":
+		return "lxd-nftables"
 	case "device_proxy":
 		return "lxd-device-proxy"
 	default:
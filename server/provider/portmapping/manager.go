@@ -114,6 +114,8 @@ func (m *Manager) AutoSelectProvider(instanceType string) string {
 	switch instanceType {
 	case "docker":
 		return "docker"
+	case "podman":
+		return "podman"
 	case "lxd":
 		return "lxd"
 	case "incus":
@@ -149,6 +151,10 @@ func (m *Manager) GetProviderCapabilities(providerType string) map[string]interf
 		capabilities["description"] = "Docker原生端口映射，端口在容器创建时固定"
 		capabilities["methods"] = []string{"port-binding"}
 		capabilities["limitations"] = []string{"不支持运行时端口修改", "需要重新创建容器"}
+	case "podman":
+		capabilities["description"] = "Podman原生端口映射，端口变更时保留数据重建容器"
+		capabilities["methods"] = []string{"port-binding"}
+		capabilities["limitations"] = []string{"不支持运行时端口修改", "rootless模式无法绑定特权端口"}
 	case "lxd":
 		capabilities["description"] = "LXD原生端口映射，支持动态调整"
 		capabilities["methods"] = []string{"proxy-device"}
@@ -165,6 +171,10 @@ func (m *Manager) GetProviderCapabilities(providerType string) map[string]interf
 		capabilities["description"] = "通用iptables NAT端口映射"
 		capabilities["methods"] = []string{"nat", "dnat", "snat"}
 		capabilities["limitations"] = []string{"需要root权限"}
+	case "nftables":
+		capabilities["description"] = "nftables DNAT端口映射，端口段以区间元素存储"
+		capabilities["methods"] = []string{"dnat", "map", "interval"}
+		capabilities["limitations"] = []string{"需要root权限", "需要宿主机安装nft命令"}
 	}
 
 	return capabilities
@@ -0,0 +1,271 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"oneclickvirt/global"
+	"oneclickvirt/model/provider"
+	mockProvider "oneclickvirt/provider/mock"
+	"oneclickvirt/provider/portmapping"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// MockPortMapping 模拟端口映射实现，数据库记录和真实Provider一致，转发规则写入内存模拟节点
+type MockPortMapping struct {
+	*portmapping.BaseProvider
+}
+
+// NewMockPortMapping 创建模拟端口映射Provider
+func NewMockPortMapping(config *portmapping.ManagerConfig) portmapping.PortMappingProvider {
+	return &MockPortMapping{
+		BaseProvider: portmapping.NewBaseProvider("mock", config),
+	}
+}
+
+// SupportsDynamicMapping 模拟节点支持动态端口映射
+func (m *MockPortMapping) SupportsDynamicMapping() bool {
+	return true
+}
+
+// CreatePortMapping 创建模拟端口映射
+func (m *MockPortMapping) CreatePortMapping(ctx context.Context, req *portmapping.PortMappingRequest) (*portmapping.PortMappingResult, error) {
+	global.APP_LOG.Info("Creating mock port mapping",
+		zap.String("instanceId", req.InstanceID),
+		zap.Int("hostPort", req.HostPort),
+		zap.Int("guestPort", req.GuestPort),
+		zap.String("protocol", req.Protocol))
+
+	if err := m.validateRequest(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %v", err)
+	}
+
+	instance, err := m.getInstance(req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	providerInfo, err := m.getProvider(req.ProviderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %v", err)
+	}
+
+	hostPort := req.HostPort
+	if hostPort == 0 {
+		hostPort, err = m.BaseProvider.AllocatePort(ctx, req.ProviderID, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate port: %v", err)
+		}
+	}
+
+	// 先在模拟节点上生效，再保存数据库记录
+	node := mockProvider.GetNode(providerInfo.Name)
+	if err := node.AddPortMapping(mockProvider.PortMapping{
+		InstanceName: instance.Name,
+		Protocol:     req.Protocol,
+		HostPort:     hostPort,
+		GuestPort:    req.GuestPort,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to apply port mapping: %v", err)
+	}
+
+	isSSH := req.GuestPort == 22
+	if req.IsSSH != nil {
+		isSSH = *req.IsSSH
+	}
+
+	result := &portmapping.PortMappingResult{
+		InstanceID:    req.InstanceID,
+		ProviderID:    req.ProviderID,
+		Protocol:      req.Protocol,
+		HostPort:      hostPort,
+		GuestPort:     req.GuestPort,
+		HostIP:        providerInfo.Endpoint,
+		PublicIP:      m.getPublicIP(providerInfo),
+		IPv6Address:   req.IPv6Address,
+		Status:        "active",
+		Description:   req.Description,
+		MappingMethod: "mock",
+		IsSSH:         isSSH,
+		IsAutomatic:   req.HostPort == 0,
+	}
+
+	portModel := m.BaseProvider.ToDBModel(result)
+	if err := global.APP_DB.Create(portModel).Error; err != nil {
+		node.RemovePortMapping(req.Protocol, hostPort)
+		global.APP_LOG.Error("Failed to save port mapping to database", zap.Error(err))
+		return nil, fmt.Errorf("failed to save port mapping: %v", err)
+	}
+
+	result.ID = portModel.ID
+	result.CreatedAt = portModel.CreatedAt.Format("2006-01-02T15:04:05Z07:00")
+	result.UpdatedAt = portModel.UpdatedAt.Format("2006-01-02T15:04:05Z07:00")
+
+	global.APP_LOG.Info("Mock port mapping created successfully",
+		zap.Uint("id", result.ID),
+		zap.Int("hostPort", hostPort),
+		zap.Int("guestPort", req.GuestPort))
+
+	return result, nil
+}
+
+// DeletePortMapping 删除模拟端口映射
+func (m *MockPortMapping) DeletePortMapping(ctx context.Context, req *portmapping.DeletePortMappingRequest) error {
+	global.APP_LOG.Info("Deleting mock port mapping",
+		zap.Uint("id", req.ID),
+		zap.String("instanceId", req.InstanceID))
+
+	var portModel provider.Port
+	if err := global.APP_DB.First(&portModel, req.ID).Error; err != nil {
+		return fmt.Errorf("port mapping not found: %v", err)
+	}
+
+	if providerInfo, err := m.getProvider(portModel.ProviderID); err == nil {
+		mockProvider.GetNode(providerInfo.Name).RemovePortMapping(portModel.Protocol, portModel.HostPort)
+	} else if !req.ForceDelete {
+		return fmt.Errorf("failed to get provider: %v", err)
+	}
+
+	if err := global.APP_DB.Delete(&portModel).Error; err != nil {
+		return fmt.Errorf("failed to delete port mapping from database: %v", err)
+	}
+
+	global.APP_LOG.Info("Mock port mapping deleted successfully", zap.Uint("id", req.ID))
+	return nil
+}
+
+// UpdatePortMapping 更新模拟端口映射
+func (m *MockPortMapping) UpdatePortMapping(ctx context.Context, req *portmapping.UpdatePortMappingRequest) (*portmapping.PortMappingResult, error) {
+	global.APP_LOG.Info("Updating mock port mapping", zap.Uint("id", req.ID))
+
+	var portModel provider.Port
+	if err := global.APP_DB.First(&portModel, req.ID).Error; err != nil {
+		return nil, fmt.Errorf("port mapping not found: %v", err)
+	}
+
+	providerInfo, err := m.getProvider(portModel.ProviderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %v", err)
+	}
+	instance, err := m.getInstance(strconv.FormatUint(uint64(portModel.InstanceID), 10))
+	if err != nil {
+		return nil, err
+	}
+
+	// 先删除旧规则再添加新规则，新规则失败时还原旧规则
+	node := mockProvider.GetNode(providerInfo.Name)
+	node.RemovePortMapping(portModel.Protocol, portModel.HostPort)
+	if err := node.AddPortMapping(mockProvider.PortMapping{
+		InstanceName: instance.Name,
+		Protocol:     req.Protocol,
+		HostPort:     req.HostPort,
+		GuestPort:    req.GuestPort,
+	}); err != nil {
+		node.AddPortMapping(mockProvider.PortMapping{
+			InstanceName: instance.Name,
+			Protocol:     portModel.Protocol,
+			HostPort:     portModel.HostPort,
+			GuestPort:    portModel.GuestPort,
+		})
+		return nil, fmt.Errorf("failed to apply port mapping: %v", err)
+	}
+
+	updates := map[string]interface{}{
+		"host_port":   req.HostPort,
+		"guest_port":  req.GuestPort,
+		"protocol":    req.Protocol,
+		"description": req.Description,
+		"status":      req.Status,
+	}
+	if err := global.APP_DB.Model(&portModel).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update port mapping: %v", err)
+	}
+	if err := global.APP_DB.First(&portModel, req.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get updated port mapping: %v", err)
+	}
+
+	result := m.BaseProvider.FromDBModel(&portModel)
+	result.HostIP = providerInfo.Endpoint
+	result.PublicIP = m.getPublicIP(providerInfo)
+	result.MappingMethod = "mock"
+
+	global.APP_LOG.Info("Mock port mapping updated successfully", zap.Uint("id", req.ID))
+	return result, nil
+}
+
+// ListPortMappings 列出模拟端口映射
+func (m *MockPortMapping) ListPortMappings(ctx context.Context, instanceID string) ([]*portmapping.PortMappingResult, error) {
+	var ports []provider.Port
+	if err := global.APP_DB.Where("instance_id = ?", instanceID).Find(&ports).Error; err != nil {
+		return nil, fmt.Errorf("failed to list port mappings: %v", err)
+	}
+
+	var results []*portmapping.PortMappingResult
+	for _, port := range ports {
+		result := m.BaseProvider.FromDBModel(&port)
+		result.MappingMethod = "mock"
+		if providerInfo, err := m.getProvider(port.ProviderID); err == nil {
+			result.HostIP = providerInfo.Endpoint
+			result.PublicIP = m.getPublicIP(providerInfo)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// validateRequest 验证请求参数
+func (m *MockPortMapping) validateRequest(req *portmapping.PortMappingRequest) error {
+	if req.InstanceID == "" {
+		return fmt.Errorf("instance ID is required")
+	}
+	if req.GuestPort <= 0 || req.GuestPort > 65535 {
+		return fmt.Errorf("invalid guest port: %d", req.GuestPort)
+	}
+	if req.HostPort < 0 || req.HostPort > 65535 {
+		return fmt.Errorf("invalid host port: %d", req.HostPort)
+	}
+	if req.Protocol == "" {
+		req.Protocol = "tcp"
+	}
+	return portmapping.ValidateProtocol(req.Protocol)
+}
+
+// getInstance 获取实例信息
+func (m *MockPortMapping) getInstance(instanceID string) (*provider.Instance, error) {
+	var instance provider.Instance
+	id, err := strconv.ParseUint(instanceID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid instance ID: %s", instanceID)
+	}
+
+	if err := global.APP_DB.First(&instance, uint(id)).Error; err != nil {
+		return nil, fmt.Errorf("instance not found: %v", err)
+	}
+
+	return &instance, nil
+}
+
+// getProvider 获取Provider信息
+func (m *MockPortMapping) getProvider(providerID uint) (*provider.Provider, error) {
+	var providerInfo provider.Provider
+	if err := global.APP_DB.First(&providerInfo, providerID).Error; err != nil {
+		return nil, fmt.Errorf("provider not found: %v", err)
+	}
+	return &providerInfo, nil
+}
+
+// getPublicIP 获取公网IP
+func (m *MockPortMapping) getPublicIP(providerInfo *provider.Provider) string {
+	if providerInfo.PortIP != "" {
+		return providerInfo.PortIP
+	}
+	return providerInfo.Endpoint
+}
+
+// init 注册模拟端口映射Provider
+func init() {
+	portmapping.RegisterProvider("mock", func(config *portmapping.ManagerConfig) portmapping.PortMappingProvider {
+		return NewMockPortMapping(config)
+	})
+}
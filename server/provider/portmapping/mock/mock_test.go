@@ -0,0 +1,85 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	mockProvider "oneclickvirt/provider/mock"
+	"oneclickvirt/provider/portmapping"
+)
+
+func setupTestDB(t *testing.T) {
+	mockProvider.SetupTestDB(t, &providerModel.Provider{}, &providerModel.Instance{}, &providerModel.Port{})
+}
+
+func TestMockFlow_CreatePortMappingDelete(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+
+	dbProvider := providerModel.Provider{Name: "mock-node", Type: "mock", Endpoint: "192.0.2.10", PortRangeStart: 20000, PortRangeEnd: 20010}
+	if err := global.APP_DB.Create(&dbProvider).Error; err != nil {
+		t.Fatalf("创建Provider记录失败: %v", err)
+	}
+
+	prov, err := provider.GetProvider("mock")
+	if err != nil {
+		t.Fatalf("获取mock Provider失败: %v", err)
+	}
+	if err := prov.Connect(ctx, provider.NodeConfig{ID: dbProvider.ID, Name: dbProvider.Name, Host: dbProvider.Endpoint}); err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	if err := prov.CreateInstance(ctx, provider.InstanceConfig{Name: "vm1", Image: "debian12", CPU: "1", Memory: "512", Disk: "10240"}); err != nil {
+		t.Fatalf("创建实例失败: %v", err)
+	}
+	remote, err := prov.GetInstance(ctx, "vm1")
+	if err != nil || remote.Status != "running" || remote.PrivateIP == "" {
+		t.Fatalf("实例状态不正确: %+v, %v", remote, err)
+	}
+
+	instance := providerModel.Instance{Name: "vm1", ProviderID: dbProvider.ID, Status: "running", PrivateIP: remote.PrivateIP}
+	if err := global.APP_DB.Create(&instance).Error; err != nil {
+		t.Fatalf("创建实例记录失败: %v", err)
+	}
+
+	manager := portmapping.CreateManagerWithAllProviders(nil)
+	result, err := manager.CreatePortMapping(ctx, "mock", &portmapping.PortMappingRequest{
+		InstanceID: strconv.FormatUint(uint64(instance.ID), 10),
+		ProviderID: dbProvider.ID,
+		Protocol:   "both",
+		GuestPort:  22,
+	})
+	if err != nil {
+		t.Fatalf("创建端口映射失败: %v", err)
+	}
+	if result.HostPort != 20000 || !result.IsSSH {
+		t.Errorf("端口映射结果不正确: %+v", result)
+	}
+
+	node := mockProvider.GetNode("mock-node")
+	if mappings := node.PortMappings("vm1"); len(mappings) != 2 || mappings[0].GuestIP != remote.PrivateIP {
+		t.Errorf("节点端口规则不正确: %+v", mappings)
+	}
+
+	if err := manager.DeletePortMapping(ctx, "mock", &portmapping.DeletePortMappingRequest{ID: result.ID}); err != nil {
+		t.Fatalf("删除端口映射失败: %v", err)
+	}
+	if mappings := node.PortMappings("vm1"); len(mappings) != 0 {
+		t.Errorf("端口规则未删除: %+v", mappings)
+	}
+
+	node.InjectFault("DeleteInstance", errors.New("node busy"), 1)
+	if err := prov.DeleteInstance(ctx, "vm1"); err == nil {
+		t.Fatal("注入的故障未生效")
+	}
+	if err := prov.DeleteInstance(ctx, "vm1"); err != nil {
+		t.Fatalf("故障次数用尽后删除实例失败: %v", err)
+	}
+	if _, err := prov.GetInstance(ctx, "vm1"); err == nil {
+		t.Error("实例删除后仍然存在")
+	}
+}
@@ -0,0 +1,392 @@
+package nftables
+
+import (
+	"context"
+	"fmt"
+	"oneclickvirt/global"
+	"oneclickvirt/model/provider"
+	providerPkg "oneclickvirt/provider"
+	"oneclickvirt/provider/portmapping"
+	providerService "oneclickvirt/service/provider"
+	"oneclickvirt/utils"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// mappingMethod 写入端口映射记录的映射方法
+const mappingMethod = "nftables-dnat"
+
+// NftablesPortMapping nftables端口映射实现
+type NftablesPortMapping struct {
+	*portmapping.BaseProvider
+}
+
+// NewNftablesPortMapping 创建nftables端口映射Provider
+func NewNftablesPortMapping(config *portmapping.ManagerConfig) portmapping.PortMappingProvider {
+	return &NftablesPortMapping{
+		BaseProvider: portmapping.NewBaseProvider("nftables", config),
+	}
+}
+
+// SupportsDynamicMapping nftables支持动态端口映射
+func (n *NftablesPortMapping) SupportsDynamicMapping() bool {
+	return true
+}
+
+// CreatePortMapping 创建nftables端口映射
+func (n *NftablesPortMapping) CreatePortMapping(ctx context.Context, req *portmapping.PortMappingRequest) (*portmapping.PortMappingResult, error) {
+	global.APP_LOG.Info("Creating nftables port mapping",
+		zap.String("instanceId", req.InstanceID),
+		zap.Int("hostPort", req.HostPort),
+		zap.Int("guestPort", req.GuestPort),
+		zap.String("protocol", req.Protocol))
+
+	// 验证请求参数
+	if err := n.validateRequest(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %v", err)
+	}
+
+	// 获取实例信息
+	instance, err := n.getInstance(req.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance: %v", err)
+	}
+
+	// 获取Provider信息
+	providerInfo, err := n.getProvider(req.ProviderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %v", err)
+	}
+
+	// 分配端口
+	hostPort := req.HostPort
+	if hostPort == 0 {
+		hostPort, err = n.BaseProvider.AllocatePort(ctx, req.ProviderID, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate port: %v", err)
+		}
+	}
+
+	targetIP := n.targetIP(instance, req.IPv6Address)
+	if targetIP == "" {
+		return nil, fmt.Errorf("instance private IP address not found for %s", instance.Name)
+	}
+
+	mapping := Mapping{Protocol: req.Protocol, HostPort: hostPort, GuestPort: req.GuestPort, TargetIP: targetIP}
+	if err := n.executeCommands(ctx, instance, providerInfo, ApplyCommands([]Mapping{mapping})); err != nil {
+		return nil, fmt.Errorf("failed to create nftables mapping: %v", err)
+	}
+
+	// 判断是否为SSH端口：优先使用请求中的IsSSH字段，否则根据GuestPort判断
+	isSSH := req.GuestPort == 22
+	if req.IsSSH != nil {
+		isSSH = *req.IsSSH
+	}
+
+	// 保存到数据库
+	result := &portmapping.PortMappingResult{
+		InstanceID:    req.InstanceID,
+		ProviderID:    req.ProviderID,
+		Protocol:      req.Protocol,
+		HostPort:      hostPort,
+		GuestPort:     req.GuestPort,
+		HostIP:        providerInfo.Endpoint,
+		PublicIP:      n.getPublicIP(providerInfo),
+		IPv6Address:   req.IPv6Address,
+		Status:        "active",
+		Description:   req.Description,
+		MappingMethod: mappingMethod,
+		IsSSH:         isSSH,
+		IsAutomatic:   req.HostPort == 0,
+	}
+
+	// 转换为数据库模型并保存
+	portModel := n.BaseProvider.ToDBModel(result)
+	if err := global.APP_DB.Create(portModel).Error; err != nil {
+		global.APP_LOG.Error("Failed to save port mapping to database", zap.Error(err))
+		// 尝试清理已创建的映射元素
+		if cleanupErr := n.executeCommands(ctx, instance, providerInfo, RemoveCommands([]Mapping{mapping})); cleanupErr != nil {
+			global.APP_LOG.Error("Failed to cleanup nftables mapping", zap.Error(cleanupErr))
+		}
+		return nil, fmt.Errorf("failed to save port mapping: %v", err)
+	}
+
+	result.ID = portModel.ID
+	result.CreatedAt = portModel.CreatedAt.Format("2006-01-02T15:04:05Z07:00")
+	result.UpdatedAt = portModel.UpdatedAt.Format("2006-01-02T15:04:05Z07:00")
+
+	global.APP_LOG.Info("nftables port mapping created successfully",
+		zap.Uint("id", result.ID),
+		zap.Int("hostPort", hostPort),
+		zap.Int("guestPort", req.GuestPort))
+
+	return result, nil
+}
+
+// DeletePortMapping 删除nftables端口映射
+func (n *NftablesPortMapping) DeletePortMapping(ctx context.Context, req *portmapping.DeletePortMappingRequest) error {
+	global.APP_LOG.Info("Deleting nftables port mapping",
+		zap.Uint("id", req.ID),
+		zap.String("instanceId", req.InstanceID))
+
+	// 获取端口映射信息
+	var portModel provider.Port
+	if err := global.APP_DB.First(&portModel, req.ID).Error; err != nil {
+		return fmt.Errorf("port mapping not found: %v", err)
+	}
+
+	// 删除映射元素
+	if err := n.removeMapping(ctx, &portModel); err != nil {
+		if !req.ForceDelete {
+			return fmt.Errorf("failed to remove nftables mapping: %v", err)
+		}
+		global.APP_LOG.Warn("Failed to remove nftables mapping, but force delete is enabled", zap.Error(err))
+	}
+
+	// 从数据库删除
+	if err := global.APP_DB.Delete(&portModel).Error; err != nil {
+		return fmt.Errorf("failed to delete port mapping from database: %v", err)
+	}
+
+	global.APP_LOG.Info("nftables port mapping deleted successfully", zap.Uint("id", req.ID))
+	return nil
+}
+
+// UpdatePortMapping 更新nftables端口映射
+func (n *NftablesPortMapping) UpdatePortMapping(ctx context.Context, req *portmapping.UpdatePortMappingRequest) (*portmapping.PortMappingResult, error) {
+	global.APP_LOG.Info("Updating nftables port mapping", zap.Uint("id", req.ID))
+
+	// 获取现有端口映射
+	var portModel provider.Port
+	if err := global.APP_DB.First(&portModel, req.ID).Error; err != nil {
+		return nil, fmt.Errorf("port mapping not found: %v", err)
+	}
+
+	// 获取实例信息
+	instance, err := n.getInstance(req.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance: %v", err)
+	}
+
+	// 获取Provider信息
+	providerInfo, err := n.getProvider(portModel.ProviderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %v", err)
+	}
+
+	// 端口发生变化时删除旧元素并添加新元素
+	if req.HostPort != portModel.HostPort || req.GuestPort != portModel.GuestPort || req.Protocol != portModel.Protocol {
+		if err := n.removeMapping(ctx, &portModel); err != nil {
+			global.APP_LOG.Warn("Failed to remove old nftables mapping", zap.Error(err))
+		}
+
+		targetIP := n.targetIP(instance, portModel.IPv6Address)
+		if targetIP == "" {
+			return nil, fmt.Errorf("instance private IP address not found for %s", instance.Name)
+		}
+		mapping := Mapping{Protocol: req.Protocol, HostPort: req.HostPort, GuestPort: req.GuestPort, TargetIP: targetIP}
+		if err := n.executeCommands(ctx, instance, providerInfo, ApplyCommands([]Mapping{mapping})); err != nil {
+			return nil, fmt.Errorf("failed to create new nftables mapping: %v", err)
+		}
+	}
+
+	// 更新数据库记录
+	updates := map[string]interface{}{
+		"host_port":   req.HostPort,
+		"guest_port":  req.GuestPort,
+		"protocol":    req.Protocol,
+		"description": req.Description,
+		"status":      req.Status,
+	}
+
+	if err := global.APP_DB.Model(&portModel).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update port mapping: %v", err)
+	}
+
+	// 重新获取更新后的记录
+	if err := global.APP_DB.First(&portModel, req.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get updated port mapping: %v", err)
+	}
+
+	result := n.BaseProvider.FromDBModel(&portModel)
+	result.HostIP = providerInfo.Endpoint
+	result.PublicIP = n.getPublicIP(providerInfo)
+	result.MappingMethod = mappingMethod
+
+	global.APP_LOG.Info("nftables port mapping updated successfully", zap.Uint("id", req.ID))
+	return result, nil
+}
+
+// ListPortMappings 列出nftables端口映射
+func (n *NftablesPortMapping) ListPortMappings(ctx context.Context, instanceID string) ([]*portmapping.PortMappingResult, error) {
+	var ports []provider.Port
+	if err := global.APP_DB.Where("instance_id = ?", instanceID).Find(&ports).Error; err != nil {
+		return nil, fmt.Errorf("failed to list port mappings: %v", err)
+	}
+
+	var results []*portmapping.PortMappingResult
+	for _, port := range ports {
+		result := n.BaseProvider.FromDBModel(&port)
+		result.MappingMethod = mappingMethod
+
+		// 获取Provider信息以填充IP地址
+		if providerInfo, err := n.getProvider(port.ProviderID); err == nil {
+			result.HostIP = providerInfo.Endpoint
+			result.PublicIP = n.getPublicIP(providerInfo)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// removeMapping 删除端口记录对应的映射元素，端口段记录按区间删除
+func (n *NftablesPortMapping) removeMapping(ctx context.Context, port *provider.Port) error {
+	instance, err := n.getInstance(strconv.FormatUint(uint64(port.InstanceID), 10))
+	if err != nil {
+		return err
+	}
+	providerInfo, err := n.getProvider(port.ProviderID)
+	if err != nil {
+		return err
+	}
+
+	// 目标地址决定元素所在的地址族集合
+	mapping := Mapping{
+		Protocol:    port.Protocol,
+		HostPort:    port.HostPort,
+		HostPortEnd: port.HostPortEnd,
+		GuestPort:   port.GuestPort,
+		TargetIP:    n.targetIP(instance, port.IPv6Address),
+	}
+	return n.executeCommands(ctx, instance, providerInfo, RemoveCommands([]Mapping{mapping}))
+}
+
+// validateRequest 验证请求参数
+func (n *NftablesPortMapping) validateRequest(req *portmapping.PortMappingRequest) error {
+	if req.InstanceID == "" {
+		return fmt.Errorf("instance ID is required")
+	}
+	if req.GuestPort <= 0 || req.GuestPort > 65535 {
+		return fmt.Errorf("invalid guest port: %d", req.GuestPort)
+	}
+	if req.HostPort < 0 || req.HostPort > 65535 {
+		return fmt.Errorf("invalid host port: %d", req.HostPort)
+	}
+	if req.Protocol == "" {
+		req.Protocol = "both"
+	}
+	return portmapping.ValidateProtocol(req.Protocol)
+}
+
+// targetIP 映射目标地址，指定IPv6地址时映射到实例IPv6，否则映射到实例内网IPv4
+func (n *NftablesPortMapping) targetIP(instance *provider.Instance, ipv6Address string) string {
+	if ipv6Address != "" {
+		return ipv6Address
+	}
+	return instance.PrivateIP
+}
+
+// getInstance 获取实例信息
+func (n *NftablesPortMapping) getInstance(instanceID string) (*provider.Instance, error) {
+	var instance provider.Instance
+	id, err := strconv.ParseUint(instanceID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid instance ID: %s", instanceID)
+	}
+
+	if err := global.APP_DB.First(&instance, uint(id)).Error; err != nil {
+		return nil, fmt.Errorf("instance not found: %v", err)
+	}
+
+	return &instance, nil
+}
+
+// getProvider 获取Provider信息
+func (n *NftablesPortMapping) getProvider(providerID uint) (*provider.Provider, error) {
+	var providerInfo provider.Provider
+	if err := global.APP_DB.First(&providerInfo, providerID).Error; err != nil {
+		return nil, fmt.Errorf("provider not found: %v", err)
+	}
+	return &providerInfo, nil
+}
+
+// getPublicIP 获取公网IP
+func (n *NftablesPortMapping) getPublicIP(providerInfo *provider.Provider) string {
+	// 优先使用PortIP（端口映射专用IP），如果为空则使用Endpoint（SSH地址）
+	if providerInfo.PortIP != "" {
+		return providerInfo.PortIP
+	}
+	return providerInfo.Endpoint
+}
+
+// executeCommands 在实例所在宿主机上执行nft命令，优先使用已连接Provider的SSH连接，否则创建临时SSH连接
+func (n *NftablesPortMapping) executeCommands(ctx context.Context, instance *provider.Instance, providerInfo *provider.Provider, commands []string) error {
+	// 集群Provider中实例位于连接节点以外的节点时，经集群内部SSH在该节点上执行
+	if instance.Node != "" && instance.Node != providerInfo.HostName {
+		wrapped := make([]string, len(commands))
+		for idx, cmd := range commands {
+			wrapped[idx] = providerPkg.ClusterNodeCommand(instance.Node, cmd)
+		}
+		commands = wrapped
+	}
+
+	var execute func(cmd string) error
+	providerInstance, exists := providerService.GetProviderService().GetProviderByID(providerInfo.ID)
+	if exists && providerInstance.IsConnected() {
+		execute = func(cmd string) error {
+			_, err := providerInstance.ExecuteSSHCommand(ctx, cmd)
+			return err
+		}
+	} else {
+		global.APP_LOG.Warn("Provider未连接，使用临时SSH连接",
+			zap.Uint("providerId", providerInfo.ID),
+			zap.String("providerName", providerInfo.Name))
+		sshClient, err := n.createSSHClient(providerInfo)
+		if err != nil {
+			return fmt.Errorf("failed to create SSH client: %v", err)
+		}
+		defer sshClient.Close()
+		execute = func(cmd string) error {
+			_, err := sshClient.Execute(cmd)
+			return err
+		}
+	}
+
+	for _, cmd := range commands {
+		if err := execute(cmd); err != nil {
+			global.APP_LOG.Error("Failed to execute nft command",
+				zap.String("command", cmd),
+				zap.Error(err))
+			return fmt.Errorf("failed to execute nft command '%s': %v", cmd, err)
+		}
+	}
+	return nil
+}
+
+// createSSHClient 创建SSH客户端连接到provider主机
+func (n *NftablesPortMapping) createSSHClient(providerInfo *provider.Provider) (*utils.SSHClient, error) {
+	host, port := utils.ParseEndpoint(providerInfo.Endpoint, 22)
+
+	sshConfig := utils.SSHConfig{
+		Host:           host,
+		Port:           port,
+		Username:       providerInfo.Username,
+		Password:       providerInfo.Password,
+		PrivateKey:     providerInfo.SSHKey,
+		ConnectTimeout: 10 * time.Second,
+		ExecuteTimeout: 60 * time.Second,
+	}
+
+	return utils.NewSSHClient(sshConfig)
+}
+
+// init 注册nftables端口映射Provider
+func init() {
+	portmapping.RegisterProvider("nftables", func(config *portmapping.ManagerConfig) portmapping.PortMappingProvider {
+		return NewNftablesPortMapping(config)
+	})
+}
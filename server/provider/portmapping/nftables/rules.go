@@ -0,0 +1,193 @@
+package nftables
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 所有端口映射集中在独立的inet表中，与系统防火墙和iptables-nft生成的表互不干扰：
+//   - ports4/ports6：单端口映射，键为 协议.宿主机端口，值为 实例地址.实例端口
+//   - ranges4/ranges6：端口段映射（内外端口一致），区间键，一个端口段只占一个元素
+//   - addrs6：IPv6整地址映射，宿主机上附加的IPv6地址整体DNAT到实例
+//
+// 映射变更只增删map元素，规则本身不变，元素更新通过nft -f整批提交，要么全部生效要么全部失败
+const (
+	// TableName 端口映射使用的nftables表名
+	TableName = "oneclickvirt"
+	// PersistPath 持久化的表定义文件
+	PersistPath = "/etc/nftables.d/oneclickvirt.nft"
+)
+
+// tableDefinition 表、映射集合和链的定义，只在表不存在时创建一次
+var tableDefinition = []string{
+	"table inet " + TableName + " {",
+	"map ports4 { type inet_proto . inet_service : ipv4_addr . inet_service; }",
+	"map ranges4 { type inet_proto . inet_service : ipv4_addr; flags interval; }",
+	"map ports6 { type inet_proto . inet_service : ipv6_addr . inet_service; }",
+	"map ranges6 { type inet_proto . inet_service : ipv6_addr; flags interval; }",
+	"map addrs6 { type ipv6_addr : ipv6_addr; }",
+	"chain prerouting {",
+	"type nat hook prerouting priority dstnat; policy accept;",
+	"fib daddr type local meta nfproto ipv4 meta l4proto { tcp, udp } dnat ip addr . port to meta l4proto . th dport map @ports4",
+	"fib daddr type local meta nfproto ipv4 meta l4proto { tcp, udp } dnat ip to meta l4proto . th dport map @ranges4",
+	"fib daddr type local meta nfproto ipv6 meta l4proto { tcp, udp } dnat ip6 addr . port to meta l4proto . th dport map @ports6",
+	"fib daddr type local meta nfproto ipv6 meta l4proto { tcp, udp } dnat ip6 to meta l4proto . th dport map @ranges6",
+	"meta nfproto ipv6 dnat ip6 to ip6 daddr map @addrs6",
+	"}",
+	"chain forward {",
+	"type filter hook forward priority filter; policy accept;",
+	"ct status dnat accept",
+	"}",
+	"}",
+}
+
+// Mapping 一条DNAT端口映射，HostPortEnd大于HostPort时表示端口段
+type Mapping struct {
+	Protocol    string // tcp, udp, both
+	HostPort    int
+	HostPortEnd int
+	GuestPort   int
+	TargetIP    string
+}
+
+// element map中的一个元素
+type element struct {
+	set   string
+	key   string
+	value string
+}
+
+// EnsureTableCommand 表不存在时创建端口映射表的命令
+func EnsureTableCommand() string {
+	return fmt.Sprintf("nft list table inet %s >/dev/null 2>&1 || %s", TableName, batchCommand(tableDefinition))
+}
+
+// PersistCommand 将端口映射表保存到独立文件，并在nftables服务配置中追加include随服务启动加载
+// 保存的文件先声明再删除表，重复加载时不会产生重复规则
+func PersistCommand() string {
+	include := fmt.Sprintf(`include "%s"`, PersistPath)
+	return fmt.Sprintf("mkdir -p /etc/nftables.d && "+
+		"{ printf 'table inet %[1]s\\ndelete table inet %[1]s\\n'; nft list table inet %[1]s; } > %[2]s && "+
+		"for f in /etc/nftables.conf /etc/sysconfig/nftables.conf; do "+
+		"[ -f \"$f\" ] && { grep -qF '%[2]s' \"$f\" || echo '%[3]s' >> \"$f\"; }; done; true",
+		TableName, PersistPath, include)
+}
+
+// ApplyCommands 生成添加映射的命令：确保表存在，在同一批次中清除同键旧元素并添加新元素，最后持久化
+func ApplyCommands(mappings []Mapping) []string {
+	elements := mappingElements(mappings)
+	if len(elements) == 0 {
+		return nil
+	}
+
+	lines := destroyElementLines(elements)
+	for _, e := range elements {
+		lines = append(lines, fmt.Sprintf("add element inet %s %s { %s : %s }", TableName, e.set, e.key, e.value))
+	}
+	return []string{
+		EnsureTableCommand(),
+		batchCommand(lines),
+		PersistCommand(),
+	}
+}
+
+// RemoveCommands 生成删除映射的命令，元素不存在时忽略；按TargetIP的地址族选择映射集合，未指定时按IPv4处理
+func RemoveCommands(mappings []Mapping) []string {
+	elements := mappingElements(mappings)
+	if len(elements) == 0 {
+		return nil
+	}
+	return []string{batchCommand(destroyElementLines(elements)), PersistCommand()}
+}
+
+// AddressApplyCommands 生成IPv6整地址映射命令，mappedIPv6为宿主机上附加的地址
+func AddressApplyCommands(mappedIPv6, targetIPv6 string) []string {
+	e := element{set: "addrs6", key: mappedIPv6, value: targetIPv6}
+	lines := append(destroyElementLines([]element{e}), fmt.Sprintf("add element inet %s addrs6 { %s : %s }", TableName, e.key, e.value))
+	return []string{
+		EnsureTableCommand(),
+		batchCommand(lines),
+		PersistCommand(),
+	}
+}
+
+// AddressExistsCommand 检查IPv6地址是否已被映射的命令，已映射时退出码为0
+func AddressExistsCommand(mappedIPv6 string) string {
+	return fmt.Sprintf("nft get element inet %s addrs6 '{ %s }' >/dev/null 2>&1", TableName, mappedIPv6)
+}
+
+// mappingElements 将映射转换为map元素，端口段内外端口一致时作为一个区间元素，否则逐端口展开
+func mappingElements(mappings []Mapping) []element {
+	var elements []element
+	for _, m := range mappings {
+		protocols := []string{m.Protocol}
+		if m.Protocol == "both" || m.Protocol == "" {
+			protocols = []string{"tcp", "udp"}
+		}
+		ip := cleanIP(m.TargetIP)
+		suffix := "4"
+		if strings.Contains(ip, ":") {
+			suffix = "6"
+		}
+
+		for _, proto := range protocols {
+			elements = append(elements, rangeElements(proto, suffix, ip, m)...)
+		}
+	}
+	return elements
+}
+
+// cleanIP 提取纯IP地址，去除前缀长度和"10.0.0.5 (eth0)"形式的接口名称
+func cleanIP(ip string) string {
+	ip = strings.TrimSpace(ip)
+	if idx := strings.IndexAny(ip, " (/"); idx >= 0 {
+		ip = ip[:idx]
+	}
+	return ip
+}
+
+// rangeElements 生成单个协议和地址族下的元素
+func rangeElements(proto, suffix, ip string, m Mapping) []element {
+	switch {
+	case m.HostPortEnd <= m.HostPort:
+		return []element{{
+			set:   "ports" + suffix,
+			key:   fmt.Sprintf("%s . %d", proto, m.HostPort),
+			value: fmt.Sprintf("%s . %d", ip, m.GuestPort),
+		}}
+	case m.GuestPort == m.HostPort:
+		return []element{{
+			set:   "ranges" + suffix,
+			key:   fmt.Sprintf("%s . %d-%d", proto, m.HostPort, m.HostPortEnd),
+			value: ip,
+		}}
+	default:
+		elements := make([]element, 0, m.HostPortEnd-m.HostPort+1)
+		for offset := 0; offset <= m.HostPortEnd-m.HostPort; offset++ {
+			elements = append(elements, element{
+				set:   "ports" + suffix,
+				key:   fmt.Sprintf("%s . %d", proto, m.HostPort+offset),
+				value: fmt.Sprintf("%s . %d", ip, m.GuestPort+offset),
+			})
+		}
+		return elements
+	}
+}
+
+// destroyElementLines 生成删除元素的语句，destroy在元素不存在时不报错，可与添加语句放在同一批次中原子提交
+func destroyElementLines(elements []element) []string {
+	lines := make([]string, 0, len(elements))
+	for _, e := range elements {
+		lines = append(lines, fmt.Sprintf("destroy element inet %s %s { %s }", TableName, e.set, e.key))
+	}
+	return lines
+}
+
+// batchCommand 将多行nft语句通过nft -f -一次性提交，整批在一个事务中生效
+func batchCommand(lines []string) string {
+	quoted := make([]string, len(lines))
+	for idx, line := range lines {
+		quoted[idx] = "'" + line + "'"
+	}
+	return fmt.Sprintf("printf '%%s\\n' %s | nft -f -", strings.Join(quoted, " "))
+}
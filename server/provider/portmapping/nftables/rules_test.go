@@ -0,0 +1,60 @@
+package nftables
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyCommands(t *testing.T) {
+	script := strings.Join(ApplyCommands([]Mapping{
+		{Protocol: "tcp", HostPort: 10022, GuestPort: 22, TargetIP: "10.0.0.5"},
+		{Protocol: "both", HostPort: 20000, HostPortEnd: 20999, GuestPort: 20000, TargetIP: "10.0.0.5/24"},
+		{Protocol: "udp", HostPort: 30000, HostPortEnd: 30001, GuestPort: 40000, TargetIP: "2001:db8::5"},
+	}), "\n")
+
+	for _, want := range []string{
+		"nft list table inet oneclickvirt >/dev/null 2>&1 || printf",
+		"'destroy element inet oneclickvirt ports4 { tcp . 10022 }'",
+		"'add element inet oneclickvirt ports4 { tcp . 10022 : 10.0.0.5 . 22 }'",
+		"'add element inet oneclickvirt ranges4 { tcp . 20000-20999 : 10.0.0.5 }'",
+		"'add element inet oneclickvirt ranges4 { udp . 20000-20999 : 10.0.0.5 }'",
+		"'add element inet oneclickvirt ports6 { udp . 30001 : 2001:db8::5 . 40001 }'",
+		"| nft -f -",
+		"> " + PersistPath,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("missing %q in:\n%s", want, script)
+		}
+	}
+	// 删除旧元素和添加新元素在同一个nft -f批次中提交
+	if strings.Count(script, "| nft -f -") != 2 {
+		t.Errorf("expected table creation and one element batch:\n%s", script)
+	}
+	if strings.Index(script, "'destroy element inet oneclickvirt ranges4 { udp . 20000-20999 }'") >
+		strings.Index(script, "'add element inet oneclickvirt ports4 { tcp . 10022 : 10.0.0.5 . 22 }'") {
+		t.Errorf("old elements must be destroyed before new ones are added:\n%s", script)
+	}
+	// 内外端口一致的端口段只生成一个区间元素
+	if strings.Count(script, "add element inet oneclickvirt ranges4") != 2 {
+		t.Errorf("expected one range element per protocol:\n%s", script)
+	}
+}
+
+func TestRemoveCommandsByFamily(t *testing.T) {
+	script := strings.Join(RemoveCommands([]Mapping{{Protocol: "tcp", HostPort: 10022, GuestPort: 22}}), "\n")
+	if !strings.Contains(script, "'destroy element inet oneclickvirt ports4 { tcp . 10022 }'") {
+		t.Errorf("missing ipv4 element removal in:\n%s", script)
+	}
+	// 未指定目标地址时按IPv4处理，不能误删同端口的IPv6映射
+	if strings.Contains(script, "ports6") {
+		t.Errorf("ipv4 removal must not touch ipv6 mappings:\n%s", script)
+	}
+	if strings.Contains(script, "add element") {
+		t.Errorf("remove commands must not add elements:\n%s", script)
+	}
+
+	script = strings.Join(RemoveCommands([]Mapping{{Protocol: "tcp", HostPort: 10022, GuestPort: 22, TargetIP: "2001:db8::5"}}), "\n")
+	if !strings.Contains(script, "'destroy element inet oneclickvirt ports6 { tcp . 10022 }'") || strings.Contains(script, "ports4") {
+		t.Errorf("ipv6 removal must only touch ipv6 mappings:\n%s", script)
+	}
+}
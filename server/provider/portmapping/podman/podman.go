@@ -0,0 +1,339 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"oneclickvirt/global"
+	"oneclickvirt/model/provider"
+	"oneclickvirt/provider/portmapping"
+	providerService "oneclickvirt/service/provider"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// PodmanPortMapping Podman端口映射实现
+// 端口在 podman run -p 时固定，变更端口需要重建容器；Podman Provider会先commit保留容器数据再按完整端口列表重建，
+// 因此每次变更都以数据库中的端口记录为准计算完整列表
+type PodmanPortMapping struct {
+	*portmapping.BaseProvider
+}
+
+// portUpdater Podman Provider提供的端口重建能力
+type portUpdater interface {
+	UpdatePortMappings(ctx context.Context, instanceName string, ports []string) error
+}
+
+// NewPodmanPortMapping 创建Podman端口映射Provider
+func NewPodmanPortMapping(config *portmapping.ManagerConfig) portmapping.PortMappingProvider {
+	return &PodmanPortMapping{
+		BaseProvider: portmapping.NewBaseProvider("podman", config),
+	}
+}
+
+// SupportsDynamicMapping Podman不支持动态端口映射，变更需要重建容器
+func (p *PodmanPortMapping) SupportsDynamicMapping() bool {
+	return false
+}
+
+// CreatePortMapping 创建Podman端口映射
+func (p *PodmanPortMapping) CreatePortMapping(ctx context.Context, req *portmapping.PortMappingRequest) (*portmapping.PortMappingResult, error) {
+	global.APP_LOG.Info("Creating Podman port mapping",
+		zap.String("instanceId", req.InstanceID),
+		zap.Int("hostPort", req.HostPort),
+		zap.Int("guestPort", req.GuestPort),
+		zap.String("protocol", req.Protocol))
+
+	if err := p.validateRequest(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %v", err)
+	}
+
+	instance, err := p.getInstance(req.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance: %v", err)
+	}
+	providerInfo, err := p.getProvider(req.ProviderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %v", err)
+	}
+
+	hostPort := req.HostPort
+	if hostPort == 0 {
+		hostPort, err = p.BaseProvider.AllocatePort(ctx, req.ProviderID, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate port: %v", err)
+		}
+	}
+
+	newPort := provider.Port{HostPort: hostPort, GuestPort: req.GuestPort, Protocol: strings.ToLower(req.Protocol)}
+	ports, err := p.desiredPorts(instance.ID, 0, &newPort)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.applyPorts(ctx, providerInfo, instance, ports); err != nil {
+		return nil, fmt.Errorf("failed to create podman port mapping: %v", err)
+	}
+
+	isSSH := req.GuestPort == 22
+	if req.IsSSH != nil {
+		isSSH = *req.IsSSH
+	}
+
+	result := &portmapping.PortMappingResult{
+		InstanceID:    req.InstanceID,
+		ProviderID:    req.ProviderID,
+		Protocol:      strings.ToLower(req.Protocol),
+		HostPort:      hostPort,
+		GuestPort:     req.GuestPort,
+		HostIP:        providerInfo.Endpoint,
+		PublicIP:      p.getPublicIP(providerInfo),
+		IPv6Address:   req.IPv6Address,
+		Status:        "active",
+		Description:   req.Description,
+		MappingMethod: "podman-native",
+		IsSSH:         isSSH,
+		IsAutomatic:   req.HostPort == 0,
+	}
+
+	portModel := p.BaseProvider.ToDBModel(result)
+	if err := global.APP_DB.Create(portModel).Error; err != nil {
+		global.APP_LOG.Error("Failed to save port mapping to database", zap.Error(err))
+		// 按数据库中原有端口重建，撤销新增的映射
+		if rollbackPorts, listErr := p.desiredPorts(instance.ID, 0, nil); listErr == nil {
+			p.applyPorts(ctx, providerInfo, instance, rollbackPorts)
+		}
+		return nil, fmt.Errorf("failed to save port mapping: %v", err)
+	}
+
+	result.ID = portModel.ID
+	result.CreatedAt = portModel.CreatedAt.Format("2006-01-02T15:04:05Z07:00")
+	result.UpdatedAt = portModel.UpdatedAt.Format("2006-01-02T15:04:05Z07:00")
+
+	global.APP_LOG.Info("Podman port mapping created successfully",
+		zap.Uint("id", result.ID),
+		zap.Int("hostPort", hostPort),
+		zap.Int("guestPort", req.GuestPort))
+
+	return result, nil
+}
+
+// DeletePortMapping 删除Podman端口映射
+func (p *PodmanPortMapping) DeletePortMapping(ctx context.Context, req *portmapping.DeletePortMappingRequest) error {
+	global.APP_LOG.Info("Deleting Podman port mapping",
+		zap.Uint("id", req.ID),
+		zap.String("instanceId", req.InstanceID))
+
+	var portModel provider.Port
+	if err := global.APP_DB.First(&portModel, req.ID).Error; err != nil {
+		return fmt.Errorf("port mapping not found: %v", err)
+	}
+
+	instance, err := p.getInstance(req.InstanceID)
+	if err == nil {
+		var providerInfo *provider.Provider
+		if providerInfo, err = p.getProvider(portModel.ProviderID); err == nil {
+			var ports []string
+			if ports, err = p.desiredPorts(instance.ID, portModel.ID, nil); err == nil {
+				err = p.applyPorts(ctx, providerInfo, instance, ports)
+			}
+		}
+	}
+	if err != nil {
+		if !req.ForceDelete {
+			return fmt.Errorf("failed to remove podman port mapping: %v", err)
+		}
+		global.APP_LOG.Warn("Failed to remove podman port mapping, but force delete is enabled", zap.Error(err))
+	}
+
+	if err := global.APP_DB.Delete(&portModel).Error; err != nil {
+		return fmt.Errorf("failed to delete port mapping from database: %v", err)
+	}
+
+	global.APP_LOG.Info("Podman port mapping deleted successfully", zap.Uint("id", req.ID))
+	return nil
+}
+
+// UpdatePortMapping 更新Podman端口映射，端口变化时重建容器
+func (p *PodmanPortMapping) UpdatePortMapping(ctx context.Context, req *portmapping.UpdatePortMappingRequest) (*portmapping.PortMappingResult, error) {
+	global.APP_LOG.Info("Updating Podman port mapping", zap.Uint("id", req.ID))
+
+	var portModel provider.Port
+	if err := global.APP_DB.First(&portModel, req.ID).Error; err != nil {
+		return nil, fmt.Errorf("port mapping not found: %v", err)
+	}
+	providerInfo, err := p.getProvider(portModel.ProviderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %v", err)
+	}
+
+	if req.Protocol == "" {
+		req.Protocol = portModel.Protocol
+	}
+	if req.HostPort != portModel.HostPort || req.GuestPort != portModel.GuestPort || req.Protocol != portModel.Protocol {
+		if err := portmapping.ValidateProtocol(req.Protocol); err != nil {
+			return nil, err
+		}
+		instance, err := p.getInstance(strconv.FormatUint(uint64(portModel.InstanceID), 10))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get instance: %v", err)
+		}
+		changed := provider.Port{HostPort: req.HostPort, GuestPort: req.GuestPort, Protocol: req.Protocol}
+		ports, err := p.desiredPorts(instance.ID, portModel.ID, &changed)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.applyPorts(ctx, providerInfo, instance, ports); err != nil {
+			return nil, fmt.Errorf("failed to update podman port mapping: %v", err)
+		}
+	}
+
+	updates := map[string]interface{}{
+		"host_port":   req.HostPort,
+		"guest_port":  req.GuestPort,
+		"protocol":    req.Protocol,
+		"description": req.Description,
+		"status":      req.Status,
+	}
+	if err := global.APP_DB.Model(&portModel).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update port mapping: %v", err)
+	}
+	if err := global.APP_DB.First(&portModel, req.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get updated port mapping: %v", err)
+	}
+
+	result := p.BaseProvider.FromDBModel(&portModel)
+	result.HostIP = providerInfo.Endpoint
+	result.PublicIP = p.getPublicIP(providerInfo)
+	result.MappingMethod = "podman-native"
+
+	global.APP_LOG.Info("Podman port mapping updated successfully", zap.Uint("id", req.ID))
+	return result, nil
+}
+
+// ListPortMappings 列出Podman端口映射
+func (p *PodmanPortMapping) ListPortMappings(ctx context.Context, instanceID string) ([]*portmapping.PortMappingResult, error) {
+	var ports []provider.Port
+	if err := global.APP_DB.Where("instance_id = ?", instanceID).Find(&ports).Error; err != nil {
+		return nil, fmt.Errorf("failed to list port mappings: %v", err)
+	}
+
+	var results []*portmapping.PortMappingResult
+	for _, port := range ports {
+		result := p.BaseProvider.FromDBModel(&port)
+		result.MappingMethod = "podman-native"
+		if providerInfo, err := p.getProvider(port.ProviderID); err == nil {
+			result.HostIP = providerInfo.Endpoint
+			result.PublicIP = p.getPublicIP(providerInfo)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// desiredPorts 根据数据库中的有效端口计算容器应有的完整端口列表
+// excludeID 为需要排除的端口记录（删除或修改），extra 为新增或修改后的端口
+func (p *PodmanPortMapping) desiredPorts(instanceID, excludeID uint, extra *provider.Port) ([]string, error) {
+	var ports []provider.Port
+	if err := global.APP_DB.Where("instance_id = ? AND status = ?", instanceID, "active").Find(&ports).Error; err != nil {
+		return nil, fmt.Errorf("failed to list port mappings: %v", err)
+	}
+	if extra != nil {
+		ports = append(ports, *extra)
+	}
+
+	var result []string
+	for _, port := range ports {
+		if excludeID != 0 && port.ID == excludeID {
+			continue
+		}
+		hostPort := strconv.Itoa(port.HostPort)
+		guestPort := strconv.Itoa(port.GuestPort)
+		if port.HostPortEnd > port.HostPort {
+			hostPort = fmt.Sprintf("%d-%d", port.HostPort, port.HostPortEnd)
+		}
+		if port.GuestPortEnd > port.GuestPort {
+			guestPort = fmt.Sprintf("%d-%d", port.GuestPort, port.GuestPortEnd)
+		}
+		protocol := port.Protocol
+		if protocol == "" {
+			protocol = "both"
+		}
+		result = append(result, fmt.Sprintf("0.0.0.0:%s:%s/%s", hostPort, guestPort, protocol))
+	}
+	return result, nil
+}
+
+// applyPorts 通过已连接的Podman Provider按完整端口列表重建容器
+func (p *PodmanPortMapping) applyPorts(ctx context.Context, providerInfo *provider.Provider, instance *provider.Instance, ports []string) error {
+	providerInstance, exists := providerService.GetProviderService().GetProviderByID(providerInfo.ID)
+	if !exists || !providerInstance.IsConnected() {
+		return fmt.Errorf("Podman Provider %s 未连接", providerInfo.Name)
+	}
+	updater, ok := providerInstance.(portUpdater)
+	if !ok {
+		return fmt.Errorf("provider %s does not support port updates", providerInfo.Name)
+	}
+	return updater.UpdatePortMappings(ctx, instance.Name, ports)
+}
+
+// validateRequest 验证请求参数
+func (p *PodmanPortMapping) validateRequest(req *portmapping.PortMappingRequest) error {
+	if req.InstanceID == "" {
+		return fmt.Errorf("instance ID is required")
+	}
+	if req.GuestPort <= 0 || req.GuestPort > 65535 {
+		return fmt.Errorf("invalid guest port: %d", req.GuestPort)
+	}
+	if req.HostPort < 0 || req.HostPort > 65535 {
+		return fmt.Errorf("invalid host port: %d", req.HostPort)
+	}
+	if req.Protocol == "" {
+		req.Protocol = "tcp"
+	}
+	return portmapping.ValidateProtocol(req.Protocol)
+}
+
+// getInstance 获取实例信息
+func (p *PodmanPortMapping) getInstance(instanceID string) (*provider.Instance, error) {
+	var instance provider.Instance
+	id, err := strconv.ParseUint(instanceID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid instance ID: %s", instanceID)
+	}
+
+	if err := global.APP_DB.First(&instance, uint(id)).Error; err != nil {
+		return nil, fmt.Errorf("instance not found: %v", err)
+	}
+
+	return &instance, nil
+}
+
+// getProvider 获取Provider信息
+func (p *PodmanPortMapping) getProvider(providerID uint) (*provider.Provider, error) {
+	var providerInfo provider.Provider
+	if err := global.APP_DB.First(&providerInfo, providerID).Error; err != nil {
+		return nil, fmt.Errorf("provider not found: %v", err)
+	}
+	return &providerInfo, nil
+}
+
+// getPublicIP 获取公网IP，优先使用端口映射专用IP
+func (p *PodmanPortMapping) getPublicIP(providerInfo *provider.Provider) string {
+	endpoint := providerInfo.PortIP
+	if endpoint == "" {
+		endpoint = providerInfo.Endpoint
+	}
+	if idx := strings.LastIndex(endpoint, ":"); idx > 0 && strings.Count(endpoint, ":") == 1 {
+		return endpoint[:idx]
+	}
+	return endpoint
+}
+
+// init 注册Podman端口映射Provider
+func init() {
+	portmapping.RegisterProvider("podman", func(config *portmapping.ManagerConfig) portmapping.PortMappingProvider {
+		return NewPodmanPortMapping(config)
+	})
+}
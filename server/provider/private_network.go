@@ -0,0 +1,172 @@
+package provider
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// PrivateNetworkSpec 私有网络操作参数
+type PrivateNetworkSpec struct {
+	Name         string // Provider上的网络名
+	CIDR         string // 网段
+	VlanID       int    // VLAN标签，仅Proxmox使用
+	InstanceType string // 接入实例的类型：container, vm
+	Instance     string // 接入、断开的目标实例名
+	Device       string // 已接入时实例上的网卡设备名
+	IPAddress    string // 实例在私有网络中的地址
+}
+
+// PrivateNetworkProvider 支持用户私有网络的Provider实现此接口
+// 私有网络只在单个节点内二层互通，不做NAT，也不路由到其他网络
+type PrivateNetworkProvider interface {
+	// CreatePrivateNetwork 在节点上创建私有网络
+	CreatePrivateNetwork(ctx context.Context, spec PrivateNetworkSpec) error
+	// DeletePrivateNetwork 删除没有实例接入的私有网络
+	DeletePrivateNetwork(ctx context.Context, spec PrivateNetworkSpec) error
+	// AttachPrivateNetwork 为spec.Instance添加接入私有网络的网卡，返回网卡设备名
+	AttachPrivateNetwork(ctx context.Context, spec PrivateNetworkSpec) (string, error)
+	// DetachPrivateNetwork 移除spec.Instance上接入私有网络的网卡
+	DetachPrivateNetwork(ctx context.Context, spec PrivateNetworkSpec) error
+}
+
+// 私有网络网段允许的前缀长度范围
+const (
+	PrivateNetworkMinPrefix = 16
+	PrivateNetworkMaxPrefix = 29
+)
+
+// ParsePrivateNetworkCIDR 解析私有网络网段，只允许RFC1918的IPv4地址，返回规范化后的网段
+func ParsePrivateNetworkCIDR(cidr string) (*net.IPNet, error) {
+	ip, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("网段格式错误: %s", cidr)
+	}
+	if ip.To4() == nil || !ip.IsPrivate() {
+		return nil, fmt.Errorf("网段必须是私有IPv4地址段（10.0.0.0/8、172.16.0.0/12、192.168.0.0/16）")
+	}
+	ones, _ := ipNet.Mask.Size()
+	if ones < PrivateNetworkMinPrefix || ones > PrivateNetworkMaxPrefix {
+		return nil, fmt.Errorf("网段前缀长度必须在 /%d 到 /%d 之间", PrivateNetworkMinPrefix, PrivateNetworkMaxPrefix)
+	}
+	return ipNet, nil
+}
+
+// PrivateNetworkGateway 网段的第一个可用地址，LXD和Docker的网桥使用该地址，不分配给实例
+func PrivateNetworkGateway(ipNet *net.IPNet) string {
+	return uint32ToIP(ipToUint32(ipNet.IP) + 1).String()
+}
+
+// PrivateNetworksOverlap 判断两个网段是否重叠
+func PrivateNetworksOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// ValidatePrivateNetworkIP 校验实例地址在网段内，且不是网络地址、网关或广播地址
+func ValidatePrivateNetworkIP(ipNet *net.IPNet, address string) error {
+	ip := net.ParseIP(address).To4()
+	if ip == nil || !ipNet.Contains(ip) {
+		return fmt.Errorf("地址 %s 不在网段 %s 内", address, ipNet.String())
+	}
+	first, last := privateNetworkHostRange(ipNet)
+	if n := ipToUint32(ip); n < first || n > last {
+		return fmt.Errorf("地址 %s 是保留地址", address)
+	}
+	return nil
+}
+
+// NextPrivateNetworkIP 分配网段内第一个未使用的实例地址
+func NextPrivateNetworkIP(ipNet *net.IPNet, used []string) (string, error) {
+	usedSet := make(map[string]bool, len(used))
+	for _, address := range used {
+		usedSet[address] = true
+	}
+	first, last := privateNetworkHostRange(ipNet)
+	for n := first; n <= last; n++ {
+		address := uint32ToIP(n).String()
+		if !usedSet[address] {
+			return address, nil
+		}
+	}
+	return "", fmt.Errorf("网段 %s 中没有可用地址", ipNet.String())
+}
+
+// HostRouteCommand 列出节点上全部IPv4路由的命令，包含网桥直连网段和本机地址
+const HostRouteCommand = "ip -4 route show table all"
+
+// defaultInstanceNetworks 各类型节点默认的实例网段，网桥尚未创建时也不允许私有网络占用
+// Proxmox为内部NAT网桥vmbr1的网段，与proxmox.InternalIPPrefix保持一致
+var defaultInstanceNetworks = map[string][]string{
+	"docker":  {"172.17.0.0/16"},
+	"proxmox": {"172.16.1.0/24"},
+}
+
+// ParseHostRoutes 解析ip route输出中的目标网段，跳过默认路由，单个地址按/32处理
+func ParseHostRoutes(output string) []*net.IPNet {
+	var routes []*net.IPNet
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		dest := fields[0]
+		// table all 的输出中local、broadcast等路由类型位于目标之前
+		switch dest {
+		case "local", "broadcast", "unicast", "unreachable", "blackhole", "prohibit", "throw", "anycast", "multicast":
+			if len(fields) < 2 {
+				continue
+			}
+			dest = fields[1]
+		}
+		if dest == "default" {
+			continue
+		}
+		if !strings.Contains(dest, "/") {
+			dest += "/32"
+		}
+		if _, ipNet, err := net.ParseCIDR(dest); err == nil && ipNet.IP.To4() != nil {
+			routes = append(routes, ipNet)
+		}
+	}
+	return routes
+}
+
+// CheckPrivateNetworkHostConflict 检查私有网络网段是否与节点默认实例网段或节点已有路由重叠
+func CheckPrivateNetworkHostConflict(ipNet *net.IPNet, providerType string, hostRoutes []*net.IPNet) error {
+	for _, cidr := range defaultInstanceNetworks[providerType] {
+		if _, defaultNet, err := net.ParseCIDR(cidr); err == nil && PrivateNetworksOverlap(ipNet, defaultNet) {
+			return fmt.Errorf("网段 %s 与节点默认实例网段 %s 冲突，请更换网段", ipNet.String(), defaultNet.String())
+		}
+	}
+	for _, route := range hostRoutes {
+		if PrivateNetworksOverlap(ipNet, route) {
+			return fmt.Errorf("网段 %s 与节点网络 %s 冲突，请更换网段", ipNet.String(), route.String())
+		}
+	}
+	return nil
+}
+
+// PrivateNetworkAddressWithPrefix 实例地址加上网段前缀，如 10.10.0.2/24
+func PrivateNetworkAddressWithPrefix(ipNet *net.IPNet, address string) string {
+	ones, _ := ipNet.Mask.Size()
+	return fmt.Sprintf("%s/%d", address, ones)
+}
+
+// privateNetworkHostRange 可分配给实例的地址范围，跳过网络地址、网关和广播地址
+func privateNetworkHostRange(ipNet *net.IPNet) (uint32, uint32) {
+	network := ipToUint32(ipNet.IP)
+	broadcast := network | ^binary.BigEndian.Uint32(net.IP(ipNet.Mask).To4())
+	return network + 2, broadcast - 1
+}
+
+func ipToUint32(ip net.IP) uint32 {
+	return binary.BigEndian.Uint32(ip.To4())
+}
+
+func uint32ToIP(n uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, n)
+	return ip
+}
@@ -0,0 +1,85 @@
+package provider
+
+import "testing"
+
+func TestParsePrivateNetworkCIDR(t *testing.T) {
+	ipNet, err := ParsePrivateNetworkCIDR("10.10.0.5/24")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if ipNet.String() != "10.10.0.0/24" || PrivateNetworkGateway(ipNet) != "10.10.0.1" {
+		t.Fatalf("unexpected network: %s gateway %s", ipNet, PrivateNetworkGateway(ipNet))
+	}
+
+	for _, cidr := range []string{"8.8.8.0/24", "10.0.0.0/8", "192.168.1.0/30", "fd00::/64", "10.1.1.1"} {
+		if _, err := ParsePrivateNetworkCIDR(cidr); err == nil {
+			t.Errorf("expected %s to be rejected", cidr)
+		}
+	}
+
+	other, _ := ParsePrivateNetworkCIDR("10.10.0.128/25")
+	if !PrivateNetworksOverlap(ipNet, other) {
+		t.Errorf("expected overlap between %s and %s", ipNet, other)
+	}
+	other, _ = ParsePrivateNetworkCIDR("10.10.1.0/24")
+	if PrivateNetworksOverlap(ipNet, other) {
+		t.Errorf("unexpected overlap between %s and %s", ipNet, other)
+	}
+}
+
+func TestPrivateNetworkAddressAllocation(t *testing.T) {
+	ipNet, _ := ParsePrivateNetworkCIDR("192.168.50.0/29")
+
+	address, err := NextPrivateNetworkIP(ipNet, []string{"192.168.50.2"})
+	if err != nil || address != "192.168.50.3" {
+		t.Fatalf("unexpected address %q: %v", address, err)
+	}
+	if _, err := NextPrivateNetworkIP(ipNet, []string{"192.168.50.2", "192.168.50.3", "192.168.50.4", "192.168.50.5", "192.168.50.6"}); err == nil {
+		t.Fatal("expected exhausted network")
+	}
+
+	if err := ValidatePrivateNetworkIP(ipNet, "192.168.50.6"); err != nil {
+		t.Errorf("expected valid address: %v", err)
+	}
+	for _, address := range []string{"192.168.50.0", "192.168.50.1", "192.168.50.7", "192.168.51.2"} {
+		if err := ValidatePrivateNetworkIP(ipNet, address); err == nil {
+			t.Errorf("expected %s to be rejected", address)
+		}
+	}
+	if got := PrivateNetworkAddressWithPrefix(ipNet, "192.168.50.3"); got != "192.168.50.3/29" {
+		t.Errorf("unexpected address with prefix: %s", got)
+	}
+}
+
+func TestCheckPrivateNetworkHostConflict(t *testing.T) {
+	routes := ParseHostRoutes(`default via 192.0.2.1 dev eth0 proto static
+10.89.12.0/24 dev lxdbr0 proto kernel scope link src 10.89.12.1
+local 10.89.12.1 dev lxdbr0 table local proto kernel scope host src 10.89.12.1
+broadcast 10.89.12.255 dev lxdbr0 table local proto kernel scope link src 10.89.12.1
+192.168.50.0/24 via 10.89.12.254 dev lxdbr0`)
+	if len(routes) != 4 {
+		t.Fatalf("unexpected routes: %v", routes)
+	}
+
+	for _, tc := range []struct {
+		cidr         string
+		providerType string
+		conflict     bool
+	}{
+		{"10.89.0.0/16", "lxd", true},
+		{"192.168.50.128/25", "incus", true},
+		{"172.17.5.0/24", "docker", true},
+		{"172.16.1.0/24", "proxmox", true},
+		{"172.17.5.0/24", "lxd", false},
+		{"10.90.0.0/24", "docker", false},
+	} {
+		ipNet, err := ParsePrivateNetworkCIDR(tc.cidr)
+		if err != nil {
+			t.Fatalf("parse %s failed: %v", tc.cidr, err)
+		}
+		err = CheckPrivateNetworkHostConflict(ipNet, tc.providerType, routes)
+		if (err != nil) != tc.conflict {
+			t.Errorf("%s on %s: conflict=%v, got err %v", tc.cidr, tc.providerType, tc.conflict, err)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"sync"
 
@@ -15,6 +16,9 @@ type Instance = provider.ProviderInstance
 type Image = provider.ProviderImage
 type InstanceConfig = provider.ProviderInstanceConfig
 type NodeConfig = provider.ProviderNodeConfig
+type Snapshot = provider.ProviderSnapshot
+type ResizeSpec = provider.ProviderResizeSpec
+type Capabilities = provider.ProviderCapabilities
 
 // ProgressCallback 进度回调函数类型
 type ProgressCallback func(percentage int, message string)
@@ -26,6 +30,10 @@ type Provider interface {
 	GetName() string
 	GetSupportedInstanceTypes() []string // 获取支持的实例类型
 
+	// 能力探测：Connect时探测一次，健康检查时由服务层调用ProbeCapabilities刷新，GetCapabilities返回最近一次结果
+	ProbeCapabilities(ctx context.Context) (Capabilities, error)
+	GetCapabilities() Capabilities
+
 	// 实例管理
 	ListInstances(ctx context.Context) ([]Instance, error)
 	CreateInstance(ctx context.Context, config InstanceConfig) error
@@ -57,6 +65,21 @@ type Provider interface {
 	SetInstancePassword(ctx context.Context, instanceID, password string) error
 	ResetInstancePassword(ctx context.Context, instanceID string) (string, error)
 
+	// 配置调整
+	ResizeInstance(ctx context.Context, instanceID string, spec ResizeSpec) error
+
+	// 快照管理
+	CreateSnapshot(ctx context.Context, instanceID, snapshotName string) error
+	ListSnapshots(ctx context.Context, instanceID string) ([]Snapshot, error)
+	RestoreSnapshot(ctx context.Context, instanceID, snapshotName string) error
+	DeleteSnapshot(ctx context.Context, instanceID, snapshotName string) error
+
+	// 迁移：导出归档写入w，导入时从r读取同类型Provider导出的归档
+	ExportInstance(ctx context.Context, instanceID string, w io.Writer, progressCallback ProgressCallback) error
+	ImportInstance(ctx context.Context, instanceName, instanceType string, r io.Reader, progressCallback ProgressCallback) error
+	// 备份恢复：用ExportInstance生成的归档原地覆盖已有实例，恢复后实例保持停止状态
+	RestoreInstance(ctx context.Context, instanceID string, r io.Reader, progressCallback ProgressCallback) error
+
 	// SSH命令执行
 	ExecuteSSHCommand(ctx context.Context, command string) (string, error)
 }
@@ -164,6 +164,12 @@ func (p *ProxmoxProvider) apiCreateInstanceWithProgress(ctx context.Context, con
 		if err := p.apiCreateVM(ctx, vmid, config, updateProgress); err != nil {
 			return fmt.Errorf("API创建虚拟机失败: %w", err)
 		}
+		// cicustom片段需要写入宿主机文件，通过SSH完成
+		if config.UserData != "" {
+			if err := p.setCloudInitUserData(vmid, config.UserData); err != nil {
+				global.APP_LOG.Warn("设置cloud-init user-data失败", zap.Int("vmid", vmid), zap.Error(err))
+			}
+		}
 	}
 
 	updateProgress(90, "配置网络和启动...")
@@ -0,0 +1,28 @@
+package proxmox
+
+import (
+	"context"
+	"fmt"
+
+	"oneclickvirt/provider"
+)
+
+// ProbeCapabilities 探测Proxmox节点能力
+// LXC容器以unprivileged方式创建并固定开启nesting；IPv6环境检查与创建带IPv6实例时一致；迁移依赖SSH执行规则
+func (p *ProxmoxProvider) ProbeCapabilities(ctx context.Context) (provider.Capabilities, error) {
+	if !p.connected || p.sshClient == nil {
+		return p.GetCapabilities(), fmt.Errorf("provider not connected")
+	}
+
+	return p.StoreCapabilities(provider.Capabilities{
+		InstanceTypes:  p.GetSupportedInstanceTypes(),
+		IPv6:           p.checkIPv6Environment(ctx) == nil,
+		Snapshot:       true,
+		Backup:         true,
+		Migration:      p.shouldUseSSH(),
+		DiskResize:     true,
+		DiskQuota:      true,
+		BandwidthLimit: true,
+		Nesting:        true,
+	}), nil
+}
@@ -0,0 +1,361 @@
+package proxmox
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// clusterGuest /cluster/resources 中的虚拟机或容器
+type clusterGuest struct {
+	VMID   int    `json:"vmid"`
+	Name   string `json:"name"`
+	Node   string `json:"node"`
+	Type   string `json:"type"` // qemu, lxc
+	Status string `json:"status"`
+}
+
+// parseClusterNodes 解析 `pvesh get /cluster/resources --type node` 与 `/cluster/status` 的输出
+// status输出用于补充节点IP，解析失败时忽略
+func parseClusterNodes(resourcesOutput, statusOutput string) ([]provider.ClusterNode, error) {
+	var resources []struct {
+		Node    string  `json:"node"`
+		Status  string  `json:"status"`
+		CPU     float64 `json:"cpu"`
+		MaxCPU  int     `json:"maxcpu"`
+		Mem     int64   `json:"mem"`
+		MaxMem  int64   `json:"maxmem"`
+		Disk    int64   `json:"disk"`
+		MaxDisk int64   `json:"maxdisk"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(resourcesOutput)), &resources); err != nil {
+		return nil, fmt.Errorf("解析集群节点失败: %w", err)
+	}
+
+	var status []struct {
+		Type  string `json:"type"`
+		Name  string `json:"name"`
+		IP    string `json:"ip"`
+		Local int    `json:"local"`
+	}
+	_ = json.Unmarshal([]byte(strings.TrimSpace(statusOutput)), &status)
+	ips := make(map[string]string, len(status))
+	local := ""
+	for _, s := range status {
+		if s.Type != "node" {
+			continue
+		}
+		ips[s.Name] = s.IP
+		if s.Local == 1 {
+			local = s.Name
+		}
+	}
+
+	nodes := make([]provider.ClusterNode, 0, len(resources))
+	for _, r := range resources {
+		if r.Node == "" {
+			continue
+		}
+		nodes = append(nodes, provider.ClusterNode{
+			Name:      r.Node,
+			IP:        ips[r.Node],
+			Online:    r.Status == "online",
+			CPUUsage:  r.CPU,
+			MaxCPU:    r.MaxCPU,
+			Memory:    r.Mem / 1024 / 1024,
+			MaxMemory: r.MaxMem / 1024 / 1024,
+			Disk:      r.Disk / 1024 / 1024,
+			MaxDisk:   r.MaxDisk / 1024 / 1024,
+			Local:     r.Node == local,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes, nil
+}
+
+// parseClusterGuests 解析 `pvesh get /cluster/resources --type vm` 的输出
+func parseClusterGuests(output string) ([]clusterGuest, error) {
+	var guests []clusterGuest
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &guests); err != nil {
+		return nil, fmt.Errorf("解析集群实例失败: %w", err)
+	}
+	return guests, nil
+}
+
+// clusterNodeLoad 节点负载评分，内存占比权重最高，越小越空闲
+func clusterNodeLoad(node provider.ClusterNode) float64 {
+	ratio := func(used, total int64) float64 {
+		if total <= 0 {
+			return 1
+		}
+		return float64(used) / float64(total)
+	}
+	return ratio(node.Memory, node.MaxMemory)*0.6 + node.CPUUsage*0.3 + ratio(node.Disk, node.MaxDisk)*0.1
+}
+
+// pickClusterNode 选择放置节点：指定节点时校验其在线，否则在剩余内存足够的在线节点中选负载最低的
+func pickClusterNode(nodes []provider.ClusterNode, pinned string, memoryMB int64) (string, error) {
+	if pinned != "" {
+		for _, node := range nodes {
+			if node.Name == pinned {
+				if !node.Online {
+					return "", fmt.Errorf("指定的节点 %s 不在线", pinned)
+				}
+				return pinned, nil
+			}
+		}
+		return "", fmt.Errorf("集群中不存在节点 %s", pinned)
+	}
+
+	best := ""
+	bestLoad := 0.0
+	for _, node := range nodes {
+		if !node.Online {
+			continue
+		}
+		if memoryMB > 0 && node.MaxMemory-node.Memory < memoryMB {
+			continue
+		}
+		if load := clusterNodeLoad(node); best == "" || load < bestLoad {
+			best, bestLoad = node.Name, load
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("集群中没有可用内存足够的在线节点")
+	}
+	return best, nil
+}
+
+// clusterNodes 获取集群节点列表，在任意节点执行均返回整个集群的数据
+func (p *ProxmoxProvider) clusterNodes(ctx context.Context) ([]provider.ClusterNode, error) {
+	output, err := p.sshClient.Execute("pvesh get /cluster/resources --type node --output-format json")
+	if err != nil {
+		return nil, fmt.Errorf("获取集群节点失败: %w", err)
+	}
+	status, _ := p.sshClient.Execute("pvesh get /cluster/status --output-format json")
+	return parseClusterNodes(output, status)
+}
+
+// clusterGuests 获取整个集群的虚拟机和容器
+func (p *ProxmoxProvider) clusterGuests(ctx context.Context) ([]clusterGuest, error) {
+	output, err := p.sshClient.Execute("pvesh get /cluster/resources --type vm --output-format json")
+	if err != nil {
+		return nil, fmt.Errorf("获取集群实例失败: %w", err)
+	}
+	return parseClusterGuests(output)
+}
+
+// ListClusterNodes 返回集群各节点的容量、负载和在线状态
+func (p *ProxmoxProvider) ListClusterNodes(ctx context.Context) ([]provider.ClusterNode, error) {
+	if !p.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+	nodes, err := p.clusterNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if guests, err := p.clusterGuests(ctx); err == nil {
+		counts := make(map[string]int)
+		for _, guest := range guests {
+			counts[guest.Node]++
+		}
+		for i := range nodes {
+			nodes[i].Instances = counts[nodes[i].Name]
+		}
+	}
+	for i := range nodes {
+		nodes[i].Local = nodes[i].Name == p.node
+	}
+	return nodes, nil
+}
+
+// SelectClusterNode 为新实例选择节点，非集群时返回空字符串
+func (p *ProxmoxProvider) SelectClusterNode(ctx context.Context, config provider.InstanceConfig) (string, error) {
+	if !p.connected {
+		return "", fmt.Errorf("not connected")
+	}
+	if !p.clustered {
+		return "", nil
+	}
+	nodes, err := p.clusterNodes(ctx)
+	if err != nil {
+		return "", err
+	}
+	node, err := pickClusterNode(nodes, config.Node, provider.ParseSizeMB(config.Memory))
+	if err != nil {
+		return "", err
+	}
+	global.APP_LOG.Info("Proxmox集群选择放置节点",
+		zap.String("instance", utils.TruncateString(config.Name, 50)),
+		zap.String("pinned", config.Node),
+		zap.String("node", node))
+	return node, nil
+}
+
+// detectCluster 连接后检测是否为多节点集群
+func (p *ProxmoxProvider) detectCluster(ctx context.Context) {
+	nodes, err := p.clusterNodes(ctx)
+	if err != nil {
+		global.APP_LOG.Debug("检测Proxmox集群失败，按单节点处理", zap.Error(err))
+		return
+	}
+	p.clustered = len(nodes) > 1
+	if p.clustered {
+		names := make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			names = append(names, node.Name)
+		}
+		global.APP_LOG.Info("检测到Proxmox集群",
+			zap.String("provider", p.config.Name),
+			zap.String("localNode", p.node),
+			zap.Strings("nodes", names))
+	}
+}
+
+// root 返回持有SSH连接的Provider，按节点派生的Provider共用它的锁
+func (p *ProxmoxProvider) root() *ProxmoxProvider {
+	if p.cluster != nil {
+		return p.cluster
+	}
+	return p
+}
+
+// onNode 返回在指定节点上执行操作的Provider，命令经集群内部SSH转发到该节点，API请求使用该节点路径
+func (p *ProxmoxProvider) onNode(node string) *ProxmoxProvider {
+	root := p.root()
+	if node == "" || node == root.node {
+		return root
+	}
+	relay := utils.NewSSHClientWithExecutor(utils.SSHConfig{
+		Host:           node,
+		Port:           22,
+		Username:       "root",
+		ExecuteTimeout: time.Duration(root.config.SSHExecuteTimeout) * time.Second,
+	}, utils.CommandExecutorFunc(func(command string) (string, error) {
+		return root.sshClient.Execute(provider.ClusterNodeCommand(node, command))
+	}))
+	return &ProxmoxProvider{
+		config:        root.config,
+		sshClient:     relay,
+		apiClient:     root.apiClient,
+		transport:     root.transport,
+		providerID:    root.providerID,
+		connected:     root.connected,
+		node:          node,
+		providerUUID:  root.providerUUID,
+		healthChecker: root.healthChecker,
+		version:       root.version,
+		clustered:     true,
+		cluster:       root,
+	}
+}
+
+// forInstance 返回实例所在节点对应的Provider，非集群、找不到实例或实例位于连接节点时返回自身
+// 优先使用数据库中记录的节点，记录为空或实例已不在该节点上时再查询整个集群并更新记录
+func (p *ProxmoxProvider) forInstance(ctx context.Context, id string) *ProxmoxProvider {
+	if !p.clustered || p.cluster != nil {
+		return p
+	}
+	stored := p.storedInstanceNode(id)
+	if stored != "" {
+		if _, err := p.sshClient.Execute(guestOnNodeCommand(stored, id)); err == nil {
+			return p.onNode(stored)
+		}
+		global.APP_LOG.Info("实例记录的集群节点已失效，重新查询", zap.String("id", utils.TruncateString(id, 50)), zap.String("node", stored))
+	}
+	guests, err := p.clusterGuests(ctx)
+	if err != nil {
+		global.APP_LOG.Warn("查询实例所在集群节点失败，使用连接节点", zap.String("id", utils.TruncateString(id, 50)), zap.Error(err))
+		return p
+	}
+	for _, guest := range guests {
+		if guest.Name == id || strconv.Itoa(guest.VMID) == id {
+			if stored != "" && guest.Node != stored {
+				p.recordInstanceNode(id, guest.Node)
+			}
+			return p.onNode(guest.Node)
+		}
+	}
+	return p
+}
+
+// storedInstanceNode 数据库中记录的实例所在节点，按VMID调用或找不到记录时返回空字符串
+func (p *ProxmoxProvider) storedInstanceNode(id string) string {
+	var nodes []string
+	if err := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("provider_id = ? AND name = ?", p.providerID, id).
+		Limit(1).Pluck("node", &nodes).Error; err != nil || len(nodes) == 0 {
+		return ""
+	}
+	return nodes[0]
+}
+
+// recordInstanceNode 实例在集群内被迁移到其他节点后更新记录
+func (p *ProxmoxProvider) recordInstanceNode(id, node string) {
+	if err := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("provider_id = ? AND name = ?", p.providerID, id).
+		Update("node", node).Error; err != nil {
+		global.APP_LOG.Warn("更新实例所在集群节点失败", zap.String("id", utils.TruncateString(id, 50)), zap.Error(err))
+	}
+}
+
+// guestOnNodeCommand 检查实例配置是否位于指定节点的命令，/etc/pve为集群共享文件系统，在任意节点执行均可
+func guestOnNodeCommand(node, id string) string {
+	dir := "/etc/pve/nodes/" + node
+	if _, err := strconv.Atoi(id); err == nil {
+		return fmt.Sprintf("test -f %[1]s/qemu-server/%[2]s.conf -o -f %[1]s/lxc/%[2]s.conf", dir, id)
+	}
+	return fmt.Sprintf("grep -qsxE '(name|hostname): %s' %s/qemu-server/*.conf %s/lxc/*.conf", id, dir, dir)
+}
+
+// listClusterInstances 汇总所有在线节点上的实例，节点名写入Metadata
+func (p *ProxmoxProvider) listClusterInstances(ctx context.Context) ([]provider.Instance, error) {
+	nodes, err := p.clusterNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var all []provider.Instance
+	for _, node := range nodes {
+		if !node.Online {
+			continue
+		}
+		instances, err := p.onNode(node.Name).listNodeInstances(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取节点 %s 的实例失败: %w", node.Name, err)
+		}
+		for i := range instances {
+			if instances[i].Metadata == nil {
+				instances[i].Metadata = map[string]string{}
+			}
+			instances[i].Metadata["node"] = node.Name
+		}
+		all = append(all, instances...)
+	}
+	return all, nil
+}
+
+// uploadContent 写入文本文件，转发到其他节点时没有SFTP，改为经命令写入
+func (p *ProxmoxProvider) uploadContent(content, remotePath string, perm os.FileMode) error {
+	if p.cluster == nil {
+		return p.sshClient.UploadContent(content, remotePath, perm)
+	}
+	cmd := fmt.Sprintf("echo %s | base64 -d > %s && chmod %o %s",
+		base64.StdEncoding.EncodeToString([]byte(content)), remotePath, perm, remotePath)
+	if output, err := p.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("%w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return nil
+}
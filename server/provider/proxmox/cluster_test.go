@@ -0,0 +1,78 @@
+package proxmox
+
+import "testing"
+
+const clusterResourcesFixture = `[
+ {"id":"node/pve1","type":"node","node":"pve1","status":"online","cpu":0.10,"maxcpu":16,"mem":8589934592,"maxmem":68719476736,"disk":10737418240,"maxdisk":107374182400},
+ {"id":"node/pve2","type":"node","node":"pve2","status":"online","cpu":0.70,"maxcpu":16,"mem":60129542144,"maxmem":68719476736,"disk":10737418240,"maxdisk":107374182400},
+ {"id":"node/pve3","type":"node","node":"pve3","status":"offline","maxcpu":16,"maxmem":68719476736,"maxdisk":107374182400}
+]`
+
+const clusterStatusFixture = `[
+ {"type":"cluster","name":"lab","nodes":3},
+ {"type":"node","name":"pve2","ip":"10.0.0.2","local":1,"online":1},
+ {"type":"node","name":"pve1","ip":"10.0.0.1","local":0,"online":1}
+]`
+
+func TestParseClusterNodes(t *testing.T) {
+	nodes, err := parseClusterNodes(clusterResourcesFixture, clusterStatusFixture)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(nodes) != 3 {
+		t.Fatalf("expected 3 nodes, got %d", len(nodes))
+	}
+	if nodes[0].Name != "pve1" || nodes[0].IP != "10.0.0.1" || nodes[0].MaxMemory != 65536 || nodes[0].Memory != 8192 {
+		t.Fatalf("unexpected first node: %+v", nodes[0])
+	}
+	if !nodes[1].Local || nodes[2].Online {
+		t.Fatalf("local/online flags not parsed: %+v", nodes)
+	}
+
+	// status输出不可用时仍然返回节点
+	if nodes, err := parseClusterNodes(clusterResourcesFixture, ""); err != nil || len(nodes) != 3 || nodes[0].IP != "" {
+		t.Fatalf("status output should be optional: %v %+v", err, nodes)
+	}
+}
+
+func TestPickClusterNode(t *testing.T) {
+	nodes, err := parseClusterNodes(clusterResourcesFixture, clusterStatusFixture)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		pinned  string
+		memory  int64
+		want    string
+		wantErr bool
+	}{
+		{name: "least loaded", want: "pve1"},
+		{name: "pinned", pinned: "pve2", want: "pve2"},
+		{name: "pinned offline", pinned: "pve3", wantErr: true},
+		{name: "pinned unknown", pinned: "pve9", wantErr: true},
+		{name: "not enough memory", memory: 60 * 1024, wantErr: true},
+	}
+	for _, tc := range cases {
+		got, err := pickClusterNode(nodes, tc.pinned, tc.memory)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got %q", tc.name, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("%s: got %q, %v; want %q", tc.name, got, err, tc.want)
+		}
+	}
+}
+
+func TestGuestOnNodeCommand(t *testing.T) {
+	if got := guestOnNodeCommand("pve2", "105"); got != "test -f /etc/pve/nodes/pve2/qemu-server/105.conf -o -f /etc/pve/nodes/pve2/lxc/105.conf" {
+		t.Errorf("unexpected vmid command: %s", got)
+	}
+	if got := guestOnNodeCommand("pve2", "vm-abc"); got != "grep -qsxE '(name|hostname): vm-abc' /etc/pve/nodes/pve2/qemu-server/*.conf /etc/pve/nodes/pve2/lxc/*.conf" {
+		t.Errorf("unexpected name command: %s", got)
+	}
+}
@@ -0,0 +1,178 @@
+package proxmox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// termproxyKeepAlive termproxy要求客户端定期发送心跳，否则会断开空闲连接
+const termproxyKeepAlive = 30 * time.Second
+
+// consoleProxy termproxy/vncproxy 接口返回的连接信息
+type consoleProxy struct {
+	Port   interface{} `json:"port"` // 不同版本可能返回数字或字符串
+	Ticket string      `json:"ticket"`
+	User   string      `json:"user"`
+}
+
+// OpenConsole 打开实例控制台
+// 串口：配置了API Token时使用 termproxy，否则通过SSH运行 qm terminal / pct console；
+// VNC：只能通过 vncproxy，票据作为浏览器端noVNC的密码
+func (p *ProxmoxProvider) OpenConsole(ctx context.Context, instanceID, consoleType string) (provider.ConsoleSession, error) {
+	if target := p.forInstance(ctx, instanceID); target != p {
+		return target.OpenConsole(ctx, instanceID, consoleType)
+	}
+	if !p.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find instance %s: %w", instanceID, err)
+	}
+
+	switch consoleType {
+	case provider.ConsoleTypeSerial:
+		if p.shouldUseAPI() {
+			session, err := p.apiOpenConsole(ctx, vmid, instanceType, "termproxy")
+			if err == nil {
+				return session, nil
+			}
+			global.APP_LOG.Warn("Proxmox termproxy打开控制台失败", zap.String("vmid", vmid), zap.Error(err))
+			if !p.shouldFallbackToSSH() {
+				return nil, fmt.Errorf("API调用失败且不允许回退到SSH: %w", err)
+			}
+		}
+		if !p.shouldUseSSH() {
+			return nil, fmt.Errorf("执行规则不允许使用SSH")
+		}
+		// 虚拟机需要配置 serial0 才能使用 qm terminal
+		command := fmt.Sprintf("qm terminal %s", vmid)
+		if instanceType == "container" {
+			command = fmt.Sprintf("pct console %s", vmid)
+		}
+		return provider.NewSSHConsole(p.sshClient, command)
+	case provider.ConsoleTypeVNC:
+		if !p.hasAPIAccess() {
+			return nil, fmt.Errorf("VNC控制台需要配置Proxmox API Token")
+		}
+		return p.apiOpenConsole(ctx, vmid, instanceType, "vncproxy")
+	default:
+		return nil, fmt.Errorf("不支持的控制台类型: %s", consoleType)
+	}
+}
+
+// apiOpenConsole 调用 termproxy/vncproxy 获取票据，并连接 vncwebsocket
+func (p *ProxmoxProvider) apiOpenConsole(ctx context.Context, vmid, instanceType, proxyType string) (provider.ConsoleSession, error) {
+	kind := "qemu"
+	if instanceType == "container" {
+		kind = "lxc"
+	}
+	baseURL := fmt.Sprintf("https://%s:8006/api2/json/nodes/%s/%s/%s", p.config.Host, p.node, kind, vmid)
+
+	form := url.Values{}
+	if proxyType == "vncproxy" {
+		form.Set("websocket", "1")
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/"+proxyType, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	p.setAPIAuth(req)
+
+	resp, err := p.apiClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s 调用失败: %d", proxyType, resp.StatusCode)
+	}
+
+	var result struct {
+		Data consoleProxy `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析%s响应失败: %w", proxyType, err)
+	}
+	proxy := result.Data
+	if proxy.Ticket == "" || proxy.Port == nil {
+		return nil, fmt.Errorf("%s 未返回票据", proxyType)
+	}
+
+	wsURL := fmt.Sprintf("wss://%s:8006/api2/json/nodes/%s/%s/%s/vncwebsocket?port=%v&vncticket=%s",
+		p.config.Host, p.node, kind, vmid, proxy.Port, url.QueryEscape(proxy.Ticket))
+	authReq, err := http.NewRequest("GET", wsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	p.setAPIAuth(authReq)
+
+	dialer := websocket.Dialer{TLSClientConfig: p.transport.TLSClientConfig, HandshakeTimeout: 10 * time.Second}
+	conn, _, err := dialer.DialContext(ctx, wsURL, authReq.Header)
+	if err != nil {
+		return nil, fmt.Errorf("连接vncwebsocket失败: %w", err)
+	}
+
+	if proxyType == "vncproxy" {
+		return &provider.WebSocketConsole{Conn: conn, Secret: proxy.Ticket}, nil
+	}
+	return p.startTermproxySession(conn, proxy)
+}
+
+// startTermproxySession 完成termproxy认证并包装其帧格式：
+// 输入 "0:长度:数据"，调整大小 "1:列:行:"，心跳 "2"
+func (p *ProxmoxProvider) startTermproxySession(conn *websocket.Conn, proxy consoleProxy) (provider.ConsoleSession, error) {
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(proxy.User+":"+proxy.Ticket+"\n")); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("termproxy认证失败: %w", err)
+	}
+	_, reply, err := conn.ReadMessage()
+	if err != nil || !strings.HasPrefix(string(reply), "OK") {
+		conn.Close()
+		return nil, fmt.Errorf("termproxy认证失败: %v %s", err, string(reply))
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	done := make(chan struct{})
+	session := &provider.WebSocketConsole{
+		Conn: conn,
+		EncodeInput: func(data []byte) []byte {
+			return append([]byte(fmt.Sprintf("0:%d:", len(data))), data...)
+		},
+		OnClose: func() { close(done) },
+	}
+	session.ResizeFunc = func(rows, cols int) error {
+		return session.WriteControl([]byte(fmt.Sprintf("1:%d:%d:", cols, rows)))
+	}
+
+	go func() {
+		ticker := time.NewTicker(termproxyKeepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := session.WriteControl([]byte("2")); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return session, nil
+}
@@ -680,6 +680,20 @@ func (p *ProxmoxProvider) createVM(ctx context.Context, vmid int, config provide
 		global.APP_LOG.Warn("设置用户密码失败", zap.Int("vmid", vmid), zap.Error(err))
 	}
 
+	// 通过cloud-init写入用户选择的SSH公钥，首次启动时生效
+	if len(config.SSHKeys) > 0 {
+		if err := p.setCloudInitSSHKeys(vmid, config.SSHKeys); err != nil {
+			global.APP_LOG.Warn("设置cloud-init SSH公钥失败", zap.Int("vmid", vmid), zap.Error(err))
+		}
+	}
+
+	// 用户提供的user-data通过cicustom片段在首次启动时执行
+	if config.UserData != "" {
+		if err := p.setCloudInitUserData(vmid, config.UserData); err != nil {
+			global.APP_LOG.Warn("设置cloud-init user-data失败", zap.Int("vmid", vmid), zap.Error(err))
+		}
+	}
+
 	// 设置虚拟机名称，以便后续能够通过名称查找
 	_, err = p.sshClient.Execute(fmt.Sprintf("qm set %d --name %s", vmid, config.Name))
 	if err != nil {
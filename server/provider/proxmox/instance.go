@@ -15,6 +15,14 @@ func (p *ProxmoxProvider) ListInstances(ctx context.Context) ([]provider.Instanc
 	if !p.connected {
 		return nil, fmt.Errorf("not connected")
 	}
+	if p.clustered && p.cluster == nil {
+		return p.listClusterInstances(ctx)
+	}
+	return p.listNodeInstances(ctx)
+}
+
+// listNodeInstances 获取当前节点上的实例
+func (p *ProxmoxProvider) listNodeInstances(ctx context.Context) ([]provider.Instance, error) {
 
 	// 根据执行规则判断使用哪种方式
 	if p.shouldUseAPI() {
@@ -41,6 +49,12 @@ func (p *ProxmoxProvider) ListInstances(ctx context.Context) ([]provider.Instanc
 }
 
 func (p *ProxmoxProvider) CreateInstance(ctx context.Context, config provider.InstanceConfig) error {
+	// 集群中在选定的节点上创建
+	if p.cluster == nil && config.Node != "" {
+		if target := p.onNode(config.Node); target != p {
+			return target.CreateInstance(ctx, config)
+		}
+	}
 	if !p.connected {
 		return fmt.Errorf("not connected")
 	}
@@ -70,6 +84,12 @@ func (p *ProxmoxProvider) CreateInstance(ctx context.Context, config provider.In
 }
 
 func (p *ProxmoxProvider) CreateInstanceWithProgress(ctx context.Context, config provider.InstanceConfig, progressCallback provider.ProgressCallback) error {
+	// 集群中在选定的节点上创建
+	if p.cluster == nil && config.Node != "" {
+		if target := p.onNode(config.Node); target != p {
+			return target.CreateInstanceWithProgress(ctx, config, progressCallback)
+		}
+	}
 	if !p.connected {
 		return fmt.Errorf("not connected")
 	}
@@ -99,6 +119,9 @@ func (p *ProxmoxProvider) CreateInstanceWithProgress(ctx context.Context, config
 }
 
 func (p *ProxmoxProvider) StartInstance(ctx context.Context, id string) error {
+	if target := p.forInstance(ctx, id); target != p {
+		return target.StartInstance(ctx, id)
+	}
 	if !p.connected {
 		return fmt.Errorf("not connected")
 	}
@@ -128,6 +151,9 @@ func (p *ProxmoxProvider) StartInstance(ctx context.Context, id string) error {
 }
 
 func (p *ProxmoxProvider) StopInstance(ctx context.Context, id string) error {
+	if target := p.forInstance(ctx, id); target != p {
+		return target.StopInstance(ctx, id)
+	}
 	if !p.connected {
 		return fmt.Errorf("not connected")
 	}
@@ -157,6 +183,9 @@ func (p *ProxmoxProvider) StopInstance(ctx context.Context, id string) error {
 }
 
 func (p *ProxmoxProvider) RestartInstance(ctx context.Context, id string) error {
+	if target := p.forInstance(ctx, id); target != p {
+		return target.RestartInstance(ctx, id)
+	}
 	if !p.connected {
 		return fmt.Errorf("not connected")
 	}
@@ -186,6 +215,9 @@ func (p *ProxmoxProvider) RestartInstance(ctx context.Context, id string) error
 }
 
 func (p *ProxmoxProvider) DeleteInstance(ctx context.Context, id string) error {
+	if target := p.forInstance(ctx, id); target != p {
+		return target.DeleteInstance(ctx, id)
+	}
 	if !p.connected {
 		return fmt.Errorf("not connected")
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/provider"
 	"strconv"
 	"strings"
@@ -560,6 +561,9 @@ func (p *ProxmoxProvider) setupNATMapping(ctx context.Context, vmInternalIPv6, h
 
 // GetInstanceIPv6 获取实例的内网IPv6地址 (公开方法)
 func (p *ProxmoxProvider) GetInstanceIPv6(ctx context.Context, instanceName string) (string, error) {
+	if target := p.forInstance(ctx, instanceName); target != p {
+		return target.GetInstanceIPv6(ctx, instanceName)
+	}
 	// 先查找实例的VMID和类型
 	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceName)
 	if err != nil {
@@ -571,6 +575,9 @@ func (p *ProxmoxProvider) GetInstanceIPv6(ctx context.Context, instanceName stri
 
 // GetInstancePublicIPv6 获取实例的公网IPv6地址
 func (p *ProxmoxProvider) GetInstancePublicIPv6(ctx context.Context, instanceName string) (string, error) {
+	if target := p.forInstance(ctx, instanceName); target != p {
+		return target.GetInstancePublicIPv6(ctx, instanceName)
+	}
 	// 先查找实例的VMID和类型
 	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceName)
 	if err != nil {
@@ -701,3 +708,37 @@ func (p *ProxmoxProvider) getNATMappedIPv6(ctx context.Context, vmid string) (st
 
 	return "", fmt.Errorf("未找到IPv6 NAT映射")
 }
+
+// AllocateInstanceIPv6 按节点网络配置为已有实例分配IPv6，用于实例迁移到本节点后重新分配地址
+// NAT模式下实例持有内网IPv6并映射公网IPv6，直接分配模式下实例获取的就是公网IPv6
+func (p *ProxmoxProvider) AllocateInstanceIPv6(ctx context.Context, instanceName string) (string, string, error) {
+	if target := p.forInstance(ctx, instanceName); target != p {
+		return target.AllocateInstanceIPv6(ctx, instanceName)
+	}
+	var providerInfo providerModel.Provider
+	if err := global.APP_DB.Where("name = ?", p.config.Name).First(&providerInfo).Error; err != nil {
+		return "", "", fmt.Errorf("获取节点配置失败: %w", err)
+	}
+	if !provider.NetworkTypeHasIPv6(providerInfo.NetworkType) {
+		return "", "", nil
+	}
+
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceName)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find instance %s: %w", instanceName, err)
+	}
+	vmidInt, err := strconv.Atoi(vmid)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid vmid %s: %w", vmid, err)
+	}
+	if err := p.configureInstanceIPv6(ctx, vmidInt, provider.InstanceConfig{Name: instanceName}, instanceType); err != nil {
+		return "", "", err
+	}
+
+	ipv6Address, _ := p.GetInstanceIPv6(ctx, instanceName)
+	if providerInfo.NetworkType != "nat_ipv4_ipv6" {
+		return "", ipv6Address, nil
+	}
+	publicIPv6, _ := p.GetInstancePublicIPv6(ctx, instanceName)
+	return ipv6Address, publicIPv6, nil
+}
@@ -0,0 +1,252 @@
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// migrateArchiveDir 迁移归档在宿主机上的临时目录
+const migrateArchiveDir = "/tmp/oneclickvirt-migrate"
+
+// ExportInstance 使用 vzdump 以停止模式备份实例，并通过SFTP写入w
+// 运行中的实例由vzdump在备份结束后自动启动；vzdump 不包含快照，迁移后源节点上的快照随实例一起删除
+func (p *ProxmoxProvider) ExportInstance(ctx context.Context, instanceID string, w io.Writer, progressCallback provider.ProgressCallback) error {
+	// 备份文件经SFTP在连接节点上传输，集群中其他节点上的实例无法直接访问
+	if p.forInstance(ctx, instanceID) != p {
+		return fmt.Errorf("实例 %s 位于集群的其他节点，暂不支持导出", instanceID)
+	}
+	if err := p.checkMigratePrerequisites(); err != nil {
+		return err
+	}
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+	}
+
+	vmid, _, err := p.findVMIDByNameOrID(ctx, instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance %s: %w", instanceID, err)
+	}
+
+	dumpDir := fmt.Sprintf("%s/%s", migrateArchiveDir, vmid)
+	defer p.sshClient.Execute(fmt.Sprintf("rm -rf %s", dumpDir))
+
+	updateProgress(10, "正在使用vzdump备份实例...")
+	dumpCmd := fmt.Sprintf("mkdir -p %s && vzdump %s --dumpdir %s --mode stop --compress zstd", dumpDir, vmid, dumpDir)
+	if output, err := p.sshClient.ExecuteLongRunning(ctx, dumpCmd, dumpDir+"/vzdump"); err != nil {
+		return fmt.Errorf("failed to dump instance: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	output, err := p.sshClient.Execute(fmt.Sprintf("ls -1t %s/vzdump-*-%s-*.zst | head -n 1", dumpDir, vmid))
+	archivePath := strings.TrimSpace(output)
+	if err != nil || archivePath == "" {
+		return fmt.Errorf("未找到vzdump生成的备份文件")
+	}
+
+	updateProgress(50, "正在传输备份文件...")
+	written, err := p.sshClient.DownloadToWriter(archivePath, w)
+	if err != nil {
+		return fmt.Errorf("failed to transfer archive: %w", err)
+	}
+
+	updateProgress(100, "实例备份导出完成")
+	global.APP_LOG.Info("Proxmox实例导出成功",
+		zap.String("instance", utils.TruncateString(instanceID, 50)),
+		zap.String("vmid", vmid),
+		zap.Int64("bytes", written))
+	return nil
+}
+
+// ImportInstance 从r读取vzdump备份，分配新的VMID恢复实例，并按新VMID重新配置内网IP
+// 导入后实例保持停止状态
+func (p *ProxmoxProvider) ImportInstance(ctx context.Context, instanceName, instanceType string, r io.Reader, progressCallback provider.ProgressCallback) error {
+	if err := p.checkMigratePrerequisites(); err != nil {
+		return err
+	}
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+	}
+
+	vmid, err := p.getNextVMID(ctx, instanceType)
+	if err != nil {
+		return fmt.Errorf("获取VMID失败: %w", err)
+	}
+
+	// qmrestore/pct restore 根据文件名识别备份格式和压缩方式
+	dumpDir := fmt.Sprintf("%s/%d", migrateArchiveDir, vmid)
+	archivePath := fmt.Sprintf("%s/vzdump-lxc-%d-migrate.tar.zst", dumpDir, vmid)
+	if instanceType == "vm" {
+		archivePath = fmt.Sprintf("%s/vzdump-qemu-%d-migrate.vma.zst", dumpDir, vmid)
+	}
+	defer p.sshClient.Execute(fmt.Sprintf("rm -rf %s", dumpDir))
+
+	updateProgress(10, "正在上传备份文件...")
+	written, err := p.sshClient.UploadFromReader(r, archivePath, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to upload archive: %w", err)
+	}
+
+	// 获取存储盘配置 - 从数据库查询Provider记录
+	var providerRecord providerModel.Provider
+	if err := global.APP_DB.Where("name = ?", p.config.Name).First(&providerRecord).Error; err != nil {
+		global.APP_LOG.Warn("获取Provider记录失败，使用默认存储", zap.Error(err))
+	}
+	storage := providerRecord.StoragePool
+	if storage == "" {
+		storage = "local" // 默认存储
+	}
+
+	updateProgress(50, "正在恢复实例...")
+	restoreCmd := fmt.Sprintf("pct restore %d %s --storage %s", vmid, archivePath, storage)
+	if instanceType == "vm" {
+		restoreCmd = fmt.Sprintf("qmrestore %s %d --storage %s", archivePath, vmid, storage)
+	}
+	if output, err := p.sshClient.ExecuteLongRunning(ctx, restoreCmd, dumpDir+"/restore"); err != nil {
+		return fmt.Errorf("failed to restore instance: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	updateProgress(90, "正在配置实例网络...")
+	if err := p.reassignInternalIP(vmid, instanceType); err != nil {
+		global.APP_LOG.Warn("重新配置实例内网IP失败", zap.Int("vmid", vmid), zap.Error(err))
+	}
+
+	updateProgress(100, "实例导入完成")
+	global.APP_LOG.Info("Proxmox实例导入成功",
+		zap.String("instance", utils.TruncateString(instanceName, 50)),
+		zap.Int("vmid", vmid),
+		zap.Int64("bytes", written))
+	return nil
+}
+
+// RestoreInstance 从r读取vzdump备份，以原VMID强制覆盖恢复实例，内网IP保持不变
+// 恢复后实例保持停止状态
+func (p *ProxmoxProvider) RestoreInstance(ctx context.Context, instanceID string, r io.Reader, progressCallback provider.ProgressCallback) error {
+	// 备份文件经SFTP在连接节点上传输，集群中其他节点上的实例无法直接访问
+	if p.forInstance(ctx, instanceID) != p {
+		return fmt.Errorf("实例 %s 位于集群的其他节点，暂不支持恢复", instanceID)
+	}
+	if err := p.checkMigratePrerequisites(); err != nil {
+		return err
+	}
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+	}
+
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance %s: %w", instanceID, err)
+	}
+
+	dumpDir := fmt.Sprintf("%s/%s", migrateArchiveDir, vmid)
+	archivePath := fmt.Sprintf("%s/vzdump-lxc-%s-restore.tar.zst", dumpDir, vmid)
+	if instanceType == "vm" {
+		archivePath = fmt.Sprintf("%s/vzdump-qemu-%s-restore.vma.zst", dumpDir, vmid)
+	}
+	defer p.sshClient.Execute(fmt.Sprintf("rm -rf %s", dumpDir))
+
+	updateProgress(10, "正在上传备份文件...")
+	written, err := p.sshClient.UploadFromReader(r, archivePath, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to upload archive: %w", err)
+	}
+
+	var providerRecord providerModel.Provider
+	if err := global.APP_DB.Where("name = ?", p.config.Name).First(&providerRecord).Error; err != nil {
+		global.APP_LOG.Warn("获取Provider记录失败，使用默认存储", zap.Error(err))
+	}
+	storage := providerRecord.StoragePool
+	if storage == "" {
+		storage = "local"
+	}
+
+	updateProgress(40, "正在停止实例...")
+	p.sshClient.Execute(fmt.Sprintf("%s stop %s", p.guestCommand(instanceType), vmid))
+
+	updateProgress(50, "正在恢复备份...")
+	restoreCmd := fmt.Sprintf("pct restore %s %s --storage %s --force 1", vmid, archivePath, storage)
+	if instanceType == "vm" {
+		restoreCmd = fmt.Sprintf("qmrestore %s %s --storage %s --force 1", archivePath, vmid, storage)
+	}
+	if output, err := p.sshClient.ExecuteLongRunning(ctx, restoreCmd, dumpDir+"/restore"); err != nil {
+		return fmt.Errorf("failed to restore backup: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	updateProgress(100, "备份恢复完成")
+	global.APP_LOG.Info("Proxmox实例备份恢复成功",
+		zap.String("instance", utils.TruncateString(instanceID, 50)),
+		zap.String("vmid", vmid),
+		zap.Int64("bytes", written))
+	return nil
+}
+
+// checkMigratePrerequisites 检查迁移操作的前置条件，vzdump和restore只能通过SSH执行
+func (p *ProxmoxProvider) checkMigratePrerequisites() error {
+	if !p.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if !p.shouldUseSSH() {
+		return fmt.Errorf("执行规则不允许使用SSH，无法迁移实例")
+	}
+	return nil
+}
+
+// reassignInternalIP 内网IP由VMID推导，恢复到新VMID后需要替换备份中的旧IP
+func (p *ProxmoxProvider) reassignInternalIP(vmid int, instanceType string) error {
+	userIP := VMIDToInternalIP(vmid)
+	if instanceType == "vm" {
+		cmd := fmt.Sprintf("qm set %d --ipconfig0 ip=%s/24,gw=%s", vmid, userIP, InternalGateway)
+		if output, err := p.sshClient.Execute(cmd); err != nil {
+			return fmt.Errorf("%w, output: %s", err, utils.TruncateString(output, 200))
+		}
+		return nil
+	}
+
+	output, err := p.sshClient.Execute(fmt.Sprintf("pct config %d", vmid))
+	if err != nil {
+		return fmt.Errorf("读取容器配置失败: %w", err)
+	}
+	var net0 string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "net0:") {
+			net0 = strings.TrimSpace(strings.TrimPrefix(line, "net0:"))
+			break
+		}
+	}
+	if net0 == "" {
+		return fmt.Errorf("容器没有net0网卡")
+	}
+
+	parts := strings.Split(net0, ",")
+	options := make([]string, 0, len(parts))
+	for _, part := range parts {
+		switch {
+		case strings.HasPrefix(part, "ip="):
+			options = append(options, fmt.Sprintf("ip=%s/24", userIP))
+		case strings.HasPrefix(part, "gw="):
+			options = append(options, fmt.Sprintf("gw=%s", InternalGateway))
+		default:
+			options = append(options, part)
+		}
+	}
+
+	cmd := fmt.Sprintf("pct set %d --net0 %s", vmid, strings.Join(options, ","))
+	if output, err := p.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("%w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return nil
+}
@@ -74,12 +74,16 @@ func (p *ProxmoxProvider) parseNetworkConfigFromInstanceConfig(config provider.I
 		IPv6PortMappingMethod: providerIPv6PortMethod, // 从Provider配置读取IPv6端口映射方法
 	}
 
-	// 根据NetworkType调整端口映射方式
+	// 根据NetworkType调整端口映射方式，NAT模式使用iptables，Provider配置为nftables时保留
+	natPortMethod := "iptables"
+	if providerIPv4PortMethod == "nftables" {
+		natPortMethod = "nftables"
+	}
 	switch networkType {
 	case "nat_ipv4":
-		networkConfig.IPv4PortMappingMethod = "iptables"
+		networkConfig.IPv4PortMappingMethod = natPortMethod
 	case "nat_ipv4_ipv6":
-		networkConfig.IPv4PortMappingMethod = "iptables"
+		networkConfig.IPv4PortMappingMethod = natPortMethod
 		networkConfig.IPv6PortMappingMethod = providerIPv6PortMethod
 	case "dedicated_ipv4":
 		networkConfig.IPv4PortMappingMethod = "native"
@@ -12,6 +12,9 @@ import (
 
 // SetInstancePassword 设置实例密码
 func (p *ProxmoxProvider) SetInstancePassword(ctx context.Context, instanceID, password string) error {
+	if target := p.forInstance(ctx, instanceID); target != p {
+		return target.SetInstancePassword(ctx, instanceID, password)
+	}
 	if !p.connected {
 		return fmt.Errorf("provider not connected")
 	}
@@ -45,6 +48,9 @@ func (p *ProxmoxProvider) SetInstancePassword(ctx context.Context, instanceID, p
 
 // ResetInstancePassword 重置实例密码
 func (p *ProxmoxProvider) ResetInstancePassword(ctx context.Context, instanceID string) (string, error) {
+	if target := p.forInstance(ctx, instanceID); target != p {
+		return target.ResetInstancePassword(ctx, instanceID)
+	}
 	if !p.connected {
 		return "", fmt.Errorf("provider not connected")
 	}
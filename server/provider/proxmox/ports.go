@@ -10,6 +10,7 @@ import (
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/provider"
+	"oneclickvirt/provider/portmapping/nftables"
 
 	"go.uber.org/zap"
 )
@@ -109,6 +110,12 @@ func (p *ProxmoxProvider) cleanupInstancePortMappings(ctx context.Context, vmid
 			if err := global.APP_DB.Where("instance_id = ? AND status = 'active'", instance.ID).Find(&portMappings).Error; err != nil {
 				global.APP_LOG.Warn("获取端口映射失败", zap.String("instanceName", instanceName), zap.Error(err))
 			} else {
+				// Provider使用nftables时一次删除全部映射元素（包括端口段）
+				if _, _, ipv4PortMethod := p.getNetworkConfigFromProvider(ctx); ipv4PortMethod == "nftables" {
+					p.removeNftablesMappings(instanceName, portMappings)
+					portMappings = nil
+				}
+
 				// 清理每个端口映射
 				for _, port := range portMappings {
 					if err := p.removePortMapping(ctx, instanceName, port.HostPort, port.Protocol, port.MappingMethod); err != nil {
@@ -189,6 +196,9 @@ func (p *ProxmoxProvider) cleanupIptablesRulesForIP(ctx context.Context, ipAddre
 
 // GetInstanceIPv4 获取实例的内网IPv4地址 (公开方法)
 func (p *ProxmoxProvider) GetInstanceIPv4(ctx context.Context, instanceName string) (string, error) {
+	if target := p.forInstance(ctx, instanceName); target != p {
+		return target.GetInstanceIPv4(ctx, instanceName)
+	}
 	// 复用已有的getInstanceIPAddress方法来获取内网IPv4地址
 	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceName)
 	if err != nil {
@@ -228,6 +238,21 @@ func (p *ProxmoxProvider) configurePortMappingsWithIP(ctx context.Context, insta
 		return nil
 	}
 
+	// nftables方式下所有端口作为一批元素原子提交，端口段只占一个元素
+	if networkConfig.IPv4PortMappingMethod == "nftables" {
+		mappings := make([]nftables.Mapping, 0, len(portMappings))
+		for _, port := range portMappings {
+			mappings = append(mappings, nftables.Mapping{
+				Protocol:    port.Protocol,
+				HostPort:    port.HostPort,
+				HostPortEnd: port.HostPortEnd,
+				GuestPort:   port.GuestPort,
+				TargetIP:    instanceIP,
+			})
+		}
+		return p.setupNftablesMappings(instanceName, mappings)
+	}
+
 	// 分离SSH端口和其他端口
 	var sshPort *providerModel.Port
 	var otherPorts []providerModel.Port
@@ -280,6 +305,16 @@ func (p *ProxmoxProvider) setupPortMappingWithIP(ctx context.Context, instanceNa
 		zap.String("method", method),
 		zap.String("instanceIP", instanceIP))
 
+	// nftables的元素生成已处理both协议
+	if method == "nftables" {
+		return p.setupNftablesMappings(instanceName, []nftables.Mapping{{
+			Protocol:  protocol,
+			HostPort:  hostPort,
+			GuestPort: guestPort,
+			TargetIP:  instanceIP,
+		}})
+	}
+
 	// 如果协议是both，需要同时创建TCP和UDP规则
 	protocols := []string{protocol}
 	if protocol == "both" {
@@ -375,6 +410,8 @@ func (p *ProxmoxProvider) removePortMapping(ctx context.Context, instanceName st
 	switch method {
 	case "iptables":
 		return p.removeIptablesMapping(ctx, instanceName, hostPort, protocol)
+	case "nftables", "nftables-dnat":
+		return p.removeNftablesMapping(instanceName, hostPort, protocol)
 	case "native":
 		// Proxmox原生端口映射移除（暂时使用iptables实现）
 		return p.removeIptablesMapping(ctx, instanceName, hostPort, protocol)
@@ -437,6 +474,54 @@ func (p *ProxmoxProvider) removeIptablesMapping(ctx context.Context, instanceNam
 	return nil
 }
 
+// setupNftablesMappings 使用nftables映射集合设置端口映射，所有元素整批提交并持久化
+func (p *ProxmoxProvider) setupNftablesMappings(instanceName string, mappings []nftables.Mapping) error {
+	for _, cmd := range nftables.ApplyCommands(mappings) {
+		if output, err := p.sshClient.Execute(cmd); err != nil {
+			return fmt.Errorf("添加nftables端口映射失败: %w, output: %s", err, output)
+		}
+	}
+
+	global.APP_LOG.Info("Nftables端口映射设置成功",
+		zap.String("instance", instanceName),
+		zap.Int("count", len(mappings)))
+
+	return nil
+}
+
+// removeNftablesMapping 移除nftables端口映射，按端口记录的地址族和端口段删除元素
+func (p *ProxmoxProvider) removeNftablesMapping(instanceName string, hostPort int, protocol string) error {
+	port := providerModel.Port{HostPort: hostPort, Protocol: protocol}
+	global.APP_DB.Where("provider_id = ? AND host_port = ? AND protocol = ?", p.providerID, hostPort, protocol).First(&port)
+	p.removeNftablesMappings(instanceName, []providerModel.Port{port})
+	return nil
+}
+
+// removeNftablesMappings 批量移除端口记录对应的nftables映射元素，失败只记录警告
+func (p *ProxmoxProvider) removeNftablesMappings(instanceName string, ports []providerModel.Port) {
+	mappings := make([]nftables.Mapping, 0, len(ports))
+	for _, port := range ports {
+		mappings = append(mappings, nftables.Mapping{
+			Protocol:    port.Protocol,
+			HostPort:    port.HostPort,
+			HostPortEnd: port.HostPortEnd,
+			GuestPort:   port.GuestPort,
+			TargetIP:    port.IPv6Address, // 为空时按IPv4映射删除
+		})
+	}
+	for _, cmd := range nftables.RemoveCommands(mappings) {
+		if _, err := p.sshClient.Execute(cmd); err != nil {
+			global.APP_LOG.Warn("移除nftables端口映射失败",
+				zap.String("instance", instanceName),
+				zap.Error(err))
+		}
+	}
+
+	global.APP_LOG.Info("Nftables端口映射移除成功",
+		zap.String("instance", instanceName),
+		zap.Int("count", len(ports)))
+}
+
 // saveIptablesRules 保存iptables规则
 func (p *ProxmoxProvider) saveIptablesRules() error {
 	// 创建iptables目录
@@ -499,5 +584,8 @@ func (p *ProxmoxProvider) getInstancePrivateIP(ctx context.Context, instanceName
 // SetupPortMappingWithIP 公开的方法：在远程服务器上创建端口映射（用于手动添加端口）
 // 保持与LXD/Incus的API一致性
 func (p *ProxmoxProvider) SetupPortMappingWithIP(ctx context.Context, instanceName string, hostPort, guestPort int, protocol, method, instanceIP string) error {
+	if target := p.forInstance(ctx, instanceName); target != p {
+		return target.SetupPortMappingWithIP(ctx, instanceName, hostPort, guestPort, protocol, method, instanceIP)
+	}
 	return p.setupPortMappingWithIP(ctx, instanceName, hostPort, guestPort, protocol, method, instanceIP)
 }
@@ -0,0 +1,147 @@
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// 私有网络使用每个节点上一个没有物理端口、没有地址的VLAN感知网桥，每个私有网络使用独立的VLAN标签
+// 不同标签之间二层隔离，网桥不连接外部网络也不参与路由；集群中同一网络只在同一节点的实例之间互通
+
+// privateNetworkBridge 私有网络网桥名
+const privateNetworkBridge = "ocvpriv"
+
+// privateNetworkBridgeConfig 网桥的持久化配置，重启节点后由ifupdown加载
+const privateNetworkBridgeConfig = "/etc/network/interfaces.d/oneclickvirt-private"
+
+// CreatePrivateNetwork 确保连接节点上存在私有网络网桥，VLAN标签由调用方分配
+func (p *ProxmoxProvider) CreatePrivateNetwork(ctx context.Context, spec provider.PrivateNetworkSpec) error {
+	if err := p.checkPrivateNetworkPrerequisites(); err != nil {
+		return err
+	}
+	if spec.VlanID < 2 || spec.VlanID > 4094 {
+		return fmt.Errorf("无效的VLAN标签: %d", spec.VlanID)
+	}
+	return p.ensurePrivateNetworkBridge()
+}
+
+// DeletePrivateNetwork 网桥由所有私有网络共用，不需要在节点上删除
+func (p *ProxmoxProvider) DeletePrivateNetwork(ctx context.Context, spec provider.PrivateNetworkSpec) error {
+	return p.checkPrivateNetworkPrerequisites()
+}
+
+// AttachPrivateNetwork 为实例添加带VLAN标签的网卡，使用第一个空闲的netN
+func (p *ProxmoxProvider) AttachPrivateNetwork(ctx context.Context, spec provider.PrivateNetworkSpec) (string, error) {
+	if target := p.forInstance(ctx, spec.Instance); target != p {
+		return target.AttachPrivateNetwork(ctx, spec)
+	}
+	if err := p.checkPrivateNetworkPrerequisites(); err != nil {
+		return "", err
+	}
+	if err := p.ensurePrivateNetworkBridge(); err != nil {
+		return "", err
+	}
+
+	ipNet, err := provider.ParsePrivateNetworkCIDR(spec.CIDR)
+	if err != nil {
+		return "", err
+	}
+	address := provider.PrivateNetworkAddressWithPrefix(ipNet, spec.IPAddress)
+
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, spec.Instance)
+	if err != nil {
+		return "", fmt.Errorf("failed to find instance %s: %w", spec.Instance, err)
+	}
+	command := p.guestCommand(instanceType)
+	configOutput, err := p.sshClient.Execute(fmt.Sprintf("%s config %s", command, vmid))
+	if err != nil {
+		return "", fmt.Errorf("获取实例配置失败: %w", err)
+	}
+
+	device := freeVolumeDevice(configOutput, "net", 1, 31)
+	if device == "" {
+		return "", fmt.Errorf("实例没有空闲的网卡插槽")
+	}
+	index := strings.TrimPrefix(device, "net")
+
+	var cmd string
+	if instanceType == "vm" {
+		// 虚拟机地址通过cloud-init下发，下次启动后生效
+		cmd = fmt.Sprintf("qm set %s --%s virtio,bridge=%s,tag=%d --ipconfig%s ip=%s",
+			vmid, device, privateNetworkBridge, spec.VlanID, index, address)
+	} else {
+		cmd = fmt.Sprintf("pct set %s --%s name=eth%s,bridge=%s,tag=%d,ip=%s",
+			vmid, device, index, privateNetworkBridge, spec.VlanID, address)
+	}
+	if output, err := p.sshClient.Execute(cmd); err != nil {
+		return "", fmt.Errorf("接入私有网络失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return device, nil
+}
+
+// DetachPrivateNetwork 从实例配置中移除私有网络网卡
+func (p *ProxmoxProvider) DetachPrivateNetwork(ctx context.Context, spec provider.PrivateNetworkSpec) error {
+	if target := p.forInstance(ctx, spec.Instance); target != p {
+		return target.DetachPrivateNetwork(ctx, spec)
+	}
+	if err := p.checkPrivateNetworkPrerequisites(); err != nil {
+		return err
+	}
+
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, spec.Instance)
+	if err != nil {
+		// 实例已不存在时网卡自然已移除
+		return nil
+	}
+	if !strings.HasPrefix(spec.Device, "net") {
+		return fmt.Errorf("无效的网卡设备名: %s", spec.Device)
+	}
+
+	remove := spec.Device
+	if instanceType == "vm" {
+		remove += ",ipconfig" + strings.TrimPrefix(spec.Device, "net")
+	}
+	cmd := fmt.Sprintf("%s set %s --delete %s", p.guestCommand(instanceType), vmid, remove)
+	if output, err := p.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("断开私有网络失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return nil
+}
+
+// ensurePrivateNetworkBridge 在当前节点上创建并持久化私有网络网桥
+func (p *ProxmoxProvider) ensurePrivateNetworkBridge() error {
+	if _, err := p.sshClient.Execute(fmt.Sprintf("ip link show %s", privateNetworkBridge)); err == nil {
+		return nil
+	}
+
+	config := fmt.Sprintf("auto %s\\niface %s inet manual\\n\\tbridge-ports none\\n\\tbridge-stp off\\n\\tbridge-fd 0\\n\\tbridge-vlan-aware yes\\n\\tbridge-vids 2-4094\\n",
+		privateNetworkBridge, privateNetworkBridge)
+	cmd := fmt.Sprintf("printf '%s' > %s && (ifup %s 2>/dev/null || (ip link add name %s type bridge vlan_filtering 1 && ip link set %s up))",
+		config, privateNetworkBridgeConfig, privateNetworkBridge, privateNetworkBridge, privateNetworkBridge)
+	if output, err := p.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("创建私有网络网桥失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("Proxmox私有网络网桥创建成功",
+		zap.String("node", p.node),
+		zap.String("bridge", privateNetworkBridge))
+	return nil
+}
+
+// checkPrivateNetworkPrerequisites 私有网络操作只能通过SSH执行
+func (p *ProxmoxProvider) checkPrivateNetworkPrerequisites() error {
+	if !p.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if !p.shouldUseSSH() {
+		return fmt.Errorf("执行规则不允许使用SSH，无法管理私有网络")
+	}
+	return nil
+}
@@ -73,6 +73,8 @@ func InternalIPToVMIDCandidates(ip string) []int {
 }
 
 type ProxmoxProvider struct {
+	provider.CapabilityCache
+
 	config        provider.NodeConfig
 	sshClient     *utils.SSHClient
 	apiClient     *http.Client
@@ -82,8 +84,10 @@ type ProxmoxProvider struct {
 	node          string // Proxmox 节点名
 	providerUUID  string // Provider UUID，用于查询数据库中的配置
 	healthChecker health.HealthChecker
-	version       string       // Proxmox VE 版本，用于兼容性判断
-	mu            sync.RWMutex // 保护并发访问
+	version       string           // Proxmox VE 版本，用于兼容性判断
+	mu            sync.RWMutex     // 保护并发访问
+	clustered     bool             // 是否为多节点集群
+	cluster       *ProxmoxProvider // 按节点派生时指向持有SSH连接的Provider
 }
 
 func NewProxmoxProvider() provider.Provider {
@@ -218,6 +222,14 @@ func (p *ProxmoxProvider) Connect(ctx context.Context, config provider.NodeConfi
 			zap.Error(err))
 	}
 
+	// 检测多节点集群，集群中的实例按所在节点执行操作
+	p.detectCluster(ctx)
+
+	// 探测节点能力，失败时保留上一次结果
+	if _, err := p.ProbeCapabilities(ctx); err != nil {
+		global.APP_LOG.Warn("Proxmox节点能力探测失败", zap.Error(err))
+	}
+
 	global.APP_LOG.Info("Proxmox provider SSH连接成功",
 		zap.String("host", utils.TruncateString(config.Host, 32)),
 		zap.Int("port", config.Port),
@@ -364,6 +376,9 @@ func (p *ProxmoxProvider) shouldUseSSH() bool {
 // - LXC容器：veth<ctid>i0 或 veth<ctid>i1（如果有多个网络接口）
 // - KVM虚拟机：tap<vmid>i0 或 tap<vmid>i1（如果有多个网络接口）
 func (p *ProxmoxProvider) GetIPv6NetworkInterface(ctx context.Context, instanceName string) (string, error) {
+	if target := p.forInstance(ctx, instanceName); target != p {
+		return target.GetIPv6NetworkInterface(ctx, instanceName)
+	}
 	// 从数据库查询实例信息，检查是否有公网IPv6地址
 	var instance struct {
 		PublicIPv6 string
@@ -0,0 +1,114 @@
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// ResizeInstance 调整实例的CPU、内存、磁盘和带宽配置
+func (p *ProxmoxProvider) ResizeInstance(ctx context.Context, instanceID string, spec provider.ResizeSpec) error {
+	if target := p.forInstance(ctx, instanceID); target != p {
+		return target.ResizeInstance(ctx, instanceID, spec)
+	}
+	if !p.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if !p.shouldUseSSH() {
+		return fmt.Errorf("执行规则不允许使用SSH，无法调整实例配置")
+	}
+
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance %s: %w", instanceID, err)
+	}
+	command := p.guestCommand(instanceType)
+
+	var setArgs []string
+	if spec.CPU > 0 {
+		setArgs = append(setArgs, fmt.Sprintf("--cores %d", spec.CPU))
+	}
+	if spec.Memory > 0 {
+		setArgs = append(setArgs, fmt.Sprintf("--memory %d", spec.Memory))
+	}
+	if len(setArgs) > 0 {
+		cmd := fmt.Sprintf("%s set %s %s", command, vmid, strings.Join(setArgs, " "))
+		if output, err := p.sshClient.Execute(cmd); err != nil {
+			return fmt.Errorf("调整CPU和内存失败: %w, output: %s", err, utils.TruncateString(output, 200))
+		}
+	}
+
+	if spec.Disk > 0 {
+		// qm/pct resize 只支持扩容，传入绝对大小
+		disk := "rootfs"
+		if instanceType == "vm" {
+			disk = "scsi0"
+		}
+		cmd := fmt.Sprintf("%s resize %s %s %dM", command, vmid, disk, spec.Disk)
+		if output, err := p.sshClient.Execute(cmd); err != nil {
+			return fmt.Errorf("调整磁盘失败: %w, output: %s", err, utils.TruncateString(output, 200))
+		}
+	}
+
+	if spec.Bandwidth > 0 {
+		if err := p.resizeNetworkRate(command, vmid, spec.Bandwidth); err != nil {
+			return fmt.Errorf("调整带宽失败: %w", err)
+		}
+	}
+
+	global.APP_LOG.Info("Proxmox实例配置调整成功",
+		zap.String("instance", utils.TruncateString(instanceID, 50)),
+		zap.String("vmid", vmid),
+		zap.Int("cpu", spec.CPU),
+		zap.Int64("memory", spec.Memory),
+		zap.Int64("disk", spec.Disk),
+		zap.Int("bandwidth", spec.Bandwidth))
+	return nil
+}
+
+// resizeNetworkRate 在保留net0其他参数的前提下更新rate限速
+func (p *ProxmoxProvider) resizeNetworkRate(command, vmid string, bandwidth int) error {
+	output, err := p.sshClient.Execute(fmt.Sprintf("%s config %s", command, vmid))
+	if err != nil {
+		return fmt.Errorf("读取实例配置失败: %w", err)
+	}
+
+	var net0 string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "net0:") {
+			net0 = strings.TrimSpace(strings.TrimPrefix(line, "net0:"))
+			break
+		}
+	}
+	if net0 == "" {
+		return fmt.Errorf("实例没有net0网卡")
+	}
+
+	// Proxmox rate 参数单位为 MB/s，带宽单位为 Mbps，需要转换：MB/s = Mbps ÷ 8
+	rateMBps := bandwidth / 8
+	if rateMBps < 1 {
+		rateMBps = 1 // 最小1MB/s
+	}
+
+	parts := strings.Split(net0, ",")
+	options := make([]string, 0, len(parts)+1)
+	for _, part := range parts {
+		if !strings.HasPrefix(part, "rate=") {
+			options = append(options, part)
+		}
+	}
+	options = append(options, fmt.Sprintf("rate=%d", rateMBps))
+
+	cmd := fmt.Sprintf("%s set %s --net0 %s", command, vmid, strings.Join(options, ","))
+	if output, err := p.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("%w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return nil
+}
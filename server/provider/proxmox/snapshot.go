@@ -0,0 +1,192 @@
+package proxmox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// proxmoxSnapshotInfo pvesh返回的快照信息
+type proxmoxSnapshotInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Parent      string `json:"parent"`
+	SnapTime    int64  `json:"snaptime"`
+	VMState     int    `json:"vmstate"`
+}
+
+// CreateSnapshot 创建实例快照
+func (p *ProxmoxProvider) CreateSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	if target := p.forInstance(ctx, instanceID); target != p {
+		return target.CreateSnapshot(ctx, instanceID, snapshotName)
+	}
+	if err := p.checkSnapshotPrerequisites(snapshotName); err != nil {
+		return err
+	}
+
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance %s: %w", instanceID, err)
+	}
+
+	output, err := p.sshClient.Execute(fmt.Sprintf("%s snapshot %s %s", p.guestCommand(instanceType), vmid, snapshotName))
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("Proxmox实例快照创建成功",
+		zap.String("instance", utils.TruncateString(instanceID, 50)),
+		zap.String("vmid", vmid),
+		zap.String("snapshot", snapshotName))
+	return nil
+}
+
+// ListSnapshots 列出实例快照
+func (p *ProxmoxProvider) ListSnapshots(ctx context.Context, instanceID string) ([]provider.Snapshot, error) {
+	if target := p.forInstance(ctx, instanceID); target != p {
+		return target.ListSnapshots(ctx, instanceID)
+	}
+	if !p.connected {
+		return nil, fmt.Errorf("provider not connected")
+	}
+	if !p.shouldUseSSH() {
+		return nil, fmt.Errorf("执行规则不允许使用SSH，无法管理实例快照")
+	}
+
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find instance %s: %w", instanceID, err)
+	}
+
+	apiType := "lxc"
+	if instanceType == "vm" {
+		apiType = "qemu"
+	}
+
+	output, err := p.sshClient.Execute(fmt.Sprintf("pvesh get /nodes/%s/%s/%s/snapshot --output-format json", p.node, apiType, vmid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var infos []proxmoxSnapshotInfo
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &infos); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot list: %w", err)
+	}
+
+	snapshots := make([]provider.Snapshot, 0, len(infos))
+	for _, info := range infos {
+		// "current" 表示当前运行状态，不是真实快照
+		if info.Name == "current" {
+			continue
+		}
+		snapshots = append(snapshots, provider.Snapshot{
+			Name:        info.Name,
+			Description: strings.TrimSpace(info.Description),
+			Stateful:    info.VMState == 1,
+			Created:     time.Unix(info.SnapTime, 0),
+			Metadata: map[string]string{
+				"vmid":   vmid,
+				"parent": info.Parent,
+			},
+		})
+	}
+
+	return snapshots, nil
+}
+
+// RestoreSnapshot 将实例恢复到指定快照
+func (p *ProxmoxProvider) RestoreSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	if target := p.forInstance(ctx, instanceID); target != p {
+		return target.RestoreSnapshot(ctx, instanceID, snapshotName)
+	}
+	if err := p.checkSnapshotPrerequisites(snapshotName); err != nil {
+		return err
+	}
+
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance %s: %w", instanceID, err)
+	}
+	command := p.guestCommand(instanceType)
+
+	// 回滚后实例会处于停止状态，记录回滚前状态以便恢复运行
+	statusOutput, _ := p.sshClient.Execute(fmt.Sprintf("%s status %s", command, vmid))
+	wasRunning := strings.Contains(statusOutput, "running")
+
+	output, err := p.sshClient.Execute(fmt.Sprintf("%s rollback %s %s", command, vmid, snapshotName))
+	if err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	if wasRunning {
+		statusOutput, _ = p.sshClient.Execute(fmt.Sprintf("%s status %s", command, vmid))
+		if !strings.Contains(statusOutput, "running") {
+			if _, err := p.sshClient.Execute(fmt.Sprintf("%s start %s", command, vmid)); err != nil {
+				global.APP_LOG.Warn("快照回滚后启动实例失败",
+					zap.String("vmid", vmid),
+					zap.Error(err))
+			}
+		}
+	}
+
+	global.APP_LOG.Info("Proxmox实例快照恢复成功",
+		zap.String("instance", utils.TruncateString(instanceID, 50)),
+		zap.String("vmid", vmid),
+		zap.String("snapshot", snapshotName))
+	return nil
+}
+
+// DeleteSnapshot 删除实例快照
+func (p *ProxmoxProvider) DeleteSnapshot(ctx context.Context, instanceID, snapshotName string) error {
+	if target := p.forInstance(ctx, instanceID); target != p {
+		return target.DeleteSnapshot(ctx, instanceID, snapshotName)
+	}
+	if err := p.checkSnapshotPrerequisites(snapshotName); err != nil {
+		return err
+	}
+
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance %s: %w", instanceID, err)
+	}
+
+	output, err := p.sshClient.Execute(fmt.Sprintf("%s delsnapshot %s %s", p.guestCommand(instanceType), vmid, snapshotName))
+	if err != nil {
+		// 快照已不存在时视为删除成功
+		if strings.Contains(output, "does not exist") || strings.Contains(err.Error(), "does not exist") {
+			global.APP_LOG.Info("Proxmox实例快照不存在，跳过删除",
+				zap.String("vmid", vmid),
+				zap.String("snapshot", snapshotName))
+			return nil
+		}
+		return fmt.Errorf("failed to delete snapshot: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("Proxmox实例快照删除成功",
+		zap.String("instance", utils.TruncateString(instanceID, 50)),
+		zap.String("vmid", vmid),
+		zap.String("snapshot", snapshotName))
+	return nil
+}
+
+// checkSnapshotPrerequisites 检查快照操作的前置条件
+func (p *ProxmoxProvider) checkSnapshotPrerequisites(snapshotName string) error {
+	if !p.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if !utils.IsValidSnapshotName(snapshotName) {
+		return fmt.Errorf("invalid snapshot name: %s", snapshotName)
+	}
+	if !p.shouldUseSSH() {
+		return fmt.Errorf("执行规则不允许使用SSH，无法管理实例快照")
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package proxmox
+
+import (
+	"context"
+	"testing"
+
+	"oneclickvirt/global"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+func TestListSnapshots_ParsesPveshJSON(t *testing.T) {
+	global.APP_LOG = zap.NewNop()
+	replayer, err := utils.LoadCommandReplayer("testdata/pvesh_snapshot.json")
+	if err != nil {
+		t.Fatalf("加载录制文件失败: %v", err)
+	}
+	p := &ProxmoxProvider{
+		sshClient: utils.NewSSHClientWithExecutor(utils.SSHConfig{Host: "pve-node.example"}, replayer),
+		connected: true,
+		node:      "pve1",
+	}
+
+	snapshots, err := p.ListSnapshots(context.Background(), "web1")
+	if err != nil {
+		t.Fatalf("获取快照列表失败: %v", err)
+	}
+
+	// "current" 不是真实快照，应被过滤
+	if len(snapshots) != 2 {
+		t.Fatalf("快照数量不正确: %d", len(snapshots))
+	}
+	first := snapshots[0]
+	if first.Name != "before-upgrade" || first.Description != "pre apt upgrade" || first.Stateful {
+		t.Errorf("快照信息不正确: %+v", first)
+	}
+	if first.Created.Unix() != 1791998400 || first.Metadata["vmid"] != "101" {
+		t.Errorf("快照时间或VMID不正确: %+v", first)
+	}
+	if snapshots[1].Metadata["parent"] != "before-upgrade" {
+		t.Errorf("快照父节点不正确: %+v", snapshots[1])
+	}
+
+	executed := replayer.Executed()
+	if len(executed) != 2 || executed[1] != "pvesh get /nodes/pve1/lxc/101/snapshot --output-format json" {
+		t.Errorf("执行的命令不正确: %v", executed)
+	}
+}
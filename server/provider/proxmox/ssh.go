@@ -2,6 +2,7 @@ package proxmox
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"strconv"
 	"strings"
@@ -329,6 +330,14 @@ func (p *ProxmoxProvider) sshRestartInstance(ctx context.Context, id string) err
 	return nil
 }
 
+// guestCommand 根据实例类型返回对应的管理命令，虚拟机为qm，容器为pct
+func (p *ProxmoxProvider) guestCommand(instanceType string) string {
+	if instanceType == "vm" {
+		return "qm"
+	}
+	return "pct"
+}
+
 // findVMIDByNameOrID 根据实例名称或ID查找对应的VMID和类型
 func (p *ProxmoxProvider) findVMIDByNameOrID(ctx context.Context, identifier string) (string, string, error) {
 	global.APP_LOG.Debug("查找实例VMID",
@@ -640,9 +649,10 @@ func (p *ProxmoxProvider) getUsedInternalIPs(ctx context.Context) (map[string]bo
 // 在Proxmox中，VM的VMID和Container的CTID共享同一个ID空间，因此统一分配
 func (p *ProxmoxProvider) getNextVMID(ctx context.Context, instanceType string) (int, error) {
 	// 并发安全保护：VMID分配必须串行化，避免多个goroutine同时分配到相同ID
-	// 使用互斥锁确保同一时间只有一个goroutine在分配VMID
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	// 使用互斥锁确保同一时间只有一个goroutine在分配VMID，集群中按节点派生的Provider共用同一把锁
+	root := p.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
 
 	// VMID/CTID范围：100-999（Proxmox标准，VM和Container共享ID空间）
 	// 使用全局常量确保一致性
@@ -683,6 +693,17 @@ func (p *ProxmoxProvider) getNextVMID(ctx context.Context, instanceType string)
 		}
 	}
 
+	// 集群中VMID在所有节点间唯一，本节点的列表不够，需要合并整个集群的ID
+	if p.clustered {
+		guests, err := root.clusterGuests(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("获取集群VMID失败: %w", err)
+		}
+		for _, guest := range guests {
+			usedIDs[guest.VMID] = true
+		}
+	}
+
 	// 2. 获取已使用的内网IP列表（关键：避免IP冲突）
 	usedIPs, err := p.getUsedInternalIPs(ctx)
 	if err != nil {
@@ -1278,6 +1299,70 @@ func (p *ProxmoxProvider) configureInstanceSSHPasswordByVMID(ctx context.Context
 		// 不返回错误，因为SSH密码已经设置成功
 	}
 
+	// 注入用户选择的SSH公钥 - 失败不影响密码登录
+	if err := p.configureInstanceSSHKeysByVMID(vmid, config); err != nil {
+		global.APP_LOG.Warn("配置实例SSH公钥失败",
+			zap.String("instanceName", config.Name),
+			zap.Int("vmid", vmid),
+			zap.Error(err))
+	}
+
+	// 容器执行用户提供的首次启动脚本，虚拟机已通过cicustom交给cloud-init处理
+	if config.InstanceType == "container" {
+		if err := p.runContainerUserDataScript(vmid, config); err != nil {
+			global.APP_LOG.Warn("执行用户首次启动脚本失败",
+				zap.String("instanceName", config.Name),
+				zap.Int("vmid", vmid),
+				zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// configureInstanceSSHKeysByVMID 将用户选择的SSH公钥写入root的authorized_keys，并按需禁用SSH密码登录
+// 容器使用 pct exec，虚拟机使用 qemu-guest-agent（设置密码后虚拟机会重启，需要等待agent就绪）
+func (p *ProxmoxProvider) configureInstanceSSHKeysByVMID(vmid int, config provider.InstanceConfig) error {
+	if !provider.NeedsSSHKeyInjection(config) {
+		return nil
+	}
+	script := provider.BuildSSHKeyCommand(config.SSHKeys, config.DisablePasswordLogin)
+
+	if config.InstanceType == "container" {
+		if _, err := p.sshClient.Execute(fmt.Sprintf("pct exec %d -- sh -c '%s'", vmid, script)); err != nil {
+			return fmt.Errorf("写入SSH公钥失败: %w", err)
+		}
+	} else {
+		var lastErr error
+		for attempt := 0; attempt < 6; attempt++ {
+			if attempt > 0 {
+				time.Sleep(10 * time.Second)
+			}
+			if _, lastErr = p.sshClient.Execute(fmt.Sprintf("qm guest exec %d -- sh -c '%s'", vmid, script)); lastErr == nil {
+				break
+			}
+		}
+		if lastErr != nil {
+			return fmt.Errorf("通过guest agent写入SSH公钥失败: %w", lastErr)
+		}
+	}
+
+	global.APP_LOG.Info("Proxmox实例SSH公钥配置完成",
+		zap.String("instanceName", config.Name),
+		zap.Int("vmid", vmid),
+		zap.Int("keys", len(config.SSHKeys)),
+		zap.Bool("disablePasswordLogin", config.DisablePasswordLogin))
+	return nil
+}
+
+// setCloudInitSSHKeys 设置虚拟机cloud-init的sshkeys，qm set --sshkeys 需要读取宿主机上的公钥文件
+func (p *ProxmoxProvider) setCloudInitSSHKeys(vmid int, keys []string) error {
+	keyFile := fmt.Sprintf("/tmp/oneclickvirt-sshkeys-%d.pub", vmid)
+	content := base64.StdEncoding.EncodeToString([]byte(strings.Join(keys, "\n") + "\n"))
+	cmd := fmt.Sprintf("echo %s | base64 -d > %s && qm set %d --sshkeys %s; ret=$?; rm -f %s; exit $ret", content, keyFile, vmid, keyFile, keyFile)
+	if output, err := p.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("%w, output: %s", err, utils.TruncateString(output, 200))
+	}
 	return nil
 }
 
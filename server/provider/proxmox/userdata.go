@@ -0,0 +1,93 @@
+package proxmox
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// userDataStorage 存放cloud-init片段的存储，需要开启snippets内容类型
+	userDataStorage = "local"
+	// userDataSnippetDir local存储的snippets目录
+	userDataSnippetDir = "/var/lib/vz/snippets"
+)
+
+// userDataSnippetName 虚拟机user-data片段文件名
+func userDataSnippetName(vmid string) string {
+	return fmt.Sprintf("oneclickvirt-%s-vendor.yaml", vmid)
+}
+
+// setCloudInitUserData 将用户提供的user-data写入snippets并通过 cicustom vendor 挂载
+// 使用vendor而不是user，Proxmox生成的user配置（ciuser、cipassword、sshkeys）仍然生效
+func (p *ProxmoxProvider) setCloudInitUserData(vmid int, userData string) error {
+	if err := p.ensureSnippetsStorage(); err != nil {
+		return err
+	}
+	name := userDataSnippetName(fmt.Sprintf("%d", vmid))
+	if _, err := p.sshClient.Execute(fmt.Sprintf("mkdir -p %s", userDataSnippetDir)); err != nil {
+		return fmt.Errorf("创建snippets目录失败: %w", err)
+	}
+	if err := p.uploadContent(userData, userDataSnippetDir+"/"+name, 0600); err != nil {
+		return fmt.Errorf("上传user-data片段失败: %w", err)
+	}
+	cmd := fmt.Sprintf("qm set %d --cicustom vendor=%s:snippets/%s", vmid, userDataStorage, name)
+	if output, err := p.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("设置cicustom失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	global.APP_LOG.Info("虚拟机user-data配置完成",
+		zap.Int("vmid", vmid),
+		zap.String("type", provider.DetectUserDataType(userData)),
+		zap.Int("size", len(userData)))
+	return nil
+}
+
+// ensureSnippetsStorage 确保local存储开启了snippets内容类型，未开启时追加
+func (p *ProxmoxProvider) ensureSnippetsStorage() error {
+	output, err := p.sshClient.Execute(fmt.Sprintf("pvesh get /storage/%s --output-format json", userDataStorage))
+	if err != nil {
+		return fmt.Errorf("获取存储 %s 配置失败: %w", userDataStorage, err)
+	}
+	var storage struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &storage); err != nil {
+		return fmt.Errorf("解析存储 %s 配置失败: %w", userDataStorage, err)
+	}
+	for _, content := range strings.Split(storage.Content, ",") {
+		if strings.TrimSpace(content) == "snippets" {
+			return nil
+		}
+	}
+
+	contents := "snippets"
+	if storage.Content != "" {
+		contents = storage.Content + ",snippets"
+	}
+	if _, err := p.sshClient.Execute(fmt.Sprintf("pvesm set %s --content %s", userDataStorage, contents)); err != nil {
+		return fmt.Errorf("为存储 %s 开启snippets失败: %w", userDataStorage, err)
+	}
+	global.APP_LOG.Info("已为存储开启snippets内容类型", zap.String("storage", userDataStorage))
+	return nil
+}
+
+// runContainerUserDataScript 容器没有cloud-init，通过 pct exec 在后台执行用户提供的首次启动脚本
+func (p *ProxmoxProvider) runContainerUserDataScript(vmid int, config provider.InstanceConfig) error {
+	if provider.DetectUserDataType(config.UserData) != provider.UserDataTypeScript {
+		return nil
+	}
+	cmd := fmt.Sprintf("pct exec %d -- sh -c '%s'", vmid, provider.BuildUserDataCommand(config.UserData, false))
+	if _, err := p.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("执行首次启动脚本失败: %w", err)
+	}
+	global.APP_LOG.Info("容器首次启动脚本已执行",
+		zap.String("instanceName", config.Name),
+		zap.Int("vmid", vmid))
+	return nil
+}
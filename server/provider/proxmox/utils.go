@@ -311,6 +311,9 @@ func (p *ProxmoxProvider) cleanupVMFiles(ctx context.Context, vmid string) error
 		}
 	}
 
+	// 删除user-data片段
+	p.sshClient.Execute(fmt.Sprintf("rm -f %s/%s", userDataSnippetDir, userDataSnippetName(vmid)))
+
 	// 删除VM目录
 	vmDir := fmt.Sprintf("/root/vm%s", vmid)
 	return p.safeRemove(ctx, vmDir)
@@ -0,0 +1,241 @@
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// 数据卷使用 pvesm alloc 分配的独立磁盘，属主VMID为 volumeOwnerBase+卷ID，不属于任何实例
+// qm/pct destroy 不会删除属主不是自身的磁盘，实例删除或重装后数据卷仍然保留
+// 虚拟机挂载为 scsiN，容器挂载为 mpN；集群中本地存储上的卷只能挂载到同一节点的实例
+
+// volumeOwnerBase 数据卷属主VMID起点，远离实例使用的VMID范围
+const volumeOwnerBase = 900000
+
+// volumeOwner 数据卷的属主VMID
+func volumeOwner(spec provider.VolumeSpec) int {
+	return volumeOwnerBase + int(spec.ID)
+}
+
+// CreateVolume 在存储上分配数据卷，容器卷优先使用subvol格式，不支持时分配raw磁盘并格式化为ext4
+func (p *ProxmoxProvider) CreateVolume(ctx context.Context, spec provider.VolumeSpec) (string, error) {
+	if err := p.checkVolumePrerequisites(); err != nil {
+		return "", err
+	}
+
+	storage := spec.Pool
+	if storage == "" {
+		var providerRecord providerModel.Provider
+		if err := global.APP_DB.Where("name = ?", p.config.Name).First(&providerRecord).Error; err != nil {
+			global.APP_LOG.Warn("获取Provider记录失败，使用默认存储", zap.Error(err))
+		}
+		storage = providerRecord.StoragePool
+		if storage == "" {
+			storage = "local"
+		}
+	}
+
+	owner := volumeOwner(spec)
+	if spec.InstanceType != "vm" {
+		cmd := fmt.Sprintf("pvesm alloc %s %d subvol-%d-disk-0 %dM --format subvol", storage, owner, owner, spec.SizeMB)
+		if _, err := p.sshClient.Execute(cmd); err == nil {
+			return storage, nil
+		}
+	}
+
+	cmd := fmt.Sprintf("pvesm alloc %s %d vm-%d-disk-0 %dM", storage, owner, owner, spec.SizeMB)
+	if output, err := p.sshClient.Execute(cmd); err != nil {
+		return "", fmt.Errorf("分配数据卷失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	if spec.InstanceType != "vm" {
+		volid := fmt.Sprintf("%s:vm-%d-disk-0", storage, owner)
+		cmd := fmt.Sprintf("mkfs.ext4 -q -F $(pvesm path %s)", volid)
+		if output, err := p.sshClient.Execute(cmd); err != nil {
+			p.sshClient.Execute(fmt.Sprintf("pvesm free %s", volid))
+			return "", fmt.Errorf("格式化数据卷失败: %w, output: %s", err, utils.TruncateString(output, 200))
+		}
+	}
+
+	global.APP_LOG.Info("Proxmox数据卷创建成功",
+		zap.String("storage", storage),
+		zap.Int("owner", owner),
+		zap.Int64("sizeMB", spec.SizeMB))
+	return storage, nil
+}
+
+// AttachVolume 将数据卷挂载到实例的第一个空闲scsiN或mpN
+func (p *ProxmoxProvider) AttachVolume(ctx context.Context, spec provider.VolumeSpec) (string, error) {
+	if target := p.forInstance(ctx, spec.Instance); target != p {
+		return target.AttachVolume(ctx, spec)
+	}
+	if err := p.checkVolumePrerequisites(); err != nil {
+		return "", err
+	}
+
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, spec.Instance)
+	if err != nil {
+		return "", fmt.Errorf("failed to find instance %s: %w", spec.Instance, err)
+	}
+	volid, err := p.findVolumeID(spec)
+	if err != nil {
+		return "", err
+	}
+
+	command := p.guestCommand(instanceType)
+	configOutput, err := p.sshClient.Execute(fmt.Sprintf("%s config %s", command, vmid))
+	if err != nil {
+		return "", fmt.Errorf("获取实例配置失败: %w", err)
+	}
+
+	var device, value string
+	if instanceType == "vm" {
+		device = freeVolumeDevice(configOutput, "scsi", 1, 30)
+		value = volid
+	} else {
+		mountPath := spec.MountPath
+		if mountPath == "" {
+			mountPath = provider.DefaultVolumeMountPath(spec.Name)
+		}
+		device = freeVolumeDevice(configOutput, "mp", 0, 255)
+		value = fmt.Sprintf("%s,mp=%s", volid, mountPath)
+	}
+	if device == "" {
+		return "", fmt.Errorf("实例没有空闲的磁盘插槽")
+	}
+
+	cmd := fmt.Sprintf("%s set %s --%s %s", command, vmid, device, value)
+	if output, err := p.sshClient.Execute(cmd); err != nil {
+		return "", fmt.Errorf("挂载数据卷失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return device, nil
+}
+
+// DetachVolume 从实例配置中移除数据卷，属主不是实例的磁盘不会被放入unused或删除
+func (p *ProxmoxProvider) DetachVolume(ctx context.Context, spec provider.VolumeSpec) error {
+	if target := p.forInstance(ctx, spec.Instance); target != p {
+		return target.DetachVolume(ctx, spec)
+	}
+	if err := p.checkVolumePrerequisites(); err != nil {
+		return err
+	}
+
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, spec.Instance)
+	if err != nil {
+		// 实例已不存在时数据卷自然已卸载
+		return nil
+	}
+	if spec.Device == "" {
+		return fmt.Errorf("缺少数据卷设备名")
+	}
+
+	cmd := fmt.Sprintf("%s set %s --delete %s", p.guestCommand(instanceType), vmid, spec.Device)
+	if output, err := p.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("卸载数据卷失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return nil
+}
+
+// ResizeVolume 扩容数据卷，qm/pct resize 需要磁盘已挂载到实例
+func (p *ProxmoxProvider) ResizeVolume(ctx context.Context, spec provider.VolumeSpec) error {
+	if spec.Instance == "" || spec.Device == "" {
+		return fmt.Errorf("Proxmox数据卷需要挂载到实例后才能调整容量")
+	}
+	if target := p.forInstance(ctx, spec.Instance); target != p {
+		return target.ResizeVolume(ctx, spec)
+	}
+	if err := p.checkVolumePrerequisites(); err != nil {
+		return err
+	}
+
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, spec.Instance)
+	if err != nil {
+		return fmt.Errorf("failed to find instance %s: %w", spec.Instance, err)
+	}
+
+	cmd := fmt.Sprintf("%s resize %s %s %dM", p.guestCommand(instanceType), vmid, spec.Device, spec.SizeMB)
+	if output, err := p.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("调整数据卷容量失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+	return nil
+}
+
+// DeleteVolume 释放数据卷
+func (p *ProxmoxProvider) DeleteVolume(ctx context.Context, spec provider.VolumeSpec) error {
+	if err := p.checkVolumePrerequisites(); err != nil {
+		return err
+	}
+
+	volid, err := p.findVolumeID(spec)
+	if err != nil {
+		// 已不存在时视为删除成功
+		return nil
+	}
+	if output, err := p.sshClient.Execute(fmt.Sprintf("pvesm free %s", volid)); err != nil {
+		return fmt.Errorf("删除数据卷失败: %w, output: %s", err, utils.TruncateString(output, 200))
+	}
+
+	global.APP_LOG.Info("Proxmox数据卷删除成功", zap.String("volid", volid))
+	return nil
+}
+
+// findVolumeID 查询数据卷在存储上的volid
+func (p *ProxmoxProvider) findVolumeID(spec provider.VolumeSpec) (string, error) {
+	output, err := p.sshClient.Execute(fmt.Sprintf("pvesm list %s --vmid %d", spec.Pool, volumeOwner(spec)))
+	if err != nil {
+		return "", fmt.Errorf("查询数据卷失败: %w", err)
+	}
+	volid := parseVolumeID(output, volumeOwner(spec))
+	if volid == "" {
+		return "", fmt.Errorf("数据卷 %s 在存储 %s 上不存在", spec.Name, spec.Pool)
+	}
+	return volid, nil
+}
+
+// parseVolumeID 从 pvesm list 输出中找到属主的第一个磁盘
+func parseVolumeID(output string, owner int) string {
+	suffix := fmt.Sprintf("-%d-disk-0", owner)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && strings.HasSuffix(fields[0], suffix) {
+			return fields[0]
+		}
+	}
+	return ""
+}
+
+// freeVolumeDevice 在 qm/pct config 输出中查找第一个未使用的设备名
+func freeVolumeDevice(configOutput, prefix string, from, to int) string {
+	used := make(map[string]bool)
+	for _, line := range strings.Split(configOutput, "\n") {
+		if key, _, ok := strings.Cut(line, ":"); ok {
+			used[strings.TrimSpace(key)] = true
+		}
+	}
+	for i := from; i <= to; i++ {
+		device := fmt.Sprintf("%s%d", prefix, i)
+		if !used[device] {
+			return device
+		}
+	}
+	return ""
+}
+
+// checkVolumePrerequisites 数据卷操作只能通过SSH执行
+func (p *ProxmoxProvider) checkVolumePrerequisites() error {
+	if !p.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	if !p.shouldUseSSH() {
+		return fmt.Errorf("执行规则不允许使用SSH，无法管理数据卷")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package proxmox
+
+import "testing"
+
+func TestParseVolumeID(t *testing.T) {
+	output := `Volid                              Format  Type             Size VMID
+local-lvm:vm-900012-disk-0         raw     images     10737418240 900012
+local-lvm:vm-900012-disk-1         raw     images      1073741824 900012
+`
+	if got := parseVolumeID(output, 900012); got != "local-lvm:vm-900012-disk-0" {
+		t.Fatalf("unexpected volid: %q", got)
+	}
+	if got := parseVolumeID(output, 900013); got != "" {
+		t.Fatalf("expected no volid for other owner, got %q", got)
+	}
+	if got := parseVolumeID("local:subvol-900005-disk-0 subvol rootdir 0 900005", 900005); got != "local:subvol-900005-disk-0" {
+		t.Fatalf("subvol not matched: %q", got)
+	}
+}
+
+func TestFreeVolumeDevice(t *testing.T) {
+	vmConfig := `boot: order=scsi0
+scsi0: local-lvm:vm-101-disk-0,size=20G
+scsi1: local-lvm:vm-900001-disk-0,size=10G
+scsihw: virtio-scsi-pci
+`
+	if got := freeVolumeDevice(vmConfig, "scsi", 1, 30); got != "scsi2" {
+		t.Fatalf("expected scsi2, got %q", got)
+	}
+	if got := freeVolumeDevice("rootfs: local:subvol-102-disk-0,size=8G\n", "mp", 0, 255); got != "mp0" {
+		t.Fatalf("expected mp0, got %q", got)
+	}
+	if got := freeVolumeDevice("mp0: x\nmp1: y\n", "mp", 0, 1); got != "" {
+		t.Fatalf("expected no free slot, got %q", got)
+	}
+}
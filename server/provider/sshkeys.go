@@ -0,0 +1,52 @@
+package provider
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// NeedsSSHKeyInjection 实例配置中是否包含需要注入的SSH公钥或禁用密码登录选项
+func NeedsSSHKeyInjection(config InstanceConfig) bool {
+	return len(config.SSHKeys) > 0 || config.DisablePasswordLogin
+}
+
+// BuildSSHKeyScript 生成写入root的authorized_keys并按需禁用SSH密码登录的sh脚本
+// 脚本只依赖POSIX sh和busybox常见命令，可在Alpine、Debian、CentOS等系统中运行
+func BuildSSHKeyScript(keys []string, disablePasswordLogin bool) string {
+	var b strings.Builder
+	b.WriteString("umask 077\n")
+	b.WriteString("mkdir -p /root/.ssh\n")
+	b.WriteString("touch /root/.ssh/authorized_keys\n")
+	if len(keys) > 0 {
+		b.WriteString("cat >> /root/.ssh/authorized_keys <<'ONECLICKVIRT_KEYS'\n")
+		for _, key := range keys {
+			key = strings.TrimSpace(key)
+			if key != "" {
+				b.WriteString(key)
+				b.WriteString("\n")
+			}
+		}
+		b.WriteString("ONECLICKVIRT_KEYS\n")
+		// 去除重复的公钥（重置或多次注入时）
+		b.WriteString("awk '!seen[$0]++' /root/.ssh/authorized_keys > /root/.ssh/authorized_keys.tmp && mv /root/.ssh/authorized_keys.tmp /root/.ssh/authorized_keys\n")
+	}
+	b.WriteString("chmod 700 /root/.ssh && chmod 600 /root/.ssh/authorized_keys\n")
+	if disablePasswordLogin {
+		b.WriteString("if [ -f /etc/ssh/sshd_config ]; then\n")
+		b.WriteString("  sed -i -E 's/^#?[[:space:]]*PasswordAuthentication.*/PasswordAuthentication no/' /etc/ssh/sshd_config\n")
+		b.WriteString("  grep -q '^PasswordAuthentication no' /etc/ssh/sshd_config || echo 'PasswordAuthentication no' >> /etc/ssh/sshd_config\n")
+		b.WriteString("  for f in /etc/ssh/sshd_config.d/*.conf; do [ -f \"$f\" ] && sed -i -E 's/^[[:space:]]*PasswordAuthentication.*/PasswordAuthentication no/' \"$f\"; done\n")
+		b.WriteString("  sed -i -E 's/^#?[[:space:]]*PermitRootLogin.*/PermitRootLogin prohibit-password/' /etc/ssh/sshd_config\n")
+		b.WriteString("fi\n")
+		b.WriteString("(systemctl restart sshd || systemctl restart ssh || rc-service sshd restart || service sshd restart || service ssh restart) >/dev/null 2>&1 || true\n")
+	}
+	return b.String()
+}
+
+// BuildSSHKeyCommand 将SSH公钥脚本编码为可安全嵌入 exec 命令的单行命令
+// 脚本经base64编码后传入，避免公钥注释中的引号等字符破坏外层命令
+func BuildSSHKeyCommand(keys []string, disablePasswordLogin bool) string {
+	script := BuildSSHKeyScript(keys, disablePasswordLogin)
+	return fmt.Sprintf("echo %s | base64 -d | sh", base64.StdEncoding.EncodeToString([]byte(script)))
+}
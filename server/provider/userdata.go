@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// UserDataTypeCloudConfig cloud-init配置（#cloud-config）
+	UserDataTypeCloudConfig = "cloud-config"
+	// UserDataTypeScript 首次启动时执行的shell脚本（#!）
+	UserDataTypeScript = "script"
+
+	// MaxUserDataBytes user-data的硬性上限，等级配置的大小限制不能超过该值
+	MaxUserDataBytes = 64 * 1024
+	// userDataLogPath 通过exec执行的首次启动脚本输出日志
+	userDataLogPath = "/var/log/oneclickvirt-userdata.log"
+)
+
+// DetectUserDataType 根据首行识别user-data类型，无法识别时返回空字符串
+func DetectUserDataType(userData string) string {
+	firstLine := strings.TrimSpace(strings.SplitN(strings.TrimPrefix(userData, "\ufeff"), "\n", 2)[0])
+	switch {
+	case firstLine == "#cloud-config":
+		return UserDataTypeCloudConfig
+	case strings.HasPrefix(firstLine, "#!"):
+		return UserDataTypeScript
+	default:
+		return ""
+	}
+}
+
+// ValidateUserData 校验user-data的大小、编码和格式，cloud-config需要是合法的YAML对象
+func ValidateUserData(userData string, maxBytes int) error {
+	if userData == "" {
+		return nil
+	}
+	if maxBytes <= 0 || maxBytes > MaxUserDataBytes {
+		maxBytes = MaxUserDataBytes
+	}
+	if len(userData) > maxBytes {
+		return fmt.Errorf("user-data大小不能超过%dKB", maxBytes/1024)
+	}
+	if !utf8.ValidString(userData) || strings.ContainsRune(userData, 0) {
+		return errors.New("user-data必须是UTF-8文本")
+	}
+
+	switch DetectUserDataType(userData) {
+	case UserDataTypeCloudConfig:
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal([]byte(userData), &doc); err != nil {
+			return fmt.Errorf("cloud-config格式错误: %v", err)
+		}
+		return nil
+	case UserDataTypeScript:
+		return nil
+	default:
+		return errors.New("user-data首行必须是 #cloud-config 或 #! 开头的脚本解释器")
+	}
+}
+
+// SupportsUserData 判断节点类型和实例类型能否使用指定类型的user-data
+// LXD/Incus和Proxmox虚拟机通过cloud-init处理，Docker、Podman和Proxmox容器只能在首次启动后执行脚本
+func SupportsUserData(providerType, instanceType, userDataType string) bool {
+	switch providerType {
+	case "lxd", "incus":
+		return true
+	case "proxmox":
+		return instanceType == "vm" || userDataType == UserDataTypeScript
+	case "docker", "podman":
+		return userDataType == UserDataTypeScript
+	default:
+		return false
+	}
+}
+
+// BuildUserDataCommand 生成在实例内执行首次启动脚本的单行命令，脚本在后台运行，输出写入日志
+// skipIfCloudInit为true时，镜像自带cloud-init则跳过（脚本已由cloud-init执行）
+func BuildUserDataCommand(userData string, skipIfCloudInit bool) string {
+	var b strings.Builder
+	if skipIfCloudInit {
+		b.WriteString("command -v cloud-init >/dev/null 2>&1 && exit 0\n")
+	}
+	b.WriteString("umask 077\n")
+	fmt.Fprintf(&b, "echo %s | base64 -d > /root/.oneclickvirt-userdata\n", base64.StdEncoding.EncodeToString([]byte(userData)))
+	b.WriteString("chmod 700 /root/.oneclickvirt-userdata\n")
+	fmt.Fprintf(&b, "nohup /root/.oneclickvirt-userdata > %s 2>&1 &\n", userDataLogPath)
+	return fmt.Sprintf("echo %s | base64 -d | sh", base64.StdEncoding.EncodeToString([]byte(b.String())))
+}
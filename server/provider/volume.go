@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// VolumeSpec 数据卷操作参数
+type VolumeSpec struct {
+	ID           uint   // 数据卷记录ID，Proxmox用它生成卷的属主ID
+	Name         string // Provider上的卷名
+	Pool         string // 存储池，创建时为空表示使用Provider默认存储池
+	SizeMB       int64  // 容量（MB），调整大小时为新容量
+	InstanceType string // 可挂载的实例类型：container为文件系统卷，vm为块设备
+	Instance     string // 挂载、卸载以及已挂载时调整大小的目标实例名
+	Device       string // 已挂载时实例上的设备名
+	MountPath    string // 挂载到容器时的路径
+}
+
+// VolumeProvider 支持独立数据卷的Provider实现此接口
+// 数据卷不随实例删除，重装实例时先卸载再挂载到新实例
+type VolumeProvider interface {
+	// CreateVolume 在存储池上创建数据卷，返回实际使用的存储池
+	CreateVolume(ctx context.Context, spec VolumeSpec) (string, error)
+	// AttachVolume 将数据卷挂载到spec.Instance，返回实例上的设备名
+	AttachVolume(ctx context.Context, spec VolumeSpec) (string, error)
+	// DetachVolume 从spec.Instance上卸载数据卷，数据保留
+	DetachVolume(ctx context.Context, spec VolumeSpec) error
+	// ResizeVolume 将数据卷扩容到spec.SizeMB
+	ResizeVolume(ctx context.Context, spec VolumeSpec) error
+	// DeleteVolume 删除未挂载的数据卷
+	DeleteVolume(ctx context.Context, spec VolumeSpec) error
+}
+
+// DefaultVolumeMountPath 容器内默认挂载路径
+func DefaultVolumeMountPath(name string) string {
+	return "/mnt/" + name
+}
+
+// ValidateVolumeMountPath 校验容器挂载路径：必须是绝对路径，不能是系统目录，且不包含会破坏命令的字符
+func ValidateVolumeMountPath(mountPath string) error {
+	if mountPath == "" {
+		return nil
+	}
+	if !strings.HasPrefix(mountPath, "/") {
+		return fmt.Errorf("挂载路径必须是绝对路径")
+	}
+	if strings.ContainsAny(mountPath, " '\"`$;&|<>\\,=") {
+		return fmt.Errorf("挂载路径包含非法字符")
+	}
+	switch path.Clean(mountPath) {
+	case "/", "/bin", "/boot", "/dev", "/etc", "/lib", "/lib64", "/proc", "/root", "/run", "/sbin", "/sys", "/usr", "/var":
+		return fmt.Errorf("不能挂载到系统目录 %s", mountPath)
+	}
+	return nil
+}
@@ -45,9 +45,18 @@ func InitAdminRouter(Router *gin.RouterGroup) {
 		AdminGroup.POST("/instances/:id/transfer", admin.TransferInstanceOwnership) // 实例转移归属
 		AdminGroup.PUT("/instances/:id/reset-password", admin.ResetInstancePassword)
 		AdminGroup.GET("/instances/:id/password/:taskId", admin.GetInstanceNewPassword)
+		AdminGroup.PUT("/instances/:id/resize", admin.ResizeInstance)
+		AdminGroup.POST("/instances/:id/migrate", admin.MigrateInstance)
+		AdminGroup.POST("/instances/adopt", admin.AdoptInstances) // 导入节点上未管理的实例
+		AdminGroup.GET("/volumes", admin.GetVolumeList)
+		AdminGroup.GET("/instances/:id/snapshots", admin.GetInstanceSnapshots)
+		AdminGroup.POST("/instances/:id/snapshots", admin.CreateInstanceSnapshot)
+		AdminGroup.POST("/instances/:id/snapshots/:snapshotId/restore", admin.RestoreInstanceSnapshot)
+		AdminGroup.DELETE("/instances/:id/snapshots/:snapshotId", admin.DeleteInstanceSnapshot)
 		AdminGroup.GET("/instance-type-permissions", admin.GetAdminInstanceTypePermissions)
 		AdminGroup.PUT("/instance-type-permissions", admin.UpdateAdminInstanceTypePermissions)
-		AdminGroup.GET("/instances/:id/ssh", admin.AdminSSHWebSocket) // 管理员WebSocket SSH连接
+		AdminGroup.GET("/instances/:id/ssh", admin.AdminSSHWebSocket)                 // 管理员WebSocket SSH连接
+		AdminGroup.GET("/instances/:id/console", admin.AdminInstanceConsoleWebSocket) // 管理员WebSocket虚拟化控制台
 
 		// 节点管理
 		AdminGroup.GET("/providers/:id/ssh", admin.AdminProviderSSHWebSocket) // 管理员WebSocket SSH连接到节点服务器
@@ -92,7 +101,17 @@ func InitAdminRouter(Router *gin.RouterGroup) {
 		AdminGroup.DELETE("/providers/:id", admin.DeleteProvider)
 		AdminGroup.POST("/providers/freeze", admin.FreezeProvider)
 		AdminGroup.POST("/providers/unfreeze", admin.UnfreezeProvider)
+		AdminGroup.POST("/providers/maintenance/enter", admin.EnterProviderMaintenance)
+		AdminGroup.POST("/providers/maintenance/exit", admin.ExitProviderMaintenance)
+		AdminGroup.GET("/providers/:id/maintenance", admin.GetProviderMaintenanceStatus)
+		AdminGroup.POST("/providers/:id/evacuate", admin.EvacuateProvider)
+		AdminGroup.POST("/providers/:id/reconcile", admin.ReconcileProvider)
+		AdminGroup.GET("/providers/:id/unmanaged-instances", admin.GetUnmanagedInstances)
+		AdminGroup.GET("/providers/:id/cluster-nodes", admin.GetClusterNodes)
+		AdminGroup.GET("/drifts", admin.GetDriftReport)
+		AdminGroup.POST("/drifts/:id/fix", admin.FixDrift)
 		AdminGroup.POST("/providers/test-ssh-connection", admin.TestSSHConnection)
+		AdminGroup.POST("/providers/import", admin.ImportProviders)
 		// Provider验证接口（用于前端实时验证）
 		AdminGroup.GET("/providers/check-name", admin.CheckProviderName)
 		AdminGroup.GET("/providers/check-endpoint", admin.CheckProviderEndpoint)
@@ -172,6 +191,12 @@ func InitAdminRouter(Router *gin.RouterGroup) {
 		AdminGroup.PUT("/products/:id/toggle", admin.ToggleProduct)
 		AdminGroup.PUT("/products/:id/stock", admin.UpdateProductStock)
 
+		// 实例规格管理
+		AdminGroup.GET("/flavors", admin.GetFlavors)
+		AdminGroup.POST("/flavors", admin.CreateFlavor)
+		AdminGroup.PUT("/flavors/:id", admin.UpdateFlavor)
+		AdminGroup.DELETE("/flavors/:id", admin.DeleteFlavor)
+
 		// 兑换码管理
 		AdminGroup.GET("/redemption-codes", admin.GetRedemptionCodes)
 		AdminGroup.POST("/redemption-codes", admin.CreateRedemptionCodeFixed)
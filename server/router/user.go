@@ -21,6 +21,9 @@ func InitUserRouter(Router *gin.RouterGroup) {
 		UserGroup.GET("/user/profile", user.GetUserInfo)
 		UserGroup.PUT("/user/profile", user.UpdateProfile)
 		UserGroup.PUT("/user/reset-password", user.UserResetPassword)
+		UserGroup.GET("/user/ssh-keys", user.GetSSHKeys)
+		UserGroup.POST("/user/ssh-keys", user.CreateSSHKey)
+		UserGroup.DELETE("/user/ssh-keys/:id", user.DeleteSSHKey)
 		UserGroup.GET("/user/info", user.GetUserInfo)
 		UserGroup.GET("/user/dashboard", user.GetUserDashboard)
 		UserGroup.GET("/user/limits", user.GetUserLimits)
@@ -36,7 +39,34 @@ func InitUserRouter(Router *gin.RouterGroup) {
 		UserGroup.PUT("/user/instances/:id/reset-password", user.ResetInstancePassword)
 		UserGroup.GET("/user/instances/:id/password/:taskId", user.GetInstanceNewPassword)
 		UserGroup.GET("/user/instances/:id/ports", user.GetInstancePorts)
-		UserGroup.GET("/user/instances/:id/ssh", user.SSHWebSocket) // WebSocket SSH连接
+		UserGroup.PUT("/user/instances/:id/resize", user.ResizeInstance)
+		UserGroup.POST("/user/instances/:id/reinstall", user.ReinstallInstance)
+		UserGroup.GET("/user/instances/:id/snapshots", user.GetInstanceSnapshots)
+		UserGroup.POST("/user/instances/:id/snapshots", user.CreateInstanceSnapshot)
+		UserGroup.POST("/user/instances/:id/snapshots/:snapshotId/restore", user.RestoreInstanceSnapshot)
+		UserGroup.DELETE("/user/instances/:id/snapshots/:snapshotId", user.DeleteInstanceSnapshot)
+		UserGroup.GET("/user/instances/:id/backups", user.GetInstanceBackups)
+		UserGroup.POST("/user/instances/:id/backups", user.CreateInstanceBackup)
+		UserGroup.POST("/user/instances/:id/backups/:backupId/restore", user.RestoreInstanceBackup)
+		UserGroup.GET("/user/instances/:id/backups/:backupId/download", user.DownloadInstanceBackup)
+		UserGroup.DELETE("/user/instances/:id/backups/:backupId", user.DeleteInstanceBackup)
+		UserGroup.GET("/user/instances/:id/backup-policy", user.GetInstanceBackupPolicy)
+		UserGroup.PUT("/user/instances/:id/backup-policy", user.UpdateInstanceBackupPolicy)
+		UserGroup.GET("/user/instances/:id/firewall", user.GetInstanceFirewall)
+		UserGroup.PUT("/user/instances/:id/firewall", user.UpdateInstanceFirewall)
+		UserGroup.GET("/user/volumes", user.GetUserVolumes)
+		UserGroup.POST("/user/volumes", user.CreateVolume)
+		UserGroup.POST("/user/volumes/:id/attach", user.AttachVolume)
+		UserGroup.POST("/user/volumes/:id/detach", user.DetachVolume)
+		UserGroup.POST("/user/volumes/:id/resize", user.ResizeVolume)
+		UserGroup.DELETE("/user/volumes/:id", user.DeleteVolume)
+		UserGroup.GET("/user/private-networks", user.GetUserPrivateNetworks)
+		UserGroup.POST("/user/private-networks", user.CreatePrivateNetwork)
+		UserGroup.DELETE("/user/private-networks/:id", user.DeletePrivateNetwork)
+		UserGroup.POST("/user/private-networks/:id/attach", user.AttachPrivateNetwork)
+		UserGroup.POST("/user/private-networks/:id/detach", user.DetachPrivateNetwork)
+		UserGroup.GET("/user/instances/:id/ssh", user.SSHWebSocket)                 // WebSocket SSH连接
+		UserGroup.GET("/user/instances/:id/console", user.InstanceConsoleWebSocket) // WebSocket虚拟化控制台
 		UserGroup.POST("/user/instances/action", user.InstanceAction)
 		UserGroup.GET("/user/instances/:id/logs", user.GetInstanceLogs)
 
@@ -58,6 +88,8 @@ func InitUserRouter(Router *gin.RouterGroup) {
 		// 资源管理
 		UserGroup.GET("/user/resources/available", user.GetAvailableResources)
 		UserGroup.POST("/user/resources/claim", user.ClaimResource)
+		UserGroup.POST("/user/resources/placement", user.PreviewPlacement)
+		UserGroup.GET("/user/flavors", user.GetAvailableFlavors)
 		UserGroup.GET("/user/providers/available", user.GetAvailableProviders)
 		UserGroup.GET("/user/images", user.GetUserSystemImages)
 		UserGroup.GET("/user/images/filtered", user.GetFilteredSystemImages)
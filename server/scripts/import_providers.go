@@ -0,0 +1,83 @@
+// 按清单批量导入节点，与管理后台 POST /admin/providers/import 使用相同的逻辑
+// 用法: go run scripts/import_providers.go -f providers.yaml [-concurrency 4] [-skip-ssh-test] [-auto-configure=false]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"oneclickvirt/core"
+	"oneclickvirt/global"
+	"oneclickvirt/initialize"
+	adminProvider "oneclickvirt/service/admin/provider"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	manifestPath := flag.String("f", "", "节点清单文件(.yaml/.yml/.csv)")
+	format := flag.String("format", "", "清单格式(yaml/csv)，默认根据文件扩展名判断")
+	concurrency := flag.Int("concurrency", 4, "同时处理的节点数，最大10")
+	skipSSHTest := flag.Bool("skip-ssh-test", false, "跳过SSH连接测试")
+	autoConfigure := flag.Bool("auto-configure", true, "为LXD、Incus和Proxmox节点执行自动配置")
+	flag.Parse()
+
+	if *manifestPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	data, err := os.ReadFile(*manifestPath)
+	if err != nil {
+		fmt.Printf("读取清单失败: %v\n", err)
+		os.Exit(1)
+	}
+	if *format == "" {
+		*format = adminProvider.ManifestFormatFromFilename(*manifestPath)
+	}
+	requests, err := adminProvider.ParseProviderManifest(data, *format)
+	if err != nil {
+		fmt.Printf("清单格式错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 初始化核心组件
+	global.APP_VP = core.Viper()
+	global.APP_LOG = core.Zap()
+	zap.ReplaceGlobals(global.APP_LOG)
+
+	fmt.Println("正在连接数据库...")
+	global.APP_DB = initialize.Gorm()
+	if global.APP_DB == nil {
+		fmt.Println("数据库连接失败")
+		os.Exit(1)
+	}
+
+	fmt.Printf("开始导入 %d 个节点...\n", len(requests))
+	results := adminProvider.NewService().ImportProviders(context.Background(), requests, adminProvider.ProviderImportOptions{
+		Concurrency:   *concurrency,
+		SkipSSHTest:   *skipSSHTest,
+		AutoConfigure: *autoConfigure,
+	})
+
+	failed := 0
+	for _, result := range results {
+		line := fmt.Sprintf("第%d行 %-24s %-8s", result.Row, result.Name, result.Action)
+		if result.ProviderID > 0 {
+			line += fmt.Sprintf(" id=%d configured=%v", result.ProviderID, result.Configured)
+		}
+		if result.Error != "" {
+			line += " 错误: " + result.Error
+			failed++
+		}
+		if result.ConfigureError != "" {
+			line += " 自动配置失败: " + result.ConfigureError
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("导入完成: 共 %d 个，失败 %d 个\n", len(results), failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
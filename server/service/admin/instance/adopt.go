@@ -0,0 +1,439 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/provider"
+	"oneclickvirt/service/admin/traffic_monitor"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/resources"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// dnatDestinationPattern 匹配 iptables-save 格式中的 DNAT 目标，如 --to-destination 10.0.3.15:22 或 10.0.3.15:20000-20010
+	dnatDestinationPattern = regexp.MustCompile(`--to-destination\s+([0-9.]+):([0-9]+(?:-[0-9]+)?)`)
+	dnatDportPattern       = regexp.MustCompile(`--dport\s+([0-9]+(?::[0-9]+)?)`)
+	dnatProtocolPattern    = regexp.MustCompile(`-p\s+(tcp|udp)\b`)
+)
+
+// dnatRule 宿主机上的一条端口DNAT规则
+type dnatRule struct {
+	DestIP string
+	Port   provider.InspectedPort
+}
+
+// ListUnmanagedInstances 列出节点上存在但面板中没有记录的实例，并尽量读取实例的实际配置和端口转发
+func (s *Service) ListUnmanagedInstances(ctx context.Context, providerID uint) ([]adminModel.UnmanagedInstance, error) {
+	prov, _, err := (&provider2.ProviderApiService{}).GetProviderByID(providerID)
+	if err != nil {
+		return nil, err
+	}
+	return discoverUnmanagedInstances(ctx, prov, providerID, nil)
+}
+
+// AdoptInstances 将节点上未管理的实例导入面板并分配给用户
+// 每个实例单独事务：创建实例记录、登记发现的端口映射、计入节点资源和用户配额，提交后启动流量监控
+func (s *Service) AdoptInstances(ctx context.Context, req adminModel.AdoptInstancesRequest) ([]adminModel.AdoptInstanceResult, error) {
+	prov, dbProvider, err := (&provider2.ProviderApiService{}).GetProviderByID(req.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(req.Instances))
+	for _, item := range req.Instances {
+		names[item.Name] = true
+	}
+	unmanaged, err := discoverUnmanagedInstances(ctx, prov, req.ProviderID, names)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]adminModel.UnmanagedInstance, len(unmanaged))
+	for _, u := range unmanaged {
+		byName[u.Name] = u
+	}
+
+	results := make([]adminModel.AdoptInstanceResult, 0, len(req.Instances))
+	for _, item := range req.Instances {
+		result := adminModel.AdoptInstanceResult{Name: item.Name}
+		found, ok := byName[item.Name]
+		if !ok {
+			result.Error = "节点上不存在该实例或实例已被面板管理"
+			results = append(results, result)
+			continue
+		}
+
+		instance, portCount, err := adoptInstance(dbProvider, found, item)
+		if err != nil {
+			global.APP_LOG.Warn("导入实例失败",
+				zap.Uint("providerID", req.ProviderID),
+				zap.String("instanceName", item.Name),
+				zap.Error(err))
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.InstanceID = instance.ID
+		result.PortCount = portCount
+
+		if err := traffic_monitor.GetManager().AttachMonitor(ctx, instance.ID); err != nil {
+			global.APP_LOG.Warn("导入实例后启动流量监控失败",
+				zap.Uint("instanceID", instance.ID),
+				zap.Error(err))
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// adoptInstance 在一个事务中为导入的实例创建记录并计入资源
+func adoptInstance(dbProvider *providerModel.Provider, found adminModel.UnmanagedInstance, item adminModel.AdoptInstanceItem) (*providerModel.Instance, int, error) {
+	cpu, memory, disk := found.CPU, found.Memory, found.Disk
+	if item.CPU > 0 {
+		cpu = item.CPU
+	}
+	if item.Memory > 0 {
+		memory = item.Memory
+	}
+	if item.Disk > 0 {
+		disk = item.Disk
+	}
+	if cpu <= 0 || memory <= 0 || disk <= 0 {
+		return nil, 0, errors.New("无法从节点读取实例的CPU、内存或磁盘配置，请手动填写")
+	}
+	bandwidth := item.Bandwidth
+	if bandwidth <= 0 {
+		bandwidth = dbProvider.DefaultOutboundBandwidth
+	}
+
+	var user userModel.User
+	if err := global.APP_DB.First(&user, item.UserID).Error; err != nil {
+		return nil, 0, errors.New("用户不存在")
+	}
+
+	expiredAt := time.Now().AddDate(1, 0, 0)
+	if item.ExpiredAt != nil {
+		expiredAt = *item.ExpiredAt
+	} else if dbProvider.ExpiresAt != nil {
+		expiredAt = *dbProvider.ExpiresAt
+	}
+
+	instance := &providerModel.Instance{
+		Name:         found.Name,
+		Provider:     dbProvider.Name,
+		ProviderID:   dbProvider.ID,
+		Status:       adoptedStatus(found.Status),
+		Image:        found.Image,
+		InstanceType: found.InstanceType,
+		CPU:          cpu,
+		Memory:       memory,
+		Disk:         disk,
+		Bandwidth:    bandwidth,
+		PrivateIP:    found.PrivateIP,
+		IPv6Address:  found.IPv6Address,
+		PublicIP:     dbProvider.Endpoint,
+		Username:     "root",
+		ExpiredAt:    expiredAt,
+		UserID:       user.ID,
+	}
+	if dbProvider.PortIP != "" {
+		instance.PublicIP = dbProvider.PortIP
+	}
+
+	portCount := 0
+	err := global.APP_DB.Transaction(func(tx *gorm.DB) error {
+		// 已软删除的同名记录会占用唯一索引，实例仍在节点上说明删除未完成，清理旧记录后重新导入
+		if err := tx.Unscoped().Where("provider_id = ? AND name = ? AND deleted_at IS NOT NULL", dbProvider.ID, found.Name).
+			Delete(&providerModel.Instance{}).Error; err != nil {
+			return fmt.Errorf("清理旧实例记录失败: %v", err)
+		}
+		if err := tx.Create(instance).Error; err != nil {
+			return fmt.Errorf("创建实例记录失败: %v", err)
+		}
+
+		for _, port := range found.Ports {
+			hostPortEnd := port.HostPort
+			if port.HostPortEnd > 0 {
+				hostPortEnd = port.HostPortEnd
+			}
+			var conflict int64
+			tx.Model(&providerModel.Port{}).
+				Where("provider_id = ? AND status = ? AND protocol IN ?", dbProvider.ID, "active", adoptConflictProtocols(port.Protocol)).
+				Where("host_port <= ? AND (host_port_end >= ? OR (host_port_end = 0 AND host_port >= ?))", hostPortEnd, port.HostPort, port.HostPort).
+				Count(&conflict)
+			if conflict > 0 {
+				global.APP_LOG.Warn("导入实例的端口已被其他映射占用，跳过",
+					zap.String("instanceName", found.Name),
+					zap.Int("hostPort", port.HostPort))
+				continue
+			}
+
+			record := providerModel.Port{
+				InstanceID:   instance.ID,
+				ProviderID:   dbProvider.ID,
+				HostPort:     port.HostPort,
+				HostPortEnd:  port.HostPortEnd,
+				GuestPort:    port.GuestPort,
+				GuestPortEnd: port.GuestPortEnd,
+				PortCount:    hostPortEnd - port.HostPort + 1,
+				Protocol:     port.Protocol,
+				Description:  "导入",
+				Status:       "active",
+				IsSSH:        port.GuestPort == 22 && port.GuestPortEnd == 0,
+				IsAutomatic:  false,
+				PortType:     "manual",
+			}
+			if err := tx.Create(&record).Error; err != nil {
+				return fmt.Errorf("创建端口映射失败: %v", err)
+			}
+			portCount++
+		}
+
+		resourceService := &resources.ResourceService{}
+		if err := resourceService.AllocateResourcesInTx(tx, dbProvider.ID, instance.InstanceType, cpu, memory, disk); err != nil {
+			return fmt.Errorf("计入节点资源失败: %v", err)
+		}
+		resourceUsage := resources.ResourceUsage{
+			CPU:       cpu,
+			Memory:    memory,
+			Disk:      disk,
+			Bandwidth: bandwidth,
+		}
+		if err := resources.NewQuotaService().UpdateUserQuotaAfterCreationWithTx(tx, user.ID, resourceUsage); err != nil {
+			return fmt.Errorf("更新用户配额失败: %v", err)
+		}
+
+		// 实例已被接管，对应的孤儿漂移记录视为已修复
+		now := time.Now()
+		return tx.Model(&providerModel.InstanceDrift{}).
+			Where("provider_id = ? AND kind = ? AND instance_name = ? AND fixed_at IS NULL",
+				dbProvider.ID, providerModel.DriftKindOrphan, found.Name).
+			Updates(map[string]interface{}{"fixed_at": now, "fix_action": "导入实例", "fixed_by": "manual"}).Error
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	global.APP_LOG.Info("实例已导入",
+		zap.Uint("providerID", dbProvider.ID),
+		zap.Uint("instanceID", instance.ID),
+		zap.String("instanceName", instance.Name),
+		zap.Uint("userID", user.ID),
+		zap.Int("ports", portCount))
+	return instance, portCount, nil
+}
+
+// discoverUnmanagedInstances 读取节点上的实例列表，排除已有记录的实例，names不为空时只处理指定的实例
+func discoverUnmanagedInstances(ctx context.Context, prov provider.Provider, providerID uint, names map[string]bool) ([]adminModel.UnmanagedInstance, error) {
+	listCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	hostInstances, err := prov.ListInstances(listCtx)
+	if err != nil {
+		return nil, fmt.Errorf("获取节点实例列表失败: %v", err)
+	}
+
+	var managed []string
+	if err := global.APP_DB.Model(&providerModel.Instance{}).Where("provider_id = ?", providerID).
+		Pluck("name", &managed).Error; err != nil {
+		return nil, fmt.Errorf("查询实例记录失败: %v", err)
+	}
+	managedSet := make(map[string]bool, len(managed))
+	for _, name := range managed {
+		managedSet[name] = true
+	}
+
+	// DNAT规则只读取一次，读取失败时只使用实例自身声明的端口转发
+	var rules []dnatRule
+	if output, err := prov.ExecuteSSHCommand(ctx, "iptables -t nat -S 2>/dev/null"); err == nil {
+		rules = parseDNATRules(output)
+	}
+
+	inspector, canInspect := prov.(provider.InstanceInspector)
+	unmanaged := make([]adminModel.UnmanagedInstance, 0)
+	for _, host := range hostInstances {
+		if host.Name == "" || managedSet[host.Name] || managedSet[host.ID] {
+			continue
+		}
+		if names != nil && !names[host.Name] {
+			continue
+		}
+
+		item := adminModel.UnmanagedInstance{
+			Name:         host.Name,
+			Status:       host.Status,
+			InstanceType: adoptedInstanceType(host.Type),
+			Image:        host.Image,
+			PrivateIP:    host.PrivateIP,
+			IPv6Address:  host.IPv6Address,
+		}
+		if item.PrivateIP == "" {
+			item.PrivateIP = host.IP
+		}
+		if cpu, err := strconv.Atoi(strings.TrimSpace(host.CPU)); err == nil {
+			item.CPU = cpu
+		}
+
+		var devicePorts []provider.InspectedPort
+		if canInspect {
+			inspection, err := inspector.InspectInstance(ctx, host.Name)
+			if err != nil {
+				item.InspectError = err.Error()
+			} else {
+				item.Inspected = true
+				devicePorts = inspection.Ports
+				mergeInspection(&item, inspection)
+			}
+		} else {
+			item.InspectError = "该类型节点不支持读取实例配置，请手动填写资源"
+		}
+		item.Ports = mergeDiscoveredPorts(devicePorts, rules, item.PrivateIP)
+		unmanaged = append(unmanaged, item)
+	}
+	return unmanaged, nil
+}
+
+// mergeInspection 用读取到的实际配置覆盖实例列表中的信息
+func mergeInspection(item *adminModel.UnmanagedInstance, inspection *provider.InstanceInspection) {
+	if inspection.InstanceType != "" {
+		item.InstanceType = inspection.InstanceType
+	}
+	if inspection.Image != "" {
+		item.Image = inspection.Image
+	}
+	if inspection.CPU > 0 {
+		item.CPU = inspection.CPU
+	}
+	item.Memory = inspection.Memory
+	item.Disk = inspection.Disk
+	if inspection.PrivateIP != "" {
+		item.PrivateIP = inspection.PrivateIP
+	}
+	if inspection.IPv6Address != "" {
+		item.IPv6Address = inspection.IPv6Address
+	}
+}
+
+// parseDNATRules 解析 iptables -t nat -S 输出中的DNAT规则
+func parseDNATRules(output string) []dnatRule {
+	var rules []dnatRule
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, "-A ") || !strings.Contains(line, "-j DNAT") {
+			continue
+		}
+		dest := dnatDestinationPattern.FindStringSubmatch(line)
+		dport := dnatDportPattern.FindStringSubmatch(line)
+		proto := dnatProtocolPattern.FindStringSubmatch(line)
+		if dest == nil || dport == nil || proto == nil {
+			continue
+		}
+
+		hostStart, hostEnd, err := provider.ParsePortRange(strings.Replace(dport[1], ":", "-", 1))
+		if err != nil {
+			continue
+		}
+		guestStart, guestEnd, err := provider.ParsePortRange(dest[2])
+		if err != nil {
+			continue
+		}
+		// 端口段DNAT未指定目标端口段时，目标端口与宿主机端口一一对应
+		if hostEnd > 0 && guestEnd == 0 && guestStart == hostStart {
+			guestEnd = hostEnd
+		}
+		rules = append(rules, dnatRule{
+			DestIP: dest[1],
+			Port: provider.InspectedPort{
+				HostPort:     hostStart,
+				HostPortEnd:  hostEnd,
+				GuestPort:    guestStart,
+				GuestPortEnd: guestEnd,
+				Protocol:     proto[1],
+			},
+		})
+	}
+	return rules
+}
+
+// mergeDiscoveredPorts 合并设备端口与指向实例IP的DNAT规则，同一映射的tcp和udp合并为both，按宿主机端口排序
+func mergeDiscoveredPorts(devicePorts []provider.InspectedPort, rules []dnatRule, privateIP string) []adminModel.UnmanagedPort {
+	type portKey struct{ hostStart, hostEnd, guestStart, guestEnd int }
+	merged := make(map[portKey]*adminModel.UnmanagedPort)
+	var order []portKey
+
+	add := func(port provider.InspectedPort, source string) {
+		key := portKey{port.HostPort, port.HostPortEnd, port.GuestPort, port.GuestPortEnd}
+		existing, ok := merged[key]
+		if !ok {
+			merged[key] = &adminModel.UnmanagedPort{
+				HostPort:     port.HostPort,
+				HostPortEnd:  port.HostPortEnd,
+				GuestPort:    port.GuestPort,
+				GuestPortEnd: port.GuestPortEnd,
+				Protocol:     port.Protocol,
+				Source:       source,
+			}
+			order = append(order, key)
+			return
+		}
+		if existing.Protocol != port.Protocol {
+			existing.Protocol = "both"
+		}
+	}
+
+	for _, port := range devicePorts {
+		add(port, "device")
+	}
+	if privateIP != "" {
+		for _, rule := range rules {
+			if rule.DestIP == privateIP {
+				add(rule.Port, "iptables")
+			}
+		}
+	}
+
+	ports := make([]adminModel.UnmanagedPort, 0, len(order))
+	for _, key := range order {
+		ports = append(ports, *merged[key])
+	}
+	sort.SliceStable(ports, func(i, j int) bool { return ports[i].HostPort < ports[j].HostPort })
+	return ports
+}
+
+// adoptConflictProtocols 与指定协议冲突的已有端口映射协议
+func adoptConflictProtocols(protocol string) []string {
+	if protocol == "both" {
+		return []string{"tcp", "udp", "both"}
+	}
+	return []string{protocol, "both"}
+}
+
+// adoptedInstanceType 将节点报告的实例类型转换为面板使用的类型
+func adoptedInstanceType(hostType string) string {
+	switch strings.ToLower(hostType) {
+	case "vm", "virtual-machine", "qemu", "kvm":
+		return "vm"
+	default:
+		return "container"
+	}
+}
+
+// adoptedStatus 将节点报告的实例状态转换为面板使用的状态，非运行状态统一视为已停止
+func adoptedStatus(hostStatus string) string {
+	status := strings.ToLower(strings.TrimSpace(hostStatus))
+	if status == "running" || status == "active" || strings.HasPrefix(status, "up") {
+		return "running"
+	}
+	return "stopped"
+}
@@ -0,0 +1,36 @@
+package instance
+
+import (
+	"testing"
+
+	"oneclickvirt/provider"
+)
+
+func TestParseDNATRulesAndMergePorts(t *testing.T) {
+	output := `-P PREROUTING ACCEPT
+-A PREROUTING -p tcp -m tcp --dport 10022 -j DNAT --to-destination 10.0.3.15:22
+-A PREROUTING -p udp -m udp --dport 10022 -j DNAT --to-destination 10.0.3.15:22
+-A PREROUTING -p tcp -m tcp --dport 20000:20010 -j DNAT --to-destination 10.0.3.15:20000-20010
+-A DOCKER ! -i docker0 -p tcp -m tcp --dport 18080 -j DNAT --to-destination 10.0.3.99:80
+-A POSTROUTING -s 10.0.3.0/24 -j MASQUERADE`
+
+	rules := parseDNATRules(output)
+	if len(rules) != 4 {
+		t.Fatalf("expected 4 DNAT rules, got %d", len(rules))
+	}
+
+	device := []provider.InspectedPort{{HostPort: 10080, GuestPort: 80, Protocol: "tcp"}}
+	ports := mergeDiscoveredPorts(device, rules, "10.0.3.15")
+	if len(ports) != 3 {
+		t.Fatalf("expected 3 merged ports, got %+v", ports)
+	}
+	if ports[0].HostPort != 10022 || ports[0].Protocol != "both" || ports[0].Source != "iptables" {
+		t.Errorf("tcp and udp rules for the same mapping should merge into both: %+v", ports[0])
+	}
+	if ports[1].HostPort != 10080 || ports[1].Source != "device" {
+		t.Errorf("device port missing: %+v", ports[1])
+	}
+	if ports[2].HostPortEnd != 20010 || ports[2].GuestPortEnd != 20010 {
+		t.Errorf("port range not parsed: %+v", ports[2])
+	}
+}
@@ -0,0 +1,156 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	resourceModel "oneclickvirt/model/resource"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/resources"
+
+	"go.uber.org/zap"
+)
+
+// EvacuateProvider 为维护中节点上的所有实例排队迁移任务
+// 按内存从大到小依次为实例选择剩余内存最多且资源充足的同类型节点，无法安排的实例记录原因后跳过
+func (s *Service) EvacuateProvider(providerID uint) (*adminModel.EvacuationResult, error) {
+	var source providerModel.Provider
+	if err := global.APP_DB.First(&source, providerID).Error; err != nil {
+		return nil, errors.New("节点不存在")
+	}
+	if !source.MaintenanceMode {
+		return nil, errors.New("只有处于维护模式的节点才能批量迁移实例")
+	}
+
+	result := &adminModel.EvacuationResult{
+		Queued:  []adminModel.MaintenanceMigrationItem{},
+		Skipped: []adminModel.EvacuationSkippedItem{},
+	}
+
+	var instances []providerModel.Instance
+	if err := global.APP_DB.Where("provider_id = ? AND status NOT IN (?)", source.ID, []string{"deleting", "deleted"}).
+		Find(&instances).Error; err != nil {
+		return nil, fmt.Errorf("查询节点实例失败: %v", err)
+	}
+	if len(instances) == 0 {
+		return result, nil
+	}
+	// 先安排大实例，减少碎片导致的无处可放
+	sort.SliceStable(instances, func(i, j int) bool {
+		return instances[i].Memory > instances[j].Memory
+	})
+
+	var candidates []providerModel.Provider
+	if err := global.APP_DB.Where("type = ? AND id <> ? AND is_frozen = ? AND maintenance_mode = ? AND status <> ?",
+		source.Type, source.ID, false, false, "inactive").
+		Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("查询目标节点失败: %v", err)
+	}
+	// 已探测过能力的节点以能力文档为准
+	usable := candidates[:0]
+	for _, candidate := range candidates {
+		if _, probed := candidate.ParseCapabilities(); probed {
+			if err := provider2.RequireCapabilities(&candidate, providerModel.CapabilityMigration); err != nil {
+				continue
+			}
+		}
+		usable = append(usable, candidate)
+	}
+	candidates = usable
+
+	resourceService := &resources.ResourceService{}
+	for _, instance := range instances {
+		if instance.Status != "running" && instance.Status != "stopped" {
+			result.Skipped = append(result.Skipped, adminModel.EvacuationSkippedItem{
+				InstanceID:   instance.ID,
+				InstanceName: instance.Name,
+				Reason:       fmt.Sprintf("实例状态为 %s，无法迁移", instance.Status),
+			})
+			continue
+		}
+
+		target := s.pickEvacuationTarget(resourceService, candidates, instance)
+		if target < 0 {
+			result.Skipped = append(result.Skipped, adminModel.EvacuationSkippedItem{
+				InstanceID:   instance.ID,
+				InstanceName: instance.Name,
+				Reason:       "没有资源充足的同类型节点",
+			})
+			continue
+		}
+
+		taskID, err := s.MigrateInstance(instance.ID, adminModel.MigrateInstanceRequest{TargetProviderID: candidates[target].ID})
+		if err != nil {
+			result.Skipped = append(result.Skipped, adminModel.EvacuationSkippedItem{
+				InstanceID:   instance.ID,
+				InstanceName: instance.Name,
+				Reason:       err.Error(),
+			})
+			continue
+		}
+
+		// 在内存副本上累加本次规划的占用，后续实例基于累加后的余量选择节点
+		reserveEvacuationResources(&candidates[target], instance)
+		result.Queued = append(result.Queued, adminModel.MaintenanceMigrationItem{
+			TaskID:           taskID,
+			InstanceID:       instance.ID,
+			InstanceName:     instance.Name,
+			TargetProviderID: candidates[target].ID,
+			Status:           "pending",
+		})
+	}
+
+	global.APP_LOG.Info("节点批量迁移任务已创建",
+		zap.Uint("providerID", source.ID),
+		zap.String("providerName", source.Name),
+		zap.Int("queued", len(result.Queued)),
+		zap.Int("skipped", len(result.Skipped)))
+
+	return result, nil
+}
+
+// pickEvacuationTarget 选择剩余内存最多且能容纳实例的节点，返回其在candidates中的下标，没有可用节点时返回-1
+func (s *Service) pickEvacuationTarget(resourceService *resources.ResourceService, candidates []providerModel.Provider, instance providerModel.Instance) int {
+	best := -1
+	var bestFree int64
+	for i := range candidates {
+		candidate := &candidates[i]
+		check := resourceService.CheckProviderAvailability(candidate, resourceModel.ResourceCheckRequest{
+			ProviderID:   candidate.ID,
+			InstanceType: instance.InstanceType,
+			CPU:          instance.CPU,
+			Memory:       instance.Memory,
+			Disk:         instance.Disk,
+		})
+		if !check.Allowed {
+			continue
+		}
+		// 导入时使用原实例名，目标节点上不能存在同名实例
+		var sameNameCount int64
+		global.APP_DB.Model(&providerModel.Instance{}).
+			Where("provider_id = ? AND name = ?", candidate.ID, instance.Name).
+			Count(&sameNameCount)
+		if sameNameCount > 0 {
+			continue
+		}
+		if best < 0 || check.AvailableMemory > bestFree {
+			best = i
+			bestFree = check.AvailableMemory
+		}
+	}
+	return best
+}
+
+// reserveEvacuationResources 按资源限制配置在节点副本上累加实例占用，与AllocateResourcesInTx的扣减规则一致
+func reserveEvacuationResources(p *providerModel.Provider, instance providerModel.Instance) {
+	if instance.InstanceType == "vm" {
+		p.VMCount++
+	} else {
+		p.ContainerCount++
+	}
+	p.ReserveResources(instance.InstanceType, instance.CPU, instance.Memory, instance.Disk)
+}
@@ -0,0 +1,141 @@
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	resourceModel "oneclickvirt/model/resource"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/resources"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MigrateInstance 管理员将实例迁移到同类型的其他节点（异步任务）
+func (s *Service) MigrateInstance(instanceID uint, req adminModel.MigrateInstanceRequest) (uint, error) {
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("实例不存在")
+		}
+		return 0, err
+	}
+
+	if instance.Status != "running" && instance.Status != "stopped" {
+		return 0, errors.New("只有运行中或已停止的实例才能迁移")
+	}
+	if req.TargetProviderID == instance.ProviderID {
+		return 0, errors.New("目标节点不能与当前节点相同")
+	}
+	// 数据卷位于源节点的存储池中，不随实例归档迁移
+	if hasVolumes, err := resources.InstanceHasVolumes(global.APP_DB, instance.ID); err != nil {
+		return 0, err
+	} else if hasVolumes {
+		return 0, errors.New("实例挂载了数据卷，请先卸载数据卷后再迁移")
+	}
+	if hasNetworks, err := resources.InstanceHasPrivateNetworks(global.APP_DB, instance.ID); err != nil {
+		return 0, err
+	} else if hasNetworks {
+		return 0, errors.New("实例已接入私有网络，请先断开私有网络后再迁移")
+	}
+
+	var sourceProvider, targetProvider providerModel.Provider
+	if err := global.APP_DB.First(&sourceProvider, instance.ProviderID).Error; err != nil {
+		return 0, fmt.Errorf("源节点不存在: %v", err)
+	}
+	if err := global.APP_DB.First(&targetProvider, req.TargetProviderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("目标节点不存在")
+		}
+		return 0, err
+	}
+	if sourceProvider.Type != targetProvider.Type {
+		return 0, fmt.Errorf("只能迁移到相同类型的节点，当前节点类型为 %s，目标节点类型为 %s", sourceProvider.Type, targetProvider.Type)
+	}
+	if _, probed := sourceProvider.ParseCapabilities(); probed {
+		// 已探测过能力的节点以能力文档为准
+		if err := provider2.RequireCapabilities(&sourceProvider, providerModel.CapabilityMigration); err != nil {
+			return 0, err
+		}
+		if err := provider2.RequireCapabilities(&targetProvider, providerModel.CapabilityMigration); err != nil {
+			return 0, err
+		}
+	} else if sourceProvider.Type != "lxd" && sourceProvider.Type != "incus" && sourceProvider.Type != "proxmox" && sourceProvider.Type != "libvirt" {
+		return 0, fmt.Errorf("%s 类型的节点不支持实例迁移", sourceProvider.Type)
+	}
+	if targetProvider.IsFrozen {
+		return 0, errors.New("目标节点已被冻结")
+	}
+	if targetProvider.MaintenanceMode {
+		return 0, errors.New("目标节点处于维护模式")
+	}
+
+	resourceService := &resources.ResourceService{}
+	if err := resourceService.ValidateInstanceTypeSupport(targetProvider.ID, instance.InstanceType); err != nil {
+		return 0, err
+	}
+
+	// 导入时使用原实例名，目标节点上不能存在同名实例
+	var sameNameCount int64
+	global.APP_DB.Model(&providerModel.Instance{}).
+		Where("provider_id = ? AND name = ?", targetProvider.ID, instance.Name).
+		Count(&sameNameCount)
+	if sameNameCount > 0 {
+		return 0, errors.New("目标节点上已存在同名实例")
+	}
+
+	var existingTask adminModel.Task
+	if err := global.APP_DB.Where("instance_id = ? AND task_type = 'migrate' AND status IN ('pending', 'running')", instance.ID).First(&existingTask).Error; err == nil {
+		return 0, errors.New("该实例已有进行中的迁移任务，请稍后重试")
+	}
+
+	// 提前检查目标节点资源，任务执行时会在事务中再次检查并占用
+	checkResult, err := resourceService.CheckProviderResources(resourceModel.ResourceCheckRequest{
+		ProviderID:   targetProvider.ID,
+		InstanceType: instance.InstanceType,
+		CPU:          instance.CPU,
+		Memory:       instance.Memory,
+		Disk:         instance.Disk,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("检查目标节点资源失败: %v", err)
+	}
+	if !checkResult.Allowed {
+		return 0, fmt.Errorf("目标节点资源不足: %s", checkResult.Reason)
+	}
+
+	taskData, err := json.Marshal(adminModel.MigrateTaskRequest{
+		InstanceId:       instance.ID,
+		SourceProviderId: sourceProvider.ID,
+		TargetProviderId: targetProvider.ID,
+		OriginalStatus:   instance.Status,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("序列化任务数据失败: %v", err)
+	}
+
+	// 管理员任务使用实例的用户ID，任务在源节点的队列中执行
+	task, err := s.taskService.CreateTask(instance.UserID, &instance.ProviderID, &instance.ID, "migrate", string(taskData), 7200)
+	if err != nil {
+		global.APP_LOG.Error("管理员创建迁移实例任务失败",
+			zap.Uint("instanceID", instanceID),
+			zap.Error(err))
+		return 0, fmt.Errorf("创建迁移任务失败: %v", err)
+	}
+
+	global.APP_DB.Model(&instance).Update("status", "migrating")
+
+	global.APP_LOG.Info("管理员创建迁移实例任务成功",
+		zap.Uint("instanceID", instanceID),
+		zap.Uint("taskID", task.ID),
+		zap.String("instanceName", instance.Name),
+		zap.String("sourceProvider", sourceProvider.Name),
+		zap.String("targetProvider", targetProvider.Name))
+
+	return task.ID, nil
+}
@@ -9,6 +9,7 @@ import (
 	"oneclickvirt/service/interfaces"
 	"oneclickvirt/service/resources"
 	"oneclickvirt/service/traffic"
+	"strings"
 	"time"
 
 	"oneclickvirt/global"
@@ -305,6 +306,7 @@ func (s *Service) CreateInstance(req admin.CreateInstanceRequest) error {
 		Status:       "creating",
 		ExpiredAt:    expiredAt,
 		PublicIP:     provider.Endpoint, // 设置公网IP为Provider的地址
+		Node:         strings.TrimSpace(req.Node),
 	}
 
 	// 初始化数据库服务
@@ -0,0 +1,210 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/database"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// GetInstanceSnapshots 管理员获取实例快照列表
+func (s *Service) GetInstanceSnapshots(instanceID uint) ([]providerModel.InstanceSnapshot, error) {
+	var count int64
+	if err := global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", instanceID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, errors.New("实例不存在")
+	}
+
+	var snapshots []providerModel.InstanceSnapshot
+	if err := global.APP_DB.Where("instance_id = ?", instanceID).Order("created_at DESC").Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("获取快照列表失败: %v", err)
+	}
+	return snapshots, nil
+}
+
+// CreateInstanceSnapshot 管理员创建实例快照（异步任务），快照数量仍受实例所属用户等级限制
+func (s *Service) CreateInstanceSnapshot(instanceID uint, req adminModel.CreateSnapshotRequest) (*userModel.SnapshotTaskResponse, error) {
+	instance, err := s.getSnapshotInstance(instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.IsValidSnapshotName(req.Name) {
+		return nil, errors.New("快照名称只能包含字母、数字、下划线和连字符，且必须以字母开头")
+	}
+
+	var existing providerModel.InstanceSnapshot
+	if err := global.APP_DB.Where("instance_id = ? AND name = ?", instance.ID, req.Name).First(&existing).Error; err == nil {
+		return nil, errors.New("快照名称已存在")
+	}
+
+	snapshot := providerModel.InstanceSnapshot{
+		InstanceID:  instance.ID,
+		ProviderID:  instance.ProviderID,
+		UserID:      instance.UserID,
+		Name:        req.Name,
+		Description: req.Description,
+		Status:      "creating",
+	}
+
+	dbService := database.GetDatabaseService()
+	if err := dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+		quotaService := resources.NewQuotaService()
+		result, err := quotaService.ValidateSnapshotCreationInTx(tx, instance.UserID, instance.ID)
+		if err != nil {
+			return err
+		}
+		if !result.Allowed {
+			return errors.New(result.Reason)
+		}
+		return tx.Create(&snapshot).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	taskID, err := s.createSnapshotTask(instance, &snapshot, "create-snapshot", "")
+	if err != nil {
+		global.APP_DB.Delete(&snapshot)
+		return nil, err
+	}
+
+	return &userModel.SnapshotTaskResponse{TaskID: taskID, SnapshotID: snapshot.ID}, nil
+}
+
+// RestoreInstanceSnapshot 管理员将实例恢复到指定快照（异步任务）
+func (s *Service) RestoreInstanceSnapshot(instanceID, snapshotID uint) (*userModel.SnapshotTaskResponse, error) {
+	instance, err := s.getSnapshotInstance(instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot providerModel.InstanceSnapshot
+	if err := global.APP_DB.Where("id = ? AND instance_id = ?", snapshotID, instance.ID).First(&snapshot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("快照不存在")
+		}
+		return nil, err
+	}
+	if snapshot.Status != "available" {
+		return nil, fmt.Errorf("快照当前状态为 %s，无法恢复", snapshot.Status)
+	}
+
+	taskID, err := s.createSnapshotTask(instance, &snapshot, "restore-snapshot", instance.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	global.APP_DB.Model(&snapshot).Update("status", "restoring")
+	global.APP_DB.Model(instance).Update("status", "restoring")
+
+	return &userModel.SnapshotTaskResponse{TaskID: taskID, SnapshotID: snapshot.ID}, nil
+}
+
+// DeleteInstanceSnapshot 管理员删除实例快照（异步任务）
+func (s *Service) DeleteInstanceSnapshot(instanceID, snapshotID uint) (*userModel.SnapshotTaskResponse, error) {
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("实例不存在")
+		}
+		return nil, err
+	}
+	if err := checkSnapshotTaskInProgress(instance.ID); err != nil {
+		return nil, err
+	}
+
+	var snapshot providerModel.InstanceSnapshot
+	if err := global.APP_DB.Where("id = ? AND instance_id = ?", snapshotID, instance.ID).First(&snapshot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("快照不存在")
+		}
+		return nil, err
+	}
+	if snapshot.Status != "available" && snapshot.Status != "failed" {
+		return nil, fmt.Errorf("快照当前状态为 %s，无法删除", snapshot.Status)
+	}
+
+	taskID, err := s.createSnapshotTask(&instance, &snapshot, "delete-snapshot", "")
+	if err != nil {
+		return nil, err
+	}
+
+	global.APP_DB.Model(&snapshot).Update("status", "deleting")
+
+	return &userModel.SnapshotTaskResponse{TaskID: taskID, SnapshotID: snapshot.ID}, nil
+}
+
+// getSnapshotInstance 获取可执行快照操作的实例
+func (s *Service) getSnapshotInstance(instanceID uint) (*providerModel.Instance, error) {
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("实例不存在")
+		}
+		return nil, err
+	}
+
+	if instance.Status != "running" && instance.Status != "stopped" {
+		return nil, errors.New("只有运行中或已停止的实例才能进行快照操作")
+	}
+
+	if err := checkSnapshotTaskInProgress(instance.ID); err != nil {
+		return nil, err
+	}
+
+	return &instance, nil
+}
+
+// createSnapshotTask 创建快照相关任务，管理员任务使用实例的用户ID
+func (s *Service) createSnapshotTask(instance *providerModel.Instance, snapshot *providerModel.InstanceSnapshot, taskType, originalStatus string) (uint, error) {
+	taskData, err := json.Marshal(adminModel.SnapshotTaskRequest{
+		InstanceId:     instance.ID,
+		ProviderId:     instance.ProviderID,
+		SnapshotId:     snapshot.ID,
+		OriginalStatus: originalStatus,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("序列化任务数据失败: %v", err)
+	}
+
+	task, err := s.taskService.CreateTask(instance.UserID, &instance.ProviderID, &instance.ID, taskType, string(taskData), 1800)
+	if err != nil {
+		global.APP_LOG.Error("管理员创建快照任务失败",
+			zap.Uint("instanceID", instance.ID),
+			zap.String("taskType", taskType),
+			zap.Error(err))
+		return 0, fmt.Errorf("创建快照任务失败: %v", err)
+	}
+
+	global.APP_LOG.Info("管理员创建快照任务成功",
+		zap.Uint("instanceID", instance.ID),
+		zap.String("taskType", taskType),
+		zap.String("snapshot", snapshot.Name),
+		zap.Uint("taskID", task.ID),
+		zap.Uint("userID", instance.UserID))
+
+	return task.ID, nil
+}
+
+// checkSnapshotTaskInProgress 检查实例是否已有进行中的快照任务
+func checkSnapshotTaskInProgress(instanceID uint) error {
+	var existingTask adminModel.Task
+	if err := global.APP_DB.Where("instance_id = ? AND task_type IN (?) AND status IN ('pending', 'running')",
+		instanceID, []string{"create-snapshot", "restore-snapshot", "delete-snapshot"}).First(&existingTask).Error; err == nil {
+		return errors.New("实例已有快照任务正在进行，请稍后重试")
+	}
+	return nil
+}
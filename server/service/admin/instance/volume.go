@@ -0,0 +1,45 @@
+package instance
+
+import (
+	"fmt"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+)
+
+// GetVolumeList 管理员获取数据卷列表
+func (s *Service) GetVolumeList(req admin.VolumeListRequest) ([]providerModel.Volume, int64, error) {
+	query := global.APP_DB.Model(&providerModel.Volume{})
+	if req.ProviderID > 0 {
+		query = query.Where("provider_id = ?", req.ProviderID)
+	}
+	if req.UserID > 0 {
+		query = query.Where("user_id = ?", req.UserID)
+	}
+	if req.InstanceID > 0 {
+		query = query.Where("instance_id = ?", req.InstanceID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计数据卷失败: %v", err)
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	var volumes []providerModel.Volume
+	if err := query.Order("created_at DESC, id DESC").
+		Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).
+		Find(&volumes).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询数据卷失败: %v", err)
+	}
+	return volumes, total, nil
+}
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
@@ -202,6 +203,15 @@ func (s *Service) CheckProviderHealthWithOptions(providerID uint, forceRefresh b
 					zap.String("newVersion", version))
 				provider.Version = version
 			}
+
+			// 刷新节点能力文档，探测失败时保留上一次结果
+			if caps, probeErr := providerInstance.ProbeCapabilities(ctx); probeErr != nil {
+				global.APP_LOG.Warn("刷新节点能力失败",
+					zap.String("provider", localProviderName),
+					zap.Error(probeErr))
+			} else if data, marshalErr := json.Marshal(caps); marshalErr == nil {
+				provider.Capabilities = string(data)
+			}
 		}
 	}
 
@@ -0,0 +1,27 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"oneclickvirt/provider"
+	provider2 "oneclickvirt/service/provider"
+)
+
+// GetClusterNodes 获取集群Provider各节点的容量、负载和在线状态
+func (s *Service) GetClusterNodes(ctx context.Context, providerID uint) ([]provider.ClusterNode, error) {
+	providerApiService := &provider2.ProviderApiService{}
+	prov, dbProvider, err := providerApiService.GetProviderByID(providerID)
+	if err != nil {
+		return nil, err
+	}
+	clusterProvider, ok := prov.(provider.ClusterProvider)
+	if !ok {
+		return nil, fmt.Errorf("%s 类型的节点不支持集群", dbProvider.Type)
+	}
+
+	listCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	return clusterProvider.ListClusterNodes(listCtx)
+}
@@ -9,6 +9,8 @@ import (
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/service/database"
 	"oneclickvirt/utils"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -92,6 +94,12 @@ func (s *Service) CreateProvider(req admin.CreateProviderRequest) error {
 		return fmt.Errorf("必须提供SSH密码或SSH密钥其中一种认证方式")
 	}
 
+	if req.Type == "podman" {
+		if err := validatePodmanRootlessUser(req.PodmanRootlessUser); err != nil {
+			return err
+		}
+	}
+
 	provider := providerModel.Provider{
 		Name:                  req.Name,
 		Type:                  req.Type,
@@ -154,6 +162,17 @@ func (s *Service) CreateProvider(req admin.CreateProviderRequest) error {
 		VMLimitCPU:    req.VMLimitCpu,
 		VMLimitMemory: req.VMLimitMemory,
 		VMLimitDisk:   req.VMLimitDisk,
+		// 超分配比例
+		ContainerCPURatio:    req.ContainerCPURatio,
+		ContainerMemoryRatio: req.ContainerMemoryRatio,
+		ContainerDiskRatio:   req.ContainerDiskRatio,
+		VMCPURatio:           req.VMCPURatio,
+		VMMemoryRatio:        req.VMMemoryRatio,
+		VMDiskRatio:          req.VMDiskRatio,
+		// 状态漂移自动修复策略
+		DriftFixGhost:  req.DriftFixGhost,
+		DriftFixStatus: req.DriftFixStatus,
+		DriftFixPort:   req.DriftFixPort,
 		// 容器特殊配置选项（仅 LXD/Incus 容器）
 		ContainerPrivileged:   req.ContainerPrivileged,
 		ContainerAllowNesting: req.ContainerAllowNesting,
@@ -162,6 +181,13 @@ func (s *Service) CreateProvider(req admin.CreateProviderRequest) error {
 		ContainerMemorySwap:   req.ContainerMemorySwap,
 		ContainerMaxProcesses: req.ContainerMaxProcesses,
 		ContainerDiskIOLimit:  req.ContainerDiskIOLimit,
+		// Podman运行模式
+		PodmanRootlessUser: req.PodmanRootlessUser,
+		// Proxmox集群放置节点
+		ClusterPinnedNode: strings.TrimSpace(req.ClusterPinnedNode),
+	}
+	if err := provider.ValidateOvercommitRatios(); err != nil {
+		return err
 	}
 
 	// 节点级别等级限制配置
@@ -281,8 +307,8 @@ func (s *Service) CreateProvider(req admin.CreateProviderRequest) error {
 		return fmt.Errorf("流量采集间隔不能超过300秒（5分钟），当前值: %d秒", req.TrafficCollectInterval)
 	}
 	// 端口映射方式默认值
-	// Docker 类型固定使用 native
-	if provider.Type == "docker" {
+	// Docker/Podman 类型固定使用 native
+	if provider.Type == "docker" || provider.Type == "podman" {
 		provider.IPv4PortMappingMethod = "native"
 		provider.IPv6PortMappingMethod = "native"
 	} else {
@@ -327,3 +353,20 @@ func (s *Service) CreateProvider(req admin.CreateProviderRequest) error {
 		zap.String("endpoint", utils.TruncateString(req.Endpoint, 64)))
 	return nil
 }
+
+// podmanUserPattern 宿主机Linux用户名格式，用户名会拼接到runuser命令中，必须严格校验
+var podmanUserPattern = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// validatePodmanRootlessUser 校验rootless Podman运行用户，为空表示rootful模式
+func validatePodmanRootlessUser(user string) error {
+	if user == "" {
+		return nil
+	}
+	if user == "root" {
+		return fmt.Errorf("rootless模式的运行用户不能是root，rootful模式请留空")
+	}
+	if !podmanUserPattern.MatchString(user) {
+		return fmt.Errorf("无效的Podman运行用户名: %s", user)
+	}
+	return nil
+}
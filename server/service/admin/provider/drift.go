@@ -0,0 +1,536 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/resources"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// driftAutoFixMinSeen 漂移需连续出现的次数，达到后才按节点策略自动修复，避免节点短暂异常时误删记录
+	driftAutoFixMinSeen = 2
+	// driftGracePeriod 新创建的实例在此时间内不参与缺失和状态检查
+	driftGracePeriod = 10 * time.Minute
+	// driftFixedRetention 已修复记录的保留时间
+	driftFixedRetention = 30 * 24 * time.Hour
+)
+
+// driftStableStatuses 只对稳定状态的实例做对比，创建、删除等中间状态由任务流程负责
+var driftStableStatuses = map[string]bool{"running": true, "stopped": true, "paused": true}
+
+// driftMemoryPattern 解析节点上报的内存值，如 512、512MB、1.5 GiB
+var driftMemoryPattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([a-zA-Z]*)$`)
+
+// ReconcileAllProviders 检查所有已激活节点的状态漂移，由调度器定期调用
+func (s *Service) ReconcileAllProviders(ctx context.Context) {
+	var providers []providerModel.Provider
+	if err := global.APP_DB.Where("status = ? AND is_frozen = ?", "active", false).Find(&providers).Error; err != nil {
+		global.APP_LOG.Error("查询漂移检查节点失败", zap.Error(err))
+		return
+	}
+
+	for _, p := range providers {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		if _, err := s.ReconcileProvider(ctx, p.ID); err != nil {
+			global.APP_LOG.Warn("节点漂移检查失败",
+				zap.Uint("providerID", p.ID),
+				zap.String("providerName", p.Name),
+				zap.Error(err))
+		}
+	}
+
+	if err := global.APP_DB.Where("fixed_at IS NOT NULL AND fixed_at < ?", time.Now().Add(-driftFixedRetention)).
+		Delete(&providerModel.InstanceDrift{}).Error; err != nil {
+		global.APP_LOG.Warn("清理已修复的漂移记录失败", zap.Error(err))
+	}
+}
+
+// ReconcileProvider 对比节点上的实例与数据库记录，保存漂移记录并按节点策略自动修复
+func (s *Service) ReconcileProvider(ctx context.Context, providerID uint) (*admin.DriftReconcileResult, error) {
+	providerApiService := &provider2.ProviderApiService{}
+	prov, dbProvider, err := providerApiService.GetProviderByID(providerID)
+	if err != nil {
+		return nil, err
+	}
+
+	listCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	hostInstances, err := prov.ListInstances(listCtx)
+	if err != nil {
+		return nil, fmt.Errorf("获取节点实例列表失败: %v", err)
+	}
+
+	var dbInstances []providerModel.Instance
+	if err := global.APP_DB.Where("provider_id = ?", providerID).Find(&dbInstances).Error; err != nil {
+		return nil, fmt.Errorf("查询实例记录失败: %v", err)
+	}
+
+	var ports []providerModel.Port
+	if err := global.APP_DB.Where("provider_id = ? AND status = ?", providerID, "active").Find(&ports).Error; err != nil {
+		return nil, fmt.Errorf("查询端口映射失败: %v", err)
+	}
+	liveInstanceIDs, err := loadLiveInstanceIDs(ports)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	findings := classifyDrift(dbInstances, hostInstances, ports, liveInstanceIDs, now)
+	records, err := saveDriftFindings(providerID, findings, now)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &admin.DriftReconcileResult{
+		ProviderID:    dbProvider.ID,
+		ProviderName:  dbProvider.Name,
+		CheckedAt:     now,
+		HostInstances: len(hostInstances),
+		DBInstances:   len(dbInstances),
+		Counts:        make(map[string]int),
+	}
+	for i := range records {
+		record := &records[i]
+		result.Counts[record.Kind]++
+		if record.SeenCount < driftAutoFixMinSeen || !driftAutoFixEnabled(dbProvider, record.Kind) {
+			continue
+		}
+		// 节点返回空列表而数据库中仍有实例时，更可能是节点接口异常，不自动清理实例记录
+		if record.Kind == providerModel.DriftKindGhost && len(hostInstances) == 0 {
+			continue
+		}
+		if err := s.fixDrift(record, "auto"); err != nil {
+			global.APP_LOG.Warn("自动修复漂移失败",
+				zap.Uint("providerID", providerID),
+				zap.Uint("driftID", record.ID),
+				zap.String("kind", record.Kind),
+				zap.Error(err))
+			continue
+		}
+		result.AutoFixed++
+	}
+
+	if err := global.APP_DB.Model(&providerModel.Provider{}).Where("id = ?", providerID).
+		Update("drift_checked_at", now).Error; err != nil {
+		global.APP_LOG.Warn("更新漂移检查时间失败", zap.Uint("providerID", providerID), zap.Error(err))
+	}
+
+	global.APP_LOG.Info("节点漂移检查完成",
+		zap.Uint("providerID", providerID),
+		zap.Int("hostInstances", result.HostInstances),
+		zap.Int("dbInstances", result.DBInstances),
+		zap.Int("drifts", len(records)),
+		zap.Int("autoFixed", result.AutoFixed))
+	return result, nil
+}
+
+// GetDriftReport 获取漂移报告，默认只返回未修复的记录
+func (s *Service) GetDriftReport(req admin.DriftListRequest) ([]providerModel.InstanceDrift, int64, error) {
+	query := global.APP_DB.Model(&providerModel.InstanceDrift{})
+	if req.ProviderID > 0 {
+		query = query.Where("provider_id = ?", req.ProviderID)
+	}
+	if req.Kind != "" {
+		query = query.Where("kind = ?", req.Kind)
+	}
+	if !req.IncludeFixed {
+		query = query.Where("fixed_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计漂移记录失败: %v", err)
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	var drifts []providerModel.InstanceDrift
+	if err := query.Order("last_seen_at DESC, id DESC").
+		Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).
+		Find(&drifts).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询漂移记录失败: %v", err)
+	}
+	return drifts, total, nil
+}
+
+// FixDrift 管理员手动修复漂移记录，不受节点自动修复策略限制
+func (s *Service) FixDrift(id uint) error {
+	var record providerModel.InstanceDrift
+	if err := global.APP_DB.First(&record, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("漂移记录不存在")
+		}
+		return fmt.Errorf("查询漂移记录失败: %v", err)
+	}
+	if record.FixedAt != nil {
+		return errors.New("漂移记录已修复")
+	}
+	return s.fixDrift(&record, "manual")
+}
+
+// fixDrift 执行修复并记录修复结果
+func (s *Service) fixDrift(record *providerModel.InstanceDrift, fixedBy string) error {
+	var action string
+	switch record.Kind {
+	case providerModel.DriftKindGhost:
+		if err := cleanupGhostInstance(record.InstanceID); err != nil {
+			return err
+		}
+		action = "删除实例记录"
+	case providerModel.DriftKindStatus:
+		// 只在实例状态仍与检查时一致时更新，避免覆盖期间任务写入的新状态
+		res := global.APP_DB.Model(&providerModel.Instance{}).
+			Where("id = ? AND status = ?", record.InstanceID, record.Expected).
+			Update("status", record.Actual)
+		if res.Error != nil {
+			return fmt.Errorf("更新实例状态失败: %v", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("实例状态已变化，请重新检查")
+		}
+		action = "状态更新为" + record.Actual
+	case providerModel.DriftKindPort:
+		if err := global.APP_DB.Delete(&providerModel.Port{}, record.PortID).Error; err != nil {
+			return fmt.Errorf("删除端口映射失败: %v", err)
+		}
+		action = "删除端口映射"
+	case providerModel.DriftKindOrphan:
+		return errors.New("节点上的未管理实例需要导入或在节点上手动删除")
+	default:
+		return errors.New("资源配置漂移需要核实后手动调整实例配置")
+	}
+
+	now := time.Now()
+	record.FixedAt = &now
+	record.FixAction = action
+	record.FixedBy = fixedBy
+	if err := global.APP_DB.Model(record).Updates(map[string]interface{}{
+		"fixed_at":   now,
+		"fix_action": action,
+		"fixed_by":   fixedBy,
+	}).Error; err != nil {
+		return fmt.Errorf("更新漂移记录失败: %v", err)
+	}
+
+	global.APP_LOG.Info("漂移已修复",
+		zap.Uint("driftID", record.ID),
+		zap.Uint("providerID", record.ProviderID),
+		zap.String("kind", record.Kind),
+		zap.String("instanceName", record.InstanceName),
+		zap.String("action", action),
+		zap.String("fixedBy", fixedBy))
+	return nil
+}
+
+// cleanupGhostInstance 清理节点上已不存在的实例的数据库记录，释放节点资源、用户配额和数据卷等附属资源
+func cleanupGhostInstance(instanceID uint) error {
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("实例记录已不存在")
+		}
+		return fmt.Errorf("查询实例失败: %v", err)
+	}
+
+	var activeTasks int64
+	global.APP_DB.Model(&admin.Task{}).
+		Where("instance_id = ? AND status IN ?", instanceID, []string{"pending", "processing", "running"}).
+		Count(&activeTasks)
+	if activeTasks > 0 {
+		return errors.New("实例有进行中的任务，暂不清理")
+	}
+
+	return global.APP_DB.Transaction(func(tx *gorm.DB) error {
+		portMappingService := resources.PortMappingService{}
+		if err := portMappingService.DeleteInstancePortMappingsInTx(tx, instance.ID); err != nil {
+			global.APP_LOG.Warn("删除实例端口映射失败", zap.Uint("instanceId", instance.ID), zap.Error(err))
+		}
+
+		resourceService := &resources.ResourceService{}
+		if err := resourceService.ReleaseResourcesInTx(tx, instance.ProviderID, instance.InstanceType,
+			instance.CPU, instance.Memory, instance.Disk); err != nil {
+			global.APP_LOG.Warn("释放Provider资源失败", zap.Uint("instanceId", instance.ID), zap.Error(err))
+		}
+
+		resourceUsage := resources.ResourceUsage{
+			CPU:       instance.CPU,
+			Memory:    instance.Memory,
+			Disk:      instance.Disk,
+			Bandwidth: instance.Bandwidth,
+		}
+		if err := resources.NewQuotaService().UpdateUserQuotaAfterDeletionWithTx(tx, instance.UserID, resourceUsage); err != nil {
+			global.APP_LOG.Warn("释放用户配额失败", zap.Uint("instanceId", instance.ID), zap.Error(err))
+		}
+
+		if err := tx.Where("instance_id = ?", instance.ID).Delete(&providerModel.InstanceSnapshot{}).Error; err != nil {
+			return fmt.Errorf("删除实例快照记录失败: %v", err)
+		}
+		if err := tx.Where("instance_id = ?", instance.ID).Delete(&providerModel.InstanceBackupPolicy{}).Error; err != nil {
+			return fmt.Errorf("删除实例备份策略失败: %v", err)
+		}
+		if err := resources.ReleaseInstanceAttachmentsInTx(tx, instance.ID); err != nil {
+			return err
+		}
+		if err := tx.Delete(&instance).Error; err != nil {
+			return fmt.Errorf("删除实例记录失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// loadLiveInstanceIDs 返回端口映射关联的实例中仍然存在的实例ID
+func loadLiveInstanceIDs(ports []providerModel.Port) (map[uint]bool, error) {
+	live := make(map[uint]bool)
+	if len(ports) == 0 {
+		return live, nil
+	}
+	ids := make([]uint, 0, len(ports))
+	for _, port := range ports {
+		ids = append(ids, port.InstanceID)
+	}
+	var existing []uint
+	if err := global.APP_DB.Model(&providerModel.Instance{}).Where("id IN ?", ids).Pluck("id", &existing).Error; err != nil {
+		return nil, fmt.Errorf("查询端口关联实例失败: %v", err)
+	}
+	for _, id := range existing {
+		live[id] = true
+	}
+	return live, nil
+}
+
+// saveDriftFindings 保存本次检查结果：已有记录累加发现次数，新漂移创建记录，未再出现的未修复记录视为已恢复并删除
+func saveDriftFindings(providerID uint, findings []providerModel.InstanceDrift, now time.Time) ([]providerModel.InstanceDrift, error) {
+	var existing []providerModel.InstanceDrift
+	if err := global.APP_DB.Where("provider_id = ? AND fixed_at IS NULL", providerID).Find(&existing).Error; err != nil {
+		return nil, fmt.Errorf("查询漂移记录失败: %v", err)
+	}
+	byKey := make(map[string]*providerModel.InstanceDrift, len(existing))
+	for i := range existing {
+		byKey[existing[i].DriftKey()] = &existing[i]
+	}
+
+	seen := make(map[uint]bool)
+	records := make([]providerModel.InstanceDrift, 0, len(findings))
+	err := global.APP_DB.Transaction(func(tx *gorm.DB) error {
+		for _, finding := range findings {
+			finding.ProviderID = providerID
+			if old, ok := byKey[finding.DriftKey()]; ok && !seen[old.ID] {
+				old.Expected = finding.Expected
+				old.Actual = finding.Actual
+				old.Detail = finding.Detail
+				old.LastSeenAt = now
+				old.SeenCount++
+				if err := tx.Save(old).Error; err != nil {
+					return err
+				}
+				seen[old.ID] = true
+				records = append(records, *old)
+				continue
+			}
+			finding.FirstSeenAt = now
+			finding.LastSeenAt = now
+			finding.SeenCount = 1
+			if err := tx.Create(&finding).Error; err != nil {
+				return err
+			}
+			records = append(records, finding)
+		}
+
+		var resolved []uint
+		for _, record := range existing {
+			if !seen[record.ID] {
+				resolved = append(resolved, record.ID)
+			}
+		}
+		if len(resolved) > 0 {
+			return tx.Delete(&providerModel.InstanceDrift{}, resolved).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存漂移记录失败: %v", err)
+	}
+	return records, nil
+}
+
+// classifyDrift 对比数据库实例、节点实例和端口映射，返回发现的漂移（未设置节点ID和时间字段）
+func classifyDrift(dbInstances []providerModel.Instance, hostInstances []providerModel.ProviderInstance,
+	ports []providerModel.Port, liveInstanceIDs map[uint]bool, now time.Time) []providerModel.InstanceDrift {
+	hostByName := make(map[string]*providerModel.ProviderInstance, len(hostInstances)*2)
+	for i := range hostInstances {
+		host := &hostInstances[i]
+		if host.ID != "" {
+			hostByName[host.ID] = host
+		}
+		if host.Name != "" {
+			hostByName[host.Name] = host
+		}
+	}
+
+	var drifts []providerModel.InstanceDrift
+	dbNames := make(map[string]bool, len(dbInstances))
+	for _, inst := range dbInstances {
+		dbNames[inst.Name] = true
+		if !driftStableStatuses[inst.Status] || now.Sub(inst.CreatedAt) < driftGracePeriod {
+			continue
+		}
+
+		host, ok := hostByName[inst.Name]
+		if !ok {
+			drifts = append(drifts, providerModel.InstanceDrift{
+				Kind:         providerModel.DriftKindGhost,
+				InstanceID:   inst.ID,
+				InstanceName: inst.Name,
+				Expected:     inst.Status,
+				Actual:       "missing",
+				Detail:       "节点上不存在该实例",
+			})
+			continue
+		}
+
+		if hostStatus := normalizeDriftStatus(host.Status); driftStableStatuses[hostStatus] && hostStatus != inst.Status {
+			drifts = append(drifts, providerModel.InstanceDrift{
+				Kind:         providerModel.DriftKindStatus,
+				InstanceID:   inst.ID,
+				InstanceName: inst.Name,
+				Expected:     inst.Status,
+				Actual:       hostStatus,
+				Detail:       "节点上的实例状态与数据库不一致",
+			})
+		}
+
+		if expected, actual, ok := resourceDrift(inst, host); ok {
+			drifts = append(drifts, providerModel.InstanceDrift{
+				Kind:         providerModel.DriftKindResource,
+				InstanceID:   inst.ID,
+				InstanceName: inst.Name,
+				Expected:     expected,
+				Actual:       actual,
+				Detail:       "节点上报告的资源与数据库配置不一致",
+			})
+		}
+	}
+
+	for _, host := range hostInstances {
+		if dbNames[host.Name] || dbNames[host.ID] {
+			continue
+		}
+		name := host.Name
+		if name == "" {
+			name = host.ID
+		}
+		drifts = append(drifts, providerModel.InstanceDrift{
+			Kind:         providerModel.DriftKindOrphan,
+			InstanceName: name,
+			Actual:       host.Status,
+			Detail:       "数据库中没有该实例的记录",
+		})
+	}
+
+	for _, port := range ports {
+		if liveInstanceIDs[port.InstanceID] {
+			continue
+		}
+		drifts = append(drifts, providerModel.InstanceDrift{
+			Kind:       providerModel.DriftKindPort,
+			InstanceID: port.InstanceID,
+			PortID:     port.ID,
+			Expected:   fmt.Sprintf("%d->%d/%s", port.HostPort, port.GuestPort, port.Protocol),
+			Actual:     "instance missing",
+			Detail:     "端口映射指向的实例已删除",
+		})
+	}
+	return drifts
+}
+
+// normalizeDriftStatus 将各虚拟化平台的实例状态归一为 running、stopped、paused
+func normalizeDriftStatus(status string) string {
+	s := strings.ToLower(strings.TrimSpace(status))
+	switch {
+	case s == "running" || s == "active" || s == "started" || strings.HasPrefix(s, "up"):
+		return "running"
+	case s == "stopped" || s == "shutoff" || s == "shut off" || s == "created" || s == "dead" ||
+		s == "inactive" || strings.HasPrefix(s, "exited"):
+		return "stopped"
+	case s == "paused" || s == "frozen" || s == "suspended":
+		return "paused"
+	default:
+		return s
+	}
+}
+
+// resourceDrift 比较CPU和内存：节点报告的CPU与配置不同，或节点报告的内存超过配置的10%（内存可能是使用量，只检查超出）
+func resourceDrift(inst providerModel.Instance, host *providerModel.ProviderInstance) (expected, actual string, ok bool) {
+	var expectedParts, actualParts []string
+	if cpu, err := strconv.Atoi(strings.TrimSpace(host.CPU)); err == nil && cpu > 0 && cpu != inst.CPU {
+		expectedParts = append(expectedParts, fmt.Sprintf("cpu=%d", inst.CPU))
+		actualParts = append(actualParts, fmt.Sprintf("cpu=%d", cpu))
+	}
+	if memory := parseDriftMemoryMB(host.Memory); inst.Memory > 0 && memory > inst.Memory*11/10 {
+		expectedParts = append(expectedParts, fmt.Sprintf("memory=%dMB", inst.Memory))
+		actualParts = append(actualParts, fmt.Sprintf("memory=%dMB", memory))
+	}
+	if len(expectedParts) == 0 {
+		return "", "", false
+	}
+	return strings.Join(expectedParts, ","), strings.Join(actualParts, ","), true
+}
+
+// parseDriftMemoryMB 将节点上报的内存转换为MB，无单位按MB处理，无法解析时返回0
+func parseDriftMemoryMB(value string) int64 {
+	matches := driftMemoryPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if matches == nil {
+		return 0
+	}
+	number, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0
+	}
+	switch strings.ToLower(matches[2]) {
+	case "", "m", "mb", "mib":
+		return int64(number)
+	case "g", "gb", "gib":
+		return int64(number * 1024)
+	case "k", "kb", "kib":
+		return int64(number / 1024)
+	case "b":
+		return int64(number / 1024 / 1024)
+	default:
+		return 0
+	}
+}
+
+// driftAutoFixEnabled 检查节点是否开启了该类型漂移的自动修复
+func driftAutoFixEnabled(p *providerModel.Provider, kind string) bool {
+	switch kind {
+	case providerModel.DriftKindGhost:
+		return p.DriftFixGhost
+	case providerModel.DriftKindStatus:
+		return p.DriftFixStatus
+	case providerModel.DriftKindPort:
+		return p.DriftFixPort
+	default:
+		return false
+	}
+}
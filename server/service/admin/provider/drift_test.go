@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	providerModel "oneclickvirt/model/provider"
+)
+
+func TestClassifyDrift(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-time.Hour)
+	dbInstances := []providerModel.Instance{
+		{ID: 1, Name: "ghost", Status: "running", CreatedAt: old},
+		{ID: 2, Name: "stopped-on-host", Status: "running", CPU: 1, Memory: 512, CreatedAt: old},
+		{ID: 3, Name: "creating", Status: "creating", CreatedAt: old},
+		{ID: 4, Name: "fresh", Status: "running", CreatedAt: now},
+	}
+	hostInstances := []providerModel.ProviderInstance{
+		{Name: "stopped-on-host", Status: "Exited (0) 2 hours ago", CPU: "2", Memory: "512 MB"},
+		{Name: "orphan", Status: "running"},
+	}
+	ports := []providerModel.Port{
+		{ID: 10, InstanceID: 2, HostPort: 10022, GuestPort: 22},
+		{ID: 11, InstanceID: 99, HostPort: 10023, GuestPort: 22},
+	}
+
+	drifts := classifyDrift(dbInstances, hostInstances, ports, map[uint]bool{2: true}, now)
+
+	kinds := make(map[string][]providerModel.InstanceDrift)
+	for _, d := range drifts {
+		kinds[d.Kind] = append(kinds[d.Kind], d)
+	}
+	if len(kinds[providerModel.DriftKindGhost]) != 1 || kinds[providerModel.DriftKindGhost][0].InstanceID != 1 {
+		t.Fatalf("expected only instance 1 as ghost, got %+v", kinds[providerModel.DriftKindGhost])
+	}
+	if len(kinds[providerModel.DriftKindStatus]) != 1 || kinds[providerModel.DriftKindStatus][0].Actual != "stopped" {
+		t.Fatalf("expected a running->stopped status drift, got %+v", kinds[providerModel.DriftKindStatus])
+	}
+	if len(kinds[providerModel.DriftKindResource]) != 1 || kinds[providerModel.DriftKindResource][0].Actual != "cpu=2" {
+		t.Fatalf("expected a cpu resource drift, got %+v", kinds[providerModel.DriftKindResource])
+	}
+	if len(kinds[providerModel.DriftKindOrphan]) != 1 || kinds[providerModel.DriftKindOrphan][0].InstanceName != "orphan" {
+		t.Fatalf("expected the unmanaged host instance as orphan, got %+v", kinds[providerModel.DriftKindOrphan])
+	}
+	if len(kinds[providerModel.DriftKindPort]) != 1 || kinds[providerModel.DriftKindPort][0].PortID != 11 {
+		t.Fatalf("expected port 11 as dangling, got %+v", kinds[providerModel.DriftKindPort])
+	}
+}
+
+func TestParseDriftMemoryMB(t *testing.T) {
+	cases := map[string]int64{"512": 512, "512 MB": 512, "2GiB": 2048, "1048576 KB": 1024, "unknown": 0, "": 0}
+	for input, want := range cases {
+		if got := parseDriftMemoryMB(input); got != want {
+			t.Errorf("parseDriftMemoryMB(%q) = %d, want %d", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/database"
+	"oneclickvirt/service/email"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// EnterMaintenance 将Provider切换到维护模式：禁止申领新实例并通知受影响的用户
+// 原allow_claim会被保存，退出维护时恢复
+func (s *Service) EnterMaintenance(req admin.EnterMaintenanceRequest) error {
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, req.ID).Error; err != nil {
+		return fmt.Errorf("Provider不存在")
+	}
+	if provider.MaintenanceMode {
+		return errors.New("该节点已处于维护模式")
+	}
+
+	now := time.Now()
+	dbService := database.GetDatabaseService()
+	if err := dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+		return tx.Model(&providerModel.Provider{}).Where("id = ?", provider.ID).Updates(map[string]interface{}{
+			"maintenance_mode":        true,
+			"maintenance_reason":      req.Reason,
+			"maintenance_started_at":  now,
+			"maintenance_allow_claim": provider.AllowClaim,
+			"allow_claim":             false,
+		}).Error
+	}); err != nil {
+		return fmt.Errorf("更新节点维护状态失败: %v", err)
+	}
+
+	global.APP_LOG.Info("节点进入维护模式",
+		zap.Uint("providerID", provider.ID),
+		zap.String("providerName", provider.Name),
+		zap.String("reason", req.Reason),
+		zap.Bool("evacuate", req.Evacuate))
+
+	go s.notifyMaintenanceUsers(provider.ID, provider.Name, req.Reason, req.Evacuate)
+	return nil
+}
+
+// ExitMaintenance 退出维护模式并恢复进入维护前的allow_claim
+// 维护期间管理员重新开启过申领时保留当前设置，只有allow_claim仍为进入维护时设置的false才恢复
+func (s *Service) ExitMaintenance(req admin.ExitMaintenanceRequest) error {
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, req.ID).Error; err != nil {
+		return fmt.Errorf("Provider不存在")
+	}
+	if !provider.MaintenanceMode {
+		return errors.New("该节点未处于维护模式")
+	}
+
+	dbService := database.GetDatabaseService()
+	if err := dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+		// 加锁重新读取，避免与维护期间对allow_claim的修改并发
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&provider, provider.ID).Error; err != nil {
+			return err
+		}
+		if !provider.MaintenanceMode {
+			return errors.New("该节点未处于维护模式")
+		}
+		updates := map[string]interface{}{
+			"maintenance_mode":        false,
+			"maintenance_reason":      "",
+			"maintenance_started_at":  nil,
+			"maintenance_allow_claim": false,
+		}
+		if !provider.AllowClaim {
+			updates["allow_claim"] = provider.MaintenanceAllowClaim
+			provider.AllowClaim = provider.MaintenanceAllowClaim
+		}
+		return tx.Model(&providerModel.Provider{}).Where("id = ?", provider.ID).Updates(updates).Error
+	}); err != nil {
+		return fmt.Errorf("更新节点维护状态失败: %v", err)
+	}
+
+	global.APP_LOG.Info("节点退出维护模式",
+		zap.Uint("providerID", provider.ID),
+		zap.String("providerName", provider.Name),
+		zap.Bool("allowClaim", provider.AllowClaim))
+	return nil
+}
+
+// GetMaintenanceStatus 获取节点维护进度：剩余实例数和本次维护期间创建的迁移任务
+func (s *Service) GetMaintenanceStatus(providerID uint) (*admin.MaintenanceStatusResponse, error) {
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, providerID).Error; err != nil {
+		return nil, fmt.Errorf("Provider不存在")
+	}
+
+	resp := &admin.MaintenanceStatusResponse{
+		ProviderID:      provider.ID,
+		ProviderName:    provider.Name,
+		MaintenanceMode: provider.MaintenanceMode,
+		Reason:          provider.MaintenanceReason,
+		StartedAt:       provider.MaintenanceStartedAt,
+		Migrations:      []admin.MaintenanceMigrationItem{},
+	}
+
+	var instances []providerModel.Instance
+	if err := global.APP_DB.Select("id", "name").
+		Where("provider_id = ? AND status NOT IN (?)", provider.ID, []string{"deleting", "deleted"}).
+		Find(&instances).Error; err != nil {
+		return nil, fmt.Errorf("查询节点实例失败: %v", err)
+	}
+	resp.RemainingInstances = int64(len(instances))
+	resp.Empty = len(instances) == 0
+
+	instanceNames := make(map[uint]string, len(instances))
+	for _, inst := range instances {
+		instanceNames[inst.ID] = inst.Name
+	}
+
+	// 迁移任务在源节点队列中执行，provider_id即源节点
+	var tasks []admin.Task
+	if provider.MaintenanceStartedAt != nil {
+		if err := global.APP_DB.
+			Where("provider_id = ? AND task_type = ? AND created_at >= ?", provider.ID, "migrate", *provider.MaintenanceStartedAt).
+			Order("id ASC").
+			Find(&tasks).Error; err != nil {
+			return nil, fmt.Errorf("查询迁移任务失败: %v", err)
+		}
+	}
+
+	scheduled := make(map[uint]bool)
+	for _, task := range tasks {
+		item := admin.MaintenanceMigrationItem{
+			TaskID:       task.ID,
+			Status:       task.Status,
+			Progress:     task.Progress,
+			ErrorMessage: task.ErrorMessage,
+		}
+		var data admin.MigrateTaskRequest
+		if err := json.Unmarshal([]byte(task.TaskData), &data); err == nil {
+			item.InstanceID = data.InstanceId
+			item.TargetProviderID = data.TargetProviderId
+		} else if task.InstanceID != nil {
+			item.InstanceID = *task.InstanceID
+		}
+		item.InstanceName = instanceNames[item.InstanceID]
+		if item.InstanceName == "" {
+			// 迁移完成后实例记录已转移到目标节点
+			var inst providerModel.Instance
+			if err := global.APP_DB.Unscoped().Select("name").First(&inst, item.InstanceID).Error; err == nil {
+				item.InstanceName = inst.Name
+			}
+		}
+
+		switch task.Status {
+		case "pending", "processing", "running":
+			resp.PendingMigrations++
+			scheduled[item.InstanceID] = true
+		case "completed":
+			resp.CompletedMigrations++
+		default:
+			resp.FailedMigrations++
+		}
+		resp.Migrations = append(resp.Migrations, item)
+	}
+
+	for _, inst := range instances {
+		if !scheduled[inst.ID] {
+			resp.Unscheduled++
+		}
+	}
+
+	return resp, nil
+}
+
+// notifyMaintenanceUsers 向节点上有实例的用户发送维护通知邮件
+func (s *Service) notifyMaintenanceUsers(providerID uint, providerName, reason string, evacuate bool) {
+	var userIDs []uint
+	if err := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("provider_id = ? AND status NOT IN (?)", providerID, []string{"deleting", "deleted"}).
+		Distinct().Pluck("user_id", &userIDs).Error; err != nil {
+		global.APP_LOG.Error("查询维护节点上的用户失败", zap.Uint("providerID", providerID), zap.Error(err))
+		return
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+
+	var users []userModel.User
+	if err := global.APP_DB.Select("id", "username", "email").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		global.APP_LOG.Error("查询维护通知用户失败", zap.Uint("providerID", providerID), zap.Error(err))
+		return
+	}
+
+	emailService := email.NewEmailService()
+	sent := 0
+	for _, u := range users {
+		if u.Email == "" {
+			continue
+		}
+		if err := emailService.SendMaintenanceEmail(u.Email, u.Username, providerName, reason, evacuate); err != nil {
+			global.APP_LOG.Warn("发送节点维护通知失败",
+				zap.Uint("providerID", providerID),
+				zap.Uint("userID", u.ID),
+				zap.Error(err))
+			continue
+		}
+		sent++
+	}
+
+	global.APP_LOG.Info("节点维护通知发送完成",
+		zap.Uint("providerID", providerID),
+		zap.Int("users", len(users)),
+		zap.Int("sent", sent))
+}
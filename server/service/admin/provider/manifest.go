@@ -0,0 +1,461 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ManifestFormatYAML YAML格式的节点清单：节点列表，或包含providers字段的对象
+	ManifestFormatYAML = "yaml"
+	// ManifestFormatCSV CSV格式的节点清单：首行为字段名，levelLimits列填写JSON
+	ManifestFormatCSV = "csv"
+
+	defaultImportConcurrency = 4
+	maxImportConcurrency     = 10
+)
+
+// manifestProviderTypes 清单中允许的节点类型
+var manifestProviderTypes = map[string]bool{
+	"docker": true, "podman": true, "lxd": true, "incus": true, "proxmox": true, "libvirt": true,
+}
+
+// ProviderImportOptions 批量导入节点的选项
+type ProviderImportOptions struct {
+	Concurrency   int  // 同时处理的节点数，默认4，最大10
+	SkipSSHTest   bool // 跳过SSH连接测试
+	AutoConfigure bool // 为LXD、Incus和Proxmox节点执行自动配置
+}
+
+// ManifestFormatFromFilename 根据文件扩展名判断清单格式
+func ManifestFormatFromFilename(filename string) string {
+	if strings.HasSuffix(strings.ToLower(filename), ".csv") {
+		return ManifestFormatCSV
+	}
+	return ManifestFormatYAML
+}
+
+// ParseProviderManifest 解析节点清单，字段名与创建Provider接口的JSON字段一致
+func ParseProviderManifest(data []byte, format string) ([]admin.CreateProviderRequest, error) {
+	var rows []map[string]interface{}
+	var err error
+	switch format {
+	case ManifestFormatCSV:
+		rows, err = parseCSVManifest(data)
+	case ManifestFormatYAML, "yml", "":
+		rows, err = parseYAMLManifest(data)
+	default:
+		return nil, fmt.Errorf("不支持的清单格式: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("清单中没有节点")
+	}
+
+	requests := make([]admin.CreateProviderRequest, 0, len(rows))
+	for i, row := range rows {
+		req, err := decodeManifestRow(row)
+		if err != nil {
+			return nil, fmt.Errorf("第%d行: %v", i+1, err)
+		}
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
+
+// ImportProviders 按清单批量创建节点：校验字段、测试SSH连接、创建节点并按需自动配置
+// 名称已存在且地址相同的节点视为已导入，不会重复创建，未完成自动配置时会补做，因此清单可以重复执行
+func (s *Service) ImportProviders(ctx context.Context, requests []admin.CreateProviderRequest, opts ProviderImportOptions) []admin.ProviderImportResult {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultImportConcurrency
+	}
+	if concurrency > maxImportConcurrency {
+		concurrency = maxImportConcurrency
+	}
+
+	results := make([]admin.ProviderImportResult, len(requests))
+	seenNames := make(map[string]int, len(requests))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i := range requests {
+		results[i] = admin.ProviderImportResult{Row: i + 1, Name: requests[i].Name}
+		// 清单内重名的行只处理第一行，避免并发创建时互相冲突
+		if first, ok := seenNames[requests[i].Name]; ok {
+			results[i].Action = "failed"
+			results[i].Error = fmt.Sprintf("与第%d行的节点名称重复", first)
+			continue
+		}
+		seenNames[requests[i].Name] = i + 1
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				results[i].Action = "failed"
+				results[i].Error = "导入已取消"
+				return
+			}
+			s.importProviderRow(ctx, requests[i], opts, &results[i])
+		}(i)
+	}
+	wg.Wait()
+
+	created, existing, failed := 0, 0, 0
+	for _, result := range results {
+		switch result.Action {
+		case "created":
+			created++
+		case "existing":
+			existing++
+		default:
+			failed++
+		}
+	}
+	global.APP_LOG.Info("节点清单导入完成",
+		zap.Int("total", len(results)),
+		zap.Int("created", created),
+		zap.Int("existing", existing),
+		zap.Int("failed", failed))
+	return results
+}
+
+// importProviderRow 导入清单中的一行
+func (s *Service) importProviderRow(ctx context.Context, req admin.CreateProviderRequest, opts ProviderImportOptions, result *admin.ProviderImportResult) {
+	fail := func(err error) {
+		result.Action = "failed"
+		result.Error = err.Error()
+		global.APP_LOG.Warn("节点清单导入失败",
+			zap.Int("row", result.Row),
+			zap.String("name", utils.TruncateString(req.Name, 32)),
+			zap.Error(err))
+	}
+
+	applyManifestDefaults(&req)
+	if err := validateManifestRequest(req); err != nil {
+		fail(err)
+		return
+	}
+
+	existing, err := findImportedProvider(req)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	if existing == nil && !opts.SkipSSHTest && req.Endpoint != "" {
+		sshConfig := utils.SSHConfig{
+			Host:       utils.ExtractHost(req.Endpoint),
+			Port:       req.SSHPort,
+			Username:   req.Username,
+			Password:   req.Password,
+			PrivateKey: req.SSHKey,
+		}
+		_, _, avgLatency, err := utils.TestSSHConnectionLatency(sshConfig, 1)
+		if err != nil {
+			fail(fmt.Errorf("SSH连接测试失败: %v", err))
+			return
+		}
+		result.SSHLatency = avgLatency.Milliseconds()
+	}
+
+	if existing == nil {
+		if err := s.CreateProvider(req); err != nil {
+			fail(err)
+			return
+		}
+		var created providerModel.Provider
+		if err := global.APP_DB.Where("name = ?", req.Name).First(&created).Error; err != nil {
+			fail(fmt.Errorf("查询新建节点失败: %v", err))
+			return
+		}
+		existing = &created
+		result.Action = "created"
+	} else {
+		result.Action = "existing"
+	}
+	result.ProviderID = existing.ID
+	result.Configured = existing.AutoConfigured
+
+	if !opts.AutoConfigure || existing.AutoConfigured || !supportsAutoConfigure(existing.Type) {
+		return
+	}
+	if ctx.Err() != nil {
+		result.ConfigureError = "导入已取消"
+		return
+	}
+	certService := &provider2.CertService{}
+	if err := certService.AutoConfigureProvider(existing); err != nil {
+		result.ConfigureError = err.Error()
+		global.APP_LOG.Warn("节点清单导入后自动配置失败",
+			zap.Uint("providerID", existing.ID),
+			zap.String("name", existing.Name),
+			zap.Error(err))
+		return
+	}
+	result.Configured = true
+}
+
+// findImportedProvider 查找清单行对应的已有节点，名称相同但地址不同、或地址已被其他节点使用时返回错误
+func findImportedProvider(req admin.CreateProviderRequest) (*providerModel.Provider, error) {
+	var existing providerModel.Provider
+	err := global.APP_DB.Where("name = ?", req.Name).First(&existing).Error
+	if err == nil {
+		if existing.Endpoint != req.Endpoint || existing.SSHPort != req.SSHPort || existing.Type != req.Type {
+			return nil, fmt.Errorf("节点名称 '%s' 已被其他地址或类型的节点使用", req.Name)
+		}
+		return &existing, nil
+	}
+
+	if req.Endpoint != "" {
+		var count int64
+		global.APP_DB.Model(&providerModel.Provider{}).
+			Where("endpoint = ? AND ssh_port = ?", req.Endpoint, req.SSHPort).
+			Count(&count)
+		if count > 0 {
+			return nil, fmt.Errorf("SSH地址 '%s:%d' 已被其他节点使用", req.Endpoint, req.SSHPort)
+		}
+	}
+	return nil, nil
+}
+
+// supportsAutoConfigure 只有LXD、Incus和Proxmox支持自动配置
+func supportsAutoConfigure(providerType string) bool {
+	return providerType == "lxd" || providerType == "incus" || providerType == "proxmox"
+}
+
+// applyManifestDefaults 为清单中省略的字段填写与创建接口相同的默认值
+func applyManifestDefaults(req *admin.CreateProviderRequest) {
+	if req.SSHPort == 0 {
+		req.SSHPort = 22
+	}
+	if req.ExecutionRule == "" {
+		req.ExecutionRule = "auto"
+	}
+	if req.NetworkType == "" {
+		req.NetworkType = "nat_ipv4"
+	}
+}
+
+// validateManifestRequest 校验清单行，规则与创建Provider接口的参数绑定一致
+func validateManifestRequest(req admin.CreateProviderRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return errors.New("节点名称不能为空")
+	}
+	if !manifestProviderTypes[req.Type] {
+		return fmt.Errorf("不支持的节点类型: %s", req.Type)
+	}
+	if req.Endpoint == "" {
+		return errors.New("节点地址不能为空")
+	}
+	if req.Password == "" && req.SSHKey == "" {
+		return errors.New("必须提供SSH密码或SSH密钥其中一种认证方式")
+	}
+	switch req.ExecutionRule {
+	case "auto", "api_only", "ssh_only":
+	default:
+		return fmt.Errorf("无效的执行规则: %s", req.ExecutionRule)
+	}
+	switch req.NetworkType {
+	case "nat_ipv4", "nat_ipv4_ipv6", "dedicated_ipv4", "dedicated_ipv4_ipv6", "ipv6_only":
+	default:
+		return fmt.Errorf("无效的网络类型: %s", req.NetworkType)
+	}
+	if req.PortRangeStart != 0 && req.PortRangeEnd != 0 && req.PortRangeStart > req.PortRangeEnd {
+		return errors.New("端口范围起始不能大于结束")
+	}
+	ratios := providerModel.Provider{
+		ContainerCPURatio:    req.ContainerCPURatio,
+		ContainerMemoryRatio: req.ContainerMemoryRatio,
+		ContainerDiskRatio:   req.ContainerDiskRatio,
+		VMCPURatio:           req.VMCPURatio,
+		VMMemoryRatio:        req.VMMemoryRatio,
+		VMDiskRatio:          req.VMDiskRatio,
+	}
+	return ratios.ValidateOvercommitRatios()
+}
+
+// parseYAMLManifest 解析YAML清单，支持顶层为节点列表或 providers: [...]
+func parseYAMLManifest(data []byte) ([]map[string]interface{}, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析YAML清单失败: %v", err)
+	}
+	doc = normalizeYAMLValue(doc)
+	if wrapper, ok := doc.(map[string]interface{}); ok {
+		doc = wrapper["providers"]
+	}
+	list, ok := doc.([]interface{})
+	if !ok {
+		return nil, errors.New("YAML清单应为节点列表或包含providers列表")
+	}
+
+	rows := make([]map[string]interface{}, 0, len(list))
+	for i, item := range list {
+		row, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("第%d行不是有效的节点配置", i+1)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// normalizeYAMLValue 将YAML中非字符串键的映射（如等级限制的数字键）转换为字符串键，便于转换为JSON
+func normalizeYAMLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeYAMLValue(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = normalizeYAMLValue(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeYAMLValue(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// parseCSVManifest 解析CSV清单，空单元格表示使用默认值
+func parseCSVManifest(data []byte) ([]map[string]interface{}, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("读取CSV表头失败: %v", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+
+	var rows []map[string]interface{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取CSV第%d行失败: %v", len(rows)+1, err)
+		}
+		row := make(map[string]interface{}, len(header))
+		for i, value := range record {
+			if i < len(header) && strings.TrimSpace(value) != "" {
+				row[header[i]] = strings.TrimSpace(value)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// decodeManifestRow 按创建请求的字段类型转换清单中的值，CSV中的字符串和YAML中写成数字的密码都能正确解析
+func decodeManifestRow(row map[string]interface{}) (admin.CreateProviderRequest, error) {
+	var req admin.CreateProviderRequest
+	kinds := manifestFieldKinds()
+	converted := make(map[string]interface{}, len(row))
+	for key, value := range row {
+		kind, ok := kinds[key]
+		if !ok {
+			return req, fmt.Errorf("未知字段: %s", key)
+		}
+		v, err := convertManifestValue(value, kind)
+		if err != nil {
+			return req, fmt.Errorf("字段 %s: %v", key, err)
+		}
+		converted[key] = v
+	}
+
+	data, err := json.Marshal(converted)
+	if err != nil {
+		return req, err
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return req, err
+	}
+	return req, nil
+}
+
+// convertManifestValue 将清单中的值转换为目标字段类型
+func convertManifestValue(value interface{}, kind reflect.Kind) (interface{}, error) {
+	text, isText := value.(string)
+	switch kind {
+	case reflect.String:
+		return fmt.Sprint(value), nil
+	case reflect.Bool:
+		if isText {
+			return strconv.ParseBool(text)
+		}
+	case reflect.Int, reflect.Int64:
+		if isText {
+			return strconv.ParseInt(text, 10, 64)
+		}
+	case reflect.Float64:
+		if isText {
+			return strconv.ParseFloat(text, 64)
+		}
+	case reflect.Map:
+		if isText {
+			var m map[string]interface{}
+			if err := json.Unmarshal([]byte(text), &m); err != nil {
+				return nil, fmt.Errorf("应为JSON对象: %v", err)
+			}
+			return m, nil
+		}
+	}
+	return value, nil
+}
+
+var (
+	manifestKindsOnce sync.Once
+	manifestKinds     map[string]reflect.Kind
+)
+
+// manifestFieldKinds 返回创建请求各JSON字段的类型
+func manifestFieldKinds() map[string]reflect.Kind {
+	manifestKindsOnce.Do(func() {
+		t := reflect.TypeOf(admin.CreateProviderRequest{})
+		manifestKinds = make(map[string]reflect.Kind, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			manifestKinds[name] = field.Type.Kind()
+		}
+	})
+	return manifestKinds
+}
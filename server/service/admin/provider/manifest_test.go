@@ -0,0 +1,66 @@
+package provider
+
+import "testing"
+
+func TestParseProviderManifestYAML(t *testing.T) {
+	manifest := `
+providers:
+  - name: hk-01
+    type: lxd
+    endpoint: 203.0.113.10
+    username: root
+    password: 123456
+    region: HK
+    portRangeStart: 20000
+    portRangeEnd: 30000
+    enableTrafficControl: true
+    trafficMultiplier: 1.5
+    levelLimits:
+      1:
+        max-instances: 1
+        cpu: 1
+`
+	requests, err := ParseProviderManifest([]byte(manifest), ManifestFormatYAML)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 provider, got %d", len(requests))
+	}
+	req := requests[0]
+	if req.Name != "hk-01" || req.Password != "123456" || req.PortRangeStart != 20000 || !req.EnableTrafficControl || req.TrafficMultiplier != 1.5 {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+	if req.LevelLimits[1]["cpu"] != float64(1) {
+		t.Fatalf("level limits not decoded: %+v", req.LevelLimits)
+	}
+
+	applyManifestDefaults(&req)
+	if err := validateManifestRequest(req); err != nil {
+		t.Fatalf("expected a valid request, got %v", err)
+	}
+}
+
+func TestParseProviderManifestCSV(t *testing.T) {
+	manifest := "name,type,endpoint,sshPort,username,password,allowClaim,levelLimits\n" +
+		"pve-01,proxmox,198.51.100.7,2222,root,secret,true,\"{\"\"1\"\":{\"\"cpu\"\":2}}\"\n" +
+		"bad-01,docker,198.51.100.8,,root,secret,,\n"
+
+	requests, err := ParseProviderManifest([]byte(manifest), ManifestFormatCSV)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 providers, got %d", len(requests))
+	}
+	if requests[0].SSHPort != 2222 || !requests[0].AllowClaim || requests[0].LevelLimits[1]["cpu"] != float64(2) {
+		t.Fatalf("unexpected first row: %+v", requests[0])
+	}
+	if requests[1].SSHPort != 0 {
+		t.Fatalf("empty cells should keep defaults: %+v", requests[1])
+	}
+
+	if _, err := ParseProviderManifest([]byte("name,colour\nx,red\n"), ManifestFormatCSV); err == nil {
+		t.Fatal("unknown columns should be rejected")
+	}
+}
@@ -12,6 +12,7 @@ import (
 	traffic_monitor "oneclickvirt/service/admin/traffic_monitor"
 	"oneclickvirt/service/database"
 	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/resources"
 	"oneclickvirt/utils"
 	"strings"
 	"time"
@@ -158,8 +159,8 @@ func (s *Service) GetProviderList(req admin.ProviderListRequest) ([]admin.Provid
 		runningTasksCount := taskCountMap[provider.ID]
 		usedTraffic := trafficUsageMap[provider.ID]
 
-		// Docker 类型固定使用 native 端口映射方式
-		if provider.Type == "docker" {
+		// Docker/Podman 类型固定使用 native 端口映射方式
+		if provider.Type == "docker" || provider.Type == "podman" {
 			provider.IPv4PortMappingMethod = "native"
 			provider.IPv6PortMappingMethod = "native"
 		}
@@ -193,6 +194,9 @@ func (s *Service) GetProviderList(req admin.ProviderListRequest) ([]admin.Provid
 			CurrentVMCount:        int(instanceCount.VMCount),
 			// 流量使用情况
 			UsedTraffic: usedTraffic,
+			// 超分配后的有效容量
+			ContainerCapacity: resources.BuildProviderCapacity(&provider, "container"),
+			VMCapacity:        resources.BuildProviderCapacity(&provider, "vm"),
 		}
 		providerResponses = append(providerResponses, providerResponse)
 	}
@@ -346,7 +350,12 @@ func (s *Service) UpdateProvider(req admin.UpdateProviderRequest) error {
 	provider.ContainerEnabled = req.ContainerEnabled
 	provider.VirtualMachineEnabled = req.VirtualMachineEnabled
 	provider.TotalQuota = req.TotalQuota
-	provider.AllowClaim = req.AllowClaim
+	if provider.MaintenanceMode {
+		// 维护期间保持禁止申领，修改的值在退出维护时生效
+		provider.MaintenanceAllowClaim = req.AllowClaim
+	} else {
+		provider.AllowClaim = req.AllowClaim
+	}
 	provider.Status = req.Status
 	provider.MaxContainerInstances = req.MaxContainerInstances
 	provider.MaxVMInstances = req.MaxVMInstances
@@ -448,8 +457,8 @@ func (s *Service) UpdateProvider(req admin.UpdateProviderRequest) error {
 			zap.Float64("newValue", req.TrafficMultiplier))
 	}
 	// 端口映射方式更新
-	// Docker 类型固定使用 native，忽略前端传入的值
-	if provider.Type == "docker" {
+	// Docker/Podman 类型固定使用 native，忽略前端传入的值
+	if provider.Type == "docker" || provider.Type == "podman" {
 		provider.IPv4PortMappingMethod = "native"
 		provider.IPv6PortMappingMethod = "native"
 	} else {
@@ -475,6 +484,20 @@ func (s *Service) UpdateProvider(req admin.UpdateProviderRequest) error {
 	provider.VMLimitCPU = req.VMLimitCpu
 	provider.VMLimitMemory = req.VMLimitMemory
 	provider.VMLimitDisk = req.VMLimitDisk
+	// 超分配比例更新
+	provider.ContainerCPURatio = req.ContainerCPURatio
+	provider.ContainerMemoryRatio = req.ContainerMemoryRatio
+	provider.ContainerDiskRatio = req.ContainerDiskRatio
+	provider.VMCPURatio = req.VMCPURatio
+	provider.VMMemoryRatio = req.VMMemoryRatio
+	provider.VMDiskRatio = req.VMDiskRatio
+	if err := provider.ValidateOvercommitRatios(); err != nil {
+		return err
+	}
+	// 状态漂移自动修复策略更新
+	provider.DriftFixGhost = req.DriftFixGhost
+	provider.DriftFixStatus = req.DriftFixStatus
+	provider.DriftFixPort = req.DriftFixPort
 	// 容器特殊配置选项更新（仅 LXD/Incus 容器）
 	provider.ContainerPrivileged = req.ContainerPrivileged
 	provider.ContainerAllowNesting = req.ContainerAllowNesting
@@ -485,6 +508,15 @@ func (s *Service) UpdateProvider(req admin.UpdateProviderRequest) error {
 	provider.ContainerMemorySwap = req.ContainerMemorySwap
 	provider.ContainerMaxProcesses = req.ContainerMaxProcesses
 	provider.ContainerDiskIOLimit = req.ContainerDiskIOLimit
+	// Podman运行模式更新，切换模式后原有容器属于另一个用户的存储，需要管理员自行迁移
+	if provider.Type == "podman" {
+		if err := validatePodmanRootlessUser(req.PodmanRootlessUser); err != nil {
+			return err
+		}
+		provider.PodmanRootlessUser = req.PodmanRootlessUser
+	}
+	// Proxmox集群放置节点更新，仅影响之后创建的实例
+	provider.ClusterPinnedNode = strings.TrimSpace(req.ClusterPinnedNode)
 
 	// 节点级别等级限制配置更新
 	if req.LevelLimits != nil {
@@ -848,6 +880,9 @@ func (s *Service) GetProviderStatus(providerID uint) (*admin.ProviderStatusRespo
 		NodeDiskTotal:    provider.NodeDiskTotal,
 		ResourceSynced:   provider.ResourceSynced,
 		ResourceSyncedAt: provider.ResourceSyncedAt,
+		// 超分配后的有效容量
+		ContainerCapacity: resources.BuildProviderCapacity(&provider, "container"),
+		VMCapacity:        resources.BuildProviderCapacity(&provider, "vm"),
 	}
 
 	return response, nil
@@ -64,6 +64,18 @@ func (s *ConfigService) UpdateConfig(req configModel.UpdateConfigRequest) error
 				return fmt.Errorf("等级 %d 的流量限制不能为空或小于等于0", level)
 			}
 
+			if modelLimit.MaxSnapshots < 0 {
+				return fmt.Errorf("等级 %d 的快照数量限制不能小于0", level)
+			}
+
+			if modelLimit.MaxUserDataSize < 0 || modelLimit.MaxUserDataSize > 64 {
+				return fmt.Errorf("等级 %d 的user-data大小限制必须在0-64KB之间", level)
+			}
+
+			if modelLimit.MaxFirewallRules < 0 {
+				return fmt.Errorf("等级 %d 的防火墙规则数量限制不能小于0", level)
+			}
+
 			// 验证 MaxResources
 			if modelLimit.MaxResources == nil {
 				return fmt.Errorf("等级 %d 的资源配置不能为空", level)
@@ -103,9 +115,13 @@ func (s *ConfigService) UpdateConfig(req configModel.UpdateConfigRequest) error
 			}
 
 			levelLimits[levelKey] = map[string]interface{}{
-				"max-instances": modelLimit.MaxInstances,
-				"max-resources": modelLimit.MaxResources,
-				"max-traffic":   modelLimit.MaxTraffic,
+				"max-instances":      modelLimit.MaxInstances,
+				"max-resources":      modelLimit.MaxResources,
+				"max-traffic":        modelLimit.MaxTraffic,
+				"max-snapshots":      modelLimit.MaxSnapshots,
+				"allow-user-data":    modelLimit.AllowUserData,
+				"max-user-data-size": modelLimit.MaxUserDataSize,
+				"max-firewall-rules": modelLimit.MaxFirewallRules,
 			}
 		}
 		quotaConfig["levelLimits"] = levelLimits
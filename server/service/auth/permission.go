@@ -7,6 +7,7 @@ import (
 	"oneclickvirt/global"
 	"oneclickvirt/model/permission"
 	"oneclickvirt/model/user"
+	"oneclickvirt/provider"
 
 	"go.uber.org/zap"
 )
@@ -233,6 +234,45 @@ func (s *PermissionService) CheckInstanceResetPermission(userID uint, instanceTy
 	}
 }
 
+// defaultMaxUserDataKB 等级未配置user-data大小限制时的默认值（KB）
+const defaultMaxUserDataKB = 16
+
+// ValidateInstanceUserData 检查用户等级是否允许提供user-data，并按等级大小限制和节点类型校验内容
+func (s *PermissionService) ValidateInstanceUserData(userID uint, userData, providerType, instanceType string) error {
+	if userData == "" {
+		return nil
+	}
+	effective, err := s.GetUserEffectivePermission(userID)
+	if err != nil {
+		return fmt.Errorf("获取用户权限失败: %v", err)
+	}
+
+	// admin 不受等级开关限制，只受硬性上限约束
+	maxBytes := provider.MaxUserDataBytes
+	if effective.EffectiveType != "admin" {
+		levelLimit, exists := global.APP_CONFIG.Quota.LevelLimits[effective.EffectiveLevel]
+		if !exists || !levelLimit.AllowUserData {
+			return fmt.Errorf("您的等级不允许使用自定义user-data")
+		}
+		maxKB := levelLimit.MaxUserDataSize
+		if maxKB <= 0 {
+			maxKB = defaultMaxUserDataKB
+		}
+		maxBytes = maxKB * 1024
+	}
+
+	if err := provider.ValidateUserData(userData, maxBytes); err != nil {
+		return err
+	}
+	if !provider.SupportsUserData(providerType, instanceType, provider.DetectUserDataType(userData)) {
+		if provider.DetectUserDataType(userData) == provider.UserDataTypeCloudConfig {
+			return fmt.Errorf("%s 节点的%s实例不支持cloud-config，请改用 #! 开头的shell脚本", providerType, instanceType)
+		}
+		return fmt.Errorf("%s 节点不支持自定义user-data", providerType)
+	}
+	return nil
+}
+
 // CheckAPIAccess 检查API访问权限
 func (s *PermissionService) CheckAPIAccess(userID uint, path string, method string) bool {
 	// 检查是否为管理员API
@@ -0,0 +1,260 @@
+package console
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	provider2 "oneclickvirt/service/provider"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	// openTimeout 打开控制台（查找实例、申请票据、建立连接）的超时时间
+	openTimeout = 30 * time.Second
+	// sessionTimeout 单个控制台会话的最长时间，与WebSocket SSH保持一致
+	sessionTimeout = 30 * time.Minute
+)
+
+// SessionInfo 控制台会话的请求信息，用于审计日志
+type SessionInfo struct {
+	UserID      uint
+	Username    string
+	IsAdmin     bool
+	Method      string
+	Path        string
+	ClientIP    string
+	UserAgent   string
+	ConsoleType string
+}
+
+// controlMessage 浏览器端发送的控制消息，与WebSocket SSH终端使用相同格式
+type controlMessage struct {
+	Type string `json:"type"`
+	Cols int    `json:"cols"`
+	Rows int    `json:"rows"`
+}
+
+// NormalizeConsoleType 校验控制台类型，为空时默认使用串口控制台
+func NormalizeConsoleType(consoleType string) (string, error) {
+	switch consoleType {
+	case "", provider.ConsoleTypeSerial:
+		return provider.ConsoleTypeSerial, nil
+	case provider.ConsoleTypeVNC:
+		return provider.ConsoleTypeVNC, nil
+	default:
+		return "", fmt.Errorf("不支持的控制台类型: %s", consoleType)
+	}
+}
+
+// Serve 打开实例控制台并与浏览器WebSocket桥接，会话开始和结束都会写入审计日志
+func Serve(ws *websocket.Conn, instance *providerModel.Instance, info SessionInfo) {
+	startedAt := time.Now()
+	auditLog := startAudit(instance, info)
+
+	ctx, cancel := context.WithTimeout(context.Background(), openTimeout)
+	session, err := openConsole(ctx, instance, info.ConsoleType)
+	cancel()
+	if err != nil {
+		global.APP_LOG.Warn("打开实例控制台失败",
+			zap.Uint("instanceId", instance.ID),
+			zap.String("consoleType", info.ConsoleType),
+			zap.Error(err))
+		ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("打开控制台失败: %v\r\n", err)))
+		finishAudit(auditLog, startedAt, 0, 0, err)
+		return
+	}
+
+	global.APP_LOG.Info("实例控制台会话开始",
+		zap.Uint("instanceId", instance.ID),
+		zap.String("instanceName", instance.Name),
+		zap.Uint("userId", info.UserID),
+		zap.Bool("admin", info.IsAdmin),
+		zap.String("consoleType", info.ConsoleType))
+
+	// VNC票据作为noVNC的密码，在转发RFB数据之前以文本消息下发
+	if password := session.Password(); password != "" {
+		auth, _ := json.Marshal(map[string]string{"type": "auth", "password": password})
+		ws.WriteMessage(websocket.TextMessage, auth)
+	}
+
+	bytesIn, bytesOut, bridgeErr := bridge(ws, session)
+
+	global.APP_LOG.Info("实例控制台会话结束",
+		zap.Uint("instanceId", instance.ID),
+		zap.Uint("userId", info.UserID),
+		zap.Duration("duration", time.Since(startedAt)),
+		zap.Int64("bytesIn", bytesIn),
+		zap.Int64("bytesOut", bytesOut))
+	finishAudit(auditLog, startedAt, bytesIn, bytesOut, bridgeErr)
+}
+
+// openConsole 获取实例所在节点的Provider并打开控制台
+func openConsole(ctx context.Context, instance *providerModel.Instance, consoleType string) (provider.ConsoleSession, error) {
+	providerApiService := &provider2.ProviderApiService{}
+	prov, _, err := providerApiService.GetProviderByID(instance.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+	consoleProvider, ok := prov.(provider.ConsoleProvider)
+	if !ok {
+		return nil, fmt.Errorf("%s 类型的节点不支持浏览器控制台", prov.GetType())
+	}
+	return consoleProvider.OpenConsole(ctx, instance.Name, consoleType)
+}
+
+// bridge 在WebSocket和控制台之间双向转发数据，任意一端关闭或超时后结束
+func bridge(ws *websocket.Conn, session provider.ConsoleSession) (int64, int64, error) {
+	var bytesIn, bytesOut atomic.Int64
+	errChan := make(chan error, 2)
+
+	// WebSocket -> 控制台
+	go func() {
+		for {
+			messageType, message, err := ws.ReadMessage()
+			if err != nil {
+				errChan <- err
+				return
+			}
+			if messageType == websocket.TextMessage && handleControlMessage(session, message) {
+				continue
+			}
+			n, err := session.Write(message)
+			bytesIn.Add(int64(n))
+			if err != nil {
+				errChan <- err
+				return
+			}
+		}
+	}()
+
+	// 控制台 -> WebSocket
+	go func() {
+		buf := make([]byte, 8192)
+		for {
+			n, err := session.Read(buf)
+			if n > 0 {
+				if writeErr := ws.WriteMessage(websocket.BinaryMessage, buf[:n]); writeErr != nil {
+					errChan <- writeErr
+					return
+				}
+				bytesOut.Add(int64(n))
+			}
+			if err != nil {
+				errChan <- err
+				return
+			}
+		}
+	}()
+
+	timer := time.NewTimer(sessionTimeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case err = <-errChan:
+	case <-timer.C:
+		err = fmt.Errorf("控制台会话超时")
+	}
+
+	// 关闭两端以中断仍在阻塞读取的goroutine
+	session.Close()
+	ws.Close()
+
+	if isNormalClose(err) {
+		err = nil
+	}
+	return bytesIn.Load(), bytesOut.Load(), err
+}
+
+// handleControlMessage 处理终端大小调整和心跳消息，返回false表示是普通输入
+func handleControlMessage(session provider.ConsoleSession, message []byte) bool {
+	var msg controlMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return false
+	}
+	switch msg.Type {
+	case "resize":
+		if msg.Cols > 0 && msg.Rows > 0 {
+			if err := session.Resize(msg.Rows, msg.Cols); err != nil {
+				global.APP_LOG.Debug("控制台窗口大小调整失败", zap.Error(err))
+			}
+		}
+		return true
+	case "ping":
+		return true
+	default:
+		return false
+	}
+}
+
+// isNormalClose 浏览器正常关闭或控制台正常退出不视为错误
+func isNormalClose(err error) bool {
+	if err == nil {
+		return true
+	}
+	if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+		return true
+	}
+	return errors.Is(err, io.EOF)
+}
+
+// startAudit 记录控制台会话开始，写入失败只记录日志，不影响会话
+func startAudit(instance *providerModel.Instance, info SessionInfo) *adminModel.AuditLog {
+	request, _ := json.Marshal(map[string]interface{}{
+		"action":       "console",
+		"instanceId":   instance.ID,
+		"instanceName": instance.Name,
+		"providerId":   instance.ProviderID,
+		"consoleType":  info.ConsoleType,
+		"admin":        info.IsAdmin,
+	})
+	userID := info.UserID
+	auditLog := &adminModel.AuditLog{
+		UserID:     &userID,
+		Username:   info.Username,
+		Method:     info.Method,
+		Path:       info.Path,
+		StatusCode: 101,
+		ClientIP:   info.ClientIP,
+		UserAgent:  info.UserAgent,
+		Request:    string(request),
+	}
+	if err := global.APP_DB.Create(auditLog).Error; err != nil {
+		global.APP_LOG.Error("写入控制台审计日志失败", zap.Uint("instanceId", instance.ID), zap.Error(err))
+	}
+	return auditLog
+}
+
+// finishAudit 会话结束后补充持续时间、流量和结束原因
+func finishAudit(auditLog *adminModel.AuditLog, startedAt time.Time, bytesIn, bytesOut int64, sessionErr error) {
+	if auditLog.ID == 0 {
+		return
+	}
+	result := map[string]interface{}{
+		"result":   "closed",
+		"bytesIn":  bytesIn,
+		"bytesOut": bytesOut,
+	}
+	if sessionErr != nil {
+		result["result"] = "error"
+		result["error"] = sessionErr.Error()
+	}
+	response, _ := json.Marshal(result)
+	if err := global.APP_DB.Model(auditLog).Updates(map[string]interface{}{
+		"latency":  time.Since(startedAt).Milliseconds(),
+		"response": string(response),
+	}).Error; err != nil {
+		global.APP_LOG.Error("更新控制台审计日志失败", zap.Uint("auditLogId", auditLog.ID), zap.Error(err))
+	}
+}
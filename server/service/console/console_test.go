@@ -0,0 +1,86 @@
+package console
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// echoConsole 回显输入的控制台，记录终端大小
+type echoConsole struct {
+	net.Conn
+	rows, cols int
+}
+
+func (c *echoConsole) Resize(rows, cols int) error {
+	c.rows, c.cols = rows, cols
+	return nil
+}
+
+func (c *echoConsole) Password() string { return "" }
+
+func newEchoConsole() *echoConsole {
+	client, server := net.Pipe()
+	go func() {
+		io.Copy(server, server)
+		server.Close()
+	}()
+	return &echoConsole{Conn: client}
+}
+
+func TestBridge_ForwardsInputAndHandlesControlMessages(t *testing.T) {
+	session := newEchoConsole()
+	done := make(chan error, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			done <- err
+			return
+		}
+		_, _, err = bridge(ws, session)
+		done <- err
+	}))
+	defer server.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("连接WebSocket失败: %v", err)
+	}
+
+	// 控制消息不应转发到控制台
+	client.WriteMessage(websocket.TextMessage, []byte(`{"type":"resize","cols":120,"rows":40}`))
+	client.WriteMessage(websocket.TextMessage, []byte(`{"type":"ping"}`))
+	client.WriteMessage(websocket.BinaryMessage, []byte("ls\r"))
+
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, reply, err := client.ReadMessage()
+	if err != nil {
+		t.Fatalf("读取控制台输出失败: %v", err)
+	}
+	if string(reply) != "ls\r" {
+		t.Errorf("控制台输出不正确: %q", reply)
+	}
+
+	client.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	client.Close()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("正常关闭不应返回错误: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("bridge未在关闭后退出")
+	}
+
+	if session.rows != 40 || session.cols != 120 {
+		t.Errorf("终端大小未调整: %dx%d", session.rows, session.cols)
+	}
+}
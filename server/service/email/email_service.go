@@ -205,3 +205,30 @@ https://github.com/qdmz/oneclickvirt`, activationURL)
 
 	return s.SendEmail([]string{to}, subject, body)
 }
+
+// SendMaintenanceEmail 发送节点维护通知邮件
+func (s *EmailService) SendMaintenanceEmail(to, username, providerName, reason string, evacuate bool) error {
+	subject := fmt.Sprintf("节点维护通知 - %s", providerName)
+	if reason == "" {
+		reason = "例行维护"
+	}
+	plan := "维护期间节点暂停申领新实例，您现有的实例不受影响。"
+	if evacuate {
+		plan = "您在该节点上的实例将被迁移到同类型的其他节点，迁移期间实例会短暂停机，完成后实例的IP地址和端口映射可能发生变化。"
+	}
+	body := fmt.Sprintf(`尊敬的 %s：
+
+您的实例所在节点 %s 即将进入维护。
+
+维护原因：%s
+
+%s
+
+如果您有任何问题，请随时联系我们。
+
+---
+OneClickVirt
+https://github.com/qdmz/oneclickvirt`, username, providerName, reason, plan)
+
+	return s.SendEmail([]string{to}, subject, body)
+}
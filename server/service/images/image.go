@@ -137,6 +137,8 @@ func (s *ImageService) GetImageDownloadPath(providerType, instanceType string) s
 		return filepath.Join(baseDir, "incus_container_images")
 	case "docker":
 		return filepath.Join(baseDir, "docker_images")
+	case "podman":
+		return filepath.Join(baseDir, "podman_images")
 	default:
 		return filepath.Join(baseDir, "images")
 	}
@@ -348,3 +350,33 @@ func (s *ImageService) GetFilteredImages(providerID uint, instanceType string) (
 	// 根据Provider类型、实例类型和架构过滤镜像
 	return s.GetAvailableImages(provider.Type, instanceType, architecture)
 }
+
+// GetReinstallImage 获取重装系统的目标镜像，镜像必须在该Provider的可用镜像列表中且与节点兼容
+func (s *ImageService) GetReinstallImage(providerID uint, instanceType string, imageID uint) (*system.SystemImage, error) {
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, providerID).Error; err != nil {
+		return nil, fmt.Errorf("Provider不存在: %v", err)
+	}
+
+	images, err := s.GetFilteredImages(providerID, instanceType)
+	if err != nil {
+		return nil, err
+	}
+
+	architecture := provider.Architecture
+	if architecture == "" {
+		architecture = "amd64"
+	}
+
+	for _, img := range images {
+		if img.ID != imageID {
+			continue
+		}
+		if !s.isImageCompatible(img, provider.Type, instanceType, architecture) {
+			return nil, fmt.Errorf("镜像 %s 与节点 %s 不兼容", img.Name, provider.Name)
+		}
+		return &img, nil
+	}
+
+	return nil, fmt.Errorf("镜像不存在或不可用于该节点")
+}
@@ -135,7 +135,8 @@ func (s *Service) detectVethInterface(providerInstance provider.Provider, instan
 				zap.String("instance", instanceName),
 				zap.Error(err))
 		}
-	} else if providerType == "incus" {
+	} else if providerType == "incus" || providerType == "podman" {
+		// Podman rootless模式下返回的是容器网络命名空间内的接口（slirp4netns/pasta），不符合veth命名
 		if incusProv, ok := providerInstance.(interface {
 			GetVethInterfaceName(context.Context, string) (string, error)
 		}); ok {
@@ -143,27 +144,29 @@ func (s *Service) detectVethInterface(providerInstance provider.Provider, instan
 			defer cancel()
 			vethName, err := incusProv.GetVethInterfaceName(ctx, instanceName)
 			if err == nil && vethName != "" {
-				global.APP_LOG.Info("通过Incus Provider方法成功获取veth接口",
+				global.APP_LOG.Info("通过Provider方法成功获取veth接口",
 					zap.String("instance", instanceName),
+					zap.String("providerType", providerType),
 					zap.String("veth", vethName))
 				return vethName, nil
 			}
-			global.APP_LOG.Warn("Incus Provider方法获取veth接口失败，使用备用方法",
+			global.APP_LOG.Warn("Provider方法获取veth接口失败，使用备用方法",
 				zap.String("instance", instanceName),
+				zap.String("providerType", providerType),
 				zap.Error(err))
 		}
 	}
 
 	// 备用方法：通过进程和网络命名空间检测（适用于所有虚拟化类型）
 	var detectCmd string
-	if providerType == "docker" {
-		// Docker容器veth接口检测
+	if providerType == "docker" || providerType == "podman" {
+		// Docker/Podman(rootful)容器veth接口检测
 		detectCmd = fmt.Sprintf(`
-# 检测Docker容器对应的veth接口
+# 检测Docker/Podman容器对应的veth接口
 CONTAINER_NAME='%s'
 
 # 1. 获取容器PID
-CONTAINER_PID=$(docker inspect -f '{{.State.Pid}}' "$CONTAINER_NAME" 2>/dev/null)
+CONTAINER_PID=$(%s inspect -f '{{.State.Pid}}' "$CONTAINER_NAME" 2>/dev/null)
 if [ -z "$CONTAINER_PID" ] || [ "$CONTAINER_PID" = "0" ]; then
     echo "ERROR: 容器未运行或PID为0" >&2
     exit 1
@@ -190,7 +193,7 @@ fi
 
 echo "ERROR: 无法找到有效的veth接口" >&2
 exit 1
-`, instanceName)
+`, instanceName, providerType)
 	} else if providerType == "lxd" || providerType == "incus" {
 		// LXD/Incus容器veth接口检测（备用方法）
 		cmd := "lxc"
@@ -483,8 +486,8 @@ func (s *Service) detectNetworkInterfaces(providerInstance provider.Provider, in
 		zap.String("instance", instanceName),
 		zap.Bool("hasIPv6", hasIPv6))
 
-	// Docker/LXD/Incus 容器: 优先检测veth接口
-	if providerType == "docker" || providerType == "lxd" || providerType == "incus" {
+	// Docker/Podman/LXD/Incus 容器: 优先检测veth接口
+	if providerType == "docker" || providerType == "podman" || providerType == "lxd" || providerType == "incus" {
 		// 尝试检测veth接口
 		vethInterface, err := s.detectVethInterface(providerInstance, instanceName)
 		if err != nil {
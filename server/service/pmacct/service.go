@@ -564,6 +564,21 @@ plugin_pipe_size[sqlite]: %d
 		bpfFilter,
 		dataFile,
 		sqlCacheEntries, pluginBufferSize, pluginPipeSize)
+
+	// Podman rootless容器的流量不经过宿主机veth，需要通过nsenter进入容器网络命名空间运行pmacctd
+	// 容器重启后旧命名空间销毁，pmacctd退出并由systemd按新PID重新拉起
+	execStart := fmt.Sprintf("/usr/sbin/pmacctd -f %s", configFile)
+	netnsPIDCmd, inNetns := "", false
+	if netnsProv, ok := providerInstance.(interface {
+		GetNetnsPIDCommand(string) (string, bool)
+	}); ok {
+		netnsPIDCmd, inNetns = netnsProv.GetNetnsPIDCommand(instanceName)
+	}
+	netnsScript := fmt.Sprintf("%s/pmacctd-netns.sh", configDir)
+	if inNetns {
+		execStart = netnsScript
+	}
+
 	// systemd服务文件内容
 	systemdService := fmt.Sprintf(`[Unit]
 Description=pmacct daemon for instance %s
@@ -572,7 +587,7 @@ After=network.target
 
 [Service]
 Type=simple
-ExecStart=/usr/sbin/pmacctd -f %s
+ExecStart=%s
 ExecReload=/bin/kill -HUP $MAINPID
 Restart=on-failure
 RestartSec=5s
@@ -581,7 +596,7 @@ StandardError=journal
 
 [Install]
 WantedBy=multi-user.target
-`, instanceName, configFile)
+`, instanceName, execStart)
 
 	// 步骤1: 创建配置目录
 	mkdirCmd := fmt.Sprintf("mkdir -p %s && chmod 755 %s", configDir, configDir)
@@ -597,6 +612,18 @@ WantedBy=multi-user.target
 		return fmt.Errorf("failed to upload pmacct config file: %w", err)
 	}
 
+	// 上传进入容器网络命名空间的启动脚本，exec保证pkill按配置文件路径仍能匹配到pmacctd进程
+	if inNetns {
+		wrapper := fmt.Sprintf(`#!/bin/sh
+PID=$(%s 2>/dev/null)
+[ -n "$PID" ] && [ "$PID" != "0" ] || exit 1
+exec nsenter -t "$PID" -n /usr/sbin/pmacctd -f %s
+`, netnsPIDCmd, configFile)
+		if err := s.uploadFileViaSFTP(providerInstance, wrapper, netnsScript, 0755); err != nil {
+			return fmt.Errorf("failed to upload pmacct netns script: %w", err)
+		}
+	}
+
 	// 步骤3: 初始化SQLite数据库表结构
 	// pmacct不会自动创建表，需要手动创建acct_v9表
 	if err := s.initializePmacctDatabase(providerInstance, dataFile); err != nil {
@@ -631,6 +658,11 @@ fi
 	initSystem = strings.TrimSpace(initSystem)
 	global.APP_LOG.Info("检测到init系统", zap.String("initSystem", initSystem))
 
+	// 进入网络命名空间依赖systemd在容器重启后自动重新拉起pmacctd
+	if inNetns && initSystem != "systemd" {
+		return fmt.Errorf("rootless容器流量监控需要宿主机使用systemd，当前init系统: %s", initSystem)
+	}
+
 	// 根据init系统类型创建服务
 	switch initSystem {
 	case "systemd":
@@ -83,10 +83,10 @@ func (s *ProviderApiService) GetProviderStatusByID(providerIDStr string) (map[st
 		return nil, fmt.Errorf("Provider不存在")
 	}
 
-	// Docker 类型固定使用 native 端口映射方式
+	// Docker/Podman 类型固定使用 native 端口映射方式
 	ipv4Method := dbProvider.IPv4PortMappingMethod
 	ipv6Method := dbProvider.IPv6PortMappingMethod
-	if dbProvider.Type == "docker" {
+	if dbProvider.Type == "docker" || dbProvider.Type == "podman" {
 		ipv4Method = "native"
 		ipv6Method = "native"
 	}
@@ -160,10 +160,10 @@ func (s *ProviderApiService) GetProviderCapabilitiesByID(providerIDStr string) (
 		return nil, fmt.Errorf("Provider不存在")
 	}
 
-	// Docker 类型固定使用 native 端口映射方式
+	// Docker/Podman 类型固定使用 native 端口映射方式
 	ipv4Method := dbProvider.IPv4PortMappingMethod
 	ipv6Method := dbProvider.IPv6PortMappingMethod
-	if dbProvider.Type == "docker" {
+	if dbProvider.Type == "docker" || dbProvider.Type == "podman" {
 		ipv4Method = "native"
 		ipv6Method = "native"
 	}
@@ -171,9 +171,13 @@ func (s *ProviderApiService) GetProviderCapabilitiesByID(providerIDStr string) (
 	// 检查Provider是否已连接（不尝试新连接）
 	providerService := GetProviderService()
 	var supportedTypes []string
+	var nodeCapabilities *provider.Capabilities
 
 	if prov, exists := providerService.GetProviderByID(dbProvider.ID); exists && prov.IsConnected() {
 		supportedTypes = prov.GetSupportedInstanceTypes()
+		if caps := prov.GetCapabilities(); !caps.ProbedAt.IsZero() {
+			nodeCapabilities = &caps
+		}
 	} else {
 		// 根据配置返回支持的实例类型
 		if dbProvider.ContainerEnabled && dbProvider.VirtualMachineEnabled {
@@ -211,6 +215,14 @@ func (s *ProviderApiService) GetProviderCapabilitiesByID(providerIDStr string) (
 		"trafficMultiplier": dbProvider.TrafficMultiplier,
 	}
 
+	// 节点能力文档：优先使用已连接Provider的最新探测结果，否则使用数据库中保存的结果
+	if nodeCapabilities == nil {
+		if caps, ok := dbProvider.ParseCapabilities(); ok {
+			nodeCapabilities = caps
+		}
+	}
+	capabilities["capabilities"] = nodeCapabilities
+
 	return capabilities, nil
 }
 
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+
+	"go.uber.org/zap"
+)
+
+// capabilityLabels 能力名称对应的中文描述，用于错误提示
+var capabilityLabels = map[string]string{
+	providerModel.CapabilityIPv6:           "IPv6",
+	providerModel.CapabilitySnapshot:       "快照",
+	providerModel.CapabilityBackup:         "备份",
+	providerModel.CapabilityMigration:      "跨节点迁移",
+	providerModel.CapabilityDiskResize:     "磁盘扩容",
+	providerModel.CapabilityDiskQuota:      "磁盘配额",
+	providerModel.CapabilityBandwidthLimit: "带宽限制",
+	providerModel.CapabilityDiskIOLimit:    "磁盘IO限制",
+	providerModel.CapabilityNesting:        "嵌套虚拟化",
+	providerModel.CapabilityPrivileged:     "特权容器",
+}
+
+// SaveProviderCapabilities 将探测到的能力文档持久化到数据库
+func SaveProviderCapabilities(providerID uint, caps provider.Capabilities) error {
+	data, err := json.Marshal(caps)
+	if err != nil {
+		return fmt.Errorf("序列化节点能力失败: %w", err)
+	}
+	return global.APP_DB.Model(&providerModel.Provider{}).
+		Where("id = ?", providerID).
+		Update("capabilities", string(data)).Error
+}
+
+// RequireCapabilities 检查节点是否具备指定能力
+// 尚未探测过能力的节点不做限制，由Provider在执行时自行报错
+func RequireCapabilities(dbProvider *providerModel.Provider, required ...string) error {
+	caps, ok := dbProvider.ParseCapabilities()
+	if !ok {
+		return nil
+	}
+	for _, name := range required {
+		if !caps.Supports(name) {
+			label := capabilityLabels[name]
+			if label == "" {
+				label = name
+			}
+			return fmt.Errorf("节点 %s 不支持%s", dbProvider.Name, label)
+		}
+	}
+	return nil
+}
+
+// RequireInstanceType 检查节点是否支持指定的实例类型
+func RequireInstanceType(dbProvider *providerModel.Provider, instanceType string) error {
+	caps, ok := dbProvider.ParseCapabilities()
+	if !ok || caps.SupportsInstanceType(instanceType) {
+		return nil
+	}
+	return fmt.Errorf("节点 %s 不支持 %s 类型的实例", dbProvider.Name, instanceType)
+}
+
+// persistConnectedCapabilities Connect成功后保存Provider探测到的能力，失败只记录日志
+func persistConnectedCapabilities(providerID uint, prov provider.Provider) {
+	caps := prov.GetCapabilities()
+	if caps.ProbedAt.IsZero() {
+		return
+	}
+	if err := SaveProviderCapabilities(providerID, caps); err != nil {
+		global.APP_LOG.Warn("保存节点能力失败", zap.Uint("providerId", providerID), zap.Error(err))
+	}
+}
@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"encoding/json"
+	"testing"
+
+	providerModel "oneclickvirt/model/provider"
+)
+
+func TestRequireCapabilities(t *testing.T) {
+	dbProvider := &providerModel.Provider{Name: "node-1"}
+
+	// 尚未探测能力的节点不做限制
+	if err := RequireCapabilities(dbProvider, providerModel.CapabilitySnapshot); err != nil {
+		t.Fatalf("未探测的节点不应被拒绝: %v", err)
+	}
+
+	data, _ := json.Marshal(providerModel.ProviderCapabilities{
+		InstanceTypes: []string{"container"},
+		Snapshot:      true,
+	})
+	dbProvider.Capabilities = string(data)
+
+	if err := RequireCapabilities(dbProvider, providerModel.CapabilitySnapshot); err != nil {
+		t.Errorf("节点支持快照: %v", err)
+	}
+	if err := RequireCapabilities(dbProvider, providerModel.CapabilitySnapshot, providerModel.CapabilityMigration); err == nil {
+		t.Error("节点不支持迁移时应被拒绝")
+	}
+	if err := RequireInstanceType(dbProvider, "container"); err != nil {
+		t.Errorf("节点支持容器: %v", err)
+	}
+	if err := RequireInstanceType(dbProvider, "vm"); err == nil {
+		t.Error("节点不支持虚拟机时应被拒绝")
+	}
+}
@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"sync"
 	"time"
@@ -133,6 +134,8 @@ func (ps *ProviderService) LoadProvider(dbProvider providerModel.Provider) error
 		SSHConnectTimeout:     dbProvider.SSHConnectTimeout,
 		SSHExecuteTimeout:     dbProvider.SSHExecuteTimeout,
 		HostName:              dbProvider.HostName, // 传递数据库中存储的主机名，避免动态获取导致的节点混淆
+		StoragePool:           dbProvider.StoragePool,
+		PodmanRootlessUser:    dbProvider.PodmanRootlessUser,
 		// 资源限制配置
 		ContainerLimitCPU:    dbProvider.ContainerLimitCPU,
 		ContainerLimitMemory: dbProvider.ContainerLimitMemory,
@@ -209,6 +212,7 @@ func (ps *ProviderService) LoadProvider(dbProvider providerModel.Provider) error
 	// 存储Provider实例（使用ID作为key）
 	// 此时已经持有ps.mutex.Lock()，不需要再次加锁
 	ps.providers[dbProvider.ID] = prov
+	persistConnectedCapabilities(dbProvider.ID, prov)
 
 	global.APP_LOG.Info("Provider加载成功",
 		zap.String("name", dbProvider.Name),
@@ -379,3 +383,126 @@ func (ps *ProviderService) ResetInstancePassword(ctx context.Context, providerID
 	// 调用Provider的密码重置方法
 	return prov.ResetInstancePassword(ctx, instanceName)
 }
+
+// CreateSnapshot 创建实例快照
+func (ps *ProviderService) CreateSnapshot(ctx context.Context, providerID uint, instanceName, snapshotName string) error {
+	prov, err := ps.getOrLoadProvider(providerID)
+	if err != nil {
+		return err
+	}
+	return prov.CreateSnapshot(ctx, instanceName, snapshotName)
+}
+
+// ListSnapshots 列出实例快照
+func (ps *ProviderService) ListSnapshots(ctx context.Context, providerID uint, instanceName string) ([]provider.Snapshot, error) {
+	prov, err := ps.getOrLoadProvider(providerID)
+	if err != nil {
+		return nil, err
+	}
+	return prov.ListSnapshots(ctx, instanceName)
+}
+
+// RestoreSnapshot 恢复实例快照
+func (ps *ProviderService) RestoreSnapshot(ctx context.Context, providerID uint, instanceName, snapshotName string) error {
+	prov, err := ps.getOrLoadProvider(providerID)
+	if err != nil {
+		return err
+	}
+	return prov.RestoreSnapshot(ctx, instanceName, snapshotName)
+}
+
+// DeleteSnapshot 删除实例快照
+func (ps *ProviderService) DeleteSnapshot(ctx context.Context, providerID uint, instanceName, snapshotName string) error {
+	prov, err := ps.getOrLoadProvider(providerID)
+	if err != nil {
+		return err
+	}
+	return prov.DeleteSnapshot(ctx, instanceName, snapshotName)
+}
+
+// GetVolumeProvider 获取支持数据卷的Provider，节点类型不支持时返回错误
+func (ps *ProviderService) GetVolumeProvider(providerID uint) (provider.VolumeProvider, error) {
+	prov, err := ps.getOrLoadProvider(providerID)
+	if err != nil {
+		return nil, err
+	}
+	volumeProvider, ok := prov.(provider.VolumeProvider)
+	if !ok {
+		return nil, fmt.Errorf("%s 类型的节点不支持数据卷", prov.GetType())
+	}
+	return volumeProvider, nil
+}
+
+// GetPrivateNetworkProvider 获取支持私有网络的Provider，节点类型不支持时返回错误
+func (ps *ProviderService) GetPrivateNetworkProvider(providerID uint) (provider.PrivateNetworkProvider, error) {
+	prov, err := ps.getOrLoadProvider(providerID)
+	if err != nil {
+		return nil, err
+	}
+	networkProvider, ok := prov.(provider.PrivateNetworkProvider)
+	if !ok {
+		return nil, fmt.Errorf("%s 类型的节点不支持私有网络", prov.GetType())
+	}
+	return networkProvider, nil
+}
+
+// ResizeInstance 调整实例配置
+func (ps *ProviderService) ResizeInstance(ctx context.Context, providerID uint, instanceName string, spec provider.ResizeSpec) error {
+	prov, err := ps.getOrLoadProvider(providerID)
+	if err != nil {
+		return err
+	}
+	return prov.ResizeInstance(ctx, instanceName, spec)
+}
+
+// ExportInstance 导出实例归档到w
+func (ps *ProviderService) ExportInstance(ctx context.Context, providerID uint, instanceName string, w io.Writer, progressCallback provider.ProgressCallback) error {
+	prov, err := ps.getOrLoadProvider(providerID)
+	if err != nil {
+		return err
+	}
+	return prov.ExportInstance(ctx, instanceName, w, progressCallback)
+}
+
+// RestoreInstance 从归档原地恢复实例
+func (ps *ProviderService) RestoreInstance(ctx context.Context, providerID uint, instanceName string, r io.Reader, progressCallback provider.ProgressCallback) error {
+	prov, err := ps.getOrLoadProvider(providerID)
+	if err != nil {
+		return err
+	}
+	return prov.RestoreInstance(ctx, instanceName, r, progressCallback)
+}
+
+// getOrLoadProvider 获取已连接的Provider实例，未连接时尝试动态加载
+func (ps *ProviderService) getOrLoadProvider(providerID uint) (provider.Provider, error) {
+	var dbProvider providerModel.Provider
+	if err := global.APP_DB.First(&dbProvider, providerID).Error; err != nil {
+		return nil, fmt.Errorf("获取Provider信息失败: %v", err)
+	}
+
+	ps.mutex.RLock()
+	prov, exists := ps.providers[dbProvider.ID]
+	ps.mutex.RUnlock()
+	if exists {
+		return prov, nil
+	}
+
+	global.APP_LOG.Info("Provider未连接，尝试动态加载",
+		zap.Uint("id", dbProvider.ID),
+		zap.String("name", dbProvider.Name))
+	if err := ps.LoadProvider(dbProvider); err != nil {
+		global.APP_LOG.Error("动态加载Provider失败",
+			zap.Uint("id", dbProvider.ID),
+			zap.String("name", dbProvider.Name),
+			zap.Error(err))
+		return nil, fmt.Errorf("Provider ID %d 连接失败: %v", dbProvider.ID, err)
+	}
+
+	ps.mutex.RLock()
+	prov, exists = ps.providers[dbProvider.ID]
+	ps.mutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("Provider ID %d 连接后仍然不可用", dbProvider.ID)
+	}
+	return prov, nil
+}
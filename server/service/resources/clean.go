@@ -60,6 +60,21 @@ func (s *PortMappingService) DeleteInstancePortMappingsInTx(tx *gorm.DB, instanc
 	return nil
 }
 
+// ReleaseInstanceAttachmentsInTx 在事务中清理实例的附属记录：解除数据卷挂载、删除私有网络接入和防火墙规则
+// 用于实例已不存在于节点上时的记录清理，端口映射和资源配额由调用方单独释放
+func ReleaseInstanceAttachmentsInTx(tx *gorm.DB, instanceID uint) error {
+	if err := DetachInstanceVolumesInTx(tx, instanceID); err != nil {
+		return fmt.Errorf("解除实例数据卷挂载失败: %v", err)
+	}
+	if err := DetachInstancePrivateNetworksInTx(tx, instanceID); err != nil {
+		return fmt.Errorf("删除实例私有网络接入记录失败: %v", err)
+	}
+	if err := tx.Where("instance_id = ?", instanceID).Delete(&provider.FirewallRule{}).Error; err != nil {
+		return fmt.Errorf("删除实例防火墙规则失败: %v", err)
+	}
+	return nil
+}
+
 // BatchDeletePortMappingWithTask 批量删除端口映射（通过任务系统异步执行，仅支持删除手动添加的端口）
 // 返回任务数据列表（由调用者创建和启动任务）
 func (s *PortMappingService) BatchDeletePortMappingWithTask(req admin.BatchDeletePortMappingRequest) ([]*admin.DeletePortMappingTaskRequest, error) {
@@ -0,0 +1,303 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	productModel "oneclickvirt/model/product"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/model/resource"
+	systemModel "oneclickvirt/model/system"
+	"oneclickvirt/service/auth"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// FlavorService 实例规格管理服务
+type FlavorService struct{}
+
+// NewFlavorService 创建实例规格服务
+func NewFlavorService() *FlavorService {
+	return &FlavorService{}
+}
+
+// ListFlavors 获取实例规格列表
+func (s *FlavorService) ListFlavors(req adminModel.FlavorListRequest) ([]resource.Flavor, error) {
+	query := global.APP_DB.Model(&resource.Flavor{}).Order("sort_order ASC, id ASC")
+	if req.Name != "" {
+		query = query.Where("name LIKE ?", "%"+req.Name+"%")
+	}
+	if req.Enabled != "" {
+		query = query.Where("is_enabled = ?", req.Enabled == "true")
+	}
+
+	var flavors []resource.Flavor
+	if err := query.Find(&flavors).Error; err != nil {
+		return nil, fmt.Errorf("查询实例规格失败: %v", err)
+	}
+	if req.InstanceType == "" {
+		return flavors, nil
+	}
+	filtered := make([]resource.Flavor, 0, len(flavors))
+	for _, flavor := range flavors {
+		if flavor.AllowsInstanceType(req.InstanceType) {
+			filtered = append(filtered, flavor)
+		}
+	}
+	return filtered, nil
+}
+
+// GetFlavor 获取实例规格
+func (s *FlavorService) GetFlavor(id uint) (*resource.Flavor, error) {
+	var flavor resource.Flavor
+	if err := global.APP_DB.First(&flavor, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("实例规格不存在")
+		}
+		return nil, fmt.Errorf("查询实例规格失败: %v", err)
+	}
+	return &flavor, nil
+}
+
+// CreateFlavor 创建实例规格
+func (s *FlavorService) CreateFlavor(req adminModel.FlavorRequest) (*resource.Flavor, error) {
+	flavor := &resource.Flavor{}
+	applyFlavorRequest(flavor, req)
+	if err := validateFlavorSpecs(flavor); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	global.APP_DB.Model(&resource.Flavor{}).Where("name = ?", flavor.Name).Count(&count)
+	if count > 0 {
+		return nil, errors.New("实例规格名称已存在")
+	}
+
+	if err := global.APP_DB.Create(flavor).Error; err != nil {
+		return nil, fmt.Errorf("创建实例规格失败: %v", err)
+	}
+
+	global.APP_LOG.Info("实例规格已创建",
+		zap.Uint("flavorID", flavor.ID),
+		zap.String("name", flavor.Name))
+	return flavor, nil
+}
+
+// UpdateFlavor 更新实例规格，已创建的实例不受影响
+func (s *FlavorService) UpdateFlavor(id uint, req adminModel.FlavorRequest) (*resource.Flavor, error) {
+	flavor, err := s.GetFlavor(id)
+	if err != nil {
+		return nil, err
+	}
+	applyFlavorRequest(flavor, req)
+	if err := validateFlavorSpecs(flavor); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	global.APP_DB.Model(&resource.Flavor{}).Where("name = ? AND id <> ?", flavor.Name, flavor.ID).Count(&count)
+	if count > 0 {
+		return nil, errors.New("实例规格名称已存在")
+	}
+
+	if err := global.APP_DB.Save(flavor).Error; err != nil {
+		return nil, fmt.Errorf("更新实例规格失败: %v", err)
+	}
+
+	global.APP_LOG.Info("实例规格已更新",
+		zap.Uint("flavorID", flavor.ID),
+		zap.String("name", flavor.Name))
+	return flavor, nil
+}
+
+// DeleteFlavor 删除实例规格，仍被产品引用时不允许删除
+func (s *FlavorService) DeleteFlavor(id uint) error {
+	if _, err := s.GetFlavor(id); err != nil {
+		return err
+	}
+
+	var productCount int64
+	global.APP_DB.Model(&productModel.Product{}).Where("flavor_id = ?", id).Count(&productCount)
+	if productCount > 0 {
+		return fmt.Errorf("实例规格仍被 %d 个产品引用，请先修改产品", productCount)
+	}
+
+	if err := global.APP_DB.Delete(&resource.Flavor{}, id).Error; err != nil {
+		return fmt.Errorf("删除实例规格失败: %v", err)
+	}
+
+	global.APP_LOG.Info("实例规格已删除", zap.Uint("flavorID", id))
+	return nil
+}
+
+// GetUserFlavors 获取用户可选择的实例规格
+// 只返回已启用、节点提供且不超过用户等级资源限制的规格，providerID为0时不按节点过滤
+func (s *FlavorService) GetUserFlavors(userID uint, providerID uint, instanceType string) ([]resource.Flavor, error) {
+	flavors, err := s.ListFlavors(adminModel.FlavorListRequest{InstanceType: instanceType, Enabled: "true"})
+	if err != nil {
+		return nil, err
+	}
+
+	permissionService := auth.PermissionService{}
+	effective, err := permissionService.GetUserEffectivePermission(userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户权限失败: %v", err)
+	}
+
+	// 管理员不受等级限制
+	var limits []map[string]float64
+	if effective.EffectiveType != "admin" {
+		if levelLimits, ok := global.APP_CONFIG.Quota.LevelLimits[effective.EffectiveLevel]; ok {
+			globalLimits := make(map[string]float64, len(levelLimits.MaxResources))
+			for key, value := range levelLimits.MaxResources {
+				switch v := value.(type) {
+				case float64:
+					globalLimits[key] = v
+				case int:
+					globalLimits[key] = float64(v)
+				}
+			}
+			limits = append(limits, globalLimits)
+		}
+		if providerID > 0 {
+			var provider providerModel.Provider
+			if err := global.APP_DB.First(&provider, providerID).Error; err == nil {
+				limits = append(limits, providerLevelMaxResources(&provider, effective.EffectiveLevel))
+			}
+		}
+	}
+
+	available := make([]resource.Flavor, 0, len(flavors))
+	for _, flavor := range flavors {
+		if providerID > 0 && !flavor.AllowsProvider(providerID) {
+			continue
+		}
+		if flavorExceedsLimits(&flavor, limits) {
+			continue
+		}
+		available = append(available, flavor)
+	}
+	return available, nil
+}
+
+// ResolveFlavor 获取用户选择的规格并校验规格可用于指定的节点和实例类型
+func (s *FlavorService) ResolveFlavor(db *gorm.DB, flavorID uint, providerID uint, instanceType string) (*resource.Flavor, error) {
+	var flavor resource.Flavor
+	if err := db.First(&flavor, flavorID).Error; err != nil {
+		return nil, errors.New("实例规格不存在")
+	}
+	if !flavor.IsEnabled {
+		return nil, errors.New("实例规格已停用")
+	}
+	if instanceType != "" && !flavor.AllowsInstanceType(instanceType) {
+		return nil, fmt.Errorf("实例规格 %s 不允许创建 %s 类型实例", flavor.Name, instanceType)
+	}
+	if providerID > 0 && !flavor.AllowsProvider(providerID) {
+		return nil, fmt.Errorf("该节点不提供实例规格 %s", flavor.Name)
+	}
+	return &flavor, nil
+}
+
+// CheckFlavorImage 检查镜像是否在规格的镜像白名单中
+func CheckFlavorImage(flavor *resource.Flavor, imageID uint) error {
+	if !flavor.AllowsImage(imageID) {
+		return fmt.Errorf("实例规格 %s 不允许使用所选镜像", flavor.Name)
+	}
+	return nil
+}
+
+// CheckFlavorImageName 按镜像名称检查镜像是否在规格的镜像白名单中
+func CheckFlavorImageName(flavor *resource.Flavor, imageName string) error {
+	imageIDs := flavor.ImageIDList()
+	if len(imageIDs) == 0 {
+		return nil
+	}
+	var count int64
+	global.APP_DB.Model(&systemModel.SystemImage{}).Where("id IN ? AND name = ?", imageIDs, imageName).Count(&count)
+	if count == 0 {
+		return fmt.Errorf("实例规格 %s 不允许使用所选镜像", flavor.Name)
+	}
+	return nil
+}
+
+// FlavorSpecIDs 返回规格对应的预定义CPU、内存、磁盘和带宽规格ID
+func FlavorSpecIDs(flavor *resource.Flavor) (cpuID, memoryID, diskID, bandwidthID string) {
+	return fmt.Sprintf("cpu-%d", flavor.CPU),
+		fmt.Sprintf("mem-%dmb", flavor.Memory),
+		fmt.Sprintf("disk-%dmb", flavor.Disk),
+		fmt.Sprintf("bw-%dmbps", flavor.Bandwidth)
+}
+
+// CheckFlavorMatches 检查请求的资源是否与规格一致
+func CheckFlavorMatches(flavor *resource.Flavor, cpu int, memory, disk int64, bandwidth int) error {
+	if cpu != flavor.CPU || memory != flavor.Memory || disk != flavor.Disk {
+		return fmt.Errorf("请求的资源与实例规格 %s 不一致", flavor.Name)
+	}
+	if bandwidth > 0 && bandwidth != flavor.Bandwidth {
+		return fmt.Errorf("请求的带宽与实例规格 %s 不一致", flavor.Name)
+	}
+	return nil
+}
+
+// applyFlavorRequest 将请求内容写入规格
+func applyFlavorRequest(flavor *resource.Flavor, req adminModel.FlavorRequest) {
+	flavor.Name = strings.TrimSpace(req.Name)
+	flavor.Description = req.Description
+	flavor.CPU = req.CPU
+	flavor.Memory = req.Memory
+	flavor.Disk = req.Disk
+	flavor.Bandwidth = req.Bandwidth
+	flavor.Traffic = req.Traffic
+	flavor.InstanceTypes = strings.Join(req.InstanceTypes, ",")
+	flavor.ImageIDs = resource.FormatFlavorIDs(req.ImageIDs)
+	flavor.ProviderIDs = resource.FormatFlavorIDs(req.ProviderIDs)
+	flavor.IsEnabled = req.IsEnabled
+	flavor.SortOrder = req.SortOrder
+}
+
+// validateFlavorSpecs 规格的各项资源必须是系统预定义规格，保证创建实例时可以按规格ID下发
+func validateFlavorSpecs(flavor *resource.Flavor) error {
+	if flavor.Name == "" {
+		return errors.New("实例规格名称不能为空")
+	}
+	if len(flavor.InstanceTypeList()) == 0 {
+		return errors.New("至少需要允许一种实例类型")
+	}
+	cpuID, memoryID, diskID, bandwidthID := FlavorSpecIDs(flavor)
+	if spec, err := constant.GetCPUSpecByID(cpuID); err != nil || spec.Cores != flavor.CPU {
+		return fmt.Errorf("CPU核心数 %d 不是预定义规格", flavor.CPU)
+	}
+	if spec, err := constant.GetMemorySpecByID(memoryID); err != nil || int64(spec.SizeMB) != flavor.Memory {
+		return fmt.Errorf("内存 %dMB 不是预定义规格", flavor.Memory)
+	}
+	if spec, err := constant.GetDiskSpecByID(diskID); err != nil || int64(spec.SizeMB) != flavor.Disk {
+		return fmt.Errorf("磁盘 %dMB 不是预定义规格", flavor.Disk)
+	}
+	if spec, err := constant.GetBandwidthSpecByID(bandwidthID); err != nil || spec.SpeedMbps != flavor.Bandwidth {
+		return fmt.Errorf("带宽 %dMbps 不是预定义规格", flavor.Bandwidth)
+	}
+	return nil
+}
+
+// flavorExceedsLimits 检查规格是否超过任一等级资源限制，限制值小于等于0表示不限制
+func flavorExceedsLimits(flavor *resource.Flavor, limits []map[string]float64) bool {
+	values := map[string]float64{
+		"cpu":       float64(flavor.CPU),
+		"memory":    float64(flavor.Memory),
+		"disk":      float64(flavor.Disk),
+		"bandwidth": float64(flavor.Bandwidth),
+	}
+	for _, limit := range limits {
+		for key, value := range values {
+			if max, ok := limit[key]; ok && max > 0 && value > max {
+				return true
+			}
+		}
+	}
+	return false
+}
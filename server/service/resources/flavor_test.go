@@ -0,0 +1,45 @@
+package resources
+
+import (
+	"testing"
+
+	"oneclickvirt/model/resource"
+)
+
+func TestValidateFlavorSpecs(t *testing.T) {
+	flavor := &resource.Flavor{Name: "small", CPU: 2, Memory: 2048, Disk: 20480, Bandwidth: 100, InstanceTypes: "container,vm"}
+	if err := validateFlavorSpecs(flavor); err != nil {
+		t.Fatalf("expected predefined specs to be accepted, got %v", err)
+	}
+
+	cpuID, memoryID, diskID, bandwidthID := FlavorSpecIDs(flavor)
+	if cpuID != "cpu-2" || memoryID != "mem-2048mb" || diskID != "disk-20480mb" || bandwidthID != "bw-100mbps" {
+		t.Fatalf("unexpected spec IDs: %s %s %s %s", cpuID, memoryID, diskID, bandwidthID)
+	}
+
+	odd := *flavor
+	odd.Memory = 1000
+	if err := validateFlavorSpecs(&odd); err == nil {
+		t.Fatal("expected memory outside the predefined specs to be rejected")
+	}
+}
+
+func TestFlavorRestrictions(t *testing.T) {
+	flavor := &resource.Flavor{CPU: 1, Memory: 512, Disk: 10240, Bandwidth: 10, InstanceTypes: "container", ProviderIDs: resource.FormatFlavorIDs([]uint{3, 5, 3})}
+
+	if !flavor.AllowsInstanceType("container") || flavor.AllowsInstanceType("vm") {
+		t.Fatal("instance type whitelist not honoured")
+	}
+	if !flavor.AllowsProvider(5) || flavor.AllowsProvider(4) {
+		t.Fatal("provider availability not honoured")
+	}
+	if !flavor.AllowsImage(42) {
+		t.Fatal("an empty image whitelist should allow every image")
+	}
+	if err := CheckFlavorMatches(flavor, 1, 512, 10240, 0); err != nil {
+		t.Fatalf("matching resources should pass, got %v", err)
+	}
+	if err := CheckFlavorMatches(flavor, 2, 512, 10240, 0); err == nil {
+		t.Fatal("resources that differ from the flavor should be rejected")
+	}
+}
@@ -0,0 +1,96 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/model/resource"
+	"oneclickvirt/provider"
+	mockProvider "oneclickvirt/provider/mock"
+)
+
+// TestMockFlow_ResourceAccounting 在模拟节点和SQLite上创建容器和虚拟机，检查资源按实例类型分配、
+// 同步回填和释放，两种类型各自受超分配预算限制，折算后的合计不超过节点物理容量
+func TestMockFlow_ResourceAccounting(t *testing.T) {
+	mockProvider.SetupTestDB(t, &providerModel.Provider{}, &providerModel.Instance{}, &providerModel.Volume{})
+	ctx := context.Background()
+
+	dbProvider := providerModel.Provider{
+		Name: "mock-resource-node", Type: "mock", Endpoint: "192.0.2.30", Status: "active",
+		ContainerEnabled: true, VirtualMachineEnabled: true,
+		ContainerLimitCPU: true, ContainerLimitMemory: true, ContainerLimitDisk: true,
+		VMLimitCPU: true, VMLimitMemory: true, VMLimitDisk: true,
+		ContainerCPURatio: 4,
+		NodeCPUCores:      8, NodeMemoryTotal: 65536, NodeDiskTotal: 1024000,
+	}
+	if err := global.APP_DB.Create(&dbProvider).Error; err != nil {
+		t.Fatalf("创建Provider记录失败: %v", err)
+	}
+
+	prov, err := provider.GetProvider("mock")
+	if err != nil {
+		t.Fatalf("获取mock Provider失败: %v", err)
+	}
+	if err := prov.Connect(ctx, provider.NodeConfig{ID: dbProvider.ID, Name: dbProvider.Name, Host: dbProvider.Endpoint}); err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+
+	s := &ResourceService{}
+	create := func(name, instanceType string, cpu int) {
+		t.Helper()
+		if err := prov.CreateInstance(ctx, provider.InstanceConfig{Name: name, Image: "debian12", InstanceType: instanceType, CPU: "1", Memory: "1024", Disk: "10240"}); err != nil {
+			t.Fatalf("创建实例%s失败: %v", name, err)
+		}
+		instance := providerModel.Instance{Name: name, ProviderID: dbProvider.ID, UserID: 1, InstanceType: instanceType, Status: "running", CPU: cpu, Memory: 1024, Disk: 10240}
+		if err := global.APP_DB.Create(&instance).Error; err != nil {
+			t.Fatalf("创建实例记录失败: %v", err)
+		}
+		if err := s.AllocateResources(dbProvider.ID, instanceType, cpu, 1024, 10240); err != nil {
+			t.Fatalf("分配实例%s资源失败: %v", name, err)
+		}
+	}
+	create("ct1", "container", 20)
+	create("vm1", "vm", 2)
+
+	check := func(instanceType string, cpu int) bool {
+		t.Helper()
+		result, err := s.CheckProviderResources(resource.ResourceCheckRequest{ProviderID: dbProvider.ID, InstanceType: instanceType, CPU: cpu})
+		if err != nil {
+			t.Fatalf("检查资源失败: %v", err)
+		}
+		return result.Allowed
+	}
+	assertCapacity := func(stage string) {
+		t.Helper()
+		// 20核容器折算为5个物理核，加上2核虚拟机后物理上只剩1核
+		if !check("container", 4) || check("container", 5) {
+			t.Errorf("%s: 容器应按4倍超分配使用剩余的1个物理核", stage)
+		}
+		if !check("vm", 1) || check("vm", 2) {
+			t.Errorf("%s: 虚拟机应只能使用剩余的1个物理核", stage)
+		}
+	}
+	assertCapacity("分配后")
+
+	// 模拟升级前只有共享用量的数据，启动时的同步会按实例记录回填各类型用量
+	if err := global.APP_DB.Model(&providerModel.Provider{}).Where("id = ?", dbProvider.ID).Updates(map[string]interface{}{
+		"container_used_cpu_cores": 0,
+		"vm_used_cpu_cores":        0,
+	}).Error; err != nil {
+		t.Fatalf("清空按类型用量失败: %v", err)
+	}
+	if err := s.SyncProviderResources(dbProvider.ID); err != nil {
+		t.Fatalf("同步节点资源失败: %v", err)
+	}
+	assertCapacity("同步后")
+
+	if err := s.ReleaseResources(dbProvider.ID, "container", 20, 1024, 10240); err != nil {
+		t.Fatalf("释放容器资源失败: %v", err)
+	}
+	global.APP_DB.First(&dbProvider, dbProvider.ID)
+	if dbProvider.ContainerUsedCPUCores != 0 || dbProvider.VMUsedCPUCores != 2 || dbProvider.ContainerCount != 0 {
+		t.Errorf("释放后用量不正确: container=%d vm=%d count=%d", dbProvider.ContainerUsedCPUCores, dbProvider.VMUsedCPUCores, dbProvider.ContainerCount)
+	}
+}
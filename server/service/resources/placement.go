@@ -0,0 +1,340 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"oneclickvirt/config"
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/model/resource"
+	"oneclickvirt/service/auth"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// PlacementStrategySpread 分散策略：优先选择空闲资源最多的节点
+	PlacementStrategySpread = "spread"
+	// PlacementStrategyPack 集中策略：优先填满已有负载的节点，便于空出整台节点
+	PlacementStrategyPack = "pack"
+)
+
+// defaultPlacementConfig 未配置或权重全为0时使用的默认调度配置
+var defaultPlacementConfig = config.Placement{
+	Strategy:     PlacementStrategySpread,
+	CPUWeight:    1,
+	MemoryWeight: 2,
+	DiskWeight:   1,
+	QueueWeight:  1,
+	HealthWeight: 1,
+}
+
+// PlacementService 自动选择节点服务
+type PlacementService struct{}
+
+// NewPlacementService 创建自动选择节点服务
+func NewPlacementService() *PlacementService {
+	return &PlacementService{}
+}
+
+// RankProviders 按调度策略为请求的实例规格给可申领的节点打分
+// 只返回查询错误，没有可用节点时Selected为nil，Excluded中记录每个节点被排除的原因
+func (s *PlacementService) RankProviders(userID uint, req resource.PlacementRequest) (*resource.PlacementResult, error) {
+	cfg := effectivePlacementConfig(global.APP_CONFIG.Placement)
+
+	var flavor *resource.Flavor
+	if req.FlavorID > 0 {
+		var err error
+		if flavor, err = NewFlavorService().ResolveFlavor(global.APP_DB, req.FlavorID, 0, req.InstanceType); err != nil {
+			return nil, err
+		}
+		req.CPU, req.Memory, req.Disk = flavor.CPU, flavor.Memory, flavor.Disk
+	}
+
+	result := &resource.PlacementResult{
+		Strategy:   cfg.Strategy,
+		Candidates: []resource.PlacementCandidate{},
+		Excluded:   map[uint]string{},
+	}
+
+	// 管理员不受节点等级限制
+	userLevel := 0
+	if userID > 0 {
+		permissionService := auth.PermissionService{}
+		effective, err := permissionService.GetUserEffectivePermission(userID)
+		if err != nil {
+			return nil, fmt.Errorf("获取用户权限失败: %v", err)
+		}
+		if effective.EffectiveType != "admin" {
+			userLevel = effective.EffectiveLevel
+		}
+	}
+
+	var providers []providerModel.Provider
+	if err := global.APP_DB.Where("(status = ? OR status = ?) AND allow_claim = ? AND is_frozen = ? AND maintenance_mode = ?",
+		"active", "partial", true, false, false).
+		Find(&providers).Error; err != nil {
+		return nil, fmt.Errorf("查询节点失败: %v", err)
+	}
+	if len(providers) == 0 {
+		return result, nil
+	}
+
+	queueLengths, err := providerQueueLengths(providers)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceService := &ResourceService{}
+	now := time.Now()
+	for i := range providers {
+		p := &providers[i]
+		if flavor != nil && !flavor.AllowsProvider(p.ID) {
+			result.Excluded[p.ID] = "节点不提供该实例规格"
+			continue
+		}
+		if reason := placementFilterReason(p, req, userLevel, now); reason != "" {
+			result.Excluded[p.ID] = reason
+			continue
+		}
+		check := resourceService.CheckProviderAvailability(p, resource.ResourceCheckRequest{
+			ProviderID:   p.ID,
+			InstanceType: req.InstanceType,
+			CPU:          req.CPU,
+			Memory:       req.Memory,
+			Disk:         req.Disk,
+		})
+		if !check.Allowed {
+			result.Excluded[p.ID] = check.Reason
+			continue
+		}
+		result.Candidates = append(result.Candidates, scorePlacementCandidate(p, req, queueLengths[p.ID], cfg))
+	}
+
+	sort.SliceStable(result.Candidates, func(i, j int) bool {
+		if result.Candidates[i].Score != result.Candidates[j].Score {
+			return result.Candidates[i].Score > result.Candidates[j].Score
+		}
+		return result.Candidates[i].QueueLength < result.Candidates[j].QueueLength
+	})
+	if len(result.Candidates) > 0 {
+		selected := result.Candidates[0]
+		result.Selected = &selected
+	}
+
+	global.APP_LOG.Debug("自动选择节点完成",
+		zap.Uint("userID", userID),
+		zap.String("instanceType", req.InstanceType),
+		zap.String("strategy", cfg.Strategy),
+		zap.Int("candidates", len(result.Candidates)),
+		zap.Int("excluded", len(result.Excluded)))
+
+	return result, nil
+}
+
+// SelectProvider 自动选择得分最高的节点，没有满足条件的节点时返回错误
+func (s *PlacementService) SelectProvider(userID uint, req resource.PlacementRequest) (uint, error) {
+	result, err := s.RankProviders(userID, req)
+	if err != nil {
+		return 0, err
+	}
+	if result.Selected == nil {
+		return 0, fmt.Errorf("没有满足条件的可用节点")
+	}
+	return result.Selected.ProviderID, nil
+}
+
+// effectivePlacementConfig 补全调度配置，策略无效时使用spread，权重全为0时使用默认权重
+func effectivePlacementConfig(cfg config.Placement) config.Placement {
+	if cfg.Strategy != PlacementStrategySpread && cfg.Strategy != PlacementStrategyPack {
+		cfg.Strategy = PlacementStrategySpread
+	}
+	if cfg.CPUWeight <= 0 && cfg.MemoryWeight <= 0 && cfg.DiskWeight <= 0 && cfg.QueueWeight <= 0 && cfg.HealthWeight <= 0 {
+		strategy := cfg.Strategy
+		cfg = defaultPlacementConfig
+		cfg.Strategy = strategy
+	}
+	return cfg
+}
+
+// providerQueueLengths 批量统计节点上排队和执行中的任务数
+func providerQueueLengths(providers []providerModel.Provider) (map[uint]int64, error) {
+	ids := make([]uint, 0, len(providers))
+	for _, p := range providers {
+		ids = append(ids, p.ID)
+	}
+	var rows []struct {
+		ProviderID uint
+		Count      int64
+	}
+	if err := global.APP_DB.Model(&adminModel.Task{}).
+		Select("provider_id, COUNT(*) as count").
+		Where("provider_id IN ? AND status IN ?", ids, []string{"pending", "processing", "running"}).
+		Group("provider_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("统计节点任务队列失败: %v", err)
+	}
+	lengths := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		lengths[row.ProviderID] = row.Count
+	}
+	return lengths, nil
+}
+
+// placementFilterReason 检查节点是否满足筛选条件和节点等级限制，满足时返回空字符串
+func placementFilterReason(p *providerModel.Provider, req resource.PlacementRequest, userLevel int, now time.Time) string {
+	if p.ExpiresAt != nil && p.ExpiresAt.Before(now) {
+		return "节点已过期"
+	}
+	if p.TrafficLimited {
+		return "节点流量超限"
+	}
+	if req.Region != "" && !strings.EqualFold(p.Region, req.Region) {
+		return "地区不匹配"
+	}
+	if req.Country != "" && !strings.EqualFold(p.Country, req.Country) && !strings.EqualFold(p.CountryCode, req.Country) {
+		return "国家不匹配"
+	}
+	if req.Architecture != "" && !strings.EqualFold(p.Architecture, req.Architecture) {
+		return "CPU架构不匹配"
+	}
+	if channel, status := placementRequiredChannel(p); status == "offline" {
+		return fmt.Sprintf("节点%s连接离线", channel)
+	}
+	if userLevel > 0 {
+		limits := providerLevelMaxResources(p, userLevel)
+		if max, ok := limits["cpu"]; ok && max > 0 && float64(req.CPU) > max {
+			return fmt.Sprintf("超过节点等级限制：CPU最多 %.0f 核", max)
+		}
+		if max, ok := limits["memory"]; ok && max > 0 && float64(req.Memory) > max {
+			return fmt.Sprintf("超过节点等级限制：内存最多 %.0f MB", max)
+		}
+		if max, ok := limits["disk"]; ok && max > 0 && float64(req.Disk) > max {
+			return fmt.Sprintf("超过节点等级限制：磁盘最多 %.0f MB", max)
+		}
+	}
+	return ""
+}
+
+// providerLevelMaxResources 解析节点对指定用户等级配置的max-resources，未配置时返回nil
+func providerLevelMaxResources(p *providerModel.Provider, level int) map[string]float64 {
+	if p.LevelLimits == "" {
+		return nil
+	}
+	var allLimits map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(p.LevelLimits), &allLimits); err != nil {
+		return nil
+	}
+	levelLimits, ok := allLimits[fmt.Sprintf("%d", level)]
+	if !ok {
+		return nil
+	}
+	maxResources, ok := levelLimits["max-resources"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	limits := make(map[string]float64, len(maxResources))
+	for key, value := range maxResources {
+		switch v := value.(type) {
+		case float64:
+			limits[key] = v
+		case int:
+			limits[key] = float64(v)
+		}
+	}
+	return limits
+}
+
+// scorePlacementCandidate 计算节点得分，各项得分在0-1之间，综合得分按权重加权后换算为0-100
+// 资源得分为放入实例后剩余可分配量占超分配后容量的比例，pack策略下取反，使负载更高的节点得分更高，容量未知时均为0
+// 剩余可分配量按实时占用计算而不读取Available*字段：后者只在资源同步时写入，分配和释放后不会更新，
+// 且取两种实例类型中较大者，无法反映所请求实例类型的超分配比例
+func scorePlacementCandidate(p *providerModel.Provider, req resource.PlacementRequest, queueLength int64, cfg config.Placement) resource.PlacementCandidate {
+	capacityCPU, capacityMemory, capacityDisk := p.EffectiveCapacity(req.InstanceType)
+	availableCPU, availableMemory, availableDisk := p.AvailableResources(req.InstanceType)
+	ratio := freeRatio
+	if cfg.Strategy == PlacementStrategyPack {
+		ratio = packRatio
+	}
+	cpuFree := ratio(float64(availableCPU-req.CPU), float64(capacityCPU))
+	memoryFree := ratio(float64(availableMemory-req.Memory), float64(capacityMemory))
+	diskFree := ratio(float64(availableDisk-req.Disk), float64(capacityDisk))
+
+	candidate := resource.PlacementCandidate{
+		ProviderID:   p.ID,
+		ProviderName: p.Name,
+		Region:       p.Region,
+		Country:      p.Country,
+		CPUScore:     cpuFree,
+		MemoryScore:  memoryFree,
+		DiskScore:    diskFree,
+		QueueScore:   1 / float64(1+queueLength),
+		HealthScore:  placementHealthScore(p),
+		QueueLength:  queueLength,
+	}
+
+	totalWeight := cfg.CPUWeight + cfg.MemoryWeight + cfg.DiskWeight + cfg.QueueWeight + cfg.HealthWeight
+	if totalWeight > 0 {
+		weighted := cfg.CPUWeight*candidate.CPUScore +
+			cfg.MemoryWeight*candidate.MemoryScore +
+			cfg.DiskWeight*candidate.DiskScore +
+			cfg.QueueWeight*candidate.QueueScore +
+			cfg.HealthWeight*candidate.HealthScore
+		candidate.Score = weighted / totalWeight * 100
+	}
+	return candidate
+}
+
+// packRatio pack策略下的资源得分，即放入实例后的占用比例，总量未知或为0时返回0，避免未上报容量的节点得分最高
+func packRatio(free, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return 1 - freeRatio(free, total)
+}
+
+// placementRequiredChannel 返回节点执行规则下创建实例必需的连接通道及其状态
+// api_only只使用API；ssh_only和auto下镜像导入、密码和SSH公钥配置都要通过SSH执行
+func placementRequiredChannel(p *providerModel.Provider) (channel, status string) {
+	if p.ExecutionRule == "api_only" {
+		return "API", p.APIStatus
+	}
+	return "SSH", p.SSHStatus
+}
+
+// placementHealthScore 按创建实例会用到的连接通道计算健康得分，取各通道得分的平均值
+// 在线为1，未检查为0.5，离线为0；auto规则下API作为备用通道也计入，未配置API（N/A）时不计入
+func placementHealthScore(p *providerModel.Provider) float64 {
+	_, required := placementRequiredChannel(p)
+	statuses := []string{required}
+	if p.ExecutionRule != "api_only" && p.ExecutionRule != "ssh_only" && p.APIStatus != "N/A" {
+		statuses = append(statuses, p.APIStatus)
+	}
+	total := 0.0
+	for _, status := range statuses {
+		switch status {
+		case "online":
+			total += 1
+		case "offline":
+		default:
+			total += 0.5
+		}
+	}
+	return total / float64(len(statuses))
+}
+
+// freeRatio 计算剩余比例并限制在0-1之间，总量未知时返回0
+func freeRatio(free, total float64) float64 {
+	if total <= 0 || free <= 0 {
+		return 0
+	}
+	if free >= total {
+		return 1
+	}
+	return free / total
+}
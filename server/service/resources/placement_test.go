@@ -0,0 +1,165 @@
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"oneclickvirt/config"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/model/resource"
+)
+
+func TestScorePlacementCandidateStrategy(t *testing.T) {
+	idle := &providerModel.Provider{Name: "idle", Status: "active", NodeCPUCores: 16, ContainerUsedCPUCores: 2, NodeMemoryTotal: 32768, ContainerUsedMemory: 2768, NodeDiskTotal: 500000, ContainerUsedDisk: 50000}
+	busy := &providerModel.Provider{Name: "busy", Status: "active", NodeCPUCores: 16, ContainerUsedCPUCores: 12, NodeMemoryTotal: 32768, ContainerUsedMemory: 26768, NodeDiskTotal: 500000, ContainerUsedDisk: 400000}
+	req := resource.PlacementRequest{InstanceType: "container", CPU: 1, Memory: 1024, Disk: 10240}
+
+	spread := effectivePlacementConfig(config.Placement{Strategy: PlacementStrategySpread})
+	if a, b := scorePlacementCandidate(idle, req, 0, spread), scorePlacementCandidate(busy, req, 0, spread); a.Score <= b.Score {
+		t.Fatalf("spread should prefer the idle node, got idle=%.2f busy=%.2f", a.Score, b.Score)
+	}
+
+	pack := effectivePlacementConfig(config.Placement{Strategy: PlacementStrategyPack})
+	if a, b := scorePlacementCandidate(idle, req, 0, pack), scorePlacementCandidate(busy, req, 0, pack); a.Score >= b.Score {
+		t.Fatalf("pack should prefer the busy node, got idle=%.2f busy=%.2f", a.Score, b.Score)
+	}
+
+	unknown := &providerModel.Provider{Name: "unknown", Status: "active"}
+	if a, b := scorePlacementCandidate(unknown, req, 0, pack), scorePlacementCandidate(busy, req, 0, pack); a.Score >= b.Score {
+		t.Fatalf("pack should not prefer a node without reported capacity, got unknown=%.2f busy=%.2f", a.Score, b.Score)
+	}
+}
+
+func TestScorePlacementCandidateQueueAndHealth(t *testing.T) {
+	node := &providerModel.Provider{Status: "active", SSHStatus: "online", APIStatus: "online", NodeCPUCores: 8, NodeMemoryTotal: 8192, NodeDiskTotal: 100000}
+	req := resource.PlacementRequest{InstanceType: "vm", CPU: 1, Memory: 512, Disk: 1024}
+	cfg := effectivePlacementConfig(config.Placement{})
+
+	if idle, queued := scorePlacementCandidate(node, req, 0, cfg), scorePlacementCandidate(node, req, 5, cfg); idle.Score <= queued.Score {
+		t.Fatalf("a longer task queue should lower the score, got %.2f vs %.2f", idle.Score, queued.Score)
+	}
+
+	partial := *node
+	partial.Status, partial.APIStatus = "partial", "offline"
+	if a, b := scorePlacementCandidate(node, req, 0, cfg), scorePlacementCandidate(&partial, req, 0, cfg); a.Score <= b.Score {
+		t.Fatalf("a node with the API fallback offline should score lower, got %.2f vs %.2f", a.Score, b.Score)
+	}
+	partial.ExecutionRule = "ssh_only"
+	if score := scorePlacementCandidate(&partial, req, 0, cfg).HealthScore; score != 1 {
+		t.Fatalf("ssh_only nodes should not be penalized for the unused API, got %.2f", score)
+	}
+}
+
+func TestPlacementFilterReason(t *testing.T) {
+	now := time.Now()
+	expired := now.Add(-time.Hour)
+	p := &providerModel.Provider{
+		Region:       "Asia",
+		Country:      "Japan",
+		CountryCode:  "JP",
+		Architecture: "amd64",
+		LevelLimits:  `{"1":{"max-resources":{"cpu":2,"memory":2048,"disk":20480}}}`,
+	}
+	req := resource.PlacementRequest{InstanceType: "container", Country: "jp", Architecture: "AMD64", CPU: 2, Memory: 1024, Disk: 10240}
+
+	if reason := placementFilterReason(p, req, 1, now); reason != "" {
+		t.Fatalf("expected node to match, got %q", reason)
+	}
+	if reason := placementFilterReason(p, resource.PlacementRequest{Region: "Europe"}, 0, now); reason == "" {
+		t.Fatal("expected region mismatch to exclude the node")
+	}
+	over := req
+	over.CPU = 4
+	if reason := placementFilterReason(p, over, 1, now); reason == "" {
+		t.Fatal("expected node level limit to exclude the node")
+	}
+	if reason := placementFilterReason(p, over, 2, now); reason != "" {
+		t.Fatalf("levels without node limits should not be restricted, got %q", reason)
+	}
+	p.SSHStatus, p.APIStatus = "offline", "online"
+	if reason := placementFilterReason(p, req, 1, now); reason == "" {
+		t.Fatal("expected node with SSH offline to be excluded")
+	}
+	p.ExecutionRule = "api_only"
+	if reason := placementFilterReason(p, req, 1, now); reason != "" {
+		t.Fatalf("api_only nodes only need the API channel, got %q", reason)
+	}
+	p.APIStatus = "offline"
+	if reason := placementFilterReason(p, req, 1, now); reason == "" {
+		t.Fatal("expected api_only node with API offline to be excluded")
+	}
+	p.ExpiresAt = &expired
+	if reason := placementFilterReason(p, req, 1, now); reason == "" {
+		t.Fatal("expected expired node to be excluded")
+	}
+}
+
+func TestCheckProviderAvailabilityOvercommit(t *testing.T) {
+	p := &providerModel.Provider{
+		ContainerEnabled:      true,
+		ContainerLimitCPU:     true,
+		ContainerCPURatio:     4,
+		VMLimitCPU:            true,
+		NodeCPUCores:          8,
+		UsedCPUCores:          20,
+		ContainerUsedCPUCores: 20,
+	}
+	s := &ResourceService{}
+
+	if result := s.CheckProviderAvailability(p, resource.ResourceCheckRequest{InstanceType: "container", CPU: 12}); !result.Allowed {
+		t.Fatalf("containers should fit within 4x CPU overcommit, got %q", result.Reason)
+	}
+	if result := s.CheckProviderAvailability(p, resource.ResourceCheckRequest{InstanceType: "container", CPU: 13}); result.Allowed {
+		t.Fatal("containers should not exceed 4x CPU overcommit")
+	}
+
+	// 20核容器折算为5个物理核，再加1核虚拟机后物理上只剩2核
+	p.VirtualMachineEnabled = true
+	p.UsedCPUCores, p.VMUsedCPUCores = 21, 1
+	if result := s.CheckProviderAvailability(p, resource.ResourceCheckRequest{InstanceType: "vm", CPU: 2}); !result.Allowed {
+		t.Fatalf("VMs should fit in the remaining physical cores, got %q", result.Reason)
+	}
+	if result := s.CheckProviderAvailability(p, resource.ResourceCheckRequest{InstanceType: "vm", CPU: 3}); result.Allowed {
+		t.Fatal("VMs should not exceed the physical cores left by containers")
+	}
+	if result := s.CheckProviderAvailability(p, resource.ResourceCheckRequest{InstanceType: "container", CPU: 8}); !result.Allowed {
+		t.Fatalf("containers should fit in the remaining physical cores at 4x, got %q", result.Reason)
+	}
+	if result := s.CheckProviderAvailability(p, resource.ResourceCheckRequest{InstanceType: "container", CPU: 9}); result.Allowed {
+		t.Fatal("containers should not exceed the physical cores left by VMs")
+	}
+}
+
+func TestPlacementMixedNodePhysicalCapacity(t *testing.T) {
+	// 虚拟机按1.0、容器按1.5超分配内存，各自预算未满但合计不能超过物理内存
+	p := &providerModel.Provider{
+		Name:                  "mixed",
+		Status:                "active",
+		ContainerEnabled:      true,
+		VirtualMachineEnabled: true,
+		ContainerLimitMemory:  true,
+		VMLimitMemory:         true,
+		ContainerMemoryRatio:  1.5,
+		NodeMemoryTotal:       16384,
+		UsedMemory:            8192,
+		VMUsedMemory:          8192,
+	}
+	s := &ResourceService{}
+
+	if result := s.CheckProviderAvailability(p, resource.ResourceCheckRequest{InstanceType: "container", Memory: 12288}); !result.Allowed {
+		t.Fatalf("containers should fit in the remaining 8GB physical memory at 1.5x, got %q", result.Reason)
+	}
+	if result := s.CheckProviderAvailability(p, resource.ResourceCheckRequest{InstanceType: "container", Memory: 12289}); result.Allowed {
+		t.Fatal("containers should not push combined usage above physical memory")
+	}
+
+	p.UsedMemory, p.VMUsedMemory = 16384, 16384
+	if result := s.CheckProviderAvailability(p, resource.ResourceCheckRequest{InstanceType: "container", Memory: 1024}); result.Allowed {
+		t.Fatal("containers should not be placed on a node whose memory is fully used by VMs")
+	}
+	cfg := config.Placement{MemoryWeight: 1}
+	req := resource.PlacementRequest{InstanceType: "container", Memory: 1024}
+	if candidate := scorePlacementCandidate(p, req, 0, cfg); candidate.MemoryScore != 0 {
+		t.Errorf("memory score should reflect the exhausted physical memory, got %v", candidate.MemoryScore)
+	}
+}
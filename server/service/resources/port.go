@@ -90,9 +90,9 @@ func (s *PortMappingService) CreatePortMappingWithTask(req admin.CreatePortMappi
 		return 0, nil, fmt.Errorf("Provider不存在")
 	}
 
-	// 只支持 LXD/Incus/Proxmox 手动添加端口
-	if providerInfo.Type != "lxd" && providerInfo.Type != "incus" && providerInfo.Type != "proxmox" {
-		return 0, nil, fmt.Errorf("不支持的 Provider 类型，手动添加端口仅支持 LXD/Incus/Proxmox")
+	// 只支持 LXD/Incus/Proxmox/libvirt/Podman 手动添加端口，Podman通过保留数据重建容器生效
+	if providerInfo.Type != "lxd" && providerInfo.Type != "incus" && providerInfo.Type != "proxmox" && providerInfo.Type != "libvirt" && providerInfo.Type != "podman" {
+		return 0, nil, fmt.Errorf("不支持的 Provider 类型，手动添加端口仅支持 LXD/Incus/Proxmox/libvirt/Podman")
 	}
 
 	// 检查是否为独立IPv4模式或纯IPv6模式
@@ -596,6 +596,15 @@ func (s *PortMappingService) allocateHostPort(providerID uint, rangeStart, range
 	return allocatedPort, nil
 }
 
+// AllocateHostPort 在Provider配置的端口范围内分配一个未被占用的宿主机端口
+func (s *PortMappingService) AllocateHostPort(providerID uint) (int, error) {
+	var providerInfo provider.Provider
+	if err := global.APP_DB.Where("id = ?", providerID).First(&providerInfo).Error; err != nil {
+		return 0, fmt.Errorf("Provider不存在: %v", err)
+	}
+	return s.allocateHostPort(providerID, providerInfo.PortRangeStart, providerInfo.PortRangeEnd)
+}
+
 // allocateHostPortWithRetry 带重试的端口分配（内部辅助函数）
 func (s *PortMappingService) allocateHostPortWithRetry(providerID uint, rangeStart, rangeEnd int, retryCount int) (int, error) {
 	const maxRetries = 3
@@ -0,0 +1,19 @@
+package resources
+
+import (
+	"oneclickvirt/model/provider"
+
+	"gorm.io/gorm"
+)
+
+// InstanceHasPrivateNetworks 实例是否接入了私有网络，私有网络只存在于单个节点上，迁移前需要先断开
+func InstanceHasPrivateNetworks(db *gorm.DB, instanceID uint) (bool, error) {
+	var count int64
+	err := db.Model(&provider.PrivateNetworkAttachment{}).Where("instance_id = ?", instanceID).Count(&count).Error
+	return count > 0, err
+}
+
+// DetachInstancePrivateNetworksInTx 实例删除时在事务中删除其私有网络接入记录，网卡随实例一起删除
+func DetachInstancePrivateNetworksInTx(tx *gorm.DB, instanceID uint) error {
+	return tx.Where("instance_id = ?", instanceID).Delete(&provider.PrivateNetworkAttachment{}).Error
+}
@@ -37,6 +37,10 @@ type ResourceRequest struct {
 	Bandwidth    int // 带宽字段
 	InstanceType string
 	ProviderID   uint //  Provider ID 用于节点级限制检查
+	// ExcludeInstanceID 调整实例配置时排除该实例自身的占用，请求的资源即为调整后的配置
+	ExcludeInstanceID uint
+	// FlavorID 选择的实例规格，请求的资源必须与规格一致
+	FlavorID uint
 }
 
 // QuotaCheckResult 配额检查结果
@@ -118,6 +122,20 @@ func (s *QuotaService) validateInTransaction(tx *gorm.DB, req ResourceRequest) (
 		}, nil
 	}
 
+	// 选择了实例规格时，规格必须可用于该节点和实例类型，且请求的资源与规格一致
+	if req.FlavorID > 0 {
+		flavor, err := NewFlavorService().ResolveFlavor(tx, req.FlavorID, req.ProviderID, req.InstanceType)
+		if err == nil {
+			err = CheckFlavorMatches(flavor, req.CPU, req.Memory, req.Disk, req.Bandwidth)
+		}
+		if err != nil {
+			return &QuotaCheckResult{
+				Allowed: false,
+				Reason:  err.Error(),
+			}, nil
+		}
+	}
+
 	// 获取用户等级限制
 	levelLimits, exists := global.APP_CONFIG.Quota.LevelLimits[user.Level]
 	if !exists {
@@ -165,6 +183,23 @@ func (s *QuotaService) validateInTransaction(tx *gorm.DB, req ResourceRequest) (
 		}
 	}
 
+	// 调整已有实例配置时，从当前占用中扣除该实例，避免重复计算
+	if req.ExcludeInstanceID > 0 {
+		var excluded provider.Instance
+		if err := tx.Where("id = ? AND user_id = ? AND status NOT IN (?)",
+			req.ExcludeInstanceID, req.UserID, []string{"deleting", "deleted", "failed"}).
+			First(&excluded).Error; err == nil {
+			currentInstances--
+			currentResources.CPU -= excluded.CPU
+			currentResources.Memory -= excluded.Memory
+			currentResources.Disk -= excluded.Disk
+			currentResources.Bandwidth -= excluded.Bandwidth
+			if req.ProviderID > 0 && excluded.ProviderID == req.ProviderID {
+				currentProviderInstances--
+			}
+		}
+	}
+
 	// 计算请求的资源
 	requestedResources := ResourceUsage{
 		CPU:       req.CPU,
@@ -203,16 +238,13 @@ func (s *QuotaService) validateInTransaction(tx *gorm.DB, req ResourceRequest) (
 		}
 	}
 
-	// 2. 检查CPU限制（考虑超分配设置）
-	shouldCheckCPU := true
-	if req.ProviderID > 0 && prov != nil {
-		switch req.InstanceType {
-		case "container":
-			shouldCheckCPU = prov.ContainerLimitCPU
-		case "vm":
-			shouldCheckCPU = prov.VMLimitCPU
-		}
+	// 节点上不计入总量预算的资源允许超分配，不检查对应的用户配额
+	shouldCheckCPU, shouldCheckMemory, shouldCheckDisk := true, true, true
+	if req.ProviderID > 0 && prov != nil && (req.InstanceType == "container" || req.InstanceType == "vm") {
+		shouldCheckCPU, shouldCheckMemory, shouldCheckDisk = prov.ResourceLimits(req.InstanceType)
 	}
+
+	// 2. 检查CPU限制（考虑超分配设置）
 	if shouldCheckCPU && currentResources.CPU+requestedResources.CPU > maxResources.CPU {
 		result.Allowed = false
 		result.Reason = fmt.Sprintf("CPU资源不足：需要 %d，当前使用 %d，最大允许 %d",
@@ -221,15 +253,6 @@ func (s *QuotaService) validateInTransaction(tx *gorm.DB, req ResourceRequest) (
 	}
 
 	// 3. 检查内存限制（考虑超分配设置）
-	shouldCheckMemory := true
-	if req.ProviderID > 0 && prov != nil {
-		switch req.InstanceType {
-		case "container":
-			shouldCheckMemory = prov.ContainerLimitMemory
-		case "vm":
-			shouldCheckMemory = prov.VMLimitMemory
-		}
-	}
 	if shouldCheckMemory && currentResources.Memory+requestedResources.Memory > maxResources.Memory {
 		result.Allowed = false
 		result.Reason = fmt.Sprintf("内存资源不足：需要 %dMB，当前使用 %dMB，最大允许 %dMB",
@@ -238,15 +261,6 @@ func (s *QuotaService) validateInTransaction(tx *gorm.DB, req ResourceRequest) (
 	}
 
 	// 4. 检查磁盘限制（考虑超分配设置）
-	shouldCheckDisk := true
-	if req.ProviderID > 0 && prov != nil {
-		switch req.InstanceType {
-		case "container":
-			shouldCheckDisk = prov.ContainerLimitDisk
-		case "vm":
-			shouldCheckDisk = prov.VMLimitDisk
-		}
-	}
 	if shouldCheckDisk && currentResources.Disk+requestedResources.Disk > maxResources.Disk {
 		result.Allowed = false
 		result.Reason = fmt.Sprintf("磁盘资源不足：需要 %dMB，当前使用 %dMB，最大允许 %dMB",
@@ -303,6 +317,13 @@ func (s *QuotaService) getCurrentResourceUsage(tx *gorm.DB, userID uint) (int, R
 		totalResources.Bandwidth += instance.Bandwidth
 	}
 
+	// 数据卷容量同样计入磁盘占用
+	volumeDisk, err := s.getVolumeDiskUsage(tx, userID)
+	if err != nil {
+		return 0, ResourceUsage{}, err
+	}
+	totalResources.Disk += volumeDisk
+
 	return instanceCount, totalResources, nil
 }
 
@@ -475,6 +496,84 @@ func (s *QuotaService) ValidateAdminInstanceCreation(req ResourceRequest) (*Quot
 	return s.ValidateInstanceCreation(req)
 }
 
+// ValidateSnapshotCreationInTx 在事务中验证实例快照数量是否超过用户等级限制
+func (s *QuotaService) ValidateSnapshotCreationInTx(tx *gorm.DB, userID uint, instanceID uint) (*QuotaCheckResult, error) {
+	var user user.User
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("用户不存在: %v", err)
+	}
+
+	levelLimits, exists := global.APP_CONFIG.Quota.LevelLimits[user.Level]
+	if !exists {
+		return &QuotaCheckResult{
+			Allowed: false,
+			Reason:  fmt.Sprintf("用户等级 %d 没有配置资源限制", user.Level),
+		}, nil
+	}
+
+	if levelLimits.MaxSnapshots <= 0 {
+		return &QuotaCheckResult{
+			Allowed: false,
+			Reason:  fmt.Sprintf("用户等级 %d 不允许创建快照", user.Level),
+		}, nil
+	}
+
+	// 统计该实例现有快照（失败的快照不计入）
+	var count int64
+	if err := tx.Model(&provider.InstanceSnapshot{}).
+		Where("instance_id = ? AND status != ?", instanceID, "failed").
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询快照数量失败: %v", err)
+	}
+
+	if int(count) >= levelLimits.MaxSnapshots {
+		return &QuotaCheckResult{
+			Allowed: false,
+			Reason:  fmt.Sprintf("实例快照数量已达上限 %d", levelLimits.MaxSnapshots),
+		}, nil
+	}
+
+	return &QuotaCheckResult{
+		Allowed: true,
+		Reason:  "快照配额验证通过",
+	}, nil
+}
+
+// ValidateFirewallRulesInTx 在事务中验证实例防火墙规则数量是否超过用户等级限制，清空规则总是允许
+func (s *QuotaService) ValidateFirewallRulesInTx(tx *gorm.DB, userID uint, ruleCount int) (*QuotaCheckResult, error) {
+	var user user.User
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("用户不存在: %v", err)
+	}
+
+	levelLimits, exists := global.APP_CONFIG.Quota.LevelLimits[user.Level]
+	if !exists {
+		return &QuotaCheckResult{
+			Allowed: false,
+			Reason:  fmt.Sprintf("用户等级 %d 没有配置资源限制", user.Level),
+		}, nil
+	}
+
+	if ruleCount > 0 && levelLimits.MaxFirewallRules <= 0 {
+		return &QuotaCheckResult{
+			Allowed: false,
+			Reason:  fmt.Sprintf("用户等级 %d 不允许使用防火墙", user.Level),
+		}, nil
+	}
+
+	if ruleCount > levelLimits.MaxFirewallRules {
+		return &QuotaCheckResult{
+			Allowed: false,
+			Reason:  fmt.Sprintf("实例防火墙规则数量超过上限 %d", levelLimits.MaxFirewallRules),
+		}, nil
+	}
+
+	return &QuotaCheckResult{
+		Allowed: true,
+		Reason:  "防火墙规则配额验证通过",
+	}, nil
+}
+
 // RecalculateUserQuota 重新计算用户配额
 // 由于系统会重新初始化数据库，这个功能主要用于运行时的配额同步
 func (s *QuotaService) RecalculateUserQuota(userID uint) error {
@@ -651,32 +750,17 @@ func (s *QuotaService) mergeLevelLimitsWithOvercommit(userLimits, providerLimits
 	}
 
 	// 根据实例类型和超分配设置合并资源限制
+	limited := map[string]bool{"cpu": true, "memory": true, "disk": true}
+	if instanceType == "container" || instanceType == "vm" {
+		limited["cpu"], limited["memory"], limited["disk"] = prov.ResourceLimits(instanceType)
+	}
 	resourceKeys := []string{"cpu", "memory", "disk", "bandwidth"}
 	for _, key := range resourceKeys {
 		userVal := s.getResourceValue(userLimits.MaxResources, key)
 		providerVal := s.getResourceValue(providerLimits.MaxResources, key)
 
-		// 检查该资源是否允许超分配
-		allowOvercommit := false
-		if instanceType == "container" {
-			switch key {
-			case "cpu":
-				allowOvercommit = !prov.ContainerLimitCPU
-			case "memory":
-				allowOvercommit = !prov.ContainerLimitMemory
-			case "disk":
-				allowOvercommit = !prov.ContainerLimitDisk
-			}
-		} else if instanceType == "vm" {
-			switch key {
-			case "cpu":
-				allowOvercommit = !prov.VMLimitCPU
-			case "memory":
-				allowOvercommit = !prov.VMLimitMemory
-			case "disk":
-				allowOvercommit = !prov.VMLimitDisk
-			}
-		}
+		// 检查该资源是否允许超分配（带宽不参与超分配）
+		allowOvercommit := key != "bandwidth" && !limited[key]
 
 		// 如果允许超分配，只使用用户限制，忽略 Provider 限制
 		if allowOvercommit {
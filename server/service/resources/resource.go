@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
 	dashboardModel "oneclickvirt/model/dashboard"
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/model/resource"
@@ -134,11 +135,11 @@ func (s *ResourceService) checkProviderResourceAvailability(provider *providerMo
 		return result
 	}
 
-	// 计算可用资源（考虑Provider的资源限制配置）
+	// 计算可用资源（考虑Provider的资源限制配置和超分配比例）
 	// 如果资源类型配置为不限制（false），则不计入总量，允许超分配
-	availableCPU := provider.NodeCPUCores - provider.UsedCPUCores
-	availableMemory := provider.NodeMemoryTotal - provider.UsedMemory
-	availableDisk := provider.NodeDiskTotal - provider.UsedDisk
+	// 计入总量时，可分配容量为物理容量乘以该实例类型的超分配比例，并与该类型自身的占用比较，
+	// 同时两种类型的占用按各自比例折算后的合计不能超过节点物理容量
+	availableCPU, availableMemory, availableDisk := provider.AvailableResources(req.InstanceType)
 
 	result.AvailableCPU = availableCPU
 	result.AvailableMemory = availableMemory
@@ -280,6 +281,7 @@ func (s *ResourceService) AllocateResourcesInTx(tx *gorm.DB, providerID uint, in
 
 		updates["container_count"] = provider.ContainerCount + 1
 	}
+	typedUsageUpdates(updates, &provider, instanceType, cpu, memory, disk)
 
 	if err := tx.Model(&provider).Updates(updates).Error; err != nil {
 		global.APP_LOG.Error("更新资源占用失败",
@@ -412,6 +414,7 @@ func (s *ResourceService) ReleaseResourcesInTx(tx *gorm.DB, providerID uint, ins
 		}
 		updates["container_count"] = newContainerCount
 	}
+	typedUsageUpdates(updates, &provider, instanceType, -cpu, -memory, -disk)
 
 	if err := tx.Model(&provider).Updates(updates).Error; err != nil {
 		global.APP_LOG.Error("更新资源占用失败",
@@ -438,6 +441,73 @@ func (s *ResourceService) ReleaseResources(providerID uint, instanceType string,
 	})
 }
 
+// AdjustResourcesInTx 在事务中按差值调整实例资源占用（调整实例配置时调用）
+// 扩容部分会按Provider的资源限制配置检查可用量，实例数量不变
+func (s *ResourceService) AdjustResourcesInTx(tx *gorm.DB, providerID uint, instanceType string, cpuDelta int, memoryDelta, diskDelta int64) error {
+	var provider providerModel.Provider
+	// 使用悲观锁锁定Provider记录
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&provider, providerID).Error; err != nil {
+		return fmt.Errorf("Provider不存在或无法锁定: %v", err)
+	}
+
+	limitCPU, limitMemory, limitDisk := provider.ResourceLimits(instanceType)
+	availableCPU, availableMemory, availableDisk := provider.AvailableResources(instanceType)
+
+	updates := map[string]interface{}{
+		"updated_at": time.Now(),
+	}
+
+	if limitCPU && cpuDelta != 0 {
+		if cpuDelta > 0 && cpuDelta > availableCPU {
+			return fmt.Errorf("CPU资源不足：需要增加 %d 核，可用 %d 核", cpuDelta, availableCPU)
+		}
+		newCPU := provider.UsedCPUCores + cpuDelta
+		if newCPU < 0 {
+			newCPU = 0
+		}
+		updates["used_cpu_cores"] = newCPU
+	}
+
+	if limitMemory && memoryDelta != 0 {
+		if memoryDelta > 0 && memoryDelta > availableMemory {
+			return fmt.Errorf("内存资源不足：需要增加 %d MB，可用 %d MB", memoryDelta, availableMemory)
+		}
+		newMemory := provider.UsedMemory + memoryDelta
+		if newMemory < 0 {
+			newMemory = 0
+		}
+		updates["used_memory"] = newMemory
+	}
+
+	if limitDisk && diskDelta != 0 {
+		if diskDelta > 0 && diskDelta > availableDisk {
+			return fmt.Errorf("磁盘资源不足：需要增加 %d MB，可用 %d MB", diskDelta, availableDisk)
+		}
+		newDisk := provider.UsedDisk + diskDelta
+		if newDisk < 0 {
+			newDisk = 0
+		}
+		updates["used_disk"] = newDisk
+	}
+	typedUsageUpdates(updates, &provider, instanceType, cpuDelta, memoryDelta, diskDelta)
+
+	if err := tx.Model(&provider).Updates(updates).Error; err != nil {
+		global.APP_LOG.Error("调整资源占用失败",
+			zap.Uint("providerId", providerID),
+			zap.String("error", utils.TruncateString(err.Error(), 200)))
+		return err
+	}
+
+	global.APP_LOG.Info("资源占用调整成功",
+		zap.Uint("providerId", providerID),
+		zap.String("instanceType", instanceType),
+		zap.Int("cpuDelta", cpuDelta),
+		zap.Int64("memoryDelta", memoryDelta),
+		zap.Int64("diskDelta", diskDelta))
+
+	return nil
+}
+
 // SyncProviderResources 同步Provider资源使用情况（基于实际实例计算）
 func (s *ResourceService) SyncProviderResources(providerID uint) error {
 	dbService := database.GetDatabaseService()
@@ -466,32 +536,64 @@ func (s *ResourceService) SyncProviderResources(providerID uint) error {
 		vmCount := stats.VMCount
 
 		// 统计容器资源（排除deleted、deleting、failed状态）
+		var containerStats dashboardModel.ResourceUsageStats
 		err = tx.Model(&providerModel.Instance{}).
 			Where("provider_id = ? AND instance_type = ? AND status NOT IN (?)",
 				providerID, "container", []string{"deleted", "deleting", "failed"}).
-			Select("COUNT(*) as container_count, COALESCE(SUM(memory), 0) as used_memory, COALESCE(SUM(disk), 0) as used_disk").
-			Scan(&stats).Error
+			Select("COUNT(*) as container_count, COALESCE(SUM(cpu), 0) as used_cpu_cores, COALESCE(SUM(memory), 0) as used_memory, COALESCE(SUM(disk), 0) as used_disk").
+			Scan(&containerStats).Error
 		if err != nil {
 			return fmt.Errorf("统计容器资源失败: %v", err)
 		}
 
-		containerMemory := stats.UsedMemory
-		containerDisk := stats.UsedDisk
-		containerCount := stats.ContainerCount
+		containerCPU := containerStats.UsedCPUCores
+		containerMemory := containerStats.UsedMemory
+		containerDisk := containerStats.UsedDisk
+		containerCount := containerStats.ContainerCount
+
+		// 数据卷容量计入对应实例类型的磁盘占用
+		vmVolumeDisk, err := sumProviderVolumeDisk(tx, providerID, "vm")
+		if err != nil {
+			return fmt.Errorf("统计数据卷容量失败: %v", err)
+		}
+		containerVolumeDisk, err := sumProviderVolumeDisk(tx, providerID, "container")
+		if err != nil {
+			return fmt.Errorf("统计数据卷容量失败: %v", err)
+		}
+		vmDisk += vmVolumeDisk
+		containerDisk += containerVolumeDisk
 
 		// 设置缓存过期时间（5分钟后）
 		cacheExpiry := time.Now().Add(5 * time.Minute)
 
+		// 按实例类型统计计入总量预算的资源，与AllocateResourcesInTx的扣减规则一致
+		// 各类型占用分别与该类型超分配后的容量比较，启动时的全量同步会据此回填已有节点的数据
+		containerLimitCPU, containerLimitMemory, containerLimitDisk := provider.ResourceLimits("container")
+		vmLimitCPU, vmLimitMemory, vmLimitDisk := provider.ResourceLimits("vm")
+		provider.ContainerUsedCPUCores = int(countIf(containerLimitCPU, containerCPU))
+		provider.ContainerUsedMemory = countIf(containerLimitMemory, containerMemory)
+		provider.ContainerUsedDisk = countIf(containerLimitDisk, containerDisk)
+		provider.VMUsedCPUCores = int(countIf(vmLimitCPU, vmCPU))
+		provider.VMUsedMemory = countIf(vmLimitMemory, vmMemory)
+		provider.VMUsedDisk = countIf(vmLimitDisk, vmDisk)
+
+		// 可用量按启用的实例类型中剩余可分配量较大者计算，已考虑合计物理容量
+		availableCPU, availableMemory := 0, int64(0)
+		for _, instanceType := range []string{"container", "vm"} {
+			if (instanceType == "container" && !provider.ContainerEnabled) || (instanceType == "vm" && !provider.VirtualMachineEnabled) {
+				continue
+			}
+			typeCPU, typeMemory, _ := provider.AvailableResources(instanceType)
+			if typeCPU > availableCPU {
+				availableCPU = typeCPU
+			}
+			if typeMemory > availableMemory {
+				availableMemory = typeMemory
+			}
+		}
+
 		// 更新Provider资源统计
 		totalInstances := int(vmCount + containerCount)
-		availableCPU := provider.NodeCPUCores - int(vmCPU)
-		if availableCPU < 0 {
-			availableCPU = 0
-		}
-		availableMemory := provider.NodeMemoryTotal - (vmMemory + containerMemory)
-		if availableMemory < 0 {
-			availableMemory = 0
-		}
 
 		// 计算最大实例数限制（基于容器和虚拟机的单独限制）
 		// 0 表示无限制，不应该被处理成有限制的情况
@@ -512,23 +614,56 @@ func (s *ResourceService) SyncProviderResources(providerID uint) error {
 
 		now := time.Now()
 		updates := map[string]interface{}{
-			"used_cpu_cores":      int(vmCPU), // 只有虚拟机占用CPU核心
-			"used_memory":         vmMemory + containerMemory,
-			"used_disk":           vmDisk + containerDisk,
-			"vm_count":            int(vmCount),
-			"container_count":     int(containerCount),
-			"available_cpu_cores": availableCPU,
-			"available_memory":    availableMemory,
-			"used_instances":      totalInstances,
-			"resource_synced":     true,
-			"resource_synced_at":  &now,
-			"count_cache_expiry":  &cacheExpiry, // 设置缓存过期时间
+			"used_cpu_cores":           int(vmCPU), // 只有虚拟机占用CPU核心
+			"used_memory":              vmMemory + containerMemory,
+			"used_disk":                vmDisk + containerDisk,
+			"container_used_cpu_cores": provider.ContainerUsedCPUCores,
+			"container_used_memory":    provider.ContainerUsedMemory,
+			"container_used_disk":      provider.ContainerUsedDisk,
+			"vm_used_cpu_cores":        provider.VMUsedCPUCores,
+			"vm_used_memory":           provider.VMUsedMemory,
+			"vm_used_disk":             provider.VMUsedDisk,
+			"vm_count":                 int(vmCount),
+			"container_count":          int(containerCount),
+			"available_cpu_cores":      availableCPU,
+			"available_memory":         availableMemory,
+			"used_instances":           totalInstances,
+			"resource_synced":          true,
+			"resource_synced_at":       &now,
+			"count_cache_expiry":       &cacheExpiry, // 设置缓存过期时间
 		}
 
 		return tx.Model(&provider).Updates(updates).Error
 	})
 }
 
+// typedUsageUpdates 按资源限制配置将指定实例类型的占用变化写入updates，结果不小于0
+func typedUsageUpdates(updates map[string]interface{}, provider *providerModel.Provider, instanceType string, cpuDelta int, memoryDelta, diskDelta int64) {
+	prefix := "container_"
+	if instanceType == "vm" {
+		prefix = "vm_"
+	}
+	limitCPU, limitMemory, limitDisk := provider.ResourceLimits(instanceType)
+	usedCPU, usedMemory, usedDisk := provider.UsedResources(instanceType)
+	if limitCPU && cpuDelta != 0 {
+		updates[prefix+"used_cpu_cores"] = max(usedCPU+cpuDelta, 0)
+	}
+	if limitMemory && memoryDelta != 0 {
+		updates[prefix+"used_memory"] = max(usedMemory+memoryDelta, 0)
+	}
+	if limitDisk && diskDelta != 0 {
+		updates[prefix+"used_disk"] = max(usedDisk+diskDelta, 0)
+	}
+}
+
+// countIf 资源计入总量预算时返回其用量，否则返回0
+func countIf(limited bool, value int64) int64 {
+	if limited {
+		return value
+	}
+	return 0
+}
+
 // GetProviderResourceStatus 获取Provider资源状态
 func (s *ResourceService) GetProviderResourceStatus(providerID uint) (map[string]interface{}, error) {
 	var provider providerModel.Provider
@@ -562,6 +697,10 @@ func (s *ResourceService) GetProviderResourceStatus(providerID uint) (map[string
 				"available": provider.NodeDiskTotal - provider.UsedDisk,
 			},
 		},
+		"effectiveCapacity": map[string]interface{}{
+			"container": BuildProviderCapacity(&provider, "container"),
+			"vm":        BuildProviderCapacity(&provider, "vm"),
+		},
 		"instances": map[string]interface{}{
 			"containers": provider.ContainerCount,
 			"vms":        provider.VMCount,
@@ -574,6 +713,15 @@ func (s *ResourceService) GetProviderResourceStatus(providerID uint) (map[string
 	return status, nil
 }
 
+// BuildProviderCapacity 生成指定实例类型的超分配比例和有效容量，未计入总量预算的资源不限制
+func BuildProviderCapacity(provider *providerModel.Provider, instanceType string) adminModel.ProviderCapacityInfo {
+	info := adminModel.ProviderCapacityInfo{}
+	info.LimitCPU, info.LimitMemory, info.LimitDisk = provider.ResourceLimits(instanceType)
+	info.CPURatio, info.MemoryRatio, info.DiskRatio = provider.OvercommitRatios(instanceType)
+	info.EffectiveCPUCores, info.EffectiveMemory, info.EffectiveDisk = provider.EffectiveCapacity(instanceType)
+	return info
+}
+
 // ValidateInstanceTypeSupport 验证Provider是否支持指定的实例类型
 func (s *ResourceService) ValidateInstanceTypeSupport(providerID uint, instanceType string) error {
 	var provider providerModel.Provider
@@ -594,5 +742,16 @@ func (s *ResourceService) ValidateInstanceTypeSupport(providerID uint, instanceT
 		return fmt.Errorf("不支持的实例类型: %s", instanceType)
 	}
 
+	// 已探测过能力的节点，还需节点实际支持该实例类型（如LXD未安装QEMU时无法创建虚拟机）
+	if caps, ok := provider.ParseCapabilities(); ok && !caps.SupportsInstanceType(instanceType) {
+		return fmt.Errorf("该节点当前环境不支持 %s 类型的实例", instanceType)
+	}
+
 	return nil
 }
+
+// CheckProviderAvailability 基于给定的Provider数据检查资源是否充足，不查询数据库
+// 用于批量规划时在内存中的Provider副本上累加已规划的资源占用
+func (s *ResourceService) CheckProviderAvailability(provider *providerModel.Provider, req resource.ResourceCheckRequest) *resource.ResourceCheckResult {
+	return s.checkProviderResourceAvailability(provider, req)
+}
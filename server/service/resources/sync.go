@@ -112,6 +112,36 @@ func (s *QuotaSyncService) extractLevelLimits(configMap map[string]interface{})
 				}
 			}
 
+			// 解析 MaxSnapshots
+			if maxSnapshots, exists := limitMap["max-snapshots"]; exists {
+				if snapshots, ok := maxSnapshots.(float64); ok {
+					levelLimit.MaxSnapshots = int(snapshots)
+				} else if snapshots, ok := maxSnapshots.(int); ok {
+					levelLimit.MaxSnapshots = snapshots
+				}
+			}
+
+			// 解析 user-data 配置
+			if allowUserData, ok := limitMap["allow-user-data"].(bool); ok {
+				levelLimit.AllowUserData = allowUserData
+			}
+			if maxSize, exists := limitMap["max-user-data-size"]; exists {
+				if size, ok := maxSize.(float64); ok {
+					levelLimit.MaxUserDataSize = int(size)
+				} else if size, ok := maxSize.(int); ok {
+					levelLimit.MaxUserDataSize = size
+				}
+			}
+
+			// 解析 MaxFirewallRules
+			if maxRules, exists := limitMap["max-firewall-rules"]; exists {
+				if rules, ok := maxRules.(float64); ok {
+					levelLimit.MaxFirewallRules = int(rules)
+				} else if rules, ok := maxRules.(int); ok {
+					levelLimit.MaxFirewallRules = rules
+				}
+			}
+
 			// 解析 MaxResources
 			if maxResources, exists := limitMap["max-resources"]; exists {
 				if resourcesMap, ok := maxResources.(map[string]interface{}); ok {
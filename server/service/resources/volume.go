@@ -0,0 +1,143 @@
+package resources
+
+import (
+	"fmt"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/provider"
+	"oneclickvirt/model/user"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// InstanceHasVolumes 实例上是否挂载了数据卷，迁移等需要在节点间移动实例的操作据此拒绝
+func InstanceHasVolumes(db *gorm.DB, instanceID uint) (bool, error) {
+	var count int64
+	err := db.Model(&provider.Volume{}).Where("instance_id = ?", instanceID).Count(&count).Error
+	return count > 0, err
+}
+
+// MarkVolumeDetached 清除数据卷的挂载信息
+func MarkVolumeDetached(query *gorm.DB) error {
+	return query.Updates(map[string]interface{}{
+		"instance_id": 0,
+		"device":      "",
+		"mount_path":  "",
+		"status":      provider.VolumeStatusAvailable,
+	}).Error
+}
+
+// DetachInstanceVolumesInTx 实例删除时在事务中解除其数据卷的挂载记录
+// 数据卷不属于实例，Provider删除实例时不会删除它们，只需把记录恢复为未挂载
+func DetachInstanceVolumesInTx(tx *gorm.DB, instanceID uint) error {
+	return MarkVolumeDetached(tx.Model(&provider.Volume{}).Where("instance_id = ?", instanceID))
+}
+
+// getVolumeDiskUsage 统计用户数据卷占用的容量（MB），删除中和创建失败的数据卷不计入
+func (s *QuotaService) getVolumeDiskUsage(tx *gorm.DB, userID uint) (int64, error) {
+	var total int64
+	err := tx.Model(&provider.Volume{}).
+		Where("user_id = ? AND status NOT IN (?)", userID, []string{provider.VolumeStatusDeleting, provider.VolumeStatusFailed}).
+		Select("COALESCE(SUM(size_mb), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// ValidateVolumeInTx 在事务中验证新增的数据卷容量是否超过用户等级的磁盘限制
+// 节点对该实例类型不限制磁盘时允许超分配，不检查
+func (s *QuotaService) ValidateVolumeInTx(tx *gorm.DB, userID, providerID uint, instanceType string, sizeDeltaMB int64) (*QuotaCheckResult, error) {
+	var u user.User
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&u, userID).Error; err != nil {
+		return nil, fmt.Errorf("用户不存在: %v", err)
+	}
+	if u.Status != 1 {
+		return &QuotaCheckResult{Allowed: false, Reason: "用户账户已被禁用"}, nil
+	}
+
+	levelLimits, exists := global.APP_CONFIG.Quota.LevelLimits[u.Level]
+	if !exists {
+		return &QuotaCheckResult{
+			Allowed: false,
+			Reason:  fmt.Sprintf("用户等级 %d 没有配置资源限制", u.Level),
+		}, nil
+	}
+
+	var prov provider.Provider
+	if err := tx.First(&prov, providerID).Error; err != nil {
+		return nil, fmt.Errorf("Provider 不存在: %v", err)
+	}
+	providerLevelLimits, err := s.getProviderLevelLimits(tx, providerID, u.Level)
+	if err != nil {
+		return nil, fmt.Errorf("获取 Provider 等级限制失败: %v", err)
+	}
+	if providerLevelLimits != nil {
+		levelLimits = s.mergeLevelLimitsWithOvercommit(levelLimits, *providerLevelLimits, &prov, instanceType)
+	}
+
+	_, currentResources, err := s.getCurrentResourceUsage(tx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取当前资源使用情况失败: %v", err)
+	}
+	maxResources := s.GetLevelMaxResources(levelLimits)
+
+	result := &QuotaCheckResult{
+		CurrentResources:  currentResources,
+		MaxResources:      maxResources,
+		MaxQuota:          maxResources,
+		RequiredResources: ResourceUsage{Disk: sizeDeltaMB},
+	}
+
+	if _, _, limitDisk := prov.ResourceLimits(instanceType); limitDisk && currentResources.Disk+sizeDeltaMB > maxResources.Disk {
+		result.Allowed = false
+		result.Reason = fmt.Sprintf("磁盘资源不足：需要 %dMB，当前使用 %dMB，最大允许 %dMB",
+			sizeDeltaMB, currentResources.Disk, maxResources.Disk)
+		return result, nil
+	}
+
+	result.Allowed = true
+	result.Reason = "资源验证通过"
+	return result, nil
+}
+
+// ReserveVolumeResourcesInTx 在事务中为数据卷新增的容量校验用户配额并占用Provider磁盘预算
+// 创建数据卷时sizeDeltaMB为完整容量，扩容时为增加的容量
+func ReserveVolumeResourcesInTx(tx *gorm.DB, volume *provider.Volume, sizeDeltaMB int64) error {
+	quotaService := NewQuotaService()
+	result, err := quotaService.ValidateVolumeInTx(tx, volume.UserID, volume.ProviderID, volume.InstanceType, sizeDeltaMB)
+	if err != nil {
+		return err
+	}
+	if !result.Allowed {
+		return fmt.Errorf("%s", result.Reason)
+	}
+
+	if err := (&ResourceService{}).AdjustResourcesInTx(tx, volume.ProviderID, volume.InstanceType, 0, 0, sizeDeltaMB); err != nil {
+		return err
+	}
+	return quotaService.UpdateUserQuotaAfterCreationWithTx(tx, volume.UserID, ResourceUsage{Disk: sizeDeltaMB})
+}
+
+// ReleaseVolumeResourcesInTx 在事务中释放数据卷占用的用户配额和Provider磁盘预算
+func ReleaseVolumeResourcesInTx(tx *gorm.DB, volume *provider.Volume, sizeMB int64) error {
+	if err := (&ResourceService{}).AdjustResourcesInTx(tx, volume.ProviderID, volume.InstanceType, 0, 0, -sizeMB); err != nil {
+		return err
+	}
+	if err := NewQuotaService().UpdateUserQuotaAfterDeletionWithTx(tx, volume.UserID, ResourceUsage{Disk: sizeMB}); err != nil {
+		global.APP_LOG.Warn("释放数据卷用户配额失败",
+			zap.Uint("volumeId", volume.ID),
+			zap.Error(err))
+	}
+	return nil
+}
+
+// sumProviderVolumeDisk 统计Provider上指定实例类型的数据卷容量（MB），与getVolumeDiskUsage使用相同的状态口径
+func sumProviderVolumeDisk(tx *gorm.DB, providerID uint, instanceType string) (int64, error) {
+	var total int64
+	err := tx.Model(&provider.Volume{}).
+		Where("provider_id = ? AND instance_type = ? AND status NOT IN (?)",
+			providerID, instanceType, []string{provider.VolumeStatusDeleting, provider.VolumeStatusFailed}).
+		Select("COALESCE(SUM(size_mb), 0)").
+		Scan(&total).Error
+	return total, err
+}
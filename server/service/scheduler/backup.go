@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	"oneclickvirt/model/provider"
+
+	"go.uber.org/zap"
+)
+
+// backupTaskTypes 备份相关任务类型，同一实例同时只允许一个备份任务
+var backupTaskTypes = []string{"create-backup", "restore-backup"}
+
+// scheduleInstanceBackups 为到期的备份策略创建备份任务
+func (s *SchedulerService) scheduleInstanceBackups() {
+	if global.APP_DB == nil {
+		return
+	}
+
+	now := time.Now()
+	var policies []provider.InstanceBackupPolicy
+	if err := global.APP_DB.Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Find(&policies).Error; err != nil {
+		global.APP_LOG.Error("查询到期的备份策略失败", zap.Error(err))
+		return
+	}
+
+	for i := range policies {
+		select {
+		case <-s.ctx.Done():
+			return
+		default:
+		}
+
+		policy := &policies[i]
+		if err := s.createScheduledBackup(policy, now); err != nil {
+			global.APP_LOG.Warn("创建定时备份失败",
+				zap.Uint("instanceId", policy.InstanceID),
+				zap.Error(err))
+		}
+
+		// 无论本次是否成功都推进到下一个周期，避免每分钟重复尝试
+		nextRunAt := policy.NextRunAfter(now)
+		global.APP_DB.Model(policy).Updates(map[string]interface{}{
+			"last_run_at": now,
+			"next_run_at": nextRunAt,
+		})
+	}
+}
+
+// createScheduledBackup 创建定时备份记录和对应的备份任务
+func (s *SchedulerService) createScheduledBackup(policy *provider.InstanceBackupPolicy, now time.Time) error {
+	var instance provider.Instance
+	if err := global.APP_DB.First(&instance, policy.InstanceID).Error; err != nil {
+		return err
+	}
+	if instance.Status != "running" && instance.Status != "stopped" {
+		global.APP_LOG.Info("实例当前状态不允许备份，跳过本次定时备份",
+			zap.Uint("instanceId", instance.ID),
+			zap.String("status", instance.Status))
+		return nil
+	}
+
+	var count int64
+	global.APP_DB.Model(&adminModel.Task{}).
+		Where("instance_id = ? AND task_type IN (?) AND status IN ('pending', 'running')", instance.ID, backupTaskTypes).
+		Count(&count)
+	if count > 0 {
+		global.APP_LOG.Info("实例已有进行中的备份任务，跳过本次定时备份",
+			zap.Uint("instanceId", instance.ID))
+		return nil
+	}
+
+	backup := provider.InstanceBackup{
+		InstanceID:  instance.ID,
+		ProviderID:  instance.ProviderID,
+		UserID:      instance.UserID,
+		Name:        "auto-" + now.Format("20060102-1504"),
+		Description: "定时备份",
+		TriggerType: "scheduled",
+		Status:      "creating",
+	}
+	if err := global.APP_DB.Create(&backup).Error; err != nil {
+		return err
+	}
+
+	taskData, err := json.Marshal(adminModel.BackupTaskRequest{
+		InstanceId: instance.ID,
+		ProviderId: instance.ProviderID,
+		BackupId:   backup.ID,
+	})
+	if err != nil {
+		global.APP_DB.Delete(&backup)
+		return err
+	}
+
+	task, err := s.taskService.CreateTask(instance.UserID, &instance.ProviderID, &instance.ID, "create-backup", string(taskData), 3600)
+	if err != nil {
+		global.APP_DB.Delete(&backup)
+		return err
+	}
+
+	global.APP_LOG.Info("已创建定时备份任务",
+		zap.Uint("instanceId", instance.ID),
+		zap.Uint("backupId", backup.ID),
+		zap.Uint("taskId", task.ID))
+	return nil
+}
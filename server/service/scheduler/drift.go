@@ -0,0 +1,23 @@
+package scheduler
+
+import (
+	"oneclickvirt/global"
+	adminProviderService "oneclickvirt/service/admin/provider"
+)
+
+// reconcileInstanceDrift 对比节点实例与数据库记录，检查耗时较长，在后台执行且同时只运行一次
+func (s *SchedulerService) reconcileInstanceDrift() {
+	if global.APP_DB == nil {
+		return
+	}
+	if !s.driftRunning.CompareAndSwap(false, true) {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.driftRunning.Store(false)
+		adminProviderService.NewService().ReconcileAllProviders(s.ctx)
+	}()
+}
@@ -269,9 +269,12 @@ func (s *ProviderHealthSchedulerService) checkSingleProviderHealth(provider prov
 // 此方法仅控制是否允许在该Provider上申领新实例
 // 不影响现有实例的状态，保持实例的实际运行状态和用户操作意图
 func (s *ProviderHealthSchedulerService) updateProviderAllowClaim(providerID uint, allowClaim bool) {
-	err := global.APP_DB.Model(&providerModel.Provider{}).
-		Where("id = ?", providerID).
-		Update("allow_claim", allowClaim).Error
+	query := global.APP_DB.Model(&providerModel.Provider{}).Where("id = ?", providerID)
+	if allowClaim {
+		// 维护中的节点由管理员退出维护时恢复申领状态
+		query = query.Where("maintenance_mode = ?", false)
+	}
+	err := query.Update("allow_claim", allowClaim).Error
 
 	if err != nil {
 		global.APP_LOG.Error("更新Provider的allow_claim状态失败",
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"oneclickvirt/global"
@@ -18,13 +19,14 @@ import (
 
 // SchedulerService 全局任务调度器
 type SchedulerService struct {
-	taskService TaskServiceInterface
-	ctx         context.Context
-	cancel      context.CancelFunc
-	wg          sync.WaitGroup
-	running     bool
-	mu          sync.RWMutex
-	triggerChan chan struct{} // 用于立即触发任务处理
+	taskService  TaskServiceInterface
+	ctx          context.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+	running      bool
+	mu           sync.RWMutex
+	triggerChan  chan struct{} // 用于立即触发任务处理
+	driftRunning atomic.Bool   // 漂移检查是否正在执行
 }
 
 // TaskServiceInterface 任务服务接口
@@ -32,6 +34,7 @@ type TaskServiceInterface interface {
 	StartTask(taskID uint) error
 	CancelTaskByAdmin(taskID uint, reason string) error
 	CleanupTimeoutTasksWithLockRelease(timeoutThreshold time.Time) (int64, int64)
+	CreateTask(userID uint, providerID *uint, instanceID *uint, taskType string, taskData string, timeoutDuration int) (*adminModel.Task, error)
 }
 
 // NewSchedulerService 创建新的调度器服务
@@ -116,12 +119,16 @@ func (s *SchedulerService) runTaskScheduler() {
 	cleanupTicker := time.NewTicker(1 * time.Minute)      // 超时清理保持1分钟
 	maintenanceTicker := time.NewTicker(10 * time.Minute) // 系统维护保持10分钟
 	trafficAggTicker := time.NewTicker(5 * time.Minute)   // 流量聚合保持5分钟
+	backupTicker := time.NewTicker(1 * time.Minute)       // 定时备份检查1分钟
+	driftTicker := time.NewTicker(30 * time.Minute)       // 实例状态漂移检查30分钟
 
 	defer func() {
 		taskTicker.Stop()
 		cleanupTicker.Stop()
 		maintenanceTicker.Stop()
 		trafficAggTicker.Stop()
+		backupTicker.Stop()
+		driftTicker.Stop()
 	}()
 
 	global.APP_LOG.Info("Task scheduler main loop started with traffic aggregation")
@@ -149,6 +156,12 @@ func (s *SchedulerService) runTaskScheduler() {
 		case <-trafficAggTicker.C:
 			// 定期聚合流量数据，更新缓存
 			s.aggregateTrafficData()
+
+		case <-backupTicker.C:
+			s.scheduleInstanceBackups()
+
+		case <-driftTicker.C:
+			s.reconcileInstanceDrift()
 		}
 	}
 }
@@ -28,6 +28,7 @@ func GetDefaultStorageConfig() *system.StorageConfig {
 			system.CacheDir,
 			system.TempDir,
 			system.AvatarsDir,
+			system.BackupsDir,
 		},
 	}
 }
@@ -117,6 +118,27 @@ func (s *StorageService) GetAvatarsPath() string {
 	return s.GetStoragePath(system.AvatarsDir)
 }
 
+// GetBackupsPath 获取实例备份存储路径
+func (s *StorageService) GetBackupsPath() string {
+	return s.GetStoragePath(system.BackupsDir)
+}
+
+// GetBackupFilePath 获取备份归档的完整路径，relPath为相对备份目录的路径
+func (s *StorageService) GetBackupFilePath(relPath string) string {
+	return filepath.Join(s.GetBackupsPath(), relPath)
+}
+
+// RemoveBackupFile 删除备份归档，文件不存在时视为成功
+func (s *StorageService) RemoveBackupFile(relPath string) error {
+	if relPath == "" {
+		return nil
+	}
+	if err := os.Remove(s.GetBackupFilePath(relPath)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
 // CleanupTempFiles 清理临时文件
 func (s *StorageService) CleanupTempFiles() error {
 	tempPath := s.GetTempPath()
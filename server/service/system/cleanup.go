@@ -40,7 +40,7 @@ func (s *InstanceCleanupService) RepairStuckInstances() error {
 
 	var stuckInstances []providerModel.Instance
 	if err := global.APP_DB.Where("status IN (?) AND updated_at < ?",
-		[]string{"deleting", "resetting", "creating"}, cutoffTime).Find(&stuckInstances).Error; err != nil {
+		[]string{"deleting", "resetting", "reinstalling", "resizing", "migrating", "creating"}, cutoffTime).Find(&stuckInstances).Error; err != nil {
 		global.APP_LOG.Error("查询卡住的实例失败", zap.Error(err))
 		return err
 	}
@@ -61,6 +61,15 @@ func (s *InstanceCleanupService) RepairStuckInstances() error {
 		case "resetting":
 			// resetting状态超时，恢复为stopped
 			newStatus = "stopped"
+		case "reinstalling":
+			// reinstalling状态超时，恢复为stopped
+			newStatus = "stopped"
+		case "resizing":
+			// resizing状态超时，恢复为stopped，由状态同步更新为实际状态
+			newStatus = "stopped"
+		case "migrating":
+			// migrating状态超时，恢复为stopped，需要管理员确认实例所在节点
+			newStatus = "stopped"
 		case "creating":
 			// creating状态超时，标记为failed
 			newStatus = "failed"
@@ -0,0 +1,297 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/storage"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// loadBackupTaskContext 解析备份任务数据并加载实例和备份记录
+func (s *TaskService) loadBackupTaskContext(task *adminModel.Task) (*adminModel.BackupTaskRequest, *providerModel.Instance, *providerModel.InstanceBackup, error) {
+	var taskReq adminModel.BackupTaskRequest
+	if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+		global.APP_LOG.Error("解析备份任务数据失败",
+			zap.Uint("taskId", task.ID),
+			zap.String("taskType", task.TaskType),
+			zap.String("taskData", task.TaskData),
+			zap.Error(err))
+		return nil, nil, nil, fmt.Errorf("解析任务数据失败: %v", err)
+	}
+
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, taskReq.InstanceId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil, fmt.Errorf("实例不存在")
+		}
+		return nil, nil, nil, fmt.Errorf("获取实例信息失败: %v", err)
+	}
+
+	// 验证实例所有权
+	if instance.UserID != task.UserID {
+		return nil, nil, nil, fmt.Errorf("无权限操作此实例")
+	}
+
+	var backup providerModel.InstanceBackup
+	if err := global.APP_DB.Where("id = ? AND instance_id = ?", taskReq.BackupId, instance.ID).First(&backup).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil, fmt.Errorf("备份不存在")
+		}
+		return nil, nil, nil, fmt.Errorf("获取备份信息失败: %v", err)
+	}
+
+	return &taskReq, &instance, &backup, nil
+}
+
+// executeCreateBackupTask 执行创建备份任务：导出实例归档并保存到存储目录，然后按保留策略清理旧备份
+func (s *TaskService) executeCreateBackupTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	_, instance, backup, err := s.loadBackupTaskContext(task)
+	if err != nil {
+		return err
+	}
+
+	var providerRecord providerModel.Provider
+	if err := global.APP_DB.First(&providerRecord, instance.ProviderID).Error; err != nil {
+		global.APP_DB.Model(backup).Update("status", "failed")
+		return fmt.Errorf("获取Provider信息失败: %v", err)
+	}
+	if err := provider2.RequireCapabilities(&providerRecord, providerModel.CapabilityBackup); err != nil {
+		global.APP_DB.Model(backup).Update("status", "failed")
+		return err
+	}
+
+	relPath := filepath.Join(fmt.Sprintf("%d", instance.ID), backup.Name+backupArchiveExt(providerRecord.Type, instance.InstanceType))
+	fullPath := storage.GetStorageService().GetBackupFilePath(relPath)
+	if err := utils.EnsureDir(filepath.Dir(fullPath)); err != nil {
+		global.APP_DB.Model(backup).Update("status", "failed")
+		return fmt.Errorf("创建备份目录失败: %v", err)
+	}
+
+	// 先写入临时文件，导出完成后再改名，避免留下不完整的归档
+	partPath := fullPath + ".part"
+	file, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		global.APP_DB.Model(backup).Update("status", "failed")
+		return fmt.Errorf("创建备份文件失败: %v", err)
+	}
+
+	s.updateTaskProgress(task.ID, 20, "正在导出实例归档...")
+
+	providerService := provider2.GetProviderService()
+	exportErr := providerService.ExportInstance(ctx, instance.ProviderID, instance.Name, file, func(percentage int, message string) {
+		s.updateTaskProgress(task.ID, 20+percentage*70/100, message)
+	})
+	closeErr := file.Close()
+	if exportErr == nil && closeErr != nil {
+		exportErr = closeErr
+	}
+	if exportErr == nil {
+		exportErr = os.Rename(partPath, fullPath)
+	}
+	if exportErr != nil {
+		os.Remove(partPath)
+		global.APP_LOG.Error("创建实例备份失败",
+			zap.Uint("taskId", task.ID),
+			zap.Uint("instanceId", instance.ID),
+			zap.String("backup", backup.Name),
+			zap.Error(exportErr))
+		global.APP_DB.Model(backup).Update("status", "failed")
+		return fmt.Errorf("创建备份失败: %v", exportErr)
+	}
+
+	s.updateTaskProgress(task.ID, 92, "正在更新备份记录...")
+
+	var size int64
+	if info, err := os.Stat(fullPath); err == nil {
+		size = info.Size()
+	}
+	if err := global.APP_DB.Model(backup).Updates(map[string]interface{}{
+		"status":    "available",
+		"file_path": relPath,
+		"size":      size,
+	}).Error; err != nil {
+		return fmt.Errorf("更新备份状态失败: %v", err)
+	}
+
+	if backup.TriggerType == "scheduled" {
+		s.updateTaskProgress(task.ID, 96, "正在清理过期备份...")
+		pruneScheduledBackups(instance.ID)
+	}
+
+	global.APP_LOG.Info("实例备份创建成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("instanceId", instance.ID),
+		zap.String("instanceName", instance.Name),
+		zap.String("backup", backup.Name),
+		zap.Int64("size", size))
+
+	return nil
+}
+
+// executeRestoreBackupTask 执行恢复备份任务：用备份归档原地覆盖实例
+func (s *TaskService) executeRestoreBackupTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	taskReq, instance, backup, err := s.loadBackupTaskContext(task)
+	if err != nil {
+		return err
+	}
+
+	originalStatus := taskReq.OriginalStatus
+	if originalStatus == "" {
+		originalStatus = "stopped"
+	}
+
+	// 恢复结束后无论成功与否都恢复备份和实例状态，避免状态锁死
+	finalStatus := originalStatus
+	defer func() {
+		global.APP_DB.Model(backup).Update("status", "available")
+		if err := global.APP_DB.Model(instance).Update("status", finalStatus).Error; err != nil {
+			global.APP_LOG.Error("恢复实例状态失败",
+				zap.Uint("instanceId", instance.ID),
+				zap.String("status", finalStatus),
+				zap.Error(err))
+		}
+	}()
+
+	var providerRecord providerModel.Provider
+	if err := global.APP_DB.First(&providerRecord, instance.ProviderID).Error; err != nil {
+		return fmt.Errorf("获取Provider信息失败: %v", err)
+	}
+	if err := provider2.RequireCapabilities(&providerRecord, providerModel.CapabilityBackup); err != nil {
+		return err
+	}
+
+	file, err := os.Open(storage.GetStorageService().GetBackupFilePath(backup.FilePath))
+	if err != nil {
+		return fmt.Errorf("打开备份文件失败: %v", err)
+	}
+	defer file.Close()
+
+	s.updateTaskProgress(task.ID, 20, "正在恢复备份...")
+
+	providerService := provider2.GetProviderService()
+	if err := providerService.RestoreInstance(ctx, instance.ProviderID, instance.Name, file, func(percentage int, message string) {
+		s.updateTaskProgress(task.ID, 20+percentage*60/100, message)
+	}); err != nil {
+		global.APP_LOG.Error("恢复实例备份失败",
+			zap.Uint("taskId", task.ID),
+			zap.Uint("instanceId", instance.ID),
+			zap.String("backup", backup.Name),
+			zap.Error(err))
+		// 恢复过程中实例已被停止，实际状态由状态同步更新
+		finalStatus = "stopped"
+		return fmt.Errorf("恢复备份失败: %v", err)
+	}
+
+	// 归档中的proxy设备已清除，按当前端口记录重新创建
+	if providerRecord.Type == "lxd" || providerRecord.Type == "incus" {
+		s.updateTaskProgress(task.ID, 82, "正在恢复端口映射...")
+		s.applyInstanceProxyDevices(ctx, instance, providerRecord)
+	}
+
+	// 恢复后实例处于停止状态，原本运行中的实例需要重新启动并同步当前密码
+	finalStatus = "stopped"
+	if originalStatus == "running" {
+		s.updateTaskProgress(task.ID, 88, "正在启动实例...")
+		providerApiService := &provider2.ProviderApiService{}
+		prov, _, err := providerApiService.GetProviderByID(instance.ProviderID)
+		if err == nil {
+			err = prov.StartInstance(ctx, instance.Name)
+		}
+		if err != nil {
+			global.APP_LOG.Warn("恢复备份后启动实例失败",
+				zap.Uint("instanceId", instance.ID),
+				zap.Error(err))
+		} else {
+			finalStatus = "running"
+			s.syncRestoredPassword(ctx, instance)
+		}
+	}
+
+	global.APP_LOG.Info("实例备份恢复成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("instanceId", instance.ID),
+		zap.String("instanceName", instance.Name),
+		zap.String("backup", backup.Name))
+
+	return nil
+}
+
+// syncRestoredPassword 备份中的登录密码可能已过期，恢复后重新设置为数据库中的当前密码
+func (s *TaskService) syncRestoredPassword(ctx context.Context, instance *providerModel.Instance) {
+	if instance.Password == "" {
+		return
+	}
+	// 等待实例内的服务就绪
+	time.Sleep(10 * time.Second)
+	providerService := provider2.GetProviderService()
+	if err := providerService.SetInstancePassword(ctx, instance.ProviderID, instance.Name, instance.Password); err != nil {
+		global.APP_LOG.Warn("恢复备份后同步实例密码失败",
+			zap.Uint("instanceId", instance.ID),
+			zap.Error(err))
+	}
+}
+
+// pruneScheduledBackups 按实例备份策略的保留数量删除最旧的定时备份，手动备份不受影响
+func pruneScheduledBackups(instanceID uint) {
+	var policy providerModel.InstanceBackupPolicy
+	if err := global.APP_DB.Where("instance_id = ?", instanceID).First(&policy).Error; err != nil || policy.KeepLast <= 0 {
+		return
+	}
+
+	var backups []providerModel.InstanceBackup
+	if err := global.APP_DB.Where("instance_id = ? AND trigger_type = ? AND status = ?", instanceID, "scheduled", "available").
+		Order("created_at DESC").Find(&backups).Error; err != nil {
+		global.APP_LOG.Warn("查询过期备份失败", zap.Uint("instanceId", instanceID), zap.Error(err))
+		return
+	}
+	if len(backups) <= policy.KeepLast {
+		return
+	}
+	expired := backups[policy.KeepLast:]
+
+	for i := range expired {
+		if err := storage.GetStorageService().RemoveBackupFile(expired[i].FilePath); err != nil {
+			global.APP_LOG.Warn("删除过期备份文件失败",
+				zap.Uint("backupId", expired[i].ID),
+				zap.Error(err))
+		}
+		global.APP_DB.Delete(&expired[i])
+	}
+
+	global.APP_LOG.Info("已清理过期的定时备份",
+		zap.Uint("instanceId", instanceID),
+		zap.Int("count", len(expired)),
+		zap.Int("keepLast", policy.KeepLast))
+}
+
+// backupArchiveExt 根据Provider类型返回归档文件扩展名，便于下载后直接用对应工具恢复
+func backupArchiveExt(providerType, instanceType string) string {
+	switch providerType {
+	case "proxmox":
+		if instanceType == "vm" {
+			return ".vma.zst"
+		}
+		return ".tar.zst"
+	case "docker", "podman", "libvirt":
+		return ".tar"
+	default:
+		return ".tar.gz"
+	}
+}
@@ -361,6 +361,149 @@ func (s *TaskService) handleCancelledTaskCleanup(taskID uint) {
 		}
 	}
 
+	// 处理重装系统任务的清理：只恢复实例状态
+	if task.TaskType == "reinstall" && task.InstanceID != nil {
+		var taskReq adminModel.ReinstallTaskRequest
+		if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+			global.APP_LOG.Error("解析重装系统任务数据失败", zap.Uint("taskId", taskID), zap.Error(err))
+			return
+		}
+		originalStatus := taskReq.OriginalStatus
+		if originalStatus == "" {
+			originalStatus = "stopped"
+		}
+		if err := global.APP_DB.Model(&providerModel.Instance{}).
+			Where("id = ? AND status = ?", *task.InstanceID, "reinstalling").
+			Update("status", originalStatus).Error; err != nil {
+			global.APP_LOG.Error("恢复实例状态失败",
+				zap.Uint("instanceId", *task.InstanceID),
+				zap.String("newStatus", originalStatus),
+				zap.Error(err))
+		}
+	}
+
+	// 处理调整配置任务的清理：只恢复实例状态，资源计数由任务执行流程自行回滚
+	if task.TaskType == "resize" && task.InstanceID != nil {
+		var taskReq adminModel.ResizeTaskRequest
+		if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+			global.APP_LOG.Error("解析调整配置任务数据失败", zap.Uint("taskId", taskID), zap.Error(err))
+			return
+		}
+		originalStatus := taskReq.OriginalStatus
+		if originalStatus == "" {
+			originalStatus = "running"
+		}
+		if err := global.APP_DB.Model(&providerModel.Instance{}).
+			Where("id = ? AND status = ?", *task.InstanceID, "resizing").
+			Update("status", originalStatus).Error; err != nil {
+			global.APP_LOG.Error("恢复实例状态失败",
+				zap.Uint("instanceId", *task.InstanceID),
+				zap.String("newStatus", originalStatus),
+				zap.Error(err))
+		}
+	}
+
+	// 处理迁移任务的清理：只恢复实例状态，执行中的迁移由任务流程自行回滚
+	if task.TaskType == "migrate" && task.InstanceID != nil {
+		var taskReq adminModel.MigrateTaskRequest
+		if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+			global.APP_LOG.Error("解析迁移任务数据失败", zap.Uint("taskId", taskID), zap.Error(err))
+			return
+		}
+		originalStatus := taskReq.OriginalStatus
+		if originalStatus == "" {
+			originalStatus = "running"
+		}
+		if err := global.APP_DB.Model(&providerModel.Instance{}).
+			Where("id = ? AND status = ?", *task.InstanceID, "migrating").
+			Update("status", originalStatus).Error; err != nil {
+			global.APP_LOG.Error("恢复实例状态失败",
+				zap.Uint("instanceId", *task.InstanceID),
+				zap.String("newStatus", originalStatus),
+				zap.Error(err))
+		}
+	}
+
+	// 处理快照任务的清理
+	if (task.TaskType == "create-snapshot" || task.TaskType == "restore-snapshot" || task.TaskType == "delete-snapshot") && task.InstanceID != nil {
+		var taskReq adminModel.SnapshotTaskRequest
+		if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+			global.APP_LOG.Error("解析快照任务数据失败", zap.Uint("taskId", taskID), zap.Error(err))
+			return
+		}
+
+		// 创建中的快照无法确认是否已生成，标记为失败；恢复和删除中的快照恢复为可用
+		snapshotStatus := "available"
+		if task.TaskType == "create-snapshot" {
+			snapshotStatus = "failed"
+		}
+		if err := global.APP_DB.Model(&providerModel.InstanceSnapshot{}).
+			Where("id = ? AND status IN (?)", taskReq.SnapshotId, []string{"creating", "restoring", "deleting"}).
+			Update("status", snapshotStatus).Error; err != nil {
+			global.APP_LOG.Error("恢复快照状态失败",
+				zap.Uint("snapshotId", taskReq.SnapshotId),
+				zap.Error(err))
+		}
+
+		// 恢复快照任务需要恢复实例状态
+		if task.TaskType == "restore-snapshot" {
+			originalStatus := taskReq.OriginalStatus
+			if originalStatus == "" {
+				originalStatus = "stopped"
+			}
+			if err := global.APP_DB.Model(&providerModel.Instance{}).
+				Where("id = ? AND status = ?", *task.InstanceID, "restoring").
+				Update("status", originalStatus).Error; err != nil {
+				global.APP_LOG.Error("恢复实例状态失败",
+					zap.Uint("instanceId", *task.InstanceID),
+					zap.String("newStatus", originalStatus),
+					zap.Error(err))
+			}
+		}
+	}
+
+	// 处理备份任务的清理
+	if (task.TaskType == "create-backup" || task.TaskType == "restore-backup") && task.InstanceID != nil {
+		var taskReq adminModel.BackupTaskRequest
+		if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+			global.APP_LOG.Error("解析备份任务数据失败", zap.Uint("taskId", taskID), zap.Error(err))
+			return
+		}
+
+		// 创建中的备份归档不完整，标记为失败；恢复中的备份恢复为可用
+		backupStatus := "available"
+		if task.TaskType == "create-backup" {
+			backupStatus = "failed"
+		}
+		if err := global.APP_DB.Model(&providerModel.InstanceBackup{}).
+			Where("id = ? AND status IN (?)", taskReq.BackupId, []string{"creating", "restoring"}).
+			Update("status", backupStatus).Error; err != nil {
+			global.APP_LOG.Error("恢复备份状态失败",
+				zap.Uint("backupId", taskReq.BackupId),
+				zap.Error(err))
+		}
+
+		// 恢复备份过程中实例会被停止，取消后恢复为stopped，由状态同步更新为实际状态
+		if task.TaskType == "restore-backup" {
+			if err := global.APP_DB.Model(&providerModel.Instance{}).
+				Where("id = ? AND status = ?", *task.InstanceID, "restoring").
+				Update("status", "stopped").Error; err != nil {
+				global.APP_LOG.Error("恢复实例状态失败",
+					zap.Uint("instanceId", *task.InstanceID),
+					zap.String("newStatus", "stopped"),
+					zap.Error(err))
+			}
+		}
+	}
+
+	// 处理数据卷和私有网络任务的清理：创建中的无法确认是否已生成，标记为失败；其他操作恢复原状态
+	switch task.TaskType {
+	case "create-volume", "attach-volume", "detach-volume", "resize-volume", "delete-volume":
+		s.handleCancelledVolumeTask(task)
+	case "create-private-network", "delete-private-network", "attach-private-network", "detach-private-network":
+		s.handleCancelledPrivateNetworkTask(task)
+	}
+
 	// 处理其他操作任务（start、stop、restart）的清理
 	if (task.TaskType == "start" || task.TaskType == "stop" || task.TaskType == "restart") && task.InstanceID != nil {
 		// 获取实例信息
@@ -12,7 +12,9 @@ import (
 	"oneclickvirt/service/database"
 	provider2 "oneclickvirt/service/provider"
 	"oneclickvirt/service/resources"
+	"oneclickvirt/service/storage"
 	"oneclickvirt/service/traffic"
+	"os"
 	"time"
 
 	"go.uber.org/zap"
@@ -161,6 +163,9 @@ func (s *TaskService) executeDeleteInstanceTask(ctx context.Context, task *admin
 			zap.Error(err))
 	}
 
+	// 第二步：事务外移除宿主机上的防火墙规则（SSH操作）
+	s.removeInstanceFirewall(deleteCtx, &instance, &provider)
+
 	// 更新进度 (90%)
 	s.updateTaskProgress(task.ID, 90, "正在清理数据库记录...")
 
@@ -216,7 +221,53 @@ func (s *TaskService) executeDeleteInstanceTask(ctx context.Context, task *admin
 			// 配额释放失败不阻止整个流程
 		}
 
-		// 4. 软删除当前实例记录（保留流量数据以供统计）- 这是最关键的操作
+		// 4. 删除实例快照记录（快照随实例一起在Provider上删除）
+		if err := tx.Where("instance_id = ?", instanceID).Delete(&providerModel.InstanceSnapshot{}).Error; err != nil {
+			global.APP_LOG.Warn("删除实例快照记录失败",
+				zap.Uint("taskId", task.ID),
+				zap.Uint("instanceId", instanceID),
+				zap.Error(err))
+		}
+
+		// 5. 删除实例备份策略和备份记录，归档文件在事务提交后删除
+		if err := tx.Where("instance_id = ?", instanceID).Delete(&providerModel.InstanceBackupPolicy{}).Error; err != nil {
+			global.APP_LOG.Warn("删除实例备份策略失败",
+				zap.Uint("taskId", task.ID),
+				zap.Uint("instanceId", instanceID),
+				zap.Error(err))
+		}
+		if err := tx.Where("instance_id = ?", instanceID).Delete(&providerModel.InstanceBackup{}).Error; err != nil {
+			global.APP_LOG.Warn("删除实例备份记录失败",
+				zap.Uint("taskId", task.ID),
+				zap.Uint("instanceId", instanceID),
+				zap.Error(err))
+		}
+
+		// 6. 解除数据卷挂载记录，数据卷保留供挂载到其他实例
+		if err := resources.DetachInstanceVolumesInTx(tx, instanceID); err != nil {
+			global.APP_LOG.Warn("解除实例数据卷挂载失败",
+				zap.Uint("taskId", task.ID),
+				zap.Uint("instanceId", instanceID),
+				zap.Error(err))
+		}
+
+		// 7. 删除私有网络接入记录，释放实例在私有网络中的地址
+		if err := resources.DetachInstancePrivateNetworksInTx(tx, instanceID); err != nil {
+			global.APP_LOG.Warn("删除实例私有网络接入记录失败",
+				zap.Uint("taskId", task.ID),
+				zap.Uint("instanceId", instanceID),
+				zap.Error(err))
+		}
+
+		// 8. 删除实例防火墙规则
+		if err := tx.Where("instance_id = ?", instanceID).Delete(&providerModel.FirewallRule{}).Error; err != nil {
+			global.APP_LOG.Warn("删除实例防火墙规则失败",
+				zap.Uint("taskId", task.ID),
+				zap.Uint("instanceId", instanceID),
+				zap.Error(err))
+		}
+
+		// 9. 软删除当前实例记录（保留流量数据以供统计）- 这是最关键的操作
 		if err := tx.Delete(&instance).Error; err != nil {
 			return fmt.Errorf("删除实例记录失败: %v", err)
 		}
@@ -243,6 +294,15 @@ func (s *TaskService) executeDeleteInstanceTask(ctx context.Context, task *admin
 		return err
 	}
 
+	// 删除实例的所有备份归档
+	backupDir := storage.GetStorageService().GetBackupFilePath(fmt.Sprintf("%d", instanceID))
+	if err := os.RemoveAll(backupDir); err != nil {
+		global.APP_LOG.Warn("删除实例备份目录失败",
+			zap.Uint("instanceId", instanceID),
+			zap.String("dir", backupDir),
+			zap.Error(err))
+	}
+
 	// 标记任务完成
 	operationType := "用户"
 	if taskReq.AdminOperation {
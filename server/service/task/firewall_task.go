@@ -0,0 +1,109 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider/portmapping/iptables"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// executeApplyFirewallTask 执行应用实例防火墙规则任务，按数据库中的规则整体重建宿主机上的防火墙链
+func (s *TaskService) executeApplyFirewallTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	var taskReq adminModel.FirewallTaskRequest
+	if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+		global.APP_LOG.Error("解析防火墙任务数据失败",
+			zap.Uint("taskId", task.ID),
+			zap.String("taskData", task.TaskData),
+			zap.Error(err))
+		return fmt.Errorf("解析任务数据失败: %v", err)
+	}
+
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, taskReq.InstanceId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("实例不存在")
+		}
+		return fmt.Errorf("获取实例信息失败: %v", err)
+	}
+
+	// 验证实例所有权
+	if instance.UserID != task.UserID {
+		return fmt.Errorf("无权限操作此实例")
+	}
+
+	var providerInfo providerModel.Provider
+	if err := global.APP_DB.First(&providerInfo, instance.ProviderID).Error; err != nil {
+		return fmt.Errorf("获取Provider配置失败: %v", err)
+	}
+
+	s.updateTaskProgress(task.ID, 40, "正在应用防火墙规则...")
+
+	if err := s.applyInstanceFirewall(ctx, &instance, &providerInfo); err != nil {
+		return fmt.Errorf("应用防火墙规则失败: %v", err)
+	}
+
+	s.updateTaskProgress(task.ID, 100, "防火墙规则已生效")
+	return nil
+}
+
+// applyInstanceFirewall 在实例所在宿主机上按数据库中的规则重建防火墙链，staleInstanceIDs为需要一并清理的旧实例链
+func (s *TaskService) applyInstanceFirewall(ctx context.Context, instance *providerModel.Instance, providerInfo *providerModel.Provider, staleInstanceIDs ...uint) error {
+	var rules []providerModel.FirewallRule
+	if err := global.APP_DB.Where("instance_id = ?", instance.ID).Order("priority ASC, id ASC").Find(&rules).Error; err != nil {
+		return fmt.Errorf("获取防火墙规则失败: %v", err)
+	}
+
+	var commands []string
+	for _, staleID := range staleInstanceIDs {
+		commands = append(commands, iptables.FirewallRemoveCommands(staleID)...)
+	}
+	commands = append(commands, iptables.FirewallApplyCommands(instance, rules)...)
+	return iptables.ExecuteHostCommands(ctx, instance, providerInfo, commands)
+}
+
+// reapplyInstanceFirewall 实例启动、重启、重置或迁移后重新应用防火墙规则，实例没有规则时跳过，失败只记录警告
+func (s *TaskService) reapplyInstanceFirewall(ctx context.Context, instance *providerModel.Instance, providerInfo *providerModel.Provider, staleInstanceIDs ...uint) {
+	if !hasFirewallRules(instance.ID) {
+		return
+	}
+
+	if err := s.applyInstanceFirewall(ctx, instance, providerInfo, staleInstanceIDs...); err != nil {
+		global.APP_LOG.Warn("重新应用防火墙规则失败",
+			zap.Uint("instanceId", instance.ID),
+			zap.String("instanceName", instance.Name),
+			zap.Error(err))
+	}
+}
+
+// removeInstanceFirewall 从宿主机移除实例的防火墙链，实例没有规则时跳过，失败只记录警告
+func (s *TaskService) removeInstanceFirewall(ctx context.Context, instance *providerModel.Instance, providerInfo *providerModel.Provider) {
+	if !hasFirewallRules(instance.ID) {
+		return
+	}
+
+	if err := iptables.ExecuteHostCommands(ctx, instance, providerInfo, iptables.FirewallRemoveCommands(instance.ID)); err != nil {
+		global.APP_LOG.Warn("移除实例防火墙规则失败",
+			zap.Uint("instanceId", instance.ID),
+			zap.String("instanceName", instance.Name),
+			zap.Error(err))
+	}
+}
+
+// hasFirewallRules 实例是否配置了防火墙规则
+func hasFirewallRules(instanceID uint) bool {
+	var count int64
+	if err := global.APP_DB.Model(&providerModel.FirewallRule{}).Where("instance_id = ?", instanceID).Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
@@ -7,6 +7,8 @@ import (
 
 	"oneclickvirt/global"
 	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	provider2 "oneclickvirt/service/provider"
 	"oneclickvirt/utils"
 )
 
@@ -89,8 +91,44 @@ func (s *TaskService) executeTaskLogic(ctx context.Context, task *adminModel.Tas
 		return s.executeDeleteInstanceTask(ctx, task)
 	case "reset":
 		return s.executeResetInstanceTask(ctx, task)
+	case "reinstall":
+		return s.executeReinstallTask(ctx, task)
 	case "reset-password":
 		return s.executeResetPasswordTask(ctx, task)
+	case "resize":
+		return s.executeResizeTask(ctx, task)
+	case "migrate":
+		return s.executeMigrateTask(ctx, task)
+	case "create-snapshot":
+		return s.executeCreateSnapshotTask(ctx, task)
+	case "restore-snapshot":
+		return s.executeRestoreSnapshotTask(ctx, task)
+	case "delete-snapshot":
+		return s.executeDeleteSnapshotTask(ctx, task)
+	case "create-backup":
+		return s.executeCreateBackupTask(ctx, task)
+	case "restore-backup":
+		return s.executeRestoreBackupTask(ctx, task)
+	case "create-volume":
+		return s.executeCreateVolumeTask(ctx, task)
+	case "attach-volume":
+		return s.executeAttachVolumeTask(ctx, task)
+	case "detach-volume":
+		return s.executeDetachVolumeTask(ctx, task)
+	case "resize-volume":
+		return s.executeResizeVolumeTask(ctx, task)
+	case "delete-volume":
+		return s.executeDeleteVolumeTask(ctx, task)
+	case "create-private-network":
+		return s.executeCreatePrivateNetworkTask(ctx, task)
+	case "delete-private-network":
+		return s.executeDeletePrivateNetworkTask(ctx, task)
+	case "attach-private-network":
+		return s.executeAttachPrivateNetworkTask(ctx, task)
+	case "detach-private-network":
+		return s.executeDetachPrivateNetworkTask(ctx, task)
+	case "apply-firewall":
+		return s.executeApplyFirewallTask(ctx, task)
 	case "create-port-mapping":
 		return s.executeCreatePortMappingTask(ctx, task)
 	case "delete-port-mapping":
@@ -99,3 +137,12 @@ func (s *TaskService) executeTaskLogic(ctx context.Context, task *adminModel.Tas
 		return fmt.Errorf("未知的任务类型: %s", task.TaskType)
 	}
 }
+
+// requireProviderCapabilities 按数据库中保存的节点能力检查任务所需能力，节点尚未探测能力时不做限制
+func (s *TaskService) requireProviderCapabilities(providerID uint, required ...string) error {
+	var dbProvider providerModel.Provider
+	if err := global.APP_DB.First(&dbProvider, providerID).Error; err != nil {
+		return fmt.Errorf("获取Provider信息失败: %v", err)
+	}
+	return provider2.RequireCapabilities(&dbProvider, required...)
+}
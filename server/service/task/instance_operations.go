@@ -95,6 +95,9 @@ func (s *TaskService) executeStartInstanceTask(ctx context.Context, task *adminM
 		return fmt.Errorf("更新实例状态失败: %v", err)
 	}
 
+	// 实例网络重建后重新应用防火墙规则
+	s.reapplyInstanceFirewall(ctx, &instance, &provider)
+
 	// 更新进度 (90%)
 	s.updateTaskProgress(task.ID, 90, "正在初始化监控服务...")
 
@@ -391,6 +394,9 @@ func (s *TaskService) executeRestartInstanceTask(ctx context.Context, task *admi
 		return fmt.Errorf("更新实例状态失败: %v", err)
 	}
 
+	// 实例网络重建后重新应用防火墙规则
+	s.reapplyInstanceFirewall(ctx, &instance, &provider)
+
 	// 更新进度 (80%)
 	s.updateTaskProgress(task.ID, 80, "正在重新初始化监控服务...")
 
@@ -32,7 +32,7 @@ func (s *TaskService) calculateEstimatedDuration(taskType string, instanceType s
 			return 300 // 5分钟 - VM创建较慢
 		}
 		return 180 // 3分钟 - 容器创建较快
-	case "reset":
+	case "reset", "reinstall":
 		if instanceType == "vm" {
 			return 450 // 7.5分钟 - VM重置 (创建的1.5倍)
 		}
@@ -56,6 +56,33 @@ func (s *TaskService) calculateEstimatedDuration(taskType string, instanceType s
 		return 300 // 5分钟 - 删除操作
 	case "reset-password":
 		return 30 // 30秒 - 密码重置操作快
+	case "resize":
+		return 60 // 1分钟 - 调整配置，磁盘扩容可能较慢
+	case "migrate":
+		if instanceType == "vm" {
+			return 1800 // 30分钟 - VM迁移需要传输完整磁盘
+		}
+		return 600 // 10分钟 - 容器迁移
+	case "create-snapshot", "restore-snapshot":
+		if instanceType == "vm" {
+			return 180 // 3分钟 - VM快照涉及磁盘和内存状态
+		}
+		return 60 // 1分钟 - 容器快照
+	case "delete-snapshot":
+		return 30 // 30秒 - 删除快照操作快
+	case "create-backup", "restore-backup":
+		if instanceType == "vm" {
+			return 1200 // 20分钟 - VM备份需要导出并传输完整磁盘
+		}
+		return 300 // 5分钟 - 容器备份
+	case "attach-volume", "detach-volume":
+		return 60 // 1分钟 - Docker需要重建容器
+	case "create-volume", "resize-volume", "delete-volume":
+		return 30 // 30秒 - 存储卷操作快
+	case "create-private-network", "delete-private-network", "attach-private-network", "detach-private-network":
+		return 30 // 30秒 - 网络配置操作快
+	case "apply-firewall":
+		return 15 // 15秒 - 只在宿主机上执行iptables命令
 	default:
 		return 120 // 默认2分钟 - 保守估计
 	}
@@ -0,0 +1,802 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	resourceModel "oneclickvirt/model/resource"
+	"oneclickvirt/provider"
+	"oneclickvirt/provider/incus"
+	"oneclickvirt/provider/libvirt"
+	"oneclickvirt/provider/lxd"
+	"oneclickvirt/provider/portmapping"
+	"oneclickvirt/provider/portmapping/iptables"
+	"oneclickvirt/provider/portmapping/nftables"
+	"oneclickvirt/provider/proxmox"
+	traffic_monitor "oneclickvirt/service/admin/traffic_monitor"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/service/traffic"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MigrateTaskContext 迁移任务上下文
+type MigrateTaskContext struct {
+	Instance        providerModel.Instance
+	SourceProvider  providerModel.Provider
+	TargetProvider  providerModel.Provider
+	OldPortMappings []providerModel.Port
+	SourcePrivateIP string // 实例在源节点上的内网IP，用于清理源节点的端口转发规则
+	OriginalStatus  string
+	NewPrivateIP    string
+	TargetNode      string // 集群Provider中实例导入后所在的节点，非集群时为空
+	Reserved        bool   // 已在目标节点占用资源
+	Imported        bool   // 已开始向目标节点导入实例，回滚时需要清理
+}
+
+// executeMigrateTask 执行实例迁移任务
+// 数据库记录在目标节点启动成功后才切换，此前任一阶段失败都回滚到源节点
+func (s *TaskService) executeMigrateTask(ctx context.Context, task *adminModel.Task) error {
+	var taskReq adminModel.MigrateTaskRequest
+	if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+		return fmt.Errorf("解析任务数据失败: %v", err)
+	}
+
+	migrateCtx := MigrateTaskContext{OriginalStatus: taskReq.OriginalStatus}
+	if migrateCtx.OriginalStatus == "" {
+		migrateCtx.OriginalStatus = "running"
+	}
+
+	// 阶段1: 准备阶段
+	if err := s.migrateTask_Prepare(ctx, task, &taskReq, &migrateCtx); err != nil {
+		global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", taskReq.InstanceId).Update("status", migrateCtx.OriginalStatus)
+		return err
+	}
+
+	// 阶段2-5: 占用目标节点资源、停止实例、传输归档、在目标节点启动（失败时回滚）
+	stages := []func(context.Context, *adminModel.Task, *MigrateTaskContext) error{
+		s.migrateTask_ReserveTarget,
+		s.migrateTask_StopInstance,
+		s.migrateTask_TransferInstance,
+		s.migrateTask_StartOnTarget,
+	}
+	for _, stage := range stages {
+		if err := stage(ctx, task, &migrateCtx); err != nil {
+			s.migrateTask_Rollback(&migrateCtx)
+			return err
+		}
+	}
+
+	// 阶段6: 切换数据库记录到目标节点（短事务）
+	if err := s.migrateTask_SwitchRecords(ctx, task, &migrateCtx); err != nil {
+		s.migrateTask_Rollback(&migrateCtx)
+		return err
+	}
+
+	// 阶段7: 删除源节点上的实例（失败只记录警告）
+	s.migrateTask_DeleteSourceInstance(ctx, task, &migrateCtx)
+
+	// 阶段8: 在目标节点重建端口映射
+	if err := s.migrateTask_RestorePortMappings(ctx, task, &migrateCtx); err != nil {
+		return err
+	}
+
+	// 阶段9: 在目标节点重新分配IPv6（失败只记录警告）
+	s.migrateTask_AllocateIPv6(ctx, task, &migrateCtx)
+
+	// 阶段10: 在目标节点重新应用防火墙规则
+	s.migrateTask_ReapplyFirewall(ctx, task, &migrateCtx)
+
+	// 阶段11: 在目标节点重新初始化监控
+	s.migrateTask_ReinitializeMonitoring(ctx, task, &migrateCtx)
+
+	s.updateTaskProgress(task.ID, 100, "迁移完成")
+
+	global.APP_LOG.Info("实例迁移成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("instanceId", migrateCtx.Instance.ID),
+		zap.String("instanceName", migrateCtx.Instance.Name),
+		zap.String("sourceProvider", migrateCtx.SourceProvider.Name),
+		zap.String("targetProvider", migrateCtx.TargetProvider.Name))
+
+	return nil
+}
+
+// migrateTask_Prepare 阶段1: 查询实例、源节点和目标节点信息
+func (s *TaskService) migrateTask_Prepare(ctx context.Context, task *adminModel.Task, taskReq *adminModel.MigrateTaskRequest, migrateCtx *MigrateTaskContext) error {
+	s.updateTaskProgress(task.ID, 5, "正在准备迁移...")
+
+	err := s.dbService.ExecuteQuery(ctx, func() error {
+		if err := global.APP_DB.First(&migrateCtx.Instance, taskReq.InstanceId).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("实例不存在")
+			}
+			return fmt.Errorf("获取实例信息失败: %v", err)
+		}
+		if migrateCtx.Instance.UserID != task.UserID {
+			return fmt.Errorf("无权限操作此实例")
+		}
+		if migrateCtx.Instance.ProviderID != taskReq.SourceProviderId {
+			return fmt.Errorf("实例所在节点已变化，请重新发起迁移")
+		}
+		if err := global.APP_DB.First(&migrateCtx.SourceProvider, taskReq.SourceProviderId).Error; err != nil {
+			return fmt.Errorf("获取源节点信息失败: %v", err)
+		}
+		if err := global.APP_DB.First(&migrateCtx.TargetProvider, taskReq.TargetProviderId).Error; err != nil {
+			return fmt.Errorf("获取目标节点信息失败: %v", err)
+		}
+		return global.APP_DB.Where("instance_id = ? AND status = 'active'", migrateCtx.Instance.ID).Find(&migrateCtx.OldPortMappings).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if migrateCtx.SourceProvider.Type != migrateCtx.TargetProvider.Type {
+		return fmt.Errorf("只能迁移到相同类型的节点")
+	}
+	migrateCtx.SourcePrivateIP = migrateCtx.Instance.PrivateIP
+
+	// 任务排队期间可能挂载了数据卷，数据卷位于源节点的存储池中，不随实例归档迁移
+	if hasVolumes, err := resources.InstanceHasVolumes(global.APP_DB, migrateCtx.Instance.ID); err != nil {
+		return fmt.Errorf("检查实例数据卷失败: %v", err)
+	} else if hasVolumes {
+		return fmt.Errorf("实例挂载了数据卷，请先卸载数据卷后再迁移")
+	}
+	if hasNetworks, err := resources.InstanceHasPrivateNetworks(global.APP_DB, migrateCtx.Instance.ID); err != nil {
+		return fmt.Errorf("检查实例私有网络失败: %v", err)
+	} else if hasNetworks {
+		return fmt.Errorf("实例已接入私有网络，请先断开私有网络后再迁移")
+	}
+
+	// 在任何SSH操作之前按节点能力拒绝迁移
+	if err := provider2.RequireCapabilities(&migrateCtx.SourceProvider, providerModel.CapabilityMigration); err != nil {
+		return err
+	}
+	if err := provider2.RequireCapabilities(&migrateCtx.TargetProvider, providerModel.CapabilityMigration); err != nil {
+		return err
+	}
+	return provider2.RequireInstanceType(&migrateCtx.TargetProvider, migrateCtx.Instance.InstanceType)
+}
+
+// migrateTask_ReserveTarget 阶段2: 在事务中检查并占用目标节点资源
+func (s *TaskService) migrateTask_ReserveTarget(ctx context.Context, task *adminModel.Task, migrateCtx *MigrateTaskContext) error {
+	s.updateTaskProgress(task.ID, 10, "正在检查目标节点资源...")
+
+	instance := migrateCtx.Instance
+	err := s.dbService.ExecuteTransaction(ctx, func(tx *gorm.DB) error {
+		resourceService := &resources.ResourceService{}
+		result, err := resourceService.CheckProviderResourcesWithTx(tx, resourceModel.ResourceCheckRequest{
+			ProviderID:   migrateCtx.TargetProvider.ID,
+			InstanceType: instance.InstanceType,
+			CPU:          instance.CPU,
+			Memory:       instance.Memory,
+			Disk:         instance.Disk,
+		})
+		if err != nil {
+			return fmt.Errorf("检查目标节点资源失败: %v", err)
+		}
+		if !result.Allowed {
+			return fmt.Errorf("目标节点资源不足: %s", result.Reason)
+		}
+		return resourceService.AllocateResourcesInTx(tx, migrateCtx.TargetProvider.ID, instance.InstanceType,
+			instance.CPU, instance.Memory, instance.Disk)
+	})
+	if err != nil {
+		return err
+	}
+
+	migrateCtx.Reserved = true
+	return nil
+}
+
+// migrateTask_StopInstance 阶段3: 同步最终流量数据，移除源节点监控并停止实例
+func (s *TaskService) migrateTask_StopInstance(ctx context.Context, task *adminModel.Task, migrateCtx *MigrateTaskContext) error {
+	s.updateTaskProgress(task.ID, 15, "正在同步流量数据...")
+
+	syncTrigger := traffic.NewSyncTriggerService()
+	syncTrigger.TriggerInstanceTrafficSync(migrateCtx.Instance.ID, "实例迁移前最终同步")
+
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return fmt.Errorf("任务已取消")
+	}
+
+	// pmacct监控绑定在源节点上，迁移完成后在目标节点重新初始化
+	if err := traffic_monitor.GetManager().DetachMonitor(ctx, migrateCtx.Instance.ID); err != nil {
+		global.APP_LOG.Warn("清理源节点流量监控失败",
+			zap.Uint("instanceId", migrateCtx.Instance.ID),
+			zap.Error(err))
+	}
+
+	if migrateCtx.OriginalStatus != "running" {
+		return nil
+	}
+
+	s.updateTaskProgress(task.ID, 18, "正在停止实例...")
+
+	providerApiService := &provider2.ProviderApiService{}
+	prov, _, err := providerApiService.GetProviderByID(migrateCtx.SourceProvider.ID)
+	if err != nil {
+		return fmt.Errorf("获取源节点失败: %v", err)
+	}
+	if err := prov.StopInstance(ctx, migrateCtx.Instance.Name); err != nil {
+		return fmt.Errorf("停止实例失败: %v", err)
+	}
+	return nil
+}
+
+// migrateTask_TransferInstance 阶段4: 从源节点导出实例并流式导入目标节点
+func (s *TaskService) migrateTask_TransferInstance(ctx context.Context, task *adminModel.Task, migrateCtx *MigrateTaskContext) error {
+	s.updateTaskProgress(task.ID, 20, "正在导出实例...")
+
+	providerApiService := &provider2.ProviderApiService{}
+	sourceProv, _, err := providerApiService.GetProviderByID(migrateCtx.SourceProvider.ID)
+	if err != nil {
+		return fmt.Errorf("获取源节点失败: %v", err)
+	}
+	targetProv, _, err := providerApiService.GetProviderByID(migrateCtx.TargetProvider.ID)
+	if err != nil {
+		return fmt.Errorf("获取目标节点失败: %v", err)
+	}
+
+	// 目标节点上已有同名实例时导入会失败，回滚时也不能删除它
+	if _, err := targetProv.GetInstance(ctx, migrateCtx.Instance.Name); err == nil {
+		return fmt.Errorf("目标节点上已存在同名实例")
+	}
+	migrateCtx.Imported = true
+
+	// 导出和导入并发进行，进度只前进不后退
+	// 导出阶段映射到20%-40%，导入阶段映射到40%-80%
+	progress := newMonotonicProgress(func(percentage int, message string) {
+		s.updateTaskProgress(task.ID, percentage, message)
+	})
+
+	instanceName := migrateCtx.Instance.Name
+	reader, writer := io.Pipe()
+	exportDone := make(chan error, 1)
+	go func() {
+		err := sourceProv.ExportInstance(ctx, instanceName, writer, func(percentage int, message string) {
+			progress.update(20+percentage*20/100, message)
+		})
+		writer.CloseWithError(err)
+		exportDone <- err
+	}()
+
+	importErr := targetProv.ImportInstance(ctx, instanceName, migrateCtx.Instance.InstanceType, reader, func(percentage int, message string) {
+		progress.update(40+percentage*40/100, message)
+	})
+	// 导入提前失败时关闭读端，避免导出端阻塞在写入上
+	reader.CloseWithError(importErr)
+	exportErr := <-exportDone
+
+	if exportErr != nil {
+		return fmt.Errorf("导出实例失败: %v", exportErr)
+	}
+	if importErr != nil {
+		return fmt.Errorf("导入实例失败: %v", importErr)
+	}
+	return nil
+}
+
+// migrateTask_StartOnTarget 阶段5: 在目标节点启动实例并获取新的内网IP
+func (s *TaskService) migrateTask_StartOnTarget(ctx context.Context, task *adminModel.Task, migrateCtx *MigrateTaskContext) error {
+	providerApiService := &provider2.ProviderApiService{}
+	prov, _, err := providerApiService.GetProviderByID(migrateCtx.TargetProvider.ID)
+	if err != nil {
+		return fmt.Errorf("获取目标节点失败: %v", err)
+	}
+
+	// 导入后需要启动才能获取内网IP和配置端口映射，原本停止的实例在端口映射完成后不再保持运行
+	s.updateTaskProgress(task.ID, 82, "正在目标节点启动实例...")
+	if err := prov.StartInstance(ctx, migrateCtx.Instance.Name); err != nil {
+		return fmt.Errorf("在目标节点启动实例失败: %v", err)
+	}
+
+	timer := time.NewTimer(10 * time.Second)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return fmt.Errorf("任务已取消")
+	}
+
+	var ip string
+	switch migrateCtx.TargetProvider.Type {
+	case "lxd":
+		if lxdProv, ok := prov.(*lxd.LXDProvider); ok {
+			ip, err = lxdProv.GetInstanceIPv4(ctx, migrateCtx.Instance.Name)
+		}
+	case "incus":
+		if incusProv, ok := prov.(*incus.IncusProvider); ok {
+			ip, err = incusProv.GetInstanceIPv4(ctx, migrateCtx.Instance.Name)
+		}
+	case "proxmox":
+		if proxmoxProv, ok := prov.(*proxmox.ProxmoxProvider); ok {
+			ip, err = proxmoxProv.GetInstanceIPv4(ctx, migrateCtx.Instance.Name)
+		}
+	case "libvirt":
+		if libvirtProv, ok := prov.(*libvirt.LibvirtProvider); ok {
+			ip, err = libvirtProv.GetInstanceIPv4(ctx, migrateCtx.Instance.Name)
+		}
+	}
+	if err != nil {
+		global.APP_LOG.Warn("获取迁移后实例内网IP失败",
+			zap.Uint("instanceId", migrateCtx.Instance.ID),
+			zap.Error(err))
+	}
+	migrateCtx.NewPrivateIP = ip
+
+	// 归档导入在目标Provider的连接节点上执行，集群中记录该节点供后续操作定位实例
+	if clusterProvider, ok := prov.(provider.ClusterProvider); ok {
+		migrateCtx.TargetNode = connectedClusterNode(ctx, clusterProvider)
+	}
+	return nil
+}
+
+// connectedClusterNode 返回集群Provider的连接节点，单节点部署或查询失败时返回空字符串
+func connectedClusterNode(ctx context.Context, clusterProvider provider.ClusterProvider) string {
+	nodes, err := clusterProvider.ListClusterNodes(ctx)
+	if err != nil {
+		global.APP_LOG.Warn("获取目标集群节点失败", zap.Error(err))
+		return ""
+	}
+	if len(nodes) <= 1 {
+		return ""
+	}
+	for _, node := range nodes {
+		if node.Local {
+			return node.Name
+		}
+	}
+	return ""
+}
+
+// migrateTask_SwitchRecords 阶段6: 在同一事务中切换实例所属节点、资源计数和快照记录
+func (s *TaskService) migrateTask_SwitchRecords(ctx context.Context, task *adminModel.Task, migrateCtx *MigrateTaskContext) error {
+	s.updateTaskProgress(task.ID, 86, "正在更新实例信息...")
+
+	instance := migrateCtx.Instance
+	target := migrateCtx.TargetProvider
+	err := s.dbService.ExecuteTransaction(ctx, func(tx *gorm.DB) error {
+		// 源节点端口记录删除后由阶段8按目标节点重新创建
+		portMappingService := &resources.PortMappingService{}
+		if err := portMappingService.DeleteInstancePortMappingsInTx(tx, instance.ID); err != nil {
+			return err
+		}
+
+		resourceService := &resources.ResourceService{}
+		if err := resourceService.ReleaseResourcesInTx(tx, migrateCtx.SourceProvider.ID, instance.InstanceType,
+			instance.CPU, instance.Memory, instance.Disk); err != nil {
+			return err
+		}
+
+		// LXD/Incus 的导出归档包含快照；vzdump 和 libvirt 归档不包含快照，对应记录随源实例删除
+		if target.Type == "proxmox" || target.Type == "libvirt" {
+			if err := tx.Where("instance_id = ?", instance.ID).Delete(&providerModel.InstanceSnapshot{}).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Model(&providerModel.InstanceSnapshot{}).Where("instance_id = ?", instance.ID).
+			Update("provider_id", target.ID).Error; err != nil {
+			return err
+		}
+
+		// IPv6地址属于源节点，迁移后不再有效，由阶段9在目标节点重新分配
+		updates := map[string]interface{}{
+			"provider_id":  target.ID,
+			"provider":     target.Name,
+			"public_ip":    providerPublicIP(target),
+			"ipv6_address": "",
+			"public_ipv6":  "",
+			"node":         migrateCtx.TargetNode,
+		}
+		if migrateCtx.NewPrivateIP != "" {
+			updates["private_ip"] = migrateCtx.NewPrivateIP
+		}
+		return tx.Model(&providerModel.Instance{}).Where("id = ?", instance.ID).Updates(updates).Error
+	})
+	if err != nil {
+		return fmt.Errorf("更新实例信息失败: %v", err)
+	}
+
+	// 之后的阶段使用目标节点上的实例信息
+	migrateCtx.Reserved = false
+	migrateCtx.Instance.ProviderID = target.ID
+	migrateCtx.Instance.Provider = target.Name
+	migrateCtx.Instance.Node = migrateCtx.TargetNode
+	if migrateCtx.NewPrivateIP != "" {
+		migrateCtx.Instance.PrivateIP = migrateCtx.NewPrivateIP
+	}
+	return nil
+}
+
+// migrateTask_DeleteSourceInstance 阶段7: 删除源节点上的实例，失败时需要管理员手动清理
+func (s *TaskService) migrateTask_DeleteSourceInstance(ctx context.Context, task *adminModel.Task, migrateCtx *MigrateTaskContext) {
+	s.updateTaskProgress(task.ID, 88, "正在删除源节点上的实例...")
+
+	// 实例记录已切换到目标节点，源节点的删除流程查不到端口记录，端口转发规则需要按迁移前的记录清理
+	s.removeSourcePortRules(ctx, migrateCtx)
+
+	providerApiService := &provider2.ProviderApiService{}
+	if err := providerApiService.DeleteInstanceByProviderID(ctx, migrateCtx.SourceProvider.ID, migrateCtx.Instance.Name); err != nil {
+		global.APP_LOG.Error("删除源节点实例失败，需要手动清理",
+			zap.Uint("taskId", task.ID),
+			zap.String("instanceName", migrateCtx.Instance.Name),
+			zap.String("sourceProvider", migrateCtx.SourceProvider.Name),
+			zap.Error(err))
+	}
+}
+
+// removeSourcePortRules 删除源节点宿主机上指向实例的iptables/nftables端口转发规则，失败只记录警告
+// LXD/Incus的device_proxy映射是实例上的设备，随源实例一起删除
+func (s *TaskService) removeSourcePortRules(ctx context.Context, migrateCtx *MigrateTaskContext) {
+	source := migrateCtx.SourceProvider
+	instanceIP := migrateCtx.SourcePrivateIP
+	if len(migrateCtx.OldPortMappings) == 0 || instanceIP == "" {
+		return
+	}
+
+	var commands []string
+	switch {
+	case source.IPv4PortMappingMethod == "nftables":
+		mappings := make([]nftables.Mapping, 0, len(migrateCtx.OldPortMappings))
+		for _, port := range migrateCtx.OldPortMappings {
+			mappings = append(mappings, nftables.Mapping{
+				Protocol:    port.Protocol,
+				HostPort:    port.HostPort,
+				HostPortEnd: port.HostPortEnd,
+				GuestPort:   port.GuestPort,
+				TargetIP:    instanceIP,
+			})
+		}
+		commands = nftables.RemoveCommands(mappings)
+	case source.Type == "proxmox" || source.Type == "libvirt" || source.IPv4PortMappingMethod == "iptables":
+		for _, port := range migrateCtx.OldPortMappings {
+			commands = append(commands, iptables.DeleteRuleCommands(port.Protocol, port.HostPort, port.GuestPort, instanceIP)...)
+		}
+		commands = append(commands, iptables.SaveRulesCommand)
+	default:
+		return
+	}
+
+	providerApiService := &provider2.ProviderApiService{}
+	prov, _, err := providerApiService.GetProviderByID(source.ID)
+	if err != nil {
+		global.APP_LOG.Warn("获取源节点失败，跳过端口转发规则清理", zap.Uint("instanceId", migrateCtx.Instance.ID), zap.Error(err))
+		return
+	}
+	for _, cmd := range commands {
+		// 规则可能已被手动删除，删除失败继续处理其余规则
+		if _, err := prov.ExecuteSSHCommand(ctx, cmd); err != nil {
+			global.APP_LOG.Debug("删除源节点端口转发规则失败", zap.String("command", cmd), zap.Error(err))
+		}
+	}
+}
+
+// migrateTask_RestorePortMappings 阶段8: 在目标节点重建端口映射，宿主机端口冲突时重新分配
+func (s *TaskService) migrateTask_RestorePortMappings(ctx context.Context, task *adminModel.Task, migrateCtx *MigrateTaskContext) error {
+	s.updateTaskProgress(task.ID, 90, "正在恢复端口映射...")
+
+	instance := migrateCtx.Instance
+	target := migrateCtx.TargetProvider
+
+	if len(migrateCtx.OldPortMappings) == 0 {
+		portMappingService := &resources.PortMappingService{}
+		if err := portMappingService.CreateDefaultPortMappings(instance.ID, target.ID); err != nil {
+			global.APP_LOG.Warn("创建默认端口映射失败", zap.Error(err))
+		}
+	} else {
+		ports := s.reallocateConflictingPorts(migrateCtx.OldPortMappings, target.ID)
+
+		manager := portmapping.NewManager(&portmapping.ManagerConfig{
+			DefaultMappingMethod: target.IPv4PortMappingMethod,
+		})
+		portMappingType := hostPortMappingType(target.Type, target.IPv4PortMappingMethod)
+
+		// 按协议分组
+		portsByProtocol := map[string][]providerModel.Port{}
+		for _, port := range ports {
+			portsByProtocol[port.Protocol] = append(portsByProtocol[port.Protocol], port)
+		}
+
+		successCount := 0
+		failCount := 0
+		for _, protocol := range []string{"tcp", "udp", "both"} {
+			if len(portsByProtocol[protocol]) == 0 {
+				continue
+			}
+			processed, failed := s.restorePortMappingsOptimized(ctx, portsByProtocol[protocol], instance, target, manager, portMappingType)
+			successCount += processed
+			failCount += failed
+		}
+
+		// LXD/Incus 的端口映射由实例上的proxy设备实现，导入时已清除源节点的设备，需要逐个重新创建
+		if target.Type == "lxd" || target.Type == "incus" {
+			s.applyInstanceProxyDevices(ctx, &migrateCtx.Instance, migrateCtx.TargetProvider)
+		}
+
+		global.APP_LOG.Info("端口映射恢复完成",
+			zap.Uint("instanceId", instance.ID),
+			zap.Int("成功", successCount),
+			zap.Int("失败", failCount))
+	}
+
+	// 更新SSH端口
+	s.dbService.ExecuteQuery(ctx, func() error {
+		var sshPort providerModel.Port
+		if err := global.APP_DB.Where("instance_id = ? AND is_ssh = true AND status = 'active'", instance.ID).First(&sshPort).Error; err == nil {
+			global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", instance.ID).Update("ssh_port", sshPort.HostPort)
+		} else {
+			global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", instance.ID).Update("ssh_port", 22)
+		}
+		return nil
+	})
+
+	// 原本停止的实例恢复停止状态
+	status := "running"
+	if migrateCtx.OriginalStatus != "running" {
+		providerApiService := &provider2.ProviderApiService{}
+		if prov, _, err := providerApiService.GetProviderByID(target.ID); err == nil {
+			if err := prov.StopInstance(ctx, instance.Name); err != nil {
+				global.APP_LOG.Warn("停止迁移后的实例失败", zap.Uint("instanceId", instance.ID), zap.Error(err))
+			} else {
+				status = migrateCtx.OriginalStatus
+			}
+		}
+	}
+	if err := global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", instance.ID).Update("status", status).Error; err != nil {
+		return fmt.Errorf("更新实例状态失败: %v", err)
+	}
+	return nil
+}
+
+// reallocateConflictingPorts 目标节点上已被占用的宿主机端口重新分配，返回调整后的端口列表
+func (s *TaskService) reallocateConflictingPorts(ports []providerModel.Port, targetProviderID uint) []providerModel.Port {
+	var usedPorts []int
+	global.APP_DB.Model(&providerModel.Port{}).
+		Where("provider_id = ? AND status = 'active'", targetProviderID).
+		Pluck("host_port", &usedPorts)
+
+	usedPortSet := make(map[int]bool, len(usedPorts))
+	for _, port := range usedPorts {
+		usedPortSet[port] = true
+	}
+	// 新分配的端口既不能与目标节点已用端口冲突，也不能与实例自身的其他端口冲突
+	reserved := make(map[int]bool, len(usedPorts)+len(ports))
+	for port := range usedPortSet {
+		reserved[port] = true
+	}
+	for _, port := range ports {
+		reserved[port.HostPort] = true
+	}
+
+	portMappingService := &resources.PortMappingService{}
+	result := make([]providerModel.Port, 0, len(ports))
+	for _, port := range ports {
+		if !usedPortSet[port.HostPort] {
+			result = append(result, port)
+			continue
+		}
+
+		newHostPort := 0
+		for attempt := 0; attempt < 10; attempt++ {
+			allocated, err := portMappingService.AllocateHostPort(targetProviderID)
+			if err != nil {
+				global.APP_LOG.Warn("分配目标节点端口失败", zap.Int("hostPort", port.HostPort), zap.Error(err))
+				break
+			}
+			if !reserved[allocated] {
+				newHostPort = allocated
+				break
+			}
+		}
+		if newHostPort == 0 {
+			// 无法分配时保留原端口，恢复时会标记为失败
+			result = append(result, port)
+			continue
+		}
+
+		global.APP_LOG.Info("目标节点端口冲突，重新分配宿主机端口",
+			zap.Int("oldHostPort", port.HostPort),
+			zap.Int("newHostPort", newHostPort),
+			zap.Int("guestPort", port.GuestPort))
+		reserved[newHostPort] = true
+		port.HostPort = newHostPort
+		port.IsAutomatic = true
+		result = append(result, port)
+	}
+	return result
+}
+
+// applyInstanceProxyDevices 按数据库中的端口记录为LXD/Incus实例重新创建proxy设备
+// 用于迁移或从归档恢复后，归档中的proxy设备已被清除的场景
+func (s *TaskService) applyInstanceProxyDevices(ctx context.Context, instance *providerModel.Instance, target providerModel.Provider) {
+	if instance.PrivateIP == "" {
+		global.APP_LOG.Warn("实例内网IP未知，跳过创建端口映射设备", zap.Uint("instanceId", instance.ID))
+		return
+	}
+
+	providerApiService := &provider2.ProviderApiService{}
+	prov, _, err := providerApiService.GetProviderByID(target.ID)
+	if err != nil {
+		global.APP_LOG.Warn("获取节点失败，跳过创建端口映射设备", zap.Error(err))
+		return
+	}
+
+	var ports []providerModel.Port
+	global.APP_DB.Where("instance_id = ? AND status = 'active'", instance.ID).Find(&ports)
+
+	for _, port := range ports {
+		switch p := prov.(type) {
+		case *lxd.LXDProvider:
+			err = p.SetupPortMappingWithIP(ctx, instance.Name, port.HostPort, port.GuestPort, port.Protocol, target.IPv4PortMappingMethod, instance.PrivateIP)
+		case *incus.IncusProvider:
+			err = p.SetupPortMappingWithIP(ctx, instance.Name, port.HostPort, port.GuestPort, port.Protocol, target.IPv4PortMappingMethod, instance.PrivateIP)
+		default:
+			return
+		}
+		if err != nil {
+			global.APP_LOG.Warn("创建端口映射设备失败",
+				zap.Uint("portId", port.ID),
+				zap.Int("hostPort", port.HostPort),
+				zap.Error(err))
+			global.APP_DB.Model(&port).Update("status", "failed")
+		}
+	}
+}
+
+// migrateTask_AllocateIPv6 阶段9: 在目标节点上为实例重新分配IPv6，目标节点未启用IPv6时保持为空
+func (s *TaskService) migrateTask_AllocateIPv6(ctx context.Context, task *adminModel.Task, migrateCtx *MigrateTaskContext) {
+	if !provider.NetworkTypeHasIPv6(migrateCtx.TargetProvider.NetworkType) {
+		return
+	}
+	providerApiService := &provider2.ProviderApiService{}
+	prov, _, err := providerApiService.GetProviderByID(migrateCtx.TargetProvider.ID)
+	if err != nil {
+		global.APP_LOG.Warn("获取目标节点失败，跳过IPv6分配", zap.Uint("instanceId", migrateCtx.Instance.ID), zap.Error(err))
+		return
+	}
+	allocator, ok := prov.(provider.IPv6Allocator)
+	if !ok {
+		return
+	}
+
+	s.updateTaskProgress(task.ID, 93, "正在分配IPv6地址...")
+	ipv6Address, publicIPv6, err := allocator.AllocateInstanceIPv6(ctx, migrateCtx.Instance.Name)
+	if err != nil {
+		global.APP_LOG.Warn("在目标节点分配IPv6失败", zap.Uint("instanceId", migrateCtx.Instance.ID), zap.Error(err))
+		return
+	}
+	if err := global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", migrateCtx.Instance.ID).Updates(map[string]interface{}{
+		"ipv6_address": ipv6Address,
+		"public_ipv6":  publicIPv6,
+	}).Error; err != nil {
+		global.APP_LOG.Warn("保存迁移后的IPv6地址失败", zap.Uint("instanceId", migrateCtx.Instance.ID), zap.Error(err))
+		return
+	}
+	migrateCtx.Instance.IPv6Address = ipv6Address
+	migrateCtx.Instance.PublicIPv6 = publicIPv6
+}
+
+// migrateTask_ReapplyFirewall 阶段10: 移除源节点上的防火墙链并在目标节点重新应用防火墙规则
+func (s *TaskService) migrateTask_ReapplyFirewall(ctx context.Context, task *adminModel.Task, migrateCtx *MigrateTaskContext) {
+	if !hasFirewallRules(migrateCtx.Instance.ID) {
+		return
+	}
+
+	s.updateTaskProgress(task.ID, 94, "正在重新应用防火墙规则...")
+	s.removeInstanceFirewall(ctx, &migrateCtx.Instance, &migrateCtx.SourceProvider)
+	s.reapplyInstanceFirewall(ctx, &migrateCtx.Instance, &migrateCtx.TargetProvider)
+}
+
+// migrateTask_ReinitializeMonitoring 阶段11: 在目标节点重新初始化pmacct监控
+func (s *TaskService) migrateTask_ReinitializeMonitoring(ctx context.Context, task *adminModel.Task, migrateCtx *MigrateTaskContext) {
+	if !migrateCtx.TargetProvider.EnableTrafficControl {
+		return
+	}
+
+	s.updateTaskProgress(task.ID, 96, "正在重新初始化监控...")
+	if err := traffic_monitor.GetManager().AttachMonitor(ctx, migrateCtx.Instance.ID); err != nil {
+		global.APP_LOG.Warn("在目标节点初始化流量监控失败",
+			zap.Uint("instanceId", migrateCtx.Instance.ID),
+			zap.Error(err))
+	}
+}
+
+// migrateTask_Rollback 迁移失败时清理目标节点并恢复源节点上的实例
+func (s *TaskService) migrateTask_Rollback(migrateCtx *MigrateTaskContext) {
+	// 任务上下文可能已取消，回滚使用独立的超时上下文
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	instance := migrateCtx.Instance
+	providerApiService := &provider2.ProviderApiService{}
+
+	if migrateCtx.Imported {
+		if err := providerApiService.DeleteInstanceByProviderID(ctx, migrateCtx.TargetProvider.ID, instance.Name); err != nil {
+			global.APP_LOG.Warn("清理目标节点上的实例失败",
+				zap.String("instanceName", instance.Name),
+				zap.String("targetProvider", migrateCtx.TargetProvider.Name),
+				zap.Error(err))
+		}
+	}
+
+	if migrateCtx.Reserved {
+		resourceService := &resources.ResourceService{}
+		if err := global.APP_DB.Transaction(func(tx *gorm.DB) error {
+			return resourceService.ReleaseResourcesInTx(tx, migrateCtx.TargetProvider.ID, instance.InstanceType,
+				instance.CPU, instance.Memory, instance.Disk)
+		}); err != nil {
+			global.APP_LOG.Error("释放目标节点资源失败",
+				zap.Uint("instanceId", instance.ID),
+				zap.Error(err))
+		}
+	}
+
+	if migrateCtx.OriginalStatus == "running" {
+		if prov, _, err := providerApiService.GetProviderByID(migrateCtx.SourceProvider.ID); err == nil {
+			if err := prov.StartInstance(ctx, instance.Name); err != nil {
+				global.APP_LOG.Error("迁移失败后启动源节点实例失败",
+					zap.Uint("instanceId", instance.ID),
+					zap.Error(err))
+			}
+		}
+	}
+
+	if err := traffic_monitor.GetManager().AttachMonitor(ctx, instance.ID); err != nil {
+		global.APP_LOG.Warn("迁移失败后恢复源节点流量监控失败",
+			zap.Uint("instanceId", instance.ID),
+			zap.Error(err))
+	}
+
+	global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", instance.ID).Update("status", migrateCtx.OriginalStatus)
+}
+
+// providerPublicIP 获取节点对外的IP地址，优先使用端口映射专用IP
+func providerPublicIP(p providerModel.Provider) string {
+	source := p.PortIP
+	if source == "" {
+		source = p.Endpoint
+	}
+	if colonIndex := strings.LastIndex(source, ":"); colonIndex > 0 {
+		if strings.Count(source, ":") > 1 && !strings.HasPrefix(source, "[") {
+			return source // IPv6格式
+		}
+		return source[:colonIndex] // IPv4格式，移除端口
+	}
+	return source
+}
+
+// monotonicProgress 合并多个并发来源的进度，保证上报的进度不回退
+type monotonicProgress struct {
+	mu      sync.Mutex
+	current int
+	report  func(percentage int, message string)
+}
+
+func newMonotonicProgress(report func(percentage int, message string)) *monotonicProgress {
+	return &monotonicProgress{report: report}
+}
+
+func (p *monotonicProgress) update(percentage int, message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if percentage <= p.current {
+		return
+	}
+	p.current = percentage
+	p.report(percentage, message)
+}
@@ -0,0 +1,139 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	mockProvider "oneclickvirt/provider/mock"
+	"oneclickvirt/provider/portmapping"
+	_ "oneclickvirt/provider/portmapping/mock"
+	"oneclickvirt/service/database"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/resources"
+)
+
+// TestMockFlow_CreatePortMappingDeleteTask 在模拟节点和SQLite上走完创建→端口映射→删除任务的流程，
+// 检查删除任务清理了节点上的实例和端口规则、附属记录并释放了节点资源
+func TestMockFlow_CreatePortMappingDeleteTask(t *testing.T) {
+	mockProvider.SetupTestDB(t,
+		&providerModel.Provider{}, &providerModel.Instance{}, &providerModel.Port{}, &adminModel.Task{},
+		&providerModel.InstanceSnapshot{}, &providerModel.InstanceBackupPolicy{}, &providerModel.InstanceBackup{},
+		&providerModel.Volume{}, &providerModel.PrivateNetworkAttachment{}, &providerModel.FirewallRule{})
+	ctx := context.Background()
+
+	dbProvider := providerModel.Provider{
+		Name: "mock-task-node", Type: "mock", Endpoint: "192.0.2.20", Status: "active",
+		ContainerEnabled: true, ContainerLimitCPU: true, ContainerLimitMemory: true, ContainerLimitDisk: true,
+		NodeCPUCores: 8, NodeMemoryTotal: 16384, NodeDiskTotal: 102400,
+		PortRangeStart: 21000, PortRangeEnd: 21010,
+	}
+	if err := global.APP_DB.Create(&dbProvider).Error; err != nil {
+		t.Fatalf("创建Provider记录失败: %v", err)
+	}
+
+	prov, _, err := (&provider2.ProviderApiService{}).GetProviderByID(dbProvider.ID)
+	if err != nil {
+		t.Fatalf("加载模拟Provider失败: %v", err)
+	}
+	if err := prov.CreateInstance(ctx, provider.InstanceConfig{Name: "ct1", Image: "debian12", CPU: "2", Memory: "1024", Disk: "10240"}); err != nil {
+		t.Fatalf("创建实例失败: %v", err)
+	}
+	remote, err := prov.GetInstance(ctx, "ct1")
+	if err != nil {
+		t.Fatalf("查询实例失败: %v", err)
+	}
+
+	instance := providerModel.Instance{
+		Name: "ct1", ProviderID: dbProvider.ID, UserID: 1, InstanceType: "container", Status: "running",
+		CPU: 2, Memory: 1024, Disk: 10240, PrivateIP: remote.PrivateIP,
+	}
+	if err := global.APP_DB.Create(&instance).Error; err != nil {
+		t.Fatalf("创建实例记录失败: %v", err)
+	}
+	if err := (&resources.ResourceService{}).AllocateResourcesInTx(global.APP_DB, dbProvider.ID, "container", 2, 1024, 10240); err != nil {
+		t.Fatalf("分配节点资源失败: %v", err)
+	}
+
+	manager := portmapping.CreateManagerWithAllProviders(nil)
+	if _, err := manager.CreatePortMapping(ctx, "mock", &portmapping.PortMappingRequest{
+		InstanceID: strconv.FormatUint(uint64(instance.ID), 10),
+		ProviderID: dbProvider.ID,
+		Protocol:   "tcp",
+		GuestPort:  22,
+	}); err != nil {
+		t.Fatalf("创建端口映射失败: %v", err)
+	}
+	node := mockProvider.GetNode("mock-task-node")
+	if len(node.PortMappings("ct1")) != 1 {
+		t.Fatalf("节点端口规则不正确: %+v", node.PortMappings("ct1"))
+	}
+
+	volume := providerModel.Volume{Name: "data", ProviderID: dbProvider.ID, UserID: 1, InstanceID: instance.ID, SizeMB: 1024, Device: "disk1", Status: providerModel.VolumeStatusInUse}
+	rule := providerModel.FirewallRule{InstanceID: instance.ID, Action: "allow", Protocol: "tcp", PortStart: 22, PortEnd: 22}
+	if err := global.APP_DB.Create(&volume).Error; err != nil {
+		t.Fatalf("创建数据卷记录失败: %v", err)
+	}
+	if err := global.APP_DB.Create(&rule).Error; err != nil {
+		t.Fatalf("创建防火墙规则失败: %v", err)
+	}
+
+	taskData, _ := json.Marshal(adminModel.DeleteInstanceTaskRequest{InstanceId: instance.ID, ProviderId: dbProvider.ID})
+	task := adminModel.Task{TaskType: "delete", Status: "running", UserID: 1, ProviderID: &dbProvider.ID, InstanceID: &instance.ID, TaskData: string(taskData)}
+	if err := global.APP_DB.Create(&task).Error; err != nil {
+		t.Fatalf("创建任务失败: %v", err)
+	}
+	s := &TaskService{dbService: database.GetDatabaseService()}
+	InitTaskStateManager(s)
+	if err := s.executeDeleteInstanceTask(ctx, &task); err != nil {
+		t.Fatalf("执行删除任务失败: %v", err)
+	}
+	global.APP_DB.First(&task, task.ID)
+	if task.Status != "completed" {
+		t.Errorf("任务状态不正确: %s", task.Status)
+	}
+
+	if _, err := prov.GetInstance(ctx, "ct1"); err == nil {
+		t.Error("节点上的实例未删除")
+	}
+	if mappings := node.PortMappings("ct1"); len(mappings) != 0 {
+		t.Errorf("节点端口规则未删除: %+v", mappings)
+	}
+	firewallRemoved := false
+	for _, cmd := range node.ExecutedCommands() {
+		if strings.Contains(cmd, "OCV-FW-") {
+			firewallRemoved = true
+		}
+	}
+	if !firewallRemoved {
+		t.Error("未在节点上移除实例防火墙规则")
+	}
+
+	var count int64
+	global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", instance.ID).Count(&count)
+	if count != 0 {
+		t.Error("实例记录未删除")
+	}
+	global.APP_DB.Model(&providerModel.Port{}).Where("instance_id = ?", instance.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("端口映射记录未删除: %d", count)
+	}
+	global.APP_DB.Model(&providerModel.FirewallRule{}).Where("instance_id = ?", instance.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("防火墙规则未删除: %d", count)
+	}
+	global.APP_DB.First(&volume, volume.ID)
+	if volume.InstanceID != 0 || volume.Status != providerModel.VolumeStatusAvailable {
+		t.Errorf("数据卷未解除挂载: %+v", volume)
+	}
+	global.APP_DB.First(&dbProvider, dbProvider.ID)
+	if dbProvider.UsedCPUCores != 0 || dbProvider.ContainerUsedMemory != 0 || dbProvider.ContainerCount != 0 {
+		t.Errorf("节点资源未释放: cpu=%d memory=%d count=%d", dbProvider.UsedCPUCores, dbProvider.ContainerUsedMemory, dbProvider.ContainerCount)
+	}
+}
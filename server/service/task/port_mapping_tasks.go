@@ -161,10 +161,7 @@ func (s *TaskService) executeCreatePortMappingTask(ctx context.Context, task *ad
 	})
 
 	// 确定使用的 portmapping provider 类型
-	portMappingType := localProviderType
-	if portMappingType == "proxmox" {
-		portMappingType = "iptables"
-	}
+	portMappingType := hostPortMappingType(localProviderType, localIPv4PortMappingMethod)
 
 	portReq := &portmapping.PortMappingRequest{
 		InstanceID:    fmt.Sprintf("%d", instance.ID),
@@ -347,10 +344,7 @@ func (s *TaskService) executeDeletePortMappingTask(ctx context.Context, task *ad
 			DefaultMappingMethod: localIPv4PortMappingMethod,
 		})
 
-		portMappingType := localProviderType
-		if portMappingType == "proxmox" {
-			portMappingType = "iptables"
-		}
+		portMappingType := hostPortMappingType(localProviderType, localIPv4PortMappingMethod)
 
 		deleteReq := &portmapping.DeletePortMappingRequest{
 			ID:         port.ID,
@@ -438,3 +432,14 @@ func (s *TaskService) executeDeletePortMappingTask(ctx context.Context, task *ad
 
 	return nil
 }
+
+// hostPortMappingType 确定portmapping provider类型，Proxmox和libvirt的端口映射由宿主机防火墙管理，按Provider配置使用iptables或nftables
+func hostPortMappingType(providerType, ipv4PortMappingMethod string) string {
+	if providerType != "proxmox" && providerType != "libvirt" {
+		return providerType
+	}
+	if ipv4PortMappingMethod == "nftables" {
+		return "nftables"
+	}
+	return "iptables"
+}
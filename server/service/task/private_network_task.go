@@ -0,0 +1,307 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	provider2 "oneclickvirt/service/provider"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// loadPrivateNetworkTaskContext 解析私有网络任务数据并加载网络记录
+func (s *TaskService) loadPrivateNetworkTaskContext(task *adminModel.Task) (*adminModel.PrivateNetworkTaskRequest, *providerModel.PrivateNetwork, error) {
+	var taskReq adminModel.PrivateNetworkTaskRequest
+	if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+		global.APP_LOG.Error("解析私有网络任务数据失败",
+			zap.Uint("taskId", task.ID),
+			zap.String("taskType", task.TaskType),
+			zap.String("taskData", task.TaskData),
+			zap.Error(err))
+		return nil, nil, fmt.Errorf("解析任务数据失败: %v", err)
+	}
+
+	var network providerModel.PrivateNetwork
+	if err := global.APP_DB.First(&network, taskReq.NetworkId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, fmt.Errorf("私有网络不存在")
+		}
+		return nil, nil, fmt.Errorf("获取私有网络信息失败: %v", err)
+	}
+
+	// 验证私有网络所有权
+	if network.UserID != task.UserID {
+		return nil, nil, fmt.Errorf("无权限操作此私有网络")
+	}
+
+	return &taskReq, &network, nil
+}
+
+// privateNetworkSpec 根据网络和接入记录构造Provider操作参数
+func privateNetworkSpec(network *providerModel.PrivateNetwork, instance *providerModel.Instance, attachment *providerModel.PrivateNetworkAttachment) provider.PrivateNetworkSpec {
+	spec := provider.PrivateNetworkSpec{
+		Name:   network.ProviderNetworkName(),
+		CIDR:   network.CIDR,
+		VlanID: network.VlanID,
+	}
+	if instance != nil {
+		spec.Instance = instance.Name
+		spec.InstanceType = instance.InstanceType
+	}
+	if attachment != nil {
+		spec.Device = attachment.Device
+		spec.IPAddress = attachment.IPAddress
+	}
+	return spec
+}
+
+// executeCreatePrivateNetworkTask 执行创建私有网络任务
+func (s *TaskService) executeCreatePrivateNetworkTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	_, network, err := s.loadPrivateNetworkTaskContext(task)
+	if err != nil {
+		return err
+	}
+
+	s.updateTaskProgress(task.ID, 30, "正在创建私有网络...")
+
+	networkProvider, err := provider2.GetProviderService().GetPrivateNetworkProvider(network.ProviderID)
+	if err == nil {
+		err = networkProvider.CreatePrivateNetwork(ctx, privateNetworkSpec(network, nil, nil))
+	}
+	if err != nil {
+		global.APP_LOG.Error("创建私有网络失败",
+			zap.Uint("taskId", task.ID),
+			zap.Uint("networkId", network.ID),
+			zap.Error(err))
+		global.APP_DB.Model(network).Update("status", providerModel.PrivateNetworkStatusFailed)
+		return fmt.Errorf("创建私有网络失败: %v", err)
+	}
+
+	if err := global.APP_DB.Model(network).Update("status", providerModel.PrivateNetworkStatusActive).Error; err != nil {
+		return fmt.Errorf("更新私有网络状态失败: %v", err)
+	}
+
+	global.APP_LOG.Info("私有网络创建成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("networkId", network.ID),
+		zap.String("cidr", network.CIDR),
+		zap.Int("vlanId", network.VlanID))
+	return nil
+}
+
+// executeDeletePrivateNetworkTask 执行删除私有网络任务
+func (s *TaskService) executeDeletePrivateNetworkTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	_, network, err := s.loadPrivateNetworkTaskContext(task)
+	if err != nil {
+		return err
+	}
+
+	var attached int64
+	global.APP_DB.Model(&providerModel.PrivateNetworkAttachment{}).Where("network_id = ?", network.ID).Count(&attached)
+	if attached > 0 {
+		global.APP_DB.Model(network).Update("status", providerModel.PrivateNetworkStatusActive)
+		return fmt.Errorf("私有网络中仍有实例，请先断开")
+	}
+
+	s.updateTaskProgress(task.ID, 30, "正在删除私有网络...")
+
+	networkProvider, err := provider2.GetProviderService().GetPrivateNetworkProvider(network.ProviderID)
+	if err == nil {
+		err = networkProvider.DeletePrivateNetwork(ctx, privateNetworkSpec(network, nil, nil))
+	}
+	if err != nil {
+		global.APP_LOG.Error("删除私有网络失败",
+			zap.Uint("taskId", task.ID),
+			zap.Uint("networkId", network.ID),
+			zap.Error(err))
+		global.APP_DB.Model(network).Update("status", providerModel.PrivateNetworkStatusActive)
+		return fmt.Errorf("删除私有网络失败: %v", err)
+	}
+
+	if err := global.APP_DB.Delete(network).Error; err != nil {
+		return fmt.Errorf("删除私有网络记录失败: %v", err)
+	}
+
+	global.APP_LOG.Info("私有网络删除成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("networkId", network.ID),
+		zap.String("name", network.Name))
+	return nil
+}
+
+// executeAttachPrivateNetworkTask 执行实例接入私有网络任务
+func (s *TaskService) executeAttachPrivateNetworkTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	taskReq, network, err := s.loadPrivateNetworkTaskContext(task)
+	if err != nil {
+		return err
+	}
+	var attachment providerModel.PrivateNetworkAttachment
+	if err := global.APP_DB.First(&attachment, taskReq.AttachmentId).Error; err != nil {
+		return fmt.Errorf("获取接入记录失败: %v", err)
+	}
+
+	s.updateTaskProgress(task.ID, 30, "正在接入私有网络...")
+
+	networkProvider, err := provider2.GetProviderService().GetPrivateNetworkProvider(network.ProviderID)
+	if err == nil {
+		err = s.attachPrivateNetwork(ctx, networkProvider, network, &attachment)
+	} else {
+		global.APP_DB.Delete(&attachment)
+	}
+	if err != nil {
+		return fmt.Errorf("接入私有网络失败: %v", err)
+	}
+	return nil
+}
+
+// attachPrivateNetwork 为实例添加私有网络网卡并更新接入记录，失败时删除接入记录释放地址
+func (s *TaskService) attachPrivateNetwork(ctx context.Context, networkProvider provider.PrivateNetworkProvider, network *providerModel.PrivateNetwork, attachment *providerModel.PrivateNetworkAttachment) error {
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, attachment.InstanceID).Error; err != nil {
+		global.APP_DB.Delete(attachment)
+		return fmt.Errorf("获取实例信息失败: %v", err)
+	}
+
+	device, err := networkProvider.AttachPrivateNetwork(ctx, privateNetworkSpec(network, &instance, attachment))
+	if err != nil {
+		global.APP_LOG.Error("接入私有网络失败",
+			zap.Uint("networkId", network.ID),
+			zap.Uint("instanceId", instance.ID),
+			zap.Error(err))
+		global.APP_DB.Delete(attachment)
+		return err
+	}
+
+	if err := global.APP_DB.Model(attachment).Updates(map[string]interface{}{
+		"device": device,
+		"status": providerModel.NetworkAttachmentStatusAttached,
+	}).Error; err != nil {
+		return fmt.Errorf("更新接入记录失败: %v", err)
+	}
+
+	global.APP_LOG.Info("实例接入私有网络成功",
+		zap.Uint("networkId", network.ID),
+		zap.Uint("instanceId", instance.ID),
+		zap.String("ipAddress", attachment.IPAddress),
+		zap.String("device", device))
+	return nil
+}
+
+// executeDetachPrivateNetworkTask 执行实例断开私有网络任务
+func (s *TaskService) executeDetachPrivateNetworkTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	taskReq, network, err := s.loadPrivateNetworkTaskContext(task)
+	if err != nil {
+		return err
+	}
+	var attachment providerModel.PrivateNetworkAttachment
+	if err := global.APP_DB.First(&attachment, taskReq.AttachmentId).Error; err != nil {
+		return fmt.Errorf("获取接入记录失败: %v", err)
+	}
+
+	s.updateTaskProgress(task.ID, 30, "正在断开私有网络...")
+
+	// 实例记录已不存在时Provider上的实例也已删除，只需清理接入记录
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, attachment.InstanceID).Error; err == nil {
+		networkProvider, err := provider2.GetProviderService().GetPrivateNetworkProvider(network.ProviderID)
+		if err == nil {
+			err = networkProvider.DetachPrivateNetwork(ctx, privateNetworkSpec(network, &instance, &attachment))
+		}
+		if err != nil {
+			global.APP_LOG.Error("断开私有网络失败",
+				zap.Uint("taskId", task.ID),
+				zap.Uint("networkId", network.ID),
+				zap.Uint("instanceId", instance.ID),
+				zap.Error(err))
+			global.APP_DB.Model(&attachment).Update("status", providerModel.NetworkAttachmentStatusAttached)
+			return fmt.Errorf("断开私有网络失败: %v", err)
+		}
+	}
+
+	if err := global.APP_DB.Delete(&attachment).Error; err != nil {
+		return fmt.Errorf("删除接入记录失败: %v", err)
+	}
+
+	global.APP_LOG.Info("实例断开私有网络成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("networkId", network.ID),
+		zap.Uint("instanceId", attachment.InstanceID))
+	return nil
+}
+
+// reattachPrivateNetworks 重装后以原地址把新实例接回私有网络，单个网络失败时删除接入记录并继续
+func (s *TaskService) reattachPrivateNetworks(ctx context.Context, attachments []providerModel.PrivateNetworkAttachment, newInstanceID uint) {
+	for i := range attachments {
+		attachment := &attachments[i]
+		var network providerModel.PrivateNetwork
+		err := global.APP_DB.First(&network, attachment.NetworkID).Error
+		var networkProvider provider.PrivateNetworkProvider
+		if err == nil {
+			networkProvider, err = provider2.GetProviderService().GetPrivateNetworkProvider(network.ProviderID)
+		}
+		if err == nil {
+			attachment.InstanceID = newInstanceID
+			err = global.APP_DB.Model(attachment).Update("instance_id", newInstanceID).Error
+		}
+		if err == nil {
+			err = s.attachPrivateNetwork(ctx, networkProvider, &network, attachment)
+		} else {
+			global.APP_DB.Delete(attachment)
+		}
+		if err != nil {
+			global.APP_LOG.Warn("重装后重新接入私有网络失败，已移除接入记录",
+				zap.Uint("networkId", attachment.NetworkID),
+				zap.Uint("instanceId", newInstanceID),
+				zap.Error(err))
+		}
+	}
+}
+
+// handleCancelledPrivateNetworkTask 取消私有网络任务后恢复网络和接入记录的状态
+func (s *TaskService) handleCancelledPrivateNetworkTask(task adminModel.Task) {
+	var taskReq adminModel.PrivateNetworkTaskRequest
+	if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+		global.APP_LOG.Error("解析私有网络任务数据失败", zap.Uint("taskId", task.ID), zap.Error(err))
+		return
+	}
+
+	var err error
+	switch task.TaskType {
+	case "create-private-network":
+		// 无法确认网络是否已在节点上创建，标记为失败，用户删除时不会调用Provider
+		err = global.APP_DB.Model(&providerModel.PrivateNetwork{}).
+			Where("id = ? AND status = ?", taskReq.NetworkId, providerModel.PrivateNetworkStatusCreating).
+			Update("status", providerModel.PrivateNetworkStatusFailed).Error
+	case "delete-private-network":
+		err = global.APP_DB.Model(&providerModel.PrivateNetwork{}).
+			Where("id = ? AND status = ?", taskReq.NetworkId, providerModel.PrivateNetworkStatusDeleting).
+			Update("status", providerModel.PrivateNetworkStatusActive).Error
+	case "attach-private-network":
+		err = global.APP_DB.Where("id = ? AND status = ?", taskReq.AttachmentId, providerModel.NetworkAttachmentStatusAttaching).
+			Delete(&providerModel.PrivateNetworkAttachment{}).Error
+	case "detach-private-network":
+		err = global.APP_DB.Model(&providerModel.PrivateNetworkAttachment{}).
+			Where("id = ? AND status = ?", taskReq.AttachmentId, providerModel.NetworkAttachmentStatusDetaching).
+			Update("status", providerModel.NetworkAttachmentStatusAttached).Error
+	}
+	if err != nil {
+		global.APP_LOG.Error("恢复私有网络状态失败",
+			zap.Uint("networkId", taskReq.NetworkId),
+			zap.Error(err))
+	}
+}
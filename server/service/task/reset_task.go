@@ -13,12 +13,16 @@ import (
 	providerModel "oneclickvirt/model/provider"
 	systemModel "oneclickvirt/model/system"
 	"oneclickvirt/provider/incus"
+	"oneclickvirt/provider/libvirt"
 	"oneclickvirt/provider/lxd"
+	"oneclickvirt/provider/podman"
 	"oneclickvirt/provider/portmapping"
 	"oneclickvirt/provider/proxmox"
 	traffic_monitor "oneclickvirt/service/admin/traffic_monitor"
+	"oneclickvirt/service/images"
 	provider2 "oneclickvirt/service/provider"
 	"oneclickvirt/service/resources"
+	"oneclickvirt/service/user/profile"
 	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
@@ -37,6 +41,33 @@ type ResetTaskContext struct {
 	NewOldName      string
 	NewPassword     string
 	NewPrivateIP    string
+	// TargetImageID 重装系统的目标镜像ID，为0表示使用原镜像重置
+	TargetImageID uint
+	// SSHKeyIDs 注入新实例的SSH公钥ID，重置时沿用旧实例的选择
+	SSHKeyIDs []uint
+	// DisablePasswordLogin 新实例是否禁用SSH密码登录
+	DisablePasswordLogin bool
+	// UserData 新实例的user-data，重置时沿用旧实例的内容
+	UserData string
+	// loginOptionsSet 登录方式是否已由重装请求指定
+	loginOptionsSet bool
+	// Volumes 旧实例上挂载的数据卷，新实例创建后重新挂载
+	Volumes []providerModel.Volume
+	// NetworkAttachments 旧实例接入的私有网络，新实例创建后以原地址重新接入
+	NetworkAttachments []providerModel.PrivateNetworkAttachment
+}
+
+// isReinstall 是否为重装系统任务，重装只更换系统并保留实例的网络信息
+func (c *ResetTaskContext) isReinstall() bool {
+	return c.TargetImageID != 0
+}
+
+// operationName 任务在进度和日志中显示的操作名称
+func (c *ResetTaskContext) operationName() string {
+	if c.isReinstall() {
+		return "重装"
+	}
+	return "重置"
 }
 
 // executeResetTask 执行实例重置任务
@@ -48,62 +79,122 @@ func (s *TaskService) executeResetTask(ctx context.Context, task *adminModel.Tas
 	}
 
 	var resetCtx ResetTaskContext
+	if err := s.runResetStages(ctx, task, &taskReq, &resetCtx); err != nil {
+		return err
+	}
+
+	global.APP_LOG.Info("用户实例重置成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("oldInstanceId", resetCtx.OldInstanceID),
+		zap.Uint("newInstanceId", resetCtx.NewInstanceID),
+		zap.String("instanceName", resetCtx.OldInstanceName),
+		zap.Uint("userId", task.UserID))
+
+	return nil
+}
+
+// executeReinstallTask 执行重装系统任务，复用重置流程但使用用户选择的镜像，保留端口映射和网络信息
+func (s *TaskService) executeReinstallTask(ctx context.Context, task *adminModel.Task) error {
+	var reinstallReq adminModel.ReinstallTaskRequest
+	if err := json.Unmarshal([]byte(task.TaskData), &reinstallReq); err != nil {
+		return fmt.Errorf("解析任务数据失败: %v", err)
+	}
+	if reinstallReq.SystemImageId == 0 {
+		return fmt.Errorf("未指定重装的目标镜像")
+	}
+
+	taskReq := adminModel.InstanceOperationTaskRequest{
+		InstanceId: reinstallReq.InstanceId,
+		ProviderId: reinstallReq.ProviderId,
+	}
+	resetCtx := ResetTaskContext{
+		TargetImageID:        reinstallReq.SystemImageId,
+		SSHKeyIDs:            reinstallReq.SSHKeyIDs,
+		DisablePasswordLogin: reinstallReq.DisablePasswordLogin,
+		UserData:             reinstallReq.UserData,
+		loginOptionsSet:      true,
+	}
+	if err := s.runResetStages(ctx, task, &taskReq, &resetCtx); err != nil {
+		return err
+	}
+
+	global.APP_LOG.Info("用户实例重装系统成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("oldInstanceId", resetCtx.OldInstanceID),
+		zap.Uint("newInstanceId", resetCtx.NewInstanceID),
+		zap.String("instanceName", resetCtx.OldInstanceName),
+		zap.String("image", resetCtx.SystemImage.Name),
+		zap.Uint("userId", task.UserID))
+
+	return nil
+}
 
+// runResetStages 依次执行重置/重装的各个阶段
+func (s *TaskService) runResetStages(ctx context.Context, task *adminModel.Task, taskReq *adminModel.InstanceOperationTaskRequest, resetCtx *ResetTaskContext) error {
 	// 阶段1: 准备阶段
-	if err := s.resetTask_Prepare(ctx, task, &taskReq, &resetCtx); err != nil {
+	if err := s.resetTask_Prepare(ctx, task, taskReq, resetCtx); err != nil {
 		return err
 	}
 
 	// 阶段2: 数据库操作 - 重命名旧实例并创建新实例记录（短事务）
-	if err := s.resetTask_RenameAndCreateNew(ctx, task, &resetCtx); err != nil {
+	if err := s.resetTask_RenameAndCreateNew(ctx, task, resetCtx); err != nil {
 		return err
 	}
 
 	// 阶段3: Provider操作 - 删除旧实例（无事务）
-	if err := s.resetTask_DeleteOldInstance(ctx, task, &resetCtx); err != nil {
+	if err := s.resetTask_DeleteOldInstance(ctx, task, resetCtx); err != nil {
 		return err
 	}
 
-	// 阶段4: Provider操作 - 创建新实例（无事务）
-	if err := s.resetTask_CreateNewInstance(ctx, task, &resetCtx); err != nil {
+	// 旧实例删除后，重新挂载前的阶段失败时数据卷恢复为未挂载，私有网络接入记录删除，避免停留在挂载中状态
+	releaseVolumes := func(err error) error {
+		if len(resetCtx.Volumes) > 0 {
+			resources.MarkVolumeDetached(global.APP_DB.Model(&providerModel.Volume{}).Where("id IN (?)", volumeIDs(resetCtx.Volumes)))
+		}
+		if len(resetCtx.NetworkAttachments) > 0 {
+			resources.DetachInstancePrivateNetworksInTx(global.APP_DB, resetCtx.Instance.ID)
+		}
 		return err
 	}
 
+	// 阶段4: Provider操作 - 创建新实例（无事务）
+	if err := s.resetTask_CreateNewInstance(ctx, task, resetCtx); err != nil {
+		return releaseVolumes(err)
+	}
+
 	// 阶段5: 设置密码（无事务）
-	if err := s.resetTask_SetPassword(ctx, task, &resetCtx); err != nil {
-		return err
+	if err := s.resetTask_SetPassword(ctx, task, resetCtx); err != nil {
+		return releaseVolumes(err)
 	}
 
 	// 阶段6: 更新实例信息（短事务）
-	if err := s.resetTask_UpdateInstanceInfo(ctx, task, &resetCtx); err != nil {
-		return err
+	if err := s.resetTask_UpdateInstanceInfo(ctx, task, resetCtx); err != nil {
+		return releaseVolumes(err)
 	}
 
-	// 阶段7: 恢复端口映射（批量短事务）
-	if err := s.resetTask_RestorePortMappings(ctx, task, &resetCtx); err != nil {
-		return err
-	}
+	// 阶段7: 重新挂载数据卷和私有网络（无事务）
+	s.resetTask_ReattachVolumes(ctx, task, resetCtx)
 
-	// 阶段8: 重新初始化监控（短事务）
-	if err := s.resetTask_ReinitializeMonitoring(ctx, task, &resetCtx); err != nil {
+	// 阶段8: 恢复端口映射（批量短事务）
+	if err := s.resetTask_RestorePortMappings(ctx, task, resetCtx); err != nil {
 		return err
 	}
 
-	s.updateTaskProgress(task.ID, 100, "重置完成")
+	// 阶段9: 重新应用防火墙规则（无事务）
+	s.resetTask_ReapplyFirewall(ctx, task, resetCtx)
 
-	global.APP_LOG.Info("用户实例重置成功",
-		zap.Uint("taskId", task.ID),
-		zap.Uint("oldInstanceId", resetCtx.OldInstanceID),
-		zap.Uint("newInstanceId", resetCtx.NewInstanceID),
-		zap.String("instanceName", resetCtx.OldInstanceName),
-		zap.Uint("userId", task.UserID))
+	// 阶段10: 重新初始化监控（短事务）
+	if err := s.resetTask_ReinitializeMonitoring(ctx, task, resetCtx); err != nil {
+		return err
+	}
 
+	s.updateTaskProgress(task.ID, 100, resetCtx.operationName()+"完成")
 	return nil
 }
 
 // resetTask_Prepare 阶段1: 准备阶段 - 查询必要信息
 func (s *TaskService) resetTask_Prepare(ctx context.Context, task *adminModel.Task, taskReq *adminModel.InstanceOperationTaskRequest, resetCtx *ResetTaskContext) error {
-	s.updateTaskProgress(task.ID, 5, "正在准备重置...")
+	s.updateTaskProgress(task.ID, 5, fmt.Sprintf("正在准备%s...", resetCtx.operationName()))
 
 	// 使用单个短事务查询所有需要的数据
 	err := s.dbService.ExecuteQuery(ctx, func() error {
@@ -125,8 +216,15 @@ func (s *TaskService) resetTask_Prepare(ctx context.Context, task *adminModel.Ta
 			return fmt.Errorf("获取Provider配置失败: %v", err)
 		}
 
-		// 3. 查询系统镜像
-		if err := global.APP_DB.Where("name = ? AND provider_type = ? AND instance_type = ? AND architecture = ?",
+		// 3. 查询系统镜像，重装时使用目标镜像
+		if resetCtx.isReinstall() {
+			imageService := &images.ImageService{}
+			targetImage, err := imageService.GetReinstallImage(resetCtx.Provider.ID, resetCtx.Instance.InstanceType, resetCtx.TargetImageID)
+			if err != nil {
+				return err
+			}
+			resetCtx.SystemImage = *targetImage
+		} else if err := global.APP_DB.Where("name = ? AND provider_type = ? AND instance_type = ? AND architecture = ?",
 			resetCtx.Instance.Image, resetCtx.Provider.Type, resetCtx.Instance.InstanceType, resetCtx.Provider.Architecture).
 			First(&resetCtx.SystemImage).Error; err != nil {
 			return fmt.Errorf("获取系统镜像信息失败: %v", err)
@@ -137,6 +235,18 @@ func (s *TaskService) resetTask_Prepare(ctx context.Context, task *adminModel.Ta
 			global.APP_LOG.Warn("获取旧端口映射失败", zap.Error(err))
 		}
 
+		// 5. 查询挂载的数据卷
+		if err := global.APP_DB.Where("instance_id = ? AND status = ?", resetCtx.Instance.ID, providerModel.VolumeStatusInUse).
+			Find(&resetCtx.Volumes).Error; err != nil {
+			global.APP_LOG.Warn("获取实例数据卷失败", zap.Error(err))
+		}
+
+		// 6. 查询接入的私有网络
+		if err := global.APP_DB.Where("instance_id = ? AND status = ?", resetCtx.Instance.ID, providerModel.NetworkAttachmentStatusAttached).
+			Find(&resetCtx.NetworkAttachments).Error; err != nil {
+			global.APP_LOG.Warn("获取实例私有网络失败", zap.Error(err))
+		}
+
 		return nil
 	})
 
@@ -144,6 +254,13 @@ func (s *TaskService) resetTask_Prepare(ctx context.Context, task *adminModel.Ta
 		return err
 	}
 
+	// 重置时沿用旧实例的SSH公钥和登录方式
+	if !resetCtx.loginOptionsSet {
+		resetCtx.SSHKeyIDs = profile.ParseSSHKeyIDs(resetCtx.Instance.SSHKeyIDs)
+		resetCtx.DisablePasswordLogin = resetCtx.Instance.PasswordLoginDisabled
+		resetCtx.UserData = resetCtx.Instance.UserData
+	}
+
 	// 保存必要信息
 	resetCtx.OldInstanceID = resetCtx.Instance.ID
 	resetCtx.OldInstanceName = resetCtx.Instance.Name
@@ -192,6 +309,22 @@ func (s *TaskService) resetTask_RenameAndCreateNew(ctx context.Context, task *ad
 			ExpiredAt:    resetCtx.Instance.ExpiredAt,
 			PublicIP:     resetCtx.Provider.Endpoint,
 			MaxTraffic:   resetCtx.Instance.MaxTraffic,
+			SSHKeyIDs:    profile.FormatSSHKeyIDs(resetCtx.SSHKeyIDs),
+			UserData:     resetCtx.UserData,
+		}
+		// 重装只更换系统，沿用旧实例的公网IP、IPv6、SSH端口和端口段
+		if resetCtx.isReinstall() {
+			newInstance.Image = resetCtx.SystemImage.Name
+			newInstance.OSType = resetCtx.SystemImage.OSType
+			if resetCtx.Instance.PublicIP != "" {
+				newInstance.PublicIP = resetCtx.Instance.PublicIP
+			}
+			newInstance.Network = resetCtx.Instance.Network
+			newInstance.IPv6Address = resetCtx.Instance.IPv6Address
+			newInstance.PublicIPv6 = resetCtx.Instance.PublicIPv6
+			newInstance.SSHPort = resetCtx.Instance.SSHPort
+			newInstance.PortRangeStart = resetCtx.Instance.PortRangeStart
+			newInstance.PortRangeEnd = resetCtx.Instance.PortRangeEnd
 		}
 
 		if err := tx.Create(&newInstance).Error; err != nil {
@@ -199,7 +332,10 @@ func (s *TaskService) resetTask_RenameAndCreateNew(ctx context.Context, task *ad
 		}
 
 		resetCtx.NewInstanceID = newInstance.ID
-		return nil
+
+		// 4. 防火墙规则转到新实例记录，由阶段9在新实例上重新应用
+		return tx.Model(&providerModel.FirewallRule{}).Where("instance_id = ?", resetCtx.OldInstanceID).
+			Update("instance_id", newInstance.ID).Error
 	})
 
 	if err != nil {
@@ -219,6 +355,17 @@ func (s *TaskService) resetTask_RenameAndCreateNew(ctx context.Context, task *ad
 func (s *TaskService) resetTask_DeleteOldInstance(ctx context.Context, task *adminModel.Task, resetCtx *ResetTaskContext) error {
 	s.updateTaskProgress(task.ID, 30, "正在删除Provider上的旧实例...")
 
+	// 数据卷不属于实例，删除旧实例时保留，标记为挂载中直到新实例创建后重新挂载
+	if len(resetCtx.Volumes) > 0 {
+		global.APP_DB.Model(&providerModel.Volume{}).Where("id IN (?)", volumeIDs(resetCtx.Volumes)).
+			Update("status", providerModel.VolumeStatusAttaching)
+	}
+	// 私有网络网卡随旧实例删除，保留接入记录占用原地址，标记为接入中直到新实例重新接入
+	if len(resetCtx.NetworkAttachments) > 0 {
+		global.APP_DB.Model(&providerModel.PrivateNetworkAttachment{}).Where("instance_id = ?", resetCtx.Instance.ID).
+			Update("status", providerModel.NetworkAttachmentStatusAttaching)
+	}
+
 	providerApiService := &provider2.ProviderApiService{}
 
 	// Provider操作，不在事务中
@@ -233,7 +380,7 @@ func (s *TaskService) resetTask_DeleteOldInstance(ctx context.Context, task *adm
 			return fmt.Errorf("删除旧实例失败: %v", deleteErr)
 		}
 
-		global.APP_LOG.Info("实例已不存在，继续重置流程")
+		global.APP_LOG.Info("实例已不存在，继续" + resetCtx.operationName() + "流程")
 	}
 
 	// 简单等待删除完成
@@ -255,19 +402,33 @@ func (s *TaskService) resetTask_CreateNewInstance(ctx context.Context, task *adm
 	createReq := provider2.CreateInstanceRequest{
 		InstanceConfig: providerModel.ProviderInstanceConfig{
 			Name:         resetCtx.OldInstanceName,
-			Image:        resetCtx.Instance.Image,
+			Image:        resetCtx.SystemImage.Name,
 			InstanceType: resetCtx.Instance.InstanceType,
 			CPU:          fmt.Sprintf("%d", resetCtx.Instance.CPU),
 			Memory:       fmt.Sprintf("%dMB", resetCtx.Instance.Memory),
 			Disk:         fmt.Sprintf("%dMB", resetCtx.Instance.Disk),
 			Env:          map[string]string{"RESET_OPERATION": "true"},
 			Metadata:     make(map[string]string),
+			UserData:     resetCtx.UserData,
 		},
 		SystemImageID: resetCtx.SystemImage.ID,
 	}
 
-	// Docker特殊处理：端口映射
-	if resetCtx.Provider.Type == "docker" && len(resetCtx.OldPortMappings) > 0 {
+	// 注入SSH公钥，已被用户删除的公钥会被忽略；没有可用公钥时不禁用密码登录，避免无法登录
+	sshKeys, err := profile.LoadSSHKeys(task.UserID, resetCtx.SSHKeyIDs)
+	if err != nil {
+		global.APP_LOG.Warn("获取SSH公钥失败，新实例将不注入公钥", zap.Uint("taskId", task.ID), zap.Error(err))
+	}
+	createReq.InstanceConfig.SSHKeys = sshKeys
+	createReq.InstanceConfig.DisablePasswordLogin = resetCtx.DisablePasswordLogin && len(sshKeys) > 0
+	if createReq.InstanceConfig.DisablePasswordLogin {
+		s.dbService.ExecuteTransaction(ctx, func(tx *gorm.DB) error {
+			return tx.Model(&providerModel.Instance{}).Where("id = ?", resetCtx.NewInstanceID).Update("password_login_disabled", true).Error
+		})
+	}
+
+	// Docker/Podman特殊处理：端口映射在创建容器时绑定
+	if (resetCtx.Provider.Type == "docker" || resetCtx.Provider.Type == "podman") && len(resetCtx.OldPortMappings) > 0 {
 		var ports []string
 		for _, oldPort := range resetCtx.OldPortMappings {
 			portMapping := fmt.Sprintf("0.0.0.0:%d:%d/%s", oldPort.HostPort, oldPort.GuestPort, oldPort.Protocol)
@@ -282,7 +443,7 @@ func (s *TaskService) resetTask_CreateNewInstance(ctx context.Context, task *adm
 		s.dbService.ExecuteTransaction(ctx, func(tx *gorm.DB) error {
 			return tx.Model(&providerModel.Instance{}).Where("id = ?", resetCtx.NewInstanceID).Update("status", "failed").Error
 		})
-		return fmt.Errorf("重置实例失败（重建阶段）: %v", err)
+		return fmt.Errorf("%s实例失败（重建阶段）: %v", resetCtx.operationName(), err)
 	}
 
 	// 等待实例启动
@@ -361,6 +522,18 @@ func (s *TaskService) resetTask_GetPrivateIP(ctx context.Context, resetCtx *Rese
 				resetCtx.NewPrivateIP = ip
 			}
 		}
+	case "libvirt":
+		if libvirtProv, ok := prov.(*libvirt.LibvirtProvider); ok {
+			if ip, err := libvirtProv.GetInstanceIPv4(ctx, resetCtx.OldInstanceName); err == nil {
+				resetCtx.NewPrivateIP = ip
+			}
+		}
+	case "podman":
+		if podmanProv, ok := prov.(*podman.PodmanProvider); ok {
+			if ip, err := podmanProv.GetInstanceIPv4(ctx, resetCtx.OldInstanceName); err == nil {
+				resetCtx.NewPrivateIP = ip
+			}
+		}
 	}
 }
 
@@ -393,7 +566,19 @@ func (s *TaskService) resetTask_UpdateInstanceInfo(ctx context.Context, task *ad
 	return nil
 }
 
-// resetTask_RestorePortMappings 阶段7: 恢复端口映射
+// resetTask_ReattachVolumes 阶段7: 把旧实例的数据卷和私有网络接到新实例，失败的数据卷保留为未挂载，失败的私有网络移除接入，不影响重装结果
+func (s *TaskService) resetTask_ReattachVolumes(ctx context.Context, task *adminModel.Task, resetCtx *ResetTaskContext) {
+	if len(resetCtx.Volumes) > 0 {
+		s.updateTaskProgress(task.ID, 85, "正在重新挂载数据卷...")
+		s.reattachVolumes(ctx, resetCtx.Volumes, resetCtx.NewInstanceID)
+	}
+	if len(resetCtx.NetworkAttachments) > 0 {
+		s.updateTaskProgress(task.ID, 86, "正在重新接入私有网络...")
+		s.reattachPrivateNetworks(ctx, resetCtx.NetworkAttachments, resetCtx.NewInstanceID)
+	}
+}
+
+// resetTask_RestorePortMappings 阶段8: 恢复端口映射
 func (s *TaskService) resetTask_RestorePortMappings(ctx context.Context, task *adminModel.Task, resetCtx *ResetTaskContext) error {
 	s.updateTaskProgress(task.ID, 88, "正在恢复端口映射...")
 
@@ -409,15 +594,18 @@ func (s *TaskService) resetTask_RestorePortMappings(ctx context.Context, task *a
 	successCount := 0
 	failCount := 0
 
-	if resetCtx.Provider.Type == "docker" {
-		// Docker: 只需恢复数据库记录
+	if resetCtx.Provider.Type == "docker" || resetCtx.Provider.Type == "podman" {
+		// Docker/Podman: 只需恢复数据库记录
 		for _, oldPort := range resetCtx.OldPortMappings {
 			err := s.dbService.ExecuteTransaction(ctx, func(tx *gorm.DB) error {
 				newPort := providerModel.Port{
 					InstanceID:    resetCtx.NewInstanceID,
 					ProviderID:    resetCtx.Provider.ID,
 					HostPort:      oldPort.HostPort,
+					HostPortEnd:   oldPort.HostPortEnd,
 					GuestPort:     oldPort.GuestPort,
+					GuestPortEnd:  oldPort.GuestPortEnd,
+					PortCount:     oldPort.PortCount,
 					Protocol:      oldPort.Protocol,
 					Description:   oldPort.Description,
 					Status:        "active",
@@ -442,10 +630,12 @@ func (s *TaskService) resetTask_RestorePortMappings(ctx context.Context, task *a
 			DefaultMappingMethod: resetCtx.Provider.IPv4PortMappingMethod,
 		})
 
-		portMappingType := resetCtx.Provider.Type
-		if portMappingType == "proxmox" {
-			portMappingType = "iptables"
-		}
+		portMappingType := hostPortMappingType(resetCtx.Provider.Type, resetCtx.Provider.IPv4PortMappingMethod)
+
+		// 端口记录关联到新实例
+		newInstance := resetCtx.Instance
+		newInstance.ID = resetCtx.NewInstanceID
+		newInstance.Name = resetCtx.OldInstanceName
 
 		// 按协议分组
 		tcpPorts := []providerModel.Port{}
@@ -465,22 +655,29 @@ func (s *TaskService) resetTask_RestorePortMappings(ctx context.Context, task *a
 
 		// 分别处理
 		if len(tcpPorts) > 0 {
-			processed, failed := s.restorePortMappingsOptimized(ctx, tcpPorts, resetCtx.Instance, resetCtx.Provider, manager, portMappingType)
+			processed, failed := s.restorePortMappingsOptimized(ctx, tcpPorts, newInstance, resetCtx.Provider, manager, portMappingType)
 			successCount += processed
 			failCount += failed
 		}
 		if len(udpPorts) > 0 {
-			processed, failed := s.restorePortMappingsOptimized(ctx, udpPorts, resetCtx.Instance, resetCtx.Provider, manager, portMappingType)
+			processed, failed := s.restorePortMappingsOptimized(ctx, udpPorts, newInstance, resetCtx.Provider, manager, portMappingType)
 			successCount += processed
 			failCount += failed
 		}
 		if len(bothPorts) > 0 {
-			processed, failed := s.restorePortMappingsOptimized(ctx, bothPorts, resetCtx.Instance, resetCtx.Provider, manager, portMappingType)
+			processed, failed := s.restorePortMappingsOptimized(ctx, bothPorts, newInstance, resetCtx.Provider, manager, portMappingType)
 			successCount += processed
 			failCount += failed
 		}
 	}
 
+	// 重装保留端口段，旧端口记录已迁移到新实例，删除旧实例的记录避免重复占用
+	if resetCtx.isReinstall() {
+		s.dbService.ExecuteTransaction(ctx, func(tx *gorm.DB) error {
+			return tx.Where("instance_id = ?", resetCtx.OldInstanceID).Delete(&providerModel.Port{}).Error
+		})
+	}
+
 	// 更新SSH端口
 	s.dbService.ExecuteQuery(ctx, func() error {
 		var sshPort providerModel.Port
@@ -499,7 +696,18 @@ func (s *TaskService) resetTask_RestorePortMappings(ctx context.Context, task *a
 	return nil
 }
 
-// resetTask_ReinitializeMonitoring 阶段8: 重新初始化监控
+// resetTask_ReapplyFirewall 阶段9: 在新实例上重新应用防火墙规则并清理旧实例的防火墙链
+func (s *TaskService) resetTask_ReapplyFirewall(ctx context.Context, task *adminModel.Task, resetCtx *ResetTaskContext) {
+	var newInstance providerModel.Instance
+	if err := global.APP_DB.First(&newInstance, resetCtx.NewInstanceID).Error; err != nil || !hasFirewallRules(newInstance.ID) {
+		return
+	}
+
+	s.updateTaskProgress(task.ID, 94, "正在重新应用防火墙规则...")
+	s.reapplyInstanceFirewall(ctx, &newInstance, &resetCtx.Provider, resetCtx.OldInstanceID)
+}
+
+// resetTask_ReinitializeMonitoring 阶段10: 重新初始化监控
 func (s *TaskService) resetTask_ReinitializeMonitoring(ctx context.Context, task *adminModel.Task, resetCtx *ResetTaskContext) error {
 	s.updateTaskProgress(task.ID, 96, "正在重新初始化监控...")
 
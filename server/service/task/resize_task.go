@@ -0,0 +1,186 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/resources"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// instanceResources 实例资源配置快照，用于调整失败时回滚
+type instanceResources struct {
+	CPU       int
+	Memory    int64
+	Disk      int64
+	Bandwidth int
+}
+
+// executeResizeTask 执行调整实例配置任务
+// 先在事务中完成配额校验和计数更新，再调用Provider执行调整，失败时回滚计数
+func (s *TaskService) executeResizeTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	var taskReq adminModel.ResizeTaskRequest
+	if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+		return fmt.Errorf("解析任务数据失败: %v", err)
+	}
+
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, taskReq.InstanceId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("实例不存在")
+		}
+		return fmt.Errorf("获取实例信息失败: %v", err)
+	}
+
+	// 验证实例所有权
+	if instance.UserID != task.UserID {
+		return fmt.Errorf("无权限操作此实例")
+	}
+
+	originalStatus := taskReq.OriginalStatus
+	if originalStatus == "" {
+		originalStatus = "running"
+	}
+
+	oldResources := instanceResources{
+		CPU:       instance.CPU,
+		Memory:    instance.Memory,
+		Disk:      instance.Disk,
+		Bandwidth: instance.Bandwidth,
+	}
+	newResources := instanceResources{
+		CPU:       taskReq.CPU,
+		Memory:    taskReq.Memory,
+		Disk:      taskReq.Disk,
+		Bandwidth: taskReq.Bandwidth,
+	}
+
+	// 扩容磁盘需要节点支持，在校验配额和任何SSH操作之前拒绝
+	if newResources.Disk > oldResources.Disk {
+		if err := s.requireProviderCapabilities(instance.ProviderID, providerModel.CapabilityDiskResize); err != nil {
+			global.APP_DB.Model(&instance).Update("status", originalStatus)
+			return err
+		}
+	}
+
+	s.updateTaskProgress(task.ID, 20, "正在校验资源配额...")
+
+	if err := s.applyResizeAccounting(&instance, oldResources, newResources, true); err != nil {
+		global.APP_DB.Model(&instance).Update("status", originalStatus)
+		return err
+	}
+
+	s.updateTaskProgress(task.ID, 40, "正在调整实例配置...")
+
+	// 只下发有变化的配置项
+	spec := provider.ResizeSpec{InstanceType: instance.InstanceType}
+	if newResources.CPU != oldResources.CPU {
+		spec.CPU = newResources.CPU
+	}
+	if newResources.Memory != oldResources.Memory {
+		spec.Memory = newResources.Memory
+	}
+	if newResources.Disk != oldResources.Disk {
+		spec.Disk = newResources.Disk
+	}
+	if newResources.Bandwidth != oldResources.Bandwidth {
+		spec.Bandwidth = newResources.Bandwidth
+	}
+
+	providerService := provider2.GetProviderService()
+	if err := providerService.ResizeInstance(ctx, instance.ProviderID, instance.Name, spec); err != nil {
+		global.APP_LOG.Error("调整实例配置失败，回滚资源计数",
+			zap.Uint("taskId", task.ID),
+			zap.Uint("instanceId", instance.ID),
+			zap.Error(err))
+		if rollbackErr := s.applyResizeAccounting(&instance, newResources, oldResources, false); rollbackErr != nil {
+			global.APP_LOG.Error("回滚资源计数失败",
+				zap.Uint("instanceId", instance.ID),
+				zap.Error(rollbackErr))
+		}
+		global.APP_DB.Model(&instance).Update("status", originalStatus)
+		return fmt.Errorf("调整实例配置失败: %v", err)
+	}
+
+	s.updateTaskProgress(task.ID, 90, "正在更新实例状态...")
+
+	if err := global.APP_DB.Model(&instance).Update("status", originalStatus).Error; err != nil {
+		global.APP_LOG.Error("恢复实例状态失败",
+			zap.Uint("instanceId", instance.ID),
+			zap.String("status", originalStatus),
+			zap.Error(err))
+	}
+
+	global.APP_LOG.Info("实例配置调整成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("instanceId", instance.ID),
+		zap.String("instanceName", instance.Name),
+		zap.Int("cpu", newResources.CPU),
+		zap.Int64("memory", newResources.Memory),
+		zap.Int64("disk", newResources.Disk),
+		zap.Int("bandwidth", newResources.Bandwidth))
+
+	return nil
+}
+
+// applyResizeAccounting 在同一事务中更新实例配置、Provider资源占用和用户配额
+// validate 为 true 时先校验用户等级和节点配额，回滚时跳过校验
+func (s *TaskService) applyResizeAccounting(instance *providerModel.Instance, from, to instanceResources, validate bool) error {
+	return global.APP_DB.Transaction(func(tx *gorm.DB) error {
+		quotaService := resources.NewQuotaService()
+
+		if validate {
+			result, err := quotaService.ValidateInTransaction(tx, resources.ResourceRequest{
+				UserID:            instance.UserID,
+				CPU:               to.CPU,
+				Memory:            to.Memory,
+				Disk:              to.Disk,
+				Bandwidth:         to.Bandwidth,
+				InstanceType:      instance.InstanceType,
+				ProviderID:        instance.ProviderID,
+				ExcludeInstanceID: instance.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("配额验证失败: %v", err)
+			}
+			if !result.Allowed {
+				return fmt.Errorf("配额不足: %s", result.Reason)
+			}
+		}
+
+		resourceService := &resources.ResourceService{}
+		if err := resourceService.AdjustResourcesInTx(tx, instance.ProviderID, instance.InstanceType,
+			to.CPU-from.CPU, to.Memory-from.Memory, to.Disk-from.Disk); err != nil {
+			return err
+		}
+
+		if err := quotaService.UpdateUserQuotaAfterDeletionWithTx(tx, instance.UserID, resources.ResourceUsage{
+			CPU: from.CPU, Memory: from.Memory, Disk: from.Disk, Bandwidth: from.Bandwidth,
+		}); err != nil {
+			return err
+		}
+		if err := quotaService.UpdateUserQuotaAfterCreationWithTx(tx, instance.UserID, resources.ResourceUsage{
+			CPU: to.CPU, Memory: to.Memory, Disk: to.Disk, Bandwidth: to.Bandwidth,
+		}); err != nil {
+			return err
+		}
+
+		return tx.Model(instance).Updates(map[string]interface{}{
+			"cpu":       to.CPU,
+			"memory":    to.Memory,
+			"disk":      to.Disk,
+			"bandwidth": to.Bandwidth,
+		}).Error
+	})
+}
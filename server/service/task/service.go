@@ -353,7 +353,10 @@ func (s *TaskService) restorePortMappingsOptimized(
 					InstanceID:    instance.ID,
 					ProviderID:    provider.ID,
 					HostPort:      oldPort.HostPort,
+					HostPortEnd:   oldPort.HostPortEnd,
 					GuestPort:     oldPort.GuestPort,
+					GuestPortEnd:  oldPort.GuestPortEnd,
+					PortCount:     oldPort.PortCount,
 					Protocol:      oldPort.Protocol,
 					Description:   oldPort.Description,
 					Status:        "active",
@@ -0,0 +1,184 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	provider2 "oneclickvirt/service/provider"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// loadSnapshotTaskContext 解析快照任务数据并加载实例和快照记录
+func (s *TaskService) loadSnapshotTaskContext(task *adminModel.Task) (*adminModel.SnapshotTaskRequest, *providerModel.Instance, *providerModel.InstanceSnapshot, error) {
+	var taskReq adminModel.SnapshotTaskRequest
+	if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+		global.APP_LOG.Error("解析快照任务数据失败",
+			zap.Uint("taskId", task.ID),
+			zap.String("taskType", task.TaskType),
+			zap.String("taskData", task.TaskData),
+			zap.Error(err))
+		return nil, nil, nil, fmt.Errorf("解析任务数据失败: %v", err)
+	}
+
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, taskReq.InstanceId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil, fmt.Errorf("实例不存在")
+		}
+		return nil, nil, nil, fmt.Errorf("获取实例信息失败: %v", err)
+	}
+
+	// 验证实例所有权
+	if instance.UserID != task.UserID {
+		return nil, nil, nil, fmt.Errorf("无权限操作此实例")
+	}
+
+	var snapshot providerModel.InstanceSnapshot
+	if err := global.APP_DB.Where("id = ? AND instance_id = ?", taskReq.SnapshotId, instance.ID).First(&snapshot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil, fmt.Errorf("快照不存在")
+		}
+		return nil, nil, nil, fmt.Errorf("获取快照信息失败: %v", err)
+	}
+
+	return &taskReq, &instance, &snapshot, nil
+}
+
+// executeCreateSnapshotTask 执行创建快照任务
+func (s *TaskService) executeCreateSnapshotTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	_, instance, snapshot, err := s.loadSnapshotTaskContext(task)
+	if err != nil {
+		return err
+	}
+
+	if err := s.requireProviderCapabilities(instance.ProviderID, providerModel.CapabilitySnapshot); err != nil {
+		global.APP_DB.Model(snapshot).Update("status", "failed")
+		return err
+	}
+
+	s.updateTaskProgress(task.ID, 30, "正在创建快照...")
+
+	providerService := provider2.GetProviderService()
+	if err := providerService.CreateSnapshot(ctx, instance.ProviderID, instance.Name, snapshot.Name); err != nil {
+		global.APP_LOG.Error("创建实例快照失败",
+			zap.Uint("taskId", task.ID),
+			zap.Uint("instanceId", instance.ID),
+			zap.String("snapshot", snapshot.Name),
+			zap.Error(err))
+		global.APP_DB.Model(snapshot).Update("status", "failed")
+		return fmt.Errorf("创建快照失败: %v", err)
+	}
+
+	s.updateTaskProgress(task.ID, 90, "正在更新快照记录...")
+
+	if err := global.APP_DB.Model(snapshot).Update("status", "available").Error; err != nil {
+		return fmt.Errorf("更新快照状态失败: %v", err)
+	}
+
+	global.APP_LOG.Info("实例快照创建成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("instanceId", instance.ID),
+		zap.String("instanceName", instance.Name),
+		zap.String("snapshot", snapshot.Name))
+
+	return nil
+}
+
+// executeRestoreSnapshotTask 执行恢复快照任务
+func (s *TaskService) executeRestoreSnapshotTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	taskReq, instance, snapshot, err := s.loadSnapshotTaskContext(task)
+	if err != nil {
+		return err
+	}
+
+	originalStatus := taskReq.OriginalStatus
+	if originalStatus == "" {
+		originalStatus = "stopped"
+	}
+
+	s.updateTaskProgress(task.ID, 30, "正在恢复快照...")
+
+	restoreErr := s.requireProviderCapabilities(instance.ProviderID, providerModel.CapabilitySnapshot)
+	if restoreErr == nil {
+		restoreErr = provider2.GetProviderService().RestoreSnapshot(ctx, instance.ProviderID, instance.Name, snapshot.Name)
+	}
+
+	s.updateTaskProgress(task.ID, 90, "正在更新实例状态...")
+
+	// 无论成功与否都恢复实例和快照状态，避免状态锁死
+	global.APP_DB.Model(snapshot).Update("status", "available")
+	if err := global.APP_DB.Model(instance).Update("status", originalStatus).Error; err != nil {
+		global.APP_LOG.Error("恢复实例状态失败",
+			zap.Uint("instanceId", instance.ID),
+			zap.String("status", originalStatus),
+			zap.Error(err))
+	}
+
+	if restoreErr != nil {
+		global.APP_LOG.Error("恢复实例快照失败",
+			zap.Uint("taskId", task.ID),
+			zap.Uint("instanceId", instance.ID),
+			zap.String("snapshot", snapshot.Name),
+			zap.Error(restoreErr))
+		return fmt.Errorf("恢复快照失败: %v", restoreErr)
+	}
+
+	global.APP_LOG.Info("实例快照恢复成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("instanceId", instance.ID),
+		zap.String("instanceName", instance.Name),
+		zap.String("snapshot", snapshot.Name))
+
+	return nil
+}
+
+// executeDeleteSnapshotTask 执行删除快照任务
+func (s *TaskService) executeDeleteSnapshotTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	_, instance, snapshot, err := s.loadSnapshotTaskContext(task)
+	if err != nil {
+		return err
+	}
+
+	s.updateTaskProgress(task.ID, 30, "正在删除快照...")
+
+	// 创建失败的快照在Provider上不存在，直接删除记录
+	if snapshot.Status != "failed" {
+		providerService := provider2.GetProviderService()
+		if err := providerService.DeleteSnapshot(ctx, instance.ProviderID, instance.Name, snapshot.Name); err != nil {
+			global.APP_LOG.Error("删除实例快照失败",
+				zap.Uint("taskId", task.ID),
+				zap.Uint("instanceId", instance.ID),
+				zap.String("snapshot", snapshot.Name),
+				zap.Error(err))
+			global.APP_DB.Model(snapshot).Update("status", "available")
+			return fmt.Errorf("删除快照失败: %v", err)
+		}
+	}
+
+	s.updateTaskProgress(task.ID, 90, "正在清理快照记录...")
+
+	if err := global.APP_DB.Delete(snapshot).Error; err != nil {
+		return fmt.Errorf("删除快照记录失败: %v", err)
+	}
+
+	global.APP_LOG.Info("实例快照删除成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("instanceId", instance.ID),
+		zap.String("instanceName", instance.Name),
+		zap.String("snapshot", snapshot.Name))
+
+	return nil
+}
@@ -0,0 +1,415 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/resources"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// loadVolumeTaskContext 解析数据卷任务数据并加载数据卷记录
+func (s *TaskService) loadVolumeTaskContext(task *adminModel.Task) (*adminModel.VolumeTaskRequest, *providerModel.Volume, error) {
+	var taskReq adminModel.VolumeTaskRequest
+	if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+		global.APP_LOG.Error("解析数据卷任务数据失败",
+			zap.Uint("taskId", task.ID),
+			zap.String("taskType", task.TaskType),
+			zap.String("taskData", task.TaskData),
+			zap.Error(err))
+		return nil, nil, fmt.Errorf("解析任务数据失败: %v", err)
+	}
+
+	var volume providerModel.Volume
+	if err := global.APP_DB.First(&volume, taskReq.VolumeId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, fmt.Errorf("数据卷不存在")
+		}
+		return nil, nil, fmt.Errorf("获取数据卷信息失败: %v", err)
+	}
+
+	// 验证数据卷所有权
+	if volume.UserID != task.UserID {
+		return nil, nil, fmt.Errorf("无权限操作此数据卷")
+	}
+
+	return &taskReq, &volume, nil
+}
+
+// volumeSpec 根据数据卷记录构造Provider操作参数
+func volumeSpec(volume *providerModel.Volume, instanceName string) provider.VolumeSpec {
+	return provider.VolumeSpec{
+		ID:           volume.ID,
+		Name:         volume.ProviderVolumeName(),
+		Pool:         volume.Pool,
+		SizeMB:       volume.SizeMB,
+		InstanceType: volume.InstanceType,
+		Instance:     instanceName,
+		Device:       volume.Device,
+		MountPath:    volume.MountPath,
+	}
+}
+
+// executeCreateVolumeTask 执行创建数据卷任务，指定了实例时创建后直接挂载
+func (s *TaskService) executeCreateVolumeTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	taskReq, volume, err := s.loadVolumeTaskContext(task)
+	if err != nil {
+		return err
+	}
+
+	s.updateTaskProgress(task.ID, 30, "正在创建数据卷...")
+
+	volumeProvider, err := provider2.GetProviderService().GetVolumeProvider(volume.ProviderID)
+	var pool string
+	if err == nil {
+		pool, err = volumeProvider.CreateVolume(ctx, volumeSpec(volume, ""))
+	}
+	if err != nil {
+		global.APP_LOG.Error("创建数据卷失败",
+			zap.Uint("taskId", task.ID),
+			zap.Uint("volumeId", volume.ID),
+			zap.Error(err))
+		s.failVolumeCreation(volume)
+		return fmt.Errorf("创建数据卷失败: %v", err)
+	}
+
+	volume.Pool = pool
+	if err := global.APP_DB.Model(volume).Updates(map[string]interface{}{
+		"pool":   pool,
+		"status": providerModel.VolumeStatusAvailable,
+	}).Error; err != nil {
+		return fmt.Errorf("更新数据卷状态失败: %v", err)
+	}
+
+	global.APP_LOG.Info("数据卷创建成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("volumeId", volume.ID),
+		zap.String("pool", pool),
+		zap.Int64("sizeMB", volume.SizeMB))
+
+	if taskReq.InstanceId == 0 {
+		return nil
+	}
+
+	s.updateTaskProgress(task.ID, 60, "正在挂载数据卷...")
+	if err := s.attachVolume(ctx, volumeProvider, volume, taskReq.InstanceId, taskReq.MountPath); err != nil {
+		return fmt.Errorf("数据卷已创建，但挂载失败: %v", err)
+	}
+	return nil
+}
+
+// executeAttachVolumeTask 执行挂载数据卷任务
+func (s *TaskService) executeAttachVolumeTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	taskReq, volume, err := s.loadVolumeTaskContext(task)
+	if err != nil {
+		return err
+	}
+
+	s.updateTaskProgress(task.ID, 30, "正在挂载数据卷...")
+
+	volumeProvider, err := provider2.GetProviderService().GetVolumeProvider(volume.ProviderID)
+	if err == nil {
+		err = s.attachVolume(ctx, volumeProvider, volume, taskReq.InstanceId, taskReq.MountPath)
+	} else {
+		global.APP_DB.Model(volume).Update("status", providerModel.VolumeStatusAvailable)
+	}
+	if err != nil {
+		return fmt.Errorf("挂载数据卷失败: %v", err)
+	}
+	return nil
+}
+
+// attachVolume 将数据卷挂载到实例并更新记录，失败时数据卷恢复为未挂载
+func (s *TaskService) attachVolume(ctx context.Context, volumeProvider provider.VolumeProvider, volume *providerModel.Volume, instanceID uint, mountPath string) error {
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil {
+		global.APP_DB.Model(volume).Update("status", providerModel.VolumeStatusAvailable)
+		return fmt.Errorf("获取实例信息失败: %v", err)
+	}
+
+	volume.MountPath = mountPath
+	if volume.InstanceType == "vm" {
+		volume.MountPath = ""
+	} else if volume.MountPath == "" {
+		volume.MountPath = provider.DefaultVolumeMountPath(volume.ProviderVolumeName())
+	}
+
+	device, err := volumeProvider.AttachVolume(ctx, volumeSpec(volume, instance.Name))
+	if err != nil {
+		global.APP_LOG.Error("挂载数据卷失败",
+			zap.Uint("volumeId", volume.ID),
+			zap.Uint("instanceId", instance.ID),
+			zap.Error(err))
+		global.APP_DB.Model(volume).Updates(map[string]interface{}{
+			"instance_id": 0,
+			"status":      providerModel.VolumeStatusAvailable,
+		})
+		return err
+	}
+
+	if err := global.APP_DB.Model(volume).Updates(map[string]interface{}{
+		"instance_id": instance.ID,
+		"device":      device,
+		"mount_path":  volume.MountPath,
+		"status":      providerModel.VolumeStatusInUse,
+	}).Error; err != nil {
+		return fmt.Errorf("更新数据卷状态失败: %v", err)
+	}
+
+	global.APP_LOG.Info("数据卷挂载成功",
+		zap.Uint("volumeId", volume.ID),
+		zap.Uint("instanceId", instance.ID),
+		zap.String("instanceName", instance.Name),
+		zap.String("device", device))
+	return nil
+}
+
+// executeDetachVolumeTask 执行卸载数据卷任务
+func (s *TaskService) executeDetachVolumeTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	_, volume, err := s.loadVolumeTaskContext(task)
+	if err != nil {
+		return err
+	}
+
+	s.updateTaskProgress(task.ID, 30, "正在卸载数据卷...")
+
+	// 实例记录已不存在时Provider上的实例也已删除，只需清理挂载信息
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, volume.InstanceID).Error; err == nil {
+		volumeProvider, err := provider2.GetProviderService().GetVolumeProvider(volume.ProviderID)
+		if err == nil {
+			err = volumeProvider.DetachVolume(ctx, volumeSpec(volume, instance.Name))
+		}
+		if err != nil {
+			global.APP_LOG.Error("卸载数据卷失败",
+				zap.Uint("taskId", task.ID),
+				zap.Uint("volumeId", volume.ID),
+				zap.Uint("instanceId", instance.ID),
+				zap.Error(err))
+			global.APP_DB.Model(volume).Update("status", providerModel.VolumeStatusInUse)
+			return fmt.Errorf("卸载数据卷失败: %v", err)
+		}
+	}
+
+	s.updateTaskProgress(task.ID, 90, "正在更新数据卷记录...")
+
+	if err := resources.MarkVolumeDetached(global.APP_DB.Model(volume)); err != nil {
+		return fmt.Errorf("更新数据卷状态失败: %v", err)
+	}
+
+	global.APP_LOG.Info("数据卷卸载成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("volumeId", volume.ID),
+		zap.Uint("instanceId", volume.InstanceID))
+	return nil
+}
+
+// executeResizeVolumeTask 执行数据卷扩容任务，新增容量已在提交任务时占用，失败时释放
+func (s *TaskService) executeResizeVolumeTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	taskReq, volume, err := s.loadVolumeTaskContext(task)
+	if err != nil {
+		return err
+	}
+
+	s.updateTaskProgress(task.ID, 30, "正在调整数据卷容量...")
+
+	instanceName := ""
+	if volume.InstanceID > 0 {
+		var instance providerModel.Instance
+		if err := global.APP_DB.First(&instance, volume.InstanceID).Error; err == nil {
+			instanceName = instance.Name
+		}
+	}
+
+	spec := volumeSpec(volume, instanceName)
+	spec.SizeMB = taskReq.SizeMB
+	volumeProvider, err := provider2.GetProviderService().GetVolumeProvider(volume.ProviderID)
+	if err == nil {
+		err = volumeProvider.ResizeVolume(ctx, spec)
+	}
+
+	restoreStatus := providerModel.VolumeStatusAvailable
+	if volume.InstanceID > 0 {
+		restoreStatus = providerModel.VolumeStatusInUse
+	}
+
+	if err != nil {
+		global.APP_LOG.Error("调整数据卷容量失败",
+			zap.Uint("taskId", task.ID),
+			zap.Uint("volumeId", volume.ID),
+			zap.Int64("sizeMB", taskReq.SizeMB),
+			zap.Error(err))
+		s.dbService.ExecuteTransaction(ctx, func(tx *gorm.DB) error {
+			if err := resources.ReleaseVolumeResourcesInTx(tx, volume, taskReq.SizeMB-volume.SizeMB); err != nil {
+				return err
+			}
+			return tx.Model(volume).Update("status", restoreStatus).Error
+		})
+		return fmt.Errorf("调整数据卷容量失败: %v", err)
+	}
+
+	if err := global.APP_DB.Model(volume).Updates(map[string]interface{}{
+		"size_mb": taskReq.SizeMB,
+		"status":  restoreStatus,
+	}).Error; err != nil {
+		return fmt.Errorf("更新数据卷记录失败: %v", err)
+	}
+
+	global.APP_LOG.Info("数据卷容量调整成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("volumeId", volume.ID),
+		zap.Int64("oldSizeMB", volume.SizeMB),
+		zap.Int64("newSizeMB", taskReq.SizeMB))
+	return nil
+}
+
+// executeDeleteVolumeTask 执行删除数据卷任务
+func (s *TaskService) executeDeleteVolumeTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 10, "正在解析任务数据...")
+
+	_, volume, err := s.loadVolumeTaskContext(task)
+	if err != nil {
+		return err
+	}
+	if volume.InstanceID > 0 {
+		global.APP_DB.Model(volume).Update("status", providerModel.VolumeStatusInUse)
+		return fmt.Errorf("数据卷仍挂载在实例上，请先卸载")
+	}
+
+	s.updateTaskProgress(task.ID, 30, "正在删除数据卷...")
+
+	volumeProvider, err := provider2.GetProviderService().GetVolumeProvider(volume.ProviderID)
+	if err == nil {
+		err = volumeProvider.DeleteVolume(ctx, volumeSpec(volume, ""))
+	}
+	if err != nil {
+		global.APP_LOG.Error("删除数据卷失败",
+			zap.Uint("taskId", task.ID),
+			zap.Uint("volumeId", volume.ID),
+			zap.Error(err))
+		global.APP_DB.Model(volume).Update("status", providerModel.VolumeStatusAvailable)
+		return fmt.Errorf("删除数据卷失败: %v", err)
+	}
+
+	s.updateTaskProgress(task.ID, 90, "正在释放资源...")
+
+	if err := s.dbService.ExecuteTransaction(ctx, func(tx *gorm.DB) error {
+		if err := resources.ReleaseVolumeResourcesInTx(tx, volume, volume.SizeMB); err != nil {
+			return err
+		}
+		return tx.Delete(volume).Error
+	}); err != nil {
+		return fmt.Errorf("删除数据卷记录失败: %v", err)
+	}
+
+	global.APP_LOG.Info("数据卷删除成功",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("volumeId", volume.ID),
+		zap.String("name", volume.Name))
+	return nil
+}
+
+// failVolumeCreation 创建失败时标记数据卷并释放已占用的配额和磁盘预算
+func (s *TaskService) failVolumeCreation(volume *providerModel.Volume) {
+	if err := s.dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+		if err := resources.ReleaseVolumeResourcesInTx(tx, volume, volume.SizeMB); err != nil {
+			return err
+		}
+		return tx.Model(volume).Update("status", providerModel.VolumeStatusFailed).Error
+	}); err != nil {
+		global.APP_LOG.Error("释放创建失败的数据卷资源失败",
+			zap.Uint("volumeId", volume.ID),
+			zap.Error(err))
+	}
+}
+
+// reattachVolumes 重装后把旧实例上的数据卷挂载到新实例，单个数据卷失败时恢复为未挂载并继续
+func (s *TaskService) reattachVolumes(ctx context.Context, volumes []providerModel.Volume, newInstanceID uint) {
+	if len(volumes) == 0 {
+		return
+	}
+	volumeProvider, err := provider2.GetProviderService().GetVolumeProvider(volumes[0].ProviderID)
+	if err != nil {
+		global.APP_LOG.Warn("获取数据卷Provider失败，数据卷保留为未挂载", zap.Error(err))
+		resources.MarkVolumeDetached(global.APP_DB.Model(&providerModel.Volume{}).Where("id IN (?)", volumeIDs(volumes)))
+		return
+	}
+	for i := range volumes {
+		volume := &volumes[i]
+		if err := s.attachVolume(ctx, volumeProvider, volume, newInstanceID, volume.MountPath); err != nil {
+			global.APP_LOG.Warn("重装后重新挂载数据卷失败，数据卷保留为未挂载",
+				zap.Uint("volumeId", volume.ID),
+				zap.Uint("instanceId", newInstanceID),
+				zap.Error(err))
+			resources.MarkVolumeDetached(global.APP_DB.Model(volume))
+		}
+	}
+}
+
+// volumeIDs 提取数据卷ID列表
+func volumeIDs(volumes []providerModel.Volume) []uint {
+	ids := make([]uint, 0, len(volumes))
+	for _, volume := range volumes {
+		ids = append(ids, volume.ID)
+	}
+	return ids
+}
+
+// handleCancelledVolumeTask 取消数据卷任务后恢复数据卷状态
+func (s *TaskService) handleCancelledVolumeTask(task adminModel.Task) {
+	var taskReq adminModel.VolumeTaskRequest
+	if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+		global.APP_LOG.Error("解析数据卷任务数据失败", zap.Uint("taskId", task.ID), zap.Error(err))
+		return
+	}
+	var volume providerModel.Volume
+	if err := global.APP_DB.First(&volume, taskReq.VolumeId).Error; err != nil {
+		return
+	}
+
+	switch task.TaskType {
+	case "create-volume":
+		if volume.Status == providerModel.VolumeStatusCreating {
+			s.failVolumeCreation(&volume)
+		}
+		return
+	case "resize-volume":
+		if volume.Status == providerModel.VolumeStatusResizing {
+			if err := s.dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+				return resources.ReleaseVolumeResourcesInTx(tx, &volume, taskReq.SizeMB-volume.SizeMB)
+			}); err != nil {
+				global.APP_LOG.Error("释放数据卷扩容资源失败", zap.Uint("volumeId", volume.ID), zap.Error(err))
+			}
+		}
+	}
+
+	status := providerModel.VolumeStatusAvailable
+	if volume.InstanceID > 0 {
+		status = providerModel.VolumeStatusInUse
+	}
+	if err := global.APP_DB.Model(&volume).
+		Where("status IN (?)", []string{providerModel.VolumeStatusAttaching, providerModel.VolumeStatusDetaching,
+			providerModel.VolumeStatusResizing, providerModel.VolumeStatusDeleting}).
+		Update("status", status).Error; err != nil {
+		global.APP_LOG.Error("恢复数据卷状态失败",
+			zap.Uint("volumeId", volume.ID),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,80 @@
+package traffic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/monitoring"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	mockProvider "oneclickvirt/provider/mock"
+)
+
+// TestMockFlow_InstanceMonthlyTraffic 在模拟节点和SQLite上为实例写入pmacct采样，
+// 检查月度流量按pmacct重启分段累加、应用节点的计费模式，并能被清空
+func TestMockFlow_InstanceMonthlyTraffic(t *testing.T) {
+	mockProvider.SetupTestDB(t, &providerModel.Provider{}, &providerModel.Instance{},
+		&monitoring.PmacctTrafficRecord{}, &monitoring.PmacctMonitor{})
+	ctx := context.Background()
+
+	dbProvider := providerModel.Provider{Name: "mock-traffic-node", Type: "mock", Endpoint: "192.0.2.40", Status: "active",
+		TrafficCountMode: "out", TrafficMultiplier: 2}
+	if err := global.APP_DB.Create(&dbProvider).Error; err != nil {
+		t.Fatalf("创建Provider记录失败: %v", err)
+	}
+	prov, err := provider.GetProvider("mock")
+	if err != nil {
+		t.Fatalf("获取mock Provider失败: %v", err)
+	}
+	if err := prov.Connect(ctx, provider.NodeConfig{ID: dbProvider.ID, Name: dbProvider.Name, Host: dbProvider.Endpoint}); err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	if err := prov.CreateInstance(ctx, provider.InstanceConfig{Name: "ct1", Image: "debian12", CPU: "1", Memory: "512", Disk: "10240"}); err != nil {
+		t.Fatalf("创建实例失败: %v", err)
+	}
+	remote, err := prov.GetInstance(ctx, "ct1")
+	if err != nil {
+		t.Fatalf("查询实例失败: %v", err)
+	}
+	instance := providerModel.Instance{Name: "ct1", ProviderID: dbProvider.ID, UserID: 1, InstanceType: "container", Status: "running", PrivateIP: remote.PrivateIP}
+	if err := global.APP_DB.Create(&instance).Error; err != nil {
+		t.Fatalf("创建实例记录失败: %v", err)
+	}
+
+	// pmacct上报累积值，第三个采样点计数回落表示pmacct重启，之前的峰值需要计入
+	const mb = 1048576
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	samples := []struct{ rx, tx int64 }{{100 * mb, 50 * mb}, {300 * mb, 80 * mb}, {20 * mb, 10 * mb}, {200 * mb, 40 * mb}}
+	for i, sample := range samples {
+		ts := start.Add(time.Duration(i) * 5 * time.Minute)
+		record := monitoring.PmacctTrafficRecord{
+			InstanceID: instance.ID, UserID: 1, ProviderID: dbProvider.ID, ProviderType: "mock", MappedIP: remote.PrivateIP,
+			RxBytes: sample.rx, TxBytes: sample.tx, TotalBytes: sample.rx + sample.tx,
+			Timestamp: ts, Year: ts.Year(), Month: int(ts.Month()), Day: ts.Day(), Hour: ts.Hour(), Minute: ts.Minute(), RecordTime: ts,
+		}
+		if err := global.APP_DB.Create(&record).Error; err != nil {
+			t.Fatalf("写入流量采样失败: %v", err)
+		}
+	}
+
+	stats, err := NewQueryService().GetInstanceMonthlyTraffic(instance.ID, 2026, 3)
+	if err != nil {
+		t.Fatalf("查询月度流量失败: %v", err)
+	}
+	if stats.RxBytes != 500*mb || stats.TxBytes != 120*mb {
+		t.Errorf("重启分段累加结果不正确: rx=%d tx=%d", stats.RxBytes/mb, stats.TxBytes/mb)
+	}
+	if stats.ActualUsageMB != 240 {
+		t.Errorf("只计出站并乘以倍率后应为240MB，得到 %.2f", stats.ActualUsageMB)
+	}
+
+	if deleted, err := NewClearService().ClearInstanceTrafficRecords(instance.ID); err != nil || deleted != int64(len(samples)) {
+		t.Fatalf("清空流量记录失败: deleted=%d err=%v", deleted, err)
+	}
+	stats, err = NewQueryService().GetInstanceMonthlyTraffic(instance.ID, 2026, 3)
+	if err != nil || stats.TotalBytes != 0 {
+		t.Errorf("清空后仍有流量: %+v, %v", stats, err)
+	}
+}
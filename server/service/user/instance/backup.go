@@ -0,0 +1,296 @@
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/cache"
+	"oneclickvirt/service/storage"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// backupTaskTypes 备份相关任务类型，同一实例同时只允许一个备份任务
+var backupTaskTypes = []string{"create-backup", "restore-backup"}
+
+// maxManualBackups 每个实例保留的手动备份上限，定时备份由策略的保留数量控制
+const maxManualBackups = 5
+
+// GetInstanceBackups 获取实例备份列表
+func (s *Service) GetInstanceBackups(userID, instanceID uint) ([]providerModel.InstanceBackup, error) {
+	if !s.HasInstanceAccess(userID, instanceID) {
+		return nil, errors.New("实例不存在或无权限")
+	}
+
+	var backups []providerModel.InstanceBackup
+	if err := global.APP_DB.Where("instance_id = ?", instanceID).Order("created_at DESC").Find(&backups).Error; err != nil {
+		return nil, fmt.Errorf("获取备份列表失败: %v", err)
+	}
+	return backups, nil
+}
+
+// CreateInstanceBackup 创建实例备份（异步任务）
+func (s *Service) CreateInstanceBackup(userID, instanceID uint, req userModel.CreateBackupRequest) (*userModel.BackupTaskResponse, error) {
+	instance, err := s.getBackupInstance(userID, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.IsValidSnapshotName(req.Name) {
+		return nil, errors.New("备份名称只能包含字母、数字、下划线和连字符，且必须以字母开头")
+	}
+
+	var existing providerModel.InstanceBackup
+	if err := global.APP_DB.Where("instance_id = ? AND name = ?", instance.ID, req.Name).First(&existing).Error; err == nil {
+		return nil, errors.New("备份名称已存在")
+	}
+
+	var manualCount int64
+	global.APP_DB.Model(&providerModel.InstanceBackup{}).
+		Where("instance_id = ? AND trigger_type = ?", instance.ID, "manual").
+		Count(&manualCount)
+	if manualCount >= maxManualBackups {
+		return nil, fmt.Errorf("每个实例最多保留%d个手动备份，请先删除旧备份", maxManualBackups)
+	}
+
+	backup := providerModel.InstanceBackup{
+		InstanceID:  instance.ID,
+		ProviderID:  instance.ProviderID,
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+		TriggerType: "manual",
+		Status:      "creating",
+	}
+	if err := global.APP_DB.Create(&backup).Error; err != nil {
+		return nil, fmt.Errorf("创建备份记录失败: %v", err)
+	}
+
+	taskID, err := s.createBackupTask(userID, instance, &backup, "create-backup", "")
+	if err != nil {
+		global.APP_DB.Delete(&backup)
+		return nil, err
+	}
+
+	return &userModel.BackupTaskResponse{TaskID: taskID, BackupID: backup.ID}, nil
+}
+
+// RestoreInstanceBackup 用备份原地恢复实例（异步任务）
+func (s *Service) RestoreInstanceBackup(userID, instanceID, backupID uint) (*userModel.BackupTaskResponse, error) {
+	instance, err := s.getBackupInstance(userID, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := getAvailableBackup(instance.ID, backupID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 备份只能恢复到创建时所在的节点，迁移后归档格式可能不兼容
+	if backup.ProviderID != instance.ProviderID {
+		return nil, errors.New("实例已迁移到其他节点，无法恢复迁移前的备份")
+	}
+
+	originalStatus := instance.Status
+	taskID, err := s.createBackupTask(userID, instance, backup, "restore-backup", originalStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	global.APP_DB.Model(backup).Update("status", "restoring")
+	global.APP_DB.Model(instance).Update("status", "restoring")
+
+	return &userModel.BackupTaskResponse{TaskID: taskID, BackupID: backup.ID}, nil
+}
+
+// DeleteInstanceBackup 删除实例备份及其归档文件
+func (s *Service) DeleteInstanceBackup(userID, instanceID, backupID uint) error {
+	if !s.HasInstanceAccess(userID, instanceID) {
+		return errors.New("实例不存在或无权限")
+	}
+
+	var backup providerModel.InstanceBackup
+	if err := global.APP_DB.Where("id = ? AND instance_id = ?", backupID, instanceID).First(&backup).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("备份不存在")
+		}
+		return err
+	}
+	if backup.Status != "available" && backup.Status != "failed" {
+		return fmt.Errorf("备份当前状态为 %s，无法删除", backup.Status)
+	}
+
+	if err := storage.GetStorageService().RemoveBackupFile(backup.FilePath); err != nil {
+		return fmt.Errorf("删除备份文件失败: %v", err)
+	}
+	if err := global.APP_DB.Delete(&backup).Error; err != nil {
+		return fmt.Errorf("删除备份记录失败: %v", err)
+	}
+
+	global.APP_LOG.Info("用户删除实例备份",
+		zap.Uint("userID", userID),
+		zap.Uint("instanceID", instanceID),
+		zap.String("backup", backup.Name))
+	return nil
+}
+
+// GetInstanceBackupFile 获取可下载的备份归档路径和下载文件名
+func (s *Service) GetInstanceBackupFile(userID, instanceID, backupID uint) (string, string, error) {
+	if !s.HasInstanceAccess(userID, instanceID) {
+		return "", "", errors.New("实例不存在或无权限")
+	}
+
+	backup, err := getAvailableBackup(instanceID, backupID)
+	if err != nil {
+		return "", "", err
+	}
+
+	fullPath := storage.GetStorageService().GetBackupFilePath(backup.FilePath)
+	if _, err := os.Stat(fullPath); err != nil {
+		return "", "", errors.New("备份文件不存在")
+	}
+	return fullPath, filepath.Base(backup.FilePath), nil
+}
+
+// GetInstanceBackupPolicy 获取实例定时备份策略，未配置时返回默认值
+func (s *Service) GetInstanceBackupPolicy(userID, instanceID uint) (*providerModel.InstanceBackupPolicy, error) {
+	if !s.HasInstanceAccess(userID, instanceID) {
+		return nil, errors.New("实例不存在或无权限")
+	}
+
+	var policy providerModel.InstanceBackupPolicy
+	err := global.APP_DB.Where("instance_id = ?", instanceID).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &providerModel.InstanceBackupPolicy{
+			InstanceID: instanceID,
+			UserID:     userID,
+			Frequency:  "daily",
+			Hour:       3,
+			KeepLast:   7,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取备份策略失败: %v", err)
+	}
+	return &policy, nil
+}
+
+// UpdateInstanceBackupPolicy 创建或更新实例定时备份策略
+func (s *Service) UpdateInstanceBackupPolicy(userID, instanceID uint, req userModel.UpdateBackupPolicyRequest) (*providerModel.InstanceBackupPolicy, error) {
+	if !s.HasInstanceAccess(userID, instanceID) {
+		return nil, errors.New("实例不存在或无权限")
+	}
+
+	var policy providerModel.InstanceBackupPolicy
+	if err := global.APP_DB.Where("instance_id = ?", instanceID).First(&policy).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("获取备份策略失败: %v", err)
+		}
+		policy = providerModel.InstanceBackupPolicy{InstanceID: instanceID, UserID: userID}
+	}
+
+	policy.Enabled = req.Enabled
+	policy.Frequency = req.Frequency
+	policy.Hour = req.Hour
+	policy.Weekday = req.Weekday
+	policy.KeepLast = req.KeepLast
+	policy.NextRunAt = nil
+	if policy.Enabled {
+		nextRunAt := policy.NextRunAfter(time.Now())
+		policy.NextRunAt = &nextRunAt
+	}
+
+	if err := global.APP_DB.Save(&policy).Error; err != nil {
+		return nil, fmt.Errorf("保存备份策略失败: %v", err)
+	}
+
+	global.APP_LOG.Info("用户更新实例备份策略",
+		zap.Uint("userID", userID),
+		zap.Uint("instanceID", instanceID),
+		zap.Bool("enabled", policy.Enabled),
+		zap.String("frequency", policy.Frequency),
+		zap.Int("keepLast", policy.KeepLast))
+	return &policy, nil
+}
+
+// getBackupInstance 获取可执行备份操作的实例
+func (s *Service) getBackupInstance(userID, instanceID uint) (*providerModel.Instance, error) {
+	var instance providerModel.Instance
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", instanceID, userID).First(&instance).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("实例不存在或无权限")
+		}
+		return nil, err
+	}
+
+	if instance.Status != "running" && instance.Status != "stopped" {
+		return nil, errors.New("只有运行中或已停止的实例才能进行备份操作")
+	}
+
+	var existingTask adminModel.Task
+	if err := global.APP_DB.Where("instance_id = ? AND task_type IN (?) AND status IN ('pending', 'running')", instance.ID, backupTaskTypes).First(&existingTask).Error; err == nil {
+		return nil, errors.New("实例已有备份任务正在进行，请稍后重试")
+	}
+
+	return &instance, nil
+}
+
+// createBackupTask 创建备份相关任务
+func (s *Service) createBackupTask(userID uint, instance *providerModel.Instance, backup *providerModel.InstanceBackup, taskType, originalStatus string) (uint, error) {
+	defer func() {
+		cacheService := cache.GetUserCacheService()
+		cacheService.InvalidateUserCache(userID)
+		cacheService.InvalidateInstanceCache(instance.ID)
+	}()
+
+	taskData, err := json.Marshal(adminModel.BackupTaskRequest{
+		InstanceId:     instance.ID,
+		ProviderId:     instance.ProviderID,
+		BackupId:       backup.ID,
+		OriginalStatus: originalStatus,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("序列化任务数据失败: %v", err)
+	}
+
+	taskService := getTaskService()
+	taskModel, err := taskService.CreateTask(userID, &instance.ProviderID, &instance.ID, taskType, string(taskData), 3600)
+	if err != nil {
+		return 0, fmt.Errorf("创建备份任务失败: %v", err)
+	}
+
+	global.APP_LOG.Info("用户创建实例备份任务",
+		zap.Uint("userID", userID),
+		zap.Uint("instanceID", instance.ID),
+		zap.String("taskType", taskType),
+		zap.String("backup", backup.Name),
+		zap.Uint("taskID", taskModel.ID))
+
+	return taskModel.ID, nil
+}
+
+// getAvailableBackup 获取可用状态的备份
+func getAvailableBackup(instanceID, backupID uint) (*providerModel.InstanceBackup, error) {
+	var backup providerModel.InstanceBackup
+	if err := global.APP_DB.Where("id = ? AND instance_id = ?", backupID, instanceID).First(&backup).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("备份不存在")
+		}
+		return nil, err
+	}
+	if backup.Status != "available" {
+		return nil, fmt.Errorf("备份当前状态为 %s，不可用", backup.Status)
+	}
+	return &backup, nil
+}
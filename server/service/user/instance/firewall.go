@@ -0,0 +1,199 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/cache"
+	"oneclickvirt/service/database"
+	"oneclickvirt/service/resources"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// GetInstanceFirewall 获取实例防火墙规则及当前等级允许的规则数量
+func (s *Service) GetInstanceFirewall(userID, instanceID uint) (*userModel.FirewallRulesResponse, error) {
+	if !s.HasInstanceAccess(userID, instanceID) {
+		return nil, errors.New("实例不存在或无权限")
+	}
+
+	var rules []providerModel.FirewallRule
+	if err := global.APP_DB.Where("instance_id = ?", instanceID).Order("priority ASC, id ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("获取防火墙规则失败: %v", err)
+	}
+
+	var currentUser userModel.User
+	if err := global.APP_DB.Select("level").First(&currentUser, userID).Error; err != nil {
+		return nil, fmt.Errorf("获取用户信息失败: %v", err)
+	}
+
+	return &userModel.FirewallRulesResponse{
+		Rules:    rules,
+		MaxRules: global.APP_CONFIG.Quota.LevelLimits[currentUser.Level].MaxFirewallRules,
+	}, nil
+}
+
+// UpdateInstanceFirewall 整体替换实例防火墙规则并在宿主机上应用（异步任务）
+func (s *Service) UpdateInstanceFirewall(userID, instanceID uint, req userModel.UpdateFirewallRulesRequest) (*userModel.FirewallTaskResponse, error) {
+	var instance providerModel.Instance
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", instanceID, userID).First(&instance).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("实例不存在或无权限")
+		}
+		return nil, err
+	}
+	if instance.Status != "running" && instance.Status != "stopped" {
+		return nil, errors.New("只有运行中或已停止的实例才能修改防火墙规则")
+	}
+
+	var dbProvider providerModel.Provider
+	if err := global.APP_DB.First(&dbProvider, instance.ProviderID).Error; err != nil {
+		return nil, errors.New("实例所在节点不存在")
+	}
+
+	rules := make([]providerModel.FirewallRule, 0, len(req.Rules))
+	for idx, ruleReq := range req.Rules {
+		rule, err := buildFirewallRule(&instance, ruleReq)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 条规则无效: %v", idx+1, err)
+		}
+		if err := checkFirewallMappingMethod(&dbProvider, rule.IPVersion); err != nil {
+			return nil, err
+		}
+		rule.Priority = idx
+		rules = append(rules, *rule)
+	}
+
+	// 在事务中验证规则数量并整体替换，防止并发修改超限
+	if err := database.GetDatabaseService().ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+		quotaService := resources.NewQuotaService()
+		result, err := quotaService.ValidateFirewallRulesInTx(tx, userID, len(rules))
+		if err != nil {
+			return err
+		}
+		if !result.Allowed {
+			return errors.New(result.Reason)
+		}
+		if err := tx.Where("instance_id = ?", instance.ID).Delete(&providerModel.FirewallRule{}).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	taskID, err := createFirewallTask(userID, &instance)
+	if err != nil {
+		return nil, err
+	}
+
+	return &userModel.FirewallTaskResponse{TaskID: taskID, InstanceID: instance.ID}, nil
+}
+
+// checkFirewallMappingMethod LXD/Incus的device_proxy映射由宿主机进程代理连接，流量不经过FORWARD链，规则无法生效
+func checkFirewallMappingMethod(dbProvider *providerModel.Provider, ipVersion string) error {
+	if dbProvider.Type != "lxd" && dbProvider.Type != "incus" {
+		return nil
+	}
+	family, method := "IPv4", dbProvider.IPv4PortMappingMethod
+	if ipVersion == providerModel.FirewallIPv6 {
+		family, method = "IPv6", dbProvider.IPv6PortMappingMethod
+	}
+	if method == "device_proxy" {
+		return fmt.Errorf("当前节点的%s端口映射方式为device_proxy，流量由宿主机代理转发，不支持防火墙规则", family)
+	}
+	return nil
+}
+
+// buildFirewallRule 校验并规范化单条防火墙规则
+func buildFirewallRule(instance *providerModel.Instance, req userModel.FirewallRuleRequest) (*providerModel.FirewallRule, error) {
+	rule := providerModel.FirewallRule{
+		InstanceID:  instance.ID,
+		Action:      req.Action,
+		Protocol:    req.Protocol,
+		PortStart:   req.PortStart,
+		PortEnd:     req.PortEnd,
+		IPVersion:   req.IPVersion,
+		Description: strings.TrimSpace(req.Description),
+	}
+	if rule.IPVersion == "" {
+		rule.IPVersion = providerModel.FirewallIPv4
+	}
+	if rule.IPVersion == providerModel.FirewallIPv6 && instance.IPv6Address == "" && instance.PublicIPv6 == "" {
+		return nil, errors.New("实例没有IPv6地址，无法添加IPv6规则")
+	}
+
+	// 端口范围只对tcp/udp有效
+	if rule.Protocol == providerModel.FirewallProtocolTCP || rule.Protocol == providerModel.FirewallProtocolUDP {
+		if rule.PortEnd == 0 {
+			rule.PortEnd = rule.PortStart
+		}
+		if rule.PortStart == 0 && rule.PortEnd != 0 {
+			return nil, errors.New("指定结束端口时必须指定起始端口")
+		}
+		if rule.PortEnd < rule.PortStart {
+			return nil, errors.New("结束端口不能小于起始端口")
+		}
+	} else if rule.PortStart != 0 || rule.PortEnd != 0 {
+		return nil, fmt.Errorf("协议 %s 不支持指定端口", rule.Protocol)
+	}
+
+	// 来源可以是单个地址或网段，统一保存为网段形式
+	source := strings.TrimSpace(req.SourceCIDR)
+	if source != "" {
+		if !strings.Contains(source, "/") {
+			if rule.IPVersion == providerModel.FirewallIPv6 {
+				source += "/128"
+			} else {
+				source += "/32"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(source)
+		if err != nil {
+			return nil, fmt.Errorf("来源地址 %s 格式错误", req.SourceCIDR)
+		}
+		if (ipNet.IP.To4() != nil) != (rule.IPVersion == providerModel.FirewallIPv4) {
+			return nil, fmt.Errorf("来源地址 %s 与规则的IP版本不一致", req.SourceCIDR)
+		}
+		rule.SourceCIDR = ipNet.String()
+	}
+
+	return &rule, nil
+}
+
+// createFirewallTask 创建应用防火墙规则任务
+func createFirewallTask(userID uint, instance *providerModel.Instance) (uint, error) {
+	defer cache.GetUserCacheService().InvalidateInstanceCache(instance.ID)
+
+	taskData, err := json.Marshal(adminModel.FirewallTaskRequest{
+		InstanceId: instance.ID,
+		ProviderId: instance.ProviderID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("序列化任务数据失败: %v", err)
+	}
+
+	taskModel, err := getTaskService().CreateTask(userID, &instance.ProviderID, &instance.ID, "apply-firewall", string(taskData), 0)
+	if err != nil {
+		return 0, fmt.Errorf("创建防火墙任务失败: %v", err)
+	}
+
+	global.APP_LOG.Info("用户创建防火墙任务",
+		zap.Uint("userID", userID),
+		zap.Uint("instanceID", instance.ID),
+		zap.Uint("taskID", taskModel.ID))
+
+	return taskModel.ID, nil
+}
@@ -0,0 +1,354 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/provider"
+	"oneclickvirt/service/cache"
+	"oneclickvirt/service/database"
+	provider2 "oneclickvirt/service/provider"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// maxPrivateNetworksPerProvider 每个用户在同一节点上最多创建的私有网络数量
+const maxPrivateNetworksPerProvider = 5
+
+// Proxmox私有网络可分配的VLAN标签范围
+const (
+	privateNetworkVlanMin = 2
+	privateNetworkVlanMax = 4094
+)
+
+// checkHostNetworkConflict 检查网段是否与节点默认实例网段或节点路由冲突，无法获取路由时拒绝创建
+func checkHostNetworkConflict(networkProvider provider.PrivateNetworkProvider, providerType string, ipNet *net.IPNet) error {
+	prov, ok := networkProvider.(provider.Provider)
+	if !ok {
+		return errors.New("无法校验节点网络")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	output, err := prov.ExecuteSSHCommand(ctx, provider.HostRouteCommand)
+	if err != nil {
+		global.APP_LOG.Warn("获取节点路由失败", zap.String("provider", prov.GetName()), zap.Error(err))
+		return errors.New("获取节点路由失败，无法校验网段")
+	}
+	return provider.CheckPrivateNetworkHostConflict(ipNet, providerType, provider.ParseHostRoutes(output))
+}
+
+// GetUserPrivateNetworks 获取用户私有网络列表，包含接入的实例
+func (s *Service) GetUserPrivateNetworks(userID uint) ([]providerModel.PrivateNetwork, error) {
+	var networks []providerModel.PrivateNetwork
+	if err := global.APP_DB.Preload("Attachments").Where("user_id = ?", userID).
+		Order("created_at DESC").Find(&networks).Error; err != nil {
+		return nil, fmt.Errorf("获取私有网络列表失败: %v", err)
+	}
+	return networks, nil
+}
+
+// CreatePrivateNetwork 在节点上创建私有网络（异步任务）
+func (s *Service) CreatePrivateNetwork(userID uint, req userModel.CreatePrivateNetworkRequest) (*userModel.PrivateNetworkTaskResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("私有网络名称不能为空")
+	}
+	ipNet, err := provider.ParsePrivateNetworkCIDR(strings.TrimSpace(req.CIDR))
+	if err != nil {
+		return nil, err
+	}
+
+	var dbProvider providerModel.Provider
+	if err := global.APP_DB.Where("id = ? AND status IN (?) AND allow_claim = ? AND is_frozen = ?",
+		req.ProviderID, []string{"active", "partial"}, true, false).First(&dbProvider).Error; err != nil {
+		return nil, errors.New("节点不存在或不可用")
+	}
+	networkProvider, err := provider2.GetProviderService().GetPrivateNetworkProvider(dbProvider.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkHostNetworkConflict(networkProvider, dbProvider.Type, ipNet); err != nil {
+		return nil, err
+	}
+
+	network := providerModel.PrivateNetwork{
+		Name:       name,
+		ProviderID: dbProvider.ID,
+		UserID:     userID,
+		CIDR:       ipNet.String(),
+		Status:     providerModel.PrivateNetworkStatusCreating,
+	}
+
+	// 在事务中锁定节点记录后检查数量、网段和VLAN，防止并发创建冲突
+	if err := database.GetDatabaseService().ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&providerModel.Provider{}, dbProvider.ID).Error; err != nil {
+			return err
+		}
+
+		var existing []providerModel.PrivateNetwork
+		if err := tx.Where("provider_id = ? AND status <> ?", dbProvider.ID, providerModel.PrivateNetworkStatusFailed).
+			Find(&existing).Error; err != nil {
+			return err
+		}
+
+		owned := 0
+		usedVlans := make(map[int]bool)
+		for _, other := range existing {
+			if other.UserID == userID {
+				owned++
+			}
+			usedVlans[other.VlanID] = true
+			// LXD和Docker的网桥在节点上持有网关地址，网段重叠会导致节点路由冲突；Proxmox网桥不持有地址，按VLAN隔离
+			if dbProvider.Type == "proxmox" {
+				continue
+			}
+			if otherNet, err := provider.ParsePrivateNetworkCIDR(other.CIDR); err == nil && provider.PrivateNetworksOverlap(ipNet, otherNet) {
+				return fmt.Errorf("网段 %s 与节点上已有的私有网络冲突，请更换网段", network.CIDR)
+			}
+		}
+		if owned >= maxPrivateNetworksPerProvider {
+			return fmt.Errorf("每个节点最多创建 %d 个私有网络", maxPrivateNetworksPerProvider)
+		}
+
+		if dbProvider.Type == "proxmox" {
+			for vlan := privateNetworkVlanMin; vlan <= privateNetworkVlanMax; vlan++ {
+				if !usedVlans[vlan] {
+					network.VlanID = vlan
+					break
+				}
+			}
+			if network.VlanID == 0 {
+				return errors.New("节点上没有可用的VLAN标签")
+			}
+		}
+
+		return tx.Create(&network).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	taskID, err := createPrivateNetworkTask(userID, &network, nil, "create-private-network", adminModel.PrivateNetworkTaskRequest{
+		NetworkId:  network.ID,
+		ProviderId: network.ProviderID,
+	})
+	if err != nil {
+		global.APP_DB.Delete(&network)
+		return nil, err
+	}
+
+	return &userModel.PrivateNetworkTaskResponse{TaskID: taskID, NetworkID: network.ID}, nil
+}
+
+// DeletePrivateNetwork 删除没有实例接入的私有网络（异步任务），创建失败的网络直接删除记录
+func (s *Service) DeletePrivateNetwork(userID, networkID uint) (*userModel.PrivateNetworkTaskResponse, error) {
+	network, err := getUserPrivateNetwork(userID, networkID)
+	if err != nil {
+		return nil, err
+	}
+
+	if network.Status == providerModel.PrivateNetworkStatusFailed {
+		if err := global.APP_DB.Delete(network).Error; err != nil {
+			return nil, fmt.Errorf("删除私有网络记录失败: %v", err)
+		}
+		return &userModel.PrivateNetworkTaskResponse{NetworkID: network.ID}, nil
+	}
+
+	var attached int64
+	global.APP_DB.Model(&providerModel.PrivateNetworkAttachment{}).Where("network_id = ?", network.ID).Count(&attached)
+	if attached > 0 {
+		return nil, errors.New("私有网络中仍有实例，请先断开")
+	}
+
+	result := global.APP_DB.Model(network).Where("status = ?", providerModel.PrivateNetworkStatusActive).
+		Update("status", providerModel.PrivateNetworkStatusDeleting)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("私有网络当前状态为 %s，无法删除", network.Status)
+	}
+
+	taskID, err := createPrivateNetworkTask(userID, network, nil, "delete-private-network", adminModel.PrivateNetworkTaskRequest{
+		NetworkId:  network.ID,
+		ProviderId: network.ProviderID,
+	})
+	if err != nil {
+		global.APP_DB.Model(network).Update("status", providerModel.PrivateNetworkStatusActive)
+		return nil, err
+	}
+
+	return &userModel.PrivateNetworkTaskResponse{TaskID: taskID, NetworkID: network.ID}, nil
+}
+
+// AttachPrivateNetwork 实例接入私有网络（异步任务），只能接入同一节点上自己的实例
+func (s *Service) AttachPrivateNetwork(userID, networkID uint, req userModel.AttachPrivateNetworkRequest) (*userModel.PrivateNetworkTaskResponse, error) {
+	network, err := getUserPrivateNetwork(userID, networkID)
+	if err != nil {
+		return nil, err
+	}
+	if network.Status != providerModel.PrivateNetworkStatusActive {
+		return nil, fmt.Errorf("私有网络当前状态为 %s，无法接入实例", network.Status)
+	}
+
+	var instance providerModel.Instance
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", req.InstanceID, userID).First(&instance).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("实例不存在或无权限")
+		}
+		return nil, err
+	}
+	if instance.ProviderID != network.ProviderID {
+		return nil, errors.New("只能接入同一节点上的实例")
+	}
+	if instance.Status != "running" && instance.Status != "stopped" {
+		return nil, errors.New("只有运行中或已停止的实例才能接入私有网络")
+	}
+
+	ipNet, err := provider.ParsePrivateNetworkCIDR(network.CIDR)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := providerModel.PrivateNetworkAttachment{
+		NetworkID:  network.ID,
+		InstanceID: instance.ID,
+		Status:     providerModel.NetworkAttachmentStatusAttaching,
+	}
+	// 唯一索引保证同一实例不会重复接入、同一地址不会分配两次
+	if err := database.GetDatabaseService().ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+		var attachments []providerModel.PrivateNetworkAttachment
+		if err := tx.Where("network_id = ?", network.ID).Find(&attachments).Error; err != nil {
+			return err
+		}
+		used := make([]string, 0, len(attachments))
+		for _, existing := range attachments {
+			if existing.InstanceID == instance.ID {
+				return errors.New("实例已接入该私有网络")
+			}
+			used = append(used, existing.IPAddress)
+		}
+
+		if req.IPAddress != "" {
+			if err := provider.ValidatePrivateNetworkIP(ipNet, req.IPAddress); err != nil {
+				return err
+			}
+			for _, address := range used {
+				if address == req.IPAddress {
+					return fmt.Errorf("地址 %s 已被使用", req.IPAddress)
+				}
+			}
+			attachment.IPAddress = req.IPAddress
+		} else {
+			address, err := provider.NextPrivateNetworkIP(ipNet, used)
+			if err != nil {
+				return err
+			}
+			attachment.IPAddress = address
+		}
+		return tx.Create(&attachment).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	taskID, err := createPrivateNetworkTask(userID, network, &instance.ID, "attach-private-network", adminModel.PrivateNetworkTaskRequest{
+		NetworkId:    network.ID,
+		ProviderId:   network.ProviderID,
+		AttachmentId: attachment.ID,
+		InstanceId:   instance.ID,
+	})
+	if err != nil {
+		global.APP_DB.Delete(&attachment)
+		return nil, err
+	}
+
+	return &userModel.PrivateNetworkTaskResponse{TaskID: taskID, NetworkID: network.ID}, nil
+}
+
+// DetachPrivateNetwork 实例断开私有网络（异步任务）
+func (s *Service) DetachPrivateNetwork(userID, networkID uint, req userModel.DetachPrivateNetworkRequest) (*userModel.PrivateNetworkTaskResponse, error) {
+	network, err := getUserPrivateNetwork(userID, networkID)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachment providerModel.PrivateNetworkAttachment
+	if err := global.APP_DB.Where("network_id = ? AND instance_id = ?", network.ID, req.InstanceID).First(&attachment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("实例未接入该私有网络")
+		}
+		return nil, err
+	}
+
+	result := global.APP_DB.Model(&attachment).Where("status = ?", providerModel.NetworkAttachmentStatusAttached).
+		Update("status", providerModel.NetworkAttachmentStatusDetaching)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("接入状态为 %s，无法断开", attachment.Status)
+	}
+
+	taskID, err := createPrivateNetworkTask(userID, network, &attachment.InstanceID, "detach-private-network", adminModel.PrivateNetworkTaskRequest{
+		NetworkId:    network.ID,
+		ProviderId:   network.ProviderID,
+		AttachmentId: attachment.ID,
+		InstanceId:   attachment.InstanceID,
+	})
+	if err != nil {
+		global.APP_DB.Model(&attachment).Update("status", providerModel.NetworkAttachmentStatusAttached)
+		return nil, err
+	}
+
+	return &userModel.PrivateNetworkTaskResponse{TaskID: taskID, NetworkID: network.ID}, nil
+}
+
+// getUserPrivateNetwork 获取用户的私有网络
+func getUserPrivateNetwork(userID, networkID uint) (*providerModel.PrivateNetwork, error) {
+	var network providerModel.PrivateNetwork
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", networkID, userID).First(&network).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("私有网络不存在")
+		}
+		return nil, err
+	}
+	return &network, nil
+}
+
+// createPrivateNetworkTask 创建私有网络相关任务
+func createPrivateNetworkTask(userID uint, network *providerModel.PrivateNetwork, instanceID *uint, taskType string, taskReq adminModel.PrivateNetworkTaskRequest) (uint, error) {
+	defer func() {
+		cacheService := cache.GetUserCacheService()
+		cacheService.InvalidateUserCache(userID)
+		if instanceID != nil {
+			cacheService.InvalidateInstanceCache(*instanceID)
+		}
+	}()
+
+	taskData, err := json.Marshal(taskReq)
+	if err != nil {
+		return 0, fmt.Errorf("序列化任务数据失败: %v", err)
+	}
+
+	taskModel, err := getTaskService().CreateTask(userID, &network.ProviderID, instanceID, taskType, string(taskData), 0)
+	if err != nil {
+		return 0, fmt.Errorf("创建私有网络任务失败: %v", err)
+	}
+
+	global.APP_LOG.Info("用户创建私有网络任务",
+		zap.Uint("userID", userID),
+		zap.Uint("networkID", network.ID),
+		zap.String("taskType", taskType),
+		zap.Uint("taskID", taskModel.ID))
+
+	return taskModel.ID, nil
+}
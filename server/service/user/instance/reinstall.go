@@ -0,0 +1,102 @@
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/auth"
+	"oneclickvirt/service/cache"
+	"oneclickvirt/service/images"
+	"oneclickvirt/service/user/profile"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ReinstallInstance 使用指定镜像重装实例系统（异步任务），保留端口映射和网络信息
+func (s *Service) ReinstallInstance(userID, instanceID uint, req userModel.ReinstallInstanceRequest) (uint, error) {
+	var instance providerModel.Instance
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", instanceID, userID).First(&instance).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("实例不存在或无权限")
+		}
+		return 0, err
+	}
+
+	if instance.Status != "running" && instance.Status != "stopped" {
+		return 0, errors.New("实例状态不允许重装系统")
+	}
+
+	// 重装与重置使用相同的等级权限
+	permissionService := auth.PermissionService{}
+	if !permissionService.CheckInstanceResetPermission(userID, instance.InstanceType) {
+		return 0, errors.New("您的等级不足，无法自行重装系统，请联系管理员处理")
+	}
+
+	var existingTask adminModel.Task
+	if err := global.APP_DB.Where("instance_id = ? AND task_type IN ('reset', 'reinstall') AND status IN ('pending', 'running')", instance.ID).First(&existingTask).Error; err == nil {
+		return 0, errors.New("实例已有重置或重装任务正在进行")
+	}
+
+	// 镜像必须来自该节点的可用镜像列表并与节点兼容
+	imageService := &images.ImageService{}
+	image, err := imageService.GetReinstallImage(instance.ProviderID, instance.InstanceType, req.ImageID)
+	if err != nil {
+		return 0, err
+	}
+	if image.MinMemoryMB > 0 && instance.Memory < int64(image.MinMemoryMB) {
+		return 0, fmt.Errorf("镜像 %s 最少需要%dMB内存，当前实例只有%dMB", image.Name, image.MinMemoryMB, instance.Memory)
+	}
+	if image.MinDiskMB > 0 && instance.Disk < int64(image.MinDiskMB) {
+		return 0, fmt.Errorf("镜像 %s 最少需要%dMB硬盘，当前实例只有%dMB", image.Name, image.MinDiskMB, instance.Disk)
+	}
+	if err := profile.ValidateLoginOptions(userID, req.SSHKeyIDs, req.DisablePasswordLogin); err != nil {
+		return 0, err
+	}
+	var dbProvider providerModel.Provider
+	if err := global.APP_DB.Select("id", "type").First(&dbProvider, instance.ProviderID).Error; err != nil {
+		return 0, fmt.Errorf("获取节点信息失败: %v", err)
+	}
+	if err := permissionService.ValidateInstanceUserData(userID, req.UserData, dbProvider.Type, instance.InstanceType); err != nil {
+		return 0, err
+	}
+
+	taskData, err := json.Marshal(adminModel.ReinstallTaskRequest{
+		InstanceId:           instance.ID,
+		ProviderId:           instance.ProviderID,
+		SystemImageId:        image.ID,
+		OriginalStatus:       instance.Status,
+		SSHKeyIDs:            req.SSHKeyIDs,
+		DisablePasswordLogin: req.DisablePasswordLogin,
+		UserData:             req.UserData,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("序列化任务数据失败: %v", err)
+	}
+
+	taskService := getTaskService()
+	taskModel, err := taskService.CreateTask(userID, &instance.ProviderID, &instance.ID, "reinstall", string(taskData), 1800)
+	if err != nil {
+		return 0, fmt.Errorf("创建重装系统任务失败: %v", err)
+	}
+
+	global.APP_DB.Model(&instance).Update("status", "reinstalling")
+
+	cacheService := cache.GetUserCacheService()
+	cacheService.InvalidateUserCache(userID)
+	cacheService.InvalidateInstanceCache(instance.ID)
+
+	global.APP_LOG.Info("用户创建重装系统任务",
+		zap.Uint("userID", userID),
+		zap.Uint("instanceID", instance.ID),
+		zap.String("fromImage", instance.Image),
+		zap.String("toImage", image.Name),
+		zap.Uint("taskID", taskModel.ID))
+
+	return taskModel.ID, nil
+}
@@ -0,0 +1,102 @@
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/cache"
+	"oneclickvirt/service/resources"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ResizeInstance 调整实例配置（异步任务）
+func (s *Service) ResizeInstance(userID, instanceID uint, req userModel.ResizeInstanceRequest) (uint, error) {
+	var instance providerModel.Instance
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", instanceID, userID).First(&instance).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("实例不存在或无权限")
+		}
+		return 0, err
+	}
+
+	if instance.Status != "running" && instance.Status != "stopped" {
+		return 0, errors.New("只有运行中或已停止的实例才能调整配置")
+	}
+	if req.Disk < instance.Disk {
+		return 0, errors.New("磁盘只支持扩容，不支持缩小")
+	}
+	if req.CPU == instance.CPU && req.Memory == instance.Memory && req.Disk == instance.Disk && req.Bandwidth == instance.Bandwidth {
+		return 0, errors.New("配置未发生变化")
+	}
+
+	var existingTask adminModel.Task
+	if err := global.APP_DB.Where("instance_id = ? AND task_type = 'resize' AND status IN ('pending', 'running')", instance.ID).First(&existingTask).Error; err == nil {
+		return 0, errors.New("实例已有调整配置任务正在进行")
+	}
+
+	// 提前校验配额，任务执行时会在事务中再次校验
+	if err := global.APP_DB.Transaction(func(tx *gorm.DB) error {
+		result, err := resources.NewQuotaService().ValidateInTransaction(tx, resources.ResourceRequest{
+			UserID:            userID,
+			CPU:               req.CPU,
+			Memory:            req.Memory,
+			Disk:              req.Disk,
+			Bandwidth:         req.Bandwidth,
+			InstanceType:      instance.InstanceType,
+			ProviderID:        instance.ProviderID,
+			ExcludeInstanceID: instance.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if !result.Allowed {
+			return errors.New(result.Reason)
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	taskData, err := json.Marshal(adminModel.ResizeTaskRequest{
+		InstanceId:     instance.ID,
+		ProviderId:     instance.ProviderID,
+		CPU:            req.CPU,
+		Memory:         req.Memory,
+		Disk:           req.Disk,
+		Bandwidth:      req.Bandwidth,
+		OriginalStatus: instance.Status,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("序列化任务数据失败: %v", err)
+	}
+
+	taskService := getTaskService()
+	taskModel, err := taskService.CreateTask(userID, &instance.ProviderID, &instance.ID, "resize", string(taskData), 900)
+	if err != nil {
+		return 0, fmt.Errorf("创建调整配置任务失败: %v", err)
+	}
+
+	global.APP_DB.Model(&instance).Update("status", "resizing")
+
+	cacheService := cache.GetUserCacheService()
+	cacheService.InvalidateUserCache(userID)
+	cacheService.InvalidateInstanceCache(instance.ID)
+
+	global.APP_LOG.Info("用户创建调整实例配置任务",
+		zap.Uint("userID", userID),
+		zap.Uint("instanceID", instance.ID),
+		zap.Int("cpu", req.CPU),
+		zap.Int64("memory", req.Memory),
+		zap.Int64("disk", req.Disk),
+		zap.Int("bandwidth", req.Bandwidth),
+		zap.Uint("taskID", taskModel.ID))
+
+	return taskModel.ID, nil
+}
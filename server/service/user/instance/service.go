@@ -272,8 +272,8 @@ func (s *Service) InstanceAction(userID uint, req userModel.InstanceActionReques
 
 		// 检查是否已有进行中的重置任务
 		var existingTask adminModel.Task
-		if err := global.APP_DB.Where("instance_id = ? AND task_type = 'reset' AND status IN ('pending', 'running')", instance.ID).First(&existingTask).Error; err == nil {
-			return errors.New("实例已有重置任务正在进行")
+		if err := global.APP_DB.Where("instance_id = ? AND task_type IN ('reset', 'reinstall') AND status IN ('pending', 'running')", instance.ID).First(&existingTask).Error; err == nil {
+			return errors.New("实例已有重置或重装任务正在进行")
 		}
 
 		// 创建重置任务，记录原始状态
@@ -0,0 +1,224 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/cache"
+	"oneclickvirt/service/database"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// snapshotTaskTypes 快照相关任务类型，同一实例同时只允许一个快照任务
+var snapshotTaskTypes = []string{"create-snapshot", "restore-snapshot", "delete-snapshot"}
+
+// GetInstanceSnapshots 获取实例快照列表
+func (s *Service) GetInstanceSnapshots(userID, instanceID uint) ([]providerModel.InstanceSnapshot, error) {
+	if !s.HasInstanceAccess(userID, instanceID) {
+		return nil, errors.New("实例不存在或无权限")
+	}
+
+	var snapshots []providerModel.InstanceSnapshot
+	if err := global.APP_DB.Where("instance_id = ?", instanceID).Order("created_at DESC").Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("获取快照列表失败: %v", err)
+	}
+	return snapshots, nil
+}
+
+// CreateInstanceSnapshot 创建实例快照（异步任务）
+func (s *Service) CreateInstanceSnapshot(userID, instanceID uint, req userModel.CreateSnapshotRequest) (*userModel.SnapshotTaskResponse, error) {
+	instance, err := s.getSnapshotInstance(userID, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.IsValidSnapshotName(req.Name) {
+		return nil, errors.New("快照名称只能包含字母、数字、下划线和连字符，且必须以字母开头")
+	}
+
+	var existing providerModel.InstanceSnapshot
+	if err := global.APP_DB.Where("instance_id = ? AND name = ?", instance.ID, req.Name).First(&existing).Error; err == nil {
+		return nil, errors.New("快照名称已存在")
+	}
+
+	snapshot := providerModel.InstanceSnapshot{
+		InstanceID:  instance.ID,
+		ProviderID:  instance.ProviderID,
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+		Status:      "creating",
+	}
+
+	// 在事务中验证快照配额并创建记录，防止并发超限
+	dbService := database.GetDatabaseService()
+	if err := dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+		quotaService := resources.NewQuotaService()
+		result, err := quotaService.ValidateSnapshotCreationInTx(tx, userID, instance.ID)
+		if err != nil {
+			return err
+		}
+		if !result.Allowed {
+			return errors.New(result.Reason)
+		}
+		return tx.Create(&snapshot).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	taskID, err := s.createSnapshotTask(userID, instance, &snapshot, "create-snapshot", "")
+	if err != nil {
+		global.APP_DB.Delete(&snapshot)
+		return nil, err
+	}
+
+	return &userModel.SnapshotTaskResponse{TaskID: taskID, SnapshotID: snapshot.ID}, nil
+}
+
+// RestoreInstanceSnapshot 将实例恢复到指定快照（异步任务）
+func (s *Service) RestoreInstanceSnapshot(userID, instanceID, snapshotID uint) (*userModel.SnapshotTaskResponse, error) {
+	instance, err := s.getSnapshotInstance(userID, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := getAvailableSnapshot(instance.ID, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	originalStatus := instance.Status
+	taskID, err := s.createSnapshotTask(userID, instance, snapshot, "restore-snapshot", originalStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	global.APP_DB.Model(snapshot).Update("status", "restoring")
+	global.APP_DB.Model(instance).Update("status", "restoring")
+
+	return &userModel.SnapshotTaskResponse{TaskID: taskID, SnapshotID: snapshot.ID}, nil
+}
+
+// DeleteInstanceSnapshot 删除实例快照（异步任务）
+func (s *Service) DeleteInstanceSnapshot(userID, instanceID, snapshotID uint) (*userModel.SnapshotTaskResponse, error) {
+	if !s.HasInstanceAccess(userID, instanceID) {
+		return nil, errors.New("实例不存在或无权限")
+	}
+
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil {
+		return nil, fmt.Errorf("获取实例信息失败: %v", err)
+	}
+	if err := checkSnapshotTaskInProgress(instance.ID); err != nil {
+		return nil, err
+	}
+
+	var snapshot providerModel.InstanceSnapshot
+	if err := global.APP_DB.Where("id = ? AND instance_id = ?", snapshotID, instance.ID).First(&snapshot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("快照不存在")
+		}
+		return nil, err
+	}
+	if snapshot.Status != "available" && snapshot.Status != "failed" {
+		return nil, fmt.Errorf("快照当前状态为 %s，无法删除", snapshot.Status)
+	}
+
+	taskID, err := s.createSnapshotTask(userID, &instance, &snapshot, "delete-snapshot", "")
+	if err != nil {
+		return nil, err
+	}
+
+	global.APP_DB.Model(&snapshot).Update("status", "deleting")
+
+	return &userModel.SnapshotTaskResponse{TaskID: taskID, SnapshotID: snapshot.ID}, nil
+}
+
+// getSnapshotInstance 获取可执行快照操作的实例
+func (s *Service) getSnapshotInstance(userID, instanceID uint) (*providerModel.Instance, error) {
+	var instance providerModel.Instance
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", instanceID, userID).First(&instance).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("实例不存在或无权限")
+		}
+		return nil, err
+	}
+
+	if instance.Status != "running" && instance.Status != "stopped" {
+		return nil, errors.New("只有运行中或已停止的实例才能进行快照操作")
+	}
+
+	if err := checkSnapshotTaskInProgress(instance.ID); err != nil {
+		return nil, err
+	}
+
+	return &instance, nil
+}
+
+// createSnapshotTask 创建快照相关任务
+func (s *Service) createSnapshotTask(userID uint, instance *providerModel.Instance, snapshot *providerModel.InstanceSnapshot, taskType, originalStatus string) (uint, error) {
+	defer func() {
+		cacheService := cache.GetUserCacheService()
+		cacheService.InvalidateUserCache(userID)
+		cacheService.InvalidateInstanceCache(instance.ID)
+	}()
+
+	taskData, err := json.Marshal(adminModel.SnapshotTaskRequest{
+		InstanceId:     instance.ID,
+		ProviderId:     instance.ProviderID,
+		SnapshotId:     snapshot.ID,
+		OriginalStatus: originalStatus,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("序列化任务数据失败: %v", err)
+	}
+
+	taskService := getTaskService()
+	taskModel, err := taskService.CreateTask(userID, &instance.ProviderID, &instance.ID, taskType, string(taskData), 1800)
+	if err != nil {
+		return 0, fmt.Errorf("创建快照任务失败: %v", err)
+	}
+
+	global.APP_LOG.Info("用户创建实例快照任务",
+		zap.Uint("userID", userID),
+		zap.Uint("instanceID", instance.ID),
+		zap.String("taskType", taskType),
+		zap.String("snapshot", snapshot.Name),
+		zap.Uint("taskID", taskModel.ID))
+
+	return taskModel.ID, nil
+}
+
+// checkSnapshotTaskInProgress 检查实例是否已有进行中的快照任务
+func checkSnapshotTaskInProgress(instanceID uint) error {
+	var existingTask adminModel.Task
+	if err := global.APP_DB.Where("instance_id = ? AND task_type IN (?) AND status IN ('pending', 'running')", instanceID, snapshotTaskTypes).First(&existingTask).Error; err == nil {
+		return errors.New("实例已有快照任务正在进行，请稍后重试")
+	}
+	return nil
+}
+
+// getAvailableSnapshot 获取可用状态的快照
+func getAvailableSnapshot(instanceID, snapshotID uint) (*providerModel.InstanceSnapshot, error) {
+	var snapshot providerModel.InstanceSnapshot
+	if err := global.APP_DB.Where("id = ? AND instance_id = ?", snapshotID, instanceID).First(&snapshot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("快照不存在")
+		}
+		return nil, err
+	}
+	if snapshot.Status != "available" {
+		return nil, fmt.Errorf("快照当前状态为 %s，无法恢复", snapshot.Status)
+	}
+	return &snapshot, nil
+}
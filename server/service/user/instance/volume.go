@@ -0,0 +1,336 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/provider"
+	"oneclickvirt/service/cache"
+	"oneclickvirt/service/database"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/resources"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// GetUserVolumes 获取用户数据卷列表
+func (s *Service) GetUserVolumes(userID uint) ([]providerModel.Volume, error) {
+	var volumes []providerModel.Volume
+	if err := global.APP_DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&volumes).Error; err != nil {
+		return nil, fmt.Errorf("获取数据卷列表失败: %v", err)
+	}
+	return volumes, nil
+}
+
+// CreateVolume 创建数据卷（异步任务），指定实例时在实例所在节点创建并直接挂载
+func (s *Service) CreateVolume(userID uint, req userModel.CreateVolumeRequest) (*userModel.VolumeTaskResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("数据卷名称不能为空")
+	}
+	if err := provider.ValidateVolumeMountPath(req.MountPath); err != nil {
+		return nil, err
+	}
+
+	volume := providerModel.Volume{
+		Name:         name,
+		ProviderID:   req.ProviderID,
+		UserID:       userID,
+		InstanceType: req.InstanceType,
+		SizeMB:       req.SizeMB,
+		Status:       providerModel.VolumeStatusCreating,
+	}
+
+	var instance *providerModel.Instance
+	if req.InstanceID > 0 {
+		var err error
+		if instance, err = getVolumeTargetInstance(userID, req.InstanceID); err != nil {
+			return nil, err
+		}
+		volume.ProviderID = instance.ProviderID
+		volume.InstanceType = instance.InstanceType
+	} else {
+		if volume.ProviderID == 0 {
+			return nil, errors.New("请选择节点或实例")
+		}
+		if volume.InstanceType == "" {
+			volume.InstanceType = "container"
+		}
+		var dbProvider providerModel.Provider
+		if err := global.APP_DB.Where("id = ? AND status IN (?) AND allow_claim = ? AND is_frozen = ?",
+			volume.ProviderID, []string{"active", "partial"}, true, false).First(&dbProvider).Error; err != nil {
+			return nil, errors.New("节点不存在或不可用")
+		}
+		if (volume.InstanceType == "vm" && !dbProvider.VirtualMachineEnabled) || (volume.InstanceType == "container" && !dbProvider.ContainerEnabled) {
+			return nil, errors.New("该节点不支持此实例类型")
+		}
+	}
+
+	if _, err := provider2.GetProviderService().GetVolumeProvider(volume.ProviderID); err != nil {
+		return nil, err
+	}
+
+	// 在事务中校验磁盘配额、占用节点磁盘预算并创建记录，防止并发超限
+	dbService := database.GetDatabaseService()
+	if err := dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+		if err := resources.ReserveVolumeResourcesInTx(tx, &volume, volume.SizeMB); err != nil {
+			return err
+		}
+		return tx.Create(&volume).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	taskReq := adminModel.VolumeTaskRequest{
+		VolumeId:   volume.ID,
+		ProviderId: volume.ProviderID,
+		MountPath:  req.MountPath,
+	}
+	var instanceID *uint
+	if instance != nil {
+		taskReq.InstanceId = instance.ID
+		instanceID = &instance.ID
+	}
+	taskID, err := createVolumeTask(userID, &volume, instanceID, "create-volume", taskReq)
+	if err != nil {
+		dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+			if err := resources.ReleaseVolumeResourcesInTx(tx, &volume, volume.SizeMB); err != nil {
+				return err
+			}
+			return tx.Delete(&volume).Error
+		})
+		return nil, err
+	}
+
+	return &userModel.VolumeTaskResponse{TaskID: taskID, VolumeID: volume.ID}, nil
+}
+
+// AttachVolume 挂载数据卷到实例（异步任务）
+func (s *Service) AttachVolume(userID, volumeID uint, req userModel.AttachVolumeRequest) (*userModel.VolumeTaskResponse, error) {
+	if err := provider.ValidateVolumeMountPath(req.MountPath); err != nil {
+		return nil, err
+	}
+	volume, err := getUserVolume(userID, volumeID)
+	if err != nil {
+		return nil, err
+	}
+	instance, err := getVolumeTargetInstance(userID, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	if instance.ProviderID != volume.ProviderID {
+		return nil, errors.New("数据卷只能挂载到同一节点上的实例")
+	}
+	if instance.InstanceType != volume.InstanceType {
+		return nil, fmt.Errorf("该数据卷只能挂载到%s类型的实例", volume.InstanceType)
+	}
+
+	if err := transitVolumeStatus(volume, providerModel.VolumeStatusAvailable, providerModel.VolumeStatusAttaching); err != nil {
+		return nil, err
+	}
+	taskID, err := createVolumeTask(userID, volume, &instance.ID, "attach-volume", adminModel.VolumeTaskRequest{
+		VolumeId:   volume.ID,
+		ProviderId: volume.ProviderID,
+		InstanceId: instance.ID,
+		MountPath:  req.MountPath,
+	})
+	if err != nil {
+		global.APP_DB.Model(volume).Update("status", providerModel.VolumeStatusAvailable)
+		return nil, err
+	}
+
+	return &userModel.VolumeTaskResponse{TaskID: taskID, VolumeID: volume.ID}, nil
+}
+
+// DetachVolume 从实例卸载数据卷（异步任务）
+func (s *Service) DetachVolume(userID, volumeID uint) (*userModel.VolumeTaskResponse, error) {
+	volume, err := getUserVolume(userID, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := transitVolumeStatus(volume, providerModel.VolumeStatusInUse, providerModel.VolumeStatusDetaching); err != nil {
+		return nil, err
+	}
+	taskID, err := createVolumeTask(userID, volume, &volume.InstanceID, "detach-volume", adminModel.VolumeTaskRequest{
+		VolumeId:   volume.ID,
+		ProviderId: volume.ProviderID,
+		InstanceId: volume.InstanceID,
+	})
+	if err != nil {
+		global.APP_DB.Model(volume).Update("status", providerModel.VolumeStatusInUse)
+		return nil, err
+	}
+
+	return &userModel.VolumeTaskResponse{TaskID: taskID, VolumeID: volume.ID}, nil
+}
+
+// ResizeVolume 数据卷扩容（异步任务），新增容量在提交时计入配额
+func (s *Service) ResizeVolume(userID, volumeID uint, req userModel.ResizeVolumeRequest) (*userModel.VolumeTaskResponse, error) {
+	volume, err := getUserVolume(userID, volumeID)
+	if err != nil {
+		return nil, err
+	}
+	if req.SizeMB <= volume.SizeMB {
+		return nil, fmt.Errorf("数据卷只支持扩容，新容量必须大于当前的 %dMB", volume.SizeMB)
+	}
+
+	var dbProvider providerModel.Provider
+	if err := global.APP_DB.First(&dbProvider, volume.ProviderID).Error; err != nil {
+		return nil, fmt.Errorf("获取节点信息失败: %v", err)
+	}
+	if dbProvider.Type == "proxmox" && volume.InstanceID == 0 {
+		return nil, errors.New("Proxmox数据卷需要挂载到实例后才能调整容量")
+	}
+
+	fromStatus := providerModel.VolumeStatusAvailable
+	if volume.InstanceID > 0 {
+		fromStatus = providerModel.VolumeStatusInUse
+	}
+
+	delta := req.SizeMB - volume.SizeMB
+	dbService := database.GetDatabaseService()
+	if err := dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+		result := tx.Model(volume).Where("status = ?", fromStatus).Update("status", providerModel.VolumeStatusResizing)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("数据卷当前状态为 %s，无法调整容量", volume.Status)
+		}
+		return resources.ReserveVolumeResourcesInTx(tx, volume, delta)
+	}); err != nil {
+		return nil, err
+	}
+
+	var instanceID *uint
+	if volume.InstanceID > 0 {
+		instanceID = &volume.InstanceID
+	}
+	taskID, err := createVolumeTask(userID, volume, instanceID, "resize-volume", adminModel.VolumeTaskRequest{
+		VolumeId:   volume.ID,
+		ProviderId: volume.ProviderID,
+		InstanceId: volume.InstanceID,
+		SizeMB:     req.SizeMB,
+	})
+	if err != nil {
+		dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+			if err := resources.ReleaseVolumeResourcesInTx(tx, volume, delta); err != nil {
+				return err
+			}
+			return tx.Model(volume).Update("status", fromStatus).Error
+		})
+		return nil, err
+	}
+
+	return &userModel.VolumeTaskResponse{TaskID: taskID, VolumeID: volume.ID}, nil
+}
+
+// DeleteVolume 删除未挂载的数据卷（异步任务），创建失败的数据卷直接删除记录
+func (s *Service) DeleteVolume(userID, volumeID uint) (*userModel.VolumeTaskResponse, error) {
+	volume, err := getUserVolume(userID, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建失败时配额和磁盘预算已经释放，Provider上也没有对应的卷
+	if volume.Status == providerModel.VolumeStatusFailed {
+		if err := global.APP_DB.Delete(volume).Error; err != nil {
+			return nil, fmt.Errorf("删除数据卷记录失败: %v", err)
+		}
+		return &userModel.VolumeTaskResponse{VolumeID: volume.ID}, nil
+	}
+
+	if volume.Status == providerModel.VolumeStatusInUse {
+		return nil, errors.New("数据卷仍挂载在实例上，请先卸载")
+	}
+	if err := transitVolumeStatus(volume, providerModel.VolumeStatusAvailable, providerModel.VolumeStatusDeleting); err != nil {
+		return nil, err
+	}
+	taskID, err := createVolumeTask(userID, volume, nil, "delete-volume", adminModel.VolumeTaskRequest{
+		VolumeId:   volume.ID,
+		ProviderId: volume.ProviderID,
+	})
+	if err != nil {
+		global.APP_DB.Model(volume).Update("status", providerModel.VolumeStatusAvailable)
+		return nil, err
+	}
+
+	return &userModel.VolumeTaskResponse{TaskID: taskID, VolumeID: volume.ID}, nil
+}
+
+// getUserVolume 获取用户的数据卷
+func getUserVolume(userID, volumeID uint) (*providerModel.Volume, error) {
+	var volume providerModel.Volume
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", volumeID, userID).First(&volume).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("数据卷不存在")
+		}
+		return nil, err
+	}
+	return &volume, nil
+}
+
+// getVolumeTargetInstance 获取可挂载数据卷的实例
+func getVolumeTargetInstance(userID, instanceID uint) (*providerModel.Instance, error) {
+	var instance providerModel.Instance
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", instanceID, userID).First(&instance).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("实例不存在或无权限")
+		}
+		return nil, err
+	}
+	if instance.Status != "running" && instance.Status != "stopped" {
+		return nil, errors.New("只有运行中或已停止的实例才能挂载数据卷")
+	}
+	return &instance, nil
+}
+
+// transitVolumeStatus 按当前状态条件更新数据卷状态，防止同一数据卷并发提交多个任务
+func transitVolumeStatus(volume *providerModel.Volume, from, to string) error {
+	result := global.APP_DB.Model(volume).Where("status = ?", from).Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("数据卷当前状态为 %s，无法执行此操作", volume.Status)
+	}
+	return nil
+}
+
+// createVolumeTask 创建数据卷相关任务
+func createVolumeTask(userID uint, volume *providerModel.Volume, instanceID *uint, taskType string, taskReq adminModel.VolumeTaskRequest) (uint, error) {
+	defer func() {
+		cacheService := cache.GetUserCacheService()
+		cacheService.InvalidateUserCache(userID)
+		if instanceID != nil {
+			cacheService.InvalidateInstanceCache(*instanceID)
+		}
+	}()
+
+	taskData, err := json.Marshal(taskReq)
+	if err != nil {
+		return 0, fmt.Errorf("序列化任务数据失败: %v", err)
+	}
+
+	taskModel, err := getTaskService().CreateTask(userID, &volume.ProviderID, instanceID, taskType, string(taskData), 0)
+	if err != nil {
+		return 0, fmt.Errorf("创建数据卷任务失败: %v", err)
+	}
+
+	global.APP_LOG.Info("用户创建数据卷任务",
+		zap.Uint("userID", userID),
+		zap.Uint("volumeID", volume.ID),
+		zap.String("taskType", taskType),
+		zap.Uint("taskID", taskModel.ID))
+
+	return taskModel.ID, nil
+}
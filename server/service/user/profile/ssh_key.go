@@ -0,0 +1,179 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"oneclickvirt/global"
+	userModel "oneclickvirt/model/user"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+)
+
+// maxSSHKeysPerUser 每个用户最多保存的SSH公钥数量
+const maxSSHKeysPerUser = 20
+
+// NormalizeSSHPublicKey 校验authorized_keys格式的公钥，返回规范化的公钥行和SHA256指纹
+// 只接受单个公钥，注释中的控制字符会被去除
+func NormalizeSSHPublicKey(publicKey string) (string, string, error) {
+	publicKey = strings.TrimSpace(publicKey)
+	if publicKey == "" {
+		return "", "", errors.New("公钥不能为空")
+	}
+	if strings.ContainsAny(publicKey, "\r\n") {
+		return "", "", errors.New("一次只能添加一个公钥")
+	}
+
+	key, comment, options, rest, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return "", "", fmt.Errorf("无效的SSH公钥: %v", err)
+	}
+	if len(options) > 0 || len(rest) > 0 {
+		return "", "", errors.New("公钥不能包含选项或多余内容")
+	}
+
+	normalized := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+	comment = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(comment))
+	if comment != "" {
+		normalized += " " + comment
+	}
+	return normalized, ssh.FingerprintSHA256(key), nil
+}
+
+// GetSSHKeys 获取用户的SSH公钥列表
+func (s *Service) GetSSHKeys(userID uint) ([]userModel.SSHKey, error) {
+	var keys []userModel.SSHKey
+	if err := global.APP_DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("获取SSH公钥列表失败: %w", err)
+	}
+	return keys, nil
+}
+
+// CreateSSHKey 添加SSH公钥，同一用户不能重复添加相同指纹的公钥
+func (s *Service) CreateSSHKey(userID uint, req userModel.CreateSSHKeyRequest) (*userModel.SSHKey, error) {
+	publicKey, fingerprint, err := NormalizeSSHPublicKey(req.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	global.APP_DB.Model(&userModel.SSHKey{}).Where("user_id = ?", userID).Count(&count)
+	if count >= maxSSHKeysPerUser {
+		return nil, fmt.Errorf("最多只能添加%d个SSH公钥", maxSSHKeysPerUser)
+	}
+
+	var existing int64
+	global.APP_DB.Model(&userModel.SSHKey{}).Where("user_id = ? AND fingerprint = ?", userID, fingerprint).Count(&existing)
+	if existing > 0 {
+		return nil, errors.New("该公钥已存在")
+	}
+
+	key := &userModel.SSHKey{
+		UserID:      userID,
+		Name:        strings.TrimSpace(req.Name),
+		PublicKey:   publicKey,
+		Fingerprint: fingerprint,
+	}
+	if err := global.APP_DB.Create(key).Error; err != nil {
+		return nil, fmt.Errorf("添加SSH公钥失败: %w", err)
+	}
+
+	global.APP_LOG.Info("用户添加SSH公钥",
+		zap.Uint("userID", userID),
+		zap.String("name", key.Name),
+		zap.String("fingerprint", fingerprint))
+	return key, nil
+}
+
+// DeleteSSHKey 删除SSH公钥，已注入实例的公钥不会从实例中移除
+func (s *Service) DeleteSSHKey(userID, keyID uint) error {
+	result := global.APP_DB.Where("id = ? AND user_id = ?", keyID, userID).Delete(&userModel.SSHKey{})
+	if result.Error != nil {
+		return fmt.Errorf("删除SSH公钥失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("SSH公钥不存在")
+	}
+	return nil
+}
+
+// ResolveSSHKeys 按ID获取用户的公钥内容，ID不属于该用户时返回错误
+func ResolveSSHKeys(userID uint, keyIDs []uint) ([]string, error) {
+	publicKeys, err := LoadSSHKeys(userID, keyIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(publicKeys) != len(uniqueIDs(keyIDs)) {
+		return nil, errors.New("SSH公钥不存在或无权限")
+	}
+	return publicKeys, nil
+}
+
+// ValidateLoginOptions 校验创建或重装实例时选择的公钥和登录方式
+func ValidateLoginOptions(userID uint, keyIDs []uint, disablePasswordLogin bool) error {
+	if _, err := ResolveSSHKeys(userID, keyIDs); err != nil {
+		return err
+	}
+	if disablePasswordLogin && len(keyIDs) == 0 {
+		return errors.New("禁用密码登录时至少需要选择一个SSH公钥")
+	}
+	return nil
+}
+
+// LoadSSHKeys 按ID获取用户仍然存在的公钥内容，已删除的公钥会被忽略（用于重置时重新注入）
+func LoadSSHKeys(userID uint, keyIDs []uint) ([]string, error) {
+	if len(keyIDs) == 0 {
+		return nil, nil
+	}
+	var keys []userModel.SSHKey
+	if err := global.APP_DB.Where("user_id = ? AND id IN ?", userID, keyIDs).Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("获取SSH公钥失败: %w", err)
+	}
+	publicKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		publicKeys = append(publicKeys, key.PublicKey)
+	}
+	return publicKeys, nil
+}
+
+// FormatSSHKeyIDs 将公钥ID列表格式化为实例记录中保存的逗号分隔字符串
+func FormatSSHKeyIDs(keyIDs []uint) string {
+	parts := make([]string, 0, len(keyIDs))
+	for _, id := range uniqueIDs(keyIDs) {
+		parts = append(parts, strconv.FormatUint(uint64(id), 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseSSHKeyIDs 解析实例记录中保存的公钥ID，忽略无法解析的项
+func ParseSSHKeyIDs(value string) []uint {
+	var ids []uint
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32)
+		if err == nil && id > 0 {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// uniqueIDs 去除重复ID并保持顺序
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
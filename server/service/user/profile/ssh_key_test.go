@@ -0,0 +1,43 @@
+package profile
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPublicKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHx0bGb5R9rQm6nGkQJmQ3e0c5yJb3cH1pK0cUq2Lx1o"
+
+func TestNormalizeSSHPublicKey(t *testing.T) {
+	normalized, fingerprint, err := NormalizeSSHPublicKey("  " + testPublicKey + " user@host  ")
+	if err != nil {
+		t.Fatalf("合法公钥校验失败: %v", err)
+	}
+	if normalized != testPublicKey+" user@host" {
+		t.Errorf("规范化结果不正确: %q", normalized)
+	}
+	if !strings.HasPrefix(fingerprint, "SHA256:") {
+		t.Errorf("指纹格式不正确: %q", fingerprint)
+	}
+
+	invalid := []string{
+		"",
+		"not-a-key",
+		testPublicKey + "\n" + testPublicKey,
+		`command="/bin/sh" ` + testPublicKey,
+	}
+	for _, key := range invalid {
+		if _, _, err := NormalizeSSHPublicKey(key); err == nil {
+			t.Errorf("非法公钥应校验失败: %q", key)
+		}
+	}
+}
+
+func TestParseSSHKeyIDs(t *testing.T) {
+	ids := ParseSSHKeyIDs(FormatSSHKeyIDs([]uint{3, 1, 3, 7}))
+	if len(ids) != 3 || ids[0] != 3 || ids[1] != 1 || ids[2] != 7 {
+		t.Errorf("公钥ID解析结果不正确: %v", ids)
+	}
+	if ids := ParseSSHKeyIDs(""); len(ids) != 0 {
+		t.Errorf("空字符串应返回空列表: %v", ids)
+	}
+}
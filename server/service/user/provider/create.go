@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"oneclickvirt/constant"
@@ -10,9 +11,11 @@ import (
 	providerModel "oneclickvirt/model/provider"
 	systemModel "oneclickvirt/model/system"
 	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/auth"
 	"oneclickvirt/service/cache"
 	"oneclickvirt/service/database"
 	"oneclickvirt/service/resources"
+	"oneclickvirt/service/user/profile"
 	"time"
 
 	"github.com/google/uuid"
@@ -43,11 +46,12 @@ func (s *Service) CreateUserInstance(userID uint, req userModel.CreateInstanceRe
 		return nil, errors.New("节点不存在")
 	}
 
-	if !provider.AllowClaim || provider.IsFrozen {
+	if !provider.AllowClaim || provider.IsFrozen || provider.MaintenanceMode {
 		global.APP_LOG.Error("服务器不可用",
 			zap.Uint("providerId", req.ProviderId),
 			zap.Bool("allowClaim", provider.AllowClaim),
-			zap.Bool("isFrozen", provider.IsFrozen))
+			zap.Bool("isFrozen", provider.IsFrozen),
+			zap.Bool("maintenanceMode", provider.MaintenanceMode))
 		return nil, errors.New("服务器不可用")
 	}
 
@@ -86,6 +90,23 @@ func (s *Service) CreateUserInstance(userID uint, req userModel.CreateInstanceRe
 		return nil, err
 	}
 
+	// 选择实例规格时使用规格对应的预定义规格ID，并检查镜像白名单
+	if req.FlavorID > 0 {
+		flavor, err := resources.NewFlavorService().ResolveFlavor(global.APP_DB, req.FlavorID, req.ProviderId, systemImage.InstanceType)
+		if err == nil {
+			err = resources.CheckFlavorImage(flavor, systemImage.ID)
+		}
+		if err != nil {
+			global.APP_LOG.Error("实例规格验证失败",
+				zap.Uint("flavorId", req.FlavorID),
+				zap.Uint("providerId", req.ProviderId),
+				zap.Uint("imageId", req.ImageId),
+				zap.Error(err))
+			return nil, err
+		}
+		req.CPUId, req.MemoryId, req.DiskId, req.BandwidthId = resources.FlavorSpecIDs(flavor)
+	}
+
 	// 验证规格ID并获取规格信息，同时验证用户权限
 	global.APP_LOG.Info("开始验证规格ID",
 		zap.String("cpuId", req.CPUId),
@@ -150,6 +171,24 @@ func (s *Service) CreateUserInstance(userID uint, req userModel.CreateInstanceRe
 		return nil, err
 	}
 
+	// 验证选择的SSH公钥和登录方式
+	if err := profile.ValidateLoginOptions(userID, req.SSHKeyIDs, req.DisablePasswordLogin); err != nil {
+		global.APP_LOG.Error("SSH公钥验证失败",
+			zap.Uint("userID", userID),
+			zap.Error(err))
+		return nil, err
+	}
+
+	// 验证自定义user-data（等级开关、大小限制和节点支持情况）
+	permissionService := auth.PermissionService{}
+	if err := permissionService.ValidateInstanceUserData(userID, req.UserData, provider.Type, systemImage.InstanceType); err != nil {
+		global.APP_LOG.Error("user-data验证失败",
+			zap.Uint("userID", userID),
+			zap.String("providerType", provider.Type),
+			zap.Error(err))
+		return nil, err
+	}
+
 	global.APP_LOG.Info("所有验证通过，开始创建实例",
 		zap.Uint("userID", userID),
 		zap.Uint("providerId", req.ProviderId),
@@ -283,8 +322,24 @@ func (s *Service) createInstanceWithMinimalTransaction(userID uint, req *userMod
 		}
 
 		// 2. 创建任务
-		taskData := fmt.Sprintf(`{"providerId":%d,"imageId":%d,"cpuId":"%s","memoryId":"%s","diskId":"%s","bandwidthId":"%s","description":"%s","sessionId":"%s"}`,
-			req.ProviderId, req.ImageId, req.CPUId, req.MemoryId, req.DiskId, req.BandwidthId, req.Description, sessionID)
+		taskDataJSON, err := json.Marshal(adminModel.CreateInstanceTaskRequest{
+			ProviderId:           req.ProviderId,
+			ImageId:              req.ImageId,
+			CPUId:                req.CPUId,
+			MemoryId:             req.MemoryId,
+			DiskId:               req.DiskId,
+			BandwidthId:          req.BandwidthId,
+			Description:          req.Description,
+			SessionId:            sessionID,
+			SSHKeyIDs:            req.SSHKeyIDs,
+			DisablePasswordLogin: req.DisablePasswordLogin,
+			UserData:             req.UserData,
+			FlavorID:             req.FlavorID,
+		})
+		if err != nil {
+			return fmt.Errorf("序列化任务数据失败: %v", err)
+		}
+		taskData := string(taskDataJSON)
 
 		// 计算预计执行时长
 		estimatedDuration := 300 // 默认5分钟
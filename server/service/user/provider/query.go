@@ -437,6 +437,20 @@ func (s *Service) GetProviderCapabilities(userID uint, providerID uint) (map[str
 		supportedTypes = append(supportedTypes, "vm")
 	}
 
+	// 已探测过能力的节点，进一步过滤掉节点实际不支持的实例类型
+	nodeCapabilities, probed := provider.ParseCapabilities()
+	if probed {
+		var filtered []string
+		for _, t := range supportedTypes {
+			if nodeCapabilities.SupportsInstanceType(t) {
+				filtered = append(filtered, t)
+			}
+		}
+		supportedTypes = filtered
+	} else {
+		nodeCapabilities = nil
+	}
+
 	capabilities := map[string]interface{}{
 		"containerEnabled": provider.ContainerEnabled,
 		"vmEnabled":        provider.VirtualMachineEnabled,
@@ -447,6 +461,7 @@ func (s *Service) GetProviderCapabilities(userID uint, providerID uint) (map[str
 		"region":           provider.Region,
 		"country":          provider.Country,
 		"city":             provider.City,
+		"capabilities":     nodeCapabilities, // 节点能力文档，未探测时为null
 	}
 
 	return capabilities, nil
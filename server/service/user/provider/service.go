@@ -22,6 +22,7 @@ import (
 	providerService "oneclickvirt/service/provider"
 	"oneclickvirt/service/resources"
 	"oneclickvirt/service/traffic"
+	"oneclickvirt/service/user/profile"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -178,6 +179,22 @@ func (s *Service) prepareInstanceCreation(ctx context.Context, task *adminModel.
 			return fmt.Errorf("服务器已过期")
 		}
 
+		// 选择了实例规格时重新验证规格（防止规格在排队期间被修改或停用）
+		var flavorTraffic int64
+		if taskReq.FlavorID > 0 {
+			flavor, err := resources.NewFlavorService().ResolveFlavor(tx, taskReq.FlavorID, provider.ID, systemImage.InstanceType)
+			if err == nil {
+				err = resources.CheckFlavorImage(flavor, systemImage.ID)
+			}
+			if err == nil {
+				err = resources.CheckFlavorMatches(flavor, cpuSpec.Cores, int64(memorySpec.SizeMB), int64(diskSpec.SizeMB), bandwidthSpec.SpeedMbps)
+			}
+			if err != nil {
+				return err
+			}
+			flavorTraffic = flavor.Traffic
+		}
+
 		// 生成实例名称
 		instanceName := s.generateInstanceName(provider.Name)
 
@@ -203,23 +220,27 @@ func (s *Service) prepareInstanceCreation(ctx context.Context, task *adminModel.
 		// 创建实例记录
 		uuid := uuid.New().String()
 		instance = providerModel.Instance{
-			UUID:               uuid,
-			Name:               instanceName,
-			Provider:           provider.Name,
-			ProviderID:         provider.ID,
-			Image:              systemImage.Name,
-			CPU:                cpuSpec.Cores,
-			Memory:             int64(memorySpec.SizeMB),
-			Disk:               int64(diskSpec.SizeMB),
-			Bandwidth:          bandwidthSpec.SpeedMbps,
-			InstanceType:       systemImage.InstanceType,
-			UserID:             task.UserID,
-			Status:             "creating",
-			OSType:             systemImage.OSType,
-			ExpiredAt:          expiredAt,
-			MaxTraffic:         0,     // 默认为0，表示继承用户等级限制，不单独限制实例
-			TrafficLimited:     false, // 显式设置为false，确保不会因流量误判为超限
-			TrafficLimitReason: "",    // 初始无限制原因
+			UUID:                  uuid,
+			Name:                  instanceName,
+			Provider:              provider.Name,
+			ProviderID:            provider.ID,
+			Image:                 systemImage.Name,
+			CPU:                   cpuSpec.Cores,
+			Memory:                int64(memorySpec.SizeMB),
+			Disk:                  int64(diskSpec.SizeMB),
+			Bandwidth:             bandwidthSpec.SpeedMbps,
+			InstanceType:          systemImage.InstanceType,
+			UserID:                task.UserID,
+			Status:                "creating",
+			OSType:                systemImage.OSType,
+			ExpiredAt:             expiredAt,
+			FlavorID:              taskReq.FlavorID,
+			MaxTraffic:            flavorTraffic, // 默认为0，表示继承用户等级限制，选择规格时使用规格的流量限制
+			TrafficLimited:        false,         // 显式设置为false，确保不会因流量误判为超限
+			TrafficLimitReason:    "",            // 初始无限制原因
+			SSHKeyIDs:             profile.FormatSSHKeyIDs(taskReq.SSHKeyIDs),
+			PasswordLoginDisabled: taskReq.DisablePasswordLogin,
+			UserData:              taskReq.UserData,
 		}
 
 		// 创建实例
@@ -325,6 +346,18 @@ func (s *Service) executeProviderCreation(ctx context.Context, task *adminModel.
 		return err
 	}
 
+	// 按节点能力检查实例类型和IPv6，在SSH操作之前拒绝节点无法完成的创建
+	if err := providerService.RequireInstanceType(&dbProvider, instance.InstanceType); err != nil {
+		global.APP_LOG.Error("节点不支持该实例类型", zap.Uint("taskId", task.ID), zap.Uint("providerId", localProviderID), zap.Error(err))
+		return err
+	}
+	if constant.NetworkType(localProviderNetworkType).HasIPv6() {
+		if err := providerService.RequireCapabilities(&dbProvider, providerModel.CapabilityIPv6); err != nil {
+			global.APP_LOG.Error("节点不支持IPv6", zap.Uint("taskId", task.ID), zap.Uint("providerId", localProviderID), zap.Error(err))
+			return err
+		}
+	}
+
 	// 实现实际的Provider API调用逻辑
 	// 首先尝试从ProviderService获取已连接的Provider实例（使用ID）
 	providerSvc := providerService.GetProviderService()
@@ -427,6 +460,17 @@ func (s *Service) executeProviderCreation(ctx context.Context, task *adminModel.
 		MemorySwap:   boolPtr(dbProvider.ContainerMemorySwap),
 		MaxProcesses: intPtr(dbProvider.ContainerMaxProcesses),
 		DiskIOLimit:  stringPtr(dbProvider.ContainerDiskIOLimit),
+		UserData:     taskReq.UserData,
+	}
+
+	// 注入用户选择的SSH公钥，公钥在提交后被删除时不禁用密码登录，避免实例无法登录
+	if len(taskReq.SSHKeyIDs) > 0 {
+		sshKeys, err := profile.LoadSSHKeys(task.UserID, taskReq.SSHKeyIDs)
+		if err != nil {
+			global.APP_LOG.Warn("获取SSH公钥失败", zap.Uint("taskId", task.ID), zap.Error(err))
+		}
+		instanceConfig.SSHKeys = sshKeys
+		instanceConfig.DisablePasswordLogin = taskReq.DisablePasswordLogin && len(sshKeys) > 0
 	}
 
 	// 预分配端口映射（所有Provider类型都需要）
@@ -447,8 +491,8 @@ func (s *Service) executeProviderCreation(ctx context.Context, task *adminModel.
 				zap.Uint("instanceId", instance.ID),
 				zap.Error(err))
 		} else {
-			// 对于Docker容器，将端口映射信息添加到实例配置中
-			if localProviderType == "docker" {
+			// 对于Docker/Podman容器，将端口映射信息添加到实例配置中
+			if localProviderType == "docker" || localProviderType == "podman" {
 				// 将端口映射信息添加到实例配置中
 				var ports []string
 				for _, port := range portMappings {
@@ -481,6 +525,27 @@ func (s *Service) executeProviderCreation(ctx context.Context, task *adminModel.
 		}
 	}
 
+	// 集群Provider：实例未指定节点时使用Provider固定节点或负载最低的节点，并记录到实例上供后续操作使用
+	if clusterProvider, ok := providerInstance.(provider.ClusterProvider); ok {
+		instanceConfig.Node = instance.Node
+		if instanceConfig.Node == "" {
+			instanceConfig.Node = dbProvider.ClusterPinnedNode
+		}
+		node, err := clusterProvider.SelectClusterNode(ctx, instanceConfig)
+		if err != nil {
+			err := fmt.Errorf("选择集群节点失败: %v", err)
+			global.APP_LOG.Error("选择集群节点失败", zap.Uint("taskId", task.ID), zap.Error(err))
+			return err
+		}
+		instanceConfig.Node = node
+		if node != instance.Node {
+			if err := global.APP_DB.Model(instance).Update("node", node).Error; err != nil {
+				global.APP_LOG.Warn("记录实例所在节点失败", zap.Uint("instanceId", instance.ID), zap.Error(err))
+			}
+			instance.Node = node
+		}
+	}
+
 	// 调用Provider API创建实例
 	// 创建进度回调函数，与任务系统集成
 	progressCallback := func(percentage int, message string) {
@@ -119,6 +119,43 @@ func (s *Service) ClaimResource(userID uint, req userModel.ClaimResourceRequest)
 	quotaService := resources.NewQuotaService()
 	reservationService := resources.GetResourceReservationService()
 
+	// 选择实例规格时使用规格的资源配置
+	var flavor *resourceModel.Flavor
+	if req.FlavorID > 0 {
+		var err error
+		if flavor, err = resources.NewFlavorService().ResolveFlavor(global.APP_DB, req.FlavorID, req.ProviderID, req.InstanceType); err != nil {
+			return nil, err
+		}
+		if err := resources.CheckFlavorImageName(flavor, req.Image); err != nil {
+			return nil, err
+		}
+		req.CPU, req.Memory, req.Disk = flavor.CPU, flavor.Memory, flavor.Disk
+	} else if req.CPU <= 0 || req.Memory <= 0 || req.Disk <= 0 {
+		return nil, errors.New("请选择实例规格或填写CPU、内存和磁盘")
+	}
+
+	// 未指定节点时按调度策略自动选择
+	if req.ProviderID == 0 {
+		providerID, err := resources.NewPlacementService().SelectProvider(userID, resourceModel.PlacementRequest{
+			InstanceType: req.InstanceType,
+			Region:       req.Region,
+			Country:      req.Country,
+			Architecture: req.Architecture,
+			CPU:          req.CPU,
+			Memory:       req.Memory,
+			Disk:         req.Disk,
+			FlavorID:     req.FlavorID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		req.ProviderID = providerID
+		global.APP_LOG.Info("自动选择节点",
+			zap.Uint("userID", userID),
+			zap.Uint("providerID", providerID),
+			zap.String("instanceType", req.InstanceType))
+	}
+
 	// 生成会话ID用于资源预留
 	sessionID := resources.GenerateSessionID()
 
@@ -143,7 +180,7 @@ func (s *Service) ClaimResource(userID uint, req userModel.ClaimResourceRequest)
 			return errors.New("提供商不存在")
 		}
 
-		if !provider.AllowClaim {
+		if !provider.AllowClaim || provider.MaintenanceMode {
 			return errors.New("该提供商不允许申领")
 		}
 
@@ -172,6 +209,7 @@ func (s *Service) ClaimResource(userID uint, req userModel.ClaimResourceRequest)
 			Disk:         req.Disk,
 			InstanceType: req.InstanceType,
 			ProviderID:   req.ProviderID,
+			FlavorID:     req.FlavorID,
 		}
 
 		quotaResult, err := quotaService.ValidateInTransaction(tx, quotaReq)
@@ -272,6 +310,11 @@ func (s *Service) ClaimResource(userID uint, req userModel.ClaimResourceRequest)
 		Status:       "creating",
 		ExpiredAt:    expiredAt,
 	}
+	if flavor != nil {
+		instance.FlavorID = flavor.ID
+		instance.Bandwidth = flavor.Bandwidth
+		instance.MaxTraffic = flavor.Traffic
+	}
 
 	// ===== 阶段3: 短事务 - 创建实例、消费预留、更新配额 =====
 	err = dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
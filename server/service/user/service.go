@@ -12,6 +12,7 @@ import (
 	adminModel "oneclickvirt/model/admin"
 	"oneclickvirt/model/auth"
 	providerModel "oneclickvirt/model/provider"
+	resourceModel "oneclickvirt/model/resource"
 	userModel "oneclickvirt/model/user"
 )
 
@@ -93,6 +94,21 @@ func (s *Service) ChangePassword(userID uint, oldPassword, newPassword string) e
 	return s.profile.ChangePassword(userID, oldPassword, newPassword)
 }
 
+// GetSSHKeys 获取用户的SSH公钥列表
+func (s *Service) GetSSHKeys(userID uint) ([]userModel.SSHKey, error) {
+	return s.profile.GetSSHKeys(userID)
+}
+
+// CreateSSHKey 添加SSH公钥
+func (s *Service) CreateSSHKey(userID uint, req userModel.CreateSSHKeyRequest) (*userModel.SSHKey, error) {
+	return s.profile.CreateSSHKey(userID, req)
+}
+
+// DeleteSSHKey 删除SSH公钥
+func (s *Service) DeleteSSHKey(userID, keyID uint) error {
+	return s.profile.DeleteSSHKey(userID, keyID)
+}
+
 // BatchDeleteUsers 批量删除用户
 func (s *Service) BatchDeleteUsers(userIDs []uint) (map[string]interface{}, error) {
 	return s.profile.BatchDeleteUsers(userIDs)
@@ -137,6 +153,16 @@ func (s *Service) ClaimResource(userID uint, req userModel.ClaimResourceRequest)
 	return s.resource.ClaimResource(userID, req)
 }
 
+// GetAvailableFlavors 获取用户可选择的实例规格
+func (s *Service) GetAvailableFlavors(userID uint, req userModel.AvailableFlavorsRequest) ([]resourceModel.Flavor, error) {
+	return resources.NewFlavorService().GetUserFlavors(userID, req.ProviderID, req.InstanceType)
+}
+
+// PreviewPlacement 预览自动选择节点的评分结果
+func (s *Service) PreviewPlacement(userID uint, req resourceModel.PlacementRequest) (*resourceModel.PlacementResult, error) {
+	return resources.NewPlacementService().RankProviders(userID, req)
+}
+
 // ===== 提供商和配置相关方法 =====
 
 // GetAvailableProviders 获取可用节点列表
@@ -196,6 +222,136 @@ func (s *Service) GetInstanceNewPassword(userID uint, instanceID uint, taskID ui
 	return s.instance.GetInstanceNewPassword(userID, instanceID, taskID)
 }
 
+// ResizeInstance 调整实例配置
+func (s *Service) ResizeInstance(userID, instanceID uint, req userModel.ResizeInstanceRequest) (uint, error) {
+	return s.instance.ResizeInstance(userID, instanceID, req)
+}
+
+// ReinstallInstance 重装实例系统
+func (s *Service) ReinstallInstance(userID, instanceID uint, req userModel.ReinstallInstanceRequest) (uint, error) {
+	return s.instance.ReinstallInstance(userID, instanceID, req)
+}
+
+// GetInstanceSnapshots 获取实例快照列表
+func (s *Service) GetInstanceSnapshots(userID, instanceID uint) ([]providerModel.InstanceSnapshot, error) {
+	return s.instance.GetInstanceSnapshots(userID, instanceID)
+}
+
+// CreateInstanceSnapshot 创建实例快照
+func (s *Service) CreateInstanceSnapshot(userID, instanceID uint, req userModel.CreateSnapshotRequest) (*userModel.SnapshotTaskResponse, error) {
+	return s.instance.CreateInstanceSnapshot(userID, instanceID, req)
+}
+
+// RestoreInstanceSnapshot 恢复实例快照
+func (s *Service) RestoreInstanceSnapshot(userID, instanceID, snapshotID uint) (*userModel.SnapshotTaskResponse, error) {
+	return s.instance.RestoreInstanceSnapshot(userID, instanceID, snapshotID)
+}
+
+// DeleteInstanceSnapshot 删除实例快照
+func (s *Service) DeleteInstanceSnapshot(userID, instanceID, snapshotID uint) (*userModel.SnapshotTaskResponse, error) {
+	return s.instance.DeleteInstanceSnapshot(userID, instanceID, snapshotID)
+}
+
+// GetInstanceBackups 获取实例备份列表
+func (s *Service) GetInstanceBackups(userID, instanceID uint) ([]providerModel.InstanceBackup, error) {
+	return s.instance.GetInstanceBackups(userID, instanceID)
+}
+
+// CreateInstanceBackup 创建实例备份
+func (s *Service) CreateInstanceBackup(userID, instanceID uint, req userModel.CreateBackupRequest) (*userModel.BackupTaskResponse, error) {
+	return s.instance.CreateInstanceBackup(userID, instanceID, req)
+}
+
+// RestoreInstanceBackup 恢复实例备份
+func (s *Service) RestoreInstanceBackup(userID, instanceID, backupID uint) (*userModel.BackupTaskResponse, error) {
+	return s.instance.RestoreInstanceBackup(userID, instanceID, backupID)
+}
+
+// DeleteInstanceBackup 删除实例备份
+func (s *Service) DeleteInstanceBackup(userID, instanceID, backupID uint) error {
+	return s.instance.DeleteInstanceBackup(userID, instanceID, backupID)
+}
+
+// GetInstanceBackupFile 获取备份归档下载路径
+func (s *Service) GetInstanceBackupFile(userID, instanceID, backupID uint) (string, string, error) {
+	return s.instance.GetInstanceBackupFile(userID, instanceID, backupID)
+}
+
+// GetInstanceBackupPolicy 获取实例定时备份策略
+func (s *Service) GetInstanceBackupPolicy(userID, instanceID uint) (*providerModel.InstanceBackupPolicy, error) {
+	return s.instance.GetInstanceBackupPolicy(userID, instanceID)
+}
+
+// UpdateInstanceBackupPolicy 更新实例定时备份策略
+func (s *Service) UpdateInstanceBackupPolicy(userID, instanceID uint, req userModel.UpdateBackupPolicyRequest) (*providerModel.InstanceBackupPolicy, error) {
+	return s.instance.UpdateInstanceBackupPolicy(userID, instanceID, req)
+}
+
+// GetUserVolumes 获取用户数据卷列表
+func (s *Service) GetUserVolumes(userID uint) ([]providerModel.Volume, error) {
+	return s.instance.GetUserVolumes(userID)
+}
+
+// CreateVolume 创建数据卷
+func (s *Service) CreateVolume(userID uint, req userModel.CreateVolumeRequest) (*userModel.VolumeTaskResponse, error) {
+	return s.instance.CreateVolume(userID, req)
+}
+
+// AttachVolume 挂载数据卷
+func (s *Service) AttachVolume(userID, volumeID uint, req userModel.AttachVolumeRequest) (*userModel.VolumeTaskResponse, error) {
+	return s.instance.AttachVolume(userID, volumeID, req)
+}
+
+// DetachVolume 卸载数据卷
+func (s *Service) DetachVolume(userID, volumeID uint) (*userModel.VolumeTaskResponse, error) {
+	return s.instance.DetachVolume(userID, volumeID)
+}
+
+// ResizeVolume 数据卷扩容
+func (s *Service) ResizeVolume(userID, volumeID uint, req userModel.ResizeVolumeRequest) (*userModel.VolumeTaskResponse, error) {
+	return s.instance.ResizeVolume(userID, volumeID, req)
+}
+
+// DeleteVolume 删除数据卷
+func (s *Service) DeleteVolume(userID, volumeID uint) (*userModel.VolumeTaskResponse, error) {
+	return s.instance.DeleteVolume(userID, volumeID)
+}
+
+// GetUserPrivateNetworks 获取用户私有网络列表
+func (s *Service) GetUserPrivateNetworks(userID uint) ([]providerModel.PrivateNetwork, error) {
+	return s.instance.GetUserPrivateNetworks(userID)
+}
+
+// CreatePrivateNetwork 创建私有网络
+func (s *Service) CreatePrivateNetwork(userID uint, req userModel.CreatePrivateNetworkRequest) (*userModel.PrivateNetworkTaskResponse, error) {
+	return s.instance.CreatePrivateNetwork(userID, req)
+}
+
+// DeletePrivateNetwork 删除私有网络
+func (s *Service) DeletePrivateNetwork(userID, networkID uint) (*userModel.PrivateNetworkTaskResponse, error) {
+	return s.instance.DeletePrivateNetwork(userID, networkID)
+}
+
+// AttachPrivateNetwork 实例接入私有网络
+func (s *Service) AttachPrivateNetwork(userID, networkID uint, req userModel.AttachPrivateNetworkRequest) (*userModel.PrivateNetworkTaskResponse, error) {
+	return s.instance.AttachPrivateNetwork(userID, networkID, req)
+}
+
+// DetachPrivateNetwork 实例断开私有网络
+func (s *Service) DetachPrivateNetwork(userID, networkID uint, req userModel.DetachPrivateNetworkRequest) (*userModel.PrivateNetworkTaskResponse, error) {
+	return s.instance.DetachPrivateNetwork(userID, networkID, req)
+}
+
+// GetInstanceFirewall 获取实例防火墙规则
+func (s *Service) GetInstanceFirewall(userID, instanceID uint) (*userModel.FirewallRulesResponse, error) {
+	return s.instance.GetInstanceFirewall(userID, instanceID)
+}
+
+// UpdateInstanceFirewall 更新实例防火墙规则
+func (s *Service) UpdateInstanceFirewall(userID, instanceID uint, req userModel.UpdateFirewallRulesRequest) (*userModel.FirewallTaskResponse, error) {
+	return s.instance.UpdateInstanceFirewall(userID, instanceID, req)
+}
+
 // GetInstanceLogs 获取实例日志
 func (s *Service) GetInstanceLogs(userID uint, instanceID uint, lines int) (string, error) {
 	return s.instance.GetInstanceLogs(userID, instanceID, lines)
@@ -8,6 +8,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -96,6 +97,7 @@ type SSHClient struct {
 	keepaliveWg     *sync.WaitGroup    // keepalive goroutine sync
 	mu              sync.RWMutex       // Protect concurrent access
 	closed          bool               // Mark as closed
+	executor        CommandExecutor    // 命令执行器，为空时直接通过SSH会话执行
 }
 
 func NewSSHClient(config SSHConfig) (*SSHClient, error) {
@@ -117,14 +119,45 @@ func NewSSHClient(config SSHConfig) (*SSHClient, error) {
 		return nil, err
 	}
 
-	return &SSHClient{
+	c := &SSHClient{
 		client:          client,
 		config:          config,
 		lastHealthTime:  time.Now(),
 		keepaliveCancel: keepaliveCancel,
 		keepaliveWg:     keepaliveWg,
 		closed:          false,
-	}, nil
+	}
+	c.executor = newRecorderFromEnv(c.DirectExecutor(), config.Host)
+	return c, nil
+}
+
+// NewSSHClientWithExecutor 创建不建立真实连接的SSHClient，所有命令交给executor执行
+// 用于测试中回放录制的命令输出，SFTP相关方法不可用
+func NewSSHClientWithExecutor(config SSHConfig, executor CommandExecutor) *SSHClient {
+	return &SSHClient{
+		config:         config,
+		lastHealthTime: time.Now(),
+		executor:       executor,
+	}
+}
+
+// SetExecutor 替换命令执行器，传入nil恢复为直接通过SSH会话执行
+func (c *SSHClient) SetExecutor(executor CommandExecutor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.executor = executor
+}
+
+// DirectExecutor 返回直接通过SSH会话执行命令的执行器，可作为录制器的底层执行器
+func (c *SSHClient) DirectExecutor() CommandExecutor {
+	return CommandExecutorFunc(c.executeDirect)
+}
+
+// getExecutor 获取当前命令执行器
+func (c *SSHClient) getExecutor() CommandExecutor {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.executor
 }
 
 // dialSSH 建立SSH连接的内部方�?
@@ -245,7 +278,8 @@ func dialSSH(config SSHConfig) (*ssh.Client, context.CancelFunc, *sync.WaitGroup
 // IsHealthy 检查SSH连接是否健康
 func (c *SSHClient) IsHealthy() bool {
 	if c.client == nil {
-		return false
+		// 无真实连接的回放客户端始终视为健康
+		return c.getExecutor() != nil
 	}
 
 	// 如果最�?秒内检查过，认为是健康的（避免频繁检查）
@@ -369,6 +403,14 @@ func (c *SSHClient) Reconnect() error {
 }
 
 func (c *SSHClient) Execute(command string) (string, error) {
+	if executor := c.getExecutor(); executor != nil {
+		return executor.Execute(command)
+	}
+	return c.executeDirect(command)
+}
+
+// executeDirect 直接通过SSH会话执行命令，连接不健康时自动重连
+func (c *SSHClient) executeDirect(command string) (string, error) {
 	// 检查连接健康状态，如果不健康则尝试重连
 	if !c.IsHealthy() {
 		global.APP_LOG.Warn("SSH连接不健康，尝试重连",
@@ -566,6 +608,15 @@ func TestSSHConnectionLatency(config SSHConfig, testCount int) (minLatency, maxL
 
 // ExecuteWithLogging 执行命令并记录详细的调试信息，用于排查复杂命令的执行问题
 func (c *SSHClient) ExecuteWithLogging(command string, logPrefix string) (string, error) {
+	if executor := c.getExecutor(); executor != nil {
+		if global.APP_LOG != nil {
+			global.APP_LOG.Debug("SSH command execution started",
+				zap.String("log_prefix", logPrefix),
+				zap.String("original_command", command))
+		}
+		return executor.Execute(command)
+	}
+
 	// 检查连接健康状态，如果不健康则尝试重连
 	if !c.IsHealthy() {
 		global.APP_LOG.Warn("SSH连接不健康，尝试重连",
@@ -719,6 +770,98 @@ func (c *SSHClient) UploadContent(content, remotePath string, perm os.FileMode)
 	return nil
 }
 
+// ExecuteLongRunning 在远程主机后台执行耗时命令并轮询等待结束，不受ExecuteTimeout限制
+// 命令输出和退出码写入以statusPrefix为前缀的临时文件，结束后清理；ctx取消时终止远程命令的整个进程组
+func (c *SSHClient) ExecuteLongRunning(ctx context.Context, command, statusPrefix string) (string, error) {
+	logFile := statusPrefix + ".log"
+	exitFile := statusPrefix + ".exit"
+	script := fmt.Sprintf("%s; echo $? > %s", command, exitFile)
+	// setsid使后台命令成为独立进程组的组长，输出的PID即进程组ID
+	launch := fmt.Sprintf("rm -f %s %s; setsid nohup sh -c '%s' > %s 2>&1 < /dev/null & echo $!",
+		logFile, exitFile, strings.ReplaceAll(script, "'", `'\''`), logFile)
+	output, err := c.Execute(launch)
+	if err != nil {
+		return output, fmt.Errorf("failed to start background command: %w", err)
+	}
+	pid := strings.TrimSpace(output)
+	defer c.Execute(fmt.Sprintf("rm -f %s %s", logFile, exitFile))
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if _, err := strconv.Atoi(pid); err == nil {
+				c.Execute(fmt.Sprintf("kill -TERM -- -%[1]s 2>/dev/null || kill -TERM %[1]s 2>/dev/null; true", pid))
+			}
+			return "", ctx.Err()
+		case <-ticker.C:
+		}
+
+		exitCode, err := c.Execute(fmt.Sprintf("cat %s 2>/dev/null", exitFile))
+		if err != nil || strings.TrimSpace(exitCode) == "" {
+			continue
+		}
+		output, _ := c.Execute(fmt.Sprintf("tail -c 4096 %s 2>/dev/null", logFile))
+		if code := strings.TrimSpace(exitCode); code != "0" {
+			return output, fmt.Errorf("command exited with code %s", code)
+		}
+		return output, nil
+	}
+}
+
+// DownloadToWriter 通过SFTP读取远程文件并写入w，返回写入的字节数
+func (c *SSHClient) DownloadToWriter(remotePath string, w io.Writer) (int64, error) {
+	sftpClient, err := sftp.NewClient(c.client)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create SFTP client: %w", err)
+	}
+	defer sftpClient.Close()
+
+	remoteFile, err := sftpClient.Open(remotePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open remote file %s: %w", remotePath, err)
+	}
+	defer remoteFile.Close()
+
+	written, err := io.Copy(w, remoteFile)
+	if err != nil {
+		return written, fmt.Errorf("failed to read remote file %s: %w", remotePath, err)
+	}
+	return written, nil
+}
+
+// UploadFromReader 通过SFTP将r中的内容写入远程文件，返回写入的字节数
+func (c *SSHClient) UploadFromReader(r io.Reader, remotePath string, perm os.FileMode) (int64, error) {
+	sftpClient, err := sftp.NewClient(c.client)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create SFTP client: %w", err)
+	}
+	defer sftpClient.Close()
+
+	if lastSlash := strings.LastIndex(remotePath, "/"); lastSlash > 0 {
+		if err := sftpClient.MkdirAll(remotePath[:lastSlash]); err != nil {
+			return 0, fmt.Errorf("failed to create remote directory %s: %w", remotePath[:lastSlash], err)
+		}
+	}
+
+	remoteFile, err := sftpClient.Create(remotePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create remote file %s: %w", remotePath, err)
+	}
+	defer remoteFile.Close()
+
+	written, err := io.Copy(remoteFile, r)
+	if err != nil {
+		return written, fmt.Errorf("failed to write remote file %s: %w", remotePath, err)
+	}
+
+	if err := sftpClient.Chmod(remotePath, perm); err != nil {
+		return written, fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	return written, nil
+}
+
 // ResolveHostToIP 解析主机名到IP地址
 // 如果host已经是IP地址，直接返回；如果是域名，解析为IP地址
 func ResolveHostToIP(host string) ([]string, error) {
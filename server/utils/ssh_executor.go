@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SSHRecordDirEnv 设置该环境变量后，新建的SSHClient会把执行的命令和输出录制到该目录下的fixture文件
+// 录制内容包含完整命令和输出（可能含密码），仅用于开发环境采集测试数据
+const SSHRecordDirEnv = "ONECLICKVIRT_SSH_RECORD_DIR"
+
+// CommandExecutor SSH命令执行器，SSHClient的Execute系列方法都经由它执行，测试中可替换为回放实现
+type CommandExecutor interface {
+	Execute(command string) (string, error)
+}
+
+// CommandExecutorFunc 将普通函数适配为CommandExecutor
+type CommandExecutorFunc func(command string) (string, error)
+
+// Execute 执行命令
+func (f CommandExecutorFunc) Execute(command string) (string, error) {
+	return f(command)
+}
+
+// CommandInteraction 一次命令执行的记录
+type CommandInteraction struct {
+	Command string `json:"command"`
+	Output  string `json:"output"`
+	Error   string `json:"error,omitempty"`
+}
+
+// CommandFixture 命令录制文件
+type CommandFixture struct {
+	Host         string               `json:"host,omitempty"`
+	RecordedAt   time.Time            `json:"recordedAt"`
+	Interactions []CommandInteraction `json:"interactions"`
+}
+
+// LoadCommandFixture 读取命令录制文件
+func LoadCommandFixture(path string) (*CommandFixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+	}
+	var fixture CommandFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	return &fixture, nil
+}
+
+// Save 写入命令录制文件，先写临时文件再改名，避免中断时留下不完整的文件
+func (f *CommandFixture) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// CommandRecorder 包装真实执行器，记录每条命令的输出和错误，每次执行后立即落盘
+type CommandRecorder struct {
+	next    CommandExecutor
+	path    string
+	fixture CommandFixture
+	mu      sync.Mutex
+}
+
+// NewCommandRecorder 创建命令录制器，path为空时只在内存中记录
+func NewCommandRecorder(next CommandExecutor, path, host string) *CommandRecorder {
+	return &CommandRecorder{
+		next: next,
+		path: path,
+		fixture: CommandFixture{
+			Host:       host,
+			RecordedAt: time.Now(),
+		},
+	}
+}
+
+// Execute 执行并记录命令
+func (r *CommandRecorder) Execute(command string) (string, error) {
+	output, err := r.next.Execute(command)
+
+	interaction := CommandInteraction{Command: command, Output: output}
+	if err != nil {
+		interaction.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Interactions = append(r.fixture.Interactions, interaction)
+	if r.path != "" {
+		// 录制失败不影响命令执行结果
+		_ = r.fixture.Save(r.path)
+	}
+	return output, err
+}
+
+// Fixture 返回当前已录制内容的副本
+func (r *CommandRecorder) Fixture() CommandFixture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fixture := r.fixture
+	fixture.Interactions = append([]CommandInteraction(nil), r.fixture.Interactions...)
+	return fixture
+}
+
+// CommandReplayer 按录制文件回放命令输出，不访问任何节点
+// 每条录制记录只能使用一次，相同命令按录制顺序依次返回，便于回放状态变化（如启动前后的status）
+type CommandReplayer struct {
+	interactions []CommandInteraction
+	used         []bool
+	executed     []string
+	mu           sync.Mutex
+}
+
+// NewCommandReplayer 根据录制内容创建回放器
+func NewCommandReplayer(fixture *CommandFixture) *CommandReplayer {
+	interactions := append([]CommandInteraction(nil), fixture.Interactions...)
+	return &CommandReplayer{
+		interactions: interactions,
+		used:         make([]bool, len(interactions)),
+	}
+}
+
+// LoadCommandReplayer 读取录制文件并创建回放器
+func LoadCommandReplayer(path string) (*CommandReplayer, error) {
+	fixture, err := LoadCommandFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewCommandReplayer(fixture), nil
+}
+
+// Execute 返回第一条未使用且命令完全相同的录制结果，找不到时返回错误
+func (r *CommandReplayer) Execute(command string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.executed = append(r.executed, command)
+
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Command != command {
+			continue
+		}
+		r.used[i] = true
+		if interaction.Error != "" {
+			return interaction.Output, errors.New(interaction.Error)
+		}
+		return interaction.Output, nil
+	}
+	return "", fmt.Errorf("no recorded interaction for command: %s", TruncateString(command, 200))
+}
+
+// Executed 返回回放期间收到的全部命令，用于断言命令构造
+func (r *CommandReplayer) Executed() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.executed...)
+}
+
+// Unused 返回尚未被执行到的录制命令，用于断言流程是否完整
+func (r *CommandReplayer) Unused() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []string
+	for i, interaction := range r.interactions {
+		if !r.used[i] {
+			unused = append(unused, interaction.Command)
+		}
+	}
+	return unused
+}
+
+// newRecorderFromEnv 如果设置了录制目录，为SSHClient创建录制器
+func newRecorderFromEnv(next CommandExecutor, host string) CommandExecutor {
+	dir := os.Getenv(SSHRecordDirEnv)
+	if dir == "" {
+		return nil
+	}
+	safeHost := strings.NewReplacer(":", "_", "/", "_", "\\", "_").Replace(host)
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.json", safeHost, time.Now().Format("20060102-150405.000000")))
+	return NewCommandRecorder(next, path, host)
+}
@@ -85,15 +85,33 @@ func MarkTaskFailed(taskID uint, errorMessage string) {
 // GetDefaultTaskTimeout 获取默认任务超时时间（秒）
 func GetDefaultTaskTimeout(taskType string) int {
 	timeouts := map[string]int{
-		"create":              1800, // 30分钟
-		"start":               300,  // 5分钟
-		"stop":                300,  // 5分钟
-		"restart":             600,  // 10分钟
-		"reset":               1200, // 20分钟
-		"delete":              600,  // 10分钟
-		"create-port-mapping": 600,  // 10分钟
-		"delete-port-mapping": 300,  // 5分钟
-		"reset-password":      600,  // 10分钟
+		"create":                 1800, // 30分钟
+		"start":                  300,  // 5分钟
+		"stop":                   300,  // 5分钟
+		"restart":                600,  // 10分钟
+		"reset":                  1200, // 20分钟
+		"reinstall":              1200, // 20分钟
+		"delete":                 600,  // 10分钟
+		"create-port-mapping":    600,  // 10分钟
+		"delete-port-mapping":    300,  // 5分钟
+		"reset-password":         600,  // 10分钟
+		"resize":                 900,  // 15分钟
+		"migrate":                7200, // 2小时
+		"create-snapshot":        1200, // 20分钟
+		"restore-snapshot":       1200, // 20分钟
+		"delete-snapshot":        600,  // 10分钟
+		"create-backup":          3600, // 1小时
+		"restore-backup":         3600, // 1小时
+		"create-volume":          900,  // 15分钟
+		"attach-volume":          900,  // 15分钟
+		"detach-volume":          900,  // 15分钟
+		"resize-volume":          900,  // 15分钟
+		"delete-volume":          600,  // 10分钟
+		"create-private-network": 600,  // 10分钟
+		"delete-private-network": 600,  // 10分钟
+		"attach-private-network": 600,  // 10分钟
+		"detach-private-network": 600,  // 10分钟
+		"apply-firewall":         300,  // 5分钟
 	}
 
 	if timeout, exists := timeouts[taskType]; exists {
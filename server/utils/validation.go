@@ -36,6 +36,20 @@ func IsValidLXDInstanceName(name string) bool {
 	return true
 }
 
+// IsValidSnapshotName 检查快照名称是否有效
+// 快照名称需要同时满足LXD/Incus、Proxmox和Docker标签的命名规则：
+// - 长度不超过40个字符（Proxmox限制）
+// - 必须以字母开头
+// - 只能包含字母、数字、连字符和下划线
+func IsValidSnapshotName(name string) bool {
+	if name == "" || len(name) > 40 {
+		return false
+	}
+
+	matched, err := regexp.MatchString(`^[a-zA-Z][a-zA-Z0-9\-_]*$`, name)
+	return err == nil && matched
+}
+
 // IsNumeric 检查字符串是否为纯数字
 func IsNumeric(s string) bool {
 	_, err := strconv.Atoi(s)